@@ -5,11 +5,60 @@ type Authorizer interface {
 	Authorize(AuthorizeUser, string) (Decision, error)
 }
 
+// ContextAuthorizer - authorizes users with knowledge of what is being done,
+// allowing per bundle and per plan entitlement policies.
+type ContextAuthorizer interface {
+	Authorizer
+	AuthorizeContext(AuthorizeUser, Context) (Decision, error)
+}
+
+// Context - describes what the user is attempting to do.
+type Context struct {
+	// Action - the bundle action being performed, e.g. provision or bind.
+	Action Action
+	// BundleFQName - the fully qualified name of the bundle.
+	BundleFQName string
+	// Plan - the name of the plan of the bundle.
+	Plan string
+	// Namespace - the target namespace of the action.
+	Namespace string
+	// NamespaceLabels and NamespaceAnnotations - the labels and annotations
+	// of the target namespace selected by the executor configuration, e.g.
+	// the environment tier or the data classification of the namespace.
+	NamespaceLabels      map[string]string
+	NamespaceAnnotations map[string]string
+}
+
+// Action - The bundle action that is being authorized.
+type Action string
+
+const (
+	// ActionProvision - Provision action.
+	ActionProvision Action = "provision"
+	// ActionDeprovision - Deprovision action.
+	ActionDeprovision Action = "deprovision"
+	// ActionBind - Bind action.
+	ActionBind Action = "bind"
+	// ActionUnbind - Unbind action.
+	ActionUnbind Action = "unbind"
+	// ActionUpdate - Update action.
+	ActionUpdate Action = "update"
+)
+
 // AuthorizeUser - an interface for a user object.
 type AuthorizeUser interface {
 	Username() string
 }
 
+// ExtraUser - a user that carries additional information, such as the
+// scopes of the token the user authenticated with, that the authorizer should
+// take into account.
+type ExtraUser interface {
+	AuthorizeUser
+	Extra() map[string][]string
+	Scopes() []string
+}
+
 // Decision - The outcome of the authorization check
 type Decision string
 
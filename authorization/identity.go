@@ -0,0 +1,74 @@
+package authorization
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// OriginatingIdentityHeader - The OSB header that identifies the user
+	// that initiated the request.
+	OriginatingIdentityHeader = "X-Broker-API-Originating-Identity"
+	// PlatformKubernetes - The platform value used by kubernetes based
+	// platforms in the originating identity header.
+	PlatformKubernetes = "kubernetes"
+	// ScopesExtraKey - the key in the user extra values that holds the scopes
+	// of an OpenShift OAuth token.
+	ScopesExtraKey = "scopes.authorization.openshift.io"
+)
+
+// User - A user parsed from the originating identity of a request.
+type User struct {
+	Name        string              `json:"username"`
+	UID         string              `json:"uid"`
+	GroupNames  []string            `json:"groups"`
+	ExtraValues map[string][]string `json:"extra"`
+}
+
+// Username - return the username.
+func (u User) Username() string {
+	return u.Name
+}
+
+// Groups - return the groups of the user.
+func (u User) Groups() []string {
+	return u.GroupNames
+}
+
+// Extra - return the extra values of the user.
+func (u User) Extra() map[string][]string {
+	return u.ExtraValues
+}
+
+// Scopes - return the scopes of the token the user authenticated with.
+func (u User) Scopes() []string {
+	return u.ExtraValues[ScopesExtraKey]
+}
+
+// ParseOriginatingIdentity - parses the value of the OSB originating identity
+// header. The header value is the platform followed by a space and the base64
+// encoded json user information. Only the kubernetes platform is supported.
+func ParseOriginatingIdentity(header string) (*User, error) {
+	parts := strings.SplitN(strings.TrimSpace(header), " ", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid originating identity header")
+	}
+	platform, value := parts[0], strings.TrimSpace(parts[1])
+	if strings.ToLower(platform) != PlatformKubernetes {
+		return nil, fmt.Errorf("unsupported originating identity platform: %v", platform)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode originating identity - %v", err)
+	}
+	u := &User{}
+	if err := json.Unmarshal(decoded, u); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal originating identity - %v", err)
+	}
+	if u.Name == "" {
+		return nil, fmt.Errorf("originating identity does not contain a username")
+	}
+	return u, nil
+}
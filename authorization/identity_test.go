@@ -0,0 +1,96 @@
+package authorization
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+func TestParseOriginatingIdentity(t *testing.T) {
+	encode := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	testCases := []struct {
+		name        string
+		header      string
+		expected    *User
+		shouldError bool
+	}{
+		{
+			name:   "kubernetes user",
+			header: "kubernetes " + encode(`{"username":"foo","uid":"123","groups":["admin","dev"],"extra":{"scopes.authorization.openshift.io":["user:info"]}}`),
+			expected: &User{
+				Name:        "foo",
+				UID:         "123",
+				GroupNames:  []string{"admin", "dev"},
+				ExtraValues: map[string][]string{ScopesExtraKey: {"user:info"}},
+			},
+		},
+		{
+			name:     "only username",
+			header:   "kubernetes " + encode(`{"username":"foo"}`),
+			expected: &User{Name: "foo"},
+		},
+		{
+			name:        "missing value",
+			header:      "kubernetes",
+			shouldError: true,
+		},
+		{
+			name:        "unsupported platform",
+			header:      "cloudfoundry " + encode(`{"user_id":"foo"}`),
+			shouldError: true,
+		},
+		{
+			name:        "invalid base64",
+			header:      "kubernetes not-base64!",
+			shouldError: true,
+		},
+		{
+			name:        "invalid json",
+			header:      "kubernetes " + encode(`{"username":`),
+			shouldError: true,
+		},
+		{
+			name:        "missing username",
+			header:      "kubernetes " + encode(`{"uid":"123"}`),
+			shouldError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := ParseOriginatingIdentity(tc.header)
+			if err != nil {
+				if tc.shouldError {
+					return
+				}
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			if tc.shouldError {
+				t.Fatalf("expected an error for header: %v", tc.header)
+			}
+			if !reflect.DeepEqual(u, tc.expected) {
+				t.Fatalf("invalid user\nexpected: %#+v\nactual: %#+v", tc.expected, u)
+			}
+		})
+	}
+}
+
+func TestUser(t *testing.T) {
+	u := User{
+		Name:        "foo",
+		GroupNames:  []string{"admin"},
+		ExtraValues: map[string][]string{ScopesExtraKey: {"user:info"}},
+	}
+	var _ ExtraUser = u
+	if u.Username() != "foo" {
+		t.Fatalf("expected username foo got: %v", u.Username())
+	}
+	if !reflect.DeepEqual(u.Groups(), []string{"admin"}) {
+		t.Fatalf("expected groups [admin] got: %v", u.Groups())
+	}
+	if !reflect.DeepEqual(u.Scopes(), []string{"user:info"}) {
+		t.Fatalf("expected scopes [user:info] got: %v", u.Scopes())
+	}
+}
@@ -27,35 +27,93 @@ func NewAuthorizer(group, resource, verb string) (authorization.Authorizer, erro
 
 }
 
+// NewContextAuthorizer - Create a new authorizer client that is able to
+// authorize bundle actions. The verb is used when no action is provided.
+func NewContextAuthorizer(group, resource, verb string) (authorization.ContextAuthorizer, error) {
+	a, err := NewAuthorizer(group, resource, verb)
+	if err != nil {
+		return nil, err
+	}
+	return a.(k8sAuthorization), nil
+}
+
 // AuthorizationUser - A user to be used by the k8s authorizer.
 type AuthorizationUser struct {
 	authv1.UserInfo
 }
 
+// ScopesExtraKey - the key in the user extra values that holds the scopes of
+// an OpenShift OAuth token.
+const ScopesExtraKey = authorization.ScopesExtraKey
+
 // Username - return the username.
 func (u AuthorizationUser) Username() string {
 	return u.UserInfo.Username
 }
 
+// Groups - return the groups of the user.
+func (u AuthorizationUser) Groups() []string {
+	return u.UserInfo.Groups
+}
+
+// Extra - return the extra values of the user.
+func (u AuthorizationUser) Extra() map[string][]string {
+	if len(u.UserInfo.Extra) == 0 {
+		return nil
+	}
+	extra := make(map[string][]string, len(u.UserInfo.Extra))
+	for k, v := range u.UserInfo.Extra {
+		extra[k] = []string(v)
+	}
+	return extra
+}
+
+// Scopes - return the scopes of the token the user authenticated with.
+func (u AuthorizationUser) Scopes() []string {
+	return []string(u.UserInfo.Extra[ScopesExtraKey])
+}
+
 type k8sAuthorization struct {
 	resource authorizationv1.ResourceAttributes
 	client   v1.SubjectAccessReviewInterface
 }
 
 func (a k8sAuthorization) Authorize(user authorization.AuthorizeUser, location string) (authorization.Decision, error) {
-	u, ok := user.(*AuthorizationUser)
+	r := a.resource
+	r.Namespace = location
+	return a.review(user, r)
+}
+
+// AuthorizeContext - authorizes the user for the bundle action. The action is
+// used as the verb, the bundle name as the resource name and the plan as the
+// subresource so that RBAC rules can target specific bundles and plans.
+func (a k8sAuthorization) AuthorizeContext(user authorization.AuthorizeUser, ctx authorization.Context) (authorization.Decision, error) {
+	return a.review(user, a.contextAttributes(ctx))
+}
+
+func (a k8sAuthorization) contextAttributes(ctx authorization.Context) authorizationv1.ResourceAttributes {
+	r := a.resource
+	r.Namespace = ctx.Namespace
+	r.Name = ctx.BundleFQName
+	r.Subresource = ctx.Plan
+	if ctx.Action != "" {
+		r.Verb = string(ctx.Action)
+	}
+	return r
+}
+
+func (a k8sAuthorization) review(user authorization.AuthorizeUser, r authorizationv1.ResourceAttributes) (authorization.Decision, error) {
+	u, ok := userInfo(user)
 	if !ok {
 		return authorization.DecisionDeny, fmt.Errorf("unknown user structure")
 	}
-	r := &a.resource
-	r.Namespace = location
 	sar := &authorizationv1.SubjectAccessReview{
 		Spec: authorizationv1.SubjectAccessReviewSpec{
-			User: u.UserInfo.Username,
-			UID:  u.UserInfo.UID,
-			//Extra:  userInfo.Extra,
-			Groups:             u.UserInfo.Groups,
-			ResourceAttributes: r,
+			User:               u.Username,
+			UID:                u.UID,
+			Extra:              convertExtra(u.Extra),
+			Groups:             u.Groups,
+			ResourceAttributes: &r,
 		},
 	}
 	sar, err := a.client.Create(sar)
@@ -73,3 +131,42 @@ func (a k8sAuthorization) Authorize(user authorization.AuthorizeUser, location s
 		return authorization.DecisionNoOpinion, nil
 	}
 }
+
+// userInfo - retrieves the user information of the users known to the
+// authorizer.
+func userInfo(user authorization.AuthorizeUser) (authv1.UserInfo, bool) {
+	switch u := user.(type) {
+	case *AuthorizationUser:
+		return u.UserInfo, true
+	case *authorization.User:
+		info := authv1.UserInfo{
+			Username: u.Name,
+			UID:      u.UID,
+			Groups:   u.GroupNames,
+		}
+		if len(u.ExtraValues) > 0 {
+			info.Extra = make(map[string]authv1.ExtraValue, len(u.ExtraValues))
+			for k, v := range u.ExtraValues {
+				info.Extra[k] = authv1.ExtraValue(v)
+			}
+		}
+		return info, true
+	default:
+		return authv1.UserInfo{}, false
+	}
+}
+
+// convertExtra - converts the authentication extra values of the user to the
+// authorization extra values of the SubjectAccessReview. The scopes of the
+// token are part of the extra values, so passing them along makes the
+// decision respect token scoping.
+func convertExtra(extra map[string]authv1.ExtraValue) map[string]authorizationv1.ExtraValue {
+	if len(extra) == 0 {
+		return nil
+	}
+	e := make(map[string]authorizationv1.ExtraValue, len(extra))
+	for k, v := range extra {
+		e[k] = authorizationv1.ExtraValue(v)
+	}
+	return e
+}
@@ -125,7 +125,7 @@ func TestSARUserInfoAuthorizer(t *testing.T) {
 					Spec: authorizationv1.SubjectAccessReviewSpec{
 						User:   "foo",
 						Groups: []string{},
-						Extra:  nil,
+						Extra:  map[string]authorizationv1.ExtraValue{"scope": []string{"hello"}},
 						ResourceAttributes: &authorizationv1.ResourceAttributes{
 							Group:     "group",
 							Resource:  "resource",
@@ -264,3 +264,142 @@ func TestSARUserInfoAuthorizer(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorizationUserExtra(t *testing.T) {
+	u := AuthorizationUser{
+		UserInfo: v1.UserInfo{
+			Username: "foo",
+			Extra: map[string]v1.ExtraValue{
+				ScopesExtraKey: []string{"user:info", "role:admin:myproject"},
+				"other":        []string{"value"},
+			},
+		},
+	}
+	var _ authorization.ExtraUser = u
+	expectedScopes := []string{"user:info", "role:admin:myproject"}
+	if !reflect.DeepEqual(u.Scopes(), expectedScopes) {
+		t.Fatalf("invalid scopes\nexpected: %v\nactual: %v", expectedScopes, u.Scopes())
+	}
+	expectedExtra := map[string][]string{
+		ScopesExtraKey: {"user:info", "role:admin:myproject"},
+		"other":        {"value"},
+	}
+	if !reflect.DeepEqual(u.Extra(), expectedExtra) {
+		t.Fatalf("invalid extra\nexpected: %v\nactual: %v", expectedExtra, u.Extra())
+	}
+	if (AuthorizationUser{}).Extra() != nil {
+		t.Fatal("expected nil extra for user without extra values")
+	}
+}
+
+func TestAuthorizeContext(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ctx      authorization.Context
+		expected authorizationv1.ResourceAttributes
+	}{
+		{
+			name: "bundle action",
+			ctx: authorization.Context{
+				Action:       authorization.ActionProvision,
+				BundleFQName: "dh-postgresql-apb",
+				Plan:         "dev",
+				Namespace:    "location",
+			},
+			expected: authorizationv1.ResourceAttributes{
+				Group:       "group",
+				Resource:    "resource",
+				Verb:        "provision",
+				Name:        "dh-postgresql-apb",
+				Subresource: "dev",
+				Namespace:   "location",
+			},
+		},
+		{
+			name: "no action uses configured verb",
+			ctx: authorization.Context{
+				BundleFQName: "dh-postgresql-apb",
+				Namespace:    "location",
+			},
+			expected: authorizationv1.ResourceAttributes{
+				Group:     "group",
+				Resource:  "resource",
+				Verb:      "verb",
+				Name:      "dh-postgresql-apb",
+				Namespace: "location",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			auth := k8sAuthorization{
+				resource: authorizationv1.ResourceAttributes{
+					Group:    "group",
+					Resource: "resource",
+					Verb:     "verb",
+				},
+				client: fakeSubjectAccessReview{
+					SubjectAccessReview: &authorizationv1.SubjectAccessReview{
+						Spec: authorizationv1.SubjectAccessReviewSpec{
+							User:               "foo",
+							ResourceAttributes: &tc.expected,
+						},
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: true,
+						},
+					},
+				},
+			}
+			dec, err := auth.AuthorizeContext(&AuthorizationUser{UserInfo: v1.UserInfo{Username: "foo"}}, tc.ctx)
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			if dec != authorization.DecisionAllowed {
+				t.Fatalf("expected: %v decision got: %v", authorization.DecisionAllowed, dec)
+			}
+		})
+	}
+}
+
+func TestAuthorizeOriginatingIdentityUser(t *testing.T) {
+	auth := k8sAuthorization{
+		resource: authorizationv1.ResourceAttributes{
+			Group:    "group",
+			Resource: "resource",
+			Verb:     "verb",
+		},
+		client: fakeSubjectAccessReview{
+			SubjectAccessReview: &authorizationv1.SubjectAccessReview{
+				Spec: authorizationv1.SubjectAccessReviewSpec{
+					User:   "foo",
+					UID:    "123",
+					Groups: []string{"admin"},
+					Extra:  map[string]authorizationv1.ExtraValue{ScopesExtraKey: []string{"user:info"}},
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Group:     "group",
+						Resource:  "resource",
+						Verb:      "verb",
+						Namespace: "location",
+					},
+				},
+				Status: authorizationv1.SubjectAccessReviewStatus{
+					Allowed: true,
+				},
+			},
+		},
+	}
+	u := &authorization.User{
+		Name:        "foo",
+		UID:         "123",
+		GroupNames:  []string{"admin"},
+		ExtraValues: map[string][]string{ScopesExtraKey: {"user:info"}},
+	}
+	dec, err := auth.Authorize(u, "location")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if dec != authorization.DecisionAllowed {
+		t.Fatalf("expected: %v decision got: %v", authorization.DecisionAllowed, dec)
+	}
+}
@@ -0,0 +1,221 @@
+package k8s
+
+import (
+	"container/list"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/authorization"
+	"github.com/automationbroker/bundle-lib/metrics"
+	authv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+)
+
+const (
+	// DefaultCacheSize - Default number of decisions kept by the cache.
+	DefaultCacheSize = 1024
+	// DefaultCacheTTL - Default amount of time a decision is considered valid.
+	DefaultCacheTTL = 30 * time.Second
+)
+
+// CacheConfig - Configuration for the decision cache.
+type CacheConfig struct {
+	// Size - maximum number of decisions to keep. The least recently used
+	// decision is evicted when the cache is full.
+	Size int
+	// TTL - how long a decision is kept before a new SubjectAccessReview is
+	// issued for the same request.
+	TTL time.Duration
+}
+
+// CachedAuthorizer - An authorizer that caches the decisions of the
+// SubjectAccessReview authorizer.
+type CachedAuthorizer struct {
+	authorizer k8sAuthorization
+	cache      *decisionCache
+}
+
+// NewCachedAuthorizer - Create a new authorizer client that caches decisions.
+func NewCachedAuthorizer(group, resource, verb string, config CacheConfig) (*CachedAuthorizer, error) {
+	a, err := NewAuthorizer(group, resource, verb)
+	if err != nil {
+		return nil, err
+	}
+	return &CachedAuthorizer{
+		authorizer: a.(k8sAuthorization),
+		cache:      newDecisionCache(config),
+	}, nil
+}
+
+// Authorize - returns the cached decision for the user and location if
+// present, otherwise it will create a SubjectAccessReview and cache the
+// outcome. Errors are never cached.
+func (c *CachedAuthorizer) Authorize(user authorization.AuthorizeUser, location string) (authorization.Decision, error) {
+	r := c.authorizer.resource
+	r.Namespace = location
+	return c.review(user, r)
+}
+
+// AuthorizeContext - returns the cached decision for the user and bundle
+// action if present, otherwise it will create a SubjectAccessReview and
+// cache the outcome. Errors are never cached.
+func (c *CachedAuthorizer) AuthorizeContext(user authorization.AuthorizeUser, ctx authorization.Context) (authorization.Decision, error) {
+	return c.review(user, c.authorizer.contextAttributes(ctx))
+}
+
+func (c *CachedAuthorizer) review(user authorization.AuthorizeUser, r authorizationv1.ResourceAttributes) (authorization.Decision, error) {
+	u, ok := userInfo(user)
+	if !ok {
+		return c.authorizer.review(user, r)
+	}
+	key := cacheKey(u, r)
+	if d, ok := c.cache.get(key); ok {
+		metrics.AuthorizationCacheHit()
+		return d, nil
+	}
+	metrics.AuthorizationCacheMiss()
+	d, err := c.authorizer.review(user, r)
+	if err != nil {
+		return d, err
+	}
+	c.cache.add(key, u.Username, d)
+	return d, nil
+}
+
+// InvalidateUser - removes all the cached decisions for a user.
+func (c *CachedAuthorizer) InvalidateUser(username string) {
+	c.cache.removeUser(username)
+}
+
+// InvalidateAll - removes all the cached decisions.
+func (c *CachedAuthorizer) InvalidateAll() {
+	c.cache.purge()
+}
+
+func cacheKey(u authv1.UserInfo, r authorizationv1.ResourceAttributes) string {
+	groups := make([]string, len(u.Groups))
+	copy(groups, u.Groups)
+	sort.Strings(groups)
+	// The extra values carry the token scopes, so they are part of the key.
+	extra := make([]string, 0, len(u.Extra))
+	for k, v := range u.Extra {
+		extra = append(extra, k+"="+strings.Join(v, ","))
+	}
+	sort.Strings(extra)
+	return strings.Join([]string{
+		u.Username,
+		u.UID,
+		strings.Join(groups, ","),
+		strings.Join(extra, ";"),
+		r.Namespace,
+		r.Group,
+		r.Version,
+		r.Resource,
+		r.Subresource,
+		r.Name,
+		r.Verb,
+	}, "|")
+}
+
+type cacheEntry struct {
+	key      string
+	username string
+	decision authorization.Decision
+	expires  time.Time
+}
+
+// decisionCache - LRU cache with a TTL on every entry.
+type decisionCache struct {
+	mutex   sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+}
+
+func newDecisionCache(config CacheConfig) *decisionCache {
+	if config.Size <= 0 {
+		config.Size = DefaultCacheSize
+	}
+	if config.TTL <= 0 {
+		config.TTL = DefaultCacheTTL
+	}
+	return &decisionCache{
+		size:    config.Size,
+		ttl:     config.TTL,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (c *decisionCache) get(key string) (authorization.Decision, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := e.Value.(*cacheEntry)
+	if c.now().After(entry.expires) {
+		c.removeElement(e)
+		return "", false
+	}
+	c.order.MoveToFront(e)
+	return entry.decision, true
+}
+
+func (c *decisionCache) add(key, username string, d authorization.Decision) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	expires := c.now().Add(c.ttl)
+	if e, ok := c.entries[key]; ok {
+		entry := e.Value.(*cacheEntry)
+		entry.decision = d
+		entry.expires = expires
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{
+		key:      key,
+		username: username,
+		decision: d,
+		expires:  expires,
+	})
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *decisionCache) removeUser(username string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for e := c.order.Front(); e != nil; {
+		next := e.Next()
+		if e.Value.(*cacheEntry).username == username {
+			c.removeElement(e)
+		}
+		e = next
+	}
+}
+
+func (c *decisionCache) purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+}
+
+func (c *decisionCache) len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
+
+func (c *decisionCache) removeElement(e *list.Element) {
+	c.order.Remove(e)
+	delete(c.entries, e.Value.(*cacheEntry).key)
+}
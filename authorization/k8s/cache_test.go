@@ -0,0 +1,173 @@
+package k8s
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/authorization"
+	"k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+)
+
+type countingSubjectAccessReview struct {
+	calls   int
+	allowed bool
+	err     error
+}
+
+func (c *countingSubjectAccessReview) Create(sar *authorizationv1.SubjectAccessReview) (*authorizationv1.SubjectAccessReview, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	sar.Status.Allowed = c.allowed
+	return sar, nil
+}
+
+func newTestCachedAuthorizer(client *countingSubjectAccessReview, config CacheConfig) *CachedAuthorizer {
+	return &CachedAuthorizer{
+		authorizer: k8sAuthorization{
+			resource: authorizationv1.ResourceAttributes{
+				Group:    "group",
+				Resource: "resource",
+				Verb:     "verb",
+			},
+			client: client,
+		},
+		cache: newDecisionCache(config),
+	}
+}
+
+func TestCachedAuthorizer(t *testing.T) {
+	foo := &AuthorizationUser{UserInfo: v1.UserInfo{Username: "foo", Groups: []string{"b", "a"}}}
+	fooReordered := &AuthorizationUser{UserInfo: v1.UserInfo{Username: "foo", Groups: []string{"a", "b"}}}
+	bar := &AuthorizationUser{UserInfo: v1.UserInfo{Username: "bar"}}
+
+	testCases := []struct {
+		name          string
+		config        CacheConfig
+		err           error
+		run           func(*CachedAuthorizer, *time.Time)
+		expectedCalls int
+		expectedLen   int
+	}{
+		{
+			name: "repeated requests are cached",
+			run: func(c *CachedAuthorizer, now *time.Time) {
+				c.Authorize(foo, "location")
+				c.Authorize(foo, "location")
+				c.Authorize(fooReordered, "location")
+			},
+			expectedCalls: 1,
+			expectedLen:   1,
+		},
+		{
+			name: "different namespaces are not shared",
+			run: func(c *CachedAuthorizer, now *time.Time) {
+				c.Authorize(foo, "location")
+				c.Authorize(foo, "other")
+			},
+			expectedCalls: 2,
+			expectedLen:   2,
+		},
+		{
+			name: "different scopes are not shared",
+			run: func(c *CachedAuthorizer, now *time.Time) {
+				scoped := &AuthorizationUser{UserInfo: v1.UserInfo{
+					Username: "foo",
+					Groups:   []string{"a", "b"},
+					Extra:    map[string]v1.ExtraValue{ScopesExtraKey: []string{"user:info"}},
+				}}
+				c.Authorize(foo, "location")
+				c.Authorize(scoped, "location")
+			},
+			expectedCalls: 2,
+			expectedLen:   2,
+		},
+		{
+			name:   "expired entries are reviewed again",
+			config: CacheConfig{TTL: time.Minute},
+			run: func(c *CachedAuthorizer, now *time.Time) {
+				c.Authorize(foo, "location")
+				*now = now.Add(2 * time.Minute)
+				c.Authorize(foo, "location")
+			},
+			expectedCalls: 2,
+			expectedLen:   1,
+		},
+		{
+			name:   "least recently used entry is evicted",
+			config: CacheConfig{Size: 1},
+			run: func(c *CachedAuthorizer, now *time.Time) {
+				c.Authorize(foo, "location")
+				c.Authorize(bar, "location")
+				c.Authorize(foo, "location")
+			},
+			expectedCalls: 3,
+			expectedLen:   1,
+		},
+		{
+			name: "invalidate user",
+			run: func(c *CachedAuthorizer, now *time.Time) {
+				c.Authorize(foo, "location")
+				c.Authorize(bar, "location")
+				c.InvalidateUser("foo")
+				c.Authorize(bar, "location")
+			},
+			expectedCalls: 2,
+			expectedLen:   1,
+		},
+		{
+			name: "invalidate all",
+			run: func(c *CachedAuthorizer, now *time.Time) {
+				c.Authorize(foo, "location")
+				c.Authorize(bar, "location")
+				c.InvalidateAll()
+			},
+			expectedCalls: 2,
+			expectedLen:   0,
+		},
+		{
+			name: "errors are not cached",
+			err:  fmt.Errorf("review failed"),
+			run: func(c *CachedAuthorizer, now *time.Time) {
+				c.Authorize(foo, "location")
+				c.Authorize(foo, "location")
+			},
+			expectedCalls: 2,
+			expectedLen:   0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &countingSubjectAccessReview{allowed: true, err: tc.err}
+			c := newTestCachedAuthorizer(client, tc.config)
+			now := time.Now()
+			c.cache.now = func() time.Time { return now }
+			tc.run(c, &now)
+			if client.calls != tc.expectedCalls {
+				t.Fatalf("expected %v subject access reviews got: %v", tc.expectedCalls, client.calls)
+			}
+			if c.cache.len() != tc.expectedLen {
+				t.Fatalf("expected %v cached decisions got: %v", tc.expectedLen, c.cache.len())
+			}
+		})
+	}
+}
+
+func TestCachedAuthorizerDecision(t *testing.T) {
+	client := &countingSubjectAccessReview{allowed: true}
+	c := newTestCachedAuthorizer(client, CacheConfig{})
+	u := &AuthorizationUser{UserInfo: v1.UserInfo{Username: "foo"}}
+	for i := 0; i < 2; i++ {
+		d, err := c.Authorize(u, "location")
+		if err != nil {
+			t.Fatalf("unknown error occured: %v", err)
+		}
+		if d != authorization.DecisionAllowed {
+			t.Fatalf("expected: %v decision got: %v", authorization.DecisionAllowed, d)
+		}
+	}
+}
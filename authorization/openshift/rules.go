@@ -0,0 +1,164 @@
+package openshift
+
+import (
+	"fmt"
+
+	"github.com/automationbroker/bundle-lib/authorization"
+	"github.com/automationbroker/bundle-lib/clients"
+	log "github.com/automationbroker/bundle-lib/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// policyRule - the parts of a policy rule that are used to determine if a
+// user is able to perform the actions of a role.
+type policyRule struct {
+	Verbs         []string
+	APIGroups     []string
+	Resources     []string
+	ResourceNames []string
+}
+
+type groupsUser interface {
+	Groups() []string
+}
+
+// NewRulesAuthorizer - Create a new authorizer that allows a user only when
+// the user could perform every action that the sandbox role grants in the
+// target namespace. This prevents a user from escalating privileges through
+// the service account of the broker.
+func NewRulesAuthorizer(sandboxRole string) (authorization.Authorizer, error) {
+	if _, err := clients.Openshift(); err != nil {
+		return nil, fmt.Errorf("Unable to connect to the cluster")
+	}
+	return rulesAuthorization{
+		role:      sandboxRole,
+		roleRules: clusterRoleRules,
+		userRules: subjectRules,
+	}, nil
+}
+
+type rulesAuthorization struct {
+	role      string
+	roleRules func(string) ([]policyRule, error)
+	userRules func(string, []string, []string, string) ([]policyRule, error)
+}
+
+func (a rulesAuthorization) Authorize(user authorization.AuthorizeUser, location string) (authorization.Decision, error) {
+	var groups, scopes []string
+	if u, ok := user.(groupsUser); ok {
+		groups = u.Groups()
+	}
+	if u, ok := user.(authorization.ExtraUser); ok {
+		scopes = u.Scopes()
+	}
+	required, err := a.roleRules(a.role)
+	if err != nil {
+		return authorization.DecisionDeny, err
+	}
+	granted, err := a.userRules(user.Username(), groups, scopes, location)
+	if err != nil {
+		return authorization.DecisionDeny, err
+	}
+	for _, r := range required {
+		if !covers(granted, r) {
+			log.Infof("user %v can not %v %v in namespace %v which is granted by the sandbox role %v",
+				user.Username(), r.Verbs, r.Resources, location, a.role)
+			return authorization.DecisionDeny, nil
+		}
+	}
+	return authorization.DecisionAllowed, nil
+}
+
+func clusterRoleRules(role string) ([]policyRule, error) {
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		return nil, err
+	}
+	cr, err := k8scli.Client.RbacV1beta1().ClusterRoles().Get(role, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	rules := []policyRule{}
+	for _, r := range cr.Rules {
+		// Non resource urls can not be granted in a namespace.
+		if len(r.Resources) == 0 {
+			continue
+		}
+		rules = append(rules, policyRule{
+			Verbs:         r.Verbs,
+			APIGroups:     r.APIGroups,
+			Resources:     r.Resources,
+			ResourceNames: r.ResourceNames,
+		})
+	}
+	return rules, nil
+}
+
+func subjectRules(user string, groups, scopes []string, namespace string) ([]policyRule, error) {
+	ocli, err := clients.Openshift()
+	if err != nil {
+		return nil, err
+	}
+	r, err := ocli.SubjectRulesReview(user, groups, scopes, namespace)
+	if err != nil {
+		return nil, err
+	}
+	rules := []policyRule{}
+	for _, rule := range r {
+		rules = append(rules, policyRule{
+			Verbs:         rule.Verbs,
+			APIGroups:     rule.APIGroups,
+			Resources:     rule.Resources,
+			ResourceNames: rule.ResourceNames,
+		})
+	}
+	return rules, nil
+}
+
+// covers - determines if every verb, group, resource and resource name of
+// the required rule is granted by at least one of the granted rules.
+func covers(granted []policyRule, required policyRule) bool {
+	names := required.ResourceNames
+	if len(names) == 0 {
+		names = []string{""}
+	}
+	for _, verb := range required.Verbs {
+		for _, group := range required.APIGroups {
+			for _, resource := range required.Resources {
+				for _, name := range names {
+					if !grants(granted, verb, group, resource, name) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+func grants(granted []policyRule, verb, group, resource, name string) bool {
+	for _, r := range granted {
+		if !has(r.Verbs, verb) || !has(r.APIGroups, group) || !has(r.Resources, resource) {
+			continue
+		}
+		// A rule without resource names applies to all resources.
+		if len(r.ResourceNames) == 0 || (name != "" && contains(r.ResourceNames, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// has - determines if the values contain the value or the wildcard.
+func has(values []string, value string) bool {
+	return contains(values, "*") || contains(values, value)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
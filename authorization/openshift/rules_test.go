@@ -0,0 +1,144 @@
+package openshift
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/authorization"
+)
+
+func TestCovers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		granted  []policyRule
+		required policyRule
+		expected bool
+	}{
+		{
+			name: "exact match",
+			granted: []policyRule{
+				{Verbs: []string{"create", "get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+			},
+			required: policyRule{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+			expected: true,
+		},
+		{
+			name: "wildcards",
+			granted: []policyRule{
+				{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}},
+			},
+			required: policyRule{Verbs: []string{"delete"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+			expected: true,
+		},
+		{
+			name: "split across rules",
+			granted: []policyRule{
+				{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+				{Verbs: []string{"delete"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+			},
+			required: policyRule{Verbs: []string{"create", "delete"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+			expected: true,
+		},
+		{
+			name: "missing verb",
+			granted: []policyRule{
+				{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+			},
+			required: policyRule{Verbs: []string{"get", "delete"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+			expected: false,
+		},
+		{
+			name: "resource names restrict",
+			granted: []policyRule{
+				{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"foo"}},
+			},
+			required: policyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}},
+			expected: false,
+		},
+		{
+			name: "resource names match",
+			granted: []policyRule{
+				{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"foo"}},
+			},
+			required: policyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"foo"}},
+			expected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if covers(tc.granted, tc.required) != tc.expected {
+				t.Fatalf("expected covers to be %v", tc.expected)
+			}
+		})
+	}
+}
+
+func TestRulesAuthorize(t *testing.T) {
+	role := []policyRule{
+		{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+	}
+	testCases := []struct {
+		name             string
+		roleErr          error
+		userErr          error
+		userRules        []policyRule
+		expectedDecision authorization.Decision
+		shouldError      bool
+	}{
+		{
+			name:             "user can perform role actions",
+			userRules:        []policyRule{{Verbs: []string{"*"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+			expectedDecision: authorization.DecisionAllowed,
+		},
+		{
+			name:             "user can not perform role actions",
+			userRules:        []policyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+			expectedDecision: authorization.DecisionDeny,
+		},
+		{
+			name:             "role lookup error",
+			roleErr:          fmt.Errorf("not found"),
+			expectedDecision: authorization.DecisionDeny,
+			shouldError:      true,
+		},
+		{
+			name:             "rules review error",
+			userErr:          fmt.Errorf("forbidden"),
+			expectedDecision: authorization.DecisionDeny,
+			shouldError:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := rulesAuthorization{
+				role: "admin",
+				roleRules: func(string) ([]policyRule, error) {
+					return role, tc.roleErr
+				},
+				userRules: func(user string, groups, scopes []string, ns string) ([]policyRule, error) {
+					if user != "foo" || ns != "location" || len(scopes) != 1 || len(groups) != 1 {
+						return nil, fmt.Errorf("unexpected subject rules review")
+					}
+					return tc.userRules, tc.userErr
+				},
+			}
+			u := &authorization.User{
+				Name:        "foo",
+				GroupNames:  []string{"dev"},
+				ExtraValues: map[string][]string{authorization.ScopesExtraKey: {"user:full"}},
+			}
+			dec, err := a.Authorize(u, "location")
+			if err != nil && !tc.shouldError {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			if err == nil && tc.shouldError {
+				t.Fatal("expected an error")
+			}
+			if dec != tc.expectedDecision {
+				t.Fatalf("expected: %v decision got: %v", tc.expectedDecision, dec)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+
+	"github.com/automationbroker/bundle-lib/authorization"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+// ErrUnauthorized - Error indicating the user is not authorized to perform
+// the action.
+type ErrUnauthorized struct {
+	User     string
+	Action   authorization.Action
+	Decision authorization.Decision
+}
+
+func (e ErrUnauthorized) Error() string {
+	if e.User == "" {
+		return fmt.Sprintf("unable to %v, no user was provided for authorization", e.Action)
+	}
+	return fmt.Sprintf("user %v is not authorized to %v - decision: %v", e.User, e.Action, e.Decision)
+}
+
+// ErrorCode - the error is of the Unauthorized class.
+func (e ErrUnauthorized) ErrorCode() liberrors.Code {
+	return liberrors.CodeUnauthorized
+}
+
+// IsErrUnauthorized - true if the error is an unauthorized error
+func IsErrUnauthorized(err error) bool {
+	_, ok := err.(ErrUnauthorized)
+	return ok
+}
+
+// authorize - consults the configured authorizer, if any, to determine if
+// the user of the instance is allowed to perform the action.
+func (e *executor) authorize(action authorization.Action, instance *ServiceInstance) error {
+	if e.authorizer == nil {
+		return nil
+	}
+	if instance.UserInfo == nil {
+		log.Errorf("authorizer configured but no user found on the service instance for %v", action)
+		return ErrUnauthorized{Action: action, Decision: authorization.DecisionDeny}
+	}
+
+	var namespace string
+	if instance.Context != nil {
+		namespace = instance.Context.Namespace
+	}
+
+	var decision authorization.Decision
+	var err error
+	if a, ok := e.authorizer.(authorization.ContextAuthorizer); ok {
+		var nsLabels, nsAnnotations map[string]string
+		nsLabels, nsAnnotations, err = e.namespaceMetadata(namespace)
+		if err != nil {
+			log.Errorf("unable to read the metadata of namespace %v - %v", namespace, err)
+			return err
+		}
+		decision, err = a.AuthorizeContext(instance.UserInfo, authorization.Context{
+			Action:               action,
+			BundleFQName:         instance.Spec.FQName,
+			Plan:                 instance.planName(),
+			Namespace:            namespace,
+			NamespaceLabels:      nsLabels,
+			NamespaceAnnotations: nsAnnotations,
+		})
+	} else {
+		decision, err = e.authorizer.Authorize(instance.UserInfo, namespace)
+	}
+	if err != nil {
+		log.Errorf("unable to authorize user %v for %v - %v", instance.UserInfo.Username(), action, err)
+		return err
+	}
+	if decision != authorization.DecisionAllowed {
+		log.Infof("user %v is not authorized to %v %v - decision: %v",
+			instance.UserInfo.Username(), action, instance.Spec.FQName, decision)
+		return ErrUnauthorized{
+			User:     instance.UserInfo.Username(),
+			Action:   action,
+			Decision: decision,
+		}
+	}
+	return nil
+}
+
+// UserCatalog - The specs of the manifest the user may provision, with only
+// the plans the user may provision, so brokers can show every user the
+// catalog of the user. The namespace and its metadata are taken from ctx,
+// the action, bundle and plan are filled in. A ContextAuthorizer is asked
+// about every plan, any other authorizer once for the namespace, which
+// keeps or drops the whole catalog. Specs without an allowed plan are left
+// out, the manifest is not changed. A nil authorizer allows everything.
+func UserCatalog(
+	authorizer authorization.Authorizer, user authorization.AuthorizeUser, ctx authorization.Context, manifest SpecManifest,
+) (SpecManifest, error) {
+	catalog := SpecManifest{}
+	if authorizer == nil {
+		for id, spec := range manifest {
+			catalog[id] = spec
+		}
+		return catalog, nil
+	}
+	if user == nil {
+		return catalog, nil
+	}
+	ctx.Action = authorization.ActionProvision
+
+	a, ok := authorizer.(authorization.ContextAuthorizer)
+	if !ok {
+		decision, err := authorizer.Authorize(user, ctx.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if decision == authorization.DecisionAllowed {
+			for id, spec := range manifest {
+				catalog[id] = spec
+			}
+		}
+		return catalog, nil
+	}
+
+	for id, spec := range manifest {
+		plans := []Plan{}
+		for _, plan := range spec.Plans {
+			ctx.BundleFQName = spec.FQName
+			ctx.Plan = plan.Name
+			decision, err := a.AuthorizeContext(user, ctx)
+			if err != nil {
+				log.Errorf("unable to authorize user %v for %v plan %v - %v", user.Username(), spec.FQName, plan.Name, err)
+				return nil, err
+			}
+			if decision == authorization.DecisionAllowed {
+				plans = append(plans, plan)
+			}
+		}
+		if len(plans) == 0 {
+			continue
+		}
+		s := *spec
+		s.Plans = plans
+		catalog[id] = &s
+	}
+	return catalog, nil
+}
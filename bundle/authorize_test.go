@@ -0,0 +1,196 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/authorization"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAuthorizer struct {
+	decision authorization.Decision
+	err      error
+	location string
+}
+
+func (f *fakeAuthorizer) Authorize(u authorization.AuthorizeUser, location string) (authorization.Decision, error) {
+	f.location = location
+	return f.decision, f.err
+}
+
+type fakeContextAuthorizer struct {
+	fakeAuthorizer
+	ctx authorization.Context
+}
+
+func (f *fakeContextAuthorizer) AuthorizeContext(u authorization.AuthorizeUser, ctx authorization.Context) (authorization.Decision, error) {
+	f.ctx = ctx
+	return f.decision, f.err
+}
+
+func TestExecutorAuthorize(t *testing.T) {
+	user := &authorization.User{Name: "foo"}
+	newInstance := func(u authorization.AuthorizeUser) *ServiceInstance {
+		return &ServiceInstance{
+			Spec:       &Spec{FQName: "new-fq-name"},
+			Context:    &Context{Namespace: "target"},
+			Parameters: &Parameters{PlanParameterKey: "dev"},
+			UserInfo:   u,
+		}
+	}
+	testCases := []struct {
+		name           string
+		authorizer     authorization.Authorizer
+		instance       *ServiceInstance
+		shouldError    bool
+		isUnauthorized bool
+	}{
+		{
+			name:     "no authorizer",
+			instance: newInstance(nil),
+		},
+		{
+			name:       "allowed",
+			authorizer: &fakeAuthorizer{decision: authorization.DecisionAllowed},
+			instance:   newInstance(user),
+		},
+		{
+			name:           "denied",
+			authorizer:     &fakeAuthorizer{decision: authorization.DecisionDeny},
+			instance:       newInstance(user),
+			shouldError:    true,
+			isUnauthorized: true,
+		},
+		{
+			name:           "no opinion",
+			authorizer:     &fakeAuthorizer{decision: authorization.DecisionNoOpinion},
+			instance:       newInstance(user),
+			shouldError:    true,
+			isUnauthorized: true,
+		},
+		{
+			name:           "no user",
+			authorizer:     &fakeAuthorizer{decision: authorization.DecisionAllowed},
+			instance:       newInstance(nil),
+			shouldError:    true,
+			isUnauthorized: true,
+		},
+		{
+			name:        "authorizer error",
+			authorizer:  &fakeAuthorizer{err: fmt.Errorf("review failed")},
+			instance:    newInstance(user),
+			shouldError: true,
+		},
+		{
+			name:       "context authorizer",
+			authorizer: &fakeContextAuthorizer{fakeAuthorizer: fakeAuthorizer{decision: authorization.DecisionAllowed}},
+			instance:   newInstance(user),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &executor{authorizer: tc.authorizer}
+			err := e.authorize(authorization.ActionProvision, tc.instance)
+			if err != nil && !tc.shouldError {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			if err == nil && tc.shouldError {
+				t.Fatal("expected an error")
+			}
+			if IsErrUnauthorized(err) != tc.isUnauthorized {
+				t.Fatalf("expected unauthorized error to be %v got: %v", tc.isUnauthorized, err)
+			}
+			switch a := tc.authorizer.(type) {
+			case *fakeContextAuthorizer:
+				expected := authorization.Context{
+					Action:       authorization.ActionProvision,
+					BundleFQName: "new-fq-name",
+					Plan:         "dev",
+					Namespace:    "target",
+				}
+				if !reflect.DeepEqual(a.ctx, expected) {
+					t.Fatalf("invalid context\nexpected: %#+v\nactual: %#+v", expected, a.ctx)
+				}
+			case *fakeAuthorizer:
+				if tc.instance.UserInfo != nil && a.location != "target" {
+					t.Fatalf("expected location target got: %v", a.location)
+				}
+			}
+		})
+	}
+}
+
+// planAuthorizer - allows the plans in the map, keyed by FQName/plan.
+type planAuthorizer map[string]bool
+
+func (p planAuthorizer) Authorize(u authorization.AuthorizeUser, location string) (authorization.Decision, error) {
+	return authorization.DecisionDeny, nil
+}
+
+func (p planAuthorizer) AuthorizeContext(u authorization.AuthorizeUser, ctx authorization.Context) (authorization.Decision, error) {
+	if ctx.Action != authorization.ActionProvision || ctx.Namespace != "target" {
+		return authorization.DecisionDeny, fmt.Errorf("unexpected context %v", ctx)
+	}
+	if p[ctx.BundleFQName+"/"+ctx.Plan] {
+		return authorization.DecisionAllowed, nil
+	}
+	return authorization.DecisionDeny, nil
+}
+
+func TestUserCatalog(t *testing.T) {
+	user := &authorization.User{Name: "foo"}
+	manifest := SpecManifest{
+		"1": {FQName: "postgresql", Plans: []Plan{{Name: "dev"}, {Name: "prod"}}},
+		"2": {FQName: "mysql", Plans: []Plan{{Name: "dev"}}},
+	}
+	ctx := authorization.Context{Namespace: "target"}
+
+	catalog, err := UserCatalog(planAuthorizer{"postgresql/dev": true}, user, ctx, manifest)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Len(t, catalog, 1)
+	assert.Equal(t, []Plan{{Name: "dev"}}, catalog["1"].Plans)
+	// the manifest is not changed
+	assert.Len(t, manifest["1"].Plans, 2)
+
+	catalog, err = UserCatalog(planAuthorizer{"postgresql/dev": true}, nil, ctx, manifest)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Empty(t, catalog)
+
+	catalog, err = UserCatalog(&fakeAuthorizer{decision: authorization.DecisionAllowed}, user, ctx, manifest)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Len(t, catalog, 2)
+
+	catalog, err = UserCatalog(nil, nil, ctx, manifest)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Len(t, catalog, 2)
+
+	_, err = UserCatalog(planAuthorizer{}, user, authorization.Context{Namespace: "other"}, manifest)
+	assert.Error(t, err)
+}
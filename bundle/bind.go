@@ -19,10 +19,10 @@ package bundle
 import (
 	"fmt"
 
+	"github.com/automationbroker/bundle-lib/authorization"
 	"github.com/automationbroker/bundle-lib/runtime"
-	"github.com/pborman/uuid"
 
-	log "github.com/sirupsen/logrus"
+	log "github.com/automationbroker/bundle-lib/logging"
 )
 
 const (
@@ -42,8 +42,37 @@ func (e *executor) Bind(
 	log.Infof("ServiceInstance.Description: %s", instance.Spec.Description)
 	log.Infof("============================================================")
 
-	go func() {
+	return e.runAction(instance, func() {
+		e.startActionSpan(bindAction, instance)
+		e.startOperation(bindAction, instance, parameters)
+		e.startEvent(bindAction, instance, bindingID)
 		e.actionStarted()
+		if err := e.authorize(authorization.ActionBind, instance); err != nil {
+			e.actionFinishedWithError(err)
+			return
+		}
+		if err := validateBindParameters(bindAction, instance, parameters); err != nil {
+			e.actionFinishedWithError(err)
+			return
+		}
+		if instance.credentialOnlyBind() {
+			if err := e.bindInstanceCredentials(instance, bindingID); err != nil {
+				log.Errorf("apb::bind error occurred - %v", err)
+				e.actionFinishedWithError(err)
+				return
+			}
+			e.actionFinishedWithSuccess()
+			return
+		}
+		if err := CheckImagePolicy(e.imagePolicy, instance.Spec); err != nil {
+			e.actionFinishedWithError(err)
+			return
+		}
+		image, err := CheckImageDrift(e.digestResolver, e.imageDriftPolicy, instance.Spec)
+		if err != nil {
+			e.actionFinishedWithError(err)
+			return
+		}
 		// Create namespace name that will be used to generate a name.
 		ns := fmt.Sprintf("%s-%.4s-", instance.Spec.FQName, bindAction)
 		// Determine if we should be using the context namespace from the
@@ -52,23 +81,28 @@ func (e *executor) Bind(
 			ns = instance.Context.Namespace
 		}
 		// Create the podname
-		pn := fmt.Sprintf("bundle-%s", uuid.New())
-		targets := []string{instance.Context.Namespace}
+		pn, key := e.newSandboxName(instance, bindAction)
+		targets := instance.Context.TargetNamespaces()
 		labels := map[string]string{
 			"bundle-fqname":   instance.Spec.FQName,
 			"bundle-action":   bindAction,
 			"bundle-pod-name": pn,
 		}
+		labels = sandboxLabels(labels, instance.Labels)
+		labels[runtime.IdempotencyKeyLabel] = key
 
-		serviceAccount, namespace, err := runtime.Provider.CreateSandbox(pn, ns, targets, clusterConfig.SandboxRole, labels)
+		serviceAccount, namespace, err := e.createSandbox(pn, ns, targets, labels)
 		ec := runtime.ExecutionContext{
-			BundleName: pn,
-			Targets:    targets,
-			Metadata:   labels,
-			Action:     bindAction,
-			Image:      instance.Spec.Image,
-			Account:    serviceAccount,
-			Location:   namespace,
+			BundleName:     pn,
+			Targets:        targets,
+			Metadata:       labels,
+			Annotations:    instance.Annotations,
+			Action:         bindAction,
+			Image:          image,
+			RuntimeVersion: instance.Spec.Runtime,
+			Account:        serviceAccount,
+			Location:       namespace,
+			IdempotencyKey: key,
 		}
 		if err != nil {
 			log.Errorf("Problem executing bundle create sandbox [%s] bind", ec.BundleName)
@@ -91,7 +125,7 @@ func (e *executor) Bind(
 		}
 
 		if instance.Spec.Runtime >= 2 {
-			err := runtime.Provider.WatchRunningBundle(ec.BundleName, ec.Location, e.updateDescription)
+			err := e.watchRunningBundle(ec.BundleName, ec.Location)
 			if err != nil {
 				log.Errorf("Bind action failed - %v", err)
 				e.actionFinishedWithError(err)
@@ -109,7 +143,7 @@ func (e *executor) Bind(
 			return
 		}
 
-		credBytes, err := runtime.Provider.ExtractCredentials(
+		credBytes, err := e.extractCredentials(
 			ec.BundleName,
 			ec.Location,
 			instance.Spec.Runtime,
@@ -126,6 +160,15 @@ func (e *executor) Bind(
 			e.actionFinishedWithError(err)
 			return
 		}
+		// only store the credentials the spec returns for bindings, in
+		// the shape declared by the plan
+		creds.Credentials, err = instance.transformBindCredentials(
+			instance.Spec.BindCredentials.Filter(creds.Credentials))
+		if err != nil {
+			log.Errorf("apb::bind error occurred - %v", err)
+			e.actionFinishedWithError(err)
+			return
+		}
 
 		labels = map[string]string{"bundleAction": "bind", "bundleName": instance.Spec.FQName}
 		err = runtime.Provider.CreateExtractedCredential(bindingID, clusterConfig.Namespace, creds.Credentials, labels)
@@ -136,7 +179,26 @@ func (e *executor) Bind(
 		}
 		e.extractedCredentials = creds
 		e.actionFinishedWithSuccess()
-	}()
+	})
+}
 
-	return e.statusChan
+// bindInstanceCredentials - binds without running the bundle, the binding
+// gets the credentials extracted when the instance was provisioned.
+func (e *executor) bindInstanceCredentials(instance *ServiceInstance, bindingID string) error {
+	log.Infof("plan binds with the instance credentials, not running bundle for binding %v", bindingID)
+	creds, err := runtime.Provider.GetExtractedCredential(instance.ID.String(), clusterConfig.Namespace)
+	if err != nil {
+		return err
+	}
+	creds, err = instance.transformBindCredentials(instance.Spec.BindCredentials.Filter(creds))
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{"bundleAction": bindAction, "bundleName": instance.Spec.FQName}
+	err = runtime.Provider.CreateExtractedCredential(bindingID, clusterConfig.Namespace, creds, labels)
+	if err != nil {
+		return err
+	}
+	e.extractedCredentials = &ExtractedCredentials{Credentials: creds}
+	return nil
 }
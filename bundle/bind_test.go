@@ -72,6 +72,15 @@ func TestBind(t *testing.T) {
 		Runtime:  2,
 		Bindable: true,
 	}
+	credentialOnlySpec := &Spec{
+		ID:              "new-spec-id",
+		Image:           "new-image",
+		FQName:          "new-fq-name",
+		Runtime:         2,
+		Bindable:        true,
+		Plans:           []Plan{{Name: "dev", CredentialOnlyBind: true}},
+		BindCredentials: &BindCredentials{Instance: []string{"admin"}},
+	}
 
 	// define test cases
 	testCases := []*struct {
@@ -178,6 +187,47 @@ func TestBind(t *testing.T) {
 				Credentials: map[string]interface{}{"test": "testingcreds"},
 			},
 		},
+		{
+			name:   "bind with the instance credentials",
+			config: ExecutorConfig{},
+			rt:     *new(runtime.MockRuntime),
+			si: ServiceInstance{
+				ID:         u,
+				Spec:       credentialOnlySpec,
+				Context:    ctx,
+				Parameters: &Parameters{PlanParameterKey: "dev"},
+			},
+			bindingID: bID.String(),
+			addExpectations: func(rt *runtime.MockRuntime, e Executor) {
+				// no sandbox is created and no bundle is run
+				rt.On("GetExtractedCredential", u.String(), mock.Anything).Return(
+					map[string]interface{}{"test": "testingcreds", "admin": "secret"}, nil)
+				rt.On("CreateExtractedCredential", bID.String(), mock.Anything,
+					map[string]interface{}{"test": "testingcreds"},
+					map[string]string{
+						"bundleAction": "bind",
+						"bundleName":   "new-fq-name",
+					},
+				).Return(nil)
+			},
+			validateMessage: func(m []StatusMessage) bool {
+				if len(m) != 2 {
+					return false
+				}
+				first := m[0]
+				second := m[1]
+				if first.State != StateInProgress {
+					return false
+				}
+				if second.State != StateSucceeded {
+					return false
+				}
+				return true
+			},
+			extractedCreds: &ExtractedCredentials{
+				Credentials: map[string]interface{}{"test": "testingcreds"},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
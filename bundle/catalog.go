@@ -0,0 +1,277 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clock"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+const (
+	// CatalogVersion - The version of the catalog archive format.
+	CatalogVersion = 1
+	// catalogFile - the catalog in the archive.
+	catalogFile = "catalog.json"
+	// catalogSignatureFile - the signature of the catalog in the archive.
+	catalogSignatureFile = "catalog.json.sig"
+	// MaxCatalogSize - the largest catalog ImportCatalog reads.
+	MaxCatalogSize int64 = 64 << 20
+	// maxCatalogSignatureSize - the largest signature ImportCatalog reads.
+	maxCatalogSignatureSize int64 = 64 << 10
+)
+
+// CatalogSigner - Signs the catalog when it is exported and verifies the
+// signature when it is imported.
+type CatalogSigner interface {
+	Sign(data []byte) ([]byte, error)
+	Verify(data, signature []byte) error
+}
+
+// ErrorCatalogSignature - The signature of the imported catalog is missing
+// or does not match.
+type ErrorCatalogSignature struct {
+	Reason string
+}
+
+func (e ErrorCatalogSignature) Error() string {
+	return fmt.Sprintf("invalid catalog signature: %v", e.Reason)
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrorCatalogSignature) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrorCatalogSignature - true if the error is an ErrorCatalogSignature.
+func IsErrorCatalogSignature(err error) bool {
+	_, ok := err.(ErrorCatalogSignature)
+	return ok
+}
+
+// catalogSnapshot - The catalog in the archive. The specs keep their
+// Provenance, digests included.
+type catalogSnapshot struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Specs      []*Spec   `json:"specs"`
+}
+
+// ExportCatalog - writes the specs of the manifest to w as a gzipped tar
+// archive holding the catalog and its signature, to be imported into an
+// environment without access to the registries. The export is timestamped
+// with the clock, nil uses clock.Real.
+func ExportCatalog(w io.Writer, manifest SpecManifest, signer CatalogSigner, clk clock.Clock) error {
+	snapshot := catalogSnapshot{
+		Version:    CatalogVersion,
+		ExportedAt: clock.OrReal(clk).Now().UTC(),
+		Specs:      []*Spec{},
+	}
+	for _, spec := range manifest {
+		snapshot.Specs = append(snapshot.Specs, spec)
+	}
+	sort.Slice(snapshot.Specs, func(i, j int) bool {
+		return snapshot.Specs[i].ID < snapshot.Specs[j].ID
+	})
+	catalog, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	signature, err := signer.Sign(catalog)
+	if err != nil {
+		log.Errorf("unable to sign the catalog - %v", err)
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{name: catalogFile, data: catalog},
+		{name: catalogSignatureFile, data: signature},
+	} {
+		hdr := &tar.Header{
+			Name:    f.name,
+			Mode:    0644,
+			Size:    int64(len(f.data)),
+			ModTime: snapshot.ExportedAt,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	log.Infof("exported %d specs in the catalog %v", len(snapshot.Specs), catalogDigest(catalog))
+	return nil
+}
+
+// ImportCatalog - reads an archive written by ExportCatalog, verifies its
+// signature and returns the manifest of its specs.
+func ImportCatalog(r io.Reader, verifier CatalogSigner) (SpecManifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	var catalog, signature []byte
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch hdr.Name {
+		case catalogFile:
+			catalog, err = readCatalogEntry(tr, hdr, MaxCatalogSize)
+		case catalogSignatureFile:
+			signature, err = readCatalogEntry(tr, hdr, maxCatalogSignatureSize)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if catalog == nil {
+		return nil, fmt.Errorf("the archive has no %v", catalogFile)
+	}
+	if signature == nil {
+		return nil, ErrorCatalogSignature{Reason: "the archive is not signed"}
+	}
+	if err := verifier.Verify(catalog, signature); err != nil {
+		log.Errorf("unable to verify the catalog - %v", err)
+		return nil, ErrorCatalogSignature{Reason: err.Error()}
+	}
+
+	snapshot := catalogSnapshot{}
+	if err := json.Unmarshal(catalog, &snapshot); err != nil {
+		return nil, err
+	}
+	if snapshot.Version > CatalogVersion {
+		return nil, fmt.Errorf("unsupported catalog version %d, the latest supported is %d",
+			snapshot.Version, CatalogVersion)
+	}
+	log.Infof("imported %d specs from the catalog %v exported at %v",
+		len(snapshot.Specs), catalogDigest(catalog), snapshot.ExportedAt)
+	return NewSpecManifest(snapshot.Specs), nil
+}
+
+// readCatalogEntry - reads the entry of the archive, failing without
+// reading it when its header is larger than the limit and once more than
+// limit bytes are read.
+func readCatalogEntry(r io.Reader, hdr *tar.Header, limit int64) ([]byte, error) {
+	if hdr.Size > limit {
+		return nil, fmt.Errorf("%v in the archive exceeds the limit of %d bytes", hdr.Name, limit)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%v in the archive exceeds the limit of %d bytes", hdr.Name, limit)
+	}
+	return data, nil
+}
+
+// HMACCatalogSigner - Signs the catalog with HMAC-SHA256 and a key shared
+// by the exporting and the importing environments.
+type HMACCatalogSigner struct {
+	Key []byte
+}
+
+// Sign - returns the HMAC of the data.
+func (s HMACCatalogSigner) Sign(data []byte) ([]byte, error) {
+	if len(s.Key) == 0 {
+		return nil, errors.New("the hmac key is empty")
+	}
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
+// Verify - checks the HMAC of the data.
+func (s HMACCatalogSigner) Verify(data, signature []byte) error {
+	expected, err := s.Sign(data)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(expected, signature) {
+		return errors.New("the hmac does not match")
+	}
+	return nil
+}
+
+// RSACatalogSigner - Signs the catalog with RSA-PSS. The importing
+// environment only needs the PublicKey, the PrivateKey is only required to
+// sign.
+type RSACatalogSigner struct {
+	PrivateKey *rsa.PrivateKey
+	PublicKey  *rsa.PublicKey
+}
+
+// Sign - returns the RSA-PSS signature of the sha256 of the data.
+func (s RSACatalogSigner) Sign(data []byte) ([]byte, error) {
+	if s.PrivateKey == nil {
+		return nil, errors.New("no private key to sign the catalog")
+	}
+	digest := sha256.Sum256(data)
+	return rsa.SignPSS(rand.Reader, s.PrivateKey, crypto.SHA256, digest[:], nil)
+}
+
+// Verify - checks the RSA-PSS signature of the data.
+func (s RSACatalogSigner) Verify(data, signature []byte) error {
+	pub := s.PublicKey
+	if pub == nil && s.PrivateKey != nil {
+		pub = &s.PrivateKey.PublicKey
+	}
+	if pub == nil {
+		return errors.New("no public key to verify the catalog")
+	}
+	digest := sha256.Sum256(data)
+	return rsa.VerifyPSS(pub, crypto.SHA256, digest[:], signature, nil)
+}
+
+// catalogDigest - the sha256 of the catalog, for the logs.
+func catalogDigest(catalog []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(catalog))
+}
@@ -0,0 +1,146 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func catalogManifest() SpecManifest {
+	return NewSpecManifest([]*Spec{
+		{
+			ID:     "one",
+			FQName: "dh-postgresql-apb",
+			Provenance: &Provenance{
+				Registry:    "dh",
+				AdapterType: "dockerhub",
+				Source:      "docker.io/ansibleplaybookbundle/postgresql-apb:latest",
+				Digest:      "sha256:482e3f2c",
+				FetchedAt:   time.Date(2018, 5, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{ID: "two", FQName: "dh-mediawiki-apb"},
+	})
+}
+
+func TestCatalogExportImport(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	testCases := []struct {
+		name     string
+		signer   CatalogSigner
+		verifier CatalogSigner
+		valid    bool
+	}{
+		{
+			name:     "hmac",
+			signer:   HMACCatalogSigner{Key: []byte("secret")},
+			verifier: HMACCatalogSigner{Key: []byte("secret")},
+			valid:    true,
+		},
+		{
+			name:     "hmac wrong key",
+			signer:   HMACCatalogSigner{Key: []byte("secret")},
+			verifier: HMACCatalogSigner{Key: []byte("other")},
+		},
+		{
+			name:     "rsa",
+			signer:   RSACatalogSigner{PrivateKey: key},
+			verifier: RSACatalogSigner{PublicKey: &key.PublicKey},
+			valid:    true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := ExportCatalog(&buf, catalogManifest(), tc.signer, nil); err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			manifest, err := ImportCatalog(&buf, tc.verifier)
+			if !tc.valid {
+				assert.True(t, IsErrorCatalogSignature(err))
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, catalogManifest(), manifest)
+		})
+	}
+}
+
+func TestExportCatalogClock(t *testing.T) {
+	exportedAt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	err := ExportCatalog(&buf, catalogManifest(), HMACCatalogSigner{Key: []byte("secret")}, clock.NewFake(exportedAt))
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	hdr, err := tar.NewReader(gz).Next()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.True(t, exportedAt.Equal(hdr.ModTime), "unexpected export time %v", hdr.ModTime)
+}
+
+func TestImportCatalogTooLarge(t *testing.T) {
+	testCases := []struct {
+		name string
+		size int64
+	}{
+		{name: catalogFile, size: MaxCatalogSize + 1},
+		{name: catalogSignatureFile, size: maxCatalogSignatureSize + 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gz)
+			if err := tw.WriteHeader(&tar.Header{Name: tc.name, Mode: 0644, Size: tc.size}); err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			// only the header is written, the entry is never read
+			gz.Close()
+
+			_, err := ImportCatalog(&buf, HMACCatalogSigner{Key: []byte("secret")})
+			if err == nil {
+				t.Fatalf("expected the %v to be refused", tc.name)
+			}
+			assert.Contains(t, err.Error(), "exceeds the limit")
+		})
+	}
+}
+
+func TestRSACatalogSignerWithoutPrivateKey(t *testing.T) {
+	_, err := RSACatalogSigner{}.Sign([]byte("catalog"))
+	assert.Error(t, err)
+}
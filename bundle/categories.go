@@ -0,0 +1,159 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CategoriesKey - apb.yml metadata key with the categories of the bundle.
+// A category is a path from the broadest category, written as a string or
+// a list:
+//
+//	metadata:
+//	  categories:
+//	    - databases/sql
+//	    - [monitoring, metrics]
+const CategoriesKey = "categories"
+
+// categorySeparator - separates the levels of a category written as a
+// string.
+const categorySeparator = "/"
+
+// Category - A hierarchical category of a spec, from the broadest level,
+// e.g. [databases sql].
+type Category []string
+
+// String - the levels of the category separated by a slash.
+func (c Category) String() string {
+	return strings.Join(c, categorySeparator)
+}
+
+// HasPrefix - true if the category is the parent category or one of its
+// subcategories.
+func (c Category) HasPrefix(parent Category) bool {
+	if len(parent) > len(c) {
+		return false
+	}
+	for i := range parent {
+		if c[i] != parent[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseCategory - the category of its string, e.g. "Databases/SQL". The
+// levels are normalized like the tags and the empty ones are dropped.
+func ParseCategory(s string) Category {
+	return normalizeCategory(strings.Split(s, categorySeparator))
+}
+
+// NormalizeTags - the tags lowercased and trimmed, without the empty and
+// the duplicate ones. The order of the first occurrences is kept.
+func NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// NormalizeTaxonomy - normalizes the tags of the spec and sets its
+// Categories from the metadata, so the specs of registries that tag their
+// images differently are grouped the same way. The categories that can not
+// be read are returned as an error, the other ones are set.
+func NormalizeTaxonomy(spec *Spec) error {
+	spec.Tags = NormalizeTags(spec.Tags)
+	categories, err := spec.MetadataCategories()
+	spec.Categories = categories
+	return err
+}
+
+// MetadataCategories - the distinct categories of the categories metadata
+// of the spec, see CategoriesKey.
+func (s *Spec) MetadataCategories() ([]Category, error) {
+	value, ok := s.Metadata[CategoriesKey]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	var items []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case string:
+		// a single category
+		items = []interface{}{v}
+	default:
+		return nil, fmt.Errorf("the %v metadata must be a list, got %T", CategoriesKey, value)
+	}
+	seen := map[string]bool{}
+	categories := []Category{}
+	invalid := []string{}
+	for _, item := range items {
+		var category Category
+		switch v := item.(type) {
+		case string:
+			category = ParseCategory(v)
+		case []interface{}:
+			levels := make([]string, 0, len(v))
+			for _, level := range v {
+				levels = append(levels, fmt.Sprint(level))
+			}
+			category = ParseCategory(strings.Join(levels, categorySeparator))
+		default:
+			invalid = append(invalid, fmt.Sprint(item))
+			continue
+		}
+		if len(category) == 0 || seen[category.String()] {
+			continue
+		}
+		seen[category.String()] = true
+		categories = append(categories, category)
+	}
+	if len(categories) == 0 {
+		categories = nil
+	}
+	if len(invalid) > 0 {
+		return categories, fmt.Errorf("invalid %v %v", CategoriesKey, strings.Join(invalid, ", "))
+	}
+	return categories, nil
+}
+
+func normalizeCategory(levels []string) Category {
+	category := Category{}
+	for _, level := range levels {
+		if level = normalizeTag(level); level != "" {
+			category = append(category, level)
+		}
+	}
+	return category
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
@@ -0,0 +1,93 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Nil(t, NormalizeTags(nil))
+	assert.Equal(t,
+		[]string{"database", "sql", "postgresql"},
+		NormalizeTags([]string{"Database", " SQL", "database", "", "PostgreSQL", "sql "}),
+	)
+}
+
+func TestNormalizeTaxonomy(t *testing.T) {
+	testCases := []struct {
+		name       string
+		metadata   map[string]interface{}
+		categories []Category
+		err        bool
+	}{
+		{name: "no categories", metadata: map[string]interface{}{}},
+		{
+			name:       "single category",
+			metadata:   map[string]interface{}{CategoriesKey: "Databases/SQL"},
+			categories: []Category{{"databases", "sql"}},
+		},
+		{
+			name: "strings and lists",
+			metadata: map[string]interface{}{CategoriesKey: []interface{}{
+				"databases/ sql/",
+				[]interface{}{"Monitoring", "Metrics"},
+				"DATABASES/SQL",
+				"/",
+			}},
+			categories: []Category{{"databases", "sql"}, {"monitoring", "metrics"}},
+		},
+		{
+			name: "invalid items are skipped",
+			metadata: map[string]interface{}{CategoriesKey: []interface{}{
+				"web",
+				map[string]interface{}{"name": "databases"},
+			}},
+			categories: []Category{{"web"}},
+			err:        true,
+		},
+		{
+			name:     "not a list",
+			metadata: map[string]interface{}{CategoriesKey: 10},
+			err:      true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &Spec{Tags: []string{"Database", "database"}, Metadata: tc.metadata}
+			err := NormalizeTaxonomy(spec)
+			if tc.err {
+				assert.Error(t, err)
+			} else if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, []string{"database"}, spec.Tags)
+			assert.Equal(t, tc.categories, spec.Categories)
+		})
+	}
+}
+
+func TestCategoryHasPrefix(t *testing.T) {
+	category := ParseCategory("databases/sql/postgresql")
+	assert.Equal(t, "databases/sql/postgresql", category.String())
+	assert.True(t, category.HasPrefix(Category{"databases"}))
+	assert.True(t, category.HasPrefix(Category{"databases", "sql", "postgresql"}))
+	assert.False(t, category.HasPrefix(Category{"databases", "nosql"}))
+	assert.False(t, Category{"databases"}.HasPrefix(category))
+}
@@ -0,0 +1,384 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"strings"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/pborman/uuid"
+)
+
+// Requirement - a spec that is provisioned before the spec requiring it.
+// The credentials extracted from the required bundle are passed to the
+// requiring bundle as the CredentialsParameter.
+type Requirement struct {
+	// FQName - the fully qualified name of the required spec.
+	FQName string `json:"name" yaml:"name"`
+	// Plan - the plan the required spec is provisioned with.
+	Plan string `json:"plan" yaml:"plan"`
+	// Parameters - the parameters the required spec is provisioned with.
+	Parameters Parameters `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	// CredentialsParameter - the parameter holding the extracted
+	// credentials of the required bundle. Defaults to
+	// <fqname>_credentials with the dashes replaced by underscores.
+	CredentialsParameter string `json:"credentials_parameter,omitempty" yaml:"credentials_parameter,omitempty"`
+}
+
+// RequiredInstance - an instance provisioned for a requirement. The spec
+// and plan are kept with the ID so the instance is found again when the
+// requirements of the spec change.
+type RequiredInstance struct {
+	// ID - the ID of the instance.
+	ID string `json:"id"`
+	// FQName - the fully qualified name of the spec of the instance.
+	FQName string `json:"name"`
+	// Plan - the plan the instance was provisioned with.
+	Plan string `json:"plan"`
+}
+
+// credentialsParameter - the parameter holding the credentials.
+func (r Requirement) credentialsParameter() string {
+	if r.CredentialsParameter != "" {
+		return r.CredentialsParameter
+	}
+	return strings.Replace(r.FQName, "-", "_", -1) + "_credentials"
+}
+
+// SpecResolver - returns the spec with the fully qualified name, used to
+// find the specs that are required by a spec.
+type SpecResolver func(fqname string) (*Spec, error)
+
+// ErrRequirementCycle - The requirements of a spec require the spec.
+type ErrRequirementCycle struct {
+	Cycle []string
+}
+
+func (e ErrRequirementCycle) Error() string {
+	return fmt.Sprintf("requirement cycle %v", strings.Join(e.Cycle, " -> "))
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrRequirementCycle) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrRequirementCycle - true if the error is an ErrRequirementCycle.
+func IsErrRequirementCycle(err error) bool {
+	_, ok := err.(ErrRequirementCycle)
+	return ok
+}
+
+// resolvedRequirement - a requirement with its spec.
+type resolvedRequirement struct {
+	Requirement
+	spec *Spec
+}
+
+// ResolveRequirements - returns the requirements of the spec, direct and
+// indirect, in the order they are provisioned: every requirement comes
+// after its own requirements. A spec required more than once with the same
+// plan is provisioned once.
+func ResolveRequirements(spec *Spec, resolve SpecResolver) ([]Requirement, error) {
+	resolved, err := resolveRequirements(spec, resolve)
+	if err != nil {
+		return nil, err
+	}
+	reqs := make([]Requirement, len(resolved))
+	for i, r := range resolved {
+		reqs[i] = r.Requirement
+	}
+	return reqs, nil
+}
+
+func resolveRequirements(spec *Spec, resolve SpecResolver) ([]resolvedRequirement, error) {
+	ordered := []resolvedRequirement{}
+	done := map[string]bool{}
+	path := []string{spec.FQName}
+
+	var visit func(s *Spec) error
+	visit = func(s *Spec) error {
+		for _, req := range s.Requires {
+			key := req.FQName + "/" + req.Plan
+			if done[key] {
+				continue
+			}
+			for _, name := range path {
+				if name == req.FQName {
+					return ErrRequirementCycle{Cycle: append(append([]string{}, path...), req.FQName)}
+				}
+			}
+			reqSpec, err := resolve(req.FQName)
+			if err != nil {
+				return fmt.Errorf("unable to resolve required spec %v - %v", req.FQName, err)
+			}
+			if _, ok := reqSpec.GetPlan(req.Plan); !ok {
+				return liberrors.New(liberrors.CodeValidation,
+					fmt.Sprintf("required spec %v has no plan %v", req.FQName, req.Plan))
+			}
+			path = append(path, req.FQName)
+			if err := visit(reqSpec); err != nil {
+				return err
+			}
+			path = path[:len(path)-1]
+			done[key] = true
+			ordered = append(ordered, resolvedRequirement{Requirement: req, spec: reqSpec})
+		}
+		return nil
+	}
+	if err := visit(spec); err != nil {
+		return nil, err
+	}
+	return ordered, nil
+}
+
+// provisionRequirements - provisions the requirements of the instance in
+// order and adds the credentials of its direct requirements to the
+// parameters of the instance. The instances provisioned for the
+// requirements are recorded in RequiredInstances. When a requirement fails
+// the requirements already provisioned are rolled back.
+func (e *executor) provisionRequirements(instance *ServiceInstance) error {
+	if len(instance.Spec.Requires) == 0 {
+		return nil
+	}
+	if e.specResolver == nil {
+		return liberrors.New(liberrors.CodeValidation,
+			fmt.Sprintf("%v requires other specs and the executor has no spec resolver", instance.Spec.FQName))
+	}
+	reqs, err := resolveRequirements(instance.Spec, e.specResolver)
+	if err != nil {
+		return err
+	}
+
+	credentials := map[string]map[string]interface{}{}
+	for _, req := range reqs {
+		key := req.FQName + "/" + req.Plan
+		params := Parameters{}
+		for k, v := range req.Parameters {
+			params[k] = v
+		}
+		addRequiredCredentials(params, req.spec, credentials)
+		params[PlanParameterKey] = req.Plan
+		reqInstance := &ServiceInstance{
+			ID:          e.newUUID(),
+			Spec:        req.spec,
+			Context:     instance.Context,
+			Parameters:  &params,
+			Labels:      instance.Labels,
+			Annotations: instance.Annotations,
+			UserInfo:    instance.UserInfo,
+		}
+		e.updateDescription(fmt.Sprintf("provisioning required %v", req.FQName), "")
+		creds, err := e.provisionRequirement(reqInstance)
+		if err != nil {
+			log.Errorf("unable to provision %v required by %v - %v", req.FQName, instance.Spec.FQName, err)
+			e.rollbackRequirements(instance)
+			return err
+		}
+		instance.RequiredInstances = append(instance.RequiredInstances, RequiredInstance{
+			ID:     reqInstance.ID.String(),
+			FQName: req.FQName,
+			Plan:   req.Plan,
+		})
+		credentials[key] = creds
+	}
+
+	if instance.Parameters == nil {
+		instance.Parameters = &Parameters{}
+	}
+	addRequiredCredentials(*instance.Parameters, instance.Spec, credentials)
+	return nil
+}
+
+// addRequiredCredentials - adds the credentials of the direct requirements
+// of the spec to the parameters.
+func addRequiredCredentials(params Parameters, spec *Spec, credentials map[string]map[string]interface{}) {
+	for _, req := range spec.Requires {
+		if creds, ok := credentials[req.FQName+"/"+req.Plan]; ok {
+			params[req.credentialsParameter()] = creds
+		}
+	}
+}
+
+// provisionRequirement - provisions the instance of a requirement with an
+// executor of its own and returns its extracted credentials.
+func (e *executor) provisionRequirement(instance *ServiceInstance) (map[string]interface{}, error) {
+	child, err := e.runRequirement(instance, func(child *executor) error {
+		return child.provisionOrUpdate(executionMethodProvision, instance)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if child.extractedCredentials == nil {
+		return map[string]interface{}{}, nil
+	}
+	labels := map[string]string{"bundleAction": string(executionMethodProvision), "bundleName": instance.Spec.FQName}
+	err = runtime.Provider.CreateExtractedCredential(
+		instance.ID.String(), clusterConfig.Namespace, child.extractedCredentials.Credentials, labels)
+	if err != nil {
+		return nil, err
+	}
+	return child.extractedCredentials.Credentials, nil
+}
+
+// requiredInstances - rebuilds the instances recorded in the
+// RequiredInstances of the instance, in the order they were provisioned.
+// Each one is resolved by the name of its spec. The parameters of the
+// requirement are taken from the requirements the spec has now, the
+// requirements that are gone keep only their plan.
+func (e *executor) requiredInstances(instance *ServiceInstance) ([]*ServiceInstance, error) {
+	if len(instance.RequiredInstances) == 0 {
+		return nil, nil
+	}
+	if e.specResolver == nil {
+		return nil, liberrors.New(liberrors.CodeValidation,
+			fmt.Sprintf("%v has required instances and the executor has no spec resolver", instance.Spec.FQName))
+	}
+	current := map[string]Parameters{}
+	reqs, err := resolveRequirements(instance.Spec, e.specResolver)
+	if err != nil {
+		log.Warningf("unable to resolve the requirements of %v, the required instances keep only their plan - %v",
+			instance.Spec.FQName, err)
+	}
+	for _, req := range reqs {
+		current[req.FQName+"/"+req.Plan] = req.Parameters
+	}
+
+	instances := make([]*ServiceInstance, len(instance.RequiredInstances))
+	for i, required := range instance.RequiredInstances {
+		reqID := uuid.Parse(required.ID)
+		if reqID == nil {
+			return nil, fmt.Errorf("invalid required instance id %v", required.ID)
+		}
+		reqSpec, err := e.specResolver(required.FQName)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve required spec %v - %v", required.FQName, err)
+		}
+		params := Parameters{}
+		for k, v := range current[required.FQName+"/"+required.Plan] {
+			params[k] = v
+		}
+		params[PlanParameterKey] = required.Plan
+		instances[i] = &ServiceInstance{
+			ID:          reqID,
+			Spec:        reqSpec,
+			Context:     instance.Context,
+			Parameters:  &params,
+			Labels:      instance.Labels,
+			Annotations: instance.Annotations,
+			UserInfo:    instance.UserInfo,
+		}
+	}
+	return instances, nil
+}
+
+// deprovisionRequirements - deprovisions the required instances of the
+// instance in the reverse order they were provisioned. A failed
+// requirement does not stop the others; the failed ones are left in
+// RequiredInstances and the first error is returned.
+func (e *executor) deprovisionRequirements(instance *ServiceInstance) error {
+	instances, err := e.requiredInstances(instance)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	remaining := []RequiredInstance{}
+	for i := len(instances) - 1; i >= 0; i-- {
+		reqInstance := instances[i]
+		e.updateDescription(fmt.Sprintf("deprovisioning required %v", reqInstance.Spec.FQName), "")
+		_, err := e.runRequirement(reqInstance, func(child *executor) error {
+			return child.deprovisionInstance(reqInstance)
+		})
+		if err != nil {
+			log.Errorf("unable to deprovision %v required by %v - %v",
+				reqInstance.Spec.FQName, instance.Spec.FQName, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			remaining = append([]RequiredInstance{instance.RequiredInstances[i]}, remaining...)
+		}
+	}
+	instance.RequiredInstances = nil
+	if len(remaining) > 0 {
+		instance.RequiredInstances = remaining
+	}
+	return firstErr
+}
+
+// rollbackRequirements - deprovisions the requirements provisioned for an
+// instance whose provision failed.
+func (e *executor) rollbackRequirements(instance *ServiceInstance) {
+	if len(instance.RequiredInstances) == 0 {
+		return
+	}
+	log.Infof("rolling back the requirements of %v", instance.Spec.FQName)
+	if err := e.deprovisionRequirements(instance); err != nil {
+		log.Errorf("unable to roll back the requirements of %v - %v", instance.Spec.FQName, err)
+	}
+}
+
+// runRequirement - runs an action for the instance of a requirement with an
+// executor of its own, forwarding its progress to this executor.
+func (e *executor) runRequirement(instance *ServiceInstance, action func(child *executor) error) (*executor, error) {
+	child := &executor{
+		statusChan:           make(chan StatusMessage),
+		statusPolicy:         e.statusPolicy,
+		lastStatus:           StatusMessage{State: StateNotYetStarted},
+		skipCreateNS:         e.skipCreateNS,
+		stateManager:         e.stateManager,
+		authorizer:           e.authorizer,
+		imagePolicy:          e.imagePolicy,
+		digestResolver:       e.digestResolver,
+		imageDriftPolicy:     e.imageDriftPolicy,
+		quotaChecker:         e.quotaChecker,
+		ctx:                  e.traceContext(),
+		actionCtx:            e.actionCtx,
+		namespaceLabels:      e.namespaceLabels,
+		namespaceAnnotations: e.namespaceAnnotations,
+		clock:                e.clock,
+		ids:                  e.ids,
+		attempt:              e.attempt,
+	}
+	forwarded := make(chan struct{})
+	statusChan := child.statusChan
+	go func() {
+		defer close(forwarded)
+		for msg := range statusChan {
+			if msg.State == StateInProgress && msg.Description != "" {
+				e.updateDescription(fmt.Sprintf("%v: %v", instance.Spec.FQName, msg.Description), "")
+			}
+		}
+	}()
+
+	child.actionStarted()
+	err := action(child)
+	if err != nil {
+		// the action may already have finished it on some errors
+		if child.statusChan != nil {
+			child.actionFinishedWithError(err)
+		}
+		<-forwarded
+		return child, err
+	}
+	child.actionFinishedWithSuccess()
+	<-forwarded
+	return child, nil
+}
@@ -0,0 +1,234 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func operatorSpec(name string, requires ...Requirement) *Spec {
+	return &Spec{
+		FQName:   name,
+		Plans:    []Plan{{Name: "default"}},
+		Operator: &OperatorBundle{Package: name, DefaultChannel: "default"},
+		Requires: requires,
+	}
+}
+
+// recordingRuntime - records the operators uninstalled, fails to install
+// the failing package and to uninstall the failingUninstall package.
+type recordingRuntime struct {
+	*runtime.FakeRuntime
+	failing          string
+	failingUninstall string
+	uninstalled      []string
+}
+
+func (r *recordingRuntime) InstallOperator(sub runtime.OperatorSubscription) error {
+	if sub.Package == r.failing {
+		return fmt.Errorf("unable to install %v", sub.Package)
+	}
+	return r.FakeRuntime.InstallOperator(sub)
+}
+
+func (r *recordingRuntime) UninstallOperator(sub runtime.OperatorSubscription) error {
+	if sub.Package == r.failingUninstall {
+		return fmt.Errorf("unable to uninstall %v", sub.Package)
+	}
+	r.uninstalled = append(r.uninstalled, sub.Package)
+	return r.FakeRuntime.UninstallOperator(sub)
+}
+
+func fakeSpecResolver(specs ...*Spec) SpecResolver {
+	return func(fqname string) (*Spec, error) {
+		for _, s := range specs {
+			if s.FQName == fqname {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("spec %v not found", fqname)
+	}
+}
+
+func TestResolveRequirements(t *testing.T) {
+	cache := operatorSpec("cache")
+	db := operatorSpec("db", Requirement{FQName: "cache", Plan: "default"})
+	app := operatorSpec("app",
+		Requirement{FQName: "db", Plan: "default"},
+		Requirement{FQName: "cache", Plan: "default"},
+	)
+
+	reqs, err := ResolveRequirements(app, fakeSpecResolver(cache, db))
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	names := []string{}
+	for _, r := range reqs {
+		names = append(names, r.FQName)
+	}
+	// cache is required twice but provisioned once, before db
+	assert.Equal(t, []string{"cache", "db"}, names)
+
+	_, err = ResolveRequirements(operatorSpec("app", Requirement{FQName: "missing", Plan: "default"}), fakeSpecResolver())
+	assert.Error(t, err)
+
+	_, err = ResolveRequirements(operatorSpec("app", Requirement{FQName: "cache", Plan: "unknown"}), fakeSpecResolver(cache))
+	assert.Error(t, err)
+
+	a := operatorSpec("a", Requirement{FQName: "b", Plan: "default"})
+	b := operatorSpec("b", Requirement{FQName: "a", Plan: "default"})
+	_, err = ResolveRequirements(a, fakeSpecResolver(a, b))
+	if !IsErrRequirementCycle(err) {
+		t.Fatalf("expected a requirement cycle got: %v", err)
+	}
+	assert.Equal(t, []string{"a", "b", "a"}, err.(ErrRequirementCycle).Cycle)
+}
+
+func TestProvisionRequirements(t *testing.T) {
+	rt := runtime.NewFakeRuntime()
+	runtime.Provider = rt
+
+	db := operatorSpec("db")
+	app := operatorSpec("app", Requirement{FQName: "db", Plan: "default", CredentialsParameter: "database"})
+	instance := &ServiceInstance{
+		ID:         uuid.NewUUID(),
+		Spec:       app,
+		Context:    &Context{Namespace: "target", Platform: "kubernetes"},
+		Parameters: &Parameters{PlanParameterKey: "default"},
+	}
+
+	for m := range NewExecutor(ExecutorConfig{SpecResolver: fakeSpecResolver(db)}).Provision(instance) {
+		assert.NotEqual(t, StateFailed, m.State, m.Error)
+	}
+	assert.Len(t, rt.Operators(), 2)
+	assert.Equal(t, []RequiredInstance{{ID: instance.RequiredInstances[0].ID, FQName: "db", Plan: "default"}},
+		instance.RequiredInstances)
+	assert.Equal(t, map[string]interface{}{}, (*instance.Parameters)["database"])
+}
+
+func TestProvisionRequirementsWithoutResolver(t *testing.T) {
+	runtime.Provider = runtime.NewFakeRuntime()
+	instance := &ServiceInstance{
+		ID:         uuid.NewUUID(),
+		Spec:       operatorSpec("app", Requirement{FQName: "db", Plan: "default"}),
+		Context:    &Context{Namespace: "target", Platform: "kubernetes"},
+		Parameters: &Parameters{PlanParameterKey: "default"},
+	}
+	var last StatusMessage
+	for m := range NewExecutor(ExecutorConfig{}).Provision(instance) {
+		last = m
+	}
+	assert.Equal(t, StateFailed, last.State)
+}
+
+func TestDeprovisionRequirements(t *testing.T) {
+	rt := &recordingRuntime{FakeRuntime: runtime.NewFakeRuntime()}
+	runtime.Provider = rt
+
+	cache := operatorSpec("cache")
+	db := operatorSpec("db", Requirement{FQName: "cache", Plan: "default"})
+	app := operatorSpec("app", Requirement{FQName: "db", Plan: "default"})
+	instance := &ServiceInstance{
+		ID:         uuid.NewUUID(),
+		Spec:       app,
+		Context:    &Context{Namespace: "target", Platform: "kubernetes"},
+		Parameters: &Parameters{PlanParameterKey: "default"},
+	}
+	config := ExecutorConfig{SpecResolver: fakeSpecResolver(cache, db)}
+
+	for m := range NewExecutor(config).Provision(instance) {
+		assert.NotEqual(t, StateFailed, m.State, m.Error)
+	}
+	assert.Len(t, rt.Operators(), 3)
+	assert.Len(t, instance.RequiredInstances, 2)
+
+	for m := range NewExecutor(config).Deprovision(instance) {
+		assert.NotEqual(t, StateFailed, m.State, m.Error)
+	}
+	assert.Equal(t, []string{"app", "db", "cache"}, rt.uninstalled)
+	assert.Len(t, rt.Operators(), 0)
+	assert.Len(t, instance.RequiredInstances, 0)
+}
+
+func TestProvisionRequirementsRollback(t *testing.T) {
+	rt := &recordingRuntime{FakeRuntime: runtime.NewFakeRuntime(), failing: "app"}
+	runtime.Provider = rt
+
+	cache := operatorSpec("cache")
+	db := operatorSpec("db", Requirement{FQName: "cache", Plan: "default"})
+	app := operatorSpec("app", Requirement{FQName: "db", Plan: "default"})
+	instance := &ServiceInstance{
+		ID:         uuid.NewUUID(),
+		Spec:       app,
+		Context:    &Context{Namespace: "target", Platform: "kubernetes"},
+		Parameters: &Parameters{PlanParameterKey: "default"},
+	}
+
+	var last StatusMessage
+	for m := range NewExecutor(ExecutorConfig{SpecResolver: fakeSpecResolver(cache, db)}).Provision(instance) {
+		last = m
+	}
+	assert.Equal(t, StateFailed, last.State)
+	assert.Equal(t, []string{"db", "cache"}, rt.uninstalled)
+	assert.Len(t, rt.Operators(), 0)
+	assert.Len(t, instance.RequiredInstances, 0)
+}
+
+func TestDeprovisionRequirementsRetry(t *testing.T) {
+	rt := &recordingRuntime{FakeRuntime: runtime.NewFakeRuntime()}
+	runtime.Provider = rt
+
+	a, b, c := operatorSpec("a"), operatorSpec("b"), operatorSpec("c")
+	app := operatorSpec("app",
+		Requirement{FQName: "a", Plan: "default"},
+		Requirement{FQName: "b", Plan: "default"},
+		Requirement{FQName: "c", Plan: "default"},
+	)
+	instance := &ServiceInstance{
+		ID:         uuid.NewUUID(),
+		Spec:       app,
+		Context:    &Context{Namespace: "target", Platform: "kubernetes"},
+		Parameters: &Parameters{PlanParameterKey: "default"},
+	}
+	config := ExecutorConfig{SpecResolver: fakeSpecResolver(a, b, c), StatusPolicy: StatusPolicyDrop}
+
+	for m := range NewExecutor(config).Provision(instance) {
+		assert.NotEqual(t, StateFailed, m.State, m.Error)
+	}
+	assert.Len(t, instance.RequiredInstances, 3)
+	failed := instance.RequiredInstances[1]
+
+	rt.failingUninstall = "b"
+	err := NewExecutor(config).(*executor).deprovisionRequirements(instance)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"c", "a"}, rt.uninstalled)
+	assert.Equal(t, []RequiredInstance{failed}, instance.RequiredInstances)
+
+	// the retry deprovisions b, not the first requirement of the spec
+	rt.failingUninstall = ""
+	err = NewExecutor(config).(*executor).deprovisionRequirements(instance)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, []string{"c", "a", "b"}, rt.uninstalled)
+	assert.Len(t, instance.RequiredInstances, 0)
+}
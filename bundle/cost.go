@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+// CostKey - the free-form plan metadata key, e.g. "cost: $0.00", that
+// Costs replaces. It is only read when the plan has no Costs.
+const CostKey = "cost"
+
+// CostEntry - The price of a plan in one currency and unit. In apb.yml:
+//
+//	plans:
+//	  - name: prod
+//	    costs:
+//	      - amount: 10.00
+//	        currency: USD
+//	        unit: MONTHLY
+type CostEntry struct {
+	Amount float64 `json:"amount" yaml:"amount"`
+	// Currency - the ISO 4217 currency code, e.g. USD.
+	Currency string `json:"currency" yaml:"currency"`
+	// Unit - the unit the amount is charged per, e.g. MONTHLY or HOURLY.
+	Unit string `json:"unit,omitempty" yaml:"unit,omitempty"`
+}
+
+// currencySymbols - the currency codes of the symbols used by the
+// free-form cost metadata.
+var currencySymbols = map[string]string{
+	"$": "USD",
+	"€": "EUR",
+	"£": "GBP",
+	"¥": "JPY",
+}
+
+// costUnits - the units of the periods used by the free-form cost
+// metadata, e.g. "$10.00/month".
+var costUnits = map[string]string{
+	"hour":  "HOURLY",
+	"day":   "DAILY",
+	"week":  "WEEKLY",
+	"month": "MONTHLY",
+	"year":  "YEARLY",
+}
+
+// CostEntries - returns the costs of the plan. Plans without Costs fall
+// back to the free-form cost metadata, e.g. "$0.00" or "$10.00/month".
+func (p Plan) CostEntries() []CostEntry {
+	if len(p.Costs) > 0 {
+		return p.Costs
+	}
+	cost, ok := p.Metadata[CostKey].(string)
+	if !ok {
+		return nil
+	}
+	entry, err := parseCost(cost)
+	if err != nil {
+		log.Warningf("plan %s has an invalid %s value %v - %v", p.Name, CostKey, cost, err)
+		return nil
+	}
+	return []CostEntry{entry}
+}
+
+func parseCost(cost string) (CostEntry, error) {
+	entry := CostEntry{}
+	value := strings.TrimSpace(cost)
+	if i := strings.Index(value, "/"); i >= 0 {
+		unit := strings.ToLower(strings.TrimSpace(value[i+1:]))
+		if u, ok := costUnits[unit]; ok {
+			entry.Unit = u
+		} else {
+			entry.Unit = strings.ToUpper(unit)
+		}
+		value = strings.TrimSpace(value[:i])
+	}
+	for symbol, code := range currencySymbols {
+		if strings.HasPrefix(value, symbol) {
+			entry.Currency = code
+			value = strings.TrimPrefix(value, symbol)
+			break
+		}
+	}
+	if entry.Currency == "" {
+		return entry, fmt.Errorf("unknown currency in %q", cost)
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return entry, fmt.Errorf("invalid amount in %q", cost)
+	}
+	entry.Amount = amount
+	return entry, nil
+}
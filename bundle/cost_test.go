@@ -0,0 +1,67 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanCostEntries(t *testing.T) {
+	testCases := []struct {
+		name     string
+		plan     Plan
+		expected []CostEntry
+	}{
+		{
+			name:     "no costs",
+			plan:     Plan{Name: "dev"},
+			expected: nil,
+		},
+		{
+			name: "structured costs",
+			plan: Plan{
+				Name:     "prod",
+				Metadata: map[string]interface{}{CostKey: "$1.00"},
+				Costs:    []CostEntry{{Amount: 10, Currency: "USD", Unit: "MONTHLY"}},
+			},
+			expected: []CostEntry{{Amount: 10, Currency: "USD", Unit: "MONTHLY"}},
+		},
+		{
+			name:     "free-form cost",
+			plan:     Plan{Name: "dev", Metadata: map[string]interface{}{CostKey: "$0.00"}},
+			expected: []CostEntry{{Amount: 0, Currency: "USD"}},
+		},
+		{
+			name:     "free-form cost with unit",
+			plan:     Plan{Name: "prod", Metadata: map[string]interface{}{CostKey: "€12.50/month"}},
+			expected: []CostEntry{{Amount: 12.5, Currency: "EUR", Unit: "MONTHLY"}},
+		},
+		{
+			name:     "invalid free-form cost",
+			plan:     Plan{Name: "prod", Metadata: map[string]interface{}{CostKey: "cheap"}},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.plan.CostEntries())
+		})
+	}
+}
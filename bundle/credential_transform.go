@@ -0,0 +1,176 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+)
+
+const (
+	// CredentialMappingMetadataKey - the plan metadata mapping binding
+	// credential keys to JSONPath expressions into the extracted
+	// credentials, e.g. username: $.DB_USER. Only dotted keys and [n]
+	// indexes are supported.
+	CredentialMappingMetadataKey = "bindCredentialMapping"
+	// CredentialTemplatesMetadataKey - the plan metadata mapping binding
+	// credential keys to Go templates executed with the extracted
+	// credentials, e.g.
+	// uri: postgres://{{.DB_USER}}:{{.DB_PASSWORD}}@{{.DB_HOST}}/{{.DB_NAME}}
+	CredentialTemplatesMetadataKey = "bindCredentialTemplates"
+	// CredentialPassthroughMetadataKey - the plan metadata that, when true,
+	// keeps the extracted credentials next to the transformed ones. By
+	// default a binding only gets the keys of the mapping and templates.
+	CredentialPassthroughMetadataKey = "bindCredentialPassthrough"
+)
+
+// ErrCredentialTransform - A binding credential declared by the plan could
+// not be computed from the extracted credentials.
+type ErrCredentialTransform struct {
+	Key string
+	Err error
+}
+
+func (e ErrCredentialTransform) Error() string {
+	return fmt.Sprintf("unable to compute the binding credential %v - %v", e.Key, e.Err)
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrCredentialTransform) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrCredentialTransform - true if the error is an ErrCredentialTransform.
+func IsErrCredentialTransform(err error) bool {
+	_, ok := err.(ErrCredentialTransform)
+	return ok
+}
+
+// transformBindCredentials - the credentials of a binding of the instance,
+// transformed as declared by the metadata of its plan. Plans without a
+// mapping or templates return the credentials unchanged.
+func (si *ServiceInstance) transformBindCredentials(creds map[string]interface{}) (map[string]interface{}, error) {
+	plan, ok := si.Spec.GetPlan(si.planName())
+	if !ok {
+		return creds, nil
+	}
+	return TransformCredentials(plan.Metadata, creds)
+}
+
+// TransformCredentials - applies the credential mapping and templates of
+// the plan metadata to the credentials.
+func TransformCredentials(metadata map[string]interface{}, creds map[string]interface{}) (map[string]interface{}, error) {
+	mapping := stringMap(metadata[CredentialMappingMetadataKey])
+	templates := stringMap(metadata[CredentialTemplatesMetadataKey])
+	if len(mapping) == 0 && len(templates) == 0 {
+		return creds, nil
+	}
+
+	transformed := map[string]interface{}{}
+	if passthrough, _ := metadata[CredentialPassthroughMetadataKey].(bool); passthrough {
+		for k, v := range creds {
+			transformed[k] = v
+		}
+	}
+	for key, path := range mapping {
+		value, err := evalPath(creds, path)
+		if err != nil {
+			return nil, ErrCredentialTransform{Key: key, Err: err}
+		}
+		transformed[key] = value
+	}
+	for key, text := range templates {
+		tmpl, err := template.New(key).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, ErrCredentialTransform{Key: key, Err: err}
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, creds); err != nil {
+			return nil, ErrCredentialTransform{Key: key, Err: err}
+		}
+		transformed[key] = buf.String()
+	}
+	return transformed, nil
+}
+
+// stringMap - the map of strings in the metadata, decoded from json or
+// yaml.
+func stringMap(value interface{}) map[string]string {
+	m := map[string]string{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			m[k] = fmt.Sprintf("%v", val)
+		}
+	case map[interface{}]interface{}:
+		for k, val := range v {
+			m[fmt.Sprintf("%v", k)] = fmt.Sprintf("%v", val)
+		}
+	case map[string]string:
+		for k, val := range v {
+			m[k] = val
+		}
+	}
+	return m
+}
+
+// evalPath - the value at the JSONPath in the credentials, e.g. $.db.host,
+// {.hosts[0]} or DB_USER.
+func evalPath(creds map[string]interface{}, path string) (interface{}, error) {
+	p := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(path), "{"), "}")
+	p = strings.TrimPrefix(strings.TrimPrefix(p, "$"), ".")
+	var current interface{} = creds
+	for _, segment := range strings.Split(p, ".") {
+		name := segment
+		indexes := []int{}
+		if i := strings.Index(segment, "["); i >= 0 {
+			name = segment[:i]
+			for _, idx := range strings.Split(strings.TrimSuffix(segment[i+1:], "]"), "][") {
+				n, err := strconv.Atoi(idx)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q in %v", idx, path)
+				}
+				indexes = append(indexes, n)
+			}
+		}
+		if name != "" {
+			var ok bool
+			switch m := current.(type) {
+			case map[string]interface{}:
+				current, ok = m[name]
+			case map[interface{}]interface{}:
+				current, ok = m[name]
+			}
+			if !ok {
+				return nil, fmt.Errorf("%v not found in %v", name, path)
+			}
+		}
+		for _, n := range indexes {
+			list, ok := current.([]interface{})
+			if !ok || n < 0 || n >= len(list) {
+				return nil, fmt.Errorf("index %d not found in %v", n, path)
+			}
+			current = list[n]
+		}
+	}
+	return current, nil
+}
@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransformCredentials(t *testing.T) {
+	creds := map[string]interface{}{
+		"DB_USER":     "admin",
+		"DB_PASSWORD": "p@ss",
+		"db":          map[string]interface{}{"hosts": []interface{}{"primary", "replica"}},
+	}
+	testCases := []struct {
+		name     string
+		metadata map[string]interface{}
+		expected map[string]interface{}
+		err      bool
+	}{
+		{
+			name:     "no transformation",
+			metadata: map[string]interface{}{"displayName": "Dev"},
+			expected: creds,
+		},
+		{
+			name: "mapping and templates",
+			metadata: map[string]interface{}{
+				CredentialMappingMetadataKey: map[interface{}]interface{}{
+					"username": "$.DB_USER",
+					"host":     "{.db.hosts[1]}",
+				},
+				CredentialTemplatesMetadataKey: map[string]interface{}{
+					"uri": "postgres://{{.DB_USER}}:{{urlquery .DB_PASSWORD}}@db/sampledb",
+				},
+			},
+			expected: map[string]interface{}{
+				"username": "admin",
+				"host":     "replica",
+				"uri":      "postgres://admin:p%40ss@db/sampledb",
+			},
+		},
+		{
+			name: "passthrough",
+			metadata: map[string]interface{}{
+				CredentialMappingMetadataKey:     map[string]interface{}{"username": "DB_USER"},
+				CredentialPassthroughMetadataKey: true,
+			},
+			expected: map[string]interface{}{
+				"username":    "admin",
+				"DB_USER":     "admin",
+				"DB_PASSWORD": "p@ss",
+				"db":          creds["db"],
+			},
+		},
+		{
+			name: "missing key",
+			metadata: map[string]interface{}{
+				CredentialMappingMetadataKey: map[string]interface{}{"host": "$.db.hosts[2]"},
+			},
+			err: true,
+		},
+		{
+			name: "missing template key",
+			metadata: map[string]interface{}{
+				CredentialTemplatesMetadataKey: map[string]interface{}{"uri": "{{.DB_NAME}}"},
+			},
+			err: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transformed, err := TransformCredentials(tc.metadata, creds)
+			if tc.err {
+				if !IsErrCredentialTransform(err) {
+					t.Fatalf("expected a credential transform error got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, tc.expected, transformed)
+		})
+	}
+}
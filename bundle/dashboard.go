@@ -0,0 +1,98 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"text/template"
+
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+// DashboardURLVars - The variables of a dashboard URL template, e.g.
+//
+//	dashboardUrlTemplate: https://{{.Parameters.app_name}}-{{.Namespace}}.{{.IngressDomain}}/
+type DashboardURLVars struct {
+	// InstanceID - the ID of the service instance.
+	InstanceID string
+	// Namespace - the namespace the service was provisioned into.
+	Namespace string
+	// IngressDomain - the ingress domain of the cluster.
+	IngressDomain string
+	// Name - the FQName of the spec.
+	Name string
+	// Platform - the platform of the cluster, kubernetes or openshift.
+	Platform string
+	// Parameters - the parameters of the service instance.
+	Parameters Parameters
+}
+
+// renderDashboardURL - renders the dashboard URL template, the result must
+// be an absolute URL.
+func renderDashboardURL(tmpl string, vars DashboardURLVars) (string, error) {
+	t, err := template.New("dashboardUrlTemplate").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	buf := bytes.Buffer{}
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	u, err := url.Parse(buf.String())
+	if err != nil {
+		return "", err
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return "", fmt.Errorf("dashboard url %q is not an absolute url", buf.String())
+	}
+	return u.String(), nil
+}
+
+// dashboardURLVars - the template variables for the service instance.
+func dashboardURLVars(instance *ServiceInstance) DashboardURLVars {
+	vars := DashboardURLVars{
+		InstanceID:    instance.ID.String(),
+		IngressDomain: clusterConfig.IngressDomain,
+		Name:          instance.Spec.FQName,
+		Parameters:    Parameters{},
+	}
+	if instance.Context != nil {
+		vars.Namespace = instance.Context.Namespace
+		vars.Platform = instance.Context.Platform
+	}
+	if instance.Parameters != nil && *instance.Parameters != nil {
+		vars.Parameters = *instance.Parameters
+	}
+	return vars
+}
+
+// applyDashboardURLTemplate - sets the dashboard URL from the template of
+// the spec when the bundle did not report one. A template that fails to
+// render does not fail the action.
+func (e *executor) applyDashboardURLTemplate(instance *ServiceInstance) {
+	if instance.Spec.DashboardURLTemplate == "" || e.dashboardURL != "" {
+		return
+	}
+	dashboardURL, err := renderDashboardURL(instance.Spec.DashboardURLTemplate, dashboardURLVars(instance))
+	if err != nil {
+		log.Warningf("unable to render the dashboard url template of %v - %v", instance.Spec.FQName, err)
+		return
+	}
+	e.dashboardURL = dashboardURL
+}
@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderDashboardURL(t *testing.T) {
+	vars := DashboardURLVars{
+		InstanceID:    "1234",
+		Namespace:     "project",
+		IngressDomain: "apps.example.com",
+		Name:          "dh-postgresql-apb",
+		Platform:      "openshift",
+		Parameters:    Parameters{"app_name": "pg"},
+	}
+	testCases := []struct {
+		name      string
+		template  string
+		expected  string
+		shouldErr bool
+	}{
+		{
+			name:     "namespace and ingress domain",
+			template: "https://{{.Namespace}}.{{.IngressDomain}}/{{.InstanceID}}",
+			expected: "https://project.apps.example.com/1234",
+		},
+		{
+			name:     "parameters",
+			template: "https://{{.Parameters.app_name}}-{{.Namespace}}.{{.IngressDomain}}/",
+			expected: "https://pg-project.apps.example.com/",
+		},
+		{
+			name:      "missing parameter",
+			template:  "https://{{.Parameters.unknown}}.{{.IngressDomain}}/",
+			shouldErr: true,
+		},
+		{
+			name:      "invalid template",
+			template:  "https://{{.Namespace",
+			shouldErr: true,
+		},
+		{
+			name:      "relative url",
+			template:  "/{{.Namespace}}",
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dashboardURL, err := renderDashboardURL(tc.template, vars)
+			if tc.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, tc.expected, dashboardURL)
+		})
+	}
+}
+
+func TestApplyDashboardURLTemplate(t *testing.T) {
+	InitializeClusterConfig(ClusterConfig{IngressDomain: "apps.example.com"})
+	defer InitializeClusterConfig(ClusterConfig{})
+
+	id := uuid.NewUUID()
+	instance := &ServiceInstance{
+		ID:      id,
+		Spec:    &Spec{FQName: "foo", DashboardURLTemplate: "https://{{.Namespace}}.{{.IngressDomain}}/"},
+		Context: &Context{Namespace: "project", Platform: "kubernetes"},
+	}
+
+	e := &executor{}
+	e.applyDashboardURLTemplate(instance)
+	assert.Equal(t, "https://project.apps.example.com/", e.DashboardURL())
+
+	// a dashboard url reported by the bundle wins
+	e = &executor{dashboardURL: "https://reported.example.com/"}
+	e.applyDashboardURLTemplate(instance)
+	assert.Equal(t, "https://reported.example.com/", e.DashboardURL())
+}
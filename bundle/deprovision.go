@@ -18,17 +18,55 @@ package bundle
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/automationbroker/bundle-lib/runtime"
-	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
 )
 
 const (
 	deprovisionAction = "deprovision"
 )
 
+// ErrBindingsExist - The service instance was not deprovisioned because it
+// still has active bindings. Unbind first or deprovision with Force.
+type ErrBindingsExist struct {
+	InstanceID string
+	BindingIDs []string
+}
+
+func (e ErrBindingsExist) Error() string {
+	return fmt.Sprintf("service instance %v still has active bindings [%v]",
+		e.InstanceID, strings.Join(e.BindingIDs, ", "))
+}
+
+// ErrorCode - the error is of the Conflict class.
+func (e ErrBindingsExist) ErrorCode() liberrors.Code {
+	return liberrors.CodeConflict
+}
+
+// IsErrBindingsExist - true if the error is an ErrBindingsExist.
+func IsErrBindingsExist(err error) bool {
+	_, ok := err.(ErrBindingsExist)
+	return ok
+}
+
+// activeBindingIDs - the sorted IDs of the bindings of the instance that
+// are not deleted.
+func activeBindingIDs(instance *ServiceInstance) []string {
+	ids := []string{}
+	for id, active := range instance.BindingIDs {
+		if active {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 // Deprovision - runs the abp with the deprovision action.
 func (e *executor) Deprovision(instance *ServiceInstance) <-chan StatusMessage {
 	log.Infof("============================================================")
@@ -40,82 +78,118 @@ func (e *executor) Deprovision(instance *ServiceInstance) <-chan StatusMessage {
 	log.Infof("ServiceInstance.Description: %s", instance.Spec.Description)
 	log.Infof("============================================================")
 
-	go func() {
+	return e.runAction(instance, func() {
+		e.startActionSpan(deprovisionAction, instance)
+		e.startOperation(deprovisionAction, instance, instance.Parameters)
+		e.startEvent(deprovisionAction, instance, "")
 		e.actionStarted()
-		if instance.Spec.Image == "" {
-			log.Error("No image field found on the apb instance.Spec (apb.yaml)")
-			log.Error("apb instance.Spec requires [name] and [image] fields to be separate")
-			log.Error("Are you trying to run a legacy ansibleapp without an image field?")
-			e.actionFinishedWithError(errors.New("No image field found on instance.Spec"))
-			return
-		}
-		// Create namespace name that will be used to generate a name.
-		ns := fmt.Sprintf("%s-%.4s-", instance.Spec.FQName, deprovisionAction)
-		// Determine if we should be using the context namespace from the executor config.
-		if e.skipCreateNS {
-			ns = instance.Context.Namespace
-		}
-		// Create the podname
-		pn := fmt.Sprintf("bundle-%s", uuid.New())
-		targets := []string{instance.Context.Namespace}
-		labels := map[string]string{
-			"bundle-fqname":   instance.Spec.FQName,
-			"bundle-action":   deprovisionAction,
-			"bundle-pod-name": pn,
+		if e.checkBindings && !e.force {
+			if ids := activeBindingIDs(instance); len(ids) > 0 {
+				err := ErrBindingsExist{InstanceID: instance.ID.String(), BindingIDs: ids}
+				log.Errorf("Refusing to deprovision - %v", err)
+				e.actionFinishedWithError(err)
+				return
+			}
 		}
-		serviceAccount, namespace, err := runtime.Provider.CreateSandbox(pn, ns, targets, clusterConfig.SandboxRole, labels)
-		if err != nil {
-			log.Errorf("Problem executing bundle create sandbox [%s] deprovision", pn)
+		if err := e.deprovisionInstance(instance); err != nil {
 			e.actionFinishedWithError(err)
 			return
 		}
-		ec := runtime.ExecutionContext{
-			BundleName: pn,
-			Targets:    targets,
-			Metadata:   labels,
-			Action:     deprovisionAction,
-			Image:      instance.Spec.Image,
-			Account:    serviceAccount,
-			Location:   namespace,
-		}
-		ec, err = e.executeApb(ec, instance, instance.Parameters)
-
-		defer runtime.Provider.DestroySandbox(
-			ec.BundleName,
-			ec.Location,
-			ec.Targets,
-			clusterConfig.Namespace,
-			clusterConfig.KeepNamespace,
-			clusterConfig.KeepNamespaceOnError,
-		)
-
-		defer func() {
-			if err := e.stateManager.DeleteState(e.stateManager.MasterName(instance.ID.String())); err != nil {
-				log.Errorf("failed to delete state for instance %s : %v ", instance.ID.String(), err)
-			}
-		}()
-
-		if err != nil {
-			log.Errorf("Problem executing bundle [%s] deprovision", ec.BundleName)
+		if err := e.deprovisionRequirements(instance); err != nil {
+			log.Errorf("unable to deprovision the requirements of %v - %v", instance.Spec.FQName, err)
 			e.actionFinishedWithError(err)
 			return
 		}
+		e.actionFinishedWithSuccess()
+	})
+}
 
-		err = runtime.Provider.WatchRunningBundle(ec.BundleName, ec.Location, e.updateDescription)
-		if err != nil {
-			log.Errorf("Deprovision action failed - %v", err)
-			e.actionFinishedWithError(err)
-			return
+// deprovisionInstance - deprovisions the instance, its scheduled actions,
+// operator or bundle, its state and its extracted credentials.
+func (e *executor) deprovisionInstance(instance *ServiceInstance) error {
+	if len(instance.Spec.Actions) > 0 {
+		if err := UnscheduleActions(instance); err != nil {
+			log.Errorf("unable to unschedule the actions of instance %v - %v", instance.ID.String(), err)
+			return err
 		}
-		err = runtime.Provider.DeleteExtractedCredential(instance.ID.String(), clusterConfig.Namespace)
-		if err != nil {
-			log.Errorf("unable to delete the extracted credentials - %v", err)
-			e.actionFinishedWithError(err)
-			return
+	}
+	if instance.Spec.Operator != nil {
+		if err := runtime.Provider.UninstallOperator(operatorSubscription(instance)); err != nil {
+			log.Errorf("unable to uninstall the operator - %v", err)
+			return err
 		}
+		return nil
+	}
+	if instance.Spec.Image == "" && instance.Spec.requiresImage() {
+		log.Error("No image field found on the apb instance.Spec (apb.yaml)")
+		log.Error("apb instance.Spec requires [name] and [image] fields to be separate")
+		log.Error("Are you trying to run a legacy ansibleapp without an image field?")
+		return liberrors.New(liberrors.CodeValidation, "No image field found on instance.Spec")
+	}
+	// Create namespace name that will be used to generate a name.
+	ns := fmt.Sprintf("%s-%.4s-", instance.Spec.FQName, deprovisionAction)
+	// Determine if we should be using the context namespace from the executor config.
+	if e.skipCreateNS {
+		ns = instance.Context.Namespace
+	}
+	// Create the podname
+	pn, key := e.newSandboxName(instance, deprovisionAction)
+	targets := instance.Context.TargetNamespaces()
+	labels := map[string]string{
+		"bundle-fqname":   instance.Spec.FQName,
+		"bundle-action":   deprovisionAction,
+		"bundle-pod-name": pn,
+	}
+	labels = sandboxLabels(labels, instance.Labels)
+	labels[runtime.IdempotencyKeyLabel] = key
+	serviceAccount, namespace, err := e.createSandbox(pn, ns, targets, labels)
+	if err != nil {
+		log.Errorf("Problem executing bundle create sandbox [%s] deprovision", pn)
+		return err
+	}
+	ec := runtime.ExecutionContext{
+		BundleName:     pn,
+		Targets:        targets,
+		Metadata:       labels,
+		Annotations:    instance.Annotations,
+		Action:         deprovisionAction,
+		Image:          instance.Spec.Image,
+		RuntimeVersion: instance.Spec.Runtime,
+		Account:        serviceAccount,
+		Location:       namespace,
+		IdempotencyKey: key,
+	}
+	ec, err = e.executeApb(ec, instance, instance.Parameters)
 
-		e.actionFinishedWithSuccess()
+	defer runtime.Provider.DestroySandbox(
+		ec.BundleName,
+		ec.Location,
+		ec.Targets,
+		clusterConfig.Namespace,
+		clusterConfig.KeepNamespace,
+		clusterConfig.KeepNamespaceOnError,
+	)
+
+	defer func() {
+		if err := e.stateManager.DeleteState(e.stateManager.MasterName(instance.ID.String())); err != nil {
+			log.Errorf("failed to delete state for instance %s : %v ", instance.ID.String(), err)
+		}
 	}()
 
-	return e.statusChan
+	if err != nil {
+		log.Errorf("Problem executing bundle [%s] deprovision", ec.BundleName)
+		return err
+	}
+
+	err = e.watchRunningBundle(ec.BundleName, ec.Location)
+	if err != nil {
+		log.Errorf("Deprovision action failed - %v", err)
+		return err
+	}
+	err = runtime.Provider.DeleteExtractedCredential(instance.ID.String(), clusterConfig.Namespace)
+	if err != nil {
+		log.Errorf("unable to delete the extracted credentials - %v", err)
+		return err
+	}
+	return nil
 }
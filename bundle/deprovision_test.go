@@ -304,6 +304,79 @@ func TestDeprovision(t *testing.T) {
 				return true
 			},
 		},
+		{
+			name:   "deprovision unsuccessfully bindings exist",
+			config: ExecutorConfig{CheckBindings: true},
+			rt:     *new(runtime.MockRuntime),
+			si: ServiceInstance{
+				ID: u,
+				Spec: &Spec{
+					ID:      "new-spec-id",
+					Image:   "new-image",
+					FQName:  "new-fq-name",
+					Runtime: 2,
+				},
+				Context: &Context{
+					Namespace: "target",
+					Platform:  "kubernetes",
+				},
+				Parameters: &Parameters{"test-param": true},
+				BindingIDs: map[string]bool{"binding-2": true, "binding-1": true, "deleted": false},
+			},
+			validateMessage: func(m []StatusMessage) bool {
+				if len(m) != 2 {
+					return false
+				}
+				if m[0].State != StateInProgress || m[1].State != StateFailed {
+					return false
+				}
+				err, ok := m[1].Error.(ErrBindingsExist)
+				if !ok {
+					return false
+				}
+				return len(err.BindingIDs) == 2 &&
+					err.BindingIDs[0] == "binding-1" && err.BindingIDs[1] == "binding-2"
+			},
+		},
+		{
+			name:   "deprovision successfully bindings exist with force",
+			config: ExecutorConfig{CheckBindings: true, Force: true},
+			rt:     *new(runtime.MockRuntime),
+			si: ServiceInstance{
+				ID: u,
+				Spec: &Spec{
+					ID:      "new-spec-id",
+					Image:   "new-image",
+					FQName:  "new-fq-name",
+					Runtime: 2,
+				},
+				Context: &Context{
+					Namespace: "target",
+					Platform:  "kubernetes",
+				},
+				Parameters: &Parameters{"test-param": true},
+				BindingIDs: map[string]bool{"binding-1": true},
+			},
+			addExpectations: func(rt *runtime.MockRuntime, e Executor) {
+				rt.On("CreateSandbox", mock.Anything, mock.Anything, []string{"target"}, mock.Anything, mock.Anything).Return("service-account-1", "location", nil)
+				rt.On("GetRuntime").Return("kubernetes")
+				rt.On("CopySecretsToNamespace", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				rt.On("MasterName", u.String()).Return("new-master-name")
+				rt.On("MasterNamespace").Return("new-masternamespace")
+				rt.On("StateIsPresent", "new-master-name").Return(false, nil)
+				rt.On("RunBundle", mock.Anything).Return(runtime.ExecutionContext{}, nil)
+				rt.On("DeleteState", "new-master-name").Return(nil)
+				rt.On("WatchRunningBundle", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				rt.On("DestroySandbox", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				rt.On("DeleteExtractedCredential", u.String(), mock.Anything).Return(nil)
+			},
+			validateMessage: func(m []StatusMessage) bool {
+				if len(m) != 2 {
+					return false
+				}
+				return m[0].State == StateInProgress && m[1].State == StateSucceeded
+			},
+		},
 		{
 			name: "deprovision unsuccessfully sandbox fail",
 			config: ExecutorConfig{
@@ -0,0 +1,120 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"strings"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+const (
+	// ImageDriftIgnore - the image is run without checking its tag.
+	ImageDriftIgnore = "ignore"
+	// ImageDriftWarn - a tag pushed again since the spec was loaded is
+	// logged and the image is run.
+	ImageDriftWarn = "warn"
+	// ImageDriftFail - a tag pushed again since the spec was loaded, or
+	// that can not be resolved, fails the action with ErrImageDrift.
+	ImageDriftFail = "fail"
+)
+
+// DigestResolver - Resolves the tag of the image of a spec to the digest
+// the registry records in the Provenance of the spec, at the time the
+// action runs, e.g. registries.NewDigestResolver.
+type DigestResolver interface {
+	ResolveDigest(spec *Spec) (string, error)
+}
+
+// DigestResolverFunc - Adapts a function to a DigestResolver.
+type DigestResolverFunc func(spec *Spec) (string, error)
+
+// ResolveDigest - calls the function.
+func (f DigestResolverFunc) ResolveDigest(spec *Spec) (string, error) {
+	return f(spec)
+}
+
+// ErrImageDrift - The tag of the image of the bundle was pushed again
+// since the spec was loaded, the image that would run is not the one in
+// the catalog.
+type ErrImageDrift struct {
+	Image    string
+	Recorded string
+	Current  string
+}
+
+func (e ErrImageDrift) Error() string {
+	return fmt.Sprintf("image %v changed since the catalog was loaded: digest %v, was %v",
+		e.Image, e.Current, e.Recorded)
+}
+
+// ErrorCode - the error is of the Conflict class.
+func (e ErrImageDrift) ErrorCode() liberrors.Code {
+	return liberrors.CodeConflict
+}
+
+// IsErrImageDrift - true if the error is an ErrImageDrift.
+func IsErrImageDrift(err error) bool {
+	_, ok := err.(ErrImageDrift)
+	return ok
+}
+
+// CheckImageDrift - compares the digest of the tag of the image of the spec
+// with the one recorded when the spec was loaded and returns the image to
+// run. When they match the image is pinned to the digest, so that the tag
+// can not be pushed again before the bundle runs. With ImageDriftFail it
+// returns ErrImageDrift when they differ, or the error of the resolver.
+// Specs without a recorded digest and images pinned to a digest are not
+// checked and run as they are.
+func CheckImageDrift(resolver DigestResolver, policy string, spec *Spec) (string, error) {
+	if resolver == nil || policy == ImageDriftIgnore || spec.Image == "" || strings.Contains(spec.Image, "@") {
+		return spec.Image, nil
+	}
+	recorded := ImageReferenceOf(spec).Digest
+	if recorded == "" {
+		return spec.Image, nil
+	}
+	current, err := resolver.ResolveDigest(spec)
+	if err != nil {
+		log.Warningf("unable to resolve the digest of image %v - %v", spec.Image, err)
+		if policy == ImageDriftFail {
+			return "", err
+		}
+		return spec.Image, nil
+	}
+	if current == recorded {
+		return pinImage(spec.Image, current), nil
+	}
+	drift := ErrImageDrift{Image: spec.Image, Recorded: recorded, Current: current}
+	if policy == ImageDriftFail {
+		return "", drift
+	}
+	log.Warning(drift.Error())
+	return spec.Image, nil
+}
+
+// pinImage - the image with its tag replaced by the digest,
+// repository@sha256:...
+func pinImage(image, digest string) string {
+	repo := image
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repo = image[:i]
+	}
+	return repo + "@" + digest
+}
@@ -0,0 +1,80 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckImageDrift(t *testing.T) {
+	resolver := DigestResolverFunc(func(spec *Spec) (string, error) {
+		if spec.Image == "docker.io/unknown/apb:latest" {
+			return "", fmt.Errorf("manifest unknown")
+		}
+		return "sha256:new", nil
+	})
+	spec := func(image, digest string) *Spec {
+		return &Spec{Image: image, Provenance: &Provenance{Digest: digest}}
+	}
+
+	testCases := []struct {
+		name     string
+		resolver DigestResolver
+		policy   string
+		spec     *Spec
+		drift    bool
+		err      bool
+		image    string
+	}{
+		{name: "tag not pushed again", resolver: resolver, policy: ImageDriftFail, spec: spec("docker.io/foo/apb:latest", "sha256:new"), image: "docker.io/foo/apb@sha256:new"},
+		{name: "tag pushed again", resolver: resolver, policy: ImageDriftFail, spec: spec("docker.io/foo/apb:latest", "sha256:old"), drift: true},
+		{name: "tag pushed again with warn", resolver: resolver, policy: ImageDriftWarn, spec: spec("docker.io/foo/apb:latest", "sha256:old"), image: "docker.io/foo/apb:latest"},
+		{name: "ignored", resolver: resolver, policy: ImageDriftIgnore, spec: spec("docker.io/foo/apb:latest", "sha256:old"), image: "docker.io/foo/apb:latest"},
+		{name: "no resolver", policy: ImageDriftFail, spec: spec("docker.io/foo/apb:latest", "sha256:old"), image: "docker.io/foo/apb:latest"},
+		{name: "no recorded digest", resolver: resolver, policy: ImageDriftFail, spec: &Spec{Image: "docker.io/foo/apb:latest"}, image: "docker.io/foo/apb:latest"},
+		{name: "pinned image", resolver: resolver, policy: ImageDriftFail, spec: spec("docker.io/foo/apb@sha256:old", "sha256:old"), image: "docker.io/foo/apb@sha256:old"},
+		{name: "unresolved with fail", resolver: resolver, policy: ImageDriftFail, spec: spec("docker.io/unknown/apb:latest", "sha256:old"), err: true},
+		{name: "unresolved with warn", resolver: resolver, policy: ImageDriftWarn, spec: spec("docker.io/unknown/apb:latest", "sha256:old"), image: "docker.io/unknown/apb:latest"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			image, err := CheckImageDrift(tc.resolver, tc.policy, tc.spec)
+			switch {
+			case tc.drift:
+				if !IsErrImageDrift(err) {
+					t.Fatalf("expected an image drift got: %v", err)
+				}
+				assert.Equal(t, "sha256:old", err.(ErrImageDrift).Recorded)
+				assert.Equal(t, "sha256:new", err.(ErrImageDrift).Current)
+			case tc.err:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tc.image, image)
+			}
+		})
+	}
+}
+
+func TestPinImage(t *testing.T) {
+	assert.Equal(t, "docker.io/foo/apb@sha256:abc", pinImage("docker.io/foo/apb:latest", "sha256:abc"))
+	assert.Equal(t, "registry:5000/foo/apb@sha256:abc", pinImage("registry:5000/foo/apb", "sha256:abc"))
+	assert.Equal(t, "registry:5000/foo/apb@sha256:abc", pinImage("registry:5000/foo/apb:v1", "sha256:abc"))
+}
@@ -17,13 +17,20 @@
 package bundle
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
+	"fmt"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/automationbroker/bundle-lib/authorization"
+	"github.com/automationbroker/bundle-lib/clock"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	"github.com/automationbroker/bundle-lib/idgen"
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/automationbroker/bundle-lib/runtime"
-	log "github.com/sirupsen/logrus"
+	"github.com/pborman/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ExecutorAccessors - Accessors for Executor state.
@@ -43,6 +50,13 @@ type ExecutorAsync interface {
 	Update(instance *ServiceInstance) <-chan StatusMessage
 }
 
+// ExecutorPreview - Reports what the actions would do without running them.
+// It is not part of Executor, the executors returned by NewExecutor
+// implement it and callers type-assert to it.
+type ExecutorPreview interface {
+	Preview(instance *ServiceInstance) (*PreviewReport, error)
+}
+
 //go:generate mockery -name=Executor -case=underscore -inpkg -note=Generated
 
 // Executor - Composite executor interface.
@@ -60,6 +74,35 @@ type executor struct {
 	mutex                sync.Mutex
 	stateManager         runtime.StateManager
 	skipCreateNS         bool
+	authorizer           authorization.Authorizer
+	imagePolicy          ImagePolicy
+	quotaChecker         QuotaChecker
+	checkBindings        bool
+	force                bool
+	ctx                  context.Context
+	// span and spanCtx - the span of the running action and the context
+	// holding it, the parent of the spans of the steps of the action.
+	span    trace.Span
+	spanCtx context.Context
+	// actionCtx - the context of the running action, cancelled when the
+	// action is abandoned on shutdown.
+	actionCtx         context.Context
+	recordHistory     bool
+	operation         *runtime.Operation
+	operationInstance string
+	statusPolicy      string
+	// namespaceLabels and namespaceAnnotations - the keys read from the
+	// target namespace, see namespaceMetadata.
+	namespaceLabels      []string
+	namespaceAnnotations []string
+	specResolver         SpecResolver
+	notifier             Notifier
+	event                *Event
+	digestResolver       DigestResolver
+	imageDriftPolicy     string
+	clock                clock.Clock
+	ids                  idgen.Generator
+	attempt              int
 }
 
 // ExecutorConfig - configuration for the executor.
@@ -67,15 +110,121 @@ type ExecutorConfig struct {
 	// This will tell the executor to use the context namespace as the
 	// namespace for the bundle to be created in.
 	SkipCreateNS bool
+	// Authorizer - optional authorizer that is consulted with the UserInfo
+	// of the service instance before provision, bind and update are run.
+	Authorizer authorization.Authorizer
+	// Context - optional context used as the parent of the tracing spans
+	// created for the actions run by the executor.
+	Context context.Context
+	// CheckBindings - refuse to deprovision a service instance that still
+	// has active bindings, the deprovision fails with ErrBindingsExist.
+	CheckBindings bool
+	// Force - deprovision even if CheckBindings finds active bindings.
+	Force bool
+	// RecordHistory - add the actions run by the executor to the history
+	// of the service instance, see History.
+	RecordHistory bool
+	// ImagePolicy - optional policy consulted with the image of the bundle
+	// before provision, update and bind are run.
+	ImagePolicy ImagePolicy
+	// QuotaChecker - optional checker that can veto provision and update
+	// with ErrQuotaExceeded.
+	QuotaChecker QuotaChecker
+	// StatusBuffer - the status messages held by the status channel for a
+	// slow consumer. Defaults to 0, an unbuffered channel.
+	StatusBuffer int
+	// StatusPolicy - what happens to a progress message when the status
+	// channel is full, one of block, drop or coalesce. Defaults to block.
+	// The final status of an action is always delivered, see
+	// StatusPolicyBlock.
+	StatusPolicy string
+	// NamespaceLabels and NamespaceAnnotations - the keys of the labels and
+	// annotations read from the target namespace. They are passed to the
+	// bundle as the namespace_labels and namespace_annotations parameters
+	// and to a ContextAuthorizer, so that the action can adapt to the
+	// policy of the namespace. Keys missing on the namespace are skipped.
+	NamespaceLabels      []string
+	NamespaceAnnotations []string
+	// SpecResolver - finds the specs required by the spec of an instance,
+	// which are provisioned before it. Provisioning a spec with
+	// requirements fails without one.
+	SpecResolver SpecResolver
+	// Notifier - optional sink of the started, succeeded and failed events
+	// of the provision, update, bind, unbind and deprovision actions, e.g.
+	// a WebhookNotifier.
+	Notifier Notifier
+	// DigestResolver - optional resolver of the tag of the image of the
+	// bundle, checked against the digest recorded in the catalog before
+	// provision, update and bind are run.
+	DigestResolver DigestResolver
+	// ImageDriftPolicy - what happens when the tag was pushed again since
+	// the catalog was loaded, one of ignore, warn or fail. Defaults to
+	// warn.
+	ImageDriftPolicy string
+	// Clock - optional source of the timestamps recorded in the history and
+	// the events of the actions. Defaults to clock.Real.
+	Clock clock.Clock
+	// IDs - optional source of the UUIDs used for the names of the bundle
+	// pods and the IDs of the required instances. Defaults to idgen.Random.
+	IDs idgen.Generator
+	// Attempt - the attempt of the action, counted by the caller from 1.
+	// When set, the bundle pod is named after the runtime.IdempotencyKey of
+	// the instance, action and attempt, so that a retry of the attempt
+	// adopts the sandbox created by the earlier try. Defaults to 0, a new
+	// pod name per action.
+	Attempt int
 }
 
 // NewExecutor - Creates a new Executor for running an APB.
 func NewExecutor(config ExecutorConfig) Executor {
+	ctx := config.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	buffer := config.StatusBuffer
+	if buffer < 0 {
+		log.Warningf("invalid status buffer %v, using an unbuffered status channel", buffer)
+		buffer = 0
+	}
+	policy := config.StatusPolicy
+	switch policy {
+	case "", StatusPolicyBlock, StatusPolicyDrop, StatusPolicyCoalesce:
+	default:
+		log.Warningf("unknown status policy %v, using %v", policy, StatusPolicyBlock)
+		policy = StatusPolicyBlock
+	}
+	driftPolicy := config.ImageDriftPolicy
+	switch driftPolicy {
+	case ImageDriftIgnore, ImageDriftWarn, ImageDriftFail:
+	case "":
+		driftPolicy = ImageDriftWarn
+	default:
+		log.Warningf("unknown image drift policy %v, using %v", driftPolicy, ImageDriftWarn)
+		driftPolicy = ImageDriftWarn
+	}
 	return &executor{
-		statusChan:   make(chan StatusMessage),
-		lastStatus:   StatusMessage{State: StateNotYetStarted},
-		skipCreateNS: config.SkipCreateNS,
-		stateManager: runtime.Provider,
+		statusChan:    make(chan StatusMessage, buffer),
+		statusPolicy:  policy,
+		lastStatus:    StatusMessage{State: StateNotYetStarted},
+		skipCreateNS:  config.SkipCreateNS,
+		stateManager:  runtime.Provider,
+		authorizer:    config.Authorizer,
+		imagePolicy:   config.ImagePolicy,
+		quotaChecker:  config.QuotaChecker,
+		checkBindings: config.CheckBindings,
+		force:         config.Force,
+		recordHistory: config.RecordHistory,
+		ctx:           ctx,
+
+		namespaceLabels:      config.NamespaceLabels,
+		namespaceAnnotations: config.NamespaceAnnotations,
+		specResolver:         config.SpecResolver,
+		notifier:             config.Notifier,
+		digestResolver:       config.DigestResolver,
+		imageDriftPolicy:     driftPolicy,
+		clock:                clock.OrReal(config.Clock),
+		ids:                  idgen.OrRandom(config.IDs),
+		attempt:              config.Attempt,
 	}
 }
 
@@ -100,23 +249,47 @@ func (e *executor) ExtractedCredentials() *ExtractedCredentials {
 	return e.extractedCredentials
 }
 
+// now - the current time of the clock of the executor.
+func (e *executor) now() time.Time {
+	return clock.OrReal(e.clock).Now()
+}
+
+// newUUID - the next UUID of the generator of the executor.
+func (e *executor) newUUID() uuid.UUID {
+	return idgen.OrRandom(e.ids).NewUUID()
+}
+
+// newSandboxName - the name of the pod running the action on the instance
+// and the idempotency key of its sandbox. Without an attempt the pod name
+// is new for every action.
+func (e *executor) newSandboxName(instance *ServiceInstance, action string) (string, string) {
+	key := runtime.IdempotencyKey(instance.ID.String(), action, e.attempt)
+	if e.attempt > 0 {
+		return fmt.Sprintf("bundle-%s", key), key
+	}
+	return fmt.Sprintf("bundle-%s", e.newUUID()), key
+}
+
 func (e *executor) actionStarted() {
 	log.Debug("executor::actionStarted")
 	e.lastStatus.State = StateInProgress
 	e.lastStatus.Description = "action started"
-	e.statusChan <- e.lastStatus
+	e.sendStatus(e.lastStatus)
 }
 
 func (e *executor) actionFinishedWithSuccess() {
+	e.finishEvent(nil)
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
 	log.Debug("executor::actionFinishedWithSuccess")
+	e.endActionSpan(nil)
+	e.finishOperation(nil)
 
 	if e.statusChan != nil {
 		e.lastStatus.State = StateSucceeded
 		e.lastStatus.Description = "action finished with success"
-		e.statusChan <- e.lastStatus
+		e.sendStatus(e.lastStatus)
 		close(e.statusChan)
 		e.statusChan = nil
 	} else {
@@ -125,16 +298,24 @@ func (e *executor) actionFinishedWithSuccess() {
 }
 
 func (e *executor) actionFinishedWithError(err error) {
+	e.finishEvent(err)
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
 	log.Debugf("executor::actionFinishedWithError[ %v ]", err.Error())
+	e.endActionSpan(err)
+	e.finishOperation(err)
 
 	if e.statusChan != nil {
 		e.lastStatus.State = StateFailed
 		e.lastStatus.Error = err
+		e.lastStatus.FailureReason = failureReason(err)
 		e.lastStatus.Description = "action finished with error"
-		e.statusChan <- e.lastStatus
+		switch e.lastStatus.FailureReason {
+		case runtime.FailureReasonPreflight, runtime.FailureReasonInternal:
+			e.lastStatus.Description = err.Error()
+		}
+		e.sendStatus(e.lastStatus)
 		close(e.statusChan)
 		e.statusChan = nil
 	} else {
@@ -142,12 +323,20 @@ func (e *executor) actionFinishedWithError(err error) {
 	}
 }
 
+// failureReason - the failure reason of the error an action failed with.
+func failureReason(err error) runtime.FailureReason {
+	if IsErrorActionPanicked(err) {
+		return runtime.FailureReasonInternal
+	}
+	return runtime.FailureReasonOf(err)
+}
+
 func (e *executor) updateDescription(newDescription string, dashboardURL string) {
 	if newDescription != "" {
 		status := e.lastStatus
 		status.Description = newDescription
 		e.lastStatus = status
-		e.statusChan <- status
+		e.sendStatus(status)
 	}
 	if dashboardURL != "" {
 		e.dashboardURL = dashboardURL
@@ -162,6 +351,7 @@ func (e *executor) executeApb(
 	log.Debugf("name:[ %s ]", instance.Spec.FQName)
 	log.Debugf("image:[ %s ]", exContext.Image)
 	log.Debugf("action:[ %s ]", exContext.Action)
+	log.Debugf("parameters:[ %v ]", instance.redactedParameters(parameters))
 	log.Debugf("pullPolicy:[ %s ]", clusterConfig.PullPolicy)
 	log.Debugf("role:[ %s ]", clusterConfig.SandboxRole)
 
@@ -172,19 +362,61 @@ func (e *executor) executeApb(
 	if exContext.Location == "" || len(exContext.Targets) == 0 {
 		errStr := "Namespace not found within request context. Cannot perform requested " + exContext.Action
 		log.Error(errStr)
-		return exContext, errors.New(errStr)
+		return exContext, liberrors.New(liberrors.CodeValidation, errStr)
 	}
 
-	extraVars, err := createExtraVars(exContext.Targets[0], parameters)
+	nsLabels, nsAnnotations, err := e.namespaceMetadata(exContext.Targets[0])
+	if err != nil {
+		log.Errorf("unable to read the metadata of namespace %v - %v", exContext.Targets[0], err)
+		return exContext, err
+	}
+	params, creds := splitCredentials(parameters)
+	vars := BuildExtraVars(exContext.Action, instance, params, creds)
+	vars.setNamespaceMetadata(nsLabels, nsAnnotations)
+	for _, c := range vars.Collisions {
+		log.Warningf("extra vars of %v: %v", exContext.BundleName, c)
+	}
+	extraVars, err := vars.JSON()
 	if err != nil {
 		return exContext, err
 	}
 
+	resources, err := sandboxResources(instance)
+	if err != nil {
+		log.Errorf("unable to determine the sandbox resources - %v", err)
+		return exContext, err
+	}
+	exContext.Resources = resources
+
+	watchTimeout, err := instance.Spec.WatchTimeout(exContext.Action)
+	if err != nil {
+		log.Errorf("unable to determine the watch timeout - %v", err)
+		return exContext, err
+	}
+	exContext.WatchTimeout = watchTimeout
+
 	secrets := getSecrets(instance.Spec)
 	exContext.ProxyConfig = getProxyConfig()
 	exContext.Secrets = secrets
 	exContext.ExtraVars = extraVars
 	exContext.Policy = clusterConfig.PullPolicy
+	exContext.OS = instance.Spec.OS
+	exContext.Architecture = instance.Spec.Architecture
+	exContext.Dependencies = instance.Spec.imageDependencies()
+	if instance.Spec.HelmChart != nil {
+		exContext, err = helmExecution(exContext, instance, parameters)
+		if err != nil {
+			log.Errorf("unable to run the helm chart - %v", err)
+			return exContext, err
+		}
+	}
+	if instance.Spec.Manifests != nil {
+		exContext, err = manifestsExecution(exContext, instance)
+		if err != nil {
+			log.Errorf("unable to apply the manifests - %v", err)
+			return exContext, err
+		}
+	}
 
 	err = runtime.Provider.CopySecretsToNamespace(exContext, clusterConfig.Namespace, secrets)
 	if err != nil {
@@ -206,7 +438,10 @@ func (e *executor) executeApb(
 		exContext.StateLocation = e.stateManager.MountLocation()
 	}
 
-	exContext, err = runtime.Provider.RunBundle(exContext)
+	if e.operation != nil {
+		e.operation.PodName = exContext.BundleName
+	}
+	exContext, err = e.runBundle(exContext)
 	if err != nil {
 		log.Errorf("error running bundle - %v", err)
 		return exContext, err
@@ -214,26 +449,6 @@ func (e *executor) executeApb(
 	return exContext, nil
 }
 
-// TODO: Instead of putting namespace directly as a parameter, we should create a dictionary
-// of apb_metadata and put context and other variables in it so we don't pollute the user
-// parameter space.
-func createExtraVars(targetNamespace string, parameters *Parameters) (string, error) {
-	var paramsCopy Parameters
-	if parameters != nil && *parameters != nil {
-		paramsCopy = *parameters
-	} else {
-		paramsCopy = make(Parameters)
-	}
-
-	if targetNamespace != "" {
-		paramsCopy[NamespaceKey] = targetNamespace
-	}
-
-	paramsCopy[ClusterKey] = runtime.Provider.GetRuntime()
-	extraVars, err := json.Marshal(paramsCopy)
-	return string(extraVars), err
-}
-
 // getProxyConfig - Returns a ProxyConfig based on the presence of a proxy
 // configuration in the broker's environment. HTTP_PROXY, HTTPS_PROXY, and
 // NO_PROXY are the relevant environment variables. If no proxy is found,
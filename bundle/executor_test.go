@@ -17,6 +17,7 @@
 package bundle
 
 import (
+	"encoding/json"
 	"errors"
 	"os"
 	"testing"
@@ -180,3 +181,23 @@ func TestGetProxyConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestExtraVarsJSON(t *testing.T) {
+	rt := new(runtime.MockRuntime)
+	rt.On("GetRuntime").Return("kubernetes")
+	runtime.Provider = rt
+
+	instance := &ServiceInstance{Context: &Context{Namespace: "ns", Targets: []string{"db"}}}
+	extraVars, err := BuildExtraVars(string(executionMethodProvision), instance, &Parameters{"foo": "bar"}, nil).JSON()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	params := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(extraVars), &params); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "ns", params[NamespaceKey])
+	assert.Equal(t, []interface{}{"ns", "db"}, params[TargetNamespacesKey])
+	assert.Equal(t, "kubernetes", params[ClusterKey])
+	assert.Equal(t, "bar", params["foo"])
+}
@@ -19,8 +19,8 @@ package bundle
 import (
 	"fmt"
 
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/automationbroker/bundle-lib/runtime"
-	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -36,6 +36,46 @@ var (
 	ErrExtractedCredentialsNotFound = fmt.Errorf("credentials not found")
 )
 
+// BindCredentials - Which of the credential keys extracted by a bind are
+// returned for the binding and which are kept internal to the instance,
+// e.g. the admin credentials. In apb.yml:
+//
+//	bind_credentials:
+//	  binding: [DB_USER, DB_PASSWORD, DB_HOST]
+//	  instance: [DB_ADMIN_PASSWORD]
+type BindCredentials struct {
+	// Binding - the keys returned for bindings. When empty every key not
+	// kept for the instance is returned.
+	Binding []string `json:"binding,omitempty" yaml:"binding,omitempty"`
+	// Instance - the keys never returned for bindings.
+	Instance []string `json:"instance,omitempty" yaml:"instance,omitempty"`
+}
+
+// Filter - returns the credentials that may be returned for a binding.
+// A nil BindCredentials returns all of them.
+func (b *BindCredentials) Filter(creds map[string]interface{}) map[string]interface{} {
+	if b == nil {
+		return creds
+	}
+	allowed := map[string]bool{}
+	for _, key := range b.Binding {
+		allowed[key] = true
+	}
+	internal := map[string]bool{}
+	for _, key := range b.Instance {
+		internal[key] = true
+	}
+	filtered := make(map[string]interface{}, len(creds))
+	for key, value := range creds {
+		if internal[key] || (len(allowed) > 0 && !allowed[key]) {
+			log.Debugf("credential %v is not returned for bindings", key)
+			continue
+		}
+		filtered[key] = value
+	}
+	return filtered
+}
+
 // RecoverExtractCredentials - Recover extracted credentials.
 func RecoverExtractCredentials(podname, ns, fqname, id string, method JobMethod, targets []string, rt int) error {
 	defer runtime.Provider.DestroySandbox(podname, ns, targets, clusterConfig.Namespace, clusterConfig.KeepNamespace, clusterConfig.KeepNamespaceOnError)
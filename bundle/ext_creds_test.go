@@ -0,0 +1,66 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindCredentialsFilter(t *testing.T) {
+	creds := map[string]interface{}{
+		"DB_USER":           "app",
+		"DB_PASSWORD":       "secret",
+		"DB_ADMIN_PASSWORD": "admin-secret",
+	}
+	testCases := []struct {
+		name     string
+		bc       *BindCredentials
+		expected map[string]interface{}
+	}{
+		{
+			name:     "no mapping",
+			bc:       nil,
+			expected: creds,
+		},
+		{
+			name: "instance keys are kept internal",
+			bc:   &BindCredentials{Instance: []string{"DB_ADMIN_PASSWORD"}},
+			expected: map[string]interface{}{
+				"DB_USER":     "app",
+				"DB_PASSWORD": "secret",
+			},
+		},
+		{
+			name:     "only binding keys are returned",
+			bc:       &BindCredentials{Binding: []string{"DB_USER"}},
+			expected: map[string]interface{}{"DB_USER": "app"},
+		},
+		{
+			name:     "instance keys win over binding keys",
+			bc:       &BindCredentials{Binding: []string{"DB_USER", "DB_ADMIN_PASSWORD"}, Instance: []string{"DB_ADMIN_PASSWORD"}},
+			expected: map[string]interface{}{"DB_USER": "app"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.bc.Filter(creds))
+		})
+	}
+}
@@ -0,0 +1,180 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+)
+
+// ExtraVarsSource - Where a key of the extra vars of a bundle comes from.
+type ExtraVarsSource string
+
+// The sources of the extra vars, from the lowest to the highest precedence.
+// A key set by several sources gets the value of the last one.
+const (
+	// ExtraVarsDefaults - the defaults of the parameters of the plan, see
+	// BuildExtraVars.
+	ExtraVarsDefaults ExtraVarsSource = "defaults"
+	// ExtraVarsParameters - the parameters of the request.
+	ExtraVarsParameters ExtraVarsSource = "parameters"
+	// ExtraVarsCredentials - the credentials passed to the bundle, under
+	// ProvisionCredentialsKey and BindCredentialsKey.
+	ExtraVarsCredentials ExtraVarsSource = "credentials"
+	// ExtraVarsContext - the keys set by the executor: the namespace, the
+	// target namespaces, the cluster and the namespace metadata.
+	ExtraVarsContext ExtraVarsSource = "context"
+)
+
+// ExtraVarsCollision - A key set by two sources, the value of Overridden
+// was replaced by the one of Kept. Defaults are meant to be overridden and
+// are not reported.
+type ExtraVarsCollision struct {
+	Key        string
+	Kept       ExtraVarsSource
+	Overridden ExtraVarsSource
+}
+
+func (c ExtraVarsCollision) String() string {
+	return fmt.Sprintf("%v from the %v overrides the %v", c.Key, c.Kept, c.Overridden)
+}
+
+// ExtraVars - The extra vars of a bundle and where each key comes from.
+type ExtraVars struct {
+	Vars       Parameters
+	Sources    map[string]ExtraVarsSource
+	Collisions []ExtraVarsCollision
+}
+
+// BuildExtraVars - builds the extra vars of the bundle running the action
+// on the instance. The sources are merged by precedence, lowest first:
+//
+//  1. the defaults of the parameters of the plan of the instance for
+//     provision and update, of its bind parameters for bind and unbind.
+//     The other actions get no defaults, they run with the parameters
+//     the instance was provisioned with.
+//  2. the parameters, the ones of the instance or of the binding
+//  3. the credentials, keyed by ProvisionCredentialsKey or
+//     BindCredentialsKey
+//  4. the context: NamespaceKey and TargetNamespacesKey from the context of
+//     the instance, and ClusterKey from the runtime
+//
+// Keys overridden by a later source are reported in the Collisions, in the
+// order of the sources and of the keys. The parameters are not modified.
+func BuildExtraVars(action string, instance *ServiceInstance, params *Parameters, creds Parameters) *ExtraVars {
+	x := &ExtraVars{Vars: Parameters{}, Sources: map[string]ExtraVarsSource{}}
+	x.merge(ExtraVarsDefaults, planDefaults(action, instance))
+	if params != nil {
+		x.merge(ExtraVarsParameters, *params)
+	}
+	x.merge(ExtraVarsCredentials, creds)
+
+	// TODO: Instead of putting namespace directly as a parameter, we should create a dictionary
+	// of apb_metadata and put context and other variables in it so we don't pollute the user
+	// parameter space.
+	context := Parameters{ClusterKey: runtime.Provider.GetRuntime()}
+	if instance.Context != nil {
+		if targets := instance.Context.TargetNamespaces(); targets[0] != "" {
+			context[NamespaceKey] = targets[0]
+			context[TargetNamespacesKey] = targets
+		}
+	}
+	x.merge(ExtraVarsContext, context)
+	return x
+}
+
+// setNamespaceMetadata - adds the labels and annotations of the namespace
+// to the context, nothing is added when no metadata was read.
+func (x *ExtraVars) setNamespaceMetadata(labels, annotations map[string]string) {
+	if labels == nil && annotations == nil {
+		return
+	}
+	x.merge(ExtraVarsContext, Parameters{
+		NamespaceLabelsKey:      labels,
+		NamespaceAnnotationsKey: annotations,
+	})
+}
+
+// merge - sets the keys of the source in the order of the keys.
+func (x *ExtraVars) merge(source ExtraVarsSource, values Parameters) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if previous, ok := x.Sources[key]; ok && previous != ExtraVarsDefaults {
+			x.Collisions = append(x.Collisions, ExtraVarsCollision{Key: key, Kept: source, Overridden: previous})
+		}
+		x.Vars[key] = values[key]
+		x.Sources[key] = source
+	}
+}
+
+// JSON - the extra vars as passed to the bundle.
+func (x *ExtraVars) JSON() (string, error) {
+	extraVars, err := json.Marshal(x.Vars)
+	return string(extraVars), err
+}
+
+// planDefaults - the defaults of the parameters of the plan of the
+// instance the action is run with.
+func planDefaults(action string, instance *ServiceInstance) Parameters {
+	defaults := Parameters{}
+	if instance.Spec == nil {
+		return defaults
+	}
+	plan, ok := instance.Spec.GetPlan(instance.planName())
+	if !ok {
+		return defaults
+	}
+	var descriptors []ParameterDescriptor
+	switch action {
+	case string(executionMethodProvision), string(executionMethodUpdate):
+		descriptors = plan.Parameters
+	case bindAction, unbindAction:
+		descriptors = plan.BindParameters
+	}
+	for _, pd := range descriptors {
+		if pd.Default != nil {
+			defaults[pd.Name] = pd.Default
+		}
+	}
+	return defaults
+}
+
+// splitCredentials - separates the credentials added to the parameters by
+// the broker from the other parameters.
+func splitCredentials(parameters *Parameters) (*Parameters, Parameters) {
+	creds := Parameters{}
+	if parameters == nil {
+		return nil, creds
+	}
+	params := Parameters{}
+	for k, v := range *parameters {
+		switch k {
+		case ProvisionCredentialsKey, BindCredentialsKey:
+			creds[k] = v
+		default:
+			params[k] = v
+		}
+	}
+	return &params, creds
+}
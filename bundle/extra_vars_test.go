@@ -0,0 +1,107 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildExtraVars(t *testing.T) {
+	rt := new(runtime.MockRuntime)
+	rt.On("GetRuntime").Return("openshift")
+	runtime.Provider = rt
+
+	instance := &ServiceInstance{
+		Spec: &Spec{Plans: []Plan{{
+			Name: "dev",
+			Parameters: []ParameterDescriptor{
+				{Name: "postgresql_version", Default: "9.6"},
+				{Name: "postgresql_database", Default: "admin"},
+				{Name: "postgresql_password"},
+			},
+			BindParameters: []ParameterDescriptor{
+				{Name: "read_only", Default: true},
+			},
+		}}},
+		Context:    &Context{Namespace: "project", Targets: []string{"db"}},
+		Parameters: &Parameters{PlanParameterKey: "dev"},
+	}
+	params := &Parameters{
+		PlanParameterKey:      "dev",
+		"postgresql_database": "sampledb",
+		NamespaceKey:          "other",
+		BindCredentialsKey:    "forged",
+	}
+	creds := Parameters{BindCredentialsKey: map[string]interface{}{"user": "admin"}}
+
+	x := BuildExtraVars(string(executionMethodProvision), instance, params, creds)
+	assert.Equal(t, Parameters{
+		PlanParameterKey:      "dev",
+		"postgresql_version":  "9.6",
+		"postgresql_database": "sampledb",
+		BindCredentialsKey:    map[string]interface{}{"user": "admin"},
+		NamespaceKey:          "project",
+		TargetNamespacesKey:   []string{"project", "db"},
+		ClusterKey:            "openshift",
+	}, x.Vars)
+	assert.Equal(t, ExtraVarsDefaults, x.Sources["postgresql_version"])
+	assert.Equal(t, ExtraVarsParameters, x.Sources["postgresql_database"])
+	assert.Equal(t, ExtraVarsCredentials, x.Sources[BindCredentialsKey])
+	assert.Equal(t, ExtraVarsContext, x.Sources[NamespaceKey])
+	// overriding a default is not a collision
+	assert.Equal(t, []ExtraVarsCollision{
+		{Key: BindCredentialsKey, Kept: ExtraVarsCredentials, Overridden: ExtraVarsParameters},
+		{Key: NamespaceKey, Kept: ExtraVarsContext, Overridden: ExtraVarsParameters},
+	}, x.Collisions)
+	assert.Equal(t, "namespace from the context overrides the parameters", x.Collisions[1].String())
+	// the parameters are left untouched
+	assert.Equal(t, "other", (*params)[NamespaceKey])
+	_, ok := (*params)[ClusterKey]
+	assert.False(t, ok)
+
+	// bind gets the defaults of the bind parameters only
+	x = BuildExtraVars(bindAction, instance, &Parameters{}, nil)
+	assert.Equal(t, true, x.Vars["read_only"])
+	_, ok = x.Vars["postgresql_version"]
+	assert.False(t, ok)
+
+	// deprovision gets no defaults
+	x = BuildExtraVars(deprovisionAction, instance, &Parameters{}, nil)
+	_, ok = x.Vars["postgresql_version"]
+	assert.False(t, ok)
+
+	// without a plan, parameters or context only the cluster is set
+	x = BuildExtraVars(string(executionMethodProvision), &ServiceInstance{}, nil, nil)
+	assert.Equal(t, Parameters{ClusterKey: "openshift"}, x.Vars)
+	assert.Empty(t, x.Collisions)
+}
+
+func TestSplitCredentials(t *testing.T) {
+	params, creds := splitCredentials(&Parameters{
+		"foo":                   "bar",
+		ProvisionCredentialsKey: map[string]interface{}{"db": "admin"},
+	})
+	assert.Equal(t, &Parameters{"foo": "bar"}, params)
+	assert.Equal(t, Parameters{ProvisionCredentialsKey: map[string]interface{}{"db": "admin"}}, creds)
+
+	params, creds = splitCredentials(nil)
+	assert.Nil(t, params)
+	assert.Empty(t, creds)
+}
@@ -0,0 +1,136 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	log "github.com/automationbroker/bundle-lib/logging"
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/ghodss/yaml"
+	"k8s.io/api/core/v1"
+)
+
+// DefaultHelmImage - the image with the helm CLI that runs the charts of
+// specs with a HelmChart.
+const DefaultHelmImage = "docker.io/alpine/helm:3.12.0"
+
+// The parameters of the specs of the helm registry that are not values of
+// the chart.
+const (
+	helmRepoParameter    = "repo"
+	helmChartParameter   = "chart"
+	helmVersionParameter = "version"
+	helmValuesParameter  = "values"
+)
+
+// HelmChart - A chart of a helm repository.
+type HelmChart struct {
+	Repo    string `json:"repo"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// helmInstallScript - installs or upgrades the release. The arguments are
+// passed in the environment so the parameters are never interpreted by
+// the shell.
+const helmInstallScript = `set -e
+printf '%s' "$HELM_VALUES" > /tmp/values.yaml
+helm upgrade --install "$HELM_RELEASE" "$HELM_CHART" --repo "$HELM_REPO" --version "$HELM_VERSION" --namespace "$HELM_NAMESPACE" --values /tmp/values.yaml --wait`
+
+// helmUninstallScript - uninstalls the release.
+const helmUninstallScript = `helm uninstall "$HELM_RELEASE" --namespace "$HELM_NAMESPACE"`
+
+// helmRelease - the name of the release of the instance.
+func helmRelease(instance *ServiceInstance) string {
+	return fmt.Sprintf("bundle-%s", instance.ID.String())
+}
+
+// helmValues - the values of the chart, the values parameter overlaid with
+// the parameters that are not reserved.
+func helmValues(parameters *Parameters) (string, error) {
+	values := map[string]interface{}{}
+	if parameters == nil || *parameters == nil {
+		return "{}", nil
+	}
+	if raw, ok := (*parameters)[helmValuesParameter].(string); ok && raw != "" {
+		if err := yaml.Unmarshal([]byte(raw), &values); err != nil {
+			return "", fmt.Errorf("the values parameter is not valid yaml - %v", err)
+		}
+	}
+	for key, value := range *parameters {
+		switch {
+		case key == helmRepoParameter, key == helmChartParameter,
+			key == helmVersionParameter, key == helmValuesParameter:
+		case strings.HasPrefix(key, "_"):
+		default:
+			values[key] = value
+		}
+	}
+	// json is valid yaml
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// helmExecution - sets up the execution context to run the chart of the
+// spec with the helm CLI instead of the bundle.
+func helmExecution(ec runtime.ExecutionContext, instance *ServiceInstance, parameters *Parameters) (runtime.ExecutionContext, error) {
+	chart := instance.Spec.HelmChart
+	version := chart.Version
+	if parameters != nil {
+		if v, ok := (*parameters)[helmVersionParameter].(string); ok && v != "" {
+			version = v
+		}
+	}
+	env := []v1.EnvVar{
+		{Name: "HELM_RELEASE", Value: helmRelease(instance)},
+		{Name: "HELM_NAMESPACE", Value: ec.Targets[0]},
+		{Name: "HELM_CACHE_HOME", Value: "/tmp/helm/cache"},
+		{Name: "HELM_CONFIG_HOME", Value: "/tmp/helm/config"},
+		{Name: "HELM_DATA_HOME", Value: "/tmp/helm/data"},
+	}
+
+	var script string
+	switch ec.Action {
+	case string(executionMethodProvision), string(executionMethodUpdate):
+		values, err := helmValues(parameters)
+		if err != nil {
+			return ec, err
+		}
+		script = helmInstallScript
+		env = append(env,
+			v1.EnvVar{Name: "HELM_REPO", Value: chart.Repo},
+			v1.EnvVar{Name: "HELM_CHART", Value: chart.Name},
+			v1.EnvVar{Name: "HELM_VERSION", Value: version},
+			v1.EnvVar{Name: "HELM_VALUES", Value: values},
+		)
+	case deprovisionAction:
+		script = helmUninstallScript
+	default:
+		return ec, fmt.Errorf("action %v is not supported for helm charts", ec.Action)
+	}
+	log.Infof("running %v of helm chart %v %v as release %v", ec.Action, chart.Name, version, helmRelease(instance))
+	ec.Command = []string{"/bin/sh", "-c"}
+	ec.Args = []string{script}
+	ec.Env = append(ec.Env, env...)
+	return ec, nil
+}
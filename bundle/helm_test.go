@@ -0,0 +1,88 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+)
+
+func TestHelmValues(t *testing.T) {
+	values, err := helmValues(&Parameters{
+		"repo":         "https://charts.example.com",
+		"chart":        "mariadb",
+		"version":      "2.1.4",
+		"values":       "replicas: 1\ndb:\n  user: admin\n",
+		"replicas":     3,
+		"_apb_plan_id": "default",
+		"namespace":    "target",
+	})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.JSONEq(t, `{"replicas":3,"db":{"user":"admin"},"namespace":"target"}`, values)
+
+	_, err = helmValues(&Parameters{"values": "replicas: [1"})
+	assert.Error(t, err)
+
+	values, err = helmValues(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", values)
+}
+
+func TestHelmExecution(t *testing.T) {
+	instance := &ServiceInstance{
+		ID: uuid.Parse("9e4e6f2a-1c2b-4c5d-8e6f-7a8b9c0d1e2f"),
+		Spec: &Spec{
+			FQName:    "mariadb",
+			HelmChart: &HelmChart{Repo: "https://charts.example.com", Name: "mariadb", Version: "2.1.4"},
+		},
+	}
+	env := func(ec runtime.ExecutionContext) map[string]string {
+		m := map[string]string{}
+		for _, e := range ec.Env {
+			m[e.Name] = e.Value
+		}
+		return m
+	}
+
+	ec := runtime.ExecutionContext{Action: "update", Targets: []string{"target"}}
+	ec, err := helmExecution(ec, instance, &Parameters{"version": "2.2.0"})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, []string{"/bin/sh", "-c"}, ec.Command)
+	assert.Equal(t, []string{helmInstallScript}, ec.Args)
+	assert.Equal(t, "bundle-9e4e6f2a-1c2b-4c5d-8e6f-7a8b9c0d1e2f", env(ec)["HELM_RELEASE"])
+	assert.Equal(t, "target", env(ec)["HELM_NAMESPACE"])
+	assert.Equal(t, "2.2.0", env(ec)["HELM_VERSION"])
+	assert.Equal(t, "https://charts.example.com", env(ec)["HELM_REPO"])
+
+	ec, err = helmExecution(runtime.ExecutionContext{Action: "deprovision", Targets: []string{"target"}}, instance, nil)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, []string{helmUninstallScript}, ec.Args)
+	assert.NotContains(t, ec.Env, v1.EnvVar{Name: "HELM_VALUES", Value: "{}"})
+
+	_, err = helmExecution(runtime.ExecutionContext{Action: "bind", Targets: []string{"target"}}, instance, nil)
+	assert.Error(t, err)
+}
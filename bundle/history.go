@@ -0,0 +1,83 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/automationbroker/bundle-lib/logging"
+	"github.com/automationbroker/bundle-lib/runtime"
+)
+
+const (
+	operationSucceeded = "succeeded"
+	operationFailed    = "failed"
+)
+
+// History - the operations run on the service instance, oldest first. Only
+// the actions of executors created with RecordHistory are recorded.
+func History(instanceID string) ([]runtime.Operation, error) {
+	return runtime.Provider.History(instanceID)
+}
+
+// startOperation - starts recording the action when the executor records
+// the history.
+func (e *executor) startOperation(action string, instance *ServiceInstance, parameters *Parameters) {
+	if !e.recordHistory {
+		return
+	}
+	e.operationInstance = instance.ID.String()
+	e.operation = &runtime.Operation{
+		Action:         action,
+		ParametersHash: parametersHash(parameters),
+		StartedAt:      e.now().UTC(),
+	}
+}
+
+// finishOperation - adds the action to the history of the instance. A
+// failure to record it does not fail the action.
+func (e *executor) finishOperation(err error) {
+	if e.operation == nil {
+		return
+	}
+	op := *e.operation
+	e.operation = nil
+	op.FinishedAt = e.now().UTC()
+	op.Result = operationSucceeded
+	if err != nil {
+		op.Result = operationFailed
+		op.Error = err.Error()
+	}
+	if err := e.stateManager.RecordOperation(e.operationInstance, op); err != nil {
+		log.Warningf("unable to record the %v operation of instance %v - %v", op.Action, e.operationInstance, err)
+	}
+}
+
+// parametersHash - the sha256 of the json encoded parameters.
+func parametersHash(parameters *Parameters) string {
+	if parameters == nil {
+		return ""
+	}
+	b, err := json.Marshal(parameters)
+	if err != nil {
+		log.Warningf("unable to hash the parameters - %v", err)
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
@@ -0,0 +1,73 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordOperation(t *testing.T) {
+	rt := runtime.NewFakeRuntime()
+	runtime.Provider = rt
+	instance := &ServiceInstance{ID: uuid.NewUUID(), Spec: &Spec{FQName: "new-fq-name"}}
+	params := &Parameters{"test-param": true}
+
+	e := NewExecutor(ExecutorConfig{RecordHistory: true}).(*executor)
+	e.stateManager = rt
+	e.startOperation(bindAction, instance, params)
+	e.operation.PodName = "bundle-1"
+	e.finishOperation(nil)
+	e.startOperation(unbindAction, instance, nil)
+	e.finishOperation(errors.New("unbind failed"))
+
+	history, err := History(instance.ID.String())
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 operations, got: %#+v", history)
+	}
+	assert.Equal(t, bindAction, history[0].Action)
+	assert.Equal(t, operationSucceeded, history[0].Result)
+	assert.Equal(t, "bundle-1", history[0].PodName)
+	assert.Equal(t, parametersHash(params), history[0].ParametersHash)
+	assert.False(t, history[0].FinishedAt.Before(history[0].StartedAt))
+	assert.Equal(t, unbindAction, history[1].Action)
+	assert.Equal(t, operationFailed, history[1].Result)
+	assert.Equal(t, "unbind failed", history[1].Error)
+
+	// executors not recording the history leave it untouched
+	e = NewExecutor(ExecutorConfig{}).(*executor)
+	e.stateManager = rt
+	e.startOperation(bindAction, instance, params)
+	e.finishOperation(nil)
+	history, _ = History(instance.ID.String())
+	assert.Len(t, history, 2)
+}
+
+func TestParametersHash(t *testing.T) {
+	a := parametersHash(&Parameters{"one": 1, "two": "2"})
+	b := parametersHash(&Parameters{"two": "2", "one": 1})
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, parametersHash(&Parameters{"one": 2, "two": "2"}))
+	assert.Empty(t, parametersHash(nil))
+}
@@ -0,0 +1,103 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+// ImageReference - The image of a bundle, with the digest when the
+// registry reported it.
+type ImageReference struct {
+	Image  string
+	Digest string
+}
+
+// ImageDecision - Whether an ImagePolicy admits an image.
+type ImageDecision struct {
+	Allowed bool
+	// Reason - why the image was denied, e.g. the critical CVEs found.
+	Reason string
+}
+
+// ImagePolicy - Decides if the image of a bundle may be run, e.g. based on
+// the Clair or Quay security scan of the image or an admission service. It
+// is consulted by the registries when the specs are loaded and by the
+// executor before the bundle is run.
+type ImagePolicy interface {
+	Evaluate(ref ImageReference) (ImageDecision, error)
+}
+
+// ImagePolicyFunc - Adapts a function to an ImagePolicy.
+type ImagePolicyFunc func(ref ImageReference) (ImageDecision, error)
+
+// Evaluate - calls the function.
+func (f ImagePolicyFunc) Evaluate(ref ImageReference) (ImageDecision, error) {
+	return f(ref)
+}
+
+// ErrImageDenied - The image policy denied the image of the bundle.
+type ErrImageDenied struct {
+	Image  string
+	Reason string
+}
+
+func (e ErrImageDenied) Error() string {
+	return fmt.Sprintf("image %v denied by the image policy: %v", e.Image, e.Reason)
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrImageDenied) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrImageDenied - true if the error is an ErrImageDenied.
+func IsErrImageDenied(err error) bool {
+	_, ok := err.(ErrImageDenied)
+	return ok
+}
+
+// ImageReferenceOf - the image of the spec and its digest.
+func ImageReferenceOf(spec *Spec) ImageReference {
+	ref := ImageReference{Image: spec.Image}
+	if spec.Provenance != nil {
+		ref.Digest = spec.Provenance.Digest
+	}
+	return ref
+}
+
+// CheckImagePolicy - returns ErrImageDenied when the policy denies the
+// image of the spec. A nil policy admits every image, specs without an
+// image, e.g. OLM operators whose image is unknown, are not evaluated.
+func CheckImagePolicy(policy ImagePolicy, spec *Spec) error {
+	if policy == nil || spec.Image == "" {
+		return nil
+	}
+	ref := ImageReferenceOf(spec)
+	decision, err := policy.Evaluate(ref)
+	if err != nil {
+		log.Errorf("unable to evaluate the image policy for %v - %v", ref.Image, err)
+		return err
+	}
+	if !decision.Allowed {
+		return ErrImageDenied{Image: ref.Image, Reason: decision.Reason}
+	}
+	return nil
+}
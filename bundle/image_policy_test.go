@@ -0,0 +1,64 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckImagePolicy(t *testing.T) {
+	spec := &Spec{
+		Image:      "docker.io/ansibleplaybookbundle/postgresql-apb:latest",
+		Provenance: &Provenance{Digest: "sha256:482e3f2c"},
+	}
+	var evaluated ImageReference
+	policy := func(decision ImageDecision, err error) ImagePolicy {
+		return ImagePolicyFunc(func(ref ImageReference) (ImageDecision, error) {
+			evaluated = ref
+			return decision, err
+		})
+	}
+
+	assert.NoError(t, CheckImagePolicy(nil, spec))
+	assert.NoError(t, CheckImagePolicy(policy(ImageDecision{Allowed: true}, nil), spec))
+	assert.Equal(t, ImageReference{Image: spec.Image, Digest: "sha256:482e3f2c"}, evaluated)
+
+	err := CheckImagePolicy(policy(ImageDecision{Reason: "critical CVEs"}, nil), spec)
+	assert.True(t, IsErrImageDenied(err))
+	assert.Contains(t, err.Error(), "critical CVEs")
+
+	err = CheckImagePolicy(policy(ImageDecision{}, fmt.Errorf("scanner unavailable")), spec)
+	assert.Error(t, err)
+	assert.False(t, IsErrImageDenied(err))
+}
+
+func TestProvisionImageDenied(t *testing.T) {
+	e := &executor{
+		imagePolicy: ImagePolicyFunc(func(ref ImageReference) (ImageDecision, error) {
+			return ImageDecision{Reason: "critical CVEs"}, nil
+		}),
+	}
+	instance := &ServiceInstance{
+		Spec:    &Spec{FQName: "postgresql-apb", Image: "docker.io/postgresql-apb:latest"},
+		Context: &Context{Namespace: "target"},
+	}
+	err := e.provisionOrUpdate(executionMethodProvision, instance)
+	assert.True(t, IsErrImageDenied(err))
+}
@@ -0,0 +1,84 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+const (
+	// DeprecatedKey - apb.yml metadata key marking the bundle as deprecated.
+	DeprecatedKey = "deprecated"
+	// MaintenanceWindowKey - apb.yml metadata key describing when the
+	// bundle's service is maintained, e.g. "Sun 02:00-04:00 UTC".
+	MaintenanceWindowKey = "maintenanceWindow"
+	// EndOfLifeKey - apb.yml metadata key with the date, YYYY-MM-DD or
+	// RFC 3339, after which the bundle's service is no longer supported.
+	EndOfLifeKey = "endOfLife"
+)
+
+// Lifecycle - The maintenance and deprecation metadata of a spec.
+type Lifecycle struct {
+	Deprecated        bool
+	MaintenanceWindow string
+	// EndOfLife - nil when the spec has no end of life.
+	EndOfLife *time.Time
+}
+
+// IsEndOfLife - true if the end of life of the spec has passed.
+func (l Lifecycle) IsEndOfLife(now time.Time) bool {
+	return l.EndOfLife != nil && !now.Before(*l.EndOfLife)
+}
+
+// Lifecycle - reads the maintenance and deprecation metadata of the spec.
+// Values that can not be parsed are ignored.
+func (s *Spec) Lifecycle() Lifecycle {
+	l := Lifecycle{}
+	switch d := s.Metadata[DeprecatedKey].(type) {
+	case bool:
+		l.Deprecated = d
+	case string:
+		deprecated, err := strconv.ParseBool(d)
+		if err != nil {
+			log.Warningf("spec %s has an invalid %s value %v", s.FQName, DeprecatedKey, d)
+		}
+		l.Deprecated = deprecated
+	}
+	if w, ok := s.Metadata[MaintenanceWindowKey]; ok {
+		l.MaintenanceWindow = fmt.Sprint(w)
+	}
+	if eol, ok := s.Metadata[EndOfLifeKey]; ok {
+		t, err := parseEndOfLife(fmt.Sprint(eol))
+		if err != nil {
+			log.Warningf("spec %s has an invalid %s value %v - %v", s.FQName, EndOfLifeKey, eol, err)
+		} else {
+			l.EndOfLife = &t
+		}
+	}
+	return l
+}
+
+func parseEndOfLife(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,78 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecLifecycle(t *testing.T) {
+	eol := time.Date(2019, 6, 30, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		metadata map[string]interface{}
+		expected Lifecycle
+	}{
+		{
+			name:     "no lifecycle metadata",
+			metadata: map[string]interface{}{"displayName": "foo"},
+			expected: Lifecycle{},
+		},
+		{
+			name: "all lifecycle metadata",
+			metadata: map[string]interface{}{
+				DeprecatedKey:        true,
+				MaintenanceWindowKey: "Sun 02:00-04:00 UTC",
+				EndOfLifeKey:         "2019-06-30",
+			},
+			expected: Lifecycle{Deprecated: true, MaintenanceWindow: "Sun 02:00-04:00 UTC", EndOfLife: &eol},
+		},
+		{
+			name: "string values",
+			metadata: map[string]interface{}{
+				DeprecatedKey: "true",
+				EndOfLifeKey:  "2019-06-30T00:00:00Z",
+			},
+			expected: Lifecycle{Deprecated: true, EndOfLife: &eol},
+		},
+		{
+			name: "invalid values are ignored",
+			metadata: map[string]interface{}{
+				DeprecatedKey: "maybe",
+				EndOfLifeKey:  "soon",
+			},
+			expected: Lifecycle{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Spec{FQName: "foo", Metadata: tc.metadata}
+			assert.Equal(t, tc.expected, s.Lifecycle())
+		})
+	}
+}
+
+func TestLifecycleIsEndOfLife(t *testing.T) {
+	eol := time.Date(2019, 6, 30, 0, 0, 0, 0, time.UTC)
+	l := Lifecycle{EndOfLife: &eol}
+	assert.False(t, l.IsEndOfLife(eol.Add(-time.Hour)))
+	assert.True(t, l.IsEndOfLife(eol))
+	assert.False(t, Lifecycle{}.IsEndOfLife(eol))
+}
@@ -0,0 +1,113 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"strings"
+)
+
+// displayNameKey - the metadata key of the display name of specs and plans.
+const displayNameKey = "displayName"
+
+// Localization - The display metadata of a spec, plan or parameter in one
+// locale. Empty values fall back to the default locale. In apb.yml:
+//
+//	metadata:
+//	  displayName: PostgreSQL (APB)
+//	localizations:
+//	  fr:
+//	    displayName: PostgreSQL (APB)
+//	    description: Base de données PostgreSQL
+type Localization struct {
+	DisplayName string `json:"displayName,omitempty" yaml:"displayName,omitempty"`
+	Title       string `json:"title,omitempty" yaml:"title,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// findLocalization - finds the localization for the locale, falling back
+// from a regional locale like fr-CA to its language.
+func findLocalization(localizations map[string]Localization, locale string) (Localization, bool) {
+	if l, ok := localizations[locale]; ok {
+		return l, true
+	}
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		l, ok := localizations[locale[:i]]
+		return l, ok
+	}
+	return Localization{}, false
+}
+
+// Localize - returns a copy of the spec with the display names,
+// descriptions and titles of the spec, its plans and parameters in the
+// locale. The spec is returned unchanged when it is not localized.
+func (s *Spec) Localize(locale string) *Spec {
+	localized := *s
+	if l, ok := findLocalization(s.Localizations, locale); ok {
+		localized.Metadata = localizeMetadata(s.Metadata, l)
+		if l.Description != "" {
+			localized.Description = l.Description
+		}
+	}
+	localized.Plans = make([]Plan, len(s.Plans))
+	for i, p := range s.Plans {
+		localized.Plans[i] = p.localize(locale)
+	}
+	return &localized
+}
+
+func (p Plan) localize(locale string) Plan {
+	if l, ok := findLocalization(p.Localizations, locale); ok {
+		p.Metadata = localizeMetadata(p.Metadata, l)
+		if l.Description != "" {
+			p.Description = l.Description
+		}
+	}
+	p.Parameters = localizeParameters(p.Parameters, locale)
+	p.BindParameters = localizeParameters(p.BindParameters, locale)
+	return p
+}
+
+func localizeParameters(params []ParameterDescriptor, locale string) []ParameterDescriptor {
+	if params == nil {
+		return nil
+	}
+	localized := make([]ParameterDescriptor, len(params))
+	for i, pd := range params {
+		if l, ok := findLocalization(pd.Localizations, locale); ok {
+			if l.Title != "" {
+				pd.Title = l.Title
+			}
+			if l.Description != "" {
+				pd.Description = l.Description
+			}
+		}
+		localized[i] = pd
+	}
+	return localized
+}
+
+func localizeMetadata(metadata map[string]interface{}, l Localization) map[string]interface{} {
+	if l.DisplayName == "" {
+		return metadata
+	}
+	localized := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		localized[k] = v
+	}
+	localized[displayNameKey] = l.DisplayName
+	return localized
+}
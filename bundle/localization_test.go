@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecLocalize(t *testing.T) {
+	spec := &Spec{
+		Description: "PostgreSQL database",
+		Metadata:    map[string]interface{}{"displayName": "PostgreSQL (APB)", "imageUrl": "foo"},
+		Localizations: map[string]Localization{
+			"fr": {DisplayName: "PostgreSQL (APB) fr", Description: "Base de données PostgreSQL"},
+		},
+		Plans: []Plan{
+			{
+				Name:        "dev",
+				Description: "Development plan",
+				Metadata:    map[string]interface{}{"displayName": "Development"},
+				Localizations: map[string]Localization{
+					"fr": {DisplayName: "Développement"},
+				},
+				Parameters: []ParameterDescriptor{
+					{
+						Name:        "postgresql_user",
+						Title:       "PostgreSQL User",
+						Description: "The database user",
+						Localizations: map[string]Localization{
+							"fr": {Title: "Utilisateur PostgreSQL"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		locale          string
+		displayName     string
+		description     string
+		planDisplayName string
+		planDescription string
+		paramTitle      string
+		paramDesc       string
+	}{
+		{
+			name:            "unknown locale",
+			locale:          "de",
+			displayName:     "PostgreSQL (APB)",
+			description:     "PostgreSQL database",
+			planDisplayName: "Development",
+			planDescription: "Development plan",
+			paramTitle:      "PostgreSQL User",
+			paramDesc:       "The database user",
+		},
+		{
+			name:            "exact locale",
+			locale:          "fr",
+			displayName:     "PostgreSQL (APB) fr",
+			description:     "Base de données PostgreSQL",
+			planDisplayName: "Développement",
+			planDescription: "Development plan",
+			paramTitle:      "Utilisateur PostgreSQL",
+			paramDesc:       "The database user",
+		},
+		{
+			name:            "regional locale falls back to language",
+			locale:          "fr-CA",
+			displayName:     "PostgreSQL (APB) fr",
+			description:     "Base de données PostgreSQL",
+			planDisplayName: "Développement",
+			planDescription: "Development plan",
+			paramTitle:      "Utilisateur PostgreSQL",
+			paramDesc:       "The database user",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localized := spec.Localize(tc.locale)
+			assert.Equal(t, tc.displayName, localized.Metadata["displayName"])
+			assert.Equal(t, "foo", localized.Metadata["imageUrl"])
+			assert.Equal(t, tc.description, localized.Description)
+			assert.Equal(t, tc.planDisplayName, localized.Plans[0].Metadata["displayName"])
+			assert.Equal(t, tc.planDescription, localized.Plans[0].Description)
+			assert.Equal(t, tc.paramTitle, localized.Plans[0].Parameters[0].Title)
+			assert.Equal(t, tc.paramDesc, localized.Plans[0].Parameters[0].Description)
+		})
+	}
+
+	// the original spec is left untouched
+	assert.Equal(t, "PostgreSQL (APB)", spec.Metadata["displayName"])
+	assert.Equal(t, "PostgreSQL User", spec.Plans[0].Parameters[0].Title)
+}
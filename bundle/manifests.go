@@ -0,0 +1,123 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+
+	log "github.com/automationbroker/bundle-lib/logging"
+	"github.com/automationbroker/bundle-lib/runtime"
+	"k8s.io/api/core/v1"
+)
+
+// DefaultManifestsImage - the image with kubectl that applies the manifests
+// of specs without an image.
+const DefaultManifestsImage = "docker.io/bitnami/kubectl:1.28"
+
+// ManifestsInstanceLabel - the label on the applied resources holding the
+// instance ID, used to prune them on deprovision.
+const ManifestsInstanceLabel = "bundle.automationbroker.io/instance"
+
+// The kinds of ManifestSource.
+const (
+	// ManifestsKindPlain - plain Kubernetes manifests, a file, directory
+	// or URL kubectl -f accepts.
+	ManifestsKindPlain = "manifests"
+	// ManifestsKindKustomize - a kustomize overlay, a directory or git URL
+	// kubectl kustomize accepts.
+	ManifestsKindKustomize = "kustomize"
+)
+
+// ManifestSource - Kubernetes manifests applied into the target namespace
+// instead of running a bundle. A source prefixed with oci:// is an OCI
+// artifact pulled with oras, which the image then has to provide.
+type ManifestSource struct {
+	Kind   string `json:"kind" yaml:"kind"`
+	Source string `json:"source" yaml:"source"`
+}
+
+// manifestsApplyScript - renders the manifests, labels them with the
+// instance and applies them server side. The arguments are passed in the
+// environment so they are never interpreted by the shell.
+const manifestsApplyScript = `set -e
+case "$MANIFESTS_SOURCE" in
+oci://*)
+	mkdir -p /tmp/manifests
+	oras pull "${MANIFESTS_SOURCE#oci://}" --output /tmp/manifests
+	MANIFESTS_SOURCE=/tmp/manifests
+	;;
+esac
+if [ "$MANIFESTS_KIND" = "kustomize" ]; then
+	kubectl kustomize "$MANIFESTS_SOURCE" > /tmp/rendered.yaml
+else
+	kubectl create --dry-run=client -o yaml -R -f "$MANIFESTS_SOURCE" > /tmp/rendered.yaml
+fi
+kubectl label --local -o yaml -f /tmp/rendered.yaml "$MANIFESTS_LABEL=$MANIFESTS_INSTANCE" |
+	kubectl apply --server-side --force-conflicts --field-manager=bundle-lib --namespace "$MANIFESTS_NAMESPACE" -f -`
+
+// manifestsPruneScript - deletes the namespaced resources labeled with the
+// instance.
+const manifestsPruneScript = `set -e
+kinds=$(kubectl api-resources --namespaced --verbs=list,delete -o name | paste -sd, -)
+kubectl delete "$kinds" --namespace "$MANIFESTS_NAMESPACE" -l "$MANIFESTS_LABEL=$MANIFESTS_INSTANCE" --ignore-not-found --wait`
+
+// validate - the kind has to be known and the source set.
+func (m *ManifestSource) validate() error {
+	if m.Kind != ManifestsKindPlain && m.Kind != ManifestsKindKustomize {
+		return fmt.Errorf("unknown manifests kind %q", m.Kind)
+	}
+	if m.Source == "" {
+		return fmt.Errorf("the manifests have no source")
+	}
+	return nil
+}
+
+// manifestsExecution - sets up the execution context to apply or prune the
+// manifests of the spec instead of running the bundle.
+func manifestsExecution(ec runtime.ExecutionContext, instance *ServiceInstance) (runtime.ExecutionContext, error) {
+	manifests := instance.Spec.Manifests
+	if err := manifests.validate(); err != nil {
+		return ec, err
+	}
+	env := []v1.EnvVar{
+		{Name: "MANIFESTS_NAMESPACE", Value: ec.Targets[0]},
+		{Name: "MANIFESTS_LABEL", Value: ManifestsInstanceLabel},
+		{Name: "MANIFESTS_INSTANCE", Value: instance.ID.String()},
+	}
+
+	var script string
+	switch ec.Action {
+	case string(executionMethodProvision), string(executionMethodUpdate):
+		script = manifestsApplyScript
+		env = append(env,
+			v1.EnvVar{Name: "MANIFESTS_KIND", Value: manifests.Kind},
+			v1.EnvVar{Name: "MANIFESTS_SOURCE", Value: manifests.Source},
+		)
+	case deprovisionAction:
+		script = manifestsPruneScript
+	default:
+		return ec, fmt.Errorf("action %v is not supported for manifests", ec.Action)
+	}
+	if ec.Image == "" {
+		ec.Image = DefaultManifestsImage
+	}
+	log.Infof("running %v of %v %v for instance %v", ec.Action, manifests.Kind, manifests.Source, instance.ID)
+	ec.Command = []string{"/bin/sh", "-c"}
+	ec.Args = []string{script}
+	ec.Env = append(ec.Env, env...)
+	return ec, nil
+}
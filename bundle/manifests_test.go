@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestsExecution(t *testing.T) {
+	instance := &ServiceInstance{
+		ID: uuid.Parse("9e4e6f2a-1c2b-4c5d-8e6f-7a8b9c0d1e2f"),
+		Spec: &Spec{
+			FQName:    "guestbook",
+			Manifests: &ManifestSource{Kind: ManifestsKindKustomize, Source: "https://github.com/example/guestbook//overlays/prod"},
+		},
+	}
+	env := func(ec runtime.ExecutionContext) map[string]string {
+		m := map[string]string{}
+		for _, e := range ec.Env {
+			m[e.Name] = e.Value
+		}
+		return m
+	}
+
+	ec := runtime.ExecutionContext{Action: "provision", Targets: []string{"target"}}
+	ec, err := manifestsExecution(ec, instance)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, DefaultManifestsImage, ec.Image)
+	assert.Equal(t, []string{"/bin/sh", "-c"}, ec.Command)
+	assert.Equal(t, []string{manifestsApplyScript}, ec.Args)
+	assert.Equal(t, "target", env(ec)["MANIFESTS_NAMESPACE"])
+	assert.Equal(t, "kustomize", env(ec)["MANIFESTS_KIND"])
+	assert.Equal(t, "https://github.com/example/guestbook//overlays/prod", env(ec)["MANIFESTS_SOURCE"])
+	assert.Equal(t, "9e4e6f2a-1c2b-4c5d-8e6f-7a8b9c0d1e2f", env(ec)["MANIFESTS_INSTANCE"])
+
+	ec = runtime.ExecutionContext{Action: "deprovision", Targets: []string{"target"}, Image: "kubectl:latest"}
+	ec, err = manifestsExecution(ec, instance)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "kubectl:latest", ec.Image)
+	assert.Equal(t, []string{manifestsPruneScript}, ec.Args)
+	assert.Equal(t, ManifestsInstanceLabel, env(ec)["MANIFESTS_LABEL"])
+
+	_, err = manifestsExecution(runtime.ExecutionContext{Action: "bind", Targets: []string{"target"}}, instance)
+	assert.Error(t, err)
+
+	instance.Spec.Manifests = &ManifestSource{Kind: "jsonnet", Source: "main.jsonnet"}
+	_, err = manifestsExecution(runtime.ExecutionContext{Action: "provision", Targets: []string{"target"}}, instance)
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	log "github.com/automationbroker/bundle-lib/logging"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// sandboxLabels - adds the user supplied labels to the labels of the
+// sandbox resources. Labels that are not valid kubernetes labels are
+// skipped and the labels set by the executor take precedence.
+func sandboxLabels(labels, userLabels map[string]string) map[string]string {
+	merged := map[string]string{}
+	for k, v := range userLabels {
+		if errs := append(validation.IsQualifiedName(k), validation.IsValidLabelValue(v)...); len(errs) > 0 {
+			log.Warningf("skipping invalid label %s=%s - %v", k, v, errs)
+			continue
+		}
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,40 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSandboxLabels(t *testing.T) {
+	labels := map[string]string{"bundle-action": "provision"}
+	userLabels := map[string]string{
+		"owner":         "team-a",
+		"cost-center":   "1234",
+		"bundle-action": "bind",
+		"not valid!":    "value",
+		"invalid-value": "not valid!",
+	}
+	expected := map[string]string{
+		"owner":         "team-a",
+		"cost-center":   "1234",
+		"bundle-action": "provision",
+	}
+	assert.Equal(t, expected, sandboxLabels(labels, userLabels))
+}
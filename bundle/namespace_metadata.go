@@ -0,0 +1,58 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"github.com/automationbroker/bundle-lib/clients"
+	log "github.com/automationbroker/bundle-lib/logging"
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// namespaceMetadata - returns the labels and annotations of the namespace
+// selected by the executor configuration. Nothing is read when no keys are
+// configured, and a namespace that does not exist yet, because the runtime
+// creates it, has no metadata.
+func (e *executor) namespaceMetadata(namespace string) (map[string]string, map[string]string, error) {
+	if namespace == "" || (len(e.namespaceLabels) == 0 && len(e.namespaceAnnotations) == 0) {
+		return nil, nil, nil
+	}
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		return nil, nil, err
+	}
+	ns, err := k8scli.Client.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
+	if kapierrors.IsNotFound(err) {
+		log.Debugf("namespace %v does not exist, it has no metadata", namespace)
+		return map[string]string{}, map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return selectKeys(ns.Labels, e.namespaceLabels), selectKeys(ns.Annotations, e.namespaceAnnotations), nil
+}
+
+// selectKeys - returns the entries of the map with the keys.
+func selectKeys(m map[string]string, keys []string) map[string]string {
+	selected := map[string]string{}
+	for _, key := range keys {
+		if value, ok := m[key]; ok {
+			selected[key] = value
+		}
+	}
+	return selected
+}
@@ -0,0 +1,123 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/authorization"
+	"github.com/automationbroker/bundle-lib/clients"
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNamespaceMetadata(t *testing.T) {
+	k, err := clients.Kubernetes()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	k.Client = fake.NewSimpleClientset(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "target",
+			Labels:      map[string]string{"tier": "prod", "team": "a"},
+			Annotations: map[string]string{"classification": "pii"},
+		},
+	})
+
+	testCases := []struct {
+		name                string
+		executor            *executor
+		namespace           string
+		expectedLabels      map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{
+			name:      "not configured",
+			executor:  &executor{},
+			namespace: "target",
+		},
+		{
+			name:                "selected keys",
+			executor:            &executor{namespaceLabels: []string{"tier", "missing"}, namespaceAnnotations: []string{"classification"}},
+			namespace:           "target",
+			expectedLabels:      map[string]string{"tier": "prod"},
+			expectedAnnotations: map[string]string{"classification": "pii"},
+		},
+		{
+			name:                "namespace not found",
+			executor:            &executor{namespaceLabels: []string{"tier"}},
+			namespace:           "missing",
+			expectedLabels:      map[string]string{},
+			expectedAnnotations: map[string]string{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labels, annotations, err := tc.executor.namespaceMetadata(tc.namespace)
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, tc.expectedLabels, labels)
+			assert.Equal(t, tc.expectedAnnotations, annotations)
+		})
+	}
+
+	ca := &fakeContextAuthorizer{fakeAuthorizer: fakeAuthorizer{decision: authorization.DecisionAllowed}}
+	e := &executor{authorizer: ca, namespaceLabels: []string{"tier"}}
+	err = e.authorize(authorization.ActionProvision, &ServiceInstance{
+		Spec:     &Spec{FQName: "fq-name"},
+		Context:  &Context{Namespace: "target"},
+		UserInfo: &authorization.User{Name: "foo"},
+	})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, map[string]string{"tier": "prod"}, ca.ctx.NamespaceLabels)
+}
+
+func TestExtraVarsNamespaceMetadata(t *testing.T) {
+	rt := new(runtime.MockRuntime)
+	rt.On("GetRuntime").Return("kubernetes")
+	runtime.Provider = rt
+
+	instance := &ServiceInstance{Context: &Context{Namespace: "target"}}
+	params := &Parameters{"foo": "bar"}
+	withoutMetadata := BuildExtraVars(string(executionMethodProvision), instance, params, nil)
+	withoutMetadata.setNamespaceMetadata(nil, nil)
+	_, ok := withoutMetadata.Vars[NamespaceLabelsKey]
+	assert.False(t, ok)
+
+	withMetadata := BuildExtraVars(string(executionMethodProvision), instance, params, nil)
+	withMetadata.setNamespaceMetadata(map[string]string{"tier": "prod"}, map[string]string{})
+	extraVars, err := withMetadata.JSON()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	vars := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(extraVars), &vars); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, map[string]interface{}{"tier": "prod"}, vars[NamespaceLabelsKey])
+	assert.Equal(t, map[string]interface{}{}, vars[NamespaceAnnotationsKey])
+	// the parameters of the instance are left untouched
+	_, ok = (*params)[NamespaceLabelsKey]
+	assert.False(t, ok)
+}
@@ -0,0 +1,321 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/automationbroker/bundle-lib/logging"
+	"github.com/automationbroker/bundle-lib/runtime"
+)
+
+const (
+	// EventStarted - the phase of the event sent when an action starts.
+	EventStarted = "started"
+	// EventSucceeded - the phase of the event sent when an action succeeds.
+	EventSucceeded = "succeeded"
+	// EventFailed - the phase of the event sent when an action fails.
+	EventFailed = "failed"
+
+	// DefaultWebhookAttempts - the attempts made to deliver an event when
+	// the WebhookConfig does not set them.
+	DefaultWebhookAttempts = 3
+	// DefaultWebhookBackoff - the wait before the first retry when the
+	// WebhookConfig does not set it.
+	DefaultWebhookBackoff = time.Second
+	// DefaultWebhookTimeout - the timeout of a delivery attempt when the
+	// WebhookConfig does not set it.
+	DefaultWebhookTimeout = 10 * time.Second
+	// NotificationQueueSize - the events waiting to be delivered, the
+	// events sent while the queue is full are logged and dropped.
+	NotificationQueueSize = 256
+)
+
+// Event - A change in the lifecycle of an action run by the executor,
+// sent to the Notifier of the executor.
+type Event struct {
+	// Type - the action and the phase, e.g. provision.succeeded.
+	Type       string    `json:"type"`
+	Action     string    `json:"action"`
+	Phase      string    `json:"phase"`
+	InstanceID string    `json:"instance_id"`
+	BindingID  string    `json:"binding_id,omitempty"`
+	FQName     string    `json:"fqname"`
+	Plan       string    `json:"plan,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Time       time.Time `json:"time"`
+	// Error and FailureReason - why the action failed.
+	Error         string                `json:"error,omitempty"`
+	FailureReason runtime.FailureReason `json:"failure_reason,omitempty"`
+}
+
+// Notifier - Receives the events of the actions run by the executor, e.g.
+// to integrate with a chat or a CMDB. The events of all the executors are
+// queued and Notify is called from a single goroutine, in the order they
+// were sent, so a slow notifier never holds an action. See
+// NotificationQueueSize.
+type Notifier interface {
+	Notify(event Event)
+}
+
+// NotifierFunc - Adapts a function to a Notifier.
+type NotifierFunc func(event Event)
+
+// Notify - calls the function.
+func (f NotifierFunc) Notify(event Event) {
+	f(event)
+}
+
+// startEvent - sends the started event of the action when the executor
+// has a notifier. The other fields of the event are kept for finishEvent.
+func (e *executor) startEvent(action string, instance *ServiceInstance, bindingID string) {
+	if e.notifier == nil {
+		return
+	}
+	event := Event{
+		Action:     action,
+		InstanceID: instance.ID.String(),
+		BindingID:  bindingID,
+		FQName:     instance.Spec.FQName,
+		Plan:       instance.planName(),
+	}
+	if instance.Context != nil {
+		event.Namespace = instance.Context.Namespace
+	}
+	e.event = &event
+	e.notify(event, EventStarted)
+}
+
+// finishEvent - sends the succeeded or failed event of the action.
+func (e *executor) finishEvent(err error) {
+	if e.event == nil {
+		return
+	}
+	event := *e.event
+	e.event = nil
+	if err != nil {
+		event.Error = err.Error()
+		event.FailureReason = failureReason(err)
+		e.notify(event, EventFailed)
+		return
+	}
+	e.notify(event, EventSucceeded)
+}
+
+func (e *executor) notify(event Event, phase string) {
+	event.Phase = phase
+	event.Type = event.Action + "." + phase
+	event.Time = e.now().UTC()
+	notifications.send(e.notifier, event)
+}
+
+// notificationQueue - Delivers the events of the executors from a single
+// goroutine, started with the first event.
+type notificationQueue struct {
+	once    sync.Once
+	events  chan queuedEvent
+	pending sync.WaitGroup
+}
+
+type queuedEvent struct {
+	notifier Notifier
+	event    Event
+}
+
+// notifications - the queue of the events of all the executors.
+var notifications = &notificationQueue{events: make(chan queuedEvent, NotificationQueueSize)}
+
+// send - queues the event, it is dropped when the queue is full.
+func (q *notificationQueue) send(notifier Notifier, event Event) {
+	q.once.Do(func() { go q.run() })
+	q.pending.Add(1)
+	select {
+	case q.events <- queuedEvent{notifier: notifier, event: event}:
+	default:
+		q.pending.Done()
+		log.Warningf("notification queue is full, dropping the %v event of instance %v", event.Type, event.InstanceID)
+	}
+}
+
+func (q *notificationQueue) run() {
+	for queued := range q.events {
+		q.deliver(queued)
+	}
+}
+
+// deliver - calls the notifier, a panic of the notifier is logged.
+func (q *notificationQueue) deliver(queued queuedEvent) {
+	defer q.pending.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("notifier panicked on the %v event of instance %v - %v", queued.event.Type, queued.event.InstanceID, r)
+		}
+	}()
+	queued.notifier.Notify(queued.event)
+}
+
+// wait - waits for the queued events to be delivered, or ctx to be done.
+func (q *notificationQueue) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		q.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WebhookConfig - Where and how a WebhookNotifier posts the events.
+type WebhookConfig struct {
+	// URL - the http or https endpoint the events are posted to.
+	URL string
+	// Username and Password - optional basic auth credentials.
+	Username string
+	Password string
+	// Token - optional bearer token, used instead of basic auth.
+	Token string
+	// Headers - optional headers added to every request.
+	Headers map[string]string
+	// Attempts - the maximum attempts made to deliver an event, defaults
+	// to DefaultWebhookAttempts. 1 disables retries.
+	Attempts int
+	// Backoff - the wait before the first retry, doubled for every
+	// following retry. Defaults to DefaultWebhookBackoff.
+	Backoff time.Duration
+	// Timeout - the timeout of an attempt, defaults to
+	// DefaultWebhookTimeout.
+	Timeout       time.Duration
+	SkipVerifyTLS bool
+}
+
+// WebhookNotifier - A Notifier posting the events as JSON to a webhook.
+// An event that can not be delivered is logged and dropped, it never
+// fails the action.
+type WebhookNotifier struct {
+	config WebhookConfig
+	client *http.Client
+}
+
+// NewWebhookNotifier - Creates a WebhookNotifier, the URL of the config
+// must be an absolute http or https URL.
+func NewWebhookNotifier(config WebhookConfig) (*WebhookNotifier, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook url %q - %v", config.URL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook url %q - expected an http or https url", config.URL)
+	}
+	if config.Attempts < 1 {
+		config.Attempts = DefaultWebhookAttempts
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = DefaultWebhookBackoff
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultWebhookTimeout
+	}
+	transport := http.DefaultTransport
+	if config.SkipVerifyTLS {
+		log.Warning("skipping verification of the webhook TLS certificate")
+		transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+	return &WebhookNotifier{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout, Transport: transport},
+	}, nil
+}
+
+// Notify - sends the event, logging a failure.
+func (w *WebhookNotifier) Notify(event Event) {
+	if err := w.Send(event); err != nil {
+		log.Warningf("unable to send the %v event of instance %v - %v", event.Type, event.InstanceID, err)
+	}
+}
+
+// Send - posts the event to the webhook. The request is retried when it
+// fails to connect, or the webhook responds with 429 or a 5xx status.
+func (w *WebhookNotifier) Send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	backoff := w.config.Backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := w.post(body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.config.Attempts {
+			return fmt.Errorf("attempt %d of %d failed - %v", attempt, w.config.Attempts, err)
+		}
+		log.Debugf("attempt %d to send the %v event failed - %v", attempt, event.Type, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post - makes one attempt, returns true with the error when it can be
+// retried.
+func (w *WebhookNotifier) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.config.Headers {
+		req.Header.Set(k, v)
+	}
+	switch {
+	case w.config.Token != "":
+		req.Header.Set("Authorization", "Bearer "+w.config.Token)
+	case w.config.Username != "":
+		req.SetBasicAuth(w.config.Username, w.config.Password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook responded with %v", resp.Status)
+	}
+	return false, fmt.Errorf("webhook responded with %v", resp.Status)
+}
@@ -0,0 +1,159 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvisionEvents(t *testing.T) {
+	runtime.Provider = runtime.NewFakeRuntime()
+	events := []Event{}
+	notifier := NotifierFunc(func(event Event) {
+		events = append(events, event)
+	})
+
+	instance := &ServiceInstance{
+		ID:         uuid.NewUUID(),
+		Spec:       operatorSpec("db"),
+		Context:    &Context{Namespace: "target", Platform: "kubernetes"},
+		Parameters: &Parameters{PlanParameterKey: "default"},
+	}
+	for range NewExecutor(ExecutorConfig{Notifier: notifier}).Provision(instance) {
+	}
+	notifications.wait(context.Background())
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got: %d", len(events))
+	}
+	assert.Equal(t, "provision.started", events[0].Type)
+	assert.Equal(t, "provision.succeeded", events[1].Type)
+	assert.Equal(t, instance.ID.String(), events[1].InstanceID)
+	assert.Equal(t, "default", events[1].Plan)
+	assert.Equal(t, "target", events[1].Namespace)
+
+	// requirements can not be resolved without a spec resolver
+	events = []Event{}
+	instance.Spec = operatorSpec("app", Requirement{FQName: "db", Plan: "default"})
+	for range NewExecutor(ExecutorConfig{Notifier: notifier}).Provision(instance) {
+	}
+	notifications.wait(context.Background())
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got: %d", len(events))
+	}
+	assert.Equal(t, "provision.failed", events[1].Type)
+	assert.NotEmpty(t, events[1].Error)
+}
+
+func TestSlowNotifier(t *testing.T) {
+	runtime.Provider = runtime.NewFakeRuntime()
+	release := make(chan struct{})
+	delivered := 0
+	notifier := NotifierFunc(func(event Event) {
+		<-release
+		delivered++
+	})
+
+	instance := &ServiceInstance{
+		ID:         uuid.NewUUID(),
+		Spec:       operatorSpec("db"),
+		Context:    &Context{Namespace: "target", Platform: "kubernetes"},
+		Parameters: &Parameters{PlanParameterKey: "default"},
+	}
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for range NewExecutor(ExecutorConfig{Notifier: notifier}).Provision(instance) {
+		}
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("the action waited for the notifier")
+	}
+
+	close(release)
+	if err := notifications.wait(context.Background()); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, 2, delivered)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	attempts := 0
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "bundle-lib", r.Header.Get("X-Source"))
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatalf("unknown error occured: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	w, err := NewWebhookNotifier(WebhookConfig{
+		URL:     server.URL,
+		Token:   "secret",
+		Headers: map[string]string{"X-Source": "bundle-lib"},
+		Backoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	event := Event{Type: "bind.succeeded", Action: "bind", Phase: EventSucceeded, BindingID: "binding"}
+	if err := w.Send(event); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	// the 503 is retried
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, event, received)
+}
+
+func TestWebhookNotifierErrors(t *testing.T) {
+	_, err := NewWebhookNotifier(WebhookConfig{URL: "ftp://example.com/hook"})
+	assert.Error(t, err)
+	_, err = NewWebhookNotifier(WebhookConfig{URL: "/hook"})
+	assert.Error(t, err)
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	w, err := NewWebhookNotifier(WebhookConfig{URL: server.URL, Username: "admin", Password: "admin"})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Error(t, w.Send(Event{Type: "provision.started"}))
+	// a 401 is not retried
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,247 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Number - A number of a spec, e.g. the bound of a parameter. The number
+// is kept as written in the spec, so integers do not lose precision to a
+// float64, and it is encoded as a json and yaml number.
+type Number json.Number
+
+// IntNumber - the number of an integer.
+func IntNumber(i int64) *Number {
+	n := Number(strconv.FormatInt(i, 10))
+	return &n
+}
+
+// FloatNumber - the number of a float, formatted with the fewest digits
+// that represent it.
+func FloatNumber(f float64) *Number {
+	n := Number(strconv.FormatFloat(f, 'g', -1, 64))
+	return &n
+}
+
+// ParseNumber - the number of its text, an error if it is not a json
+// number.
+func ParseNumber(s string) (*Number, error) {
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return nil, fmt.Errorf("%q is not a number", s)
+	}
+	if !json.Valid([]byte(s)) {
+		return nil, fmt.Errorf("%q is not a json number", s)
+	}
+	n := Number(s)
+	return &n, nil
+}
+
+// String - the number as written.
+func (n Number) String() string {
+	return string(n)
+}
+
+// IsInt - true if the number is an integer that fits in an int64.
+func (n Number) IsInt() bool {
+	_, err := n.Int64()
+	return err == nil
+}
+
+// Int64 - the number as an int64, an error if it is not an integer.
+func (n Number) Int64() (int64, error) {
+	return json.Number(n).Int64()
+}
+
+// Float64 - the number as a float64, integers larger than 2^53 are
+// rounded.
+func (n Number) Float64() (float64, error) {
+	return json.Number(n).Float64()
+}
+
+// MarshalJSON - encodes the number as a json number.
+func (n Number) MarshalJSON() ([]byte, error) {
+	if n == "" {
+		return []byte("0"), nil
+	}
+	if _, err := ParseNumber(string(n)); err != nil {
+		return nil, err
+	}
+	return []byte(n), nil
+}
+
+// UnmarshalJSON - decodes a json number.
+func (n *Number) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseNumber(string(data))
+	if err != nil {
+		return err
+	}
+	*n = *parsed
+	return nil
+}
+
+// MarshalYAML - encodes the number as a yaml number.
+func (n Number) MarshalYAML() (interface{}, error) {
+	if i, err := n.Int64(); err == nil {
+		return i, nil
+	}
+	return n.Float64()
+}
+
+// UnmarshalYAML - decodes a yaml number, keeping its text when it is also
+// a json number. Yaml numbers json does not have, e.g. .5 or +1, are
+// formatted again.
+func (n *Number) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return fmt.Errorf("%q is not a number", s)
+	}
+	if parsed, err := ParseNumber(s); err == nil {
+		*n = *parsed
+		return nil
+	}
+	*n = *FloatNumber(f)
+	return nil
+}
+
+// NilableNumber - Number that could be nil (e.g. when omitted from json/yaml)
+//
+// Deprecated: NilableNumber loses the precision of large integers, use
+// Number. ToNumber and NilableNumberOf convert between the two, Bounds and
+// SetBounds between the validators of a parameter.
+type NilableNumber float64
+
+// ToNumber - the Number of the NilableNumber, nil if it is nil.
+func (n *NilableNumber) ToNumber() *Number {
+	if n == nil {
+		return nil
+	}
+	return FloatNumber(float64(*n))
+}
+
+// NilableNumberOf - the NilableNumber of the Number, nil if it is nil or
+// not a number.
+func NilableNumberOf(n *Number) *NilableNumber {
+	if n == nil {
+		return nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil
+	}
+	nn := NilableNumber(f)
+	return &nn
+}
+
+// NumberBounds - The number validators of a parameter.
+type NumberBounds struct {
+	MultipleOf       *Number
+	Maximum          *Number
+	ExclusiveMaximum *Number
+	Minimum          *Number
+	ExclusiveMinimum *Number
+}
+
+// Bounds - the number validators of the parameter. A Number validator wins
+// over the deprecated float64 one it replaces.
+func (pd *ParameterDescriptor) Bounds() NumberBounds {
+	b := NumberBounds{
+		MultipleOf:       pd.MultipleOfNumber,
+		Maximum:          pd.MaximumNumber,
+		ExclusiveMaximum: pd.ExclusiveMaximumNumber,
+		Minimum:          pd.MinimumNumber,
+		ExclusiveMinimum: pd.ExclusiveMinimumNumber,
+	}
+	if b.MultipleOf == nil && pd.MultipleOf > 0 {
+		b.MultipleOf = FloatNumber(pd.MultipleOf)
+	}
+	if b.Maximum == nil {
+		b.Maximum = pd.Maximum.ToNumber()
+	}
+	if b.ExclusiveMaximum == nil {
+		b.ExclusiveMaximum = pd.ExclusiveMaximum.ToNumber()
+	}
+	if b.Minimum == nil {
+		b.Minimum = pd.Minimum.ToNumber()
+	}
+	if b.ExclusiveMinimum == nil {
+		b.ExclusiveMinimum = pd.ExclusiveMinimum.ToNumber()
+	}
+	return b
+}
+
+// SetBounds - sets the Number validators of the parameter and the
+// deprecated float64 ones.
+func (pd *ParameterDescriptor) SetBounds(b NumberBounds) {
+	pd.MultipleOfNumber = b.MultipleOf
+	pd.MaximumNumber = b.Maximum
+	pd.ExclusiveMaximumNumber = b.ExclusiveMaximum
+	pd.MinimumNumber = b.Minimum
+	pd.ExclusiveMinimumNumber = b.ExclusiveMinimum
+
+	pd.MultipleOf = 0
+	if m := NilableNumberOf(b.MultipleOf); m != nil {
+		pd.MultipleOf = float64(*m)
+	}
+	pd.Maximum = NilableNumberOf(b.Maximum)
+	pd.ExclusiveMaximum = NilableNumberOf(b.ExclusiveMaximum)
+	pd.Minimum = NilableNumberOf(b.Minimum)
+	pd.ExclusiveMinimum = NilableNumberOf(b.ExclusiveMinimum)
+}
+
+// parameterDescriptor - ParameterDescriptor without its methods, to encode
+// and decode its fields.
+type parameterDescriptor ParameterDescriptor
+
+// MarshalJSON - encodes the descriptor with the validators set by either
+// of their fields.
+func (pd ParameterDescriptor) MarshalJSON() ([]byte, error) {
+	pd.SetBounds(pd.Bounds())
+	return json.Marshal(parameterDescriptor(pd))
+}
+
+// UnmarshalJSON - decodes the descriptor and syncs its validators.
+func (pd *ParameterDescriptor) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*parameterDescriptor)(pd)); err != nil {
+		return err
+	}
+	pd.SetBounds(pd.Bounds())
+	return nil
+}
+
+// MarshalYAML - encodes the descriptor with the validators set by either
+// of their fields.
+func (pd ParameterDescriptor) MarshalYAML() (interface{}, error) {
+	pd.SetBounds(pd.Bounds())
+	return parameterDescriptor(pd), nil
+}
+
+// UnmarshalYAML - decodes the descriptor and syncs its validators.
+func (pd *ParameterDescriptor) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal((*parameterDescriptor)(pd)); err != nil {
+		return err
+	}
+	pd.SetBounds(pd.Bounds())
+	return nil
+}
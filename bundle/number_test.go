@@ -0,0 +1,134 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestNumberPrecision(t *testing.T) {
+	pd := ParameterDescriptor{}
+	if err := json.Unmarshal([]byte(`{"maximum": 9007199254740993, "minimum": 0.5}`), &pd); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	max, err := pd.MaximumNumber.Int64()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, int64(9007199254740993), max)
+	assert.True(t, pd.MaximumNumber.IsInt())
+	assert.False(t, pd.MinimumNumber.IsInt())
+	min, err := pd.MinimumNumber.Float64()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, 0.5, min)
+
+	b, err := json.Marshal(pd)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Contains(t, string(b), `"maximum":9007199254740993`)
+	assert.Contains(t, string(b), `"minimum":0.5`)
+	assert.NotContains(t, string(b), "exclusiveMaximum")
+}
+
+func TestNumberYAML(t *testing.T) {
+	pd := ParameterDescriptor{}
+	err := yaml.Unmarshal([]byte("maximum: 9007199254740993\nexclusive_minimum: 1.50\nmultiple_of: .5\n"), &pd)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "9007199254740993", pd.MaximumNumber.String())
+	assert.Equal(t, "1.50", pd.ExclusiveMinimumNumber.String())
+	assert.Equal(t, "0.5", pd.MultipleOfNumber.String())
+	assert.Equal(t, 0.5, pd.MultipleOf)
+	assert.Nil(t, pd.MinimumNumber)
+	assert.Nil(t, pd.Minimum)
+
+	err = yaml.Unmarshal([]byte("maximum: ten\n"), &pd)
+	assert.Error(t, err)
+}
+
+func TestParseNumber(t *testing.T) {
+	testCases := []struct {
+		text  string
+		valid bool
+	}{
+		{text: "10", valid: true},
+		{text: "-1.5e3", valid: true},
+		{text: "ten"},
+		{text: "0x10"},
+		{text: "NaN"},
+		{text: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			n, err := ParseNumber(tc.text)
+			if !tc.valid {
+				assert.Error(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, tc.text, n.String())
+		})
+	}
+}
+
+func TestNilableNumberAdapters(t *testing.T) {
+	var nilable *NilableNumber
+	assert.Nil(t, nilable.ToNumber())
+	assert.Nil(t, NilableNumberOf(nil))
+
+	f := NilableNumber(2.5)
+	assert.Equal(t, "2.5", f.ToNumber().String())
+	assert.Equal(t, NilableNumber(10), *NilableNumberOf(IntNumber(10)))
+	assert.Equal(t, "10", FloatNumber(10).String())
+}
+
+func TestParameterDescriptorBounds(t *testing.T) {
+	// the deprecated validators are encoded
+	max := NilableNumber(10)
+	pd := ParameterDescriptor{Name: "replicas", Maximum: &max, MultipleOf: 2}
+	b, err := json.Marshal(pd)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Contains(t, string(b), `"maximum":10`)
+	assert.Contains(t, string(b), `"multipleOf":2`)
+
+	// and set when decoding
+	decoded := ParameterDescriptor{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, NilableNumber(10), *decoded.Maximum)
+	assert.Equal(t, "10", decoded.MaximumNumber.String())
+	assert.Equal(t, float64(2), decoded.MultipleOf)
+
+	// the Number validators win
+	pd.MaximumNumber = IntNumber(20)
+	assert.Equal(t, "20", pd.Bounds().Maximum.String())
+	assert.Equal(t, "2", pd.Bounds().MultipleOf.String())
+	assert.Nil(t, pd.Bounds().Minimum)
+}
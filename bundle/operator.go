@@ -0,0 +1,52 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"github.com/automationbroker/bundle-lib/runtime"
+)
+
+// OperatorBundle - An operator of an OLM catalog. Specs with one are
+// provisioned by subscribing the target namespace to the operator instead
+// of running a bundle, the plans are the channels of the package.
+type OperatorBundle struct {
+	Package                string `json:"package"`
+	CatalogSource          string `json:"catalog_source"`
+	CatalogSourceNamespace string `json:"catalog_source_namespace"`
+	DefaultChannel         string `json:"default_channel"`
+}
+
+// operatorSubscription - the subscription installing the operator of the
+// instance in its namespace, from the channel of the plan.
+func operatorSubscription(instance *ServiceInstance) runtime.OperatorSubscription {
+	op := instance.Spec.Operator
+	channel := instance.planName()
+	if _, ok := instance.Spec.GetPlan(channel); !ok || channel == "" {
+		channel = op.DefaultChannel
+	}
+	sub := runtime.OperatorSubscription{
+		Name:                   op.Package,
+		Package:                op.Package,
+		Channel:                channel,
+		CatalogSource:          op.CatalogSource,
+		CatalogSourceNamespace: op.CatalogSourceNamespace,
+	}
+	if instance.Context != nil {
+		sub.Namespace = instance.Context.Namespace
+	}
+	return sub
+}
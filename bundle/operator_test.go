@@ -0,0 +1,89 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func operatorInstance(plan string) *ServiceInstance {
+	return &ServiceInstance{
+		ID: uuid.NewUUID(),
+		Spec: &Spec{
+			FQName: "etcd",
+			Plans:  []Plan{{Name: "singlenamespace-alpha"}, {Name: "clusterwide-alpha"}},
+			Operator: &OperatorBundle{
+				Package:                "etcd",
+				CatalogSource:          "community-operators",
+				CatalogSourceNamespace: "openshift-marketplace",
+				DefaultChannel:         "singlenamespace-alpha",
+			},
+		},
+		Context:    &Context{Namespace: "target", Platform: "kubernetes"},
+		Parameters: &Parameters{PlanParameterKey: plan},
+	}
+}
+
+func TestOperatorSubscription(t *testing.T) {
+	testCases := []struct {
+		name    string
+		plan    string
+		channel string
+	}{
+		{name: "plan is the channel", plan: "clusterwide-alpha", channel: "clusterwide-alpha"},
+		{name: "unknown plan uses the default channel", plan: "stable", channel: "singlenamespace-alpha"},
+		{name: "no plan uses the default channel", plan: "", channel: "singlenamespace-alpha"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sub := operatorSubscription(operatorInstance(tc.plan))
+			assert.Equal(t, runtime.OperatorSubscription{
+				Name:                   "etcd",
+				Namespace:              "target",
+				Package:                "etcd",
+				Channel:                tc.channel,
+				CatalogSource:          "community-operators",
+				CatalogSourceNamespace: "openshift-marketplace",
+			}, sub)
+		})
+	}
+}
+
+func TestOperatorProvisionAndDeprovision(t *testing.T) {
+	rt := runtime.NewFakeRuntime()
+	runtime.Provider = rt
+	instance := operatorInstance("clusterwide-alpha")
+
+	for m := range NewExecutor(ExecutorConfig{}).Provision(instance) {
+		assert.NotEqual(t, StateFailed, m.State, m.Error)
+	}
+	subs := rt.Operators()
+	if len(subs) != 1 {
+		t.Fatalf("expected 1 subscription, got: %#+v", subs)
+	}
+	assert.Equal(t, "clusterwide-alpha", subs[0].Channel)
+	assert.Equal(t, "target", subs[0].Namespace)
+
+	for m := range NewExecutor(ExecutorConfig{}).Deprovision(instance) {
+		assert.NotEqual(t, StateFailed, m.State, m.Error)
+	}
+	assert.Empty(t, rt.Operators())
+}
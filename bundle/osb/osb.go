@@ -0,0 +1,147 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package osb converts bundle specs to Open Service Broker catalog objects.
+package osb
+
+import (
+	"strings"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	schema "github.com/lestrrat/go-jsschema"
+)
+
+// Service - A service of the Open Service Broker catalog.
+type Service struct {
+	ID            string                 `json:"id"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	Tags          []string               `json:"tags,omitempty"`
+	Requires      []string               `json:"requires,omitempty"`
+	Bindable      bool                   `json:"bindable"`
+	PlanUpdatable bool                   `json:"plan_updateable,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	Plans         []Plan                 `json:"plans"`
+}
+
+// Plan - A plan of a service in the Open Service Broker catalog.
+type Plan struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Free        bool                   `json:"free"`
+	Bindable    bool                   `json:"bindable,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Schemas     *bundle.Schema         `json:"schemas,omitempty"`
+}
+
+// ConvertSpecToService - converts a spec and its plans to a catalog service.
+func ConvertSpecToService(spec *bundle.Spec) (Service, error) {
+	plans := make([]Plan, 0, len(spec.Plans))
+	for _, p := range spec.Plans {
+		plan, err := ConvertPlan(p)
+		if err != nil {
+			return Service{}, err
+		}
+		plans = append(plans, plan)
+	}
+	return Service{
+		ID:            spec.ID,
+		Name:          spec.FQName,
+		Description:   spec.Description,
+		Tags:          spec.Tags,
+		Bindable:      spec.Bindable,
+		PlanUpdatable: planUpdatable(spec.Plans),
+		Metadata:      spec.Metadata,
+		Plans:         plans,
+	}, nil
+}
+
+// CostsKey - the catalog plan metadata key of the plan costs.
+const CostsKey = "costs"
+
+// Cost - A plan cost in the catalog plan metadata, the amount is keyed by
+// the lower case currency code, e.g. {"amount": {"usd": 10.0}, "unit":
+// "MONTHLY"}.
+type Cost struct {
+	Amount map[string]float64 `json:"amount"`
+	Unit   string             `json:"unit"`
+}
+
+// ConvertPlan - converts a plan to a catalog plan, the parameters are
+// converted to the plan schemas and the costs to the costs metadata.
+func ConvertPlan(plan bundle.Plan) (Plan, error) {
+	schemaPlans, err := bundle.ConvertPlansToSchema([]bundle.Plan{plan})
+	if err != nil {
+		return Plan{}, err
+	}
+	p := schemaPlans[0]
+	return Plan{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Free:        p.Free,
+		Bindable:    p.Bindable,
+		Metadata:    withCosts(p.Metadata, ConvertCosts(plan.CostEntries())),
+		Schemas:     &p.Schemas,
+	}, nil
+}
+
+// ConvertCosts - converts the plan costs to the catalog costs, the
+// entries with the same unit are grouped into one cost.
+func ConvertCosts(entries []bundle.CostEntry) []Cost {
+	costs := []Cost{}
+	for _, e := range entries {
+		i := 0
+		for i < len(costs) && costs[i].Unit != e.Unit {
+			i++
+		}
+		if i == len(costs) {
+			costs = append(costs, Cost{Amount: map[string]float64{}, Unit: e.Unit})
+		}
+		costs[i].Amount[strings.ToLower(e.Currency)] = e.Amount
+	}
+	return costs
+}
+
+func withCosts(metadata map[string]interface{}, costs []Cost) map[string]interface{} {
+	if len(costs) == 0 {
+		return metadata
+	}
+	m := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		m[k] = v
+	}
+	m[CostsKey] = costs
+	return m
+}
+
+// ConvertParameter - converts a parameter to its JSON Schema property.
+// Numbers that are not set are omitted, enum values are converted to the
+// parameter type and a default that is not one of the enum values is
+// dropped.
+func ConvertParameter(pd bundle.ParameterDescriptor) (*schema.Schema, error) {
+	return bundle.ParameterToSchema(pd)
+}
+
+func planUpdatable(plans []bundle.Plan) bool {
+	for _, plan := range plans {
+		if len(plan.UpdatesTo) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package osb
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	schema "github.com/lestrrat/go-jsschema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertSpecToService(t *testing.T) {
+	zero := bundle.NilableNumber(0)
+	spec := &bundle.Spec{
+		ID:          "spec-id",
+		FQName:      "dh-postgresql-apb",
+		Description: "PostgreSQL",
+		Tags:        []string{"database"},
+		Bindable:    true,
+		Metadata:    map[string]interface{}{"displayName": "PostgreSQL"},
+		Plans: []bundle.Plan{
+			{
+				ID:        "plan-id",
+				Name:      "dev",
+				Free:      true,
+				UpdatesTo: []string{"prod"},
+				Parameters: []bundle.ParameterDescriptor{
+					{Name: "replicas", Type: "int", Default: float64(1), Minimum: &zero, Required: true},
+					{Name: "version", Type: "enum", Enum: []string{"9.5", "9.6"}, Default: "10"},
+				},
+			},
+		},
+	}
+	service, err := ConvertSpecToService(spec)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "spec-id", service.ID)
+	assert.Equal(t, "dh-postgresql-apb", service.Name)
+	assert.True(t, service.Bindable)
+	assert.True(t, service.PlanUpdatable)
+	assert.Equal(t, 1, len(service.Plans))
+
+	plan := service.Plans[0]
+	assert.Equal(t, "plan-id", plan.ID)
+	assert.True(t, plan.Free)
+	params := plan.Schemas.ServiceInstance.Create["parameters"]
+	assert.Equal(t, []string{"replicas"}, params.Required)
+
+	replicas := params.Properties["replicas"]
+	assert.Equal(t, 1, replicas.Default)
+	assert.True(t, replicas.Minimum.Initialized)
+	assert.Equal(t, float64(0), replicas.Minimum.Val)
+	assert.False(t, replicas.Maximum.Initialized)
+
+	version := params.Properties["version"]
+	assert.Nil(t, version.Default)
+	assert.Equal(t, []interface{}{"9.5", "9.6"}, version.Enum)
+}
+
+func TestConvertParameter(t *testing.T) {
+	testCases := []struct {
+		name            string
+		param           bundle.ParameterDescriptor
+		expectedType    schema.PrimitiveType
+		expectedEnum    []interface{}
+		expectedDefault interface{}
+		shouldErr       bool
+	}{
+		{
+			name:            "integer enum",
+			param:           bundle.ParameterDescriptor{Name: "size", Type: "int", Enum: []string{"1", "2"}, Default: float64(2)},
+			expectedType:    schema.IntegerType,
+			expectedEnum:    []interface{}{1, 2},
+			expectedDefault: 2,
+		},
+		{
+			name:            "boolean enum",
+			param:           bundle.ParameterDescriptor{Name: "ha", Type: "boolean", Enum: []string{"true", "false"}, Default: false},
+			expectedType:    schema.BooleanType,
+			expectedEnum:    []interface{}{true, false},
+			expectedDefault: false,
+		},
+		{
+			name:      "unknown type",
+			param:     bundle.ParameterDescriptor{Name: "foo", Type: "unknown"},
+			shouldErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prop, err := ConvertParameter(tc.param)
+			if tc.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, tc.expectedType, prop.Type[0])
+			assert.Equal(t, tc.expectedEnum, prop.Enum)
+			assert.Equal(t, tc.expectedDefault, prop.Default)
+		})
+	}
+}
+
+func TestConvertCosts(t *testing.T) {
+	entries := []bundle.CostEntry{
+		{Amount: 10, Currency: "USD", Unit: "MONTHLY"},
+		{Amount: 9, Currency: "EUR", Unit: "MONTHLY"},
+		{Amount: 0.02, Currency: "USD", Unit: "HOURLY"},
+	}
+	expected := []Cost{
+		{Amount: map[string]float64{"usd": 10, "eur": 9}, Unit: "MONTHLY"},
+		{Amount: map[string]float64{"usd": 0.02}, Unit: "HOURLY"},
+	}
+	assert.Equal(t, expected, ConvertCosts(entries))
+
+	plan, err := ConvertPlan(bundle.Plan{ID: "plan-id", Name: "prod", Costs: entries})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, expected, plan.Metadata[CostsKey])
+}
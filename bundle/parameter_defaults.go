@@ -0,0 +1,163 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// CoerceDefault - converts the default of a parameter to the go type of the
+// parameter type, whatever the decoder it went through:
+//
+//	int, integer  int, from integral numbers and numeric strings
+//	number        float64, from numbers and numeric strings
+//	bool, boolean bool, from booleans and "true" or "false"
+//	string, enum  string, numbers and booleans are formatted
+//	object        map[string]interface{}
+//	array         []interface{}
+//
+// A nil default stays nil and the defaults of the other types are returned
+// unchanged. An error is returned when the default can not be converted,
+// e.g. 1.5 for an int parameter.
+func CoerceDefault(paramType string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch strings.ToLower(paramType) {
+	case "int", "integer":
+		return coerceInt(value)
+	case "number":
+		return coerceFloat(value)
+	case "bool", "boolean":
+		return coerceBool(value)
+	case "string", "enum":
+		return coerceString(value)
+	case "object":
+		m, ok := stringKeys(value).(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%v is not an object", value)
+		}
+		return m, nil
+	case "array":
+		s, ok := stringKeys(value).([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%v is not an array", value)
+		}
+		return s, nil
+	}
+	return value, nil
+}
+
+// TypedDefault - the default of the parameter converted to its type, see
+// CoerceDefault.
+func (pd ParameterDescriptor) TypedDefault() (interface{}, error) {
+	def, err := CoerceDefault(pd.Type, pd.Default)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default of %v parameter %v - %v", pd.Type, pd.Name, err)
+	}
+	return def, nil
+}
+
+// coerceDefaults - converts the defaults of the parameters to their type.
+// The defaults that can not be converted are kept, LintSpec reports them.
+func coerceDefaults(params []ParameterDescriptor) {
+	for i := range params {
+		if def, err := params[i].TypedDefault(); err == nil {
+			params[i].Default = def
+		}
+	}
+}
+
+func coerceInt(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i), nil
+		}
+	case string:
+		if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return int(i), nil
+		}
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() <= math.MaxInt64 {
+			return int(rv.Uint()), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+			return int(f), nil
+		}
+	}
+	return nil, fmt.Errorf("%v is not an integer", value)
+}
+
+func coerceFloat(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f, nil
+		}
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, nil
+		}
+	}
+	if f, ok := toFloat(reflect.ValueOf(value)); ok {
+		return f, nil
+	}
+	return nil, fmt.Errorf("%v is not a number", value)
+}
+
+func coerceBool(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("%v is not a boolean", value)
+}
+
+func coerceString(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool, json.Number:
+		return fmt.Sprint(v), nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(value), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), nil
+	}
+	return nil, fmt.Errorf("%v is not a string", value)
+}
@@ -0,0 +1,99 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoerceDefault(t *testing.T) {
+	testCases := []struct {
+		name     string
+		typ      string
+		value    interface{}
+		expected interface{}
+		err      bool
+	}{
+		{name: "int from float", typ: "int", value: float64(3), expected: 3},
+		{name: "int from json number", typ: "int", value: json.Number("9007199254740993"), expected: 9007199254740993},
+		{name: "int from string", typ: "INTEGER", value: " 42 ", expected: 42},
+		{name: "int from fraction", typ: "int", value: 1.5, err: true},
+		{name: "int from word", typ: "int", value: "ten", err: true},
+		{name: "number from int", typ: "number", value: 2, expected: float64(2)},
+		{name: "number from string", typ: "number", value: "0.25", expected: 0.25},
+		{name: "number from bool", typ: "number", value: true, err: true},
+		{name: "bool from string", typ: "boolean", value: "true", expected: true},
+		{name: "bool from int", typ: "bool", value: 1, err: true},
+		{name: "string from int", typ: "string", value: 10, expected: "10"},
+		{name: "string from float", typ: "enum", value: 9.6, expected: "9.6"},
+		{name: "string from bool", typ: "string", value: false, expected: "false"},
+		{name: "string from map", typ: "string", value: map[string]interface{}{}, err: true},
+		{
+			name:     "object",
+			typ:      "object",
+			value:    map[interface{}]interface{}{"size": 1},
+			expected: map[string]interface{}{"size": 1},
+		},
+		{name: "object from string", typ: "object", value: "size", err: true},
+		{name: "array", typ: "array", value: []interface{}{1, "a"}, expected: []interface{}{1, "a"}},
+		{name: "unknown type", typ: "secret", value: 1, expected: 1},
+		{name: "nil", typ: "int", value: nil, expected: nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := CoerceDefault(tc.typ, tc.value)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestUnmarshalSpecYAMLDefaults(t *testing.T) {
+	data := `
+name: postgresql-apb
+plans:
+  - name: dev
+    parameters:
+      - name: replicas
+        type: int
+        default: "3"
+      - name: backups
+        type: boolean
+        default: "yes"
+      - name: version
+        type: enum
+        default: 9.6
+`
+	spec, err := UnmarshalSpecYAML([]byte(data), nil)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	params := spec.Plans[0].Parameters
+	assert.Equal(t, 3, params[0].Default)
+	// defaults that can not be converted are kept
+	assert.Equal(t, "yes", params[1].Default)
+	assert.Equal(t, "9.6", params[2].Default)
+}
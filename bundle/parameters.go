@@ -0,0 +1,230 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+	schema "github.com/lestrrat/go-jsschema"
+)
+
+// ErrInvalidParameters - The parameters of an action do not match the
+// parameters declared by the plan.
+type ErrInvalidParameters struct {
+	Action string
+	Errors []string
+}
+
+func (e ErrInvalidParameters) Error() string {
+	return fmt.Sprintf("invalid %v parameters: %v", e.Action, strings.Join(e.Errors, ", "))
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrInvalidParameters) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrInvalidParameters - true if the error is an ErrInvalidParameters.
+func IsErrInvalidParameters(err error) bool {
+	_, ok := err.(ErrInvalidParameters)
+	return ok
+}
+
+// brokerParameter - true for the keys added to the parameters by the broker
+// and the executor rather than by the user, including every key with the
+// BrokerParameterPrefix, e.g. the _apb_service_binding_id of a bind.
+func brokerParameter(key string) bool {
+	if strings.HasPrefix(key, BrokerParameterPrefix) {
+		return true
+	}
+	switch key {
+	case ClusterKey, NamespaceKey, TargetNamespacesKey, ProvisionCredentialsKey,
+		BindCredentialsKey, PlanParameterKey, NamespaceLabelsKey, NamespaceAnnotationsKey:
+		return true
+	}
+	return false
+}
+
+// ValidateParameters - validates the parameters against the JSON schema of
+// the descriptors, the one published in the catalog. Keys that are not
+// declared are rejected, except the ones added by the broker.
+func ValidateParameters(action string, descriptors []ParameterDescriptor, parameters Parameters) error {
+	properties, err := extractProperties(descriptors)
+	if err != nil {
+		return err
+	}
+	errs := []string{}
+	for _, name := range extractRequired(descriptors) {
+		if _, ok := parameters[name]; !ok {
+			errs = append(errs, fmt.Sprintf("%v is required", name))
+		}
+	}
+	keys := make([]string, 0, len(parameters))
+	for key := range parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if brokerParameter(key) {
+			continue
+		}
+		prop, ok := properties[key]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%v is not a declared parameter", key))
+			continue
+		}
+		if err := validateValue(prop, parameters[key]); err != nil {
+			errs = append(errs, fmt.Sprintf("%v %v", key, err))
+		}
+	}
+	if len(errs) > 0 {
+		return ErrInvalidParameters{Action: action, Errors: errs}
+	}
+	return nil
+}
+
+// validateBindParameters - validates the parameters of bind and unbind
+// against the bind parameters of the plan of the instance. The parameters
+// are not validated when the plan is unknown.
+func validateBindParameters(action string, instance *ServiceInstance, parameters *Parameters) error {
+	if instance.Spec == nil {
+		return nil
+	}
+	plan, ok := instance.Spec.GetPlan(instance.planName())
+	if !ok {
+		log.Debugf("unknown plan %q of %v, not validating the %v parameters",
+			instance.planName(), instance.Spec.FQName, action)
+		return nil
+	}
+	var params Parameters
+	if parameters != nil {
+		params = *parameters
+	}
+	if err := ValidateParameters(action, plan.BindParameters, params); err != nil {
+		log.Errorf("rejecting the %v parameters of %v - %v", action, instance.Spec.FQName, err)
+		return err
+	}
+	return nil
+}
+
+// validateValue - validates a value against the schema of its parameter.
+func validateValue(prop *schema.Schema, value interface{}) error {
+	t := prop.Type[0]
+	if value == nil {
+		if t == schema.NullType {
+			return nil
+		}
+		return fmt.Errorf("must be of type %v", t)
+	}
+	v := reflect.ValueOf(value)
+	switch t {
+	case schema.StringType:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be of type %v", t)
+		}
+		if err := validateString(prop, s); err != nil {
+			return err
+		}
+	case schema.IntegerType, schema.NumberType:
+		f, ok := toFloat(v)
+		if !ok || (t == schema.IntegerType && f != math.Trunc(f)) {
+			return fmt.Errorf("must be of type %v", t)
+		}
+		if err := validateNumber(prop, f); err != nil {
+			return err
+		}
+	case schema.BooleanType:
+		if v.Kind() != reflect.Bool {
+			return fmt.Errorf("must be of type %v", t)
+		}
+	case schema.ObjectType:
+		if v.Kind() != reflect.Map {
+			return fmt.Errorf("must be of type %v", t)
+		}
+	case schema.ArrayType:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return fmt.Errorf("must be of type %v", t)
+		}
+	case schema.NullType:
+		return fmt.Errorf("must be of type %v", t)
+	}
+	if len(prop.Enum) > 0 {
+		for _, e := range prop.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %v", prop.Enum)
+	}
+	return nil
+}
+
+func validateString(prop *schema.Schema, s string) error {
+	if prop.MaxLength.Initialized && len(s) > prop.MaxLength.Val {
+		return fmt.Errorf("must be at most %v characters", prop.MaxLength.Val)
+	}
+	if prop.MinLength.Initialized && len(s) < prop.MinLength.Val {
+		return fmt.Errorf("must be at least %v characters", prop.MinLength.Val)
+	}
+	if prop.Pattern != nil && !prop.Pattern.MatchString(s) {
+		return fmt.Errorf("must match %v", prop.Pattern)
+	}
+	return nil
+}
+
+func validateNumber(prop *schema.Schema, f float64) error {
+	if prop.MultipleOf.Initialized && math.Mod(f, prop.MultipleOf.Val) != 0 {
+		return fmt.Errorf("must be a multiple of %v", prop.MultipleOf.Val)
+	}
+	if prop.Maximum.Initialized {
+		if prop.ExclusiveMaximum.Val && f >= prop.Maximum.Val {
+			return fmt.Errorf("must be less than %v", prop.Maximum.Val)
+		}
+		if f > prop.Maximum.Val {
+			return fmt.Errorf("must be at most %v", prop.Maximum.Val)
+		}
+	}
+	if prop.Minimum.Initialized {
+		if prop.ExclusiveMinimum.Val && f <= prop.Minimum.Val {
+			return fmt.Errorf("must be greater than %v", prop.Minimum.Val)
+		}
+		if f < prop.Minimum.Val {
+			return fmt.Errorf("must be at least %v", prop.Minimum.Val)
+		}
+	}
+	return nil
+}
+
+// toFloat - the value of a number decoded from JSON or set in go.
+func toFloat(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	}
+	return 0, false
+}
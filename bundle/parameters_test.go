@@ -0,0 +1,108 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateParameters(t *testing.T) {
+	max := NilableNumber(10)
+	descriptors := []ParameterDescriptor{
+		{Name: "user", Type: "string", Required: true, MaxLength: 8, Pattern: "^[a-z]+$"},
+		{Name: "replicas", Type: "int", Maximum: &max},
+		{Name: "tier", Type: "enum", Enum: []string{"gold", "silver"}},
+		{Name: "admin", Type: "boolean"},
+	}
+	testCases := []struct {
+		name       string
+		parameters Parameters
+		errors     []string
+	}{
+		{
+			name: "valid",
+			parameters: Parameters{
+				"user": "foo", "replicas": float64(3), "tier": "gold", "admin": true,
+				PlanParameterKey: "dev", ProvisionCredentialsKey: map[string]interface{}{},
+			},
+		},
+		{
+			name: "broker keys",
+			parameters: Parameters{
+				"user":                      "foo",
+				"_apb_service_instance_id":  "3b8a2d7e-4f5c-4b3a-9d1e-7f6a5b4c3d2e",
+				"_apb_service_binding_id":   "9c1d2e3f-4a5b-4c6d-8e7f-0a1b2c3d4e5f",
+				"_apb_last_requesting_user": "admin",
+			},
+		},
+		{
+			name:       "missing required",
+			parameters: Parameters{"replicas": 3},
+			errors:     []string{"user is required"},
+		},
+		{
+			name:       "undeclared",
+			parameters: Parameters{"user": "foo", "extra": "bar"},
+			errors:     []string{"extra is not a declared parameter"},
+		},
+		{
+			name: "invalid values",
+			parameters: Parameters{
+				"user": "FooBarBaz", "replicas": 2.5, "tier": "bronze", "admin": "yes",
+			},
+			errors: []string{
+				"admin must be of type boolean",
+				"replicas must be of type integer",
+				"tier must be one of [gold silver]",
+				"user must be at most 8 characters",
+			},
+		},
+		{
+			name:       "above maximum",
+			parameters: Parameters{"user": "foo", "replicas": 11},
+			errors:     []string{"replicas must be at most 10"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateParameters(bindAction, descriptors, tc.parameters)
+			if tc.errors == nil {
+				if err != nil {
+					t.Fatalf("unknown error occured: %v", err)
+				}
+				return
+			}
+			if !IsErrInvalidParameters(err) {
+				t.Fatalf("expected invalid parameters got: %v", err)
+			}
+			assert.Equal(t, tc.errors, err.(ErrInvalidParameters).Errors)
+		})
+	}
+}
+
+func TestValidateBindParametersUnknownPlan(t *testing.T) {
+	instance := &ServiceInstance{
+		Spec:       &Spec{FQName: "fq-name", Plans: []Plan{{Name: "dev"}}},
+		Parameters: &Parameters{PlanParameterKey: "prod"},
+	}
+	err := validateBindParameters(unbindAction, instance, &Parameters{"anything": "goes"})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"crypto/md5"
+	"fmt"
+
+	"github.com/pborman/uuid"
+)
+
+const (
+	// PlanIDStable - plan IDs are name based UUIDs of the registry, the
+	// FQName of the spec and the plan name, see StablePlanID. Opt-in, the
+	// brokers migrate their stored IDs with PlanIDMigrations.
+	PlanIDStable = "stable"
+	// PlanIDLegacy - plan IDs are the md5 of the FQName of the spec and
+	// the plan name, the IDs brokers generated before stable IDs. The
+	// default, it keeps the IDs stored by the brokers valid, see
+	// LegacyPlanID.
+	PlanIDLegacy = "legacy"
+)
+
+// planIDNamespace - the UUID namespace of the stable plan IDs. It must
+// never change, every stored plan ID depends on it.
+var planIDNamespace = uuid.Parse("5d0a4c7e-8b6e-4b0c-9a4e-2f3b1c6d7e80")
+
+// StablePlanID - the plan ID derived from the registry, the FQName of the
+// spec and the plan name. It is the same on every load of the spec, by
+// every broker.
+func StablePlanID(registry, fqname, plan string) string {
+	return uuid.NewSHA1(planIDNamespace, []byte(registry+"/"+fqname+"/"+plan)).String()
+}
+
+// LegacyPlanID - the plan ID brokers generated before stable IDs, the md5
+// of the FQName of the spec followed by the plan name.
+func LegacyPlanID(fqname, plan string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(fqname+plan)))
+}
+
+// AssignPlanIDs - sets the ID of every plan of the spec according to the
+// policy, PlanIDLegacy when empty. The registry of the stable IDs is the
+// one of the Provenance of the spec.
+func AssignPlanIDs(spec *Spec, policy string) {
+	registry := ""
+	if spec.Provenance != nil {
+		registry = spec.Provenance.Registry
+	}
+	for i := range spec.Plans {
+		plan := &spec.Plans[i]
+		switch policy {
+		case PlanIDStable:
+			plan.ID = StablePlanID(registry, spec.FQName, plan.Name)
+		default:
+			plan.ID = LegacyPlanID(spec.FQName, plan.Name)
+		}
+	}
+}
+
+// MigratePlanID - the current ID of the plan of the spec that the stored
+// ID refers to, whether it was assigned with the stable or the legacy
+// policy. False if the ID is not one of a plan of the spec.
+func (s *Spec) MigratePlanID(id string) (string, bool) {
+	registry := ""
+	if s.Provenance != nil {
+		registry = s.Provenance.Registry
+	}
+	for _, plan := range s.Plans {
+		switch id {
+		case plan.ID, LegacyPlanID(s.FQName, plan.Name), StablePlanID(registry, s.FQName, plan.Name):
+			return plan.ID, true
+		}
+	}
+	return "", false
+}
+
+// PlanIDMigrations - the current plan ID of every previously assigned plan
+// ID of the specs that differs from it, for brokers to rewrite the plan
+// IDs they stored when the policy changes.
+func PlanIDMigrations(specs []*Spec) map[string]string {
+	migrations := map[string]string{}
+	for _, s := range specs {
+		registry := ""
+		if s.Provenance != nil {
+			registry = s.Provenance.Registry
+		}
+		for _, plan := range s.Plans {
+			for _, old := range []string{LegacyPlanID(s.FQName, plan.Name), StablePlanID(registry, s.FQName, plan.Name)} {
+				if old != plan.ID {
+					migrations[old] = plan.ID
+				}
+			}
+		}
+	}
+	return migrations
+}
@@ -0,0 +1,81 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func planIDSpec() *Spec {
+	return &Spec{
+		FQName:     "dh-postgresql-apb",
+		Plans:      []Plan{{Name: "dev"}, {Name: "prod"}},
+		Provenance: &Provenance{Registry: "dh"},
+	}
+}
+
+func TestAssignPlanIDs(t *testing.T) {
+	spec := planIDSpec()
+	AssignPlanIDs(spec, PlanIDStable)
+	dev := spec.Plans[0].ID
+	assert.Equal(t, StablePlanID("dh", "dh-postgresql-apb", "dev"), dev)
+	assert.NotEqual(t, dev, spec.Plans[1].ID)
+
+	// the IDs are the same on every load
+	again := planIDSpec()
+	AssignPlanIDs(again, PlanIDStable)
+	assert.Equal(t, dev, again.Plans[0].ID)
+
+	// another registry gets other IDs
+	other := planIDSpec()
+	other.Provenance.Registry = "quay"
+	AssignPlanIDs(other, PlanIDStable)
+	assert.NotEqual(t, dev, other.Plans[0].ID)
+
+	legacy := planIDSpec()
+	AssignPlanIDs(legacy, PlanIDLegacy)
+	assert.Equal(t, "e30bef520c3e1bdd32ce8ecd59a3a5ec", legacy.Plans[0].ID)
+
+	// legacy is the default
+	unset := planIDSpec()
+	AssignPlanIDs(unset, "")
+	assert.Equal(t, legacy.Plans[0].ID, unset.Plans[0].ID)
+}
+
+func TestMigratePlanID(t *testing.T) {
+	spec := planIDSpec()
+	AssignPlanIDs(spec, PlanIDStable)
+	dev := spec.Plans[0].ID
+	legacyDev := LegacyPlanID("dh-postgresql-apb", "dev")
+
+	id, ok := spec.MigratePlanID(legacyDev)
+	assert.True(t, ok)
+	assert.Equal(t, dev, id)
+	id, ok = spec.MigratePlanID(dev)
+	assert.True(t, ok)
+	assert.Equal(t, dev, id)
+	_, ok = spec.MigratePlanID("unknown")
+	assert.False(t, ok)
+
+	migrations := PlanIDMigrations([]*Spec{spec})
+	assert.Equal(t, map[string]string{
+		legacyDev: dev,
+		LegacyPlanID("dh-postgresql-apb", "prod"): spec.Plans[1].ID,
+	}, migrations)
+}
@@ -0,0 +1,226 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/automationbroker/bundle-lib/authorization"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+// previewPodName - the name of the bundle pod in a preview, the real one is
+// generated when the instance is provisioned.
+const previewPodName = "bundle-<generated>"
+
+// PreviewObject - A sandbox object the provision would create.
+type PreviewObject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Note - when the object is only created in some cases, or its name is
+	// generated.
+	Note string `json:"note,omitempty"`
+}
+
+// PreviewRoleBinding - A role the service account of the sandbox would be
+// granted.
+type PreviewRoleBinding struct {
+	Namespace      string `json:"namespace"`
+	ClusterRole    string `json:"cluster_role"`
+	ServiceAccount string `json:"service_account"`
+}
+
+// PreviewReport - What provisioning an instance would do, for approval
+// workflows run before the real execution. Nothing is created to build
+// the report.
+type PreviewReport struct {
+	Action string `json:"action"`
+	Spec   string `json:"spec"`
+	Plan   string `json:"plan"`
+	Image  string `json:"image,omitempty"`
+	// ImageDigest - the digest the image resolves to, empty when the
+	// executor has no DigestResolver and the image is not pinned.
+	ImageDigest string `json:"image_digest,omitempty"`
+	// RecordedDigest - the digest recorded when the catalog was loaded.
+	RecordedDigest string `json:"recorded_digest,omitempty"`
+	// Parameters - the parameters of the instance, redacted.
+	Parameters Parameters `json:"parameters"`
+	// Requires - the specs provisioned before this one.
+	Requires []string `json:"requires,omitempty"`
+	// Objects - the sandbox objects created for the run, deleted with
+	// the sandbox.
+	Objects []PreviewObject `json:"objects"`
+	// RoleBindings - the RBAC granted to the bundle while it runs.
+	RoleBindings []PreviewRoleBinding `json:"role_bindings"`
+	// Secrets - the secrets copied into the sandbox namespace.
+	Secrets []string `json:"secrets"`
+	// Problems - why the provision would be refused, e.g. invalid
+	// parameters or a denied image.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Allowed - true if no check refuses the provision.
+func (r PreviewReport) Allowed() bool {
+	return len(r.Problems) == 0
+}
+
+// Preview - reports what provisioning the instance would do: the image
+// that would run, the validated parameters and the sandbox that would be
+// created. The checks of the provision are run and their failures are
+// reported as Problems, an error is only returned when the instance can
+// not be previewed.
+func (e *executor) Preview(instance *ServiceInstance) (*PreviewReport, error) {
+	if instance == nil || instance.Spec == nil {
+		return nil, liberrors.New(liberrors.CodeValidation, "the instance to preview has no spec")
+	}
+	spec := instance.Spec
+	report := &PreviewReport{
+		Action:         string(executionMethodProvision),
+		Spec:           spec.FQName,
+		Plan:           instance.planName(),
+		Image:          spec.Image,
+		RecordedDigest: ImageReferenceOf(spec).Digest,
+		Parameters:     instance.redactedParameters(instance.Parameters),
+		Objects:        []PreviewObject{},
+		RoleBindings:   []PreviewRoleBinding{},
+		Secrets:        []string{},
+	}
+	problem := func(err error) {
+		if err != nil {
+			report.Problems = append(report.Problems, err.Error())
+		}
+	}
+	for _, r := range spec.Requires {
+		report.Requires = append(report.Requires, r.FQName)
+	}
+
+	if spec.Image == "" && spec.requiresImage() {
+		problem(fmt.Errorf("the spec has no image"))
+	}
+	problem(e.authorize(authorization.Action(executionMethodProvision), instance))
+	problem(CheckImagePolicy(e.imagePolicy, spec))
+	problem(e.previewDigest(report, spec))
+	problem(e.checkQuota(report.Action, instance))
+	problem(previewParameters(instance))
+
+	if spec.Operator != nil {
+		sub := operatorSubscription(instance)
+		report.Objects = append(report.Objects, PreviewObject{
+			Kind: "Subscription", Name: sub.Name, Namespace: sub.Namespace,
+			Note: "installed by OLM, no sandbox is created",
+		})
+		return report, nil
+	}
+	if instance.Context == nil {
+		problem(fmt.Errorf("the instance has no context namespace"))
+		return report, nil
+	}
+	e.previewSandbox(report, instance)
+	return report, nil
+}
+
+// previewDigest - resolves the digest of the image once and runs the
+// drift check against it.
+func (e *executor) previewDigest(report *PreviewReport, spec *Spec) error {
+	if i := strings.Index(spec.Image, "@"); i >= 0 {
+		report.ImageDigest = spec.Image[i+1:]
+		return nil
+	}
+	if e.digestResolver == nil || spec.Image == "" {
+		return nil
+	}
+	digest, err := e.digestResolver.ResolveDigest(spec)
+	if err != nil {
+		log.Warningf("unable to resolve the digest of image %v - %v", spec.Image, err)
+	}
+	report.ImageDigest = digest
+	resolved := DigestResolverFunc(func(*Spec) (string, error) {
+		return digest, err
+	})
+	_, err = CheckImageDrift(resolved, e.imageDriftPolicy, spec)
+	return err
+}
+
+// previewParameters - validates the provision parameters against the plan.
+func previewParameters(instance *ServiceInstance) error {
+	plan, ok := instance.Spec.GetPlan(instance.planName())
+	if !ok {
+		return fmt.Errorf("unknown plan %q", instance.planName())
+	}
+	var params Parameters
+	if instance.Parameters != nil {
+		params = *instance.Parameters
+	}
+	return ValidateParameters(string(executionMethodProvision), plan.Parameters, params)
+}
+
+// previewSandbox - the objects createSandbox and executeApb would create.
+// The runtime can grant another role than the sandbox role of the cluster
+// config, e.g. on openshift.
+func (e *executor) previewSandbox(report *PreviewReport, instance *ServiceInstance) {
+	namespace := instance.Context.Namespace
+	targets := instance.Context.TargetNamespaces()
+	if !e.skipCreateNS {
+		namespace = fmt.Sprintf("%s-%.4s-", instance.Spec.FQName, executionMethodProvision)
+		report.Objects = append(report.Objects, PreviewObject{
+			Kind: "Namespace", Name: namespace, Note: "the name is completed with a generated suffix",
+		})
+	}
+	report.Objects = append(report.Objects, PreviewObject{
+		Kind: "ServiceAccount", Name: previewPodName, Namespace: namespace,
+	})
+	bindings := append([]string{namespace}, targets...)
+	seen := map[string]bool{}
+	for _, ns := range bindings {
+		if seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		report.Objects = append(report.Objects, PreviewObject{
+			Kind: "RoleBinding", Name: previewPodName, Namespace: ns,
+		})
+		report.RoleBindings = append(report.RoleBindings, PreviewRoleBinding{
+			Namespace:      ns,
+			ClusterRole:    clusterConfig.SandboxRole,
+			ServiceAccount: previewPodName,
+		})
+	}
+	// the bundle runs outside of the targets and needs network access
+	if !e.skipCreateNS {
+		report.Objects = append(report.Objects, PreviewObject{
+			Kind: "NetworkPolicy", Name: previewPodName, Namespace: targets[0],
+			Note: "only when the namespace already has network policies",
+		})
+	}
+
+	secrets := getSecrets(instance.Spec)
+	sort.Strings(secrets)
+	for _, secret := range secrets {
+		report.Secrets = append(report.Secrets, secret)
+		report.Objects = append(report.Objects, PreviewObject{
+			Kind: "Secret", Name: secret, Namespace: namespace,
+			Note: fmt.Sprintf("copied from %v", clusterConfig.Namespace),
+		})
+	}
+	report.Objects = append(report.Objects, PreviewObject{
+		Kind: "Pod", Name: previewPodName, Namespace: namespace,
+	})
+}
@@ -0,0 +1,130 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func previewInstance() *ServiceInstance {
+	return &ServiceInstance{
+		ID: uuid.NewRandom(),
+		Spec: &Spec{
+			FQName:     "postgresql-apb",
+			Image:      "docker.io/postgresql-apb:latest",
+			Provenance: &Provenance{Digest: "sha256:old"},
+			Plans: []Plan{{
+				Name: "dev",
+				Parameters: []ParameterDescriptor{
+					{Name: "user", Type: "string", Required: true},
+					{Name: "password", Type: "string", DisplayType: PasswordDisplayType},
+				},
+			}},
+		},
+		Context: &Context{Namespace: "target", Targets: []string{"extra"}},
+		Parameters: &Parameters{
+			PlanParameterKey: "dev",
+			"user":           "admin",
+			"password":       "secret",
+		},
+	}
+}
+
+func TestExecutorPreview(t *testing.T) {
+	InitializeClusterConfig(ClusterConfig{SandboxRole: "edit", Namespace: "broker"})
+	defer InitializeClusterConfig(ClusterConfig{})
+
+	e := &executor{
+		imageDriftPolicy: ImageDriftWarn,
+		digestResolver: DigestResolverFunc(func(*Spec) (string, error) {
+			return "sha256:new", nil
+		}),
+	}
+	report, err := e.Preview(previewInstance())
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.True(t, report.Allowed())
+	assert.Equal(t, "provision", report.Action)
+	assert.Equal(t, "dev", report.Plan)
+	assert.Equal(t, "sha256:new", report.ImageDigest)
+	assert.Equal(t, "sha256:old", report.RecordedDigest)
+	assert.Equal(t, RedactedValue, report.Parameters["password"])
+	assert.Equal(t, "admin", report.Parameters["user"])
+	assert.Equal(t, []PreviewRoleBinding{
+		{Namespace: "postgresql-apb-prov-", ClusterRole: "edit", ServiceAccount: previewPodName},
+		{Namespace: "target", ClusterRole: "edit", ServiceAccount: previewPodName},
+		{Namespace: "extra", ClusterRole: "edit", ServiceAccount: previewPodName},
+	}, report.RoleBindings)
+	kinds := []string{}
+	for _, o := range report.Objects {
+		kinds = append(kinds, o.Kind)
+	}
+	assert.Equal(t, []string{
+		"Namespace", "ServiceAccount", "RoleBinding", "RoleBinding", "RoleBinding", "NetworkPolicy", "Pod",
+	}, kinds)
+	assert.Equal(t, []string{}, report.Secrets)
+}
+
+func TestExecutorPreviewProblems(t *testing.T) {
+	InitializeClusterConfig(ClusterConfig{SandboxRole: "admin"})
+	defer InitializeClusterConfig(ClusterConfig{})
+
+	instance := previewInstance()
+	(*instance.Parameters)["user"] = 10
+	(*instance.Parameters)["size"] = "large"
+	e := &executor{
+		skipCreateNS:     true,
+		imageDriftPolicy: ImageDriftFail,
+		digestResolver: DigestResolverFunc(func(*Spec) (string, error) {
+			return "sha256:new", nil
+		}),
+		quotaChecker: QuotaCheckerFunc(func(req QuotaRequest) error {
+			return ErrQuotaExceeded{Namespace: req.Namespace, Plan: req.Plan, Limit: 1, Current: 1}
+		}),
+	}
+	report, err := e.Preview(instance)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.False(t, report.Allowed())
+	assert.Equal(t, 3, len(report.Problems))
+	assert.Contains(t, report.Problems[0], "changed since the catalog was loaded")
+	assert.Contains(t, report.Problems[2], "size is not a declared parameter")
+	assert.Contains(t, report.Problems[2], "user must be of type string")
+
+	// the sandbox is the context namespace
+	assert.Equal(t, []PreviewRoleBinding{
+		{Namespace: "target", ClusterRole: "admin", ServiceAccount: previewPodName},
+		{Namespace: "extra", ClusterRole: "admin", ServiceAccount: previewPodName},
+	}, report.RoleBindings)
+	for _, o := range report.Objects {
+		assert.NotEqual(t, "Namespace", o.Kind)
+		assert.NotEqual(t, "NetworkPolicy", o.Kind)
+	}
+
+	_, err = e.Preview(&ServiceInstance{})
+	assert.Error(t, err)
+}
+
+func TestNewExecutorPreview(t *testing.T) {
+	_, ok := NewExecutor(ExecutorConfig{}).(ExecutorPreview)
+	assert.True(t, ok, "expected the executor to implement ExecutorPreview")
+}
@@ -0,0 +1,48 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"time"
+)
+
+// Provenance - Where a spec was loaded from. It is set by the registry
+// that loaded the spec, adapters that know the digest of the image set the
+// Digest.
+type Provenance struct {
+	// Registry - the name of the registry in the config.
+	Registry string `json:"registry"`
+	// AdapterType - the type of the registry, e.g. dockerhub or quay.
+	AdapterType string `json:"adapter_type"`
+	// Source - the image reference the spec was read from.
+	Source string `json:"source"`
+	// Digest - the digest of the image, when the adapter knows it.
+	Digest string `json:"digest,omitempty"`
+	// FetchedAt - when the registry loaded the spec.
+	FetchedAt time.Time `json:"fetched_at"`
+	// SpecSignature - the base64 signature of the spec label, when the
+	// image has one.
+	SpecSignature string `json:"spec_signature,omitempty"`
+	// SignedContent - what the SpecSignature signs, the decoded spec
+	// label. It is only kept while the specs are loaded.
+	SignedContent []byte `json:"-"`
+	// Verified - the signature was verified when the spec was loaded.
+	Verified bool `json:"verified,omitempty"`
+	// Stale - the registry failed to load and the spec is the one of its
+	// last successful load, fetched at FetchedAt.
+	Stale bool `json:"stale,omitempty"`
+}
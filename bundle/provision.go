@@ -17,8 +17,8 @@
 package bundle
 
 import (
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/automationbroker/bundle-lib/runtime"
-	log "github.com/sirupsen/logrus"
 )
 
 // Provision - will run the apb with the provision action.
@@ -32,14 +32,24 @@ func (e *executor) Provision(instance *ServiceInstance) <-chan StatusMessage {
 	log.Infof("Spec.Description: %s", instance.Spec.Description)
 	log.Infof("============================================================")
 
-	go func() {
+	return e.runAction(instance, func() {
+		e.startActionSpan(string(executionMethodProvision), instance)
+		e.startOperation(string(executionMethodProvision), instance, instance.Parameters)
+		e.startEvent(string(executionMethodProvision), instance, "")
 		e.actionStarted()
-		err := e.provisionOrUpdate(executionMethodProvision, instance)
+		err := e.provisionRequirements(instance)
 		if err != nil {
 			log.Errorf("Provision APB error: %v", err)
 			e.actionFinishedWithError(err)
 			return
 		}
+		err = e.provisionOrUpdate(executionMethodProvision, instance)
+		if err != nil {
+			log.Errorf("Provision APB error: %v", err)
+			e.rollbackRequirements(instance)
+			e.actionFinishedWithError(err)
+			return
+		}
 		// Provision can not have extracted credentials.
 		if e.extractedCredentials != nil {
 			labels := map[string]string{"bundleAction": string(executionMethodProvision), "bundleName": instance.Spec.FQName}
@@ -51,6 +61,5 @@ func (e *executor) Provision(instance *ServiceInstance) <-chan StatusMessage {
 			}
 		}
 		e.actionFinishedWithSuccess()
-	}()
-	return e.statusChan
+	})
 }
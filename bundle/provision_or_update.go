@@ -17,12 +17,12 @@
 package bundle
 
 import (
-	"errors"
 	"fmt"
 
+	"github.com/automationbroker/bundle-lib/authorization"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/automationbroker/bundle-lib/runtime"
-	"github.com/pborman/uuid"
-	log "github.com/sirupsen/logrus"
 )
 
 type executionMethod string
@@ -39,11 +39,28 @@ func (e *executor) provisionOrUpdate(method executionMethod, instance *ServiceIn
 	// with the broker and still allow for providing an img path
 	// Legacy ansibleapps will hit this.
 	// TODO: Move this validation to a Spec creation function (yet to be created)
-	if instance.Spec.Image == "" {
+	if instance.Spec.Image == "" && instance.Spec.requiresImage() {
 		log.Error("No image field found on the apb instance.Spec (apb.yaml)")
 		log.Error("apb instance.Spec requires [name] and [image] fields to be separate")
 		log.Error("Are you trying to run a legacy apb without an image field?")
-		return errors.New("No image field found on instance.Spec")
+		return liberrors.New(liberrors.CodeValidation, "No image field found on instance.Spec")
+	}
+
+	if err := e.authorize(authorization.Action(method), instance); err != nil {
+		return err
+	}
+	if err := CheckImagePolicy(e.imagePolicy, instance.Spec); err != nil {
+		return err
+	}
+	image, err := CheckImageDrift(e.digestResolver, e.imageDriftPolicy, instance.Spec)
+	if err != nil {
+		return err
+	}
+	if err := e.checkQuota(string(method), instance); err != nil {
+		return err
+	}
+	if instance.Spec.Operator != nil {
+		return runtime.Provider.InstallOperator(operatorSubscription(instance))
 	}
 
 	// Create namespace name that will be used to generate a name.
@@ -54,27 +71,32 @@ func (e *executor) provisionOrUpdate(method executionMethod, instance *ServiceIn
 		ns = instance.Context.Namespace
 	}
 	// Create the podname
-	pn := fmt.Sprintf("bundle-%s", uuid.New())
-	targets := []string{instance.Context.Namespace}
+	pn, key := e.newSandboxName(instance, string(method))
+	targets := instance.Context.TargetNamespaces()
 	labels := map[string]string{
 		"bundle-fqname":   instance.Spec.FQName,
 		"bundle-action":   string(method),
 		"bundle-pod-name": pn,
 	}
-	serviceAccount, namespace, err := runtime.Provider.CreateSandbox(pn, ns, targets, clusterConfig.SandboxRole, labels)
+	labels = sandboxLabels(labels, instance.Labels)
+	labels[runtime.IdempotencyKeyLabel] = key
+	serviceAccount, namespace, err := e.createSandbox(pn, ns, targets, labels)
 	if err != nil {
 		log.Errorf("Problem executing bundle create sandbox [%s] %v", pn, method)
 		e.actionFinishedWithError(err)
 		return err
 	}
 	ec := runtime.ExecutionContext{
-		BundleName: pn,
-		Targets:    targets,
-		Metadata:   labels,
-		Action:     string(method),
-		Image:      instance.Spec.Image,
-		Account:    serviceAccount,
-		Location:   namespace,
+		BundleName:     pn,
+		Targets:        targets,
+		Metadata:       labels,
+		Annotations:    instance.Annotations,
+		Action:         string(method),
+		Image:          image,
+		RuntimeVersion: instance.Spec.Runtime,
+		Account:        serviceAccount,
+		Location:       namespace,
+		IdempotencyKey: key,
 	}
 	ec, err = e.executeApb(ec, instance, instance.Parameters)
 	defer runtime.Provider.DestroySandbox(
@@ -93,7 +115,7 @@ func (e *executor) provisionOrUpdate(method executionMethod, instance *ServiceIn
 
 	if instance.Spec.Runtime >= 2 || !instance.Spec.Bindable {
 		log.Debugf("watching pod for serviceinstance %#v", instance.Spec)
-		err := runtime.Provider.WatchRunningBundle(ec.BundleName, ec.Location, e.updateDescription)
+		err := e.watchRunningBundle(ec.BundleName, ec.Location)
 		if err != nil {
 			log.Errorf("Provision or Update action failed - %v", err)
 			return err
@@ -110,12 +132,13 @@ func (e *executor) provisionOrUpdate(method executionMethod, instance *ServiceIn
 	if err != nil {
 		return err
 	}
+	e.applyDashboardURLTemplate(instance)
 
 	if !instance.Spec.Bindable {
 		return nil
 	}
 
-	credBytes, err := runtime.Provider.ExtractCredentials(
+	credBytes, err := e.extractCredentials(
 		ec.BundleName,
 		ec.Location,
 		instance.Spec.Runtime,
@@ -0,0 +1,98 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"context"
+	"sync"
+)
+
+// instanceQueue - Serializes the actions run on the same service instance,
+// in the order they were queued, while actions on different instances run
+// in parallel. Only the last action queued for an instance is tracked;
+// every action waits for the one queued before it.
+type instanceQueue struct {
+	mutex sync.Mutex
+	tails map[string]chan struct{}
+}
+
+func newInstanceQueue() *instanceQueue {
+	return &instanceQueue{tails: map[string]chan struct{}{}}
+}
+
+// instances - the queue of the actions of all the executors.
+var instances = newInstanceQueue()
+
+// enqueue - queues an action for the instance. The returned wait blocks
+// until the actions queued before it are done, or returns the context
+// error when ctx is done first. done must be called once the action is
+// finished, whether or not wait succeeded.
+func (q *instanceQueue) enqueue(instanceID string) (wait func(ctx context.Context) error, done func()) {
+	if instanceID == "" {
+		return func(context.Context) error { return nil }, func() {}
+	}
+	q.mutex.Lock()
+	prev := q.tails[instanceID]
+	tail := make(chan struct{})
+	q.tails[instanceID] = tail
+	q.mutex.Unlock()
+
+	wait = func(ctx context.Context) error {
+		if prev == nil {
+			return nil
+		}
+		select {
+		case <-prev:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	done = func() {
+		if prev == nil || isClosed(prev) {
+			q.release(instanceID, tail)
+			return
+		}
+		// an action that gave up waiting must not let the next one run
+		// before the previous one is done.
+		go func() {
+			<-prev
+			q.release(instanceID, tail)
+		}()
+	}
+	return wait, done
+}
+
+// release - marks the action owning tail as done, the instance is
+// forgotten when no other action was queued after it.
+func (q *instanceQueue) release(instanceID string, tail chan struct{}) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.tails[instanceID] == tail {
+		delete(q.tails, instanceID)
+	}
+	close(tail)
+}
+
+func isClosed(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
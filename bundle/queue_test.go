@@ -0,0 +1,75 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceQueue(t *testing.T) {
+	q := newInstanceQueue()
+	waitFirst, doneFirst := q.enqueue("one")
+	waitSecond, doneSecond := q.enqueue("one")
+	waitOther, doneOther := q.enqueue("two")
+
+	if err := waitFirst(context.Background()); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if err := waitOther(context.Background()); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	doneOther()
+
+	// the second action on the instance waits for the first one
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, waitSecond(ctx))
+
+	doneFirst()
+	if err := waitSecond(context.Background()); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	doneSecond()
+	assert.Empty(t, q.tails)
+}
+
+func TestInstanceQueueGiveUp(t *testing.T) {
+	q := newInstanceQueue()
+	_, doneFirst := q.enqueue("one")
+	waitSecond, doneSecond := q.enqueue("one")
+	waitThird, doneThird := q.enqueue("one")
+
+	// the second action gives up, the third still waits for the first
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, waitSecond(ctx))
+	doneSecond()
+
+	short, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+	assert.Equal(t, context.DeadlineExceeded, waitThird(short))
+
+	doneFirst()
+	if err := waitThird(context.Background()); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	doneThird()
+}
@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"net/http"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+// QuotaRequest - What a QuotaChecker is asked about, the instance that is
+// about to be provisioned or updated.
+type QuotaRequest struct {
+	Action     string
+	InstanceID string
+	FQName     string
+	Plan       string
+	Namespace  string
+	// User - the name of the user of the instance, when it is known.
+	User string
+}
+
+// QuotaChecker - Vetoes provisions and updates that exceed a quota
+// computed outside of bundle-lib, e.g. the maximum instances of a plan in
+// a namespace. It returns ErrQuotaExceeded to veto the action.
+type QuotaChecker interface {
+	CheckQuota(req QuotaRequest) error
+}
+
+// QuotaCheckerFunc - Adapts a function to a QuotaChecker.
+type QuotaCheckerFunc func(req QuotaRequest) error
+
+// CheckQuota - calls the function.
+func (f QuotaCheckerFunc) CheckQuota(req QuotaRequest) error {
+	return f(req)
+}
+
+// ErrQuotaExceeded - The action would exceed a quota. It is of the
+// Conflict class, brokers answer it with 409, or with 429 when Retryable.
+type ErrQuotaExceeded struct {
+	Namespace string
+	Plan      string
+	Limit     int
+	Current   int
+	// Retryable - the quota is a rate that frees up over time, the request
+	// may succeed later.
+	Retryable bool
+}
+
+func (e ErrQuotaExceeded) Error() string {
+	return fmt.Sprintf("quota exceeded for plan %v in namespace %v: %d of %d instances in use",
+		e.Plan, e.Namespace, e.Current, e.Limit)
+}
+
+// ErrorCode - the error is of the Conflict class.
+func (e ErrQuotaExceeded) ErrorCode() liberrors.Code {
+	return liberrors.CodeConflict
+}
+
+// HTTPStatus - the status of the OSB response, 429 when the request may
+// be retried later, 409 otherwise.
+func (e ErrQuotaExceeded) HTTPStatus() int {
+	if e.Retryable {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusConflict
+}
+
+// IsErrQuotaExceeded - true if the error is an ErrQuotaExceeded.
+func IsErrQuotaExceeded(err error) bool {
+	_, ok := err.(ErrQuotaExceeded)
+	return ok
+}
+
+// checkQuota - consults the configured quota checker, if any, before the
+// instance is provisioned or updated.
+func (e *executor) checkQuota(action string, instance *ServiceInstance) error {
+	if e.quotaChecker == nil {
+		return nil
+	}
+	req := QuotaRequest{
+		Action:     action,
+		InstanceID: instance.ID.String(),
+		FQName:     instance.Spec.FQName,
+		Plan:       instance.planName(),
+	}
+	if instance.Context != nil {
+		req.Namespace = instance.Context.Namespace
+	}
+	if instance.UserInfo != nil {
+		req.User = instance.UserInfo.Username()
+	}
+	if err := e.quotaChecker.CheckQuota(req); err != nil {
+		log.Infof("%v of %v vetoed by the quota checker - %v", action, instance.Spec.FQName, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/authorization"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutorCheckQuota(t *testing.T) {
+	id := uuid.NewRandom()
+	instance := &ServiceInstance{
+		ID:         id,
+		Spec:       &Spec{FQName: "postgresql-apb", Image: "docker.io/postgresql-apb:latest"},
+		Context:    &Context{Namespace: "target"},
+		Parameters: &Parameters{PlanParameterKey: "dev"},
+		UserInfo:   &authorization.User{Name: "foo"},
+	}
+	var got QuotaRequest
+	e := &executor{
+		quotaChecker: QuotaCheckerFunc(func(req QuotaRequest) error {
+			got = req
+			return ErrQuotaExceeded{Namespace: req.Namespace, Plan: req.Plan, Limit: 2, Current: 2}
+		}),
+	}
+	err := e.provisionOrUpdate(executionMethodProvision, instance)
+	assert.True(t, IsErrQuotaExceeded(err))
+	assert.True(t, liberrors.IsConflict(err))
+	assert.Equal(t, QuotaRequest{
+		Action:     "provision",
+		InstanceID: id.String(),
+		FQName:     "postgresql-apb",
+		Plan:       "dev",
+		Namespace:  "target",
+		User:       "foo",
+	}, got)
+
+	e.quotaChecker = nil
+	assert.NoError(t, e.checkQuota("provision", instance))
+}
+
+func TestErrQuotaExceededHTTPStatus(t *testing.T) {
+	assert.Equal(t, http.StatusConflict, ErrQuotaExceeded{}.HTTPStatus())
+	assert.Equal(t, http.StatusTooManyRequests, ErrQuotaExceeded{Retryable: true}.HTTPStatus())
+}
@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"strings"
+)
+
+const (
+	// RedactedValue - replaces the values of redacted parameters.
+	RedactedValue = "<redacted>"
+	// PasswordDisplayType - the display type of parameters holding a
+	// password, their values are always redacted.
+	PasswordDisplayType = "password"
+)
+
+// RedactedParameterNames - Parameters whose name contains one of these, in
+// any case, are redacted whatever their display type.
+var RedactedParameterNames = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"apikey",
+	"api_key",
+	"private_key",
+	"creds",
+	"credential",
+}
+
+// Redacted - returns a copy of the parameters that is safe to log. The
+// values of the parameters with the password display type in the
+// descriptors, and of the parameters matching RedactedParameterNames, are
+// replaced with RedactedValue. Nested values are redacted by name.
+func (p Parameters) Redacted(descriptors []ParameterDescriptor) Parameters {
+	passwords := map[string]bool{}
+	for _, d := range descriptors {
+		if d.DisplayType == PasswordDisplayType {
+			passwords[d.Name] = true
+		}
+	}
+	redacted := Parameters{}
+	for k, v := range p {
+		if passwords[k] || isRedactedName(k) {
+			redacted[k] = RedactedValue
+			continue
+		}
+		redacted[k] = redactValue(v)
+	}
+	return redacted
+}
+
+// redactValue - redacts the nested values matching RedactedParameterNames.
+func redactValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		redacted := map[string]interface{}{}
+		for k, nested := range value {
+			if isRedactedName(k) {
+				redacted[k] = RedactedValue
+				continue
+			}
+			redacted[k] = redactValue(nested)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(value))
+		for i, nested := range value {
+			redacted[i] = redactValue(nested)
+		}
+		return redacted
+	}
+	return v
+}
+
+func isRedactedName(name string) bool {
+	name = strings.ToLower(name)
+	for _, n := range RedactedParameterNames {
+		if strings.Contains(name, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedParameters - the parameters of the instance that are safe to log,
+// using the descriptors of the plan it was provisioned with.
+func (si *ServiceInstance) redactedParameters(parameters *Parameters) Parameters {
+	if parameters == nil {
+		return Parameters{}
+	}
+	descriptors := []ParameterDescriptor{}
+	if si.Spec != nil {
+		if plan, ok := si.Spec.GetPlan(si.planName()); ok {
+			descriptors = append(descriptors, plan.Parameters...)
+			descriptors = append(descriptors, plan.BindParameters...)
+		}
+	}
+	return parameters.Redacted(descriptors)
+}
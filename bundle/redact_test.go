@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParametersRedacted(t *testing.T) {
+	descriptors := []ParameterDescriptor{
+		{Name: "admin_pass", DisplayType: PasswordDisplayType},
+		{Name: "user", Type: "string"},
+	}
+	params := Parameters{
+		"admin_pass":     "hunter2",
+		"user":           "admin",
+		"DB_PASSWORD":    "hunter2",
+		"github_token":   "abc",
+		"size":           3,
+		"_apb_plan_id":   "default",
+		"connection":     map[string]interface{}{"host": "db", "secret": "abc"},
+		"_apb_last_resp": []interface{}{map[string]interface{}{"api_key": "abc"}},
+	}
+
+	redacted := params.Redacted(descriptors)
+
+	assert.Equal(t, Parameters{
+		"admin_pass":     RedactedValue,
+		"user":           "admin",
+		"DB_PASSWORD":    RedactedValue,
+		"github_token":   RedactedValue,
+		"size":           3,
+		"_apb_plan_id":   "default",
+		"connection":     map[string]interface{}{"host": "db", "secret": RedactedValue},
+		"_apb_last_resp": []interface{}{map[string]interface{}{"api_key": RedactedValue}},
+	}, redacted)
+	// the parameters are not modified
+	assert.Equal(t, "hunter2", params["admin_pass"])
+	assert.Equal(t, "abc", params["connection"].(map[string]interface{})["secret"])
+}
+
+func TestServiceInstanceRedactedParameters(t *testing.T) {
+	si := &ServiceInstance{
+		Spec: &Spec{Plans: []Plan{{
+			Name:       "default",
+			Parameters: []ParameterDescriptor{{Name: "admin", DisplayType: PasswordDisplayType}},
+		}}},
+		Parameters: &Parameters{PlanParameterKey: "default"},
+	}
+	params := &Parameters{PlanParameterKey: "default", "admin": "hunter2"}
+
+	assert.Equal(t, Parameters{PlanParameterKey: "default", "admin": RedactedValue}, si.redactedParameters(params))
+	assert.Equal(t, Parameters{}, si.redactedParameters(nil))
+}
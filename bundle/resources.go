@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// SandboxCPUKey - plan metadata key holding the cpu the bundle pod needs.
+	SandboxCPUKey = "sandboxCPU"
+	// SandboxMemoryKey - plan metadata key holding the memory the bundle pod
+	// needs.
+	SandboxMemoryKey = "sandboxMemory"
+)
+
+// SandboxResources - returns the resources the bundle pod needs to run the
+// plan, as declared in the plan metadata. The values are used as both the
+// requests and the limits of the bundle container.
+func (p Plan) SandboxResources() (v1.ResourceRequirements, error) {
+	list := v1.ResourceList{}
+	for key, name := range map[string]v1.ResourceName{
+		SandboxCPUKey:    v1.ResourceCPU,
+		SandboxMemoryKey: v1.ResourceMemory,
+	} {
+		value, ok := p.Metadata[key]
+		if !ok {
+			continue
+		}
+		q, err := resource.ParseQuantity(fmt.Sprintf("%v", value))
+		if err != nil {
+			return v1.ResourceRequirements{}, liberrors.Newf(liberrors.CodeValidation,
+				"plan %v has an invalid %v value %v - %v", p.Name, key, value, err)
+		}
+		list[name] = q
+	}
+	if len(list) == 0 {
+		return v1.ResourceRequirements{}, nil
+	}
+	return v1.ResourceRequirements{Requests: list, Limits: list.DeepCopy()}, nil
+}
+
+// sandboxResources - returns the resources of the plan of the instance.
+func sandboxResources(instance *ServiceInstance) (v1.ResourceRequirements, error) {
+	if instance.Spec == nil {
+		return v1.ResourceRequirements{}, nil
+	}
+	plan, ok := instance.Spec.GetPlan(instance.planName())
+	if !ok {
+		return v1.ResourceRequirements{}, nil
+	}
+	return plan.SandboxResources()
+}
@@ -0,0 +1,81 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	"k8s.io/api/core/v1"
+)
+
+func TestPlanSandboxResources(t *testing.T) {
+	testCases := []struct {
+		name           string
+		metadata       map[string]interface{}
+		expectedCPU    string
+		expectedMemory string
+		shouldError    bool
+	}{
+		{
+			name: "no hints",
+		},
+		{
+			name:           "cpu and memory",
+			metadata:       map[string]interface{}{SandboxCPUKey: "500m", SandboxMemoryKey: "256Mi"},
+			expectedCPU:    "500m",
+			expectedMemory: "256Mi",
+		},
+		{
+			name:        "numeric cpu",
+			metadata:    map[string]interface{}{SandboxCPUKey: 1},
+			expectedCPU: "1",
+		},
+		{
+			name:        "invalid memory",
+			metadata:    map[string]interface{}{SandboxMemoryKey: "lots"},
+			shouldError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := Plan{Name: "default", Metadata: tc.metadata}.SandboxResources()
+			if tc.shouldError {
+				if !liberrors.IsValidation(err) {
+					t.Fatalf("expected a validation error got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			if tc.expectedCPU == "" && tc.expectedMemory == "" {
+				if len(r.Requests) != 0 || len(r.Limits) != 0 {
+					t.Fatalf("expected no resources got: %v", r)
+				}
+				return
+			}
+			if cpu := r.Requests[v1.ResourceCPU]; tc.expectedCPU != "" && cpu.String() != tc.expectedCPU {
+				t.Fatalf("expected cpu: %v got: %v", tc.expectedCPU, cpu.String())
+			}
+			if mem := r.Limits[v1.ResourceMemory]; tc.expectedMemory != "" && mem.String() != tc.expectedMemory {
+				t.Fatalf("expected memory: %v got: %v", tc.expectedMemory, mem.String())
+			}
+		})
+	}
+}
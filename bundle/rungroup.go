@@ -0,0 +1,199 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+	"github.com/automationbroker/bundle-lib/runtime"
+)
+
+// ErrorActionPanicked - The action panicked, the panic was recovered and
+// the action failed.
+type ErrorActionPanicked struct {
+	Value interface{}
+	// Stack - the stack of the action when it panicked, to be attached
+	// to the bug report.
+	Stack string
+}
+
+func (e ErrorActionPanicked) Error() string {
+	return fmt.Sprintf("action panicked - %v", e.Value)
+}
+
+// IsErrorActionPanicked - true if the error is an ErrorActionPanicked.
+func IsErrorActionPanicked(err error) bool {
+	_, ok := err.(ErrorActionPanicked)
+	return ok
+}
+
+// ErrorShuttingDown - The action was not started because the executors
+// are shutting down.
+type ErrorShuttingDown struct{}
+
+func (e ErrorShuttingDown) Error() string {
+	return "executors are shutting down, the action was not started"
+}
+
+// ErrorCode - the error is of the Conflict class.
+func (e ErrorShuttingDown) ErrorCode() liberrors.Code {
+	return liberrors.CodeConflict
+}
+
+// IsErrorShuttingDown - true if the error is an ErrorShuttingDown.
+func IsErrorShuttingDown(err error) bool {
+	_, ok := err.(ErrorShuttingDown)
+	return ok
+}
+
+// runGroup - Owns the goroutines running the actions so they can be
+// drained on shutdown. Every action gets its own context that is
+// cancelled when the action returns or the shutdown gives up waiting.
+type runGroup struct {
+	mutex   sync.Mutex
+	wg      sync.WaitGroup
+	closed  bool
+	next    int
+	cancels map[int]context.CancelFunc
+}
+
+func newRunGroup() *runGroup {
+	return &runGroup{cancels: map[int]context.CancelFunc{}}
+}
+
+// actions - the run group of the actions of all the executors.
+var actions = newRunGroup()
+
+// Go - runs fn in a goroutine owned by the group with a context derived
+// from parent. ErrorShuttingDown is returned once the group is shut down.
+func (g *runGroup) Go(parent context.Context, fn func(ctx context.Context)) error {
+	g.mutex.Lock()
+	if g.closed {
+		g.mutex.Unlock()
+		return ErrorShuttingDown{}
+	}
+	ctx, cancel := context.WithCancel(parent)
+	id := g.next
+	g.next++
+	g.cancels[id] = cancel
+	g.wg.Add(1)
+	g.mutex.Unlock()
+
+	go func() {
+		defer func() {
+			g.mutex.Lock()
+			delete(g.cancels, id)
+			g.mutex.Unlock()
+			cancel()
+			g.wg.Done()
+		}()
+		fn(ctx)
+	}()
+	return nil
+}
+
+// Shutdown - stops new goroutines from starting and waits for the running
+// ones. When ctx is done first the running goroutines are cancelled and the
+// context error is returned.
+func (g *runGroup) Shutdown(ctx context.Context) error {
+	g.mutex.Lock()
+	g.closed = true
+	g.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		g.mutex.Lock()
+		log.Warningf("cancelling %d in-flight actions - %v", len(g.cancels), ctx.Err())
+		for _, cancel := range g.cancels {
+			cancel()
+		}
+		g.mutex.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Shutdown - stops the executors from starting new actions and waits for
+// the in-flight actions to finish. When ctx is done first, the in-flight
+// actions are cancelled, they stop sending status messages, and the
+// context error is returned. The runtime is shut down with the same
+// deadline, see runtime.Shutdown, so the bundles of the cancelled actions
+// keep running and are recovered after the restart. The queued events of
+// the notifiers are delivered within the same deadline.
+func Shutdown(ctx context.Context) error {
+	err := actions.Shutdown(ctx)
+	if nerr := notifications.wait(ctx); err == nil {
+		err = nerr
+	}
+	if rerr := runtime.Shutdown(ctx); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// runAction - runs the action in a goroutine owned by the actions run
+// group, after the actions queued before it on the same instance are done.
+// A panic in the action is recovered and fails the action. When the
+// executors are shutting down the action fails without being run. The
+// status channel of the action is returned, it is taken before the action
+// runs as finishing the action clears the one of the executor.
+func (e *executor) runAction(instance *ServiceInstance, action func()) <-chan StatusMessage {
+	instanceID := ""
+	if instance != nil && instance.ID != nil {
+		instanceID = instance.ID.String()
+	}
+	statusChan := e.statusChan
+	wait, done := instances.enqueue(instanceID)
+	err := actions.Go(e.ctx, func(ctx context.Context) {
+		defer done()
+		e.actionCtx = ctx
+		if err := wait(ctx); err != nil {
+			log.Warningf("executor::gave up waiting for the actions on instance %v - %v", instanceID, err)
+			e.actionFinishedWithError(err)
+			return
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				stack := string(debug.Stack())
+				log.Errorf("executor::action panicked - %v\n%s", r, stack)
+				e.actionFinishedWithError(ErrorActionPanicked{Value: r, Stack: stack})
+			}
+		}()
+		action()
+	})
+	if err != nil {
+		done()
+		log.Errorf("executor::unable to start action - %v", err)
+		// nothing reads the status yet, make room for the failure
+		statusChan = make(chan StatusMessage, 1)
+		e.statusChan = statusChan
+		e.actionFinishedWithError(err)
+	}
+	return statusChan
+}
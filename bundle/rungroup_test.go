@@ -0,0 +1,119 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunGroupShutdown(t *testing.T) {
+	g := newRunGroup()
+	release := make(chan struct{})
+	finished := false
+	err := g.Go(context.Background(), func(ctx context.Context) {
+		<-release
+		finished = true
+	})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	go close(release)
+	if err := g.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.True(t, finished)
+
+	err = g.Go(context.Background(), func(ctx context.Context) {})
+	assert.True(t, IsErrorShuttingDown(err))
+}
+
+func TestRunGroupShutdownCancels(t *testing.T) {
+	g := newRunGroup()
+	cancelled := make(chan struct{})
+	err := g.Go(context.Background(), func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, g.Shutdown(ctx))
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the running goroutine was not cancelled")
+	}
+}
+
+func TestRunActionPanic(t *testing.T) {
+	e := NewExecutor(ExecutorConfig{}).(*executor)
+	statusChan := e.statusChan
+	e.runAction(nil, func() {
+		e.actionStarted()
+		panic("boom")
+	})
+
+	statuses := []StatusMessage{}
+	for status := range statusChan {
+		statuses = append(statuses, status)
+	}
+	assert.Equal(t, 2, len(statuses))
+	assert.Equal(t, StateInProgress, statuses[0].State)
+	assert.Equal(t, StateFailed, statuses[1].State)
+	assert.True(t, IsErrorActionPanicked(statuses[1].Error))
+	assert.Contains(t, statuses[1].Error.(ErrorActionPanicked).Stack, "runAction")
+	assert.Equal(t, runtime.FailureReasonInternal, statuses[1].FailureReason)
+	assert.Equal(t, "action panicked - boom", statuses[1].Description)
+}
+
+func TestActionAfterShutdown(t *testing.T) {
+	saved := actions
+	defer func() { actions = saved }()
+	actions = newRunGroup()
+	if err := actions.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	instance := &ServiceInstance{Spec: &Spec{FQName: "test-apb"}}
+	e := NewExecutor(ExecutorConfig{})
+	statuses := []StatusMessage{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for status := range e.Provision(instance) {
+			statuses = append(statuses, status)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the status channel of the rejected action was not closed")
+	}
+	assert.Equal(t, 1, len(statuses))
+	assert.Equal(t, StateFailed, statuses[0].State)
+	assert.True(t, IsErrorShuttingDown(statuses[0].Error))
+}
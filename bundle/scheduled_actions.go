@@ -0,0 +1,195 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+	"github.com/automationbroker/bundle-lib/runtime"
+)
+
+// CustomAction - An action the bundle implements besides provision,
+// update, bind, unbind and deprovision, e.g. backup. Custom actions are run
+// on a schedule, see ScheduleAction.
+type CustomAction struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Schedule - the default cron expression of the action.
+	Schedule string `json:"schedule,omitempty"`
+}
+
+// ErrUnknownAction - The spec of the instance does not declare the action.
+type ErrUnknownAction struct {
+	FQName string
+	Action string
+}
+
+func (e ErrUnknownAction) Error() string {
+	return fmt.Sprintf("%v does not declare the action %v", e.FQName, e.Action)
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrUnknownAction) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrUnknownAction - true if the error is an ErrUnknownAction.
+func IsErrUnknownAction(err error) bool {
+	_, ok := err.(ErrUnknownAction)
+	return ok
+}
+
+// customAction - the action declared by the spec.
+func (s *Spec) customAction(name string) (CustomAction, bool) {
+	for _, a := range s.Actions {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return CustomAction{}, false
+}
+
+// ScheduleAction - runs the custom action of the instance on the schedule,
+// a cron expression, with the parameters of the instance. An empty
+// schedule uses the one declared by the spec. The action runs in a
+// sandbox of its own, which is kept until the action is unscheduled.
+// Scheduling an action again replaces its schedule.
+func ScheduleAction(instance *ServiceInstance, action, schedule string) error {
+	declared, ok := instance.Spec.customAction(action)
+	if !ok {
+		return ErrUnknownAction{FQName: instance.Spec.FQName, Action: action}
+	}
+	if schedule == "" {
+		schedule = declared.Schedule
+	}
+	if err := runtime.ValidateSchedule(schedule); err != nil {
+		return err
+	}
+
+	instanceID := instance.ID.String()
+	pn := runtime.ScheduledActionName(instanceID, action)
+	ns := fmt.Sprintf("%s-%.4s-", instance.Spec.FQName, action)
+	targets := instance.Context.TargetNamespaces()
+	labels := map[string]string{
+		"bundle-fqname":   instance.Spec.FQName,
+		"bundle-action":   action,
+		"bundle-pod-name": pn,
+	}
+	labels = sandboxLabels(labels, instance.Labels)
+
+	serviceAccount, namespace, err := runtime.Provider.CreateSandbox(pn, ns, targets, clusterConfig.SandboxRole, labels)
+	if err != nil {
+		log.Errorf("unable to create the sandbox of scheduled action %v - %v", action, err)
+		return err
+	}
+	params, creds := splitCredentials(instance.Parameters)
+	extraVars, err := BuildExtraVars(action, instance, params, creds).JSON()
+	if err != nil {
+		destroyScheduledSandbox(pn, namespace, targets)
+		return err
+	}
+	secrets := getSecrets(instance.Spec)
+	ec := runtime.ExecutionContext{
+		BundleName:     pn,
+		Targets:        targets,
+		Metadata:       labels,
+		Annotations:    instance.Annotations,
+		Action:         action,
+		Image:          instance.Spec.Image,
+		RuntimeVersion: instance.Spec.Runtime,
+		Account:        serviceAccount,
+		Location:       namespace,
+		ExtraVars:      extraVars,
+		Secrets:        secrets,
+		ProxyConfig:    getProxyConfig(),
+		Policy:         clusterConfig.PullPolicy,
+		OS:             instance.Spec.OS,
+		Architecture:   instance.Spec.Architecture,
+		Dependencies:   instance.Spec.imageDependencies(),
+	}
+	err = runtime.Provider.CopySecretsToNamespace(ec, clusterConfig.Namespace, secrets)
+	if err == nil {
+		err = runtime.Provider.ScheduleAction(ec, runtime.ScheduledAction{
+			InstanceID: instanceID,
+			Action:     action,
+			Schedule:   schedule,
+		})
+	}
+	if err != nil {
+		log.Errorf("unable to schedule action %v of instance %v - %v", action, instanceID, err)
+		destroyScheduledSandbox(pn, namespace, targets)
+		return err
+	}
+	return nil
+}
+
+// UnscheduleAction - stops running the action of the instance on its
+// schedule and destroys its sandbox. Unscheduling an action that is not
+// scheduled is not an error.
+func UnscheduleAction(instance *ServiceInstance, action string) error {
+	return unscheduleActions(instance, func(sa runtime.ScheduledAction) bool {
+		return sa.Action == action
+	})
+}
+
+// UnscheduleActions - stops running all the scheduled actions of the
+// instance and destroys their sandboxes, along with the role bindings in
+// the target namespaces. Deprovision unschedules the actions of specs
+// declaring custom actions.
+func UnscheduleActions(instance *ServiceInstance) error {
+	return unscheduleActions(instance, func(runtime.ScheduledAction) bool {
+		return true
+	})
+}
+
+func unscheduleActions(instance *ServiceInstance, match func(runtime.ScheduledAction) bool) error {
+	scheduled, err := runtime.Provider.ScheduledActions(instance.ID.String())
+	if err != nil {
+		return err
+	}
+	for _, sa := range scheduled {
+		if !match(sa) {
+			continue
+		}
+		if err := runtime.Provider.UnscheduleAction(sa); err != nil {
+			return err
+		}
+		destroyScheduledSandbox(runtime.ScheduledActionName(sa.InstanceID, sa.Action),
+			sa.Namespace, instance.Context.TargetNamespaces())
+	}
+	return nil
+}
+
+// RecordScheduledRuns - adds the finished runs of the scheduled actions of
+// the instance to its History. Meant to be called periodically, e.g. by
+// the broker before it reports the history.
+func RecordScheduledRuns(instanceID string) (int, error) {
+	return runtime.Provider.RecordScheduledRuns(instanceID)
+}
+
+func destroyScheduledSandbox(podName, namespace string, targets []string) {
+	runtime.Provider.DestroySandbox(
+		podName,
+		namespace,
+		targets,
+		clusterConfig.Namespace,
+		false,
+		false,
+	)
+}
@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleAction(t *testing.T) {
+	rt := runtime.NewFakeRuntime()
+	runtime.Provider = rt
+
+	instance := &ServiceInstance{
+		ID: uuid.NewUUID(),
+		Spec: &Spec{
+			FQName:  "postgresql-apb",
+			Image:   "docker.io/automationbroker/postgresql-apb",
+			Actions: []CustomAction{{Name: "backup", Schedule: "0 2 * * *"}},
+		},
+		Context:    &Context{Namespace: "target", Platform: "kubernetes"},
+		Parameters: &Parameters{"plan": "default"},
+	}
+
+	err := ScheduleAction(instance, "restore", "")
+	if !IsErrUnknownAction(err) {
+		t.Fatalf("expected an unknown action got: %v", err)
+	}
+	err = ScheduleAction(instance, "backup", "0 25 * * *")
+	if !runtime.IsErrorInvalidSchedule(err) {
+		t.Fatalf("expected an invalid schedule got: %v", err)
+	}
+
+	if err := ScheduleAction(instance, "backup", ""); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	scheduled, err := rt.ScheduledActions(instance.ID.String())
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if len(scheduled) != 1 {
+		t.Fatalf("expected 1 scheduled action, got: %d", len(scheduled))
+	}
+	// the declared schedule is the default
+	assert.Equal(t, "0 2 * * *", scheduled[0].Schedule)
+
+	rt.AddScheduledRun(instance.ID.String(), runtime.Operation{Action: "backup", Result: "succeeded"})
+	recorded, err := RecordScheduledRuns(instance.ID.String())
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, 1, recorded)
+
+	if err := UnscheduleAction(instance, "backup"); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	scheduled, err = rt.ScheduledActions(instance.ID.String())
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Empty(t, scheduled)
+	for _, s := range rt.Sandboxes() {
+		assert.True(t, s.Destroyed, s.PodName)
+	}
+}
+
+func TestDeprovisionUnschedulesActions(t *testing.T) {
+	rt := runtime.NewFakeRuntime()
+	runtime.Provider = rt
+
+	spec := operatorSpec("postgresql-apb")
+	spec.Image = "docker.io/automationbroker/postgresql-apb"
+	spec.Actions = []CustomAction{{Name: "backup", Schedule: "0 2 * * *"}}
+	instance := &ServiceInstance{
+		ID:         uuid.NewUUID(),
+		Spec:       spec,
+		Context:    &Context{Namespace: "target", Platform: "kubernetes"},
+		Parameters: &Parameters{"plan": "default"},
+	}
+	if err := ScheduleAction(instance, "backup", ""); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	var last StatusMessage
+	for status := range NewExecutor(ExecutorConfig{}).Deprovision(instance) {
+		last = status
+	}
+	assert.Equal(t, StateSucceeded, last.State)
+	scheduled, err := rt.ScheduledActions(instance.ID.String())
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Empty(t, scheduled)
+	for _, s := range rt.Sandboxes() {
+		assert.True(t, s.Destroyed, s.PodName)
+	}
+}
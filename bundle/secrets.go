@@ -22,7 +22,7 @@ import (
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/automationbroker/bundle-lib/clients"
-	log "github.com/sirupsen/logrus"
+	log "github.com/automationbroker/bundle-lib/logging"
 )
 
 // SecretsConfig - Entry for a secret config block in broker config
@@ -0,0 +1,385 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// LabelsKey - apb.yml metadata key with the labels of the bundle, a map of
+// strings searched with SpecQuery.Labels.
+const LabelsKey = "labels"
+
+// SpecQuery - The criteria of a catalog search, a spec matches when it
+// matches all the criteria that are set.
+type SpecQuery struct {
+	// Tags - the spec has all of the tags, compared case insensitively.
+	Tags []string
+	// Registry - the name of the registry the spec was loaded from, see
+	// Provenance.
+	Registry string
+	// Bindable - the spec is bindable, or not, when set.
+	Bindable *bool
+	// Text - every word of the text starts a word of the name, the display
+	// name or the description of the spec.
+	Text string
+	// Labels - the spec has all of the labels, see LabelsKey.
+	Labels map[string]string
+	// Category - the spec has the category or one of its subcategories,
+	// e.g. databases matches databases/sql. See Spec.Categories.
+	Category string
+}
+
+// idSet - The IDs of the specs matching an index entry.
+type idSet map[string]struct{}
+
+// SpecIndex - An index of the specs of a catalog. The index is updated
+// with the specs as the registries load them and searched without
+// scanning every spec. It is safe for concurrent use.
+type SpecIndex struct {
+	mutex      sync.RWMutex
+	specs      map[string]*Spec
+	tags       map[string]idSet
+	registries map[string]idSet
+	labels     map[string]idSet
+	categories map[string]idSet
+	terms      map[string]idSet
+	bindable   idSet
+	// sortedTerms - the keys of terms, sorted for the prefix searches.
+	sortedTerms []string
+}
+
+// NewSpecIndex - creates the index of the specs.
+func NewSpecIndex(specs []*Spec) *SpecIndex {
+	i := &SpecIndex{
+		specs:      map[string]*Spec{},
+		tags:       map[string]idSet{},
+		registries: map[string]idSet{},
+		labels:     map[string]idSet{},
+		categories: map[string]idSet{},
+		terms:      map[string]idSet{},
+		bindable:   idSet{},
+	}
+	i.Update(specs...)
+	return i
+}
+
+// Index - creates the index of the specs of the manifest.
+func (m SpecManifest) Index() *SpecIndex {
+	specs := make([]*Spec, 0, len(m))
+	for _, spec := range m {
+		specs = append(specs, spec)
+	}
+	return NewSpecIndex(specs)
+}
+
+// Update - adds the specs to the index, replacing the indexed specs with
+// the same ID.
+func (i *SpecIndex) Update(specs ...*Spec) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	for _, spec := range specs {
+		if spec == nil {
+			continue
+		}
+		i.remove(spec.ID)
+		i.add(spec)
+	}
+	i.sortTerms()
+}
+
+// Remove - removes the specs with the IDs from the index.
+func (i *SpecIndex) Remove(ids ...string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	for _, id := range ids {
+		i.remove(id)
+	}
+	i.sortTerms()
+}
+
+// Get - the indexed spec with the ID, nil if there is none.
+func (i *SpecIndex) Get(id string) *Spec {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.specs[id]
+}
+
+// Len - the number of indexed specs.
+func (i *SpecIndex) Len() int {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return len(i.specs)
+}
+
+// Manifest - the indexed specs.
+func (i *SpecIndex) Manifest() SpecManifest {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	manifest := SpecManifest{}
+	for id, spec := range i.specs {
+		manifest[id] = spec
+	}
+	return manifest
+}
+
+// ByTag - the specs with the tag.
+func (i *SpecIndex) ByTag(tag string) []*Spec {
+	return i.Search(SpecQuery{Tags: []string{tag}})
+}
+
+// ByRegistry - the specs loaded from the registry.
+func (i *SpecIndex) ByRegistry(registry string) []*Spec {
+	return i.Search(SpecQuery{Registry: registry})
+}
+
+// ByBindable - the specs that are bindable, or the ones that are not.
+func (i *SpecIndex) ByBindable(bindable bool) []*Spec {
+	return i.Search(SpecQuery{Bindable: &bindable})
+}
+
+// ByText - the specs matching the text, see SpecQuery.Text.
+func (i *SpecIndex) ByText(text string) []*Spec {
+	return i.Search(SpecQuery{Text: text})
+}
+
+// ByLabel - the specs with the label.
+func (i *SpecIndex) ByLabel(key, value string) []*Spec {
+	return i.Search(SpecQuery{Labels: map[string]string{key: value}})
+}
+
+// ByCategory - the specs in the category or one of its subcategories.
+func (i *SpecIndex) ByCategory(category string) []*Spec {
+	return i.Search(SpecQuery{Category: category})
+}
+
+// Search - the specs matching the query, sorted by name. An empty query
+// matches every spec.
+func (i *SpecIndex) Search(q SpecQuery) []*Spec {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	sets := []idSet{}
+	for _, tag := range q.Tags {
+		sets = append(sets, i.tags[strings.ToLower(tag)])
+	}
+	if q.Registry != "" {
+		sets = append(sets, i.registries[q.Registry])
+	}
+	for k, v := range q.Labels {
+		sets = append(sets, i.labels[labelEntry(k, v)])
+	}
+	if q.Category != "" {
+		sets = append(sets, i.categories[ParseCategory(q.Category).String()])
+	}
+	for _, word := range searchTerms(q.Text) {
+		sets = append(sets, i.prefixed(word))
+	}
+	if q.Bindable != nil && *q.Bindable {
+		sets = append(sets, i.bindable)
+	}
+
+	var ids idSet
+	if len(sets) == 0 {
+		ids = idSet{}
+		for id := range i.specs {
+			ids[id] = struct{}{}
+		}
+	} else {
+		ids = intersect(sets)
+	}
+	if q.Bindable != nil && !*q.Bindable {
+		for id := range i.bindable {
+			delete(ids, id)
+		}
+	}
+
+	specs := make([]*Spec, 0, len(ids))
+	for id := range ids {
+		specs = append(specs, i.specs[id])
+	}
+	sort.Slice(specs, func(a, b int) bool {
+		if specs[a].FQName != specs[b].FQName {
+			return specs[a].FQName < specs[b].FQName
+		}
+		return specs[a].ID < specs[b].ID
+	})
+	return specs
+}
+
+func (i *SpecIndex) add(spec *Spec) {
+	i.specs[spec.ID] = spec
+	for _, tag := range spec.Tags {
+		addID(i.tags, strings.ToLower(tag), spec.ID)
+	}
+	if spec.Provenance != nil && spec.Provenance.Registry != "" {
+		addID(i.registries, spec.Provenance.Registry, spec.ID)
+	}
+	for k, v := range specLabels(spec) {
+		addID(i.labels, labelEntry(k, v), spec.ID)
+	}
+	for _, category := range categoryPrefixes(spec) {
+		addID(i.categories, category, spec.ID)
+	}
+	for _, term := range specTerms(spec) {
+		addID(i.terms, term, spec.ID)
+	}
+	if spec.Bindable {
+		i.bindable[spec.ID] = struct{}{}
+	}
+}
+
+func (i *SpecIndex) remove(id string) {
+	spec, ok := i.specs[id]
+	if !ok {
+		return
+	}
+	delete(i.specs, id)
+	for _, tag := range spec.Tags {
+		removeID(i.tags, strings.ToLower(tag), id)
+	}
+	if spec.Provenance != nil {
+		removeID(i.registries, spec.Provenance.Registry, id)
+	}
+	for k, v := range specLabels(spec) {
+		removeID(i.labels, labelEntry(k, v), id)
+	}
+	for _, category := range categoryPrefixes(spec) {
+		removeID(i.categories, category, id)
+	}
+	for _, term := range specTerms(spec) {
+		removeID(i.terms, term, id)
+	}
+	delete(i.bindable, id)
+}
+
+func (i *SpecIndex) sortTerms() {
+	i.sortedTerms = make([]string, 0, len(i.terms))
+	for term := range i.terms {
+		i.sortedTerms = append(i.sortedTerms, term)
+	}
+	sort.Strings(i.sortedTerms)
+}
+
+// prefixed - the IDs of the specs with a term starting with the word.
+func (i *SpecIndex) prefixed(word string) idSet {
+	ids := idSet{}
+	for n := sort.SearchStrings(i.sortedTerms, word); n < len(i.sortedTerms); n++ {
+		term := i.sortedTerms[n]
+		if !strings.HasPrefix(term, word) {
+			break
+		}
+		for id := range i.terms[term] {
+			ids[id] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// specLabels - the labels in the metadata of the spec, see LabelsKey.
+func specLabels(spec *Spec) map[string]string {
+	labels := map[string]string{}
+	raw, ok := spec.Metadata[LabelsKey].(map[string]interface{})
+	if !ok {
+		return labels
+	}
+	for k, v := range raw {
+		labels[k] = fmt.Sprint(v)
+	}
+	return labels
+}
+
+// categoryPrefixes - the categories of the spec and their parents.
+func categoryPrefixes(spec *Spec) []string {
+	prefixes := []string{}
+	for _, category := range spec.Categories {
+		for n := 1; n <= len(category); n++ {
+			prefixes = append(prefixes, category[:n].String())
+		}
+	}
+	return prefixes
+}
+
+// specTerms - the words of the name, display name and description of the
+// spec.
+func specTerms(spec *Spec) []string {
+	displayName, _ := spec.Metadata[displayNameKey].(string)
+	return searchTerms(strings.Join([]string{spec.FQName, displayName, spec.Description}, " "))
+}
+
+// searchTerms - the distinct lower case words of the text.
+func searchTerms(text string) []string {
+	seen := map[string]bool{}
+	terms := []string{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+func labelEntry(key, value string) string {
+	return key + "=" + value
+}
+
+func addID(index map[string]idSet, key, id string) {
+	ids, ok := index[key]
+	if !ok {
+		ids = idSet{}
+		index[key] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func removeID(index map[string]idSet, key, id string) {
+	ids, ok := index[key]
+	if !ok {
+		return
+	}
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(index, key)
+	}
+}
+
+// intersect - the IDs in all of the sets, starting from the smallest.
+func intersect(sets []idSet) idSet {
+	sort.Slice(sets, func(a, b int) bool { return len(sets[a]) < len(sets[b]) })
+	ids := idSet{}
+	for id := range sets[0] {
+		found := true
+		for _, set := range sets[1:] {
+			if _, ok := set[id]; !ok {
+				found = false
+				break
+			}
+		}
+		if found {
+			ids[id] = struct{}{}
+		}
+	}
+	return ids
+}
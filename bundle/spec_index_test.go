@@ -0,0 +1,149 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func indexedSpecs() []*Spec {
+	return []*Spec{
+		{
+			ID:          "pg",
+			FQName:      "dh-postgresql-apb",
+			Description: "PostgreSQL database",
+			Tags:        []string{"database", "SQL"},
+			Bindable:    true,
+			Metadata: map[string]interface{}{
+				"displayName": "PostgreSQL (APB)",
+				LabelsKey:     map[string]interface{}{"tier": "data"},
+			},
+			Provenance: &Provenance{Registry: "dh"},
+		},
+		{
+			ID:          "mysql",
+			FQName:      "dh-mysql-apb",
+			Description: "MySQL database",
+			Tags:        []string{"database"},
+			Bindable:    true,
+			Provenance:  &Provenance{Registry: "dh"},
+		},
+		{
+			ID:          "wiki",
+			FQName:      "quay-mediawiki-apb",
+			Description: "Mediawiki web application",
+			Tags:        []string{"wiki"},
+			Metadata: map[string]interface{}{
+				LabelsKey: map[string]interface{}{"tier": "web"},
+			},
+			Provenance: &Provenance{Registry: "quay"},
+		},
+	}
+}
+
+func specIDs(specs []*Spec) []string {
+	ids := []string{}
+	for _, spec := range specs {
+		ids = append(ids, spec.ID)
+	}
+	return ids
+}
+
+func TestSpecIndexSearch(t *testing.T) {
+	index := NewSpecManifest(indexedSpecs()).Index()
+	notBindable := false
+	testCases := []struct {
+		name     string
+		query    SpecQuery
+		expected []string
+	}{
+		{name: "empty query", query: SpecQuery{}, expected: []string{"mysql", "pg", "wiki"}},
+		{name: "tag", query: SpecQuery{Tags: []string{"Database"}}, expected: []string{"mysql", "pg"}},
+		{name: "all tags", query: SpecQuery{Tags: []string{"database", "sql"}}, expected: []string{"pg"}},
+		{name: "unknown tag", query: SpecQuery{Tags: []string{"cache"}}, expected: []string{}},
+		{name: "registry", query: SpecQuery{Registry: "quay"}, expected: []string{"wiki"}},
+		{name: "not bindable", query: SpecQuery{Bindable: &notBindable}, expected: []string{"wiki"}},
+		{name: "text prefix", query: SpecQuery{Text: "postg"}, expected: []string{"pg"}},
+		{name: "text words", query: SpecQuery{Text: "Database my"}, expected: []string{"mysql"}},
+		{name: "display name", query: SpecQuery{Text: "(apb)"}, expected: []string{"mysql", "pg", "wiki"}},
+		{name: "label", query: SpecQuery{Labels: map[string]string{"tier": "web"}}, expected: []string{"wiki"}},
+		{
+			name:     "combined",
+			query:    SpecQuery{Registry: "dh", Text: "database", Labels: map[string]string{"tier": "data"}},
+			expected: []string{"pg"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, specIDs(index.Search(tc.query)))
+		})
+	}
+	assert.Equal(t, []string{"mysql", "pg"}, specIDs(index.ByBindable(true)))
+	assert.Equal(t, []string{"wiki"}, specIDs(index.ByTag("wiki")))
+	assert.Equal(t, []string{"mysql", "pg"}, specIDs(index.ByRegistry("dh")))
+	assert.Equal(t, []string{"pg"}, specIDs(index.ByLabel("tier", "data")))
+	assert.Equal(t, []string{"wiki"}, specIDs(index.ByText("web app")))
+}
+
+func TestSpecIndexUpdate(t *testing.T) {
+	index := NewSpecIndex(indexedSpecs())
+	assert.Equal(t, 3, index.Len())
+
+	// the updated spec replaces the indexed one
+	index.Update(&Spec{
+		ID:          "wiki",
+		FQName:      "quay-mediawiki-apb",
+		Description: "Mediawiki",
+		Bindable:    true,
+		Provenance:  &Provenance{Registry: "dh"},
+	})
+	assert.Equal(t, 3, index.Len())
+	assert.Equal(t, []string{}, specIDs(index.ByTag("wiki")))
+	assert.Equal(t, []string{}, specIDs(index.ByText("web")))
+	assert.Equal(t, []string{}, specIDs(index.ByRegistry("quay")))
+	assert.Equal(t, []string{"mysql", "pg", "wiki"}, specIDs(index.ByRegistry("dh")))
+	assert.Equal(t, "Mediawiki", index.Get("wiki").Description)
+
+	index.Remove("pg", "unknown")
+	assert.Nil(t, index.Get("pg"))
+	assert.Equal(t, []string{}, specIDs(index.ByText("postgresql")))
+	assert.Equal(t, []string{"mysql", "wiki"}, specIDs(index.ByBindable(true)))
+	assert.Equal(t, 2, len(index.Manifest()))
+	// the removed terms are not left in the index
+	assert.Equal(t, 0, len(index.terms["postgresql"]))
+	assert.NotContains(t, index.sortedTerms, "postgresql")
+}
+
+func TestSpecIndexCategories(t *testing.T) {
+	specs := indexedSpecs()
+	specs[0].Categories = []Category{{"databases", "sql"}, {"databases", "postgresql"}}
+	specs[1].Categories = []Category{{"databases", "sql"}}
+	specs[2].Categories = []Category{{"web"}}
+	index := NewSpecIndex(specs)
+
+	assert.Equal(t, []string{"mysql", "pg"}, specIDs(index.ByCategory("Databases")))
+	assert.Equal(t, []string{"mysql", "pg"}, specIDs(index.ByCategory("databases/sql")))
+	assert.Equal(t, []string{"pg"}, specIDs(index.ByCategory("databases/postgresql")))
+	assert.Equal(t, []string{}, specIDs(index.ByCategory("sql")))
+	assert.Equal(t, []string{"wiki"}, specIDs(index.Search(SpecQuery{Category: "web", Registry: "quay"})))
+
+	index.Remove("pg")
+	assert.Equal(t, []string{}, specIDs(index.ByCategory("databases/postgresql")))
+	assert.Equal(t, []string{"mysql"}, specIDs(index.ByCategory("databases")))
+}
@@ -0,0 +1,319 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// IncludeKey - the key of the mappings of an apb.yml that include
+	// other documents, see ResolveSpecYAML.
+	IncludeKey = "include"
+	// maxIncludeDepth - how deep included documents can include others.
+	maxIncludeDepth = 10
+)
+
+// IncludeResolver - Reads the documents included by an apb.yml.
+type IncludeResolver interface {
+	ResolveInclude(path string) ([]byte, error)
+}
+
+// IncludeResolverFunc - A function implementing IncludeResolver.
+type IncludeResolverFunc func(path string) ([]byte, error)
+
+// ResolveInclude - calls the function.
+func (f IncludeResolverFunc) ResolveInclude(path string) ([]byte, error) {
+	return f(path)
+}
+
+// DirIncludeResolver - resolves the includes as paths relative to the dir,
+// e.g. the directory of the apb.yml. Paths outside of the dir are rejected.
+func DirIncludeResolver(dir string) IncludeResolver {
+	return IncludeResolverFunc(func(path string) ([]byte, error) {
+		if filepath.IsAbs(path) {
+			return nil, fmt.Errorf("the path is absolute")
+		}
+		full := filepath.Join(dir, path)
+		rel, err := filepath.Rel(dir, full)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("the path is outside of %v", dir)
+		}
+		return ioutil.ReadFile(full)
+	})
+}
+
+// ErrSpecInclude - An include of an apb.yml could not be resolved.
+type ErrSpecInclude struct {
+	Path string
+	Err  error
+}
+
+func (e ErrSpecInclude) Error() string {
+	return fmt.Sprintf("unable to include %v - %v", e.Path, e.Err)
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrSpecInclude) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrSpecInclude - true if the error is an ErrSpecInclude.
+func IsErrSpecInclude(err error) bool {
+	_, ok := err.(ErrSpecInclude)
+	return ok
+}
+
+// UnmarshalSpecYAML - decodes an apb.yml with its anchors, aliases and
+// merge keys. The includes are resolved when the resolver is not nil, see
+// ResolveSpecYAML. The nested maps of the metadata and of the parameter
+// defaults are decoded with string keys, so the spec can be encoded as
+// json. The fields that are not part of the spec do not fail the decoding,
+// they are recorded in the UnknownFields of the spec.
+func UnmarshalSpecYAML(data []byte, resolver IncludeResolver) (*Spec, error) {
+	if resolver != nil {
+		var err error
+		data, err = ResolveSpecYAML(data, resolver)
+		if err != nil {
+			return nil, err
+		}
+	}
+	spec := &Spec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, err
+	}
+	spec.UnknownFields = unknownFields(data)
+	spec.Metadata = stringKeys(spec.Metadata).(map[string]interface{})
+	spec.Alpha = stringKeys(spec.Alpha).(map[string]interface{})
+	for i := range spec.Plans {
+		plan := &spec.Plans[i]
+		plan.Metadata = stringKeys(plan.Metadata).(map[string]interface{})
+		for j := range plan.Parameters {
+			plan.Parameters[j].Default = stringKeys(plan.Parameters[j].Default)
+		}
+		for j := range plan.BindParameters {
+			plan.BindParameters[j].Default = stringKeys(plan.BindParameters[j].Default)
+		}
+		coerceDefaults(plan.Parameters)
+		coerceDefaults(plan.BindParameters)
+	}
+	return spec, nil
+}
+
+// unknownFields - decodes the apb.yml strictly and returns the problems
+// the lenient decoding ignored, e.g. "line 3: field bindabel not found in
+// type bundle.Spec". The fields starting with x- hold anchors and are not
+// reported.
+func unknownFields(data []byte) []string {
+	err := yaml.UnmarshalStrict(data, &Spec{})
+	if err == nil {
+		return nil
+	}
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return []string{err.Error()}
+	}
+	fields := []string{}
+	for _, msg := range typeErr.Errors {
+		if !strings.Contains(msg, ": field x-") {
+			fields = append(fields, msg)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// ResolveSpecYAML - returns the apb.yml with its anchors, aliases and merge
+// keys expanded. When the resolver is not nil the includes are resolved
+// too, they are ignored otherwise:
+//
+//	plans:
+//	  - name: dev
+//	    include: plans/common.yml
+//	    parameters:
+//	      - include: parameters/database.yml
+//	      - name: size
+//
+// A mapping with an include key is merged into the mappings of the
+// included documents, later includes and the keys of the mapping win. An
+// item of a sequence that only has an include key is replaced by the
+// included document, the items of an included sequence are spliced in.
+// The include is a path or a list of paths, read with the resolver.
+//
+// The document is encoded again, numbers keep their value but not their
+// text: strings that look like numbers, e.g. a version of 1.0, have to be
+// quoted.
+func ResolveSpecYAML(data []byte, resolver IncludeResolver) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if resolver != nil {
+		var err error
+		doc, err = resolveIncludes(doc, resolver, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return yaml.Marshal(doc)
+}
+
+// resolveIncludes - resolves the includes of the node, the stack holds the
+// documents being included to detect cycles.
+func resolveIncludes(node interface{}, resolver IncludeResolver, stack []string) (interface{}, error) {
+	switch n := node.(type) {
+	case map[interface{}]interface{}:
+		resolved := map[interface{}]interface{}{}
+		if include, ok := n[IncludeKey]; ok {
+			paths, err := includePaths(include)
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range paths {
+				doc, err := includeDocument(path, resolver, stack)
+				if err != nil {
+					return nil, err
+				}
+				m, ok := doc.(map[interface{}]interface{})
+				if !ok {
+					return nil, ErrSpecInclude{Path: path, Err: fmt.Errorf("a mapping can only include mappings")}
+				}
+				for k, v := range m {
+					resolved[k] = v
+				}
+			}
+		}
+		for k, v := range n {
+			if k == IncludeKey {
+				continue
+			}
+			value, err := resolveIncludes(v, resolver, stack)
+			if err != nil {
+				return nil, err
+			}
+			resolved[k] = value
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := []interface{}{}
+		for _, item := range n {
+			m, ok := item.(map[interface{}]interface{})
+			if !ok || len(m) != 1 || m[IncludeKey] == nil {
+				value, err := resolveIncludes(item, resolver, stack)
+				if err != nil {
+					return nil, err
+				}
+				resolved = append(resolved, value)
+				continue
+			}
+			paths, err := includePaths(m[IncludeKey])
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range paths {
+				doc, err := includeDocument(path, resolver, stack)
+				if err != nil {
+					return nil, err
+				}
+				if items, ok := doc.([]interface{}); ok {
+					resolved = append(resolved, items...)
+				} else {
+					resolved = append(resolved, doc)
+				}
+			}
+		}
+		return resolved, nil
+	}
+	return node, nil
+}
+
+// includeDocument - reads, decodes and resolves the included document.
+func includeDocument(path string, resolver IncludeResolver, stack []string) (interface{}, error) {
+	for _, p := range stack {
+		if p == path {
+			return nil, ErrSpecInclude{Path: path, Err: fmt.Errorf("include cycle %v", strings.Join(append(stack, path), " -> "))}
+		}
+	}
+	if len(stack) >= maxIncludeDepth {
+		return nil, ErrSpecInclude{Path: path, Err: fmt.Errorf("more than %d nested includes", maxIncludeDepth)}
+	}
+	data, err := resolver.ResolveInclude(path)
+	if err != nil {
+		return nil, ErrSpecInclude{Path: path, Err: err}
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, ErrSpecInclude{Path: path, Err: err}
+	}
+	return resolveIncludes(doc, resolver, append(stack, path))
+}
+
+// includePaths - the paths of an include, a path or a list of paths.
+func includePaths(include interface{}) ([]string, error) {
+	switch i := include.(type) {
+	case string:
+		return []string{i}, nil
+	case []interface{}:
+		paths := []string{}
+		for _, p := range i {
+			path, ok := p.(string)
+			if !ok {
+				return nil, ErrSpecInclude{Path: fmt.Sprint(p), Err: fmt.Errorf("the path is not a string")}
+			}
+			paths = append(paths, path)
+		}
+		return paths, nil
+	}
+	return nil, ErrSpecInclude{Path: fmt.Sprint(include), Err: fmt.Errorf("the include is not a path or a list of paths")}
+}
+
+// stringKeys - the value with the keys of its nested maps converted to
+// strings, as decoded from json.
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, val := range v {
+			m[fmt.Sprint(k)] = stringKeys(val)
+		}
+		return m
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		m := map[string]interface{}{}
+		for k, val := range v {
+			m[k] = stringKeys(val)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, val := range v {
+			s[i] = stringKeys(val)
+		}
+		return s
+	}
+	return value
+}
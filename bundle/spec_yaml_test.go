@@ -0,0 +1,194 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const anchoredSpec = `
+name: postgresql-apb
+version: 1.0
+metadata:
+  displayName: PostgreSQL
+  dependencies: ['postgres:9.6']
+  watchTimeouts:
+    provision: {timeout: 30m}
+x-common-params: &common
+  - name: postgresql_database
+    type: string
+    default: admin
+x-plan: &plan
+  free: true
+  bindable: true
+  metadata:
+    cost: $0.00
+plans:
+  - <<: *plan
+    name: dev
+    description: dev plan
+    parameters: *common
+  - <<: *plan
+    name: prod
+    free: false
+    parameters: *common
+`
+
+func TestUnmarshalSpecYAMLAnchors(t *testing.T) {
+	spec, err := UnmarshalSpecYAML([]byte(anchoredSpec), nil)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "postgresql-apb", spec.FQName)
+	assert.Equal(t, "1.0", spec.Version)
+	if len(spec.Plans) != 2 {
+		t.Fatalf("expected 2 plans, got %d", len(spec.Plans))
+	}
+	dev, prod := spec.Plans[0], spec.Plans[1]
+	assert.True(t, dev.Free)
+	assert.True(t, dev.Bindable)
+	assert.Equal(t, "dev plan", dev.Description)
+	assert.False(t, prod.Free)
+	assert.True(t, prod.Bindable)
+	assert.Equal(t, map[string]interface{}{"cost": "$0.00"}, prod.Metadata)
+	assert.Equal(t, "postgresql_database", prod.Parameters[0].Name)
+	assert.Equal(t, "admin", prod.Parameters[0].Default)
+	// the nested maps have string keys
+	assert.Equal(t, map[string]interface{}{
+		"provision": map[string]interface{}{"timeout": "30m"},
+	}, spec.Metadata[WatchTimeoutsKey])
+	// the x- fields holding the anchors are not unknown
+	assert.Nil(t, spec.UnknownFields)
+}
+
+func TestUnmarshalSpecYAMLUnknownFields(t *testing.T) {
+	data := `
+name: postgresql-apb
+version: 1.0
+bindabel: true
+plans:
+  - name: dev
+    descripton: dev plan
+`
+	spec, err := UnmarshalSpecYAML([]byte(data), nil)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "postgresql-apb", spec.FQName)
+	assert.Equal(t, "dev", spec.Plans[0].Name)
+	assert.Equal(t, []string{
+		"line 4: field bindabel not found in type bundle.Spec",
+		"line 7: field descripton not found in type bundle.Plan",
+	}, spec.UnknownFields)
+}
+
+func TestUnmarshalSpecYAMLIncludes(t *testing.T) {
+	files := map[string]string{
+		"plan.yml": "free: true\nbindable: true\ndescription: included\n",
+		"params.yml": `
+- name: postgresql_database
+  default: admin
+- name: postgresql_user
+  default: admin
+`,
+		"password.yml": "name: postgresql_password\ndisplay_type: password\n",
+		"cycle-a.yml":  "include: cycle-b.yml\n",
+		"cycle-b.yml":  "include: cycle-a.yml\n",
+	}
+	resolver := IncludeResolverFunc(func(path string) ([]byte, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, fmt.Errorf("%v not found", path)
+		}
+		return []byte(content), nil
+	})
+
+	data := []byte(`
+name: postgresql-apb
+plans:
+  - name: dev
+    include: plan.yml
+    description: dev plan
+    parameters:
+      - include: [params.yml, password.yml]
+      - name: size
+`)
+	spec, err := UnmarshalSpecYAML(data, resolver)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	plan := spec.Plans[0]
+	assert.True(t, plan.Free)
+	assert.True(t, plan.Bindable)
+	// the keys of the mapping win over the included ones
+	assert.Equal(t, "dev plan", plan.Description)
+	names := []string{}
+	for _, p := range plan.Parameters {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"postgresql_database", "postgresql_user", "postgresql_password", "size"}, names)
+	assert.Equal(t, PasswordDisplayType, plan.Parameters[2].DisplayType)
+
+	// includes are ignored without a resolver
+	spec, err = UnmarshalSpecYAML(data, nil)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.False(t, spec.Plans[0].Free)
+	assert.Equal(t, "", spec.Plans[0].Parameters[0].Name)
+
+	for _, doc := range []string{
+		"plans:\n  - include: missing.yml\n",
+		"include: cycle-a.yml\n",
+		"include: params.yml\n",
+		"include: {path: plan.yml}\n",
+	} {
+		_, err := UnmarshalSpecYAML([]byte(doc), resolver)
+		assert.True(t, IsErrSpecInclude(err), "unexpected error for %q: %v", doc, err)
+	}
+}
+
+func TestDirIncludeResolver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "includes")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "plans"), 0755); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "plans", "dev.yml"), []byte("name: dev\n"), 0644); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	resolver := DirIncludeResolver(dir)
+	content, err := resolver.ResolveInclude("plans/dev.yml")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "name: dev\n", string(content))
+	_, err = resolver.ResolveInclude("../outside.yml")
+	assert.Error(t, err)
+	_, err = resolver.ResolveInclude(filepath.Join(dir, "plans", "dev.yml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"context"
+
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+const (
+	// StatusPolicyBlock - a progress message waits for room in the status
+	// channel, a slow consumer stalls the action.
+	StatusPolicyBlock = "block"
+	// StatusPolicyDrop - a progress message that does not fit in the
+	// status channel is dropped.
+	StatusPolicyDrop = "drop"
+	// StatusPolicyCoalesce - a progress message that does not fit in the
+	// status channel replaces the oldest message waiting in it, so the
+	// consumer catches up with the latest progress. Requires a
+	// StatusBuffer, an unbuffered channel drops the message instead.
+	StatusPolicyCoalesce = "coalesce"
+)
+
+// isFinalStatus - true if the status ends the action. The final status is
+// always delivered, whatever the status policy.
+func isFinalStatus(status StatusMessage) bool {
+	return status.State == StateSucceeded || status.State == StateFailed
+}
+
+// sendStatus - sends the status unless the action was cancelled, so a
+// status nobody reads does not block the action forever. Progress
+// messages that do not fit in the status channel are handled according to
+// the status policy. LastStatus reports the status either way.
+func (e *executor) sendStatus(status StatusMessage) {
+	if !isFinalStatus(status) {
+		switch e.statusPolicy {
+		case StatusPolicyDrop:
+			select {
+			case e.statusChan <- status:
+			default:
+				log.Debugf("executor::status channel full, dropping status [ %v ]", status.Description)
+			}
+			return
+		case StatusPolicyCoalesce:
+			e.coalesceStatus(status)
+			return
+		}
+	}
+
+	ctx := e.actionCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case e.statusChan <- status:
+	case <-ctx.Done():
+		log.Warningf("executor::action cancelled, dropping status [ %v ]", status.State)
+	}
+}
+
+// coalesceStatus - sends the status, making room for it by discarding the
+// oldest message in the status channel. Only progress messages are ever
+// discarded, the final status is the last message sent.
+func (e *executor) coalesceStatus(status StatusMessage) {
+	for i := 0; i < 2; i++ {
+		select {
+		case e.statusChan <- status:
+			return
+		default:
+		}
+		select {
+		case old := <-e.statusChan:
+			log.Debugf("executor::status channel full, coalescing status [ %v ]", old.Description)
+		default:
+		}
+	}
+	log.Debugf("executor::status channel full, dropping status [ %v ]", status.Description)
+}
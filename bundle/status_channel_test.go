@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func progress(i int) StatusMessage {
+	return StatusMessage{State: StateInProgress, Description: fmt.Sprintf("step %d", i)}
+}
+
+func drain(c chan StatusMessage) []string {
+	descriptions := []string{}
+	for {
+		select {
+		case status := <-c:
+			descriptions = append(descriptions, status.Description)
+		default:
+			return descriptions
+		}
+	}
+}
+
+func TestSendStatusPolicies(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   string
+		expected []string
+	}{
+		{
+			name:     "drop keeps the oldest messages",
+			policy:   StatusPolicyDrop,
+			expected: []string{"step 0", "step 1"},
+		},
+		{
+			name:     "coalesce keeps the newest messages",
+			policy:   StatusPolicyCoalesce,
+			expected: []string{"step 2", "step 3"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &executor{statusChan: make(chan StatusMessage, 2), statusPolicy: tc.policy}
+			for i := 0; i < 4; i++ {
+				e.sendStatus(progress(i))
+			}
+			assert.Equal(t, tc.expected, drain(e.statusChan))
+		})
+	}
+}
+
+func TestSendStatusDeliversFinalStatus(t *testing.T) {
+	for _, policy := range []string{StatusPolicyDrop, StatusPolicyCoalesce} {
+		e := &executor{statusChan: make(chan StatusMessage, 1), statusPolicy: policy}
+		e.sendStatus(progress(0))
+
+		done := make(chan struct{})
+		go func() {
+			e.sendStatus(StatusMessage{State: StateSucceeded, Description: "done"})
+			close(done)
+		}()
+
+		assert.Equal(t, "step 0", (<-e.statusChan).Description)
+		assert.Equal(t, StateSucceeded, (<-e.statusChan).State)
+		<-done
+	}
+}
+
+func TestSendStatusUnbufferedCoalesce(t *testing.T) {
+	e := &executor{statusChan: make(chan StatusMessage), statusPolicy: StatusPolicyCoalesce}
+	// nothing reads the channel, the message is dropped instead of blocking
+	e.sendStatus(progress(0))
+	assert.Empty(t, drain(e.statusChan))
+}
+
+func TestNewExecutorStatusConfig(t *testing.T) {
+	e := NewExecutor(ExecutorConfig{StatusBuffer: 5, StatusPolicy: "sometimes"}).(*executor)
+	assert.Equal(t, 5, cap(e.statusChan))
+	assert.Equal(t, StatusPolicyBlock, e.statusPolicy)
+
+	e = NewExecutor(ExecutorConfig{StatusBuffer: -1, StatusPolicy: StatusPolicyDrop}).(*executor)
+	assert.Equal(t, 0, cap(e.statusChan))
+	assert.Equal(t, StatusPolicyDrop, e.statusPolicy)
+}
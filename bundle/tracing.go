@@ -0,0 +1,104 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"context"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/automationbroker/bundle-lib/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// startActionSpan - starts the span that covers the whole action, a child
+// of the context of the executor. The span is ended when the action
+// finishes.
+func (e *executor) startActionSpan(action string, instance *ServiceInstance) {
+	ctx, span := tracing.StartSpan(e.ctx, "bundle."+action,
+		attribute.String("bundle.action", action),
+		attribute.String("bundle.fqname", instance.Spec.FQName),
+		attribute.String("bundle.image", instance.Spec.Image),
+		attribute.String("bundle.instance", instance.ID.String()),
+	)
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.span, e.spanCtx = span, ctx
+}
+
+// endActionSpan - ends the span of the action if one was started. The
+// caller holds the mutex of the executor.
+func (e *executor) endActionSpan(err error) {
+	if e.span == nil {
+		return
+	}
+	tracing.EndSpan(e.span, err)
+	e.span, e.spanCtx = nil, nil
+}
+
+// traceContext - the context the spans of the steps of the running action
+// are started from, the context of the executor outside of an action.
+func (e *executor) traceContext() context.Context {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.spanCtx != nil {
+		return e.spanCtx
+	}
+	return e.ctx
+}
+
+func (e *executor) createSandbox(
+	podName, namespace string, targets []string, labels map[string]string,
+) (string, string, error) {
+	_, span := tracing.StartSpan(e.traceContext(), "runtime.CreateSandbox",
+		attribute.String("bundle.pod", podName),
+		attribute.String("bundle.namespace", namespace),
+	)
+	serviceAccount, ns, err := runtime.Provider.CreateSandbox(podName, namespace, targets, clusterConfig.SandboxRole, labels)
+	tracing.EndSpan(span, err)
+	return serviceAccount, ns, err
+}
+
+func (e *executor) runBundle(ec runtime.ExecutionContext) (runtime.ExecutionContext, error) {
+	_, span := tracing.StartSpan(e.traceContext(), "runtime.RunBundle",
+		attribute.String("bundle.pod", ec.BundleName),
+		attribute.String("bundle.namespace", ec.Location),
+		attribute.String("bundle.image", ec.Image),
+	)
+	ec, err := runtime.Provider.RunBundle(ec)
+	tracing.EndSpan(span, err)
+	return ec, err
+}
+
+func (e *executor) watchRunningBundle(podName, namespace string) error {
+	_, span := tracing.StartSpan(e.traceContext(), "runtime.WatchRunningBundle",
+		attribute.String("bundle.pod", podName),
+		attribute.String("bundle.namespace", namespace),
+	)
+	err := runtime.Provider.WatchRunningBundle(podName, namespace, e.updateDescription)
+	tracing.EndSpan(span, err)
+	return err
+}
+
+func (e *executor) extractCredentials(podName, namespace string, bundleRuntime int) ([]byte, error) {
+	_, span := tracing.StartSpan(e.traceContext(), "runtime.ExtractCredentials",
+		attribute.String("bundle.pod", podName),
+		attribute.String("bundle.namespace", namespace),
+	)
+	credBytes, err := runtime.Provider.ExtractCredentials(podName, namespace, bundleRuntime)
+	tracing.EndSpan(span, err)
+	return credBytes, err
+}
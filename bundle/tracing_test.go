@@ -0,0 +1,60 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/tracing"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestActionSpansAreSiblings(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracing.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer tracing.SetTracerProvider(nil)
+
+	ctx, root := tracing.StartSpan(context.Background(), "root")
+	e := &executor{ctx: ctx}
+	instance := &ServiceInstance{ID: uuid.NewRandom(), Spec: &Spec{FQName: "test"}}
+	for _, action := range []string{"provision", "bind"} {
+		e.startActionSpan(action, instance)
+		_, step := tracing.StartSpan(e.traceContext(), "step")
+		step.End()
+		e.mutex.Lock()
+		e.endActionSpan(nil)
+		e.mutex.Unlock()
+		assert.Equal(t, ctx, e.ctx)
+		assert.Equal(t, ctx, e.traceContext())
+	}
+	root.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 5 {
+		t.Fatalf("expected 5 spans got: %v", len(spans))
+	}
+	rootID := root.SpanContext().SpanID()
+	// the steps are children of their action, the actions of the root
+	assert.Equal(t, rootID, spans[1].Parent().SpanID())
+	assert.Equal(t, rootID, spans[3].Parent().SpanID())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, spans[3].SpanContext().SpanID(), spans[2].Parent().SpanID())
+}
@@ -20,9 +20,11 @@ import (
 	"encoding/json"
 	"reflect"
 
+	"github.com/automationbroker/bundle-lib/authorization"
+	log "github.com/automationbroker/bundle-lib/logging"
+	"github.com/automationbroker/bundle-lib/runtime"
 	schema "github.com/lestrrat/go-jsschema"
 	"github.com/pborman/uuid"
-	log "github.com/sirupsen/logrus"
 )
 
 // Parameters - generic string to object or value parameter
@@ -43,9 +45,6 @@ func (p *Parameters) Add(key string, val interface{}) {
 // SpecManifest - Spec ID to Spec manifest
 type SpecManifest map[string]*Spec
 
-// NilableNumber - Number that could be nil (e.g. when omitted from json/yaml)
-type NilableNumber float64
-
 // ParameterDescriptor - a parameter to be used by the service catalog to get data.
 type ParameterDescriptor struct {
 	Name        string      `json:"name"`
@@ -60,12 +59,20 @@ type ParameterDescriptor struct {
 	MinLength           int    `json:"minLength,omitempty" yaml:"min_length,omitempty"`
 	Pattern             string `json:"pattern,omitempty"`
 
-	// number validators
-	MultipleOf       float64        `json:"multipleOf,omitempty" yaml:"multiple_of,omitempty"`
-	Maximum          *NilableNumber `json:"maximum,omitempty"`
-	ExclusiveMaximum *NilableNumber `json:"exclusiveMaximum,omitempty" yaml:"exclusive_maximum,omitempty"`
-	Minimum          *NilableNumber `json:"minimum,omitempty"`
-	ExclusiveMinimum *NilableNumber `json:"exclusiveMinimum,omitempty" yaml:"exclusive_minimum,omitempty"`
+	// number validators, kept in sync with the Number validators when the
+	// descriptor is encoded or decoded, see Bounds.
+	MultipleOf       float64        `json:"-" yaml:"-"`
+	Maximum          *NilableNumber `json:"-" yaml:"-"`
+	ExclusiveMaximum *NilableNumber `json:"-" yaml:"-"`
+	Minimum          *NilableNumber `json:"-" yaml:"-"`
+	ExclusiveMinimum *NilableNumber `json:"-" yaml:"-"`
+
+	// number validators keeping the precision of large integers
+	MultipleOfNumber       *Number `json:"multipleOf,omitempty" yaml:"multiple_of,omitempty"`
+	MaximumNumber          *Number `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	ExclusiveMaximumNumber *Number `json:"exclusiveMaximum,omitempty" yaml:"exclusive_maximum,omitempty"`
+	MinimumNumber          *Number `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	ExclusiveMinimumNumber *Number `json:"exclusiveMinimum,omitempty" yaml:"exclusive_minimum,omitempty"`
 
 	Enum         []string     `json:"enum,omitempty"`
 	Required     bool         `json:"required"`
@@ -73,6 +80,9 @@ type ParameterDescriptor struct {
 	DisplayType  string       `json:"displayType,omitempty" yaml:"display_type,omitempty"`
 	DisplayGroup string       `json:"displayGroup,omitempty" yaml:"display_group,omitempty"`
 	Dependencies []Dependency `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+
+	// Localizations - the title and description of the parameter by locale.
+	Localizations map[string]Localization `json:"localizations,omitempty" yaml:"localizations,omitempty"`
 }
 
 // Dependency - a parameter dependency
@@ -113,6 +123,13 @@ type Plan struct {
 	Parameters     []ParameterDescriptor  `json:"parameters"`
 	BindParameters []ParameterDescriptor  `json:"bind_parameters,omitempty" yaml:"bind_parameters,omitempty"`
 	UpdatesTo      []string               `json:"updates_to,omitempty" yaml:"updates_to,omitempty"`
+	// Localizations - the display metadata of the plan by locale.
+	Localizations map[string]Localization `json:"localizations,omitempty" yaml:"localizations,omitempty"`
+	// Costs - the price of the plan, replaces the free-form cost metadata.
+	Costs []CostEntry `json:"costs,omitempty" yaml:"costs,omitempty"`
+	// CredentialOnlyBind - bind returns the credentials extracted at
+	// provision time and unbind deletes them, the bundle is not run.
+	CredentialOnlyBind bool `json:"credential_only_bind,omitempty" yaml:"credential_only_bind,omitempty"`
 }
 
 // SchemaPlan - Plan object describing an APB deployment plan and associated parameters
@@ -153,6 +170,65 @@ type Spec struct {
 	Plans       []Plan                 `json:"plans"`
 	Alpha       map[string]interface{} `json:"alpha,omitempty"`
 	Delete      bool                   `json:"delete"`
+	// OS - the operating system of the image, read from the image config.
+	// An empty value means linux.
+	OS string `json:"os,omitempty" yaml:"-"`
+	// Architecture - the CPU architecture of the image, read from the image
+	// config.
+	Architecture string `json:"architecture,omitempty" yaml:"-"`
+	// Localizations - the display metadata of the spec by locale.
+	Localizations map[string]Localization `json:"localizations,omitempty" yaml:"localizations,omitempty"`
+	// BindCredentials - which extracted credentials are returned for
+	// bindings. When nil all of them are.
+	BindCredentials *BindCredentials `json:"bind_credentials,omitempty" yaml:"bind_credentials,omitempty"`
+	// DashboardURLTemplate - a text/template of the dashboard URL rendered
+	// with the DashboardURLVars after provisioning, for bundles that do not
+	// report the dashboard URL themselves.
+	DashboardURLTemplate string `json:"dashboard_url_template,omitempty" yaml:"dashboardUrlTemplate,omitempty"`
+	// Provenance - where the spec was loaded from, set by the registry.
+	Provenance *Provenance `json:"provenance,omitempty" yaml:"-"`
+	// HelmChart - the chart the spec installs, set by the helm registry
+	// when it has no runner image. The chart is run with the helm CLI
+	// instead of a bundle.
+	HelmChart *HelmChart `json:"helm_chart,omitempty" yaml:"-"`
+	// Operator - the OLM operator the spec installs, set by the olm
+	// registry.
+	Operator *OperatorBundle `json:"operator,omitempty" yaml:"-"`
+	// Manifests - the Kubernetes manifests the spec applies instead of
+	// running a bundle. The image defaults to DefaultManifestsImage.
+	Manifests *ManifestSource `json:"manifests,omitempty" yaml:"manifests,omitempty"`
+	// Requires - the specs provisioned before this one, see Requirement.
+	Requires []Requirement `json:"requires,omitempty" yaml:"requires,omitempty"`
+	// Actions - the custom actions of the bundle, see CustomAction.
+	Actions []CustomAction `json:"actions,omitempty" yaml:"actions,omitempty"`
+	// Categories - the categories of the spec, set from the categories
+	// metadata by NormalizeTaxonomy.
+	Categories []Category `json:"categories,omitempty" yaml:"-"`
+	// UnknownFields - the fields of the apb.yml that are not part of the
+	// spec, usually misspelled keys. Set by UnmarshalSpecYAML.
+	UnknownFields []string `json:"-" yaml:"-"`
+}
+
+// imageDependencies - the images the bundle deploys, listed in the
+// dependencies metadata of the spec.
+func (s *Spec) imageDependencies() []string {
+	deps, ok := s.Metadata["dependencies"].([]interface{})
+	if !ok {
+		return nil
+	}
+	images := []string{}
+	for _, d := range deps {
+		if image, ok := d.(string); ok && image != "" {
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
+// requiresImage - false for the specs that do not run their image, OLM
+// operators and manifests applied with the default image.
+func (s *Spec) requiresImage() bool {
+	return s.Operator == nil && s.Manifests == nil
 }
 
 // GetPlan - retrieves a plan from a spec by name. Will return
@@ -181,6 +257,24 @@ func (s *Spec) GetPlanFromID(id string) (Plan, bool) {
 type Context struct {
 	Platform  string `json:"platform"`
 	Namespace string `json:"namespace"`
+	// Targets - additional namespaces the bundle deploys into. The sandbox
+	// is given the same permissions in them as in Namespace.
+	Targets []string `json:"targets,omitempty"`
+}
+
+// TargetNamespaces - returns Namespace followed by the additional targets,
+// without duplicates.
+func (c *Context) TargetNamespaces() []string {
+	targets := []string{c.Namespace}
+	seen := map[string]bool{c.Namespace: true}
+	for _, t := range c.Targets {
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		targets = append(targets, t)
+	}
+	return targets
 }
 
 // ExtractedCredentials - Credentials that are extracted from the pods
@@ -205,6 +299,9 @@ type StatusMessage struct {
 	State       State
 	Description string
 	Error       error
+	// FailureReason - why the action failed, distinguishes a bundle pod
+	// that failed to start from a playbook that returned non-zero.
+	FailureReason runtime.FailureReason
 }
 
 // JobMethod - APB Method Type that the job was spawned from.
@@ -244,6 +341,9 @@ type ClusterConfig struct {
 	Namespace            string `yaml:"namespace"`
 	KeepNamespace        bool   `yaml:"keep_namespace"`
 	KeepNamespaceOnError bool   `yaml:"keep_namespace_on_error"`
+	// IngressDomain - the domain of the cluster routes and ingresses,
+	// available to the dashboard URL templates.
+	IngressDomain string `yaml:"ingress_domain"`
 }
 
 // ClusterConfiguration that should be used by the apb package.
@@ -275,6 +375,21 @@ const (
 	ClusterKey = "cluster"
 	// NamespaceKey parameter name passed to APBs
 	NamespaceKey = "namespace"
+	// TargetNamespacesKey parameter name passed to APBs with all the
+	// namespaces the bundle may deploy into, the first is NamespaceKey
+	TargetNamespacesKey = "target_namespaces"
+	// PlanParameterKey parameter name of the plan passed to APBs
+	PlanParameterKey = "_apb_plan_id"
+	// NamespaceLabelsKey parameter name passed to APBs with the labels of
+	// the target namespace selected by ExecutorConfig.NamespaceLabels
+	NamespaceLabelsKey = "namespace_labels"
+	// NamespaceAnnotationsKey parameter name passed to APBs with the
+	// annotations of the target namespace selected by
+	// ExecutorConfig.NamespaceAnnotations
+	NamespaceAnnotationsKey = "namespace_annotations"
+	// BrokerParameterPrefix - the prefix of the parameter names reserved
+	// for the broker, e.g. _apb_service_instance_id.
+	BrokerParameterPrefix = "_apb_"
 )
 
 // SpecLogDump - log spec for debug
@@ -295,7 +410,11 @@ func SpecLogDump(spec *Spec) {
 			log.Debugf("  Title: %s", param.Title)
 			log.Debugf("  Type: %s", param.Type)
 			log.Debugf("  Description: %s", param.Description)
-			log.Debugf("  Default: %#v", param.Default)
+			if param.DisplayType == PasswordDisplayType {
+				log.Debugf("  Default: %s", RedactedValue)
+			} else {
+				log.Debugf("  Default: %#v", param.Default)
+			}
 			log.Debugf("  DeprecatedMaxlength: %d", param.DeprecatedMaxlength)
 			log.Debugf("  MaxLength: %d", param.MaxLength)
 			log.Debugf("  MinLength: %d", param.MinLength)
@@ -338,6 +457,40 @@ type ServiceInstance struct {
 	Parameters   *Parameters     `json:"parameters"`
 	BindingIDs   map[string]bool `json:"binding_ids"`
 	DashboardURL string          `json:"dashboard_url"`
+	// Labels - user supplied labels, e.g. the owner or cost center. They
+	// are added to the sandbox namespace and bundle pod.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations - user supplied annotations. They are added to the
+	// bundle pod.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// UserInfo - the user requesting the current action. It is used to
+	// authorize the action when the executor has an authorizer configured
+	// and is not persisted.
+	UserInfo authorization.AuthorizeUser `json:"-"`
+	// RequiredInstances - the instances provisioned for the requirements of
+	// the spec, in the order they were provisioned.
+	RequiredInstances []RequiredInstance `json:"required_instances,omitempty"`
+}
+
+// planName - returns the name of the plan the instance was provisioned with.
+func (si *ServiceInstance) planName() string {
+	if si.Parameters == nil {
+		return ""
+	}
+	if plan, ok := (*si.Parameters)[PlanParameterKey].(string); ok {
+		return plan
+	}
+	return ""
+}
+
+// credentialOnlyBind - true if the plan of the instance binds without
+// running the bundle.
+func (si *ServiceInstance) credentialOnlyBind() bool {
+	if si.Spec == nil {
+		return false
+	}
+	plan, ok := si.Spec.GetPlan(si.planName())
+	return ok && plan.CredentialOnlyBind
 }
 
 // AddBinding - Add binding ID to service instance
@@ -361,6 +514,10 @@ type BindInstance struct {
 	ServiceID    uuid.UUID   `json:"service_id"`
 	Parameters   *Parameters `json:"parameters"`
 	CreateJobKey string
+	// Labels - user supplied labels of the binding.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations - user supplied annotations of the binding.
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // UserParameters - returns the Parameters field with any keys and values
@@ -374,7 +531,8 @@ func (bi *BindInstance) UserParameters() Parameters {
 	for key, value := range *bi.Parameters {
 		switch key {
 		// Do not copy keys that are generally added by the broker itself.
-		case ClusterKey, NamespaceKey, ProvisionCredentialsKey:
+		case ClusterKey, NamespaceKey, TargetNamespacesKey, ProvisionCredentialsKey,
+			NamespaceLabelsKey, NamespaceAnnotationsKey:
 			continue
 		}
 		userparams[key] = value
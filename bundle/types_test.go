@@ -550,6 +550,30 @@ func TestAddRemoveBinding(t *testing.T) {
 	assert.False(t, toDelete, "binding not marked as deleted")
 }
 
+func TestContextTargetNamespaces(t *testing.T) {
+	testCases := []struct {
+		name     string
+		context  Context
+		expected []string
+	}{
+		{
+			name:     "namespace only",
+			context:  Context{Namespace: "ns"},
+			expected: []string{"ns"},
+		},
+		{
+			name:     "additional targets",
+			context:  Context{Namespace: "ns", Targets: []string{"db", "ns", "", "web", "db"}},
+			expected: []string{"ns", "db", "web"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.context.TargetNamespaces())
+		})
+	}
+}
+
 func TestGetParameter(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -925,3 +949,11 @@ func TestNewSpecManifest(t *testing.T) {
 		})
 	}
 }
+
+func TestSpecImageDependencies(t *testing.T) {
+	spec := &Spec{Metadata: map[string]interface{}{
+		"dependencies": []interface{}{"docker.io/centos/postgresql-95-centos7", "", 3},
+	}}
+	assert.Equal(t, []string{"docker.io/centos/postgresql-95-centos7"}, spec.imageDependencies())
+	assert.Nil(t, (&Spec{}).imageDependencies())
+}
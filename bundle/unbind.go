@@ -19,9 +19,8 @@ package bundle
 import (
 	"fmt"
 
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/automationbroker/bundle-lib/runtime"
-	"github.com/pborman/uuid"
-	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -41,8 +40,26 @@ func (e *executor) Unbind(
 	log.Infof("ServiceInstance.Description: %s", instance.Spec.Description)
 	log.Infof("============================================================")
 
-	go func() {
+	return e.runAction(instance, func() {
+		e.startActionSpan(unbindAction, instance)
+		e.startOperation(unbindAction, instance, parameters)
+		e.startEvent(unbindAction, instance, bindingID)
 		e.actionStarted()
+		if err := validateBindParameters(unbindAction, instance, parameters); err != nil {
+			e.actionFinishedWithError(err)
+			return
+		}
+		if instance.credentialOnlyBind() {
+			log.Infof("plan binds with the instance credentials, not running bundle for binding %v", bindingID)
+			err := runtime.Provider.DeleteExtractedCredential(bindingID, clusterConfig.Namespace)
+			if err != nil {
+				log.Errorf("Unbind failed to delete extracted credential - %v", err)
+				e.actionFinishedWithError(err)
+				return
+			}
+			e.actionFinishedWithSuccess()
+			return
+		}
 		// Create namespace name that will be used to generate a name.
 		ns := fmt.Sprintf("%s-%.4s-", instance.Spec.FQName, unbindAction)
 		// Determine if we should be using the context namespace from the executor config.
@@ -50,28 +67,33 @@ func (e *executor) Unbind(
 			ns = instance.Context.Namespace
 		}
 		// Create the podname
-		pn := fmt.Sprintf("bundle-%s", uuid.New())
-		targets := []string{instance.Context.Namespace}
+		pn, key := e.newSandboxName(instance, unbindAction)
+		targets := instance.Context.TargetNamespaces()
 		labels := map[string]string{
 			"bundle-fqname":   instance.Spec.FQName,
 			"bundle-action":   unbindAction,
 			"bundle-pod-name": pn,
 		}
+		labels = sandboxLabels(labels, instance.Labels)
+		labels[runtime.IdempotencyKeyLabel] = key
 
-		serviceAccount, namespace, err := runtime.Provider.CreateSandbox(pn, ns, targets, clusterConfig.SandboxRole, labels)
+		serviceAccount, namespace, err := e.createSandbox(pn, ns, targets, labels)
 		if err != nil {
 			log.Errorf("Problem executing bundle create sandbox [%s] unbind", pn)
 			e.actionFinishedWithError(err)
 			return
 		}
 		ec := runtime.ExecutionContext{
-			BundleName: pn,
-			Targets:    targets,
-			Metadata:   labels,
-			Action:     unbindAction,
-			Image:      instance.Spec.Image,
-			Account:    serviceAccount,
-			Location:   namespace,
+			BundleName:     pn,
+			Targets:        targets,
+			Metadata:       labels,
+			Annotations:    instance.Annotations,
+			Action:         unbindAction,
+			Image:          instance.Spec.Image,
+			RuntimeVersion: instance.Spec.Runtime,
+			Account:        serviceAccount,
+			Location:       namespace,
+			IdempotencyKey: key,
 		}
 		ec, err = e.executeApb(ec, instance, parameters)
 		defer runtime.Provider.DestroySandbox(
@@ -88,7 +110,7 @@ func (e *executor) Unbind(
 			return
 		}
 
-		err = runtime.Provider.WatchRunningBundle(ec.BundleName, ec.Location, e.updateDescription)
+		err = e.watchRunningBundle(ec.BundleName, ec.Location)
 		if err != nil {
 			log.Errorf("Unbind action failed - %v", err)
 			e.actionFinishedWithError(err)
@@ -113,7 +135,5 @@ func (e *executor) Unbind(
 		}
 
 		e.actionFinishedWithSuccess()
-	}()
-
-	return e.statusChan
+	})
 }
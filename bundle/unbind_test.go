@@ -159,6 +159,67 @@ func TestUnbind(t *testing.T) {
 				return true
 			},
 		},
+		{
+			name:   "unbind with the instance credentials",
+			config: ExecutorConfig{},
+			rt:     *new(runtime.MockRuntime),
+			si: ServiceInstance{
+				ID: u,
+				Spec: &Spec{
+					ID:       "new-spec-id",
+					FQName:   "new-fq-name",
+					Bindable: true,
+					Plans:    []Plan{{Name: "dev", CredentialOnlyBind: true}},
+				},
+				Context:    ctx,
+				Parameters: &Parameters{PlanParameterKey: "dev"},
+			},
+			bindingID: bID.String(),
+			addExpectations: func(rt *runtime.MockRuntime, e Executor) {
+				// no sandbox is created and no bundle is run
+				rt.On("DeleteExtractedCredential",
+					bID.String(), mock.Anything,
+				).Return(nil)
+			},
+			validateMessage: func(m []StatusMessage) bool {
+				if len(m) != 2 {
+					return false
+				}
+				first := m[0]
+				second := m[1]
+				if first.State != StateInProgress {
+					return false
+				}
+				if second.State != StateSucceeded {
+					return false
+				}
+				return true
+			},
+		},
+		{
+			name:   "unbind with undeclared parameters",
+			config: ExecutorConfig{},
+			rt:     *new(runtime.MockRuntime),
+			si: ServiceInstance{
+				ID: u,
+				Spec: &Spec{
+					ID:       "new-spec-id",
+					FQName:   "new-fq-name",
+					Bindable: true,
+					Plans:    []Plan{{Name: "dev", CredentialOnlyBind: true}},
+				},
+				Context:    ctx,
+				Parameters: &Parameters{PlanParameterKey: "dev", "undeclared": "foo"},
+			},
+			bindingID:       bID.String(),
+			addExpectations: func(rt *runtime.MockRuntime, e Executor) {},
+			validateMessage: func(m []StatusMessage) bool {
+				if len(m) != 2 {
+					return false
+				}
+				return m[1].State == StateFailed && IsErrInvalidParameters(m[1].Error)
+			},
+		},
 		{
 			name:   "unbind fails to delete extracted credentials",
 			config: ExecutorConfig{},
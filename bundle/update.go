@@ -17,8 +17,8 @@
 package bundle
 
 import (
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/automationbroker/bundle-lib/runtime"
-	log "github.com/sirupsen/logrus"
 )
 
 // Update - will run the abp with the provision action.
@@ -32,7 +32,10 @@ func (e *executor) Update(instance *ServiceInstance) <-chan StatusMessage {
 	log.Infof("Spec.Description: %s", instance.Spec.Description)
 	log.Infof("============================================================")
 
-	go func() {
+	return e.runAction(instance, func() {
+		e.startActionSpan(string(executionMethodUpdate), instance)
+		e.startOperation(string(executionMethodUpdate), instance, instance.Parameters)
+		e.startEvent(string(executionMethodUpdate), instance, "")
 		e.actionStarted()
 		err := e.provisionOrUpdate(executionMethodUpdate, instance)
 		if err != nil {
@@ -50,7 +53,5 @@ func (e *executor) Update(instance *ServiceInstance) <-chan StatusMessage {
 			}
 		}
 		e.actionFinishedWithSuccess()
-	}()
-
-	return e.statusChan
+	})
 }
@@ -19,10 +19,12 @@ package bundle
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"encoding/json"
 
+	log "github.com/automationbroker/bundle-lib/logging"
 	schema "github.com/lestrrat/go-jsschema"
 )
 
@@ -247,27 +249,35 @@ func parametersToSchema(plan Plan) (Schema, error) {
 	return s, nil
 }
 
+// ParameterToSchema - converts a parameter to its JSON Schema property.
+func ParameterToSchema(pd ParameterDescriptor) (*schema.Schema, error) {
+	t, err := getType(pd.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	prop := &schema.Schema{
+		Title:       pd.Title,
+		Description: pd.Description,
+		Default:     schemaDefault(pd),
+		Type:        t,
+	}
+
+	setStringValidators(pd, prop)
+	setNumberValidators(pd, prop)
+	setEnum(pd, prop)
+	return prop, nil
+}
+
 func extractProperties(params []ParameterDescriptor) (map[string]*schema.Schema, error) {
 	properties := make(map[string]*schema.Schema)
 
 	for _, pd := range params {
-		k := pd.Name
-
-		t, err := getType(pd.Type)
+		prop, err := ParameterToSchema(pd)
 		if err != nil {
 			return properties, err
 		}
-
-		properties[k] = &schema.Schema{
-			Title:       pd.Title,
-			Description: pd.Description,
-			Default:     pd.Default,
-			Type:        t,
-		}
-
-		setStringValidators(pd, properties[k])
-		setNumberValidators(pd, properties[k])
-		setEnum(pd, properties[k])
+		properties[pd.Name] = prop
 	}
 
 	return properties, nil
@@ -312,42 +322,103 @@ func setNumberValidators(pd ParameterDescriptor, prop *schema.Schema) {
 		return
 	}
 
-	// since 0 is not useful as a value for multipleOf,
-	// we can use it as a float64 and not worry about nil
-	if pd.MultipleOf > 0 {
-		prop.MultipleOf = schema.Number{Val: pd.MultipleOf, Initialized: true}
+	bounds := pd.Bounds()
+	// 0 is not useful as a value for multipleOf
+	if bounds.MultipleOf != nil {
+		if m := schemaNumber(bounds.MultipleOf); m.Val > 0 {
+			prop.MultipleOf = m
+		}
 	}
 
 	// since 0 is a valid value for maximum, minimum, exclusiveMaximum, and exclusiveMinimum,
 	// we have to allow for empty.
-	if pd.Maximum != nil {
-		prop.Maximum = schema.Number{Val: float64(*pd.Maximum), Initialized: true}
+	if bounds.Maximum != nil {
+		prop.Maximum = schemaNumber(bounds.Maximum)
 	}
-	if pd.Minimum != nil {
-		prop.Minimum = schema.Number{Val: float64(*pd.Minimum), Initialized: true}
+	if bounds.Minimum != nil {
+		prop.Minimum = schemaNumber(bounds.Minimum)
 	}
 
 	// JSON Schema defines exclusiveMaximum and exclusiveMinimum as numbers separate from maximum and minimum
 	// but go-jsschema defines ExclusiveMaximum and ExclusiveMinimum as bool and reuses Maximum and Minimum
-	if pd.ExclusiveMaximum != nil {
-		prop.Maximum = schema.Number{Val: float64(*pd.ExclusiveMaximum), Initialized: true}
+	if bounds.ExclusiveMaximum != nil {
+		prop.Maximum = schemaNumber(bounds.ExclusiveMaximum)
 		prop.ExclusiveMaximum = schema.Bool{Val: true, Default: false, Initialized: true}
 	}
-	if pd.ExclusiveMinimum != nil {
-		prop.Minimum = schema.Number{Val: float64(*pd.ExclusiveMinimum), Initialized: true}
+	if bounds.ExclusiveMinimum != nil {
+		prop.Minimum = schemaNumber(bounds.ExclusiveMinimum)
 		prop.ExclusiveMinimum = schema.Bool{Val: true, Default: false, Initialized: true}
 	}
 }
 
+// schemaNumber - the schema number of a bound of a parameter. The schema
+// compares float64s, integers larger than 2^53 are rounded.
+func schemaNumber(n *Number) schema.Number {
+	f, err := n.Float64()
+	if err != nil {
+		log.Warningf("ignoring the bound %q that is not a number", n.String())
+		return schema.Number{}
+	}
+	return schema.Number{Val: f, Initialized: true}
+}
+
 func setEnum(pd ParameterDescriptor, prop *schema.Schema) {
 	if len(pd.Enum) > 0 {
 		prop.Enum = make([]interface{}, len(pd.Enum))
 		for i, v := range pd.Enum {
-			prop.Enum[i] = v
+			prop.Enum[i] = enumValue(v, prop.Type[0])
 		}
 	}
 }
 
+// enumValue - the enum values are always strings in the apb.yml, they are
+// converted to the type of the parameter so they validate against it.
+func enumValue(v string, t schema.PrimitiveType) interface{} {
+	switch t {
+	case schema.IntegerType:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	case schema.NumberType:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case schema.BooleanType:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// schemaDefault - the default of the parameter converted to its type, see
+// CoerceDefault. A default that is not one of the enum values is dropped as
+// clients reject such a schema.
+func schemaDefault(pd ParameterDescriptor) interface{} {
+	if pd.Default == nil {
+		return nil
+	}
+	def, err := pd.TypedDefault()
+	if err != nil {
+		log.Warningf("keeping the default of parameter %s as is, it is not a valid %s", pd.Name, pd.Type)
+		def = pd.Default
+	}
+	if len(pd.Enum) == 0 {
+		return def
+	}
+	for _, v := range pd.Enum {
+		if v == fmt.Sprint(def) {
+			return def
+		}
+	}
+	if pd.DisplayType == PasswordDisplayType {
+		log.Warningf("dropping the default of parameter %s, it is not one of the allowed values", pd.Name)
+	} else {
+		log.Warningf("dropping default %v of parameter %s, it is not one of %v", def, pd.Name, pd.Enum)
+	}
+	return nil
+}
+
 func extractRequired(params []ParameterDescriptor) []string {
 	req := make([]string, 0, len(params))
 	for _, param := range params {
@@ -361,22 +432,12 @@ func extractRequired(params []ParameterDescriptor) []string {
 func extractUpdatable(params []ParameterDescriptor) (map[string]*schema.Schema, error) {
 	upd := make(map[string]*schema.Schema)
 	for _, v := range params {
-		t, err := getType(v.Type)
+		prop, err := ParameterToSchema(v)
 		if err != nil {
 			return upd, err
 		}
 		if v.Updatable {
-			k := v.Name
-			upd[k] = &schema.Schema{
-				Title:       v.Title,
-				Description: v.Description,
-				Default:     v.Default,
-				Type:        t,
-			}
-
-			setStringValidators(v, upd[k])
-			setNumberValidators(v, upd[k])
-			setEnum(v, upd[k])
+			upd[v.Name] = prop
 		}
 	}
 	return upd, nil
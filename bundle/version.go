@@ -17,11 +17,15 @@
 package bundle
 
 import (
+	"fmt"
+	"strings"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/coreos/go-semver/semver"
-	log "github.com/sirupsen/logrus"
 )
 
-// These constants describe the minimum and maximum
+// These constants describe the default minimum and maximum
 // accepted APB spec versions. They are used to filter
 // acceptable APBs.
 
@@ -31,7 +35,7 @@ const MinSpecVersion = "1.0.0"
 // MaxSpecVersion constant to describe maximum supported spec version
 const MaxSpecVersion = "1.0.0"
 
-// These constants describe the minimum and maximum
+// These constants describe the default minimum and maximum
 // accepted APB runtime versions. They are used to filter
 // acceptable APBs.
 
@@ -39,41 +43,138 @@ const MaxSpecVersion = "1.0.0"
 const MinRuntimeVersion = 1
 
 // MaxRuntimeVersion constant to describe maximum supported runtime version
-const MaxRuntimeVersion = 2
+const MaxRuntimeVersion = 3
 
-// The minimum/maximum Bundle spec semantic versions
-var minSpecSemver = semver.New("1.0.0")
-var maxSpecSemver = semver.New("1.0.0")
+// VersionPolicy - The spec and runtime versions that are accepted. Spec
+// versions are semantic versions, a two part version like 1.0 is read as
+// 1.0.0.
+type VersionPolicy struct {
+	MinSpecVersion    string `yaml:"min_spec_version"`
+	MaxSpecVersion    string `yaml:"max_spec_version"`
+	MinRuntimeVersion int    `yaml:"min_runtime_version"`
+	MaxRuntimeVersion int    `yaml:"max_runtime_version"`
+}
 
-// ValidateVersion - Ensure the Bundle Spec Version and Bundle Runtime Version
-// are within bounds
-func (s *Spec) ValidateVersion() bool {
-	return s.checkVersion() && s.checkRuntime()
+// DefaultVersionPolicy - the versions accepted when no policy is set.
+var DefaultVersionPolicy = VersionPolicy{
+	MinSpecVersion:    MinSpecVersion,
+	MaxSpecVersion:    MaxSpecVersion,
+	MinRuntimeVersion: MinRuntimeVersion,
+	MaxRuntimeVersion: MaxRuntimeVersion,
+}
+
+// versionPolicy - the policy ValidateVersion checks specs against.
+var versionPolicy = DefaultVersionPolicy
+
+// VersionRejection - Why a spec was rejected by the VersionPolicy.
+type VersionRejection string
+
+const (
+	// VersionAccepted - the spec is accepted.
+	VersionAccepted VersionRejection = ""
+	// RejectedSpecVersionInvalid - the spec version is not a version.
+	RejectedSpecVersionInvalid VersionRejection = "SpecVersionInvalid"
+	// RejectedSpecVersionTooOld - the spec version is below the minimum.
+	RejectedSpecVersionTooOld VersionRejection = "SpecVersionTooOld"
+	// RejectedSpecVersionTooNew - the spec version is above the maximum.
+	RejectedSpecVersionTooNew VersionRejection = "SpecVersionTooNew"
+	// RejectedRuntimeTooOld - the runtime version is below the minimum.
+	RejectedRuntimeTooOld VersionRejection = "RuntimeVersionTooOld"
+	// RejectedRuntimeTooNew - the runtime version is above the maximum.
+	RejectedRuntimeTooNew VersionRejection = "RuntimeVersionTooNew"
+)
+
+// InitializeVersionPolicy - sets the versions accepted by ValidateVersion.
+// Empty fields keep their default.
+func InitializeVersionPolicy(policy VersionPolicy) error {
+	policy = policy.WithDefaults()
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	versionPolicy = policy
+	return nil
 }
 
-func (s *Spec) checkVersion() bool {
-	specSemver, err := semver.NewVersion(s.Version)
+// WithDefaults - the policy with the empty fields set to their default.
+func (p VersionPolicy) WithDefaults() VersionPolicy {
+	if p.MinSpecVersion == "" {
+		p.MinSpecVersion = DefaultVersionPolicy.MinSpecVersion
+	}
+	if p.MaxSpecVersion == "" {
+		p.MaxSpecVersion = DefaultVersionPolicy.MaxSpecVersion
+	}
+	if p.MinRuntimeVersion == 0 {
+		p.MinRuntimeVersion = DefaultVersionPolicy.MinRuntimeVersion
+	}
+	if p.MaxRuntimeVersion == 0 {
+		p.MaxRuntimeVersion = DefaultVersionPolicy.MaxRuntimeVersion
+	}
+	return p
+}
+
+// Validate - checks that the versions of the policy parse and that the
+// minimums are not above the maximums.
+func (p VersionPolicy) Validate() error {
+	min, err := parseSpecVersion(p.MinSpecVersion)
+	if err != nil {
+		return liberrors.New(liberrors.CodeValidation, fmt.Sprintf("invalid min spec version %v", p.MinSpecVersion))
+	}
+	max, err := parseSpecVersion(p.MaxSpecVersion)
 	if err != nil {
-		if s.Version == "1.0" {
-			log.Debugf("Spec [%v] version (%v) not semver compatible", s.FQName, s.Version)
-			return true
-		}
-		return false
+		return liberrors.New(liberrors.CodeValidation, fmt.Sprintf("invalid max spec version %v", p.MaxSpecVersion))
 	}
+	if max.LessThan(*min) {
+		return liberrors.New(liberrors.CodeValidation, "the max spec version is lower than the min spec version")
+	}
+	if p.MinRuntimeVersion < 1 || p.MaxRuntimeVersion < p.MinRuntimeVersion {
+		return liberrors.New(liberrors.CodeValidation, "the runtime versions must be positive and the max not lower than the min")
+	}
+	return nil
+}
 
-	if specSemver.Compare(*minSpecSemver) < 0 {
-		log.Errorf("Spec version (%v) is less than the minimum version %v", s.Version, MinSpecVersion)
-		return false
+// Check - the reason the spec is rejected, VersionAccepted when it is not.
+func (p VersionPolicy) Check(s *Spec) VersionRejection {
+	specSemver, err := parseSpecVersion(s.Version)
+	if err != nil {
+		log.Debugf("Spec [%v] version (%v) is not a version - %v", s.FQName, s.Version, err)
+		return RejectedSpecVersionInvalid
 	}
+	// the policy is validated when it is set
+	min, _ := parseSpecVersion(p.MinSpecVersion)
+	max, _ := parseSpecVersion(p.MaxSpecVersion)
 
-	if specSemver.Compare(*maxSpecSemver) > 0 {
-		log.Errorf("Spec version (%v) is greater than the maximum version %v", s.Version, MaxSpecVersion)
-		return false
+	switch {
+	case specSemver.LessThan(*min):
+		log.Errorf("Spec version (%v) is less than the minimum version %v", s.Version, p.MinSpecVersion)
+		return RejectedSpecVersionTooOld
+	case max.LessThan(*specSemver):
+		log.Errorf("Spec version (%v) is greater than the maximum version %v", s.Version, p.MaxSpecVersion)
+		return RejectedSpecVersionTooNew
+	case s.Runtime < p.MinRuntimeVersion:
+		return RejectedRuntimeTooOld
+	case s.Runtime > p.MaxRuntimeVersion:
+		return RejectedRuntimeTooNew
 	}
+	return VersionAccepted
+}
+
+// parseSpecVersion - parses the semantic version, a two part version like
+// 1.0 is read as 1.0.0.
+func parseSpecVersion(version string) (*semver.Version, error) {
+	if strings.Count(version, ".") == 1 {
+		version = version + ".0"
+	}
+	return semver.NewVersion(version)
+}
 
-	return true
+// CheckVersion - the reason the spec is rejected by the version policy,
+// VersionAccepted when it is not.
+func (s *Spec) CheckVersion() VersionRejection {
+	return versionPolicy.Check(s)
 }
 
-func (s *Spec) checkRuntime() bool {
-	return s.Runtime >= MinRuntimeVersion && s.Runtime <= MaxRuntimeVersion
+// ValidateVersion - Ensure the Bundle Spec Version and Bundle Runtime Version
+// are within bounds
+func (s *Spec) ValidateVersion() bool {
+	return s.CheckVersion() == VersionAccepted
 }
@@ -55,3 +55,78 @@ func TestValidateVersion(t *testing.T) {
 	testSpec.Runtime = 3
 	ft.False(t, testSpec.ValidateVersion()) // greater than max
 }
+
+func TestCheckVersion(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   VersionPolicy
+		spec     Spec
+		expected VersionRejection
+	}{
+		{
+			name:     "accepted",
+			policy:   DefaultVersionPolicy,
+			spec:     Spec{Version: "1.0", Runtime: 2},
+			expected: VersionAccepted,
+		},
+		{
+			name:     "invalid spec version",
+			policy:   DefaultVersionPolicy,
+			spec:     Spec{Version: "one", Runtime: 2},
+			expected: RejectedSpecVersionInvalid,
+		},
+		{
+			name:     "spec version too old",
+			policy:   DefaultVersionPolicy,
+			spec:     Spec{Version: "0.9.0", Runtime: 2},
+			expected: RejectedSpecVersionTooOld,
+		},
+		{
+			name:     "spec version too new",
+			policy:   DefaultVersionPolicy,
+			spec:     Spec{Version: "1.1", Runtime: 2},
+			expected: RejectedSpecVersionTooNew,
+		},
+		{
+			name:     "runtime too old",
+			policy:   VersionPolicy{MinRuntimeVersion: 2}.WithDefaults(),
+			spec:     Spec{Version: "1.0", Runtime: 1},
+			expected: RejectedRuntimeTooOld,
+		},
+		{
+			name:     "runtime too new",
+			policy:   DefaultVersionPolicy,
+			spec:     Spec{Version: "1.0", Runtime: 4},
+			expected: RejectedRuntimeTooNew,
+		},
+		{
+			name:     "newer spec version allowed",
+			policy:   VersionPolicy{MaxSpecVersion: "1.1.0", MaxRuntimeVersion: 4}.WithDefaults(),
+			spec:     Spec{Version: "1.1", Runtime: 4},
+			expected: VersionAccepted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ft.Equal(t, tc.expected, tc.policy.Check(&tc.spec))
+		})
+	}
+}
+
+func TestInitializeVersionPolicy(t *testing.T) {
+	defer InitializeVersionPolicy(DefaultVersionPolicy)
+
+	spec := Spec{Version: "1.1.0", Runtime: 2}
+	ft.False(t, spec.ValidateVersion())
+	if err := InitializeVersionPolicy(VersionPolicy{MaxSpecVersion: "1.1"}); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	ft.True(t, spec.ValidateVersion())
+
+	ft.Error(t, InitializeVersionPolicy(VersionPolicy{MinSpecVersion: "2.0.0"}))
+	ft.Error(t, InitializeVersionPolicy(VersionPolicy{MaxSpecVersion: "latest"}))
+	ft.Error(t, InitializeVersionPolicy(VersionPolicy{MinRuntimeVersion: 3}))
+	// a rejected policy leaves the current one in place
+	ft.True(t, spec.ValidateVersion())
+}
@@ -0,0 +1,109 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"fmt"
+	"time"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	"github.com/automationbroker/bundle-lib/runtime"
+)
+
+// WatchTimeoutsKey - spec metadata key holding the watch timeouts of the
+// actions of the bundle, keyed by action or
+// runtime.DefaultWatchTimeoutAction, e.g.
+//
+//	watchTimeouts:
+//	  provision: {timeout: 45m, stallTimeout: 10m}
+//	  default: {timeout: 10m}
+//
+// They override the WatchTimeouts of the runtime configuration.
+const WatchTimeoutsKey = "watchTimeouts"
+
+// WatchTimeout - returns the watch timeout of the action declared in the
+// spec metadata, the timeouts of the action override the default ones.
+func (s *Spec) WatchTimeout(action string) (runtime.WatchTimeout, error) {
+	timeouts := runtime.WatchTimeout{}
+	value, ok := s.Metadata[WatchTimeoutsKey]
+	if !ok {
+		return timeouts, nil
+	}
+	actions, ok := metadataMap(value)
+	if !ok {
+		return timeouts, liberrors.Newf(liberrors.CodeValidation,
+			"spec %v has an invalid %v value %v", s.FQName, WatchTimeoutsKey, value)
+	}
+	for _, key := range []string{runtime.DefaultWatchTimeoutAction, action} {
+		t, err := parseWatchTimeout(actions[key])
+		if err != nil {
+			return runtime.WatchTimeout{}, liberrors.Newf(liberrors.CodeValidation,
+				"spec %v has an invalid %v value for %v - %v", s.FQName, WatchTimeoutsKey, key, err)
+		}
+		if t.Timeout != 0 {
+			timeouts.Timeout = t.Timeout
+		}
+		if t.StallTimeout != 0 {
+			timeouts.StallTimeout = t.StallTimeout
+		}
+	}
+	return timeouts, nil
+}
+
+// parseWatchTimeout - parses the timeout and stallTimeout durations of the
+// value, nil is no timeout.
+func parseWatchTimeout(value interface{}) (runtime.WatchTimeout, error) {
+	t := runtime.WatchTimeout{}
+	if value == nil {
+		return t, nil
+	}
+	fields, ok := metadataMap(value)
+	if !ok {
+		return t, fmt.Errorf("%v is not a map", value)
+	}
+	for key, d := range map[string]*time.Duration{
+		"timeout":      &t.Timeout,
+		"stallTimeout": &t.StallTimeout,
+	} {
+		v, ok := fields[key]
+		if !ok {
+			continue
+		}
+		duration, err := time.ParseDuration(fmt.Sprintf("%v", v))
+		if err != nil {
+			return t, err
+		}
+		*d = duration
+	}
+	return t, t.Validate()
+}
+
+// metadataMap - the value as a map, the metadata of specs decoded from
+// yaml holds maps with interface keys.
+func metadataMap(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, val := range v {
+			m[fmt.Sprintf("%v", k)] = val
+		}
+		return m, true
+	}
+	return nil, false
+}
@@ -0,0 +1,63 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecWatchTimeout(t *testing.T) {
+	spec := &Spec{FQName: "dh-postgresql-apb", Metadata: map[string]interface{}{
+		WatchTimeoutsKey: map[string]interface{}{
+			"default":   map[string]interface{}{"timeout": "10m", "stallTimeout": "2m"},
+			"provision": map[interface{}]interface{}{"timeout": "45m"},
+		},
+	}}
+
+	timeout, err := spec.WatchTimeout("provision")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, runtime.WatchTimeout{Timeout: 45 * time.Minute, StallTimeout: 2 * time.Minute}, timeout)
+
+	timeout, err = spec.WatchTimeout("bind")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, runtime.WatchTimeout{Timeout: 10 * time.Minute, StallTimeout: 2 * time.Minute}, timeout)
+
+	timeout, err = (&Spec{}).WatchTimeout("bind")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, runtime.WatchTimeout{}, timeout)
+
+	for _, value := range []interface{}{
+		"10m",
+		map[string]interface{}{"bind": "10m"},
+		map[string]interface{}{"bind": map[string]interface{}{"timeout": "ten minutes"}},
+		map[string]interface{}{"bind": map[string]interface{}{"stallTimeout": "-1m"}},
+	} {
+		spec := &Spec{Metadata: map[string]interface{}{WatchTimeoutsKey: value}}
+		_, err := spec.WatchTimeout("bind")
+		assert.Error(t, err, "value %v", value)
+	}
+}
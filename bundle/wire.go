@@ -0,0 +1,227 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	"github.com/ghodss/yaml"
+	"github.com/pborman/uuid"
+)
+
+// WireVersion - The version of the format written by the Marshal helpers.
+// Brokers persisting instances and bindings outside of the CRDs, e.g. in
+// etcd or SQL, should use the helpers so the stored documents do not
+// depend on the struct tags of ServiceInstance and BindInstance.
+const WireVersion = 1
+
+// ErrorUnsupportedWireVersion - The document was written with a newer
+// version of the format than this bundle-lib reads.
+type ErrorUnsupportedWireVersion struct {
+	Version int
+}
+
+func (e ErrorUnsupportedWireVersion) Error() string {
+	return fmt.Sprintf("unsupported wire format version %d, the latest supported is %d", e.Version, WireVersion)
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrorUnsupportedWireVersion) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrorUnsupportedWireVersion - true if the error is an
+// ErrorUnsupportedWireVersion.
+func IsErrorUnsupportedWireVersion(err error) bool {
+	_, ok := err.(ErrorUnsupportedWireVersion)
+	return ok
+}
+
+// wireVersion - read first to pick the format of the document.
+type wireVersion struct {
+	Version int `json:"version"`
+}
+
+// serviceInstanceV1 - Version 1 of the ServiceInstance format.
+type serviceInstanceV1 struct {
+	Version           int                `json:"version"`
+	ID                string             `json:"id"`
+	Spec              *Spec              `json:"spec,omitempty"`
+	Context           *Context           `json:"context,omitempty"`
+	Parameters        *Parameters        `json:"parameters,omitempty"`
+	BindingIDs        []string           `json:"binding_ids,omitempty"`
+	DashboardURL      string             `json:"dashboard_url,omitempty"`
+	Labels            map[string]string  `json:"labels,omitempty"`
+	Annotations       map[string]string  `json:"annotations,omitempty"`
+	RequiredInstances []RequiredInstance `json:"required_instances,omitempty"`
+}
+
+// bindInstanceV1 - Version 1 of the BindInstance format.
+type bindInstanceV1 struct {
+	Version      int               `json:"version"`
+	ID           string            `json:"id"`
+	ServiceID    string            `json:"service_id"`
+	Parameters   *Parameters       `json:"parameters,omitempty"`
+	CreateJobKey string            `json:"create_job_key,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// MarshalServiceInstance - encodes the instance as json in the current
+// wire format. The UserInfo is not persisted.
+func MarshalServiceInstance(si *ServiceInstance) ([]byte, error) {
+	w := serviceInstanceV1{
+		Version:           WireVersion,
+		ID:                si.ID.String(),
+		Spec:              si.Spec,
+		Context:           si.Context,
+		Parameters:        si.Parameters,
+		DashboardURL:      si.DashboardURL,
+		Labels:            si.Labels,
+		Annotations:       si.Annotations,
+		RequiredInstances: si.RequiredInstances,
+	}
+	for id, ok := range si.BindingIDs {
+		if ok {
+			w.BindingIDs = append(w.BindingIDs, id)
+		}
+	}
+	return json.Marshal(w)
+}
+
+// UnmarshalServiceInstance - decodes an instance written by
+// MarshalServiceInstance. Documents without a version are decoded with the
+// struct tags of ServiceInstance, as brokers stored them before.
+func UnmarshalServiceInstance(b []byte) (*ServiceInstance, error) {
+	v := wireVersion{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	switch v.Version {
+	case 0:
+		si := &ServiceInstance{}
+		if err := json.Unmarshal(b, si); err != nil {
+			return nil, err
+		}
+		return si, nil
+	case 1:
+		w := serviceInstanceV1{}
+		if err := json.Unmarshal(b, &w); err != nil {
+			return nil, err
+		}
+		si := &ServiceInstance{
+			ID:                uuid.Parse(w.ID),
+			Spec:              w.Spec,
+			Context:           w.Context,
+			Parameters:        w.Parameters,
+			BindingIDs:        map[string]bool{},
+			DashboardURL:      w.DashboardURL,
+			Labels:            w.Labels,
+			Annotations:       w.Annotations,
+			RequiredInstances: w.RequiredInstances,
+		}
+		for _, id := range w.BindingIDs {
+			si.BindingIDs[id] = true
+		}
+		return si, nil
+	}
+	return nil, ErrorUnsupportedWireVersion{Version: v.Version}
+}
+
+// MarshalBindInstance - encodes the binding as json in the current wire
+// format.
+func MarshalBindInstance(bi *BindInstance) ([]byte, error) {
+	return json.Marshal(bindInstanceV1{
+		Version:      WireVersion,
+		ID:           bi.ID.String(),
+		ServiceID:    bi.ServiceID.String(),
+		Parameters:   bi.Parameters,
+		CreateJobKey: bi.CreateJobKey,
+		Labels:       bi.Labels,
+		Annotations:  bi.Annotations,
+	})
+}
+
+// UnmarshalBindInstance - decodes a binding written by MarshalBindInstance.
+// Documents without a version are decoded with the struct tags of
+// BindInstance.
+func UnmarshalBindInstance(b []byte) (*BindInstance, error) {
+	v := wireVersion{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	switch v.Version {
+	case 0:
+		bi := &BindInstance{}
+		if err := json.Unmarshal(b, bi); err != nil {
+			return nil, err
+		}
+		return bi, nil
+	case 1:
+		w := bindInstanceV1{}
+		if err := json.Unmarshal(b, &w); err != nil {
+			return nil, err
+		}
+		return &BindInstance{
+			ID:           uuid.Parse(w.ID),
+			ServiceID:    uuid.Parse(w.ServiceID),
+			Parameters:   w.Parameters,
+			CreateJobKey: w.CreateJobKey,
+			Labels:       w.Labels,
+			Annotations:  w.Annotations,
+		}, nil
+	}
+	return nil, ErrorUnsupportedWireVersion{Version: v.Version}
+}
+
+// MarshalServiceInstanceYAML - the yaml form of MarshalServiceInstance.
+func MarshalServiceInstanceYAML(si *ServiceInstance) ([]byte, error) {
+	b, err := MarshalServiceInstance(si)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.JSONToYAML(b)
+}
+
+// UnmarshalServiceInstanceYAML - the yaml form of UnmarshalServiceInstance.
+func UnmarshalServiceInstanceYAML(b []byte) (*ServiceInstance, error) {
+	j, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalServiceInstance(j)
+}
+
+// MarshalBindInstanceYAML - the yaml form of MarshalBindInstance.
+func MarshalBindInstanceYAML(bi *BindInstance) ([]byte, error) {
+	b, err := MarshalBindInstance(bi)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.JSONToYAML(b)
+}
+
+// UnmarshalBindInstanceYAML - the yaml form of UnmarshalBindInstance.
+func UnmarshalBindInstanceYAML(b []byte) (*BindInstance, error) {
+	j, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalBindInstance(j)
+}
@@ -0,0 +1,104 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceInstanceWireFormat(t *testing.T) {
+	si := &ServiceInstance{
+		ID:                uuid.NewRandom(),
+		Spec:              &Spec{ID: "spec-id", FQName: "dh-postgresql-apb", Image: "docker.io/postgresql-apb"},
+		Context:           &Context{Platform: "kubernetes", Namespace: "ns"},
+		Parameters:        &Parameters{"size": "small"},
+		BindingIDs:        map[string]bool{"binding-id": true},
+		DashboardURL:      "https://dashboard",
+		Labels:            map[string]string{"team": "a"},
+		RequiredInstances: []RequiredInstance{{ID: uuid.New(), FQName: "db", Plan: "default"}},
+	}
+
+	b, err := MarshalServiceInstance(si)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Contains(t, string(b), `"version":1`)
+	actual, err := UnmarshalServiceInstance(b)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, si, actual)
+
+	y, err := MarshalServiceInstanceYAML(si)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	actual, err = UnmarshalServiceInstanceYAML(y)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, si, actual)
+}
+
+func TestBindInstanceWireFormat(t *testing.T) {
+	bi := &BindInstance{
+		ID:           uuid.NewRandom(),
+		ServiceID:    uuid.NewRandom(),
+		Parameters:   &Parameters{"user": "admin"},
+		CreateJobKey: "job-key",
+		Annotations:  map[string]string{"owner": "a"},
+	}
+
+	b, err := MarshalBindInstance(bi)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	actual, err := UnmarshalBindInstance(b)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, bi, actual)
+
+	y, err := MarshalBindInstanceYAML(bi)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	actual, err = UnmarshalBindInstanceYAML(y)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, bi, actual)
+}
+
+func TestUnmarshalWireVersions(t *testing.T) {
+	id := uuid.NewRandom()
+	legacy := `{"id":"` + id.String() + `","dashboard_url":"https://dashboard"}`
+	si, err := UnmarshalServiceInstance([]byte(legacy))
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.True(t, uuid.Equal(id, si.ID))
+	assert.Equal(t, "https://dashboard", si.DashboardURL)
+
+	_, err = UnmarshalServiceInstance([]byte(`{"version":2}`))
+	assert.True(t, IsErrorUnsupportedWireVersion(err))
+	_, err = UnmarshalBindInstance([]byte(`{"version":2}`))
+	assert.True(t, IsErrorUnsupportedWireVersion(err))
+}
@@ -0,0 +1,91 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundletest
+
+import (
+	"sync"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+)
+
+// Adapter - a scriptable registry adapter that serves the specs added to it.
+type Adapter struct {
+	Name string
+
+	mutex         sync.Mutex
+	specs         []*bundle.Spec
+	imageNamesErr error
+	fetchErr      error
+}
+
+// NewAdapter - creates an adapter serving the specs.
+func NewAdapter(name string, specs ...*bundle.Spec) *Adapter {
+	return &Adapter{Name: name, specs: specs}
+}
+
+// AddSpec - adds a spec to the adapter.
+func (a *Adapter) AddSpec(spec *bundle.Spec) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.specs = append(a.specs, spec)
+}
+
+// SetErrors - the errors returned by GetImageNames and FetchSpecs.
+func (a *Adapter) SetErrors(imageNamesErr, fetchErr error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.imageNamesErr = imageNamesErr
+	a.fetchErr = fetchErr
+}
+
+// RegistryName - retrieve the registry name.
+func (a *Adapter) RegistryName() string {
+	return a.Name
+}
+
+// GetImageNames - the images of the specs.
+func (a *Adapter) GetImageNames() ([]string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.imageNamesErr != nil {
+		return nil, a.imageNamesErr
+	}
+	names := []string{}
+	for _, spec := range a.specs {
+		names = append(names, spec.Image)
+	}
+	return names, nil
+}
+
+// FetchSpecs - copies of the specs of the images.
+func (a *Adapter) FetchSpecs(imageNames []string) ([]*bundle.Spec, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.fetchErr != nil {
+		return nil, a.fetchErr
+	}
+	specs := []*bundle.Spec{}
+	for _, name := range imageNames {
+		for _, spec := range a.specs {
+			if spec.Image == name {
+				s := *spec
+				specs = append(specs, &s)
+			}
+		}
+	}
+	return specs, nil
+}
@@ -0,0 +1,140 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundletest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/runtime"
+)
+
+// DefaultTimeout - how long Collect waits for an action to finish.
+var DefaultTimeout = 30 * time.Second
+
+// Harness - runs executor actions against a FakeRuntime. Creating a harness
+// replaces runtime.Provider and the bundle cluster config, Close restores
+// the provider.
+type Harness struct {
+	Runtime  *runtime.FakeRuntime
+	previous runtime.Runtime
+}
+
+// NewHarness - creates a harness with an empty FakeRuntime.
+func NewHarness() *Harness {
+	h := &Harness{
+		Runtime:  runtime.NewFakeRuntime(),
+		previous: runtime.Provider,
+	}
+	runtime.Provider = h.Runtime
+	bundle.InitializeClusterConfig(bundle.ClusterConfig{
+		PullPolicy:  "IfNotPresent",
+		SandboxRole: "edit",
+		Namespace:   "bundletest-broker",
+	})
+	bundle.InitializeSecretsCache([]bundle.AssociationRule{})
+	return h
+}
+
+// Close - restores the runtime provider.
+func (h *Harness) Close() {
+	runtime.Provider = h.previous
+}
+
+// NewExecutor - creates an executor that uses the harness runtime.
+func (h *Harness) NewExecutor(config bundle.ExecutorConfig) bundle.Executor {
+	return bundle.NewExecutor(config)
+}
+
+// Provision - provisions the instance and returns the status messages.
+func (h *Harness) Provision(instance *bundle.ServiceInstance) ([]bundle.StatusMessage, error) {
+	return Collect(h.NewExecutor(bundle.ExecutorConfig{}).Provision(instance), DefaultTimeout)
+}
+
+// Deprovision - deprovisions the instance and returns the status messages.
+func (h *Harness) Deprovision(instance *bundle.ServiceInstance) ([]bundle.StatusMessage, error) {
+	return Collect(h.NewExecutor(bundle.ExecutorConfig{}).Deprovision(instance), DefaultTimeout)
+}
+
+// Bind - binds the instance and returns the status messages.
+func (h *Harness) Bind(instance *bundle.ServiceInstance, bindingID string) ([]bundle.StatusMessage, error) {
+	return Collect(h.NewExecutor(bundle.ExecutorConfig{}).Bind(instance, &bundle.Parameters{}, bindingID), DefaultTimeout)
+}
+
+// Unbind - unbinds the instance and returns the status messages.
+func (h *Harness) Unbind(instance *bundle.ServiceInstance, bindingID string) ([]bundle.StatusMessage, error) {
+	return Collect(h.NewExecutor(bundle.ExecutorConfig{}).Unbind(instance, &bundle.Parameters{}, bindingID), DefaultTimeout)
+}
+
+// Update - updates the instance and returns the status messages.
+func (h *Harness) Update(instance *bundle.ServiceInstance) ([]bundle.StatusMessage, error) {
+	return Collect(h.NewExecutor(bundle.ExecutorConfig{}).Update(instance), DefaultTimeout)
+}
+
+// Collect - reads the status messages until the channel is closed.
+func Collect(ch <-chan bundle.StatusMessage, timeout time.Duration) ([]bundle.StatusMessage, error) {
+	messages := []bundle.StatusMessage{}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case m, ok := <-ch:
+			if !ok {
+				return messages, nil
+			}
+			messages = append(messages, m)
+		case <-timer.C:
+			return messages, fmt.Errorf("action did not finish within %v", timeout)
+		}
+	}
+}
+
+// States - the states of the status messages with consecutive duplicates,
+// caused by description updates, removed.
+func States(messages []bundle.StatusMessage) []bundle.State {
+	states := []bundle.State{}
+	for _, m := range messages {
+		if len(states) == 0 || states[len(states)-1] != m.State {
+			states = append(states, m.State)
+		}
+	}
+	return states
+}
+
+// AssertStates - fails the test if the states of the messages are not the
+// expected states.
+func AssertStates(t testing.TB, messages []bundle.StatusMessage, expected ...bundle.State) {
+	t.Helper()
+	if states := States(messages); !reflect.DeepEqual(states, expected) {
+		t.Fatalf("invalid states\nexpected: %v\nactual: %v", expected, states)
+	}
+}
+
+// AssertSucceeded - fails the test if the action did not succeed.
+func AssertSucceeded(t testing.TB, messages []bundle.StatusMessage) {
+	t.Helper()
+	AssertStates(t, messages, bundle.StateInProgress, bundle.StateSucceeded)
+}
+
+// AssertFailed - fails the test if the action did not fail.
+func AssertFailed(t testing.TB, messages []bundle.StatusMessage) {
+	t.Helper()
+	AssertStates(t, messages, bundle.StateInProgress, bundle.StateFailed)
+}
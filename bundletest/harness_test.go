@@ -0,0 +1,97 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bundletest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/runtime"
+)
+
+func TestHarnessProvision(t *testing.T) {
+	testCases := []struct {
+		name      string
+		result    runtime.BundleResult
+		shouldErr bool
+	}{
+		{
+			name:   "provision succeeds",
+			result: runtime.BundleResult{Descriptions: []string{"creating database"}, Credentials: map[string]interface{}{"user": "admin"}},
+		},
+		{
+			name:      "provision fails",
+			result:    runtime.BundleResult{Err: fmt.Errorf("exit code 1")},
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHarness()
+			defer h.Close()
+			spec := BindableSpec()
+			h.Runtime.SetBundleResult(spec.Image, "provision", tc.result)
+			instance := NewServiceInstance(spec, DefaultNamespace)
+
+			messages, err := h.Provision(instance)
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			if tc.shouldErr {
+				AssertFailed(t, messages)
+				return
+			}
+			AssertSucceeded(t, messages)
+			creds, err := h.Runtime.GetExtractedCredential(instance.ID.String(), "")
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			if creds["user"] != "admin" {
+				t.Fatalf("expected extracted credentials got: %v", creds)
+			}
+			for _, s := range h.Runtime.Sandboxes() {
+				if !s.Destroyed {
+					t.Fatalf("expected sandbox %v to be destroyed", s.Namespace)
+				}
+			}
+		})
+	}
+}
+
+func TestAdapter(t *testing.T) {
+	a := NewAdapter("bundletest", BindableSpec())
+	a.AddSpec(NonBindableSpec())
+	names, err := a.GetImageNames()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("expected 2 images got: %v", names)
+	}
+	specs, err := a.FetchSpecs(names[1:])
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if len(specs) != 1 || specs[0].FQName != NonBindableSpec().FQName {
+		t.Fatalf("unexpected specs: %v", specs)
+	}
+	a.SetErrors(fmt.Errorf("registry down"), nil)
+	if _, err := a.GetImageNames(); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package bundletest contains helpers for testing provisioning flows against
+// bundle-lib without a cluster.
+package bundletest
+
+import (
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/pborman/uuid"
+)
+
+// DefaultNamespace - the namespace the canned service instances target.
+const DefaultNamespace = "bundletest"
+
+// BindableSpec - returns a canned bindable spec with a default plan.
+func BindableSpec() *bundle.Spec {
+	return &bundle.Spec{
+		ID:          "4be4ae98-9a55-4d40-b4c7-2a5b6e4d3f11",
+		Runtime:     2,
+		Version:     "1.0.0",
+		FQName:      "bundletest-postgresql-apb",
+		Image:       "docker.io/bundletest/postgresql-apb:latest",
+		Tags:        []string{"database"},
+		Bindable:    true,
+		Description: "canned bindable bundle",
+		Async:       "optional",
+		Plans: []bundle.Plan{
+			{
+				ID:          "6ec5b0a4-9d5f-4e34-8f0a-5d8b7bc4b0a1",
+				Name:        "default",
+				Description: "default plan",
+				Free:        true,
+				Bindable:    true,
+				Parameters: []bundle.ParameterDescriptor{
+					{Name: "database", Title: "Database", Type: "string", Default: "admin"},
+				},
+			},
+		},
+	}
+}
+
+// NonBindableSpec - returns a canned spec that can not be bound.
+func NonBindableSpec() *bundle.Spec {
+	return &bundle.Spec{
+		ID:          "b8c2cf2e-2bd3-4b8f-9b0c-6f4cbd6a9e02",
+		Runtime:     2,
+		Version:     "1.0.0",
+		FQName:      "bundletest-hello-world-apb",
+		Image:       "docker.io/bundletest/hello-world-apb:latest",
+		Description: "canned non bindable bundle",
+		Async:       "optional",
+		Plans: []bundle.Plan{
+			{
+				ID:          "0c6c1c84-2a2b-43e2-9d1f-13b1d0d3c7a5",
+				Name:        "default",
+				Description: "default plan",
+				Free:        true,
+			},
+		},
+	}
+}
+
+// NewServiceInstance - returns a service instance of the spec targeting the
+// namespace.
+func NewServiceInstance(spec *bundle.Spec, namespace string) *bundle.ServiceInstance {
+	return &bundle.ServiceInstance{
+		ID:         uuid.NewRandom(),
+		Spec:       spec,
+		Context:    &bundle.Context{Platform: "kubernetes", Namespace: namespace},
+		Parameters: &bundle.Parameters{},
+		BindingIDs: map[string]bool{},
+	}
+}
@@ -29,9 +29,37 @@ var instances struct {
 	CRD        *CRD
 }
 
+// initErrors - why a client could not be created, returned by every call
+// until Close is called.
+var initErrors struct {
+	Etcd       error
+	Kubernetes error
+	Openshift  error
+	CRD        error
+}
+
 var once struct {
 	Etcd       sync.Once
 	Kubernetes sync.Once
 	Openshift  sync.Once
 	CRD        sync.Once
 }
+
+// Close - drops the shared clients so their connections are released
+// with them. The next call of Etcd, Kubernetes, Openshift or CRDClient
+// creates a new client, also after an earlier attempt failed. Must not be
+// called while the clients are in use.
+func Close() {
+	instances.Etcd = nil
+	instances.Kubernetes = nil
+	instances.Openshift = nil
+	instances.CRD = nil
+	initErrors.Etcd = nil
+	initErrors.Kubernetes = nil
+	initErrors.Openshift = nil
+	initErrors.CRD = nil
+	once.Etcd = sync.Once{}
+	once.Kubernetes = sync.Once{}
+	once.Openshift = sync.Once{}
+	once.CRD = sync.Once{}
+}
@@ -20,7 +20,7 @@ import (
 	"errors"
 
 	clientset "github.com/automationbroker/broker-client-go/client/clientset/versioned"
-	log "github.com/sirupsen/logrus"
+	log "github.com/automationbroker/bundle-lib/logging"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/util/homedir"
 )
@@ -36,10 +36,14 @@ func CRDClient() (*CRD, error) {
 		client, err := newCRDClient()
 		if err != nil {
 			log.Error(err.Error())
-			panic(err.Error())
+			initErrors.CRD = err
+			return
 		}
 		instances.CRD = client
 	})
+	if initErrors.CRD != nil {
+		return nil, initErrors.CRD
+	}
 	if instances.CRD == nil {
 		return nil, errors.New("CRDClient client instance is nil")
 	}
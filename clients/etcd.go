@@ -25,9 +25,9 @@ import (
 	"net/http"
 	"time"
 
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/coreos/etcd/pkg/transport"
 	"github.com/coreos/etcd/version"
-	log "github.com/sirupsen/logrus"
 
 	etcd "github.com/coreos/etcd/client"
 )
@@ -84,15 +84,15 @@ func Etcd() (etcd.Client, error) {
 		client, err := newEtcd()
 		if err != nil {
 			log.Error(errMsg)
-			// NOTE: Looking to leverage panic recovery to gracefully handle this
-			// with things like retries or better intelligence, but the environment
-			// is probably in a unrecoverable state as far as the broker is concerned,
-			// and demands the attention of an operator.
-			panic(err.Error())
+			initErrors.Etcd = err
+			return
 		}
 		instances.Etcd = client
 	})
 
+	if initErrors.Etcd != nil {
+		return nil, initErrors.Etcd
+	}
 	if instances.Etcd == nil {
 		return nil, errors.New("Etcd client instance is nil")
 	}
@@ -22,7 +22,8 @@ import (
 	"fmt"
 	"os"
 
-	log "github.com/sirupsen/logrus"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -40,7 +41,7 @@ const (
 
 var (
 	// ErrCredentialsNotFound - Will be the return if extracted credentials can not be found
-	ErrCredentialsNotFound = errors.New("credentials not found")
+	ErrCredentialsNotFound error = liberrors.New(liberrors.CodeNotFound, "credentials not found")
 )
 
 // KubernetesClient - Client to interact with Kubernetes API
@@ -55,10 +56,14 @@ func Kubernetes() (*KubernetesClient, error) {
 		client, err := newKubernetes()
 		if err != nil {
 			log.Error(err.Error())
-			panic(err.Error())
+			initErrors.Kubernetes = err
+			return
 		}
 		instances.Kubernetes = client
 	})
+	if initErrors.Kubernetes != nil {
+		return nil, initErrors.Kubernetes
+	}
 	if instances.Kubernetes == nil {
 		return nil, errors.New("Kubernetes client instance is nil")
 	}
@@ -181,11 +186,8 @@ func createOnce() {
 	k8s, err := newKubernetes()
 	if err != nil {
 		log.Error(errMsg)
-		// NOTE: Looking to leverage panic recovery to gracefully handle this
-		// with things like retries or better intelligence, but the environment
-		// is probably in a unrecoverable state as far as the broker is concerned,
-		// and demands the attention of an operator.
-		panic(err.Error())
+		initErrors.Kubernetes = err
+		return
 	}
 
 	instances.Kubernetes = k8s
@@ -273,6 +275,15 @@ func (k KubernetesClient) CreateRoleBinding(
 	return nil
 }
 
+// DeleteServiceAccount - Delete a service account
+func (k KubernetesClient) DeleteServiceAccount(name string, namespace string) error {
+	err := k.Client.CoreV1().ServiceAccounts(namespace).Delete(name, &metav1.DeleteOptions{})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 // DeleteRoleBinding - Delete a Role Binding
 func (k KubernetesClient) DeleteRoleBinding(roleBindingName string, namespace string) error {
 	err := k.Client.RbacV1beta1().RoleBindings(namespace).Delete(roleBindingName, &metav1.DeleteOptions{})
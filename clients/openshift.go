@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 
+	log "github.com/automationbroker/bundle-lib/logging"
 	authoapi "github.com/openshift/api/authorization/v1"
 	"github.com/openshift/api/image/v1"
 	networkoapi "github.com/openshift/api/network/v1"
@@ -27,7 +28,6 @@ import (
 	imagev1 "github.com/openshift/client-go/image/clientset/versioned/typed/image/v1"
 	networkv1 "github.com/openshift/client-go/network/clientset/versioned/typed/network/v1"
 	routev1 "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
-	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/util/homedir"
@@ -54,14 +54,14 @@ func Openshift() (*OpenshiftClient, error) {
 		client, err := newOpenshift()
 		if err != nil {
 			log.Error(errMsg)
-			// NOTE: Looking to leverage panic recovery to gracefully handle this
-			// with things like retries or better intelligence, but the environment
-			// is probably in a unrecoverable state as far as the broker is concerned,
-			// and demands the attention of an operator.
-			panic(err.Error())
+			initErrors.Openshift = err
+			return
 		}
 		instances.Openshift = client
 	})
+	if initErrors.Openshift != nil {
+		return nil, initErrors.Openshift
+	}
 	if instances.Openshift == nil {
 		return nil, errors.New("OpenShift client instance is nil")
 	}
@@ -0,0 +1,217 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package clock contains the time source used by the executor, the runtime
+// and the registries so that timestamps and timeouts can be controlled by
+// tests.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock - the source of the current time and of the timers.
+type Clock interface {
+	// Now - returns the current time.
+	Now() time.Time
+	// After - returns a channel that receives the current time once the
+	// duration has elapsed.
+	After(d time.Duration) <-chan time.Time
+	// NewTicker - returns a ticker that sends the current time every
+	// duration until it is stopped.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker - a ticker of a Clock.
+type Ticker interface {
+	// C - the channel the ticks are sent on.
+	C() <-chan time.Time
+	// Stop - turns off the ticker, no more ticks are sent.
+	Stop()
+}
+
+// Real - the clock backed by the time package.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
+
+// OrReal - returns the clock, or the Real clock when it is nil.
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+// Fake - a clock that only moves when it is advanced. The timers returned
+// by After and the tickers fire when the clock is advanced past their
+// deadline.
+type Fake struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []waiter
+	tickers []*fakeTicker
+}
+
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFake - creates a fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now - returns the time of the fake clock.
+func (f *Fake) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.now
+}
+
+// After - returns a channel that receives the time once the clock has been
+// advanced by the duration. A non positive duration fires immediately.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, waiter{deadline: f.now.Add(d), ch: ch})
+	return ch
+}
+
+// NewTicker - returns a ticker that ticks every time the clock has been
+// advanced by the duration. Like a time.Ticker, the ticks a slow receiver
+// misses are dropped. Panics on a non positive duration.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for clock.Fake.NewTicker")
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	t := &fakeTicker{clock: f, interval: d, next: f.now.Add(d), ch: make(chan time.Time, 1)}
+	f.tickers = append(f.tickers, t)
+	return t
+}
+
+// Advance - moves the clock forward by the duration and fires the expired
+// timers.
+func (f *Fake) Advance(d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.set(f.now.Add(d))
+}
+
+// Set - moves the clock to the time and fires the expired timers.
+func (f *Fake) Set(t time.Time) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.set(t)
+}
+
+// Tickers - returns the number of tickers that have not been stopped.
+func (f *Fake) Tickers() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.tickers)
+}
+
+// Waiters - returns the number of timers that have not fired yet.
+func (f *Fake) Waiters() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.waiters)
+}
+
+func (f *Fake) set(t time.Time) {
+	f.now = t
+	pending := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.deadline.After(t) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- t
+	}
+	f.waiters = pending
+	for _, tk := range f.tickers {
+		if tk.next.After(t) {
+			continue
+		}
+		select {
+		case tk.ch <- t:
+		default:
+		}
+		for !tk.next.After(t) {
+			tk.next = tk.next.Add(tk.interval)
+		}
+	}
+}
+
+func (f *Fake) stop(t *fakeTicker) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i, tk := range f.tickers {
+		if tk == t {
+			f.tickers = append(f.tickers[:i], f.tickers[i+1:]...)
+			return
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock    *Fake
+	interval time.Duration
+	next     time.Time
+	ch       chan time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.stop(t)
+}
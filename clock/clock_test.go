@@ -0,0 +1,145 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReal(t *testing.T) {
+	before := time.Now()
+	now := Real.Now()
+	assert.False(t, now.Before(before))
+	select {
+	case <-Real.After(0):
+	case <-time.After(time.Second):
+		t.Fatalf("expected the timer to fire")
+	}
+	assert.Equal(t, Real, OrReal(nil))
+
+	ticker := Real.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	select {
+	case <-ticker.C():
+	case <-time.After(time.Second):
+		t.Fatalf("expected the ticker to tick")
+	}
+}
+
+func TestFake(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+	assert.Equal(t, start, f.Now())
+	assert.Equal(t, f, OrReal(f))
+
+	ch := f.After(time.Minute)
+	assert.Equal(t, 1, f.Waiters())
+
+	f.Advance(30 * time.Second)
+	select {
+	case <-ch:
+		t.Fatalf("timer fired before its deadline")
+	default:
+	}
+	assert.Equal(t, start.Add(30*time.Second), f.Now())
+
+	f.Advance(30 * time.Second)
+	select {
+	case fired := <-ch:
+		assert.Equal(t, start.Add(time.Minute), fired)
+	default:
+		t.Fatalf("expected the timer to fire")
+	}
+	assert.Equal(t, 0, f.Waiters())
+
+	select {
+	case <-f.After(0):
+	default:
+		t.Fatalf("expected an immediate timer to fire")
+	}
+
+	ch = f.After(time.Hour)
+	f.Set(start.Add(2 * time.Hour))
+	select {
+	case <-ch:
+	default:
+		t.Fatalf("expected the timer to fire")
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+	ticker := f.NewTicker(time.Minute)
+	assert.Equal(t, 1, f.Tickers())
+
+	f.Advance(30 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatalf("ticker ticked before its interval")
+	default:
+	}
+
+	f.Advance(30 * time.Second)
+	select {
+	case tick := <-ticker.C():
+		assert.Equal(t, start.Add(time.Minute), tick)
+	default:
+		t.Fatalf("expected the ticker to tick")
+	}
+
+	// the ticks missed by a slow receiver are dropped
+	f.Advance(time.Minute)
+	f.Advance(time.Minute)
+	select {
+	case tick := <-ticker.C():
+		assert.Equal(t, start.Add(2*time.Minute), tick)
+	default:
+		t.Fatalf("expected the ticker to tick")
+	}
+	select {
+	case <-ticker.C():
+		t.Fatalf("expected the missed tick to be dropped")
+	default:
+	}
+
+	// a jump past several intervals ticks once
+	f.Advance(5 * time.Minute)
+	select {
+	case <-ticker.C():
+	default:
+		t.Fatalf("expected the ticker to tick")
+	}
+	f.Advance(30 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatalf("ticker ticked before its interval")
+	default:
+	}
+
+	ticker.Stop()
+	assert.Equal(t, 0, f.Tickers())
+	f.Advance(time.Hour)
+	select {
+	case <-ticker.C():
+		t.Fatalf("stopped ticker ticked")
+	default:
+	}
+}
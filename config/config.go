@@ -0,0 +1,554 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	"github.com/automationbroker/bundle-lib/registries"
+	"github.com/automationbroker/bundle-lib/runtime"
+	yaml "gopkg.in/yaml.v2"
+	apicorev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// registryTypes - the registry types that registries.NewRegistry knows how
+// to create an adapter for.
+var registryTypes = map[string]bool{
+	"rhcc":            true,
+	"dockerhub":       true,
+	"mock":            true,
+	"local_openshift": true,
+	"helm":            true,
+	"openshift":       true,
+	"partner_rhcc":    true,
+	"apiv2":           true,
+	"quay":            true,
+	"galaxy":          true,
+	"registry_proxy":  true,
+	"olm":             true,
+}
+
+var pullPolicies = map[string]bool{
+	"always":       true,
+	"ifnotpresent": true,
+	"never":        true,
+}
+
+// restartPolicies - the restart policies supported for bundle pods.
+var restartPolicies = map[string]bool{
+	"":          true,
+	"Never":     true,
+	"OnFailure": true,
+}
+
+// dnsPolicies - the DNS policies supported for bundle pods.
+var dnsPolicies = map[string]bool{
+	"":                        true,
+	"ClusterFirst":            true,
+	"ClusterFirstWithHostNet": true,
+	"Default":                 true,
+	"None":                    true,
+}
+
+// Config - The bundle-lib configuration that is loaded from a single yaml
+// document.
+type Config struct {
+	Registries []registries.Config    `yaml:"registry"`
+	Cluster    bundle.ClusterConfig   `yaml:"cluster"`
+	Secrets    []bundle.SecretsConfig `yaml:"secrets"`
+	Runtime    RuntimeConfig          `yaml:"runtime"`
+	Executor   ExecutorConfig         `yaml:"executor"`
+	// Versions - the spec and runtime versions of the bundles that are
+	// accepted, the bundle defaults are used for the fields not set.
+	Versions bundle.VersionPolicy `yaml:"versions"`
+}
+
+// RuntimeConfig - The part of the runtime configuration that can be set
+// from yaml.
+type RuntimeConfig struct {
+	StateMountLocation        string                `yaml:"state_mount_location"`
+	StateMasterNamespace      string                `yaml:"state_master_namespace"`
+	RestartPolicy             string                `yaml:"restart_policy"`
+	MaxRestarts               int                   `yaml:"max_restarts"`
+	ImagePullFailureThreshold int                   `yaml:"image_pull_failure_threshold"`
+	CredentialRetry           CredentialRetryConfig `yaml:"credential_retry"`
+	InjectClusterInfo         bool                  `yaml:"inject_cluster_info"`
+	IngressDomain             string                `yaml:"ingress_domain"`
+	TargetNamespaces          TargetNamespaceConfig `yaml:"target_namespaces"`
+	Heartbeat                 HeartbeatConfig       `yaml:"heartbeat"`
+	PriorityClassName         string                `yaml:"priority_class_name"`
+	ServiceMesh               ServiceMeshConfig     `yaml:"service_mesh"`
+	DNSPolicy                 string                `yaml:"dns_policy"`
+	DNSConfig                 *DNSConfig            `yaml:"dns_config"`
+	HostAliases               []HostAliasConfig     `yaml:"host_aliases"`
+	Preflight                 PreflightConfig       `yaml:"preflight"`
+	Sandbox                   SandboxConfig         `yaml:"sandbox"`
+	// COE - the name of a COE registered with runtime.RegisterCOE, the
+	// platform is detected when empty.
+	COE          string              `yaml:"coe"`
+	Cache        CacheConfig         `yaml:"cache"`
+	ImageMirrors []ImageMirrorConfig `yaml:"image_mirrors"`
+}
+
+// ImageMirrorConfig - The images starting with source are pulled from the
+// mirror prefix instead.
+type ImageMirrorConfig struct {
+	Source string `yaml:"source"`
+	Mirror string `yaml:"mirror"`
+}
+
+// CacheConfig - The cache mounted read-only into the bundle pods, either a
+// persistent volume claim or an image.
+type CacheConfig struct {
+	ClaimName string `yaml:"claim_name"`
+	Image     string `yaml:"image"`
+	ImagePath string `yaml:"image_path"`
+	MountPath string `yaml:"mount_path"`
+}
+
+// SandboxConfig - Where the bundle pods run, the strategy is one of
+// transient-namespace, shared-runner-namespace or in-target-namespace.
+type SandboxConfig struct {
+	Strategy        string `yaml:"strategy"`
+	RunnerNamespace string `yaml:"runner_namespace"`
+}
+
+// PreflightConfig - Whether the pre-flight checks are run before the
+// sandbox is created, and the pod security level the bundle pods need.
+type PreflightConfig struct {
+	Enabled          bool   `yaml:"enabled"`
+	PodSecurityLevel string `yaml:"pod_security_level"`
+}
+
+// DNSConfig - The DNS parameters of the bundle pods, they are merged with
+// the ones of the DNS policy.
+type DNSConfig struct {
+	Nameservers []string          `yaml:"nameservers"`
+	Searches    []string          `yaml:"searches"`
+	Options     []DNSOptionConfig `yaml:"options"`
+}
+
+// DNSOptionConfig - A resolver option, the value is optional.
+type DNSOptionConfig struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// HostAliasConfig - The hostnames added to the hosts file of the bundle
+// pods for an IP.
+type HostAliasConfig struct {
+	IP        string   `yaml:"ip"`
+	Hostnames []string `yaml:"hostnames"`
+}
+
+// ServiceMeshConfig - Whether sidecar injection is disabled for the bundle
+// pods, and the annotations used to do so.
+type ServiceMeshConfig struct {
+	DisableInjection bool              `yaml:"disable_injection"`
+	Annotations      map[string]string `yaml:"annotations"`
+}
+
+// HeartbeatConfig - How the watch of a running bundle is kept alive and
+// detected as stale, both are disabled when not set.
+type HeartbeatConfig struct {
+	Interval     time.Duration `yaml:"interval"`
+	StaleTimeout time.Duration `yaml:"stale_timeout"`
+}
+
+// TargetNamespaceConfig - Whether missing target namespaces are created, and
+// the labels, annotations and resource quota they are created with.
+type TargetNamespaceConfig struct {
+	Create      bool              `yaml:"create"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+	Quota       map[string]string `yaml:"quota"`
+}
+
+// CredentialRetryConfig - How extracting credentials is retried, the
+// runtime defaults are used when it is not set.
+type CredentialRetryConfig struct {
+	Attempts int           `yaml:"attempts"`
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// ExecutorConfig - The part of the executor configuration that can be set
+// from yaml.
+type ExecutorConfig struct {
+	SkipCreateNS bool `yaml:"skip_create_ns"`
+}
+
+// ValidationError - All the problems found while validating the config.
+type ValidationError struct {
+	Errors []string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration: %v", strings.Join(e.Errors, "; "))
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ValidationError) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsValidationError - true if the error is a ValidationError.
+func IsValidationError(err error) bool {
+	_, ok := err.(ValidationError)
+	return ok
+}
+
+// Load - reads the config from the file and validates it.
+func Load(filePath string) (*Config, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse - unmarshals the yaml document and validates it.
+func Parse(b []byte) (*Config, error) {
+	c := &Config{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal config - %v", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate - validates all the sections of the config. Every problem that is
+// found is reported in the returned ValidationError.
+func (c Config) Validate() error {
+	errs := []string{}
+	names := map[string]bool{}
+	for i, r := range c.Registries {
+		prefix := fmt.Sprintf("registry[%d]", i)
+		if r.Name != "" {
+			prefix = fmt.Sprintf("registry %v", r.Name)
+			if names[r.Name] {
+				errs = append(errs, fmt.Sprintf("%v: duplicate registry name", prefix))
+			}
+			names[r.Name] = true
+		}
+		errs = append(errs, validateRegistry(prefix, r)...)
+	}
+	if c.Cluster.PullPolicy != "" && !pullPolicies[strings.ToLower(c.Cluster.PullPolicy)] {
+		errs = append(errs, fmt.Sprintf("cluster: unknown image_pull_policy %v", c.Cluster.PullPolicy))
+	}
+	if c.Cluster.Namespace == "" {
+		errs = append(errs, "cluster: namespace is required")
+	}
+	if !restartPolicies[c.Runtime.RestartPolicy] {
+		errs = append(errs, fmt.Sprintf("runtime: unknown restart_policy %v", c.Runtime.RestartPolicy))
+	}
+	if c.Runtime.MaxRestarts < 0 {
+		errs = append(errs, "runtime: max_restarts can not be negative")
+	}
+	if c.Runtime.ImagePullFailureThreshold < 0 {
+		errs = append(errs, "runtime: image_pull_failure_threshold can not be negative")
+	}
+	if r := c.Runtime.CredentialRetry; r.Attempts < 0 || r.Interval < 0 || r.Timeout < 0 {
+		errs = append(errs, "runtime: credential_retry values can not be negative")
+	}
+	if h := c.Runtime.Heartbeat; h.Interval < 0 || h.StaleTimeout < 0 {
+		errs = append(errs, "runtime: heartbeat values can not be negative")
+	}
+	if n := c.Runtime.PriorityClassName; n != "" && len(validation.IsDNS1123Subdomain(n)) > 0 {
+		errs = append(errs, fmt.Sprintf("runtime: invalid priority_class_name %v", n))
+	}
+	errs = append(errs, c.validateDNS()...)
+	if _, err := runtime.NewSandboxStrategy(c.Runtime.Sandbox.Strategy, c.Runtime.Sandbox.RunnerNamespace); err != nil {
+		errs = append(errs, fmt.Sprintf("runtime: %v", err))
+	}
+	if err := c.cacheVolume().Validate(); err != nil {
+		errs = append(errs, fmt.Sprintf("runtime: %v", err))
+	}
+	if err := c.imageMirrors().Validate(); err != nil {
+		errs = append(errs, fmt.Sprintf("runtime: %v", err))
+	}
+	if c.Runtime.COE != "" && !isRegisteredCOE(c.Runtime.COE) {
+		errs = append(errs, fmt.Sprintf("runtime: unknown coe %v", c.Runtime.COE))
+	}
+	if l := c.Runtime.Preflight.PodSecurityLevel; l != "" && !runtime.IsPodSecurityLevel(l) {
+		errs = append(errs, fmt.Sprintf("runtime: unknown preflight pod_security_level %v", l))
+	}
+	for k := range c.Runtime.ServiceMesh.Annotations {
+		if len(validation.IsQualifiedName(k)) > 0 {
+			errs = append(errs, fmt.Sprintf("runtime: invalid service_mesh annotation %v", k))
+		}
+	}
+	for name, q := range c.Runtime.TargetNamespaces.Quota {
+		if _, err := resource.ParseQuantity(q); err != nil {
+			errs = append(errs, fmt.Sprintf("runtime: invalid target_namespaces quota %v: %v", name, q))
+		}
+	}
+	if err := c.Versions.WithDefaults().Validate(); err != nil {
+		errs = append(errs, fmt.Sprintf("versions: %v", err))
+	}
+	// With skip_create_ns the bundle runs in the target namespace, which
+	// would be deleted with the sandbox unless it is kept.
+	if c.Executor.SkipCreateNS && !c.Cluster.KeepNamespace {
+		errs = append(errs, "executor: skip_create_ns requires cluster keep_namespace")
+	}
+	for i, s := range c.Secrets {
+		if !s.Validate() {
+			errs = append(errs, fmt.Sprintf("secrets[%d]: name, apb_name and secret are required", i))
+		}
+	}
+	if len(errs) != 0 {
+		return ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+func (c Config) validateDNS() []string {
+	errs := []string{}
+	if !dnsPolicies[c.Runtime.DNSPolicy] {
+		errs = append(errs, fmt.Sprintf("runtime: unknown dns_policy %v", c.Runtime.DNSPolicy))
+	}
+	if c.Runtime.DNSPolicy == "None" && (c.Runtime.DNSConfig == nil || len(c.Runtime.DNSConfig.Nameservers) == 0) {
+		errs = append(errs, "runtime: dns_policy None requires dns_config nameservers")
+	}
+	if c.Runtime.DNSConfig != nil {
+		for _, ns := range c.Runtime.DNSConfig.Nameservers {
+			if net.ParseIP(ns) == nil {
+				errs = append(errs, fmt.Sprintf("runtime: invalid dns_config nameserver %v", ns))
+			}
+		}
+		for _, o := range c.Runtime.DNSConfig.Options {
+			if o.Name == "" {
+				errs = append(errs, "runtime: dns_config options require a name")
+			}
+		}
+	}
+	for _, h := range c.Runtime.HostAliases {
+		if net.ParseIP(h.IP) == nil {
+			errs = append(errs, fmt.Sprintf("runtime: invalid host_aliases ip %v", h.IP))
+		}
+		if len(h.Hostnames) == 0 {
+			errs = append(errs, fmt.Sprintf("runtime: host_aliases ip %v requires hostnames", h.IP))
+		}
+	}
+	return errs
+}
+
+func validateRegistry(prefix string, r registries.Config) []string {
+	errs := []string{}
+	if r.Name == "" {
+		errs = append(errs, fmt.Sprintf("%v: name is required", prefix))
+	}
+	if !registryTypes[strings.ToLower(r.Type)] {
+		errs = append(errs, fmt.Sprintf("%v: unknown type %v", prefix, r.Type))
+	}
+	switch r.AuthType {
+	case "file", "secret":
+		if r.AuthName == "" {
+			errs = append(errs, fmt.Sprintf("%v: auth_type %v requires auth_name", prefix, r.AuthType))
+		}
+	case "config":
+		if r.Type == "quay" {
+			if r.Token == "" {
+				errs = append(errs, fmt.Sprintf("%v: auth_type config requires a token for quay", prefix))
+			}
+		} else if r.User == "" || r.Pass == "" {
+			errs = append(errs, fmt.Sprintf("%v: auth_type config requires user and pass", prefix))
+		}
+	case "":
+		if r.AuthName != "" && r.Type != "quay" {
+			errs = append(errs, fmt.Sprintf("%v: auth_name requires auth_type", prefix))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("%v: unknown auth_type %v", prefix, r.AuthType))
+	}
+	switch r.Deprecated {
+	case "", registries.DeprecatedShow, registries.DeprecatedHide, registries.DeprecatedTag:
+	default:
+		errs = append(errs, fmt.Sprintf("%v: unknown deprecated option %v", prefix, r.Deprecated))
+	}
+	if err := r.ValidateSignaturePolicy(); err != nil {
+		errs = append(errs, fmt.Sprintf("%v: %v", prefix, err))
+	}
+	if r.MaxImageAgeDays < 0 {
+		errs = append(errs, fmt.Sprintf("%v: max_image_age_days must not be negative", prefix))
+	}
+	if r.MaxIdleConnsPerHost < 0 {
+		errs = append(errs, fmt.Sprintf("%v: max_idle_conns_per_host must not be negative", prefix))
+	}
+	if r.RetryAttempts < 0 || r.RetryBackoff < 0 {
+		errs = append(errs, fmt.Sprintf("%v: retry_attempts and retry_backoff must not be negative", prefix))
+	}
+	// The remaining checks are done by the registry itself.
+	if len(errs) == 0 && !r.Validate() {
+		errs = append(errs, fmt.Sprintf("%v: invalid name %v", prefix, r.Name))
+	}
+	return errs
+}
+
+// RuntimeConfiguration - the runtime configuration, hooks and functions are
+// left to their defaults.
+func (c Config) RuntimeConfiguration() runtime.Configuration {
+	return runtime.Configuration{
+		StateMountLocation:        c.Runtime.StateMountLocation,
+		StateMasterNamespace:      c.Runtime.StateMasterNamespace,
+		RestartPolicy:             apicorev1.RestartPolicy(c.Runtime.RestartPolicy),
+		MaxRestarts:               c.Runtime.MaxRestarts,
+		ImagePullFailureThreshold: c.Runtime.ImagePullFailureThreshold,
+		CredentialRetryPolicy: runtime.CredentialRetryPolicy{
+			Attempts: c.Runtime.CredentialRetry.Attempts,
+			Interval: c.Runtime.CredentialRetry.Interval,
+			Timeout:  c.Runtime.CredentialRetry.Timeout,
+		},
+		InjectClusterInfo:     c.Runtime.InjectClusterInfo,
+		IngressDomain:         c.Runtime.IngressDomain,
+		TargetNamespacePolicy: c.targetNamespacePolicy(),
+		Heartbeat: runtime.HeartbeatPolicy{
+			Interval:     c.Runtime.Heartbeat.Interval,
+			StaleTimeout: c.Runtime.Heartbeat.StaleTimeout,
+		},
+		PriorityClassName: c.Runtime.PriorityClassName,
+		ServiceMesh: runtime.ServiceMeshPolicy{
+			DisableInjection: c.Runtime.ServiceMesh.DisableInjection,
+			Annotations:      c.Runtime.ServiceMesh.Annotations,
+		},
+		DNSPolicy:   apicorev1.DNSPolicy(c.Runtime.DNSPolicy),
+		DNSConfig:   c.dnsConfig(),
+		HostAliases: c.hostAliases(),
+		Preflight: runtime.PreflightPolicy{
+			Enabled:          c.Runtime.Preflight.Enabled,
+			PodSecurityLevel: c.Runtime.Preflight.PodSecurityLevel,
+		},
+		SandboxStrategy: c.sandboxStrategy(),
+		COE:             c.Runtime.COE,
+		CacheVolume:     c.cacheVolume(),
+		ImageMirrors:    c.imageMirrors(),
+	}
+}
+
+func (c Config) imageMirrors() runtime.ImageMirrors {
+	mirrors := runtime.ImageMirrors{}
+	for _, m := range c.Runtime.ImageMirrors {
+		mirrors = append(mirrors, runtime.ImageMirror{Source: m.Source, Mirror: m.Mirror})
+	}
+	return mirrors
+}
+
+func (c Config) cacheVolume() runtime.CacheVolume {
+	return runtime.CacheVolume{
+		ClaimName: c.Runtime.Cache.ClaimName,
+		Image:     c.Runtime.Cache.Image,
+		ImagePath: c.Runtime.Cache.ImagePath,
+		MountPath: c.Runtime.Cache.MountPath,
+	}
+}
+
+// isRegisteredCOE - true if the COE is registered with the runtime.
+func isRegisteredCOE(name string) bool {
+	for _, n := range runtime.RegisteredCOEs() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sandboxStrategy - the configured strategy, nil for an invalid one which is
+// reported by Validate.
+func (c Config) sandboxStrategy() runtime.SandboxStrategy {
+	s, err := runtime.NewSandboxStrategy(c.Runtime.Sandbox.Strategy, c.Runtime.Sandbox.RunnerNamespace)
+	if err != nil {
+		return nil
+	}
+	return s
+}
+
+func (c Config) dnsConfig() *apicorev1.PodDNSConfig {
+	d := c.Runtime.DNSConfig
+	if d == nil {
+		return nil
+	}
+	config := &apicorev1.PodDNSConfig{
+		Nameservers: d.Nameservers,
+		Searches:    d.Searches,
+	}
+	for _, o := range d.Options {
+		option := apicorev1.PodDNSConfigOption{Name: o.Name}
+		if o.Value != "" {
+			value := o.Value
+			option.Value = &value
+		}
+		config.Options = append(config.Options, option)
+	}
+	return config
+}
+
+func (c Config) hostAliases() []apicorev1.HostAlias {
+	if len(c.Runtime.HostAliases) == 0 {
+		return nil
+	}
+	aliases := []apicorev1.HostAlias{}
+	for _, h := range c.Runtime.HostAliases {
+		aliases = append(aliases, apicorev1.HostAlias{IP: h.IP, Hostnames: h.Hostnames})
+	}
+	return aliases
+}
+
+func (c Config) targetNamespacePolicy() runtime.TargetNamespacePolicy {
+	t := c.Runtime.TargetNamespaces
+	policy := runtime.TargetNamespacePolicy{
+		Create:      t.Create,
+		Labels:      t.Labels,
+		Annotations: t.Annotations,
+	}
+	if len(t.Quota) == 0 {
+		return policy
+	}
+	// The quantities are checked by Validate.
+	policy.Quota = apicorev1.ResourceList{}
+	for name, q := range t.Quota {
+		if quantity, err := resource.ParseQuantity(q); err == nil {
+			policy.Quota[apicorev1.ResourceName(name)] = quantity
+		}
+	}
+	return policy
+}
+
+// ExecutorConfig - the executor configuration.
+func (c Config) ExecutorConfig() bundle.ExecutorConfig {
+	return bundle.ExecutorConfig{
+		SkipCreateNS: c.Executor.SkipCreateNS,
+	}
+}
+
+// AssociationRules - the secret association rules for the secrets cache.
+func (c Config) AssociationRules() []bundle.AssociationRule {
+	rules := []bundle.AssociationRule{}
+	for _, s := range c.Secrets {
+		rules = append(rules, bundle.AssociationRule{BundleName: s.ApbName, Secret: s.Secret})
+	}
+	return rules
+}
@@ -0,0 +1,245 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/runtime"
+	apicorev1 "k8s.io/api/core/v1"
+)
+
+const validConfig = `
+registry:
+  - type: dockerhub
+    name: dh
+    org: ansibleplaybookbundle
+    white_list:
+      - ".*-apb$"
+  - type: quay
+    name: quay
+    auth_type: config
+    token: abc
+cluster:
+  namespace: ansible-service-broker
+  sandbox_role: edit
+  image_pull_policy: IfNotPresent
+  keep_namespace: true
+secrets:
+  - name: pg
+    apb_name: dh-postgresql-apb
+    secret: pg-secret
+runtime:
+  state_mount_location: /var/state
+  state_master_namespace: ansible-service-broker
+  restart_policy: OnFailure
+  max_restarts: 2
+  image_pull_failure_threshold: 3
+  priority_class_name: bundle-low
+  service_mesh:
+    disable_injection: true
+  preflight:
+    enabled: true
+    pod_security_level: baseline
+  sandbox:
+    strategy: in-target-namespace
+  dns_config:
+    nameservers:
+      - 10.0.0.10
+    options:
+      - name: ndots
+        value: "2"
+  host_aliases:
+    - ip: 10.0.0.20
+      hostnames:
+        - git.internal
+  image_mirrors:
+    - source: docker.io/ansibleplaybookbundle
+      mirror: mirror.example.com/apbs
+  credential_retry:
+    attempts: 10
+    interval: 5s
+  target_namespaces:
+    create: true
+    labels:
+      team: a
+    quota:
+      requests.cpu: "2"
+executor:
+  skip_create_ns: true
+`
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]byte(validConfig))
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if len(c.Registries) != 2 || c.Registries[0].Org != "ansibleplaybookbundle" || c.Registries[1].Token != "abc" {
+		t.Fatalf("invalid registries: %#+v", c.Registries)
+	}
+	if c.Cluster.Namespace != "ansible-service-broker" || c.Cluster.SandboxRole != "edit" {
+		t.Fatalf("invalid cluster config: %#+v", c.Cluster)
+	}
+	if rc := c.RuntimeConfiguration(); rc.StateMountLocation != "/var/state" ||
+		rc.RestartPolicy != "OnFailure" || rc.MaxRestarts != 2 || rc.ImagePullFailureThreshold != 3 || rc.PriorityClassName != "bundle-low" ||
+		rc.CredentialRetryPolicy.Attempts != 10 || rc.CredentialRetryPolicy.Interval != 5*time.Second {
+		t.Fatalf("invalid runtime configuration: %#+v", rc)
+	}
+	if s := c.RuntimeConfiguration().SandboxStrategy; s == nil || s.Name() != runtime.SandboxInTargetNamespace {
+		t.Fatalf("invalid sandbox strategy: %#+v", s)
+	}
+	if p := c.RuntimeConfiguration().Preflight; !p.Enabled || p.PodSecurityLevel != "baseline" {
+		t.Fatalf("invalid preflight policy: %#+v", p)
+	}
+	if m := c.RuntimeConfiguration().ImageMirrors; len(m) != 1 || m[0].Mirror != "mirror.example.com/apbs" {
+		t.Fatalf("invalid image mirrors: %#+v", m)
+	}
+	if !c.RuntimeConfiguration().ServiceMesh.DisableInjection {
+		t.Fatalf("expected sidecar injection to be disabled")
+	}
+	if d := c.RuntimeConfiguration().DNSConfig; d == nil || d.Nameservers[0] != "10.0.0.10" ||
+		d.Options[0].Name != "ndots" || *d.Options[0].Value != "2" {
+		t.Fatalf("invalid dns config: %#+v", d)
+	}
+	expectedAliases := []apicorev1.HostAlias{{IP: "10.0.0.20", Hostnames: []string{"git.internal"}}}
+	if !reflect.DeepEqual(c.RuntimeConfiguration().HostAliases, expectedAliases) {
+		t.Fatalf("expected host aliases: %v got: %v", expectedAliases, c.RuntimeConfiguration().HostAliases)
+	}
+	p := c.RuntimeConfiguration().TargetNamespacePolicy
+	if cpu := p.Quota["requests.cpu"]; !p.Create || p.Labels["team"] != "a" || cpu.String() != "2" {
+		t.Fatalf("invalid target namespace policy: %#+v", p)
+	}
+	if !c.ExecutorConfig().SkipCreateNS {
+		t.Fatalf("expected skip create ns to be set")
+	}
+	expectedRules := []bundle.AssociationRule{{BundleName: "dh-postgresql-apb", Secret: "pg-secret"}}
+	if !reflect.DeepEqual(c.AssociationRules(), expectedRules) {
+		t.Fatalf("expected rules: %v got: %v", expectedRules, c.AssociationRules())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   string
+		expected []string
+	}{
+		{
+			name: "all errors are reported",
+			config: `
+registry:
+  - type: unknown
+    name: one
+  - type: dockerhub
+    name: one
+    auth_type: secret
+  - type: dockerhub
+    auth_type: config
+    user: foo
+cluster:
+  image_pull_policy: Sometimes
+secrets:
+  - name: pg
+runtime:
+  restart_policy: Always
+  max_restarts: -1
+  image_pull_failure_threshold: -1
+  priority_class_name: Bundle_Priority
+  dns_policy: None
+  preflight:
+    pod_security_level: strict
+  sandbox:
+    strategy: shared-runner-namespace
+  coe: nomad
+  image_mirrors:
+    - source: docker.io
+  cache:
+    claim_name: ansible-cache
+    image: quay.io/example/ansible-cache
+  host_aliases:
+    - ip: 10.0.0.300
+      hostnames:
+        - git.internal
+  service_mesh:
+    annotations:
+      "not valid": "false"
+  target_namespaces:
+    quota:
+      requests.cpu: lots
+versions:
+  min_spec_version: 2.0.0
+executor:
+  skip_create_ns: true
+`,
+			expected: []string{
+				"registry one: unknown type unknown",
+				"registry one: duplicate registry name",
+				"registry one: auth_type secret requires auth_name",
+				"registry[2]: name is required",
+				"registry[2]: auth_type config requires user and pass",
+				"cluster: unknown image_pull_policy Sometimes",
+				"cluster: namespace is required",
+				"runtime: unknown restart_policy Always",
+				"runtime: max_restarts can not be negative",
+				"runtime: image_pull_failure_threshold can not be negative",
+				"runtime: invalid priority_class_name Bundle_Priority",
+				"runtime: dns_policy None requires dns_config nameservers",
+				"runtime: invalid host_aliases ip 10.0.0.300",
+				"runtime: the shared-runner-namespace sandbox strategy requires a runner namespace",
+				"runtime: the cache volume is either a claim or an image",
+				"runtime: image mirror 0 needs a source and a mirror",
+				"runtime: unknown coe nomad",
+				"runtime: unknown preflight pod_security_level strict",
+				"runtime: invalid service_mesh annotation not valid",
+				"runtime: invalid target_namespaces quota requests.cpu: lots",
+				"versions: the max spec version is lower than the min spec version",
+				"executor: skip_create_ns requires cluster keep_namespace",
+				"secrets[0]: name, apb_name and secret are required",
+			},
+		},
+		{
+			name: "invalid registry name",
+			config: `
+registry:
+  - type: dockerhub
+    name: Not_Valid
+cluster:
+  namespace: ansible-service-broker
+`,
+			expected: []string{"registry Not_Valid: invalid name Not_Valid"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.config))
+			if err == nil {
+				t.Fatalf("expected a validation error")
+			}
+			verr, ok := err.(ValidationError)
+			if !ok {
+				t.Fatalf("expected a validation error got: %v", err)
+			}
+			if !reflect.DeepEqual(verr.Errors, tc.expected) {
+				t.Fatalf("invalid errors\nexpected: %v\nactual: %v", tc.expected, verr.Errors)
+			}
+		})
+	}
+}
@@ -19,16 +19,99 @@ package crd
 import (
 	"encoding/json"
 	"fmt"
-	"reflect"
 
-	log "github.com/sirupsen/logrus"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 
 	"github.com/automationbroker/broker-client-go/pkg/apis/automationbroker/v1alpha1"
 	"github.com/automationbroker/bundle-lib/bundle"
 
 	"github.com/pborman/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// localizationsKey - the key the localizations are stored under in the
+// encoded metadata and parameter default, the CRD has no field for them.
+const localizationsKey = "_localizations"
+
+// costsKey - the key the plan costs are stored under in the encoded plan
+// metadata.
+const costsKey = "_costs"
+
+// bindCredentialsKey - the key the bind credentials are stored under in the
+// encoded spec metadata.
+const bindCredentialsKey = "_bind_credentials"
+
+// credentialOnlyBindKey - the key the credential only bind flag is stored
+// under in the encoded plan metadata.
+const credentialOnlyBindKey = "_credential_only_bind"
+
+// dashboardURLTemplateKey - the key the dashboard URL template is stored
+// under in the encoded spec metadata.
+const dashboardURLTemplateKey = "_dashboard_url_template"
+
+// provenanceKey - the key the provenance is stored under in the encoded
+// spec metadata.
+const provenanceKey = "_provenance"
+
+// helmChartKey - the key the helm chart is stored under in the encoded spec
+// metadata.
+const helmChartKey = "_helm_chart"
+
+// operatorKey - the key the OLM operator is stored under in the encoded
+// spec metadata.
+const operatorKey = "_operator"
+
+// manifestsKey - the key the manifests are stored under in the encoded spec
+// metadata.
+const manifestsKey = "_manifests"
+
+// requiresKey - the key the requirements are stored under in the encoded
+// spec metadata.
+const requiresKey = "_requires"
+
+// actionsKey - the key the custom actions are stored under in the encoded
+// spec metadata.
+const actionsKey = "_actions"
+
+// categoriesKey - the key the categories are stored under in the encoded
+// spec metadata.
+const categoriesKey = "_categories"
+
+// targetsAnnotation - the annotation the additional target namespaces of
+// an instance are stored under, the CRD context has no field for them.
+const targetsAnnotation = "bundle.automationbroker.io/targets"
+
+// requiredInstancesAnnotation - the annotation the instances provisioned
+// for the requirements of an instance are stored under, with the name and
+// plan of their spec.
+const requiredInstancesAnnotation = "bundle.automationbroker.io/required-instances"
+
+// ErrorMissingField - The object to convert lacks a field the conversion
+// requires.
+type ErrorMissingField struct {
+	Object string
+	Field  string
+}
+
+func (e ErrorMissingField) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("unable to convert nil %s", e.Object)
+	}
+	return fmt.Sprintf("unable to convert %s without %s", e.Object, e.Field)
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrorMissingField) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrorMissingField - true if the error is an ErrorMissingField.
+func IsErrorMissingField(err error) bool {
+	_, ok := err.(ErrorMissingField)
+	return ok
+}
+
 type arrayErrors []error
 
 func (a arrayErrors) Error() string {
@@ -37,8 +120,21 @@ func (a arrayErrors) Error() string {
 
 // ConvertSpecToBundle will convert a bundle Spec to a Bundle CRD resource type.
 func ConvertSpecToBundle(spec *bundle.Spec) (v1alpha1.BundleSpec, error) {
+	if spec == nil {
+		return v1alpha1.BundleSpec{}, ErrorMissingField{Object: "spec"}
+	}
 	// encode the metadata as string
-	metadataBytes, err := json.Marshal(spec.Metadata)
+	metadata := withLocalizations(spec.Metadata, spec.Localizations)
+	metadata = withBindCredentials(metadata, spec.BindCredentials)
+	metadata = withEncoded(metadata, dashboardURLTemplateKey, spec.DashboardURLTemplate, spec.DashboardURLTemplate == "")
+	metadata = withEncoded(metadata, provenanceKey, spec.Provenance, spec.Provenance == nil)
+	metadata = withEncoded(metadata, helmChartKey, spec.HelmChart, spec.HelmChart == nil)
+	metadata = withEncoded(metadata, operatorKey, spec.Operator, spec.Operator == nil)
+	metadata = withEncoded(metadata, manifestsKey, spec.Manifests, spec.Manifests == nil)
+	metadata = withEncoded(metadata, requiresKey, spec.Requires, len(spec.Requires) == 0)
+	metadata = withEncoded(metadata, actionsKey, spec.Actions, len(spec.Actions) == 0)
+	metadata = withEncoded(metadata, categoriesKey, spec.Categories, len(spec.Categories) == 0)
+	metadataBytes, err := json.Marshal(metadata)
 	if err != nil {
 		log.Errorf("unable to marshal the metadata for spec to a json byte array - %v", err)
 		return v1alpha1.BundleSpec{}, err
@@ -92,6 +188,56 @@ func ConvertBundleToSpec(spec v1alpha1.BundleSpec, id string) (*bundle.Spec, err
 		log.Errorf("unable to unmarshal the metadata for spec - %v", err)
 		return &bundle.Spec{}, err
 	}
+	localizations, err := extractLocalizations(metadataMap)
+	if err != nil {
+		log.Errorf("unable to unmarshal the localizations for spec - %v", err)
+		return &bundle.Spec{}, err
+	}
+	bindCredentials, err := extractBindCredentials(metadataMap)
+	if err != nil {
+		log.Errorf("unable to unmarshal the bind credentials for spec - %v", err)
+		return &bundle.Spec{}, err
+	}
+	dashboardURLTemplate := ""
+	if err := extractEncoded(metadataMap, dashboardURLTemplateKey, &dashboardURLTemplate); err != nil {
+		log.Errorf("unable to unmarshal the dashboard url template for spec - %v", err)
+		return &bundle.Spec{}, err
+	}
+	var provenance *bundle.Provenance
+	if err := extractEncoded(metadataMap, provenanceKey, &provenance); err != nil {
+		log.Errorf("unable to unmarshal the provenance for spec - %v", err)
+		return &bundle.Spec{}, err
+	}
+	var helmChart *bundle.HelmChart
+	if err := extractEncoded(metadataMap, helmChartKey, &helmChart); err != nil {
+		log.Errorf("unable to unmarshal the helm chart for spec - %v", err)
+		return &bundle.Spec{}, err
+	}
+	var operator *bundle.OperatorBundle
+	if err := extractEncoded(metadataMap, operatorKey, &operator); err != nil {
+		log.Errorf("unable to unmarshal the operator for spec - %v", err)
+		return &bundle.Spec{}, err
+	}
+	var manifests *bundle.ManifestSource
+	if err := extractEncoded(metadataMap, manifestsKey, &manifests); err != nil {
+		log.Errorf("unable to unmarshal the manifests for spec - %v", err)
+		return &bundle.Spec{}, err
+	}
+	var requires []bundle.Requirement
+	if err := extractEncoded(metadataMap, requiresKey, &requires); err != nil {
+		log.Errorf("unable to unmarshal the requirements for spec - %v", err)
+		return &bundle.Spec{}, err
+	}
+	var actions []bundle.CustomAction
+	if err := extractEncoded(metadataMap, actionsKey, &actions); err != nil {
+		log.Errorf("unable to unmarshal the custom actions for spec - %v", err)
+		return &bundle.Spec{}, err
+	}
+	var categories []bundle.Category
+	if err := extractEncoded(metadataMap, categoriesKey, &categories); err != nil {
+		log.Errorf("unable to unmarshal the categories for spec - %v", err)
+		return &bundle.Spec{}, err
+	}
 	plans := []bundle.Plan{}
 	// encode the alpha as string
 	alphaMap := map[string]interface{}{}
@@ -115,25 +261,38 @@ func ConvertBundleToSpec(spec v1alpha1.BundleSpec, id string) (*bundle.Spec, err
 	}
 
 	return &bundle.Spec{
-		ID:          id,
-		Runtime:     spec.Runtime,
-		Version:     spec.Version,
-		FQName:      spec.FQName,
-		Image:       spec.Image,
-		Tags:        spec.Tags,
-		Bindable:    spec.Bindable,
-		Description: spec.Description,
-		Async:       convertAsyncTypeToString(spec.Async),
-		Metadata:    metadataMap,
-		Alpha:       alphaMap,
-		Plans:       plans,
-		Delete:      spec.Delete,
+		ID:                   id,
+		Runtime:              spec.Runtime,
+		Version:              spec.Version,
+		FQName:               spec.FQName,
+		Image:                spec.Image,
+		Tags:                 spec.Tags,
+		Bindable:             spec.Bindable,
+		Description:          spec.Description,
+		Async:                convertAsyncTypeToString(spec.Async),
+		Metadata:             metadataMap,
+		Alpha:                alphaMap,
+		Plans:                plans,
+		Delete:               spec.Delete,
+		Localizations:        localizations,
+		BindCredentials:      bindCredentials,
+		DashboardURLTemplate: dashboardURLTemplate,
+		Provenance:           provenance,
+		HelmChart:            helmChart,
+		Operator:             operator,
+		Manifests:            manifests,
+		Requires:             requires,
+		Actions:              actions,
+		Categories:           categories,
 	}, nil
 }
 
 // ConvertServiceInstanceToCRD will take a bundle ServiceInstance and convert
 // it to a ServiceInstanceSpec CRD type.
 func ConvertServiceInstanceToCRD(si *bundle.ServiceInstance) (v1alpha1.BundleInstance, error) {
+	if si == nil {
+		return v1alpha1.BundleInstance{}, ErrorMissingField{Object: "service instance"}
+	}
 	var b []byte
 	if si.Parameters != nil {
 		by, err := json.Marshal(si.Parameters)
@@ -143,20 +302,46 @@ func ConvertServiceInstanceToCRD(si *bundle.ServiceInstance) (v1alpha1.BundleIns
 		}
 		b = by
 	}
+	params, err := EncryptParameters(b)
+	if err != nil {
+		log.Errorf("unable to encrypt the parameters - %v", err)
+		return v1alpha1.BundleInstance{}, err
+	}
+
+	if si.Spec == nil {
+		return v1alpha1.BundleInstance{}, ErrorMissingField{Object: "service instance", Field: "spec"}
+	}
+	if si.Context == nil {
+		return v1alpha1.BundleInstance{}, ErrorMissingField{Object: "service instance", Field: "context"}
+	}
 
 	bindings := []v1alpha1.LocalObjectReference{}
 	for key := range si.BindingIDs {
 		bindings = append(bindings, v1alpha1.LocalObjectReference{Name: key})
 	}
+	annotations, err := withAnnotation(si.Annotations, targetsAnnotation, si.Context.Targets, len(si.Context.Targets) == 0)
+	if err != nil {
+		log.Errorf("unable to encode the target namespaces - %v", err)
+		return v1alpha1.BundleInstance{}, err
+	}
+	annotations, err = withAnnotation(annotations, requiredInstancesAnnotation, si.RequiredInstances, len(si.RequiredInstances) == 0)
+	if err != nil {
+		log.Errorf("unable to encode the required instances - %v", err)
+		return v1alpha1.BundleInstance{}, err
+	}
 
 	return v1alpha1.BundleInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      si.Labels,
+			Annotations: annotations,
+		},
 		Spec: v1alpha1.BundleInstanceSpec{
 			Bundle: v1alpha1.LocalObjectReference{Name: si.Spec.ID},
 			Context: v1alpha1.Context{
 				Namespace: si.Context.Namespace,
 				Platform:  si.Context.Platform,
 			},
-			Parameters:   string(b),
+			Parameters:   params,
 			DashboardURL: si.DashboardURL,
 		},
 		Status: v1alpha1.BundleInstanceStatus{
@@ -174,7 +359,12 @@ func ConvertServiceInstanceToAPB(si v1alpha1.BundleInstance, spec *bundle.Spec,
 
 	parameters := &bundle.Parameters{}
 	if si.Spec.Parameters != "" {
-		err := json.Unmarshal([]byte(si.Spec.Parameters), parameters)
+		b, err := DecryptParameters(si.Spec.Parameters)
+		if err != nil {
+			log.Errorf("unable to decrypt the parameters -%v", err)
+			return &bundle.ServiceInstance{}, err
+		}
+		err = json.Unmarshal(b, parameters)
 		if err != nil {
 			log.Errorf("unable to convert parameters to unmarshaled bundle parameters -%v", err)
 			return &bundle.ServiceInstance{}, err
@@ -185,6 +375,18 @@ func ConvertServiceInstanceToAPB(si v1alpha1.BundleInstance, spec *bundle.Spec,
 	for _, val := range si.Status.Bindings {
 		bindingIDs[val.Name] = true
 	}
+	var targets []string
+	annotations, err := extractAnnotation(si.Annotations, targetsAnnotation, &targets)
+	if err != nil {
+		log.Errorf("unable to decode the target namespaces - %v", err)
+		return &bundle.ServiceInstance{}, err
+	}
+	var required []bundle.RequiredInstance
+	annotations, err = extractAnnotation(annotations, requiredInstancesAnnotation, &required)
+	if err != nil {
+		log.Errorf("unable to decode the required instances - %v", err)
+		return &bundle.ServiceInstance{}, err
+	}
 
 	return &bundle.ServiceInstance{
 		ID:   uuid.Parse(id),
@@ -192,16 +394,23 @@ func ConvertServiceInstanceToAPB(si v1alpha1.BundleInstance, spec *bundle.Spec,
 		Context: &bundle.Context{
 			Namespace: si.Spec.Context.Namespace,
 			Platform:  si.Spec.Context.Platform,
+			Targets:   targets,
 		},
-		Parameters:   parameters,
-		BindingIDs:   bindingIDs,
-		DashboardURL: si.Spec.DashboardURL,
+		Parameters:        parameters,
+		BindingIDs:        bindingIDs,
+		DashboardURL:      si.Spec.DashboardURL,
+		Labels:            si.Labels,
+		Annotations:       annotations,
+		RequiredInstances: required,
 	}, nil
 }
 
 // ConvertServiceBindingToCRD will take a bundle BindInstance and convert it
 // to a ServiceBindingSpec CRD type.
 func ConvertServiceBindingToCRD(bi *bundle.BindInstance) (v1alpha1.BundleBinding, error) {
+	if bi == nil {
+		return v1alpha1.BundleBinding{}, ErrorMissingField{Object: "bind instance"}
+	}
 	var b []byte
 	if bi.Parameters != nil {
 		by, err := json.Marshal(bi.Parameters)
@@ -211,10 +420,19 @@ func ConvertServiceBindingToCRD(bi *bundle.BindInstance) (v1alpha1.BundleBinding
 		}
 		b = by
 	}
+	params, err := EncryptParameters(b)
+	if err != nil {
+		log.Errorf("Unable to encrypt the parameters - %v", err)
+		return v1alpha1.BundleBinding{}, err
+	}
 	return v1alpha1.BundleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      bi.Labels,
+			Annotations: bi.Annotations,
+		},
 		Spec: v1alpha1.BundleBindingSpec{
 			BundleInstance: v1alpha1.LocalObjectReference{Name: bi.ServiceID.String()},
-			Parameters:     string(b),
+			Parameters:     params,
 		},
 	}, nil
 }
@@ -226,16 +444,23 @@ func ConvertServiceBindingToAPB(bi v1alpha1.BundleBinding, id string) (*bundle.B
 	// TODO: Same as above, accept the full ServiceBinding?
 	parameters := &bundle.Parameters{}
 	if bi.Spec.Parameters != "" {
-		err := json.Unmarshal([]byte(bi.Spec.Parameters), parameters)
+		b, err := DecryptParameters(bi.Spec.Parameters)
+		if err != nil {
+			log.Errorf("Unable to decrypt the parameters - %v", err)
+			return &bundle.BindInstance{}, err
+		}
+		err = json.Unmarshal(b, parameters)
 		if err != nil {
 			log.Errorf("Unable to unmarshal parameters to bundle parameters- %v", err)
 			return &bundle.BindInstance{}, err
 		}
 	}
 	return &bundle.BindInstance{
-		ID:         uuid.Parse(id),
-		ServiceID:  uuid.Parse(bi.Spec.BundleInstance.Name),
-		Parameters: parameters,
+		ID:          uuid.Parse(id),
+		ServiceID:   uuid.Parse(bi.Spec.BundleInstance.Name),
+		Parameters:  parameters,
+		Labels:      bi.Labels,
+		Annotations: bi.Annotations,
 	}, nil
 }
 
@@ -335,7 +560,9 @@ func convertToAsyncType(s string) v1alpha1.AsyncType {
 }
 
 func convertPlanToCRD(plan bundle.Plan) (v1alpha1.Plan, error) {
-	b, err := json.Marshal(plan.Metadata)
+	m := withCosts(withLocalizations(plan.Metadata, plan.Localizations), plan.Costs)
+	m = withEncoded(m, credentialOnlyBindKey, plan.CredentialOnlyBind, !plan.CredentialOnlyBind)
+	b, err := json.Marshal(m)
 	if err != nil {
 		log.Errorf("unable to marshal the metadata for plan to a json byte array - %v", err)
 		return v1alpha1.Plan{}, err
@@ -380,31 +607,20 @@ func convertPlanToCRD(plan bundle.Plan) (v1alpha1.Plan, error) {
 }
 
 func convertParametersToCRD(param bundle.ParameterDescriptor) (v1alpha1.Parameter, error) {
-	b, err := json.Marshal(map[string]interface{}{"default": param.Default})
+	b, err := json.Marshal(withLocalizations(map[string]interface{}{"default": param.Default}, param.Localizations))
 	if err != nil {
 		log.Errorf("unable to marshal the default for parameter to a json byte array - %v", err)
 		return v1alpha1.Parameter{}, err
 	}
 
-	var v1Max *v1alpha1.NilableNumber
-	if param.Maximum != nil {
-		n := v1alpha1.NilableNumber(reflect.ValueOf(*param.Maximum).Float())
-		v1Max = &n
-	}
-	var v1exMax *v1alpha1.NilableNumber
-	if param.ExclusiveMaximum != nil {
-		n := v1alpha1.NilableNumber(reflect.ValueOf(*param.ExclusiveMaximum).Float())
-		v1exMax = &n
-	}
-	var v1Min *v1alpha1.NilableNumber
-	if param.Minimum != nil {
-		n := v1alpha1.NilableNumber(reflect.ValueOf(*param.Minimum).Float())
-		v1Min = &n
-	}
-	var v1exMin *v1alpha1.NilableNumber
-	if param.ExclusiveMinimum != nil {
-		n := v1alpha1.NilableNumber(reflect.ValueOf(*param.ExclusiveMinimum).Float())
-		v1exMin = &n
+	bounds := param.Bounds()
+	v1Max := numberToCRD(bounds.Maximum)
+	v1exMax := numberToCRD(bounds.ExclusiveMaximum)
+	v1Min := numberToCRD(bounds.Minimum)
+	v1exMin := numberToCRD(bounds.ExclusiveMinimum)
+	var multipleOf float64
+	if m := numberToCRD(bounds.MultipleOf); m != nil {
+		multipleOf = float64(*m)
 	}
 
 	return v1alpha1.Parameter{
@@ -417,7 +633,7 @@ func convertParametersToCRD(param bundle.ParameterDescriptor) (v1alpha1.Paramete
 		MaxLength:           param.MaxLength,
 		MinLength:           param.MinLength,
 		Pattern:             param.Pattern,
-		MultipleOf:          param.MultipleOf,
+		MultipleOf:          multipleOf,
 		Maximum:             v1Max,
 		ExclusiveMaximum:    v1exMax,
 		ExclusiveMinimum:    v1exMin,
@@ -450,6 +666,22 @@ func convertPlanToAPB(plan v1alpha1.Plan) (bundle.Plan, error) {
 		log.Errorf("unable to unmarshal the metadata for plan - %v", err)
 		return bundle.Plan{}, err
 	}
+	localizations, err := extractLocalizations(m)
+	if err != nil {
+		log.Errorf("unable to unmarshal the localizations for plan - %v", err)
+		return bundle.Plan{}, err
+	}
+	costs, err := extractCosts(m)
+	if err != nil {
+		log.Errorf("unable to unmarshal the costs for plan - %v", err)
+		return bundle.Plan{}, err
+	}
+	var credentialOnlyBind bool
+	err = extractEncoded(m, credentialOnlyBindKey, &credentialOnlyBind)
+	if err != nil {
+		log.Errorf("unable to unmarshal the credential only bind flag for plan - %v", err)
+		return bundle.Plan{}, err
+	}
 
 	bindParams := []bundle.ParameterDescriptor{}
 	params := []bundle.ParameterDescriptor{}
@@ -477,15 +709,18 @@ func convertPlanToAPB(plan v1alpha1.Plan) (bundle.Plan, error) {
 	}
 
 	return bundle.Plan{
-		ID:             plan.ID,
-		Name:           plan.Name,
-		Description:    plan.Description,
-		Metadata:       m,
-		Free:           plan.Free,
-		Bindable:       plan.Bindable,
-		UpdatesTo:      plan.UpdatesTo,
-		Parameters:     params,
-		BindParameters: bindParams,
+		ID:                 plan.ID,
+		Name:               plan.Name,
+		Description:        plan.Description,
+		Metadata:           m,
+		Free:               plan.Free,
+		Bindable:           plan.Bindable,
+		UpdatesTo:          plan.UpdatesTo,
+		Parameters:         params,
+		BindParameters:     bindParams,
+		Localizations:      localizations,
+		Costs:              costs,
+		CredentialOnlyBind: credentialOnlyBind,
 	}, nil
 }
 
@@ -496,31 +731,21 @@ func convertParametersToAPB(param v1alpha1.Parameter) (bundle.ParameterDescripto
 		log.Errorf("unable to unmarshal the default for parameter - %v", err)
 		return bundle.ParameterDescriptor{}, err
 	}
-
-	b := m["default"]
-
-	var v1Max *bundle.NilableNumber
-	if param.Maximum != nil {
-		n := bundle.NilableNumber(reflect.ValueOf(*param.Maximum).Float())
-		v1Max = &n
-	}
-	var v1exMax *bundle.NilableNumber
-	if param.ExclusiveMaximum != nil {
-		n := bundle.NilableNumber(reflect.ValueOf(*param.ExclusiveMaximum).Float())
-		v1exMax = &n
-	}
-	var v1Min *bundle.NilableNumber
-	if param.Minimum != nil {
-		n := bundle.NilableNumber(reflect.ValueOf(*param.Minimum).Float())
-		v1Min = &n
+	localizations, err := extractLocalizations(m)
+	if err != nil {
+		log.Errorf("unable to unmarshal the localizations for parameter - %v", err)
+		return bundle.ParameterDescriptor{}, err
 	}
-	var v1exMin *bundle.NilableNumber
-	if param.ExclusiveMinimum != nil {
-		n := bundle.NilableNumber(reflect.ValueOf(*param.ExclusiveMinimum).Float())
-		v1exMin = &n
+
+	// the json decoding turns the integers into float64, the default is
+	// converted back to the type of the parameter
+	b, err := bundle.CoerceDefault(param.Type, m["default"])
+	if err != nil {
+		log.Warningf("keeping the default of parameter %s as is, it is not a valid %s", param.Name, param.Type)
+		b = m["default"]
 	}
 
-	return bundle.ParameterDescriptor{
+	pd := bundle.ParameterDescriptor{
 		Name:                param.Name,
 		Title:               param.Title,
 		Type:                param.Type,
@@ -530,15 +755,149 @@ func convertParametersToAPB(param v1alpha1.Parameter) (bundle.ParameterDescripto
 		MaxLength:           param.MaxLength,
 		MinLength:           param.MinLength,
 		Pattern:             param.Pattern,
-		MultipleOf:          param.MultipleOf,
-		Maximum:             v1Max,
-		ExclusiveMaximum:    v1exMax,
-		ExclusiveMinimum:    v1exMin,
-		Minimum:             v1Min,
 		Enum:                param.Enum,
 		Required:            param.Required,
 		Updatable:           param.Updatable,
 		DisplayType:         param.DisplayType,
 		DisplayGroup:        param.DisplayGroup,
-	}, nil
+		Localizations:       localizations,
+	}
+	bounds := bundle.NumberBounds{
+		Maximum:          numberFromCRD(param.Maximum),
+		ExclusiveMaximum: numberFromCRD(param.ExclusiveMaximum),
+		Minimum:          numberFromCRD(param.Minimum),
+		ExclusiveMinimum: numberFromCRD(param.ExclusiveMinimum),
+	}
+	if param.MultipleOf > 0 {
+		bounds.MultipleOf = bundle.FloatNumber(param.MultipleOf)
+	}
+	pd.SetBounds(bounds)
+	return pd, nil
+}
+
+// numberToCRD - the CRD only stores float64 bounds, integers larger than
+// 2^53 are rounded.
+func numberToCRD(n *bundle.Number) *v1alpha1.NilableNumber {
+	f := bundle.NilableNumberOf(n)
+	if f == nil {
+		return nil
+	}
+	v := v1alpha1.NilableNumber(*f)
+	return &v
+}
+
+func numberFromCRD(n *v1alpha1.NilableNumber) *bundle.Number {
+	if n == nil {
+		return nil
+	}
+	return bundle.FloatNumber(float64(*n))
+}
+
+// withLocalizations - adds the localizations to the encoded map.
+func withLocalizations(m map[string]interface{}, localizations map[string]bundle.Localization) map[string]interface{} {
+	return withEncoded(m, localizationsKey, localizations, len(localizations) == 0)
+}
+
+// extractLocalizations - removes the localizations from the decoded map.
+func extractLocalizations(m map[string]interface{}) (map[string]bundle.Localization, error) {
+	var localizations map[string]bundle.Localization
+	err := extractEncoded(m, localizationsKey, &localizations)
+	return localizations, err
+}
+
+// withCosts - adds the plan costs to the encoded map.
+func withCosts(m map[string]interface{}, costs []bundle.CostEntry) map[string]interface{} {
+	return withEncoded(m, costsKey, costs, len(costs) == 0)
+}
+
+// extractCosts - removes the plan costs from the decoded map.
+func extractCosts(m map[string]interface{}) ([]bundle.CostEntry, error) {
+	var costs []bundle.CostEntry
+	err := extractEncoded(m, costsKey, &costs)
+	return costs, err
+}
+
+// withBindCredentials - adds the bind credentials to the encoded map.
+func withBindCredentials(m map[string]interface{}, bc *bundle.BindCredentials) map[string]interface{} {
+	return withEncoded(m, bindCredentialsKey, bc, bc == nil)
+}
+
+// extractBindCredentials - removes the bind credentials from the decoded
+// map.
+func extractBindCredentials(m map[string]interface{}) (*bundle.BindCredentials, error) {
+	var bc *bundle.BindCredentials
+	err := extractEncoded(m, bindCredentialsKey, &bc)
+	return bc, err
+}
+
+// withEncoded - returns a copy of the map with the value added under the
+// key, or the map itself when the value is empty. It carries the bundle
+// fields the CRD has no field for in its encoded maps.
+func withEncoded(m map[string]interface{}, key string, value interface{}, empty bool) map[string]interface{} {
+	if empty {
+		return m
+	}
+	out := make(map[string]interface{}, len(m)+1)
+	for k, v := range m {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
+
+// extractEncoded - removes the value stored under the key from the map and
+// decodes it into out. out is left untouched when the key is not present.
+func extractEncoded(m map[string]interface{}, key string, out interface{}) error {
+	raw, ok := m[key]
+	if !ok {
+		return nil
+	}
+	delete(m, key)
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// withAnnotation - returns a copy of the annotations with the value added
+// as json under the key, or the annotations themselves when the value is
+// empty. It carries the instance fields the CRD has no field for.
+func withAnnotation(annotations map[string]string, key string, value interface{}, empty bool) (map[string]string, error) {
+	if empty {
+		return annotations, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(annotations)+1)
+	for k, v := range annotations {
+		out[k] = v
+	}
+	out[key] = string(b)
+	return out, nil
+}
+
+// extractAnnotation - decodes the value stored under the key into out and
+// returns a copy of the annotations without it. The annotations are
+// returned as they are when the key is not present.
+func extractAnnotation(annotations map[string]string, key string, out interface{}) (map[string]string, error) {
+	raw, ok := annotations[key]
+	if !ok {
+		return annotations, nil
+	}
+	if err := json.Unmarshal([]byte(raw), out); err != nil {
+		return nil, err
+	}
+	rest := map[string]string{}
+	for k, v := range annotations {
+		if k != key {
+			rest[k] = v
+		}
+	}
+	if len(rest) == 0 {
+		return nil, nil
+	}
+	return rest, nil
 }
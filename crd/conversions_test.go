@@ -20,7 +20,7 @@ import (
 	"encoding/base64"
 	"testing"
 
-	yaml "gopkg.in/yaml.v1"
+	yaml "gopkg.in/yaml.v2"
 
 	"github.com/automationbroker/broker-client-go/pkg/apis/automationbroker/v1alpha1"
 	"github.com/automationbroker/bundle-lib/bundle"
@@ -273,6 +273,29 @@ func TestConvertServiceBindingToAPB(t *testing.T) {
 			},
 			expectederr: false,
 		},
+		{
+			name: "labels and annotations should get copied",
+			input: v1alpha1.BundleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        uid,
+					Namespace:   "testing",
+					Labels:      map[string]string{"owner": "team-a"},
+					Annotations: map[string]string{"cost-center": "1234"},
+				},
+				Spec: v1alpha1.BundleBindingSpec{
+					BundleInstance: v1alpha1.LocalObjectReference{
+						Name: uid,
+					},
+				},
+			},
+			expected: &bundle.BindInstance{
+				ID:          uuid.Parse(uid),
+				ServiceID:   uuid.Parse(uid),
+				Parameters:  &bundle.Parameters{},
+				Labels:      map[string]string{"owner": "team-a"},
+				Annotations: map[string]string{"cost-center": "1234"},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -335,6 +358,25 @@ func TestConvertServiceBindingToCRD(t *testing.T) {
 			expected:    v1alpha1.BundleBinding{},
 			expectederr: true,
 		},
+		{
+			name: "labels and annotations should get copied",
+			input: &bundle.BindInstance{
+				ServiceID:   uuid.Parse(uid),
+				Labels:      map[string]string{"owner": "team-a"},
+				Annotations: map[string]string{"cost-center": "1234"},
+			},
+			expected: v1alpha1.BundleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"owner": "team-a"},
+					Annotations: map[string]string{"cost-center": "1234"},
+				},
+				Spec: v1alpha1.BundleBindingSpec{
+					BundleInstance: v1alpha1.LocalObjectReference{
+						Name: uid,
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -858,14 +900,18 @@ func TestConvertBundleToSpec(t *testing.T) {
 								Description: "parameter one",
 							},
 							{
-								Name:             "param2",
-								Type:             "int",
-								Description:      "parameter two",
-								Default:          float64(10),
-								Maximum:          bundleNilableNumber(float64(20)),
-								ExclusiveMaximum: bundleNilableNumber(float64(40)),
-								Minimum:          bundleNilableNumber(float64(5)),
-								ExclusiveMinimum: bundleNilableNumber(float64(5)),
+								Name:                   "param2",
+								Type:                   "int",
+								Description:            "parameter two",
+								Default:                10,
+								Maximum:                bundleNilableNumber(float64(20)),
+								ExclusiveMaximum:       bundleNilableNumber(float64(40)),
+								Minimum:                bundleNilableNumber(float64(5)),
+								ExclusiveMinimum:       bundleNilableNumber(float64(5)),
+								MaximumNumber:          bundleNumber(float64(20)),
+								ExclusiveMaximumNumber: bundleNumber(float64(40)),
+								MinimumNumber:          bundleNumber(float64(5)),
+								ExclusiveMinimumNumber: bundleNumber(float64(5)),
 							},
 						},
 						BindParameters: []bundle.ParameterDescriptor{
@@ -878,7 +924,7 @@ func TestConvertBundleToSpec(t *testing.T) {
 								Name:        "bindparam2",
 								Type:        "int",
 								Description: "bind parameter two",
-								Default:     float64(10),
+								Default:     10,
 							},
 						},
 					},
@@ -910,19 +956,24 @@ func TestConvertServiceInstanceToCRD(t *testing.T) {
 		input       *bundle.ServiceInstance
 		expected    v1alpha1.BundleInstance
 		expectederr bool
-		panics      bool
 	}{
 		{
-			name:     "nil spec should cause error",
-			input:    &bundle.ServiceInstance{},
-			expected: v1alpha1.BundleInstance{},
-			panics:   true,
+			name:        "nil spec should cause error",
+			input:       &bundle.ServiceInstance{},
+			expected:    v1alpha1.BundleInstance{},
+			expectederr: true,
 		},
 		{
-			name:     "nil ServiceInstance should cause error",
-			input:    nil,
-			expected: v1alpha1.BundleInstance{},
-			panics:   true,
+			name:        "nil context should cause error",
+			input:       &bundle.ServiceInstance{Spec: &bundle.Spec{}},
+			expected:    v1alpha1.BundleInstance{},
+			expectederr: true,
+		},
+		{
+			name:        "nil ServiceInstance should cause error",
+			input:       nil,
+			expected:    v1alpha1.BundleInstance{},
+			expectederr: true,
 		},
 		{
 			name: "BindInstance zero value",
@@ -991,12 +1042,6 @@ func TestConvertServiceInstanceToCRD(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-
-			if tc.panics {
-				assert.Panics(t, func() { ConvertServiceInstanceToCRD(tc.input) })
-				return
-			}
-
 			output, err := ConvertServiceInstanceToCRD(tc.input)
 			if tc.expectederr {
 				assert.Error(t, err)
@@ -1014,6 +1059,10 @@ func bundleNilableNumber(i float64) *bundle.NilableNumber {
 	return &n
 }
 
+func bundleNumber(i float64) *bundle.Number {
+	return bundle.FloatNumber(i)
+}
+
 func v1alpha1NilableNumber(i float64) *v1alpha1.NilableNumber {
 	n := v1alpha1.NilableNumber(i)
 	return &n
@@ -1199,3 +1248,74 @@ func TestConvertToAsyncType(t *testing.T) {
 		})
 	}
 }
+
+func TestParameterDefaultRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name     string
+		typ      string
+		value    interface{}
+		expected interface{}
+	}{
+		{name: "int", typ: "int", value: 10, expected: 10},
+		{name: "int from string", typ: "integer", value: "10", expected: 10},
+		{name: "large int", typ: "int", value: 1 << 40, expected: 1 << 40},
+		{name: "number", typ: "number", value: 1.5, expected: 1.5},
+		{name: "bool", typ: "boolean", value: true, expected: true},
+		{name: "bool from string", typ: "bool", value: "false", expected: false},
+		{name: "string from number", typ: "string", value: 9.6, expected: "9.6"},
+		{name: "enum", typ: "enum", value: "gold", expected: "gold"},
+		{
+			name:     "object",
+			typ:      "object",
+			value:    map[string]interface{}{"size": "1Gi"},
+			expected: map[string]interface{}{"size": "1Gi"},
+		},
+		{name: "array", typ: "array", value: []interface{}{"a", "b"}, expected: []interface{}{"a", "b"}},
+		{name: "no default", typ: "int", value: nil, expected: nil},
+		{name: "invalid default is kept", typ: "int", value: "ten", expected: "ten"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			param := bundle.ParameterDescriptor{Name: "param", Type: tc.typ, Default: tc.value}
+			crdParam, err := convertParametersToCRD(param)
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			out, err := convertParametersToAPB(crdParam)
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, tc.expected, out.Default)
+		})
+	}
+}
+
+func TestServiceInstanceRoundTrip(t *testing.T) {
+	uid := uuid.New()
+	si := &bundle.ServiceInstance{
+		ID:   uuid.Parse(uid),
+		Spec: &bundle.Spec{ID: uid},
+		Context: &bundle.Context{
+			Namespace: "testnamespace",
+			Platform:  "kubernetes",
+			Targets:   []string{"frontend", "backend"},
+		},
+		Parameters:  &bundle.Parameters{"foo": "bar"},
+		BindingIDs:  map[string]bool{},
+		Annotations: map[string]string{"owner": "team"},
+		RequiredInstances: []bundle.RequiredInstance{
+			{ID: uuid.New(), FQName: "cache", Plan: "default"},
+			{ID: uuid.New(), FQName: "db", Plan: "prod"},
+		},
+	}
+	instance, err := ConvertServiceInstanceToCRD(si)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "testnamespace", instance.Spec.Context.Namespace)
+	out, err := ConvertServiceInstanceToAPB(instance, si.Spec, uid)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, si, out)
+}
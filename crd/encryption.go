@@ -0,0 +1,147 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package crd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// encryptedPrefix - marks encrypted parameters in the CRDs, parameters
+// without it are plain json.
+const encryptedPrefix = "encrypted:v1:"
+
+var (
+	cipherMutex     sync.RWMutex
+	parameterCipher ParameterCipher
+)
+
+// ParameterCipher - Encrypts the parameters of instances and bindings
+// before they are stored in the CRDs, and decrypts them when they are read.
+type ParameterCipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// SetParameterCipher - sets the cipher used for the parameters. Passing nil
+// stores the parameters in plain text, which is the default. Parameters
+// that were stored in plain text are read whether or not a cipher is set.
+func SetParameterCipher(c ParameterCipher) {
+	cipherMutex.Lock()
+	defer cipherMutex.Unlock()
+	parameterCipher = c
+}
+
+func getParameterCipher() ParameterCipher {
+	cipherMutex.RLock()
+	defer cipherMutex.RUnlock()
+	return parameterCipher
+}
+
+// CipherFuncs - A ParameterCipher calling out to functions, e.g. the
+// encrypt and decrypt calls of a KMS.
+type CipherFuncs struct {
+	EncryptFunc func([]byte) ([]byte, error)
+	DecryptFunc func([]byte) ([]byte, error)
+}
+
+// Encrypt - calls the EncryptFunc.
+func (c CipherFuncs) Encrypt(plaintext []byte) ([]byte, error) {
+	return c.EncryptFunc(plaintext)
+}
+
+// Decrypt - calls the DecryptFunc.
+func (c CipherFuncs) Decrypt(ciphertext []byte) ([]byte, error) {
+	return c.DecryptFunc(ciphertext)
+}
+
+// aesGCM - Encrypts with AES-GCM, the random nonce is stored in front of
+// the ciphertext.
+type aesGCM struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMCipher - returns a ParameterCipher using AES-GCM with the key,
+// which must be 16, 24 or 32 bytes long.
+func NewAESGCMCipher(key []byte) (ParameterCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return aesGCM{aead: aead}, nil
+}
+
+func (a aesGCM) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, a.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return a.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (a aesGCM) Decrypt(ciphertext []byte) ([]byte, error) {
+	size := a.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	return a.aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+}
+
+// EncryptParameters - encrypts the json encoded parameters when a cipher is
+// set. The storage package uses it for the parameters it persists.
+func EncryptParameters(b []byte) (string, error) {
+	c := getParameterCipher()
+	if c == nil || len(b) == 0 {
+		return string(b), nil
+	}
+	ciphertext, err := c.Encrypt(b)
+	if err != nil {
+		return "", fmt.Errorf("unable to encrypt the parameters - %v", err)
+	}
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptParameters - returns the json encoded parameters, decrypting them
+// if they were stored encrypted.
+func DecryptParameters(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, encryptedPrefix) {
+		return []byte(s), nil
+	}
+	c := getParameterCipher()
+	if c == nil {
+		return nil, fmt.Errorf("the parameters are encrypted but no cipher is set")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, encryptedPrefix))
+	if err != nil {
+		return nil, err
+	}
+	b, err := c.Decrypt(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt the parameters - %v", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,111 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package crd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameterEncryption(t *testing.T) {
+	c, err := NewAESGCMCipher([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	si := &bundle.ServiceInstance{
+		Spec:       &bundle.Spec{ID: "spec-id"},
+		Context:    &bundle.Context{Namespace: "ns", Platform: "kubernetes"},
+		Parameters: &bundle.Parameters{"password": "secret"},
+	}
+	bi := &bundle.BindInstance{
+		ServiceID:  uuid.NewRandom(),
+		Parameters: &bundle.Parameters{"token": "secret"},
+	}
+
+	// stored before a cipher was set
+	plainInstance, err := ConvertServiceInstanceToCRD(si)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	SetParameterCipher(c)
+	defer SetParameterCipher(nil)
+
+	crdInstance, err := ConvertServiceInstanceToCRD(si)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.True(t, strings.HasPrefix(crdInstance.Spec.Parameters, encryptedPrefix))
+	assert.NotContains(t, crdInstance.Spec.Parameters, "secret")
+	instance, err := ConvertServiceInstanceToAPB(crdInstance, si.Spec, uuid.New())
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, si.Parameters, instance.Parameters)
+
+	instance, err = ConvertServiceInstanceToAPB(plainInstance, si.Spec, uuid.New())
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, si.Parameters, instance.Parameters)
+
+	crdBinding, err := ConvertServiceBindingToCRD(bi)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.NotContains(t, crdBinding.Spec.Parameters, "secret")
+	binding, err := ConvertServiceBindingToAPB(crdBinding, uuid.New())
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, bi.Parameters, binding.Parameters)
+
+	SetParameterCipher(nil)
+	_, err = ConvertServiceInstanceToAPB(crdInstance, si.Spec, uuid.New())
+	assert.Error(t, err)
+}
+
+func TestCipherFuncs(t *testing.T) {
+	reverse := func(b []byte) ([]byte, error) {
+		out := make([]byte, len(b))
+		for i := range b {
+			out[len(b)-1-i] = b[i]
+		}
+		return out, nil
+	}
+	SetParameterCipher(CipherFuncs{EncryptFunc: reverse, DecryptFunc: reverse})
+	defer SetParameterCipher(nil)
+
+	s, err := EncryptParameters([]byte(`{"a":"b"}`))
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	b, err := DecryptParameters(s)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, `{"a":"b"}`, string(b))
+}
+
+func TestNewAESGCMCipherInvalidKey(t *testing.T) {
+	_, err := NewAESGCMCipher([]byte("short"))
+	assert.Error(t, err)
+}
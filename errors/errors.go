@@ -0,0 +1,202 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package errors contains the error classes shared by the bundle-lib
+// packages, so that consumers can branch on the class of an error instead of
+// matching on the message.
+package errors
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Code - the class of an error.
+type Code string
+
+const (
+	// CodeUnknown - the error does not belong to a known class.
+	CodeUnknown Code = "Unknown"
+	// CodeNotFound - the requested object does not exist.
+	CodeNotFound Code = "NotFound"
+	// CodeUnauthorized - the user or credentials are not allowed to perform
+	// the request.
+	CodeUnauthorized Code = "Unauthorized"
+	// CodeConflict - the request conflicts with the current state.
+	CodeConflict Code = "Conflict"
+	// CodeTimeout - the request did not complete in time.
+	CodeTimeout Code = "Timeout"
+	// CodeValidation - the input of the request is invalid.
+	CodeValidation Code = "Validation"
+)
+
+var (
+	// ErrNotFound - sentinel for the NotFound class.
+	ErrNotFound = New(CodeNotFound, "not found")
+	// ErrUnauthorized - sentinel for the Unauthorized class.
+	ErrUnauthorized = New(CodeUnauthorized, "unauthorized")
+	// ErrConflict - sentinel for the Conflict class.
+	ErrConflict = New(CodeConflict, "conflict")
+	// ErrTimeout - sentinel for the Timeout class.
+	ErrTimeout = New(CodeTimeout, "timeout")
+	// ErrValidation - sentinel for the Validation class.
+	ErrValidation = New(CodeValidation, "validation failed")
+)
+
+// Coder - implemented by errors that know their class. Error types defined
+// in other packages can implement it to take part in the taxonomy.
+type Coder interface {
+	ErrorCode() Code
+}
+
+// Error - an error with a class.
+type Error struct {
+	Code Code
+	msg  string
+	err  error
+}
+
+// New - creates an error of the class with the message.
+func New(code Code, msg string) *Error {
+	return &Error{Code: code, msg: msg}
+}
+
+// Newf - creates an error of the class with the formatted message.
+func Newf(code Code, format string, args ...interface{}) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Wrap - classifies the error. The message of the error is unchanged.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, err: err}
+}
+
+// Wrapf - classifies the error and prefixes the message with the formatted
+// message.
+func Wrapf(code Code, err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, msg: fmt.Sprintf(format, args...), err: err}
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.err == nil:
+		return e.msg
+	case e.msg == "":
+		return e.err.Error()
+	default:
+		return fmt.Sprintf("%v - %v", e.msg, e.err)
+	}
+}
+
+// ErrorCode - the class of the error.
+func (e *Error) ErrorCode() Code {
+	return e.Code
+}
+
+// Cause - the wrapped error, for github.com/pkg/errors.
+func (e *Error) Cause() error {
+	return e.err
+}
+
+// Unwrap - the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Is - an error is one of the sentinels if it has the same class.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t == e || (isSentinel(t) && t.Code == e.Code)
+}
+
+func isSentinel(e *Error) bool {
+	switch e {
+	case ErrNotFound, ErrUnauthorized, ErrConflict, ErrTimeout, ErrValidation:
+		return true
+	}
+	return false
+}
+
+// CodeOf - returns the class of the first error in the chain that has one.
+// Errors without a class are CodeUnknown.
+func CodeOf(err error) Code {
+	for err != nil {
+		if c, ok := err.(Coder); ok {
+			return c.ErrorCode()
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Cause() error }:
+			err = e.Cause()
+		default:
+			return CodeUnknown
+		}
+	}
+	return CodeUnknown
+}
+
+// FromHTTPStatus - creates an error for an unexpected http response status.
+func FromHTTPStatus(statusCode int, msg string) *Error {
+	code := CodeUnknown
+	switch statusCode {
+	case http.StatusNotFound:
+		code = CodeNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		code = CodeUnauthorized
+	case http.StatusConflict:
+		code = CodeConflict
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		code = CodeTimeout
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		code = CodeValidation
+	}
+	return New(code, msg)
+}
+
+// IsNotFound - true if the error is of the NotFound class.
+func IsNotFound(err error) bool {
+	return CodeOf(err) == CodeNotFound
+}
+
+// IsUnauthorized - true if the error is of the Unauthorized class.
+func IsUnauthorized(err error) bool {
+	return CodeOf(err) == CodeUnauthorized
+}
+
+// IsConflict - true if the error is of the Conflict class.
+func IsConflict(err error) bool {
+	return CodeOf(err) == CodeConflict
+}
+
+// IsTimeout - true if the error is of the Timeout class.
+func IsTimeout(err error) bool {
+	return CodeOf(err) == CodeTimeout
+}
+
+// IsValidation - true if the error is of the Validation class.
+func IsValidation(err error) bool {
+	return CodeOf(err) == CodeValidation
+}
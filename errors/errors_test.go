@@ -0,0 +1,136 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type codedError struct{}
+
+func (codedError) Error() string   { return "coded" }
+func (codedError) ErrorCode() Code { return CodeConflict }
+
+type causer struct{ err error }
+
+func (c causer) Error() string { return c.err.Error() }
+func (c causer) Cause() error  { return c.err }
+
+func TestCodeOf(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected Code
+	}{
+		{
+			name:     "nil",
+			expected: CodeUnknown,
+		},
+		{
+			name:     "plain error",
+			err:      fmt.Errorf("plain"),
+			expected: CodeUnknown,
+		},
+		{
+			name:     "new",
+			err:      New(CodeNotFound, "missing"),
+			expected: CodeNotFound,
+		},
+		{
+			name:     "wrapped",
+			err:      Wrap(CodeTimeout, fmt.Errorf("slow")),
+			expected: CodeTimeout,
+		},
+		{
+			name:     "coder",
+			err:      codedError{},
+			expected: CodeConflict,
+		},
+		{
+			name:     "cause chain",
+			err:      causer{err: New(CodeValidation, "invalid")},
+			expected: CodeValidation,
+		},
+		{
+			name:     "http status",
+			err:      FromHTTPStatus(http.StatusForbidden, "403 Forbidden"),
+			expected: CodeUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if c := CodeOf(tc.err); c != tc.expected {
+				t.Fatalf("expected code: %v got: %v", tc.expected, c)
+			}
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	cause := fmt.Errorf("connection refused")
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "new",
+			err:      Newf(CodeNotFound, "bundle %v not found", "foo"),
+			expected: "bundle foo not found",
+		},
+		{
+			name:     "wrap keeps the message",
+			err:      Wrap(CodeTimeout, cause),
+			expected: "connection refused",
+		},
+		{
+			name:     "wrapf",
+			err:      Wrapf(CodeTimeout, cause, "unable to reach %v", "registry"),
+			expected: "unable to reach registry - connection refused",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.err.Error() != tc.expected {
+				t.Fatalf("expected message: %v got: %v", tc.expected, tc.err.Error())
+			}
+		})
+	}
+	if Wrap(CodeTimeout, nil) != nil {
+		t.Fatalf("expected wrapping a nil error to return nil")
+	}
+}
+
+func TestIs(t *testing.T) {
+	err := New(CodeNotFound, "bundle not found")
+	if !err.Is(ErrNotFound) {
+		t.Fatalf("expected error to match the NotFound sentinel")
+	}
+	if err.Is(ErrConflict) {
+		t.Fatalf("expected error to not match the Conflict sentinel")
+	}
+	if err.Is(New(CodeNotFound, "other")) {
+		t.Fatalf("expected error to not match a non sentinel error")
+	}
+	if !IsNotFound(err) || IsTimeout(err) {
+		t.Fatalf("invalid class checks for: %v", err)
+	}
+}
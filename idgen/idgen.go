@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package idgen contains the source of the generated identifiers, such as
+// the bundle pod names and the correlation IDs, so that tests can produce
+// stable values.
+package idgen
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pborman/uuid"
+)
+
+// Generator - the source of the generated UUIDs.
+type Generator interface {
+	NewUUID() uuid.UUID
+}
+
+// Random - the generator of random (version 4) UUIDs.
+var Random Generator = randomGenerator{}
+
+type randomGenerator struct{}
+
+func (randomGenerator) NewUUID() uuid.UUID {
+	return uuid.NewRandom()
+}
+
+// OrRandom - returns the generator, or the Random generator when it is nil.
+func OrRandom(g Generator) Generator {
+	if g == nil {
+		return Random
+	}
+	return g
+}
+
+// Sequence - a generator returning a reproducible sequence of UUIDs derived
+// from the seed. Two sequences with the same seed return the same UUIDs.
+type Sequence struct {
+	mutex sync.Mutex
+	seed  string
+	next  int
+}
+
+// NewSequence - creates a sequence for the seed.
+func NewSequence(seed string) *Sequence {
+	return &Sequence{seed: seed}
+}
+
+// NewUUID - returns the next UUID of the sequence.
+func (s *Sequence) NewUUID() uuid.UUID {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.next++
+	return uuid.NewSHA1(uuid.NameSpace_OID, []byte(fmt.Sprintf("%s/%d", s.seed, s.next)))
+}
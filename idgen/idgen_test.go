@@ -0,0 +1,41 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package idgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandom(t *testing.T) {
+	a := Random.NewUUID()
+	b := Random.NewUUID()
+	assert.NotNil(t, a)
+	assert.NotEqual(t, a.String(), b.String())
+	assert.Equal(t, Random, OrRandom(nil))
+}
+
+func TestSequence(t *testing.T) {
+	a := NewSequence("test")
+	b := NewSequence("test")
+	first := a.NewUUID()
+	assert.Equal(t, first.String(), b.NewUUID().String())
+	assert.NotEqual(t, first.String(), a.NewUUID().String())
+	assert.NotEqual(t, first.String(), NewSequence("other").NewUUID().String())
+	assert.Equal(t, a, OrRandom(a))
+}
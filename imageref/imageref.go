@@ -0,0 +1,168 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package imageref contains the parser of the container image references
+// shared by the adapters, the registries and the runtime, so that every
+// image ends up as a fully qualified reference.
+package imageref
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+)
+
+const (
+	// DefaultRegistry - the registry of the references without one.
+	DefaultRegistry = "docker.io"
+	// DefaultTag - the tag of the references without a tag or a digest.
+	DefaultTag = "latest"
+	// officialNamespace - the namespace of the single component
+	// repositories of the default registry.
+	officialNamespace = "library"
+)
+
+var (
+	registryRegexp  = regexp.MustCompile(`^(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?$`)
+	componentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	tagRegexp       = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestRegexp    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+)
+
+// registryAliases - the other names of the default registry.
+var registryAliases = map[string]bool{
+	"index.docker.io":      true,
+	"registry-1.docker.io": true,
+}
+
+// ErrorInvalidReference - The image reference can not be parsed.
+type ErrorInvalidReference struct {
+	Reference string
+	Reason    string
+}
+
+func (e ErrorInvalidReference) Error() string {
+	return fmt.Sprintf("invalid image reference %q - %s", e.Reference, e.Reason)
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrorInvalidReference) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrorInvalidReference - true if the error is an ErrorInvalidReference.
+func IsErrorInvalidReference(err error) bool {
+	_, ok := err.(ErrorInvalidReference)
+	return ok
+}
+
+// Reference - A fully qualified image reference. Tag is empty when the
+// reference only has a Digest.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// Name - the registry and repository of the image.
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String - the reference in the form registry/repository:tag@digest.
+func (r Reference) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// Parse - parses the image reference. The registry defaults to docker.io,
+// where single component repositories are in the library namespace, and
+// the tag to latest unless the reference has a digest.
+func Parse(s string) (Reference, error) {
+	invalid := func(reason string) (Reference, error) {
+		return Reference{}, ErrorInvalidReference{Reference: s, Reason: reason}
+	}
+	if s == "" {
+		return invalid("the reference is empty")
+	}
+	if strings.Contains(s, "://") {
+		return invalid("the reference must not have a scheme")
+	}
+
+	ref := Reference{}
+	name := s
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+		if !digestRegexp.MatchString(ref.Digest) {
+			return invalid(fmt.Sprintf("invalid digest %q", ref.Digest))
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+		if !tagRegexp.MatchString(ref.Tag) {
+			return invalid(fmt.Sprintf("invalid tag %q", ref.Tag))
+		}
+	}
+
+	components := strings.Split(name, "/")
+	if len(components) > 1 && isRegistry(components[0]) {
+		ref.Registry, components = components[0], components[1:]
+		if !registryRegexp.MatchString(ref.Registry) {
+			return invalid(fmt.Sprintf("invalid registry %q", ref.Registry))
+		}
+	}
+	if ref.Registry == "" || registryAliases[ref.Registry] {
+		ref.Registry = DefaultRegistry
+	}
+	for _, c := range components {
+		if !componentRegexp.MatchString(c) {
+			return invalid(fmt.Sprintf("invalid repository component %q", c))
+		}
+	}
+	if ref.Registry == DefaultRegistry && len(components) == 1 {
+		components = []string{officialNamespace, components[0]}
+	}
+	ref.Repository = strings.Join(components, "/")
+
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = DefaultTag
+	}
+	return ref, nil
+}
+
+// Normalize - the fully qualified form of the image reference.
+func Normalize(s string) (string, error) {
+	ref, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return ref.String(), nil
+}
+
+// isRegistry - true if the first component of a reference names a
+// registry rather than a namespace.
+func isRegistry(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost"
+}
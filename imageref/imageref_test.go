@@ -0,0 +1,80 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package imageref
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const digest = "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "mediawiki-apb", expected: "docker.io/library/mediawiki-apb:latest"},
+		{input: "ansibleplaybookbundle/mediawiki-apb", expected: "docker.io/ansibleplaybookbundle/mediawiki-apb:latest"},
+		{input: "docker.io/ansibleplaybookbundle/mediawiki-apb:v1", expected: "docker.io/ansibleplaybookbundle/mediawiki-apb:v1"},
+		{input: "index.docker.io/busybox", expected: "docker.io/library/busybox:latest"},
+		{input: "quay.io/org/app@" + digest, expected: "quay.io/org/app@" + digest},
+		{input: "quay.io/org/app:1.0@" + digest, expected: "quay.io/org/app:1.0@" + digest},
+		{input: "localhost:5000/app", expected: "localhost:5000/app:latest"},
+		{input: "localhost/app", expected: "localhost/app:latest"},
+		{input: "registry.example.com:8443/a/b/c:tag", expected: "registry.example.com:8443/a/b/c:tag"},
+		{input: "registry.example.com/app", expected: "registry.example.com/app:latest"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			actual, err := Normalize(tc.input)
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"/org/app:latest",
+		"https://registry.example.com/org/app",
+		"docker.io/Org/App",
+		"docker.io/org/app:",
+		"docker.io/org/app:bad/tag",
+		"docker.io/org/app@sha256:short",
+		"docker.io//app",
+		"-bad-.example.com/app",
+	} {
+		t.Run(input, func(t *testing.T) {
+			_, err := Parse(input)
+			assert.True(t, IsErrorInvalidReference(err), "%v", err)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	ref, err := Parse("quay.io/org/app:1.0@" + digest)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, Reference{Registry: "quay.io", Repository: "org/app", Tag: "1.0", Digest: digest}, ref)
+	assert.Equal(t, "quay.io/org/app", ref.Name())
+}
@@ -0,0 +1,320 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package leaderelection elects one leader among the replicas of a
+// consumer of the library, so that work such as the registry refreshes
+// only runs once in an HA deployment.
+package leaderelection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	log "github.com/automationbroker/bundle-lib/logging"
+	apicorev1 "k8s.io/api/core/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultLeaseDuration - how long the lease of the leader is valid
+	// without being renewed.
+	DefaultLeaseDuration = 15 * time.Second
+	// DefaultRenewDeadline - how long the leader keeps trying to renew its
+	// lease before it gives up the leadership.
+	DefaultRenewDeadline = 10 * time.Second
+	// DefaultRetryPeriod - how often the lease is acquired or renewed.
+	DefaultRetryPeriod = 2 * time.Second
+	// LeaderAnnotation - the annotation of the configmap holding the lease.
+	LeaderAnnotation = "bundle.automationbroker.io/leader"
+)
+
+// Config - What is elected and by whom.
+type Config struct {
+	// Namespace and Name - the configmap holding the lease, created when
+	// it does not exist.
+	Namespace string
+	Name      string
+	// Identity - what identifies the replica, defaults to the hostname
+	// which is the pod name.
+	Identity      string
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+	// Client - the kubernetes client, defaults to the shared client.
+	Client kubernetes.Interface
+	// OnStartedLeading - optional, called in a goroutine when the replica
+	// becomes the leader. The context is cancelled when it stops leading.
+	OnStartedLeading func(ctx context.Context)
+	// OnStoppedLeading - optional, called when the replica stops leading.
+	OnStoppedLeading func()
+}
+
+// leaderRecord - the lease, kept in the LeaderAnnotation.
+type leaderRecord struct {
+	HolderIdentity       string    `json:"holderIdentity"`
+	LeaseDurationSeconds int       `json:"leaseDurationSeconds"`
+	AcquireTime          time.Time `json:"acquireTime"`
+	RenewTime            time.Time `json:"renewTime"`
+	LeaderTransitions    int       `json:"leaderTransitions"`
+}
+
+// Elector - Takes part in the election of the leader of the replicas. The
+// lease is a configmap annotation updated with optimistic concurrency, the
+// expiry is measured with the clock of the replica, when it last saw the
+// lease change, so clock skew between the replicas does not matter.
+type Elector struct {
+	config Config
+	now    func() time.Time
+
+	mutex        sync.Mutex
+	leader       bool
+	observed     leaderRecord
+	observedTime time.Time
+}
+
+// New - creates an Elector, the defaults fill in what is not set.
+func New(config Config) (*Elector, error) {
+	if config.Namespace == "" || config.Name == "" {
+		return nil, fmt.Errorf("the namespace and name of the lease are required")
+	}
+	if config.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("unable to default the identity to the hostname - %v", err)
+		}
+		config.Identity = hostname
+	}
+	if config.LeaseDuration <= 0 {
+		config.LeaseDuration = DefaultLeaseDuration
+	}
+	if config.RenewDeadline <= 0 {
+		config.RenewDeadline = DefaultRenewDeadline
+	}
+	if config.RetryPeriod <= 0 {
+		config.RetryPeriod = DefaultRetryPeriod
+	}
+	if config.RenewDeadline >= config.LeaseDuration {
+		return nil, fmt.Errorf("the renew deadline %v must be shorter than the lease duration %v",
+			config.RenewDeadline, config.LeaseDuration)
+	}
+	if config.Client == nil {
+		k8scli, err := clients.Kubernetes()
+		if err != nil {
+			return nil, err
+		}
+		config.Client = k8scli.Client
+	}
+	return &Elector{config: config, now: time.Now}, nil
+}
+
+// IsLeader - true while the replica holds the lease.
+func (e *Elector) IsLeader() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.leader
+}
+
+// Identity - the identity of the replica.
+func (e *Elector) Identity() string {
+	return e.config.Identity
+}
+
+// Run - takes part in the election until ctx is done. Whenever the replica
+// becomes the leader it renews the lease until it fails to for the
+// RenewDeadline, and then takes part in the election again. The lease is
+// released when ctx is done.
+func (e *Elector) Run(ctx context.Context) {
+	defer e.release()
+	for {
+		if !e.acquire(ctx) {
+			return
+		}
+		leaderCtx, cancel := context.WithCancel(ctx)
+		if e.config.OnStartedLeading != nil {
+			go e.config.OnStartedLeading(leaderCtx)
+		}
+		e.renew(ctx)
+		cancel()
+		e.setLeader(false)
+		if e.config.OnStoppedLeading != nil {
+			e.config.OnStoppedLeading()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// acquire - tries to acquire the lease every RetryPeriod, false is returned
+// when ctx is done first.
+func (e *Elector) acquire(ctx context.Context) bool {
+	for {
+		if e.tryAcquireOrRenew() {
+			log.Infof("%s acquired the lease %s/%s", e.config.Identity, e.config.Namespace, e.config.Name)
+			e.setLeader(true)
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(e.config.RetryPeriod):
+		}
+	}
+}
+
+// renew - renews the lease every RetryPeriod until a renewal does not
+// succeed within the RenewDeadline or ctx is done.
+func (e *Elector) renew(ctx context.Context) {
+	lastRenew := e.now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.config.RetryPeriod):
+		}
+		if e.tryAcquireOrRenew() {
+			lastRenew = e.now()
+			continue
+		}
+		if e.now().Sub(lastRenew) >= e.config.RenewDeadline {
+			log.Warningf("%s failed to renew the lease %s/%s", e.config.Identity, e.config.Namespace, e.config.Name)
+			return
+		}
+	}
+}
+
+func (e *Elector) setLeader(leader bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.leader = leader
+}
+
+// tryAcquireOrRenew - takes or renews the lease, unless another replica
+// holds it and it has not expired.
+func (e *Elector) tryAcquireOrRenew() bool {
+	now := e.now()
+	record := leaderRecord{
+		HolderIdentity:       e.config.Identity,
+		LeaseDurationSeconds: int(e.config.LeaseDuration / time.Second),
+		AcquireTime:          now,
+		RenewTime:            now,
+	}
+	client := e.config.Client.CoreV1().ConfigMaps(e.config.Namespace)
+
+	cm, err := client.Get(e.config.Name, metav1.GetOptions{})
+	if kerror.IsNotFound(err) {
+		cm = &apicorev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: e.config.Name, Namespace: e.config.Namespace}}
+		if err := setRecord(cm, record); err != nil {
+			log.Errorf("unable to encode the lease - %v", err)
+			return false
+		}
+		if _, err := client.Create(cm); err != nil {
+			log.Debugf("unable to create the lease %s/%s - %v", e.config.Namespace, e.config.Name, err)
+			return false
+		}
+		e.observe(record, now)
+		return true
+	}
+	if err != nil {
+		log.Errorf("unable to get the lease %s/%s - %v", e.config.Namespace, e.config.Name, err)
+		return false
+	}
+
+	current := leaderRecord{}
+	if s := cm.Annotations[LeaderAnnotation]; s != "" {
+		if err := json.Unmarshal([]byte(s), &current); err != nil {
+			log.Warningf("ignoring the invalid lease %s/%s - %v", e.config.Namespace, e.config.Name, err)
+		}
+	}
+	e.mutex.Lock()
+	if current != e.observed {
+		e.observed = current
+		e.observedTime = now
+	}
+	expires := e.observedTime.Add(time.Duration(current.LeaseDurationSeconds) * time.Second)
+	e.mutex.Unlock()
+
+	if current.HolderIdentity != "" && current.HolderIdentity != e.config.Identity && now.Before(expires) {
+		return false
+	}
+	if current.HolderIdentity == e.config.Identity {
+		record.AcquireTime = current.AcquireTime
+		record.LeaderTransitions = current.LeaderTransitions
+	} else {
+		record.LeaderTransitions = current.LeaderTransitions + 1
+	}
+	if err := setRecord(cm, record); err != nil {
+		log.Errorf("unable to encode the lease - %v", err)
+		return false
+	}
+	// the update fails with a conflict when another replica updated the
+	// lease since it was read
+	if _, err := client.Update(cm); err != nil {
+		log.Debugf("unable to update the lease %s/%s - %v", e.config.Namespace, e.config.Name, err)
+		return false
+	}
+	e.observe(record, now)
+	return true
+}
+
+func (e *Elector) observe(record leaderRecord, now time.Time) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.observed = record
+	e.observedTime = now
+}
+
+// release - gives up the lease so another replica does not have to wait
+// for it to expire.
+func (e *Elector) release() {
+	client := e.config.Client.CoreV1().ConfigMaps(e.config.Namespace)
+	cm, err := client.Get(e.config.Name, metav1.GetOptions{})
+	if err != nil {
+		return
+	}
+	current := leaderRecord{}
+	if err := json.Unmarshal([]byte(cm.Annotations[LeaderAnnotation]), &current); err != nil ||
+		current.HolderIdentity != e.config.Identity {
+		return
+	}
+	current.HolderIdentity = ""
+	current.LeaseDurationSeconds = 1
+	if err := setRecord(cm, current); err != nil {
+		return
+	}
+	if _, err := client.Update(cm); err != nil {
+		log.Warningf("unable to release the lease %s/%s - %v", e.config.Namespace, e.config.Name, err)
+	}
+}
+
+func setRecord(cm *apicorev1.ConfigMap, record leaderRecord) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	cm.Annotations[LeaderAnnotation] = string(b)
+	return nil
+}
@@ -0,0 +1,126 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package leaderelection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestElector(t *testing.T, client *fake.Clientset, identity string, now *time.Time) *Elector {
+	e, err := New(Config{
+		Namespace: "broker",
+		Name:      "broker-leader",
+		Identity:  identity,
+		Client:    client,
+	})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	e.now = func() time.Time { return *now }
+	return e
+}
+
+func TestTryAcquireOrRenew(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	now := time.Now()
+	first := newTestElector(t, client, "broker-1", &now)
+	second := newTestElector(t, client, "broker-2", &now)
+
+	assert.True(t, first.tryAcquireOrRenew(), "the lease is created")
+	assert.False(t, second.tryAcquireOrRenew(), "the lease is held")
+
+	now = now.Add(DefaultLeaseDuration / 2)
+	assert.True(t, first.tryAcquireOrRenew(), "the holder renews")
+	assert.False(t, second.tryAcquireOrRenew(), "the lease was renewed")
+
+	// the first replica stops renewing
+	now = now.Add(DefaultLeaseDuration / 2)
+	assert.False(t, second.tryAcquireOrRenew())
+	now = now.Add(DefaultLeaseDuration)
+	assert.True(t, second.tryAcquireOrRenew(), "the lease expired")
+	assert.False(t, first.tryAcquireOrRenew())
+
+	cm, err := client.CoreV1().ConfigMaps("broker").Get("broker-leader", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Contains(t, cm.Annotations[LeaderAnnotation], `"holderIdentity":"broker-2"`)
+	assert.Contains(t, cm.Annotations[LeaderAnnotation], `"leaderTransitions":1`)
+}
+
+func TestRunReleasesTheLease(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	e, err := New(Config{
+		Namespace:        "broker",
+		Name:             "broker-leader",
+		Identity:         "broker-1",
+		Client:           client,
+		RetryPeriod:      time.Millisecond,
+		OnStartedLeading: func(context.Context) { close(started) },
+		OnStoppedLeading: func() { close(stopped) },
+	})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx)
+		close(done)
+	}()
+	<-started
+	assert.True(t, e.IsLeader())
+
+	cancel()
+	<-stopped
+	<-done
+	assert.False(t, e.IsLeader())
+
+	now := time.Now()
+	other := newTestElector(t, client, "broker-2", &now)
+	assert.True(t, other.tryAcquireOrRenew(), "the released lease is taken right away")
+}
+
+func TestNewValidation(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	_, err := New(Config{Name: "broker-leader", Client: client})
+	assert.Error(t, err)
+	_, err = New(Config{
+		Namespace:     "broker",
+		Name:          "broker-leader",
+		Client:        client,
+		LeaseDuration: time.Second,
+		RenewDeadline: 2 * time.Second,
+	})
+	assert.Error(t, err)
+
+	e, err := New(Config{Namespace: "broker", Name: "broker-leader", Client: client})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.NotEmpty(t, e.Identity())
+	assert.Equal(t, DefaultRetryPeriod, e.config.RetryPeriod)
+}
@@ -0,0 +1,146 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package logging
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Fields - key value pairs that are attached to a log entry.
+type Fields map[string]interface{}
+
+// Logger - the logging interface used by bundle-lib. Consumers that do not
+// use logrus can provide their own implementation with SetLogger.
+type Logger interface {
+	Debug(args ...interface{})
+	Debugf(format string, args ...interface{})
+	Info(args ...interface{})
+	Infof(format string, args ...interface{})
+	Warn(args ...interface{})
+	Warnf(format string, args ...interface{})
+	Warning(args ...interface{})
+	Warningf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorf(format string, args ...interface{})
+	// WithField - returns a logger that adds the field to every entry.
+	WithField(key string, value interface{}) Logger
+	// WithFields - returns a logger that adds the fields to every entry.
+	WithFields(fields Fields) Logger
+}
+
+var (
+	mutex  sync.RWMutex
+	logger Logger = NewLogrusLogger(logrus.StandardLogger())
+)
+
+// SetLogger - sets the logger used by bundle-lib. Passing nil restores the
+// default, which logs through the global logrus logger.
+func SetLogger(l Logger) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if l == nil {
+		l = NewLogrusLogger(logrus.StandardLogger())
+	}
+	logger = l
+}
+
+// GetLogger - returns the logger used by bundle-lib.
+func GetLogger() Logger {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return logger
+}
+
+// logrusLogger - Logger backed by a logrus entry.
+type logrusLogger struct {
+	*logrus.Entry
+}
+
+// NewLogrusLogger - creates a Logger that logs through the logrus logger.
+func NewLogrusLogger(l *logrus.Logger) Logger {
+	return logrusLogger{Entry: logrus.NewEntry(l)}
+}
+
+func (l logrusLogger) WithField(key string, value interface{}) Logger {
+	return logrusLogger{Entry: l.Entry.WithField(key, value)}
+}
+
+func (l logrusLogger) WithFields(fields Fields) Logger {
+	return logrusLogger{Entry: l.Entry.WithFields(logrus.Fields(fields))}
+}
+
+// WithField - returns a logger that adds the field to every entry.
+func WithField(key string, value interface{}) Logger {
+	return GetLogger().WithField(key, value)
+}
+
+// WithFields - returns a logger that adds the fields to every entry.
+func WithFields(fields Fields) Logger {
+	return GetLogger().WithFields(fields)
+}
+
+// Debug - logs at the debug level.
+func Debug(args ...interface{}) {
+	GetLogger().Debug(args...)
+}
+
+// Debugf - logs at the debug level.
+func Debugf(format string, args ...interface{}) {
+	GetLogger().Debugf(format, args...)
+}
+
+// Info - logs at the info level.
+func Info(args ...interface{}) {
+	GetLogger().Info(args...)
+}
+
+// Infof - logs at the info level.
+func Infof(format string, args ...interface{}) {
+	GetLogger().Infof(format, args...)
+}
+
+// Warn - logs at the warning level.
+func Warn(args ...interface{}) {
+	GetLogger().Warn(args...)
+}
+
+// Warnf - logs at the warning level.
+func Warnf(format string, args ...interface{}) {
+	GetLogger().Warnf(format, args...)
+}
+
+// Warning - logs at the warning level.
+func Warning(args ...interface{}) {
+	GetLogger().Warning(args...)
+}
+
+// Warningf - logs at the warning level.
+func Warningf(format string, args ...interface{}) {
+	GetLogger().Warningf(format, args...)
+}
+
+// Error - logs at the error level.
+func Error(args ...interface{}) {
+	GetLogger().Error(args...)
+}
+
+// Errorf - logs at the error level.
+func Errorf(format string, args ...interface{}) {
+	GetLogger().Errorf(format, args...)
+}
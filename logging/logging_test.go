@@ -0,0 +1,104 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type recordingLogger struct {
+	fields  Fields
+	entries *[]string
+}
+
+func (r recordingLogger) record(level string, msg string) {
+	*r.entries = append(*r.entries, fmt.Sprintf("%v %v %v", level, msg, r.fields))
+}
+
+func (r recordingLogger) Debug(args ...interface{}) { r.record("debug", fmt.Sprint(args...)) }
+func (r recordingLogger) Debugf(format string, args ...interface{}) {
+	r.record("debug", fmt.Sprintf(format, args...))
+}
+func (r recordingLogger) Info(args ...interface{}) { r.record("info", fmt.Sprint(args...)) }
+func (r recordingLogger) Infof(format string, args ...interface{}) {
+	r.record("info", fmt.Sprintf(format, args...))
+}
+func (r recordingLogger) Warn(args ...interface{}) { r.record("warn", fmt.Sprint(args...)) }
+func (r recordingLogger) Warnf(format string, args ...interface{}) {
+	r.record("warn", fmt.Sprintf(format, args...))
+}
+func (r recordingLogger) Warning(args ...interface{}) { r.record("warn", fmt.Sprint(args...)) }
+func (r recordingLogger) Warningf(format string, args ...interface{}) {
+	r.record("warn", fmt.Sprintf(format, args...))
+}
+func (r recordingLogger) Error(args ...interface{}) { r.record("error", fmt.Sprint(args...)) }
+func (r recordingLogger) Errorf(format string, args ...interface{}) {
+	r.record("error", fmt.Sprintf(format, args...))
+}
+func (r recordingLogger) WithField(key string, value interface{}) Logger {
+	return r.WithFields(Fields{key: value})
+}
+func (r recordingLogger) WithFields(fields Fields) Logger {
+	f := Fields{}
+	for k, v := range r.fields {
+		f[k] = v
+	}
+	for k, v := range fields {
+		f[k] = v
+	}
+	return recordingLogger{fields: f, entries: r.entries}
+}
+
+func TestSetLogger(t *testing.T) {
+	defer SetLogger(nil)
+	entries := []string{}
+	SetLogger(recordingLogger{entries: &entries})
+
+	Infof("provisioning %v", "foo")
+	WithField("instance", "1234").Error("failed")
+
+	expected := []string{
+		"info provisioning foo map[]",
+		"error failed map[instance:1234]",
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %v entries got: %v", len(expected), entries)
+	}
+	for i, e := range expected {
+		if entries[i] != e {
+			t.Fatalf("expected entry: %v got: %v", e, entries[i])
+		}
+	}
+}
+
+func TestDefaultLogger(t *testing.T) {
+	SetLogger(nil)
+	buf := &bytes.Buffer{}
+	out := logrus.StandardLogger().Out
+	logrus.SetOutput(buf)
+	defer logrus.SetOutput(out)
+
+	WithFields(Fields{"instance": "1234"}).Warning("using default")
+	if !strings.Contains(buf.String(), "using default") || !strings.Contains(buf.String(), "instance=1234") {
+		t.Fatalf("expected the default logger to log through logrus got: %v", buf.String())
+	}
+}
@@ -19,12 +19,13 @@ package metrics
 import (
 	"sync"
 
+	log "github.com/automationbroker/bundle-lib/logging"
 	prom "github.com/prometheus/client_golang/prometheus"
-	log "github.com/sirupsen/logrus"
 )
 
 const (
-	sandboxGuageName = "bundlelib_sandbox"
+	sandboxGuageName            = "bundlelib_sandbox"
+	authorizationCacheCountName = "bundlelib_authorization_cache_total"
 )
 
 var (
@@ -34,7 +35,8 @@ var (
 
 // Collector - collects bundlelib metrics
 type Collector struct {
-	Sandbox prom.Gauge
+	Sandbox            prom.Gauge
+	AuthorizationCache *prom.CounterVec
 }
 
 // We will never want to panic our app because of metric saving.
@@ -55,6 +57,10 @@ func RegisterCollector() {
 				Name: sandboxGuageName,
 				Help: "Guage of all sandbox namespaces that are active.",
 			}),
+			AuthorizationCache: prom.NewCounterVec(prom.CounterOpts{
+				Name: authorizationCacheCountName,
+				Help: "Counter of authorization decision cache lookups by result.",
+			}, []string{"result"}),
 		}
 
 		err := prom.Register(collector)
@@ -76,12 +82,26 @@ func SandboxDeleted() {
 	collector.Sandbox.Dec()
 }
 
+// AuthorizationCacheHit - Counter for authorization decisions served from cache.
+func AuthorizationCacheHit() {
+	defer recoverMetricPanic()
+	collector.AuthorizationCache.WithLabelValues("hit").Inc()
+}
+
+// AuthorizationCacheMiss - Counter for authorization decisions not in cache.
+func AuthorizationCacheMiss() {
+	defer recoverMetricPanic()
+	collector.AuthorizationCache.WithLabelValues("miss").Inc()
+}
+
 // Describe - returns all the descriptions of the collector
 func (c Collector) Describe(ch chan<- *prom.Desc) {
 	c.Sandbox.Describe(ch)
+	c.AuthorizationCache.Describe(ch)
 }
 
 // Collect - returns the current state of the metrics
 func (c Collector) Collect(ch chan<- prom.Metric) {
 	c.Sandbox.Collect(ch)
+	c.AuthorizationCache.Collect(ch)
 }
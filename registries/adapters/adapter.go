@@ -20,16 +20,17 @@ import (
 	"bytes"
 	b64 "encoding/base64"
 	"encoding/json"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"fmt"
 
 	"github.com/automationbroker/bundle-lib/bundle"
-	log "github.com/sirupsen/logrus"
-	yaml "gopkg.in/yaml.v1"
+	"github.com/automationbroker/bundle-lib/clock"
+	log "github.com/automationbroker/bundle-lib/logging"
+	"github.com/automationbroker/bundle-lib/registries/adapters/oauth"
 )
 
 // Adapter - Adapter will wrap the methods that a registry needs to fully manage images.
@@ -43,9 +44,39 @@ type Adapter interface {
 	FetchSpecs([]string) ([]*bundle.Spec, error)
 }
 
+// LastPushedAdapter - Implemented by the adapters whose registry API
+// reports when the images were last pushed. It is used to filter out the
+// images that were not pushed for a long time.
+type LastPushedAdapter interface {
+	// LastPushed - the time each image was last pushed. Images without a
+	// known time are missing from the map.
+	LastPushed([]string) (map[string]time.Time, error)
+}
+
+// SpecAdapter - Implemented by the adapters that fetch the spec of every
+// image on its own. The registry then fetches the images one at a time so
+// each fetch is traced.
+type SpecAdapter interface {
+	// FetchSpec - the spec of the image, nil when the image has none.
+	FetchSpec(image string) (*bundle.Spec, error)
+}
+
+// DigestAdapter - Implemented by the adapters that can resolve the tag of
+// an image to its digest at any time, e.g. to detect a tag that was pushed
+// again since the specs were loaded.
+type DigestAdapter interface {
+	// ResolveDigest - the digest the adapter records in the Provenance of
+	// the spec of the image, for the tag of the image as it is now.
+	ResolveDigest(image string) (string, error)
+}
+
 // BundleSpecLabel - label on the image that we should use to pull out the abp spec.
 const BundleSpecLabel = "com.redhat.apb.spec"
 
+// BundleSpecSignatureLabel - label on the image holding the base64
+// signature of the decoded spec label.
+const BundleSpecSignatureLabel = "com.redhat.apb.spec.signature"
+
 // Configuration - Adapter configuration. Contains the info that the adapter
 // would need to complete its request to the images.
 type Configuration struct {
@@ -60,6 +91,60 @@ type Configuration struct {
 	Tag           string
 	SkipVerifyTLS bool
 	AdapterName   string
+	// MaxIdleConnsPerHost - the idle connections kept to the registry,
+	// defaults to oauth.DefaultMaxIdleConnsPerHost.
+	MaxIdleConnsPerHost int
+	// DisableHTTP2 - only talk HTTP/1.1 to the registry.
+	DisableHTTP2 bool
+	// RetryAttempts and RetryBackoff - how requests to the registry are
+	// retried, defaults to oauth.DefaultRetryPolicy.
+	RetryAttempts int
+	RetryBackoff  time.Duration
+	// Identity - the User-Agent and correlation ID sent with the requests
+	// to the registry.
+	Identity *oauth.RequestIdentity
+	// RateLimiter - limits the requests to the registry, shared by the
+	// clients of the adapter. Nil does not limit.
+	RateLimiter *oauth.RateLimiter
+	// Clock - the time of the retry backoff and of the rate limiter, nil
+	// uses clock.Real.
+	Clock clock.Clock
+}
+
+// transportConfig - the tuning of the transport shared by the requests of
+// the adapter.
+func (c Configuration) transportConfig() oauth.TransportConfig {
+	return oauth.TransportConfig{
+		SkipVerifyTLS:       c.SkipVerifyTLS,
+		MaxIdleConnsPerHost: c.MaxIdleConnsPerHost,
+		DisableHTTP2:        c.DisableHTTP2,
+		Retry:               c.retryPolicy(),
+		Identity:            c.Identity,
+		RateLimiter:         c.RateLimiter,
+		Clock:               c.Clock,
+	}
+}
+
+// retryPolicy - the retry policy of the configuration, the defaults fill
+// in what is not set.
+func (c Configuration) retryPolicy() oauth.RetryPolicy {
+	if c.RetryAttempts == 0 && c.RetryBackoff == 0 {
+		return oauth.RetryPolicy{}
+	}
+	policy := oauth.DefaultRetryPolicy
+	if c.RetryAttempts > 0 {
+		policy.Attempts = c.RetryAttempts
+	}
+	if c.RetryBackoff > 0 {
+		policy.Backoff = c.RetryBackoff
+	}
+	return policy
+}
+
+// httpClient - returns a client using the transport shared by the adapters
+// with the same configuration, so connections to the registry are reused.
+func (c Configuration) httpClient() *http.Client {
+	return oauth.NewHTTPClient(c.transportConfig())
 }
 
 type registryResponseError struct {
@@ -69,6 +154,7 @@ type registryResponseError struct {
 
 type imageLabel struct {
 	Spec          string `json:"com.redhat.apb.spec"`
+	SpecSignature string `json:"com.redhat.apb.spec.signature"`
 	Runtime       string `json:"com.redhat.apb.runtime"`
 	BundleRuntime string `json:"com.redhat.bundle.runtime"`
 }
@@ -87,13 +173,19 @@ type manifestConfig struct {
 	Config config `json:"config"`
 }
 
+// imagePlatform - the platform the image was built for.
+type imagePlatform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+}
+
 func (rre *registryResponseError) Error() string {
 	return fmt.Sprintf("unexpected registry response code: %v message: %v", rre.code, rre.message)
 }
 
 func registryResponseHandler(resp *http.Response) ([]byte, error) {
 	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := readResponse(resp, maxResponseSize)
 	if err != nil {
 		return nil, err
 	}
@@ -142,7 +234,7 @@ func configToSpec(config []byte, image string) (*bundle.Spec, error) {
 		log.Errorf("Something went wrong decoding spec from label for '%s' : %s", image, err)
 		return nil, err
 	}
-	if err = yaml.Unmarshal(decodedSpecYaml, spec); err != nil {
+	if spec, err = bundle.UnmarshalSpecYAML(decodedSpecYaml, nil); err != nil {
 		log.Errorf("Something went wrong loading decoded spec yaml for '%s' : %s", image, err)
 		return nil, err
 	}
@@ -160,6 +252,13 @@ func configToSpec(config []byte, image string) (*bundle.Spec, error) {
 
 	// image name to be pulled during provision
 	spec.Image = image
+	spec.Provenance = specProvenance(mConf.Config.Digest, mConf.Config.Label.SpecSignature, decodedSpecYaml)
+	// platform the image was built for, used to schedule the bundle pod
+	platform := imagePlatform{}
+	if err := json.Unmarshal(config, &platform); err == nil {
+		spec.OS = platform.OS
+		spec.Architecture = platform.Architecture
+	}
 
 	log.Debugf("Successfully converted Image %s into Spec", spec.Image)
 	log.Infof("adapter::configToSpec -> Image %s runtime is %d", spec.Image, spec.Runtime)
@@ -167,6 +266,20 @@ func configToSpec(config []byte, image string) (*bundle.Spec, error) {
 	return spec, nil
 }
 
+// specProvenance - the provenance known by the adapter, nil when it knows
+// neither the digest nor a signature of the spec.
+func specProvenance(digest, signature string, decodedSpec []byte) *bundle.Provenance {
+	if digest == "" && signature == "" {
+		return nil
+	}
+	p := &bundle.Provenance{Digest: digest}
+	if signature != "" {
+		p.SpecSignature = signature
+		p.SignedContent = decodedSpec
+	}
+	return p
+}
+
 func getAPBRuntimeVersion(version string) (int, error) {
 	if version == "" {
 		log.Infof("No runtime label found. Set runtime=1. Will use 'exec' to gather bind credentials")
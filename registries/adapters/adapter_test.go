@@ -133,6 +133,26 @@ func TestResponseToSpec(t *testing.T) {
 				}
 			},
 		},
+		{
+			// the dockerhub and rhcc adapters read the specs this way
+			Name: "test spec signature label kept for the verification",
+			Response: manifestResponse{
+				SchemaVersion: 1,
+				History: []map[string]string{
+					{
+						"v1Compatibility": fmt.Sprintf(`{"config":{"Labels":{"com.redhat.apb.spec":"%s","com.redhat.apb.spec.signature":"c2lnbmF0dXJl"}}}`, testApbSpec),
+					},
+				},
+			},
+			Validate: func(t *testing.T, spec *bundle.Spec) {
+				if spec.Provenance == nil || spec.Provenance.SpecSignature != "c2lnbmF0dXJl" {
+					t.Fatalf("Expected the spec signature to be kept but the provenance was %v", spec.Provenance)
+				}
+				if len(spec.Provenance.SignedContent) == 0 {
+					t.Fatalf("Expected the signed content to be kept")
+				}
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -305,6 +325,26 @@ func TestConfigToSpec(t *testing.T) {
 	}
 }
 
+func TestConfigToSpecPlatform(t *testing.T) {
+	b, err := json.Marshal(struct {
+		manifestConfig
+		imagePlatform
+	}{
+		manifestConfig{config{imageLabel{Spec: testApbSpec}, ""}},
+		imagePlatform{OS: "windows", Architecture: "amd64"},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal config %v", err)
+	}
+	spec, err := configToSpec(b, "rick/james-apb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.OS != "windows" || spec.Architecture != "amd64" {
+		t.Fatalf("Expected the platform to be windows/amd64 but it was %v/%v", spec.OS, spec.Architecture)
+	}
+}
+
 func TestGetAPBRuntimeVersion(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -24,8 +24,9 @@ import (
 	"strings"
 
 	"github.com/automationbroker/bundle-lib/bundle"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/automationbroker/bundle-lib/registries/adapters/oauth"
-	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -86,7 +87,7 @@ func NewRegistryProxyAdapter(config Configuration) (RegistryProxyAdapter, error)
 	// NewAPIV2Adapter directly
 	apiv2a := APIV2Adapter{
 		config: config,
-		client: oauth.NewClient(config.User, config.Pass, config.SkipVerifyTLS, config.URL),
+		client: oauth.NewClientWithTransport(config.User, config.Pass, config.URL, config.transportConfig()),
 	}
 
 	if len(config.Images) < 1 {
@@ -113,7 +114,7 @@ func NewRegistryProxyAdapter(config Configuration) (RegistryProxyAdapter, error)
 func NewAPIV2Adapter(config Configuration) (APIV2Adapter, error) {
 	apiv2a := APIV2Adapter{
 		config: config,
-		client: oauth.NewClient(config.User, config.Pass, config.SkipVerifyTLS, config.URL),
+		client: oauth.NewClientWithTransport(config.User, config.Pass, config.URL, config.transportConfig()),
 	}
 
 	// Authorization
@@ -254,11 +255,11 @@ func (r APIV2Adapter) getNextImages(url string) (*apiV2CatalogResponse, string,
 
 	if resp.StatusCode != 200 {
 		log.Warnf("Failed to fetch catalog response from '%s'. Expected a 200 status and got: %v", url, resp.Status)
-		return nil, "", errors.New(resp.Status)
+		return nil, "", liberrors.FromHTTPStatus(resp.StatusCode, resp.Status)
 	}
 
 	imageList := apiV2CatalogResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&imageList)
+	err = decodeResponse(resp, maxResponseSize, &imageList)
 	if err != nil {
 		return nil, "", err
 	}
@@ -290,6 +291,11 @@ func (r APIV2Adapter) getNextImageURL(link string) string {
 	return (r.config.URL.String() + lvalue)
 }
 
+// FetchSpec - retrieve the spec for the image name.
+func (r APIV2Adapter) FetchSpec(imageName string) (*bundle.Spec, error) {
+	return r.loadSpec(imageName)
+}
+
 func (r APIV2Adapter) loadSpec(imageName string) (*bundle.Spec, error) {
 	log.Debugf("%s - LoadSpec", r.config.AdapterName)
 
@@ -355,6 +361,45 @@ func (r APIV2Adapter) loadSpec(imageName string) (*bundle.Spec, error) {
 	}
 }
 
+// ResolveDigest - the config digest of the image, as recorded by loadSpec,
+// of the tag of the image now. Only schema 2 manifests have one.
+func (r APIV2Adapter) ResolveDigest(image string) (string, error) {
+	name, tag := r.splitImage(image)
+	req, err := r.client.NewRequest(fmt.Sprintf(apiV2ManifestPath, name, tag))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("accept", schema2Ct)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	body, err := registryResponseHandler(resp)
+	if err != nil {
+		return "", fmt.Errorf("%s - error handling registry response %s", r.config.AdapterName, err)
+	}
+	if ct := resp.Header.Get("content-type"); ct != schema2Ct {
+		return "", fmt.Errorf("unable to resolve the digest of %v from a %v manifest", image, ct)
+	}
+	mConf := manifestConfig{}
+	if err := json.Unmarshal(body, &mConf); err != nil {
+		return "", err
+	}
+	return mConf.Config.Digest, nil
+}
+
+// splitImage - the repository and tag of an image of the registry, the tag
+// of the adapter when the image has none.
+func (r APIV2Adapter) splitImage(image string) (string, string) {
+	host := r.config.URL.Host
+	image = strings.TrimPrefix(image, host+"/")
+	tag := r.config.Tag
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image, tag = image[:i], image[i+1:]
+	}
+	return image, tag
+}
+
 func getSchemaVersion(ct string) (int, error) {
 	// See below links for more information on accepted media types for Docker manifests
 	// https://docs.docker.com/registry/spec/manifest-v2-1/
@@ -18,6 +18,8 @@ package adapters
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"sort"
 	"testing"
 
@@ -289,3 +291,35 @@ func TestGetNextImageURL(t *testing.T) {
 
 	}
 }
+
+func TestAPIV2ResolveDigest(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/v2/foo/bar/manifests/v1":
+			w.Header().Set("Content-Type", schema2Ct)
+			fmt.Fprint(w, `{"schemaVersion": 2, "config": {"digest": "sha256:1234"}}`)
+		case "/v2/foo/old/manifests/latest":
+			w.Header().Set("Content-Type", schema1Ct)
+			fmt.Fprint(w, `{"schemaVersion": 1}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer serv.Close()
+	u := adaptertest.GetURL(t, serv)
+	apiv2a := APIV2Adapter{
+		config: Configuration{URL: u, Tag: "latest"},
+		client: oauth.NewClient("user", "pass", true, u),
+	}
+
+	digest, err := apiv2a.ResolveDigest(fmt.Sprintf("%v/foo/bar:v1", u.Host))
+	if err != nil {
+		t.Fatal("Error: ", err)
+	}
+	ft.Equal(t, "sha256:1234", digest)
+
+	_, err = apiv2a.ResolveDigest("foo/old")
+	ft.Error(t, err, "schema 1 manifests have no config digest")
+	_, err = apiv2a.ResolveDigest("foo/missing:v1")
+	ft.Error(t, err)
+}
@@ -0,0 +1,44 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package adapters_test
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/registries/adapters"
+	"github.com/automationbroker/bundle-lib/registries/adapters/conformancetest"
+)
+
+func TestAPIV2AdapterConformance(t *testing.T) {
+	conformancetest.Run(t, func(c adapters.Configuration) (adapters.Adapter, error) {
+		a, err := adapters.NewAPIV2Adapter(c)
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	}, conformancetest.DockerRegistryV2)
+}
+
+func TestOpenShiftAdapterConformance(t *testing.T) {
+	conformancetest.Run(t, func(c adapters.Configuration) (adapters.Adapter, error) {
+		a, err := adapters.NewOpenShiftAdapter(c)
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	}, conformancetest.DockerRegistryV2)
+}
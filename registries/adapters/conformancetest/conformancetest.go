@@ -0,0 +1,290 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package conformancetest is a conformance suite for Adapter implementations.
+// The suite scripts a fake registry, serves it in the wire format of the
+// adapter and checks the adapter lists the images and loads the specs the
+// same way the in-tree adapters do:
+//
+//	func TestConformance(t *testing.T) {
+//		conformancetest.Run(t, func(c adapters.Configuration) (adapters.Adapter, error) {
+//			return NewMyAdapter(c)
+//		}, conformancetest.DockerRegistryV2)
+//	}
+//
+// Adapters talking another API than the docker registry v2 API provide
+// their own Server.
+package conformancetest
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/registries/adapters"
+)
+
+// BigCatalogSize - the number of images of the big catalog case.
+const BigCatalogSize = 1000
+
+// bigCatalogTimeout - how long listing and loading the big catalog may
+// take.
+const bigCatalogTimeout = 60 * time.Second
+
+// Image - An image of the fake registry.
+type Image struct {
+	// Name - the name of the repository, e.g. conformance/one-apb.
+	Name string
+	// Spec - the spec yaml of the image, images without one are not
+	// bundles.
+	Spec string
+	// Label - served as the spec label as is instead of the base64 of the
+	// Spec, to script bad specs.
+	Label string
+}
+
+// Registry - The scripted content and behavior of the fake registry.
+type Registry struct {
+	Images []Image
+	// PageSize - the number of images per catalog page, all the images
+	// are returned at once when 0.
+	PageSize int
+	// User and Pass - the credentials the registry requires, it is
+	// anonymous when User is empty.
+	User string
+	Pass string
+}
+
+// Server - Serves the registry in the wire format of the adapter.
+type Server func(t *testing.T, r Registry) *httptest.Server
+
+// Factory - Creates the adapter under test, the configuration holds the
+// URL of the fake registry and the credentials.
+type Factory func(config adapters.Configuration) (adapters.Adapter, error)
+
+// BundleSpec - the spec yaml of a valid bundle with the name.
+func BundleSpec(name string) string {
+	return fmt.Sprintf(`version: 1.0
+name: %v
+description: conformance bundle %v
+bindable: false
+async: optional
+metadata:
+  displayName: %v
+plans:
+  - name: default
+    description: the default plan
+    free: true
+    parameters: []
+`, name, name, name)
+}
+
+// bundleImages - n valid bundle images.
+func bundleImages(n int) []Image {
+	images := []Image{}
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("bundle-%04d-apb", i)
+		images = append(images, Image{Name: "conformance/" + name, Spec: BundleSpec(name)})
+	}
+	return images
+}
+
+// Run - runs the conformance suite against the adapters created by the
+// factory, each case with its own registry served by the server.
+func Run(t *testing.T, factory Factory, server Server) {
+	t.Run("lists every image", func(t *testing.T) {
+		r := Registry{Images: append(bundleImages(5), Image{Name: "conformance/not-a-bundle"})}
+		a, s := newAdapter(t, factory, server, r)
+		defer s.Close()
+		if a.RegistryName() == "" {
+			t.Errorf("the registry name is empty")
+		}
+		assertImageNames(t, a, r.Images)
+	})
+
+	t.Run("follows the catalog pages", func(t *testing.T) {
+		r := Registry{Images: bundleImages(7), PageSize: 2}
+		a, s := newAdapter(t, factory, server, r)
+		defer s.Close()
+		assertImageNames(t, a, r.Images)
+	})
+
+	t.Run("authenticates", func(t *testing.T) {
+		r := Registry{Images: bundleImages(3), User: "conformance", Pass: "secret"}
+		a, s := newAdapter(t, factory, server, r)
+		defer s.Close()
+		assertImageNames(t, a, r.Images)
+	})
+
+	t.Run("reports authentication failures", func(t *testing.T) {
+		r := Registry{Images: bundleImages(3), User: "conformance", Pass: "secret"}
+		s := server(t, r)
+		defer s.Close()
+		a, err := factory(configuration(t, s, r.User, "wrong"))
+		if err != nil {
+			return
+		}
+		names, err := a.GetImageNames()
+		if err == nil {
+			t.Fatalf("expected an error listing the images with bad credentials, got: %v", names)
+		}
+	})
+
+	t.Run("loads the specs", func(t *testing.T) {
+		r := Registry{Images: bundleImages(3)}
+		a, s := newAdapter(t, factory, server, r)
+		defer s.Close()
+		specs := fetchSpecs(t, a, r.Images)
+		if len(specs) != len(r.Images) {
+			t.Fatalf("expected %d specs, got %d", len(r.Images), len(specs))
+		}
+		for _, spec := range specs {
+			assertSpec(t, spec)
+		}
+	})
+
+	t.Run("skips bad specs", func(t *testing.T) {
+		r := Registry{Images: []Image{
+			{Name: "conformance/good-apb", Spec: BundleSpec("good-apb")},
+			{Name: "conformance/not-a-bundle"},
+			{Name: "conformance/bad-base64-apb", Label: "not base64!"},
+			{Name: "conformance/bad-yaml-apb", Spec: "name: [bad-yaml-apb"},
+		}}
+		a, s := newAdapter(t, factory, server, r)
+		defer s.Close()
+		names := []string{"conformance/missing-apb"}
+		for _, image := range r.Images {
+			names = append(names, image.Name)
+		}
+		specs, err := a.FetchSpecs(names)
+		if err != nil {
+			t.Fatalf("bad specs should be skipped, got: %v", err)
+		}
+		if len(specs) != 1 || specs[0].FQName != "good-apb" {
+			t.Fatalf("expected only the good-apb spec, got: %v", specNames(specs))
+		}
+	})
+
+	t.Run("handles big catalogs", func(t *testing.T) {
+		r := Registry{Images: bundleImages(BigCatalogSize), PageSize: 100}
+		a, s := newAdapter(t, factory, server, r)
+		defer s.Close()
+		start := time.Now()
+		assertImageNames(t, a, r.Images)
+		specs := fetchSpecs(t, a, r.Images)
+		if len(specs) != BigCatalogSize {
+			t.Fatalf("expected %d specs, got %d", BigCatalogSize, len(specs))
+		}
+		if d := time.Since(start); d > bigCatalogTimeout {
+			t.Fatalf("loading %d images took %v, more than %v", BigCatalogSize, d, bigCatalogTimeout)
+		}
+	})
+}
+
+func configuration(t *testing.T, s *httptest.Server, user, pass string) adapters.Configuration {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatalf("invalid server url %v - %v", s.URL, err)
+	}
+	return adapters.Configuration{
+		URL:         u,
+		User:        user,
+		Pass:        pass,
+		Tag:         "latest",
+		AdapterName: "conformance",
+	}
+}
+
+// newAdapter - serves the registry and creates the adapter for it with
+// the credentials of the registry. The server has to be closed.
+func newAdapter(t *testing.T, factory Factory, server Server, r Registry) (adapters.Adapter, *httptest.Server) {
+	s := server(t, r)
+	a, err := factory(configuration(t, s, r.User, r.Pass))
+	if err != nil {
+		s.Close()
+		t.Fatalf("unable to create the adapter - %v", err)
+	}
+	return a, s
+}
+
+// assertImageNames - the adapter lists exactly the images of the registry,
+// in any order.
+func assertImageNames(t *testing.T, a adapters.Adapter, images []Image) {
+	names, err := a.GetImageNames()
+	if err != nil {
+		t.Fatalf("unable to list the images - %v", err)
+	}
+	expected := map[string]bool{}
+	for _, image := range images {
+		expected[image.Name] = true
+	}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if !expected[name] {
+			t.Errorf("unexpected image %v", name)
+		}
+		if seen[name] {
+			t.Errorf("image %v is listed twice", name)
+		}
+		seen[name] = true
+	}
+	if len(seen) != len(expected) {
+		t.Fatalf("expected %d images, got %d", len(expected), len(seen))
+	}
+}
+
+func fetchSpecs(t *testing.T, a adapters.Adapter, images []Image) []*bundle.Spec {
+	names := []string{}
+	for _, image := range images {
+		names = append(names, image.Name)
+	}
+	specs, err := a.FetchSpecs(names)
+	if err != nil {
+		t.Fatalf("unable to fetch the specs - %v", err)
+	}
+	return specs
+}
+
+// assertSpec - the spec is the BundleSpec of its image.
+func assertSpec(t *testing.T, spec *bundle.Spec) {
+	if spec == nil {
+		t.Fatalf("nil spec")
+	}
+	if !strings.HasPrefix(spec.FQName, "bundle-") {
+		t.Errorf("unexpected spec name %v", spec.FQName)
+	}
+	if !strings.Contains(spec.Image, spec.FQName) {
+		t.Errorf("the image %v of spec %v is not the image of the bundle", spec.Image, spec.FQName)
+	}
+	if spec.Runtime < 1 {
+		t.Errorf("the runtime of spec %v is not set", spec.FQName)
+	}
+	if len(spec.Plans) != 1 || spec.Plans[0].Name != "default" {
+		t.Errorf("unexpected plans of spec %v: %#v", spec.FQName, spec.Plans)
+	}
+}
+
+func specNames(specs []*bundle.Spec) []string {
+	names := []string{}
+	for _, spec := range specs {
+		names = append(names, spec.FQName)
+	}
+	return names
+}
@@ -0,0 +1,138 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package conformancetest
+
+import (
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	registryV2Token  = "conformance-token"
+	registryV2Schema = "application/vnd.docker.distribution.manifest.v1+json"
+)
+
+// DockerRegistryV2 - Serves the registry with the docker registry v2 API.
+// The catalog is paginated with Link headers, the manifests are schema 1
+// with the spec in the labels of the image config. When the registry has
+// credentials they are exchanged for a bearer token at /token.
+func DockerRegistryV2(t *testing.T, r Registry) *httptest.Server {
+	images := map[string]Image{}
+	names := []string{}
+	for _, image := range r.Images {
+		images[image.Name] = image
+		names = append(names, image.Name)
+	}
+	sort.Strings(names)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" {
+			t.Errorf("Expected `GET` request, got `%s`", req.Method)
+		}
+		path := req.URL.Path
+		if path == "/token" {
+			user, pass, ok := req.BasicAuth()
+			if !ok || user != r.User || pass != r.Pass {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprintf(w, `{"token": %q}`, registryV2Token)
+			return
+		}
+		if !strings.HasPrefix(path, "/v2/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.User != "" && req.Header.Get("Authorization") != "Bearer "+registryV2Token {
+			w.Header().Set("Www-Authenticate",
+				fmt.Sprintf(`Bearer realm="http://%v/token",service="conformance"`, req.Host))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case path == "/v2/_catalog":
+			serveCatalog(w, req, names, r.PageSize)
+		case strings.Contains(path, "/manifests/"):
+			name := strings.TrimPrefix(strings.Split(path, "/manifests/")[0], "/v2/")
+			image, ok := images[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", registryV2Schema)
+			json.NewEncoder(w).Encode(manifest(image))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// serveCatalog - the page of the catalog after the last image of the
+// request, with a Link to the next page.
+func serveCatalog(w http.ResponseWriter, req *http.Request, names []string, pageSize int) {
+	page := names
+	if last := req.URL.Query().Get("last"); last != "" {
+		i := sort.SearchStrings(page, last)
+		if i < len(page) && page[i] == last {
+			i++
+		}
+		page = page[i:]
+	}
+	n := pageSize
+	if v, err := strconv.Atoi(req.URL.Query().Get("n")); err == nil && v > 0 && (n == 0 || v < n) {
+		n = v
+	}
+	if n > 0 && len(page) > n {
+		page = page[:n]
+		w.Header().Set("Link", fmt.Sprintf(`</v2/_catalog?last=%v&n=%d>; rel="next"`, page[n-1], n))
+	}
+	json.NewEncoder(w).Encode(map[string][]string{"repositories": page})
+}
+
+// manifest - a schema 1 manifest of the image, the config of the image is
+// the v1Compatibility of the first history entry.
+func manifest(image Image) map[string]interface{} {
+	labels := map[string]string{"com.redhat.bundle.runtime": "2"}
+	switch {
+	case image.Label != "":
+		labels["com.redhat.apb.spec"] = image.Label
+	case image.Spec != "":
+		labels["com.redhat.apb.spec"] = b64.StdEncoding.EncodeToString([]byte(image.Spec))
+	}
+	config, _ := json.Marshal(map[string]interface{}{
+		"architecture": "amd64",
+		"os":           "linux",
+		"config":       map[string]interface{}{"Labels": labels},
+	})
+	return map[string]interface{}{
+		"schemaVersion": 1,
+		"name":          image.Name,
+		"tag":           "latest",
+		"architecture":  "amd64",
+		"history":       []map[string]string{{"v1Compatibility": string(config)}},
+	}
+}
@@ -21,11 +21,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/automationbroker/bundle-lib/bundle"
-	log "github.com/sirupsen/logrus"
+	log "github.com/automationbroker/bundle-lib/logging"
 )
 
 var (
@@ -43,8 +43,9 @@ type DockerHubAdapter struct {
 
 // DockerHubImage - Image from a dockerhub registry.
 type DockerHubImage struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace"`
+	LastUpdated string `json:"last_updated"`
 }
 
 // DockerHubImageResponse - Image response for dockerhub.
@@ -122,6 +123,51 @@ func (r DockerHubAdapter) FetchSpecs(imageNames []string) ([]*bundle.Spec, error
 	return specs, nil
 }
 
+// LastPushed - the time the repositories of the org were last updated.
+func (r DockerHubAdapter) LastPushed(imageNames []string) (map[string]time.Time, error) {
+	token, err := r.getDockerHubToken()
+	if err != nil {
+		log.Errorf("unable to generate docker hub token - %v", err)
+		return nil, err
+	}
+	wanted := map[string]bool{}
+	for _, name := range imageNames {
+		wanted[name] = true
+	}
+	pushed := map[string]time.Time{}
+	for next := fmt.Sprintf(dockerHubRepoImages, r.Config.Org); next != ""; {
+		req, err := http.NewRequest("GET", next, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("JWT %v", token))
+		resp, err := r.Config.httpClient().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		iResp := DockerHubImageResponse{}
+		err = decodeResponse(resp, maxResponseSize, &iResp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range iResp.Results {
+			name := fmt.Sprintf("%v/%v", image.Namespace, image.Name)
+			if !wanted[name] || image.LastUpdated == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, image.LastUpdated)
+			if err != nil {
+				log.Debugf("unable to parse the last update %v of %v - %v", image.LastUpdated, name, err)
+				continue
+			}
+			pushed[name] = t
+		}
+		next = iResp.Next
+	}
+	return pushed, nil
+}
+
 // getDockerHubToken - will retrieve the docker hub token.
 func (r DockerHubAdapter) getDockerHubToken() (string, error) {
 	type TokenResponse struct {
@@ -143,16 +189,14 @@ func (r DockerHubAdapter) getDockerHubToken() (string, error) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.Config.httpClient().Do(req)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
-	jsonToken, err := ioutil.ReadAll(resp.Body)
-
 	tokenResp := TokenResponse{}
-	err = json.Unmarshal(jsonToken, &tokenResp)
+	err = decodeResponse(resp, maxResponseSize, &tokenResp)
 	if err != nil {
 		return "", err
 	}
@@ -174,7 +218,7 @@ func (r DockerHubAdapter) getNextImages(ctx context.Context,
 
 	req.Header.Set("Authorization", fmt.Sprintf("JWT %v", token))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.Config.httpClient().Do(req)
 	if err != nil {
 		log.Errorf("unable to get next images for url: %v - %v", url, err)
 		cancelFunc()
@@ -183,10 +227,8 @@ func (r DockerHubAdapter) getNextImages(ctx context.Context,
 	}
 	defer resp.Body.Close()
 
-	imageList, err := ioutil.ReadAll(resp.Body)
-
 	iResp := DockerHubImageResponse{}
-	err = json.Unmarshal(imageList, &iResp)
+	err = decodeResponse(resp, maxResponseSize, &iResp)
 	if err != nil {
 		log.Errorf("unable to get next images for url: %v - %v", url, err)
 		cancelFunc()
@@ -216,6 +258,11 @@ func (r DockerHubAdapter) getNextImages(ctx context.Context,
 	return &iResp, nil
 }
 
+// FetchSpec - retrieve the spec for the image name.
+func (r DockerHubAdapter) FetchSpec(imageName string) (*bundle.Spec, error) {
+	return r.loadSpec(imageName)
+}
+
 func (r DockerHubAdapter) loadSpec(imageName string) (*bundle.Spec, error) {
 	if r.Config.Tag == "" {
 		r.Config.Tag = "latest"
@@ -234,7 +281,7 @@ func (r DockerHubAdapter) loadSpec(imageName string) (*bundle.Spec, error) {
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
 	req.Header.Add("Accept", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.Config.httpClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -265,7 +312,7 @@ func (r DockerHubAdapter) getBearerToken(imageName string) (string, error) {
 		}
 		req.SetBasicAuth(r.Config.User, r.Config.Pass)
 	}
-	response, err := http.DefaultClient.Do(req)
+	response, err := r.Config.httpClient().Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -273,7 +320,7 @@ func (r DockerHubAdapter) getBearerToken(imageName string) (string, error) {
 	t := struct {
 		Token string `json:"token"`
 	}{}
-	err = json.NewDecoder(response.Body).Decode(&t)
+	err = decodeResponse(response, maxResponseSize, &t)
 	if err != nil {
 		return "", err
 	}
@@ -186,14 +186,16 @@ func TestFetchSpecs(t *testing.T) {
 							Free:        true,
 							Parameters: []bundle.ParameterDescriptor{
 								{
-									Name:        "vncpass",
-									Title:       "VNC Password",
-									Type:        "string",
-									DisplayType: "password",
-									Minimum:     bundleNilableNumber(2),
-									Maximum:     bundleNilableNumber(10),
-									Required:    true,
-									Updatable:   true,
+									Name:          "vncpass",
+									Title:         "VNC Password",
+									Type:          "string",
+									DisplayType:   "password",
+									Minimum:       bundleNilableNumber(2),
+									Maximum:       bundleNilableNumber(10),
+									MinimumNumber: bundle.IntNumber(2),
+									MaximumNumber: bundle.IntNumber(10),
+									Required:      true,
+									Updatable:     true,
 								},
 							},
 						},
@@ -18,11 +18,9 @@ package adapters
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/automationbroker/bundle-lib/bundle"
-	log "github.com/sirupsen/logrus"
-	"io/ioutil"
+	log "github.com/automationbroker/bundle-lib/logging"
 	"net/http"
 	"net/url"
 	"strings"
@@ -171,7 +169,7 @@ func (r GalaxyAdapter) getNextImages(ctx context.Context,
 		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.Config.httpClient().Do(req)
 	if err != nil {
 		log.Errorf("unable to get next roles for url: %v - %v", url, err)
 		cancelFunc()
@@ -180,10 +178,8 @@ func (r GalaxyAdapter) getNextImages(ctx context.Context,
 	}
 	defer resp.Body.Close()
 
-	imageList, err := ioutil.ReadAll(resp.Body)
-
 	iResp := GalaxySearchResponse{}
-	err = json.Unmarshal(imageList, &iResp)
+	err = decodeResponse(resp, maxResponseSize, &iResp)
 	if err != nil {
 		log.Errorf("unable to get next images for url: %v - %v", url, err)
 		cancelFunc()
@@ -214,6 +210,11 @@ func (r GalaxyAdapter) getNextImages(ctx context.Context,
 	return &iResp, nil
 }
 
+// FetchSpec - retrieve the spec for the image name.
+func (r GalaxyAdapter) FetchSpec(imageName string) (*bundle.Spec, error) {
+	return r.loadSpec(imageName)
+}
+
 func (r GalaxyAdapter) loadSpec(imageName string) (*bundle.Spec, error) {
 
 	imageSplit := strings.Split(imageName, "#")
@@ -228,17 +229,13 @@ func (r GalaxyAdapter) loadSpec(imageName string) (*bundle.Spec, error) {
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.Config.httpClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	role, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
 	roleResp := GalaxyRoleResponse{}
-	err = json.Unmarshal(role, &roleResp)
+	err = decodeResponse(resp, maxResponseSize, &roleResp)
 	if err != nil {
 		return nil, err
 	}
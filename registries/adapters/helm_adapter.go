@@ -22,7 +22,6 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"path"
 	"sort"
 	"strings"
@@ -30,6 +29,7 @@ import (
 
 	"github.com/Masterminds/semver"
 	"github.com/automationbroker/bundle-lib/bundle"
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/ghodss/yaml"
 )
 
@@ -189,13 +189,13 @@ func (r *HelmAdapter) FetchSpecs(imageNames []string) ([]*bundle.Spec, error) {
 		chart := charts[0]
 
 		if len(chart.URLs) > 0 {
-			resp, err := http.Get(chart.URLs[0])
+			resp, err := r.Config.httpClient().Get(chart.URLs[0])
 			if err != nil {
 				continue
 			}
 			defer resp.Body.Close()
 
-			values = r.loadArchive(resp.Body)
+			values = r.loadArchive(io.LimitReader(resp.Body, maxResponseSize))
 		}
 
 		// Convert chart to Bundle Spec
@@ -261,6 +261,16 @@ func (r *HelmAdapter) FetchSpecs(imageNames []string) ([]*bundle.Spec, error) {
 			},
 		}
 
+		// Without a runner image the chart is run with the helm CLI.
+		if r.Config.Runner == "" {
+			spec.Image = bundle.DefaultHelmImage
+			spec.HelmChart = &bundle.HelmChart{
+				Repo:    r.Config.URL.String(),
+				Name:    chart.Name,
+				Version: chart.Version,
+			}
+		}
+
 		specs = append(specs, spec)
 	}
 
@@ -273,13 +283,13 @@ func (r *HelmAdapter) getHelmIndex() (*IndexFile, error) {
 	index := &IndexFile{}
 
 	url := strings.TrimSuffix(r.Config.URL.String(), "/") + helmIndexPath
-	resp, err := http.Get(url)
+	resp, err := r.Config.httpClient().Get(url)
 	if err != nil {
 		return index, err
 	}
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := readResponse(resp, maxHelmIndexSize)
 	if err != nil {
 		return index, err
 	}
@@ -319,7 +329,11 @@ func (r *HelmAdapter) loadArchive(in io.Reader) string {
 			return ""
 		}
 		if valuesMatch {
-			data, err := ioutil.ReadAll(tr)
+			if hdr.Size > maxResponseSize {
+				log.Warnf("values file %v exceeds the limit of %d bytes", hdr.Name, maxResponseSize)
+				return ""
+			}
+			data, err := ioutil.ReadAll(io.LimitReader(tr, maxResponseSize))
 			if err != nil {
 				return ""
 			}
@@ -25,6 +25,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/automationbroker/bundle-lib/bundle"
 	ft "github.com/stretchr/testify/assert"
 )
 
@@ -127,4 +128,18 @@ func TestHelmFetchSpecs(t *testing.T) {
 	ft.Equal(t, spec.Version, "1.0")
 	ft.Equal(t, spec.Image, "runner_image")
 	ft.Equal(t, spec.Metadata["displayName"], "mariadb (Helm)")
+	ft.Nil(t, spec.HelmChart)
+
+	// Without a runner the chart is run with the helm CLI
+	ha.Config.Runner = ""
+	specs, err = ha.FetchSpecs(imageNames)
+	if err != nil {
+		t.Fatal("ERROR: ", err)
+	}
+	ft.Equal(t, specs[0].Image, bundle.DefaultHelmImage)
+	ft.Equal(t, specs[0].HelmChart, &bundle.HelmChart{
+		Repo:    url.String(),
+		Name:    MariaDB,
+		Version: ha.Charts[MariaDB][0].Version,
+	})
 }
@@ -26,14 +26,14 @@ import (
 
 	"github.com/automationbroker/bundle-lib/bundle"
 	"github.com/automationbroker/bundle-lib/clients"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 	v1image "github.com/openshift/api/image/v1"
-	log "github.com/sirupsen/logrus"
-	yaml "gopkg.in/yaml.v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var (
-	errRuntimeNotFound = errors.New("runtime not found")
+	errRuntimeNotFound error = liberrors.New(liberrors.CodeNotFound, "runtime not found")
 )
 
 type containerConfig struct {
@@ -144,22 +144,22 @@ func (r LocalOpenShiftAdapter) loadSpec(image v1image.Image) (*bundle.Spec, erro
 	}
 	spec := &bundle.Spec{}
 
-	metadataSpec := i.ContainerConfig.Labels.Spec
-	if metadataSpec == "" {
+	labels := i.ContainerConfig.Labels
+	if labels.Spec == "" {
 		log.Debugf("Failed to find spec label in containerConfig metadata, checking Config")
 		if i.Config.Labels.Spec == "" {
 			log.Errorf("Failed to find spec label")
 			return nil, errors.New("spec label not found in metadata")
 		}
-		metadataSpec = i.Config.Labels.Spec
+		labels = i.Config.Labels
 	}
 
-	decodedSpecYaml, err := b64.StdEncoding.DecodeString(metadataSpec)
+	decodedSpecYaml, err := b64.StdEncoding.DecodeString(labels.Spec)
 	if err != nil {
 		log.Errorf("Failed to decode spec: %v", err)
 		return nil, err
 	}
-	err = yaml.Unmarshal([]byte(decodedSpecYaml), spec)
+	spec, err = bundle.UnmarshalSpecYAML(decodedSpecYaml, nil)
 	if err != nil {
 		log.Errorf("Something went wrong loading decoded spec yaml, %s", err)
 		return nil, err
@@ -177,6 +177,8 @@ func (r LocalOpenShiftAdapter) loadSpec(image v1image.Image) (*bundle.Spec, erro
 	} else {
 		spec.Image = strings.Split(image.DockerImageReference, "@")[0]
 	}
+	// the signature label is read from the same labels as the spec
+	spec.Provenance = specProvenance("", labels.SpecSignature, decodedSpecYaml)
 
 	return spec, nil
 }
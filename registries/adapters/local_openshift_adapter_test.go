@@ -17,12 +17,34 @@
 package adapters
 
 import (
+	"fmt"
 	"testing"
 
+	v1image "github.com/openshift/api/image/v1"
 	ft "github.com/stretchr/testify/assert"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 )
 
 func TestLocalOpenshiftName(t *testing.T) {
 	loa := LocalOpenShiftAdapter{}
 	ft.Equal(t, loa.RegistryName(), "openshift-registry", "local_openshift name does not match openshift-registry")
 }
+
+func TestLocalOpenshiftLoadSpecSignature(t *testing.T) {
+	image := v1image.Image{
+		DockerImageReference: "172.30.1.1:5000/openshift/etherpad-apb@sha256:abc",
+		DockerImageMetadata: k8sruntime.RawExtension{Raw: []byte(fmt.Sprintf(
+			`{"Config":{"Labels":{"com.redhat.apb.spec":"%s","com.redhat.apb.spec.signature":"c2lnbmF0dXJl"}}}`,
+			testApbSpec))},
+	}
+	spec, err := LocalOpenShiftAdapter{}.loadSpec(image)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	ft.Equal(t, "172.30.1.1:5000/openshift/etherpad-apb", spec.Image)
+	if spec.Provenance == nil {
+		t.Fatalf("expected the spec signature to be kept")
+	}
+	ft.Equal(t, "c2lnbmF0dXJl", spec.Provenance.SpecSignature)
+	ft.NotEmpty(t, spec.Provenance.SignedContent)
+}
@@ -18,9 +18,10 @@ package adapters
 
 import (
 	"io/ioutil"
+	"path/filepath"
 
 	"github.com/automationbroker/bundle-lib/bundle"
-	log "github.com/sirupsen/logrus"
+	log "github.com/automationbroker/bundle-lib/logging"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -46,6 +47,13 @@ func (r *MockAdapter) GetImageNames() ([]string, error) {
 		return nil, err
 	}
 
+	// The includes of the specs are read next to the mock file.
+	specYaml, err = bundle.ResolveSpecYAML(specYaml, bundle.DirIncludeResolver(filepath.Dir(MockFile)))
+	if err != nil {
+		log.Errorf("Failed to resolve the includes of %s", MockFile)
+		return nil, err
+	}
+
 	var parsedData struct {
 		Apps []*bundle.Spec `yaml:"apps"`
 	}
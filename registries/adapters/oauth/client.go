@@ -18,20 +18,24 @@ package oauth
 
 import (
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"regexp"
 	"sync"
-	"time"
 
-	log "github.com/sirupsen/logrus"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 )
 
+// maxTokenResponseSize - the largest token service response the client
+// will read.
+const maxTokenResponseSize int64 = 1 << 20
+
 // oauth2Response - holds the response data from an oauth2 token request
 type oauth2Response struct {
 	Token string `json:"access_token"`
@@ -45,20 +49,18 @@ type dockerResponse struct {
 // NewClient - creates and returns a *Client ready to use. If skipVerify is
 // true, it will skip verification of the remote TLS certificate.
 func NewClient(user, pass string, skipVerify bool, url *url.URL) *Client {
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: skipVerify},
-		Proxy:           http.ProxyFromEnvironment,
-	}
-	if skipVerify == true {
-		log.Warn("skipping verification of registry TLS certificate per adapter configuration")
-	}
+	return NewClientWithTransport(user, pass, url, TransportConfig{SkipVerifyTLS: skipVerify})
+}
 
+// NewClientWithTransport - creates and returns a *Client ready to use,
+// using the shared transport for the transport config.
+func NewClientWithTransport(user, pass string, url *url.URL, config TransportConfig) *Client {
 	return &Client{
 		user:   user,
 		pass:   pass,
 		url:    url,
 		mutex:  &sync.Mutex{},
-		client: &http.Client{Timeout: time.Second * 60, Transport: transport},
+		client: NewHTTPClient(config),
 	}
 }
 
@@ -147,7 +149,7 @@ func (c *Client) Getv2WithScope(imageNames []string) error {
 		if tokenResp.StatusCode != http.StatusOK {
 			msg := fmt.Sprintf("Token not accepted by /v2/ - %s", tokenResp.Status)
 			log.Warn(msg)
-			return errors.New(msg)
+			return liberrors.FromHTTPStatus(tokenResp.StatusCode, msg)
 		}
 		log.Debug("GET /v2/ successful with new token")
 
@@ -161,7 +163,7 @@ func (c *Client) Getv2WithScope(imageNames []string) error {
 	default:
 		msg := fmt.Sprintf("Bad response from /v2/ - %s", resp.Status)
 		log.Warn(msg)
-		return errors.New(msg)
+		return liberrors.FromHTTPStatus(resp.StatusCode, msg)
 	}
 	return nil
 }
@@ -219,13 +221,23 @@ func (c *Client) getTokenWithScope(wwwauth string, imageNames []string) error {
 	if resp.StatusCode != http.StatusOK {
 		msg := fmt.Sprintf("token service responded: %s", resp.Status)
 		log.Warn(msg)
+		return liberrors.FromHTTPStatus(resp.StatusCode, msg)
+	}
+	if resp.ContentLength > maxTokenResponseSize {
+		msg := fmt.Sprintf("token service response of %d bytes exceeds the limit of %d bytes", resp.ContentLength, maxTokenResponseSize)
+		log.Warn(msg)
 		return errors.New(msg)
 	}
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxTokenResponseSize+1))
 	if err != nil {
 		log.Warnf("failed to read token body: %s", err.Error())
 		return err
 	}
+	if int64(len(body)) > maxTokenResponseSize {
+		msg := fmt.Sprintf("token service response exceeds the limit of %d bytes", maxTokenResponseSize)
+		log.Warn(msg)
+		return errors.New(msg)
+	}
 
 	c.token, err = parseAuthToken(body)
 	if err != nil {
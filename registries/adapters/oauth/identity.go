@@ -0,0 +1,113 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package oauth
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultCorrelationHeader - the header carrying the correlation ID
+	// when the RequestIdentity does not name one.
+	DefaultCorrelationHeader = "X-Request-ID"
+	// userAgentProduct - the product token of the library in the
+	// User-Agent.
+	userAgentProduct = "bundle-lib"
+)
+
+// Version - the version of the library reported in the User-Agent, set at
+// build time with -ldflags "-X ...oauth.Version=<version>".
+var Version = "dev"
+
+// RequestIdentity - How the requests to a registry identify themselves,
+// so that registries can allow-list the broker and requests can be traced
+// on the server side. It is shared by the requests of an adapter and is
+// safe for concurrent use.
+type RequestIdentity struct {
+	// UserAgent - the product put before the one of the library in the
+	// User-Agent, e.g. the broker name and version.
+	UserAgent string
+	// CorrelationHeader - the header carrying the correlation ID,
+	// DefaultCorrelationHeader when empty.
+	CorrelationHeader string
+
+	mutex         sync.Mutex
+	correlationID string
+}
+
+// SetCorrelationID - the ID sent with the following requests, e.g. one ID
+// per catalog load. An empty ID is not sent.
+func (i *RequestIdentity) SetCorrelationID(id string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.correlationID = id
+}
+
+// CorrelationID - the ID sent with the requests.
+func (i *RequestIdentity) CorrelationID() string {
+	if i == nil {
+		return ""
+	}
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.correlationID
+}
+
+// userAgent - the User-Agent of the requests.
+func (i *RequestIdentity) userAgent() string {
+	ua := userAgentProduct + "/" + Version
+	if i == nil || strings.TrimSpace(i.UserAgent) == "" {
+		return ua
+	}
+	return strings.TrimSpace(i.UserAgent) + " " + ua
+}
+
+func (i *RequestIdentity) correlationHeader() string {
+	if i == nil || i.CorrelationHeader == "" {
+		return DefaultCorrelationHeader
+	}
+	return i.CorrelationHeader
+}
+
+// identityTransport - an http.RoundTripper adding the User-Agent and the
+// correlation ID to the requests, unless they already have them.
+type identityTransport struct {
+	base     http.RoundTripper
+	identity *RequestIdentity
+}
+
+// RoundTrip - sends a copy of the request with the identity headers.
+func (t *identityTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+2)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	if r.Header.Get("User-Agent") == "" {
+		r.Header.Set("User-Agent", t.identity.userAgent())
+	}
+	if id := t.identity.CorrelationID(); id != "" {
+		header := t.identity.correlationHeader()
+		if r.Header.Get(header) == "" {
+			r.Header.Set(header, id)
+		}
+	}
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityTransport(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name          string
+		identity      *RequestIdentity
+		correlationID string
+		header        http.Header
+		userAgent     string
+		correlation   map[string]string
+	}{
+		{
+			name:      "no identity",
+			userAgent: "bundle-lib/" + Version,
+		},
+		{
+			name:          "broker name and correlation id",
+			identity:      &RequestIdentity{UserAgent: "ansible-service-broker/1.4"},
+			correlationID: "3f8a",
+			userAgent:     "ansible-service-broker/1.4 bundle-lib/" + Version,
+			correlation:   map[string]string{DefaultCorrelationHeader: "3f8a"},
+		},
+		{
+			name:          "custom correlation header",
+			identity:      &RequestIdentity{CorrelationHeader: "X-Correlation-ID"},
+			correlationID: "3f8a",
+			userAgent:     "bundle-lib/" + Version,
+			correlation:   map[string]string{"X-Correlation-ID": "3f8a", DefaultCorrelationHeader: ""},
+		},
+		{
+			name:          "headers of the request are kept",
+			identity:      &RequestIdentity{UserAgent: "broker"},
+			correlationID: "3f8a",
+			header:        http.Header{"User-Agent": {"custom"}, DefaultCorrelationHeader: {"caller"}},
+			userAgent:     "custom",
+			correlation:   map[string]string{DefaultCorrelationHeader: "caller"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.correlationID != "" {
+				tc.identity.SetCorrelationID(tc.correlationID)
+			}
+			client := NewHTTPClient(TransportConfig{Identity: tc.identity})
+			req, err := http.NewRequest("GET", server.URL, nil)
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			for k, v := range tc.header {
+				req.Header.Set(k, v[0])
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			resp.Body.Close()
+
+			assert.Equal(t, tc.userAgent, received.Get("User-Agent"))
+			for header, value := range tc.correlation {
+				assert.Equal(t, value, received.Get(header))
+			}
+			// the request of the caller is not modified
+			assert.Equal(t, tc.header.Get("User-Agent"), req.Header.Get("User-Agent"))
+		})
+	}
+}
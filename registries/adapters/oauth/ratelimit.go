@@ -0,0 +1,111 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package oauth
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clock"
+)
+
+// RateLimiter - A token bucket limiting the requests to a registry on the
+// client side. It is shared by the requests of the adapters of a registry
+// and is safe for concurrent use. A nil RateLimiter does not limit.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter - a limiter allowing rate requests per second with bursts
+// of up to burst requests. The burst defaults to the rate rounded up. A
+// rate that is not positive does not limit and returns nil.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// reserve - takes a token at now and returns how long to wait before the
+// request can be sent. The tokens go negative while requests are waiting,
+// so the waits of concurrent requests add up.
+func (l *RateLimiter) reserve(now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Wait - blocks until the request can be sent or it is cancelled.
+func (l *RateLimiter) Wait(req *http.Request) error {
+	return l.wait(req, clock.Real)
+}
+
+// wait - Wait with the time of the clock.
+func (l *RateLimiter) wait(req *http.Request, clk clock.Clock) error {
+	if l == nil {
+		return nil
+	}
+	wait := l.reserve(clk.Now())
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-clk.After(wait):
+		return nil
+	}
+}
+
+// rateLimitTransport - an http.RoundTripper sending the requests of the
+// wrapped transport at the rate of the limiter. A nil clock uses
+// clock.Real.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	limiter *RateLimiter
+	clock   clock.Clock
+}
+
+// RoundTrip - waits for the limiter and sends the request.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.wait(req, clock.OrReal(t.clock)); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
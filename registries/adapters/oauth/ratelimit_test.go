@@ -0,0 +1,99 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	assert.Nil(t, NewRateLimiter(0, 10))
+
+	now := time.Now()
+	l := NewRateLimiter(2, 2)
+
+	// the burst is sent at once
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+	// then the requests are spaced at the rate
+	assert.Equal(t, 500*time.Millisecond, l.reserve(now))
+	assert.Equal(t, time.Second, l.reserve(now))
+
+	now = now.Add(10 * time.Second)
+	// the tokens refill up to the burst
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+	assert.Equal(t, 500*time.Millisecond, l.reserve(now))
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer serv.Close()
+
+	limiter := NewRateLimiter(1, 1)
+	client := &http.Client{Transport: &rateLimitTransport{base: http.DefaultTransport, limiter: limiter}}
+	resp, err := client.Get(serv.URL)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	resp.Body.Close()
+
+	// the next request waits a second, longer than the context allows
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequest(http.MethodGet, serv.URL, nil)
+	_, err = client.Do(req.WithContext(ctx))
+	assert.Error(t, err)
+}
+
+func TestRateLimitTransportClock(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer serv.Close()
+
+	fake := clock.NewFake(time.Now())
+	limiter := NewRateLimiter(1, 1)
+	client := &http.Client{Transport: &rateLimitTransport{base: http.DefaultTransport, limiter: limiter, clock: fake}}
+	resp, err := client.Get(serv.URL)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	resp.Body.Close()
+
+	done := make(chan error)
+	go func() {
+		resp, err := client.Get(serv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+	// the next request waits a second of the clock
+	for fake.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	fake.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+}
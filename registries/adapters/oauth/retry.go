@@ -0,0 +1,171 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package oauth
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clock"
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+// RetryPolicy - How registry requests are retried. Requests are retried
+// when they fail to connect or the registry responds with a status that
+// can be transient, see RetryableStatus.
+type RetryPolicy struct {
+	// Attempts - the maximum number of attempts, at least one attempt is
+	// made.
+	Attempts int
+	// Backoff - the wait before the first retry, it doubles for every
+	// following retry.
+	Backoff time.Duration
+	// MaxBackoff - the longest wait between attempts, also bounds the
+	// Retry-After the registry asks for.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy - the retry policy used when the TransportConfig
+// does not set one.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:   3,
+	Backoff:    500 * time.Millisecond,
+	MaxBackoff: 10 * time.Second,
+}
+
+// ErrorRequestFailed - A registry request still failed to get a response
+// after all the attempts.
+type ErrorRequestFailed struct {
+	Method   string
+	URL      string
+	Attempts int
+	Err      error
+}
+
+func (e ErrorRequestFailed) Error() string {
+	return fmt.Sprintf("%v %v failed after %d attempts - %v", e.Method, e.URL, e.Attempts, e.Err)
+}
+
+// RetryableStatus - true if a response with the status code can succeed
+// when the request is retried.
+func RetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryTransport - an http.RoundTripper retrying the requests of the
+// wrapped transport according to the policy. The backoff is waited on the
+// clock, nil uses clock.Real.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+	clock  clock.Clock
+}
+
+// RoundTrip - sends the request until the registry gives a response that
+// is not retryable or the attempts are used up. The last response is
+// returned so the caller handles its status as usual, when no response
+// was received an ErrorRequestFailed is returned.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := t.policy.Attempts
+	if attempts < 1 || !replayable(req) {
+		attempts = 1
+	}
+	backoff := t.policy.Backoff
+
+	for attempt := 1; ; attempt++ {
+		r, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.base.RoundTrip(r)
+		if err == nil && !RetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if attempt >= attempts {
+			if err != nil {
+				return nil, ErrorRequestFailed{Method: req.Method, URL: req.URL.String(), Attempts: attempt, Err: err}
+			}
+			return resp, nil
+		}
+
+		wait := backoff
+		if err != nil {
+			log.Debugf("attempt %d of %v %v failed - %v", attempt, req.Method, req.URL, err)
+		} else {
+			log.Debugf("attempt %d of %v %v returned %v", attempt, req.Method, req.URL, resp.Status)
+			if d, ok := retryAfter(resp); ok {
+				wait = d
+			}
+			// drain the body so the connection can be reused
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if t.policy.MaxBackoff > 0 && wait > t.policy.MaxBackoff {
+			wait = t.policy.MaxBackoff
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-clock.OrReal(t.clock).After(wait):
+		}
+		backoff *= 2
+	}
+}
+
+// replayable - true if the body of the request can be sent again.
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewind - returns the request to send for the attempt, a copy with a
+// fresh body for every attempt after the first.
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r := req.WithContext(req.Context())
+	r.Body = body
+	return r, nil
+}
+
+// retryAfter - the wait the registry asked for in the Retry-After header
+// in seconds.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,152 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryTransport(t *testing.T) {
+	testCases := []struct {
+		name             string
+		statuses         []int
+		attempts         int
+		expectedStatus   int
+		expectedRequests int
+	}{
+		{
+			name:             "success on the first attempt",
+			statuses:         []int{http.StatusOK},
+			attempts:         3,
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 1,
+		},
+		{
+			name:             "transient bad gateway",
+			statuses:         []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			attempts:         3,
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 3,
+		},
+		{
+			name:             "attempts used up",
+			statuses:         []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusOK},
+			attempts:         2,
+			expectedStatus:   http.StatusBadGateway,
+			expectedRequests: 2,
+		},
+		{
+			name:             "not retryable",
+			statuses:         []int{http.StatusNotFound, http.StatusOK},
+			attempts:         3,
+			expectedStatus:   http.StatusNotFound,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statuses[requests])
+				requests++
+			}))
+			defer serv.Close()
+
+			client := &http.Client{Transport: &retryTransport{
+				base:   http.DefaultTransport,
+				policy: RetryPolicy{Attempts: tc.attempts, Backoff: time.Millisecond},
+			}}
+			resp, err := client.Get(serv.URL)
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			resp.Body.Close()
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			assert.Equal(t, tc.expectedRequests, requests)
+		})
+	}
+}
+
+type failingTransport struct {
+	requests int
+}
+
+func (f *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.requests++
+	return nil, errors.New("connection refused")
+}
+
+func TestRetryTransportRequestFailed(t *testing.T) {
+	base := &failingTransport{}
+	transport := &retryTransport{base: base, policy: RetryPolicy{Attempts: 3, Backoff: time.Millisecond}}
+	req, err := http.NewRequest("POST", "http://registry.example.com/v2/", strings.NewReader("body"))
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	_, err = transport.RoundTrip(req)
+	failed, ok := err.(ErrorRequestFailed)
+	assert.True(t, ok, "expected a request failed error, got %v", err)
+	assert.Equal(t, 3, failed.Attempts)
+	assert.Equal(t, 3, base.requests)
+}
+
+func TestRetryTransportClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	base := &failingTransport{}
+	transport := &retryTransport{base: base, policy: RetryPolicy{Attempts: 2, Backoff: time.Hour}, clock: fake}
+	req, err := http.NewRequest("GET", "http://registry.example.com/v2/", nil)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := transport.RoundTrip(req)
+		done <- err
+	}()
+	// the backoff only expires when the clock is advanced
+	for fake.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 1, base.requests)
+	fake.Advance(time.Hour)
+	err = <-done
+	failed, ok := err.(ErrorRequestFailed)
+	assert.True(t, ok, "expected a request failed error, got %v", err)
+	assert.Equal(t, 2, failed.Attempts)
+}
+
+func TestRetryAfter(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	_, ok := retryAfter(resp)
+	assert.False(t, ok)
+
+	resp.Header.Set("Retry-After", "2")
+	d, ok := retryAfter(resp)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, d)
+}
@@ -0,0 +1,141 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package oauth
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clock"
+	log "github.com/automationbroker/bundle-lib/logging"
+	"golang.org/x/net/http2"
+)
+
+const (
+	// DefaultMaxIdleConnsPerHost - the idle connections kept per registry
+	// host when the TransportConfig does not set it.
+	DefaultMaxIdleConnsPerHost = 10
+	// DefaultIdleConnTimeout - how long idle connections are kept when the
+	// TransportConfig does not set it.
+	DefaultIdleConnTimeout = 90 * time.Second
+	// clientTimeout - the timeout of a whole registry request.
+	clientTimeout = 60 * time.Second
+)
+
+// TransportConfig - The connection tuning of the HTTP transport used to
+// talk to the registries.
+type TransportConfig struct {
+	SkipVerifyTLS       bool
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	// DisableHTTP2 - only use HTTP/1.1, even if the registry supports
+	// HTTP/2.
+	DisableHTTP2 bool
+	// Retry - how the requests are retried, DefaultRetryPolicy when not
+	// set. It does not affect the shared transport.
+	Retry RetryPolicy
+	// Identity - the User-Agent and correlation ID of the requests. When
+	// nil the requests only carry the User-Agent of the library. It does
+	// not affect the shared transport.
+	Identity *RequestIdentity
+	// RateLimiter - limits every attempt of the requests, shared by the
+	// clients of a registry. When nil the requests are not limited. It
+	// does not affect the shared transport.
+	RateLimiter *RateLimiter
+	// Clock - the time of the retry backoff and of the rate limiter, nil
+	// uses clock.Real. It does not affect the shared transport.
+	Clock clock.Clock
+}
+
+var (
+	transports     = map[TransportConfig]*http.Transport{}
+	transportMutex sync.Mutex
+)
+
+// SharedTransport - returns the transport for the config. Transports are
+// shared between every client with the same config so connections, and
+// their TLS handshakes, are reused across requests and adapters.
+func SharedTransport(config TransportConfig) *http.Transport {
+	if config.MaxIdleConnsPerHost <= 0 {
+		config.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if config.IdleConnTimeout <= 0 {
+		config.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+
+	// the retry policy, identity, rate limiter and clock are applied on
+	// top of the shared transport
+	config.Retry = RetryPolicy{}
+	config.Identity = nil
+	config.RateLimiter = nil
+	config.Clock = nil
+
+	transportMutex.Lock()
+	defer transportMutex.Unlock()
+	if t, ok := transports[config]; ok {
+		return t
+	}
+
+	if config.SkipVerifyTLS {
+		log.Warn("skipping verification of registry TLS certificate per adapter configuration")
+	}
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       &tls.Config{InsecureSkipVerify: config.SkipVerifyTLS},
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+	}
+	// a custom TLSClientConfig turns off the automatic HTTP/2 support of
+	// the transport, it has to be configured explicitly.
+	if !config.DisableHTTP2 {
+		if err := http2.ConfigureTransport(t); err != nil {
+			log.Warnf("unable to enable HTTP/2 for the registry transport - %v", err)
+		}
+	}
+	transports[config] = t
+	return t
+}
+
+// NewHTTPClient - returns an *http.Client identifying, retrying and rate
+// limiting its requests on top of the shared transport for the config.
+func NewHTTPClient(config TransportConfig) *http.Client {
+	policy := config.Retry
+	if policy == (RetryPolicy{}) {
+		policy = DefaultRetryPolicy
+	}
+	var base http.RoundTripper = SharedTransport(config)
+	if config.RateLimiter != nil {
+		base = &rateLimitTransport{base: base, limiter: config.RateLimiter, clock: config.Clock}
+	}
+	return &http.Client{
+		Timeout: clientTimeout,
+		Transport: &identityTransport{
+			base:     &retryTransport{base: base, policy: policy, clock: config.Clock},
+			identity: config.Identity,
+		},
+	}
+}
@@ -0,0 +1,48 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package oauth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedTransport(t *testing.T) {
+	transport := SharedTransport(TransportConfig{})
+	assert.Equal(t, DefaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, DefaultIdleConnTimeout, transport.IdleConnTimeout)
+	assert.False(t, transport.TLSClientConfig.InsecureSkipVerify)
+
+	// the defaults are applied before the lookup
+	assert.True(t, transport == SharedTransport(TransportConfig{MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost}))
+
+	skipVerify := SharedTransport(TransportConfig{SkipVerifyTLS: true, MaxIdleConnsPerHost: 20})
+	assert.False(t, transport == skipVerify)
+	assert.True(t, skipVerify.TLSClientConfig.InsecureSkipVerify)
+	assert.Equal(t, 20, skipVerify.MaxIdleConnsPerHost)
+}
+
+func TestNewClientsShareTransport(t *testing.T) {
+	first := NewClient("foo", "bar", false, nil)
+	second := NewClient("baz", "qux", false, nil)
+	base := func(c *Client) http.RoundTripper {
+		return c.client.Transport.(*identityTransport).base.(*retryTransport).base
+	}
+	assert.True(t, base(first) == base(second))
+}
@@ -0,0 +1,241 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package adapters
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+const (
+	olmName             = "olm"
+	olmPackagesURL      = "%v/apis/packages.operators.coreos.com/v1/namespaces/%v/packagemanifests"
+	olmDefaultNamespace = "openshift-marketplace"
+)
+
+// OLMAdapter - OLM Adapter, lists the operators of the OLM catalogs as
+// specs. The URL is the cluster API, the namespace is the first of the
+// Namespaces and the Org, when set, only keeps the packages of that
+// catalog source. The package manifests fetched by GetImageNames are reused
+// by the FetchSpecs calls of the same load.
+type OLMAdapter struct {
+	Config Configuration
+
+	mutex     sync.Mutex
+	manifests []olmPackageManifest
+}
+
+type olmPackageManifestList struct {
+	Items []olmPackageManifest `json:"items"`
+}
+
+type olmPackageManifest struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Status struct {
+		CatalogSource          string       `json:"catalogSource"`
+		CatalogSourceNamespace string       `json:"catalogSourceNamespace"`
+		PackageName            string       `json:"packageName"`
+		DefaultChannel         string       `json:"defaultChannel"`
+		Channels               []olmChannel `json:"channels"`
+	} `json:"status"`
+}
+
+type olmChannel struct {
+	Name           string `json:"name"`
+	CurrentCSV     string `json:"currentCSV"`
+	CurrentCSVDesc struct {
+		DisplayName string            `json:"displayName"`
+		Description string            `json:"description"`
+		Version     string            `json:"version"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"currentCSVDesc"`
+}
+
+// RegistryName - Retrieve the registry name
+func (r *OLMAdapter) RegistryName() string {
+	return olmName
+}
+
+// GetImageNames - retrieve the package names of the operators
+func (r *OLMAdapter) GetImageNames() ([]string, error) {
+	log.Debug("OLMAdapter::GetImageNames")
+	manifests, err := r.packageManifests()
+	if err != nil {
+		return nil, err
+	}
+	r.mutex.Lock()
+	r.manifests = manifests
+	r.mutex.Unlock()
+	names := []string{}
+	for _, m := range manifests {
+		names = append(names, m.Status.PackageName)
+	}
+	return names, nil
+}
+
+// FetchSpecs - retrieve the spec of the operator packages.
+func (r *OLMAdapter) FetchSpecs(names []string) ([]*bundle.Spec, error) {
+	log.Debug("OLMAdapter::FetchSpecs")
+	manifests, err := r.loadedManifests()
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]olmPackageManifest{}
+	for _, m := range manifests {
+		byName[m.Status.PackageName] = m
+	}
+	specs := []*bundle.Spec{}
+	for _, name := range names {
+		m, ok := byName[name]
+		if !ok {
+			log.Warnf("operator package %v is not in the catalog", name)
+			continue
+		}
+		spec := olmSpec(m)
+		if spec == nil {
+			log.Warnf("operator package %v has no channels", name)
+			continue
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// loadedManifests - the package manifests fetched by the last
+// GetImageNames, fetched now when there are none.
+func (r *OLMAdapter) loadedManifests() ([]olmPackageManifest, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.manifests != nil {
+		return r.manifests, nil
+	}
+	manifests, err := r.packageManifests()
+	if err != nil {
+		return nil, err
+	}
+	r.manifests = manifests
+	return manifests, nil
+}
+
+// packageManifests - the package manifests of the namespace, filtered by
+// the catalog source.
+func (r *OLMAdapter) packageManifests() ([]olmPackageManifest, error) {
+	namespace := olmDefaultNamespace
+	if len(r.Config.Namespaces) > 0 && r.Config.Namespaces[0] != "" {
+		namespace = r.Config.Namespaces[0]
+	}
+	packagesURL := fmt.Sprintf(olmPackagesURL, r.Config.URL, namespace)
+	req, err := http.NewRequest("GET", packagesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+	if r.Config.Token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", r.Config.Token))
+	}
+
+	resp, err := r.Config.httpClient().Do(req)
+	if err != nil {
+		log.Errorf("Failed to load package manifests at %s - %v", packagesURL, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, liberrors.FromHTTPStatus(resp.StatusCode, resp.Status)
+	}
+	list := olmPackageManifestList{}
+	if err := decodeResponse(resp, maxResponseSize, &list); err != nil {
+		log.Errorf("Failed to decode package manifests from '%s'", packagesURL)
+		return nil, err
+	}
+
+	manifests := []olmPackageManifest{}
+	for _, m := range list.Items {
+		if r.Config.Org != "" && m.Status.CatalogSource != r.Config.Org {
+			continue
+		}
+		if m.Status.PackageName == "" {
+			m.Status.PackageName = m.Metadata.Name
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+// olmSpec - the spec of an operator package, one plan per channel with the
+// default channel first.
+func olmSpec(m olmPackageManifest) *bundle.Spec {
+	if len(m.Status.Channels) == 0 {
+		return nil
+	}
+	channels := []olmChannel{}
+	var def *olmChannel
+	for i, c := range m.Status.Channels {
+		if c.Name == m.Status.DefaultChannel {
+			def = &m.Status.Channels[i]
+			continue
+		}
+		channels = append(channels, c)
+	}
+	if def == nil {
+		def = &m.Status.Channels[0]
+		channels = channels[1:]
+	}
+	channels = append([]olmChannel{*def}, channels...)
+
+	displayName := def.CurrentCSVDesc.DisplayName
+	if displayName == "" {
+		displayName = m.Status.PackageName
+	}
+	spec := &bundle.Spec{
+		Runtime:     2,
+		Version:     "1.0",
+		FQName:      m.Status.PackageName,
+		Image:       def.CurrentCSVDesc.Annotations["containerImage"],
+		Bindable:    false,
+		Description: def.CurrentCSVDesc.Description,
+		Async:       "required",
+		Tags:        []string{"operator"},
+		Metadata: map[string]interface{}{
+			"displayName": fmt.Sprintf("%v (Operator)", displayName),
+		},
+		Operator: &bundle.OperatorBundle{
+			Package:                m.Status.PackageName,
+			CatalogSource:          m.Status.CatalogSource,
+			CatalogSourceNamespace: m.Status.CatalogSourceNamespace,
+			DefaultChannel:         def.Name,
+		},
+	}
+	for _, c := range channels {
+		spec.Plans = append(spec.Plans, bundle.Plan{
+			Name:        c.Name,
+			Description: fmt.Sprintf("Subscribe to the %v channel, currently %v", c.Name, c.CurrentCSV),
+			Free:        true,
+			Parameters:  []bundle.ParameterDescriptor{},
+		})
+	}
+	return spec
+}
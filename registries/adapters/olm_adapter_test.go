@@ -0,0 +1,170 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package adapters
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const olmTestPackageManifests = `
+{
+  "items": [
+    {
+      "metadata": {"name": "etcd", "namespace": "openshift-marketplace"},
+      "status": {
+        "catalogSource": "community-operators",
+        "catalogSourceNamespace": "openshift-marketplace",
+        "packageName": "etcd",
+        "defaultChannel": "singlenamespace-alpha",
+        "channels": [
+          {
+            "name": "clusterwide-alpha",
+            "currentCSV": "etcdoperator.v0.9.4-clusterwide",
+            "currentCSVDesc": {"displayName": "etcd", "annotations": {"containerImage": "quay.io/coreos/etcd-operator:v0.9.4-clusterwide"}}
+          },
+          {
+            "name": "singlenamespace-alpha",
+            "currentCSV": "etcdoperator.v0.9.4",
+            "currentCSVDesc": {"displayName": "etcd", "description": "Create and maintain etcd clusters", "annotations": {"containerImage": "quay.io/coreos/etcd-operator:v0.9.4"}}
+          }
+        ]
+      }
+    },
+    {
+      "metadata": {"name": "mongodb-enterprise", "namespace": "openshift-marketplace"},
+      "status": {
+        "catalogSource": "certified-operators",
+        "catalogSourceNamespace": "openshift-marketplace",
+        "packageName": "mongodb-enterprise",
+        "defaultChannel": "stable",
+        "channels": [{"name": "stable", "currentCSV": "mongodb-enterprise.v1.0.0"}]
+      }
+    }
+  ]
+}`
+
+func olmTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/apis/packages.operators.coreos.com/v1/namespaces/openshift-marketplace/packagemanifests" {
+			t.Errorf("unexpected request path %v", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, olmTestPackageManifests)
+	}))
+}
+
+func TestOLMAdapterName(t *testing.T) {
+	a := OLMAdapter{}
+	assert.Equal(t, "olm", a.RegistryName(), "registry adaptor name does not match")
+}
+
+func TestOLMGetImageNames(t *testing.T) {
+	serv := olmTestServer(t)
+	defer serv.Close()
+	u, _ := url.Parse(serv.URL)
+
+	a := OLMAdapter{Config: Configuration{URL: u, Token: "token"}}
+	names, err := a.GetImageNames()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, []string{"etcd", "mongodb-enterprise"}, names)
+
+	a.Config.Org = "certified-operators"
+	names, err = a.GetImageNames()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, []string{"mongodb-enterprise"}, names)
+
+	a.Config.Token = ""
+	_, err = a.GetImageNames()
+	assert.Error(t, err)
+}
+
+func TestOLMFetchSpecs(t *testing.T) {
+	serv := olmTestServer(t)
+	defer serv.Close()
+	u, _ := url.Parse(serv.URL)
+
+	a := OLMAdapter{Config: Configuration{URL: u, Token: "token"}}
+	specs, err := a.FetchSpecs([]string{"etcd", "missing"})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if len(specs) != 1 {
+		t.Fatalf("expected 1 spec, got: %#+v", specs)
+	}
+	spec := specs[0]
+	assert.Equal(t, "etcd", spec.FQName)
+	assert.Equal(t, "quay.io/coreos/etcd-operator:v0.9.4", spec.Image)
+	assert.Equal(t, "Create and maintain etcd clusters", spec.Description)
+	assert.Equal(t, "etcd (Operator)", spec.Metadata["displayName"])
+	assert.False(t, spec.Bindable)
+	if len(spec.Plans) != 2 {
+		t.Fatalf("expected 2 plans, got: %#+v", spec.Plans)
+	}
+	assert.Equal(t, "singlenamespace-alpha", spec.Plans[0].Name)
+	assert.Equal(t, "clusterwide-alpha", spec.Plans[1].Name)
+	if assert.NotNil(t, spec.Operator) {
+		assert.Equal(t, "etcd", spec.Operator.Package)
+		assert.Equal(t, "community-operators", spec.Operator.CatalogSource)
+		assert.Equal(t, "openshift-marketplace", spec.Operator.CatalogSourceNamespace)
+		assert.Equal(t, "singlenamespace-alpha", spec.Operator.DefaultChannel)
+	}
+}
+
+func TestOLMFetchSpecsReusesManifests(t *testing.T) {
+	var requests int32
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, olmTestPackageManifests)
+	}))
+	defer serv.Close()
+	u, _ := url.Parse(serv.URL)
+
+	a := &OLMAdapter{Config: Configuration{URL: u}}
+	if _, err := a.GetImageNames(); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	for _, batch := range [][]string{{"etcd"}, {"mongodb-enterprise"}} {
+		specs, err := a.FetchSpecs(batch)
+		if err != nil {
+			t.Fatalf("unknown error occured: %v", err)
+		}
+		assert.Len(t, specs, 1)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// the next load fetches them again
+	if _, err := a.GetImageNames(); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
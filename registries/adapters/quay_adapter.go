@@ -18,14 +18,14 @@ package adapters
 
 import (
 	b64 "encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/automationbroker/bundle-lib/bundle"
-	log "github.com/sirupsen/logrus"
-	yaml "gopkg.in/yaml.v2"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 )
 
 const (
@@ -46,6 +46,9 @@ type quayRepository struct {
 	Namespace   string `json:"namespace"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	// LastModified - the unix time of the last push, only returned when
+	// asked for with last_modified=true.
+	LastModified int64 `json:"last_modified"`
 }
 
 type quayImageResponse struct {
@@ -93,7 +96,7 @@ func (r QuayAdapter) GetImageNames() ([]string, error) {
 	req.Header.Add("Accept", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", r.config.Token))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.config.httpClient().Do(req)
 	if err != nil {
 		log.Errorf("Failed to load catalog response at %s - %v", fmt.Sprintf(quayCatalogURL, r.config.URL, r.config.Org), err)
 		return nil, err
@@ -101,7 +104,7 @@ func (r QuayAdapter) GetImageNames() ([]string, error) {
 	defer resp.Body.Close()
 
 	catalogResp := quayImageResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&catalogResp)
+	err = decodeResponse(resp, maxResponseSize, &catalogResp)
 	if err != nil {
 		log.Errorf("Failed to decode Catalog response from '%s'", fmt.Sprintf(quayCatalogURL, r.config.URL, r.config.Org))
 		return nil, err
@@ -129,6 +132,43 @@ func (r QuayAdapter) GetImageNames() ([]string, error) {
 	return uniqueList, nil
 }
 
+// LastPushed - the time the repositories of the org were last pushed.
+func (r QuayAdapter) LastPushed(imageNames []string) (map[string]time.Time, error) {
+	catalogURL := fmt.Sprintf(quayCatalogURL, r.config.URL, r.config.Org) + "&last_modified=true"
+	req, err := http.NewRequest("GET", catalogURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", r.config.Token))
+
+	resp, err := r.config.httpClient().Do(req)
+	if err != nil {
+		log.Errorf("Failed to load catalog response at %s - %v", catalogURL, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	catalogResp := quayImageResponse{}
+	err = decodeResponse(resp, maxResponseSize, &catalogResp)
+	if err != nil {
+		log.Errorf("Failed to decode Catalog response from '%s'", catalogURL)
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range imageNames {
+		wanted[name] = true
+	}
+	pushed := map[string]time.Time{}
+	for _, repo := range catalogResp.Repositories {
+		if wanted[repo.Name] && repo.LastModified > 0 {
+			pushed[repo.Name] = time.Unix(repo.LastModified, 0).UTC()
+		}
+	}
+	return pushed, nil
+}
+
 // FetchSpecs - retrieve the spec for the image names.
 func (r QuayAdapter) FetchSpecs(imageNames []string) ([]*bundle.Spec, error) {
 	specs := []*bundle.Spec{}
@@ -144,6 +184,11 @@ func (r QuayAdapter) FetchSpecs(imageNames []string) ([]*bundle.Spec, error) {
 	return specs, nil
 }
 
+// FetchSpec - retrieve the spec for the image name.
+func (r QuayAdapter) FetchSpec(imageName string) (*bundle.Spec, error) {
+	return r.loadSpec(imageName)
+}
+
 func (r QuayAdapter) loadSpec(imageName string) (*bundle.Spec, error) {
 	digest, err := r.getDigest(imageName)
 	if err != nil {
@@ -160,7 +205,7 @@ func (r QuayAdapter) getDigest(imageName string) (string, error) {
 	req.Header.Add("Accept", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", r.config.Token))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.config.httpClient().Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -171,7 +216,7 @@ func (r QuayAdapter) getDigest(imageName string) (string, error) {
 	}
 
 	digestResp := repoResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&digestResp)
+	err = decodeResponse(resp, maxResponseSize, &digestResp)
 	if err != nil {
 		log.Errorf("unable to get repository Info for image: %s - %v", imageName, err)
 		return "", err
@@ -207,7 +252,7 @@ func (r QuayAdapter) digestToSpec(digest string, imageName string) (*bundle.Spec
 	req.Header.Add("Accept", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", r.config.Token))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.config.httpClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -223,23 +268,25 @@ func (r QuayAdapter) digestToSpec(digest string, imageName string) (*bundle.Spec
 	}
 
 	manifestResp := imageLabels{}
-	err = json.NewDecoder(resp.Body).Decode(&manifestResp)
+	err = decodeResponse(resp, maxResponseSize, &manifestResp)
 	if err != nil {
 		log.Errorf("Unable to get Spec for [%s]: - %v", imageName, err)
 		return nil, err
 	}
 
-	var encodedSpec, runtime string
+	var encodedSpec, signature, runtime string
 	for _, l := range manifestResp.Label {
-		if l.Key == "com.redhat.apb.spec" {
+		if l.Key == BundleSpecLabel {
 			encodedSpec = l.Value
+		} else if l.Key == BundleSpecSignatureLabel {
+			signature = l.Value
 		} else if l.Key == "com.redhat.apb.runtime" {
 			runtime = l.Value
 		}
 	}
 
 	if encodedSpec == "" {
-		return nil, errors.New("Spec not found")
+		return nil, liberrors.New(liberrors.CodeNotFound, "Spec not found")
 	}
 
 	decodedSpecYaml, err := b64.StdEncoding.DecodeString(encodedSpec)
@@ -248,7 +295,7 @@ func (r QuayAdapter) digestToSpec(digest string, imageName string) (*bundle.Spec
 		return nil, err
 	}
 
-	if err = yaml.Unmarshal(decodedSpecYaml, spec); err != nil {
+	if spec, err = bundle.UnmarshalSpecYAML(decodedSpecYaml, nil); err != nil {
 		log.Errorf("Something went wrong loading decoded spec yaml, %s", err)
 		return nil, err
 	}
@@ -264,6 +311,11 @@ func (r QuayAdapter) digestToSpec(digest string, imageName string) (*bundle.Spec
 	}
 
 	spec.Image = fmt.Sprintf("%s/%s/%s:%s", registryName, r.config.Org, imageName, r.config.Tag)
+	spec.Provenance = &bundle.Provenance{Digest: digest}
+	if signature != "" {
+		spec.Provenance.SpecSignature = signature
+		spec.Provenance.SignedContent = decodedSpecYaml
+	}
 
 	log.Debugf("adapter::imageToSpec -> Got plans %+v", spec.Plans)
 	log.Debugf("Successfully converted Image '%s' into Spec", spec.Image)
@@ -24,6 +24,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/automationbroker/bundle-lib/bundle"
 	"github.com/stretchr/testify/assert"
@@ -239,6 +240,27 @@ func TestQuayGetImageNames(t *testing.T) {
 	}
 }
 
+func TestQuayLastPushed(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("last_modified") != "true" {
+			t.Errorf("Expected last_modified to be requested, got `%s`", r.URL.String())
+		}
+		fmt.Fprintf(w, `{"repositories": [
+			{"namespace": "foo", "name": "test-apb", "last_modified": 1527811200},
+			{"namespace": "foo", "name": "another-apb"},
+			{"namespace": "foo", "name": "ignored-apb", "last_modified": 1527811200}
+		]}`)
+	}))
+	defer serv.Close()
+
+	qa := NewQuayAdapter(Configuration{Org: "foo", URL: getQuayURL(t, serv)})
+	pushed, err := qa.LastPushed([]string{"test-apb", "another-apb"})
+	if err != nil {
+		t.Fatalf("unexpected error during test: %v\n", err)
+	}
+	assert.Equal(t, map[string]time.Time{"test-apb": time.Unix(1527811200, 0).UTC()}, pushed)
+}
+
 func TestQuayFetchSpecs(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -267,6 +289,9 @@ func TestQuayFetchSpecs(t *testing.T) {
 					Image:       "%s/foo/test-apb:latest",
 					Description: "test apb implementation",
 					Async:       "optional",
+					Provenance: &bundle.Provenance{
+						Digest: "sha256:482e3f2c582f6facac995fff1ab70612ea41bc67788bae9e51ed21448c0fc7a2",
+					},
 					Plans: []bundle.Plan{
 						{
 							Name: "default",
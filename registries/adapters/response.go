@@ -0,0 +1,84 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+const (
+	// maxResponseSize - the largest registry response, manifest, config
+	// object or catalog page, the adapters will read.
+	maxResponseSize int64 = 10 << 20
+	// maxHelmIndexSize - the largest helm repository index the helm
+	// adapter will read, the index lists every chart version.
+	maxHelmIndexSize int64 = 50 << 20
+)
+
+// responseTooLargeError - a registry response exceeded the size limit.
+type responseTooLargeError struct {
+	url   string
+	limit int64
+}
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("response from %v exceeds the limit of %d bytes", e.url, e.limit)
+}
+
+func newResponseTooLargeError(resp *http.Response, limit int64) error {
+	url := ""
+	if resp.Request != nil && resp.Request.URL != nil {
+		url = resp.Request.URL.String()
+	}
+	return &responseTooLargeError{url: url, limit: limit}
+}
+
+// readResponse - reads the response body, failing without reading it when
+// the Content-Length exceeds the limit and once more than limit bytes are
+// read.
+func readResponse(resp *http.Response, limit int64) ([]byte, error) {
+	if resp.ContentLength > limit {
+		return nil, newResponseTooLargeError(resp, limit)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, newResponseTooLargeError(resp, limit)
+	}
+	return body, nil
+}
+
+// decodeResponse - decodes the JSON response body into v as it is read
+// instead of reading the whole body first. The same limits as
+// readResponse apply.
+func decodeResponse(resp *http.Response, limit int64, v interface{}) error {
+	if resp.ContentLength > limit {
+		return newResponseTooLargeError(resp, limit)
+	}
+	lr := &io.LimitedReader{R: resp.Body, N: limit + 1}
+	err := json.NewDecoder(lr).Decode(v)
+	if lr.N <= 0 {
+		return newResponseTooLargeError(resp, limit)
+	}
+	return err
+}
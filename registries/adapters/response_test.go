@@ -0,0 +1,143 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package adapters
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestResponse(body string, contentLength int64) *http.Response {
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: contentLength,
+	}
+}
+
+func TestReadResponse(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          string
+		contentLength int64
+		limit         int64
+		expected      []byte
+		tooLarge      bool
+	}{
+		{
+			name:          "within the limit",
+			body:          "hello world",
+			contentLength: 11,
+			limit:         11,
+			expected:      []byte("hello world"),
+		},
+		{
+			name:          "content length exceeds the limit",
+			body:          "hello world",
+			contentLength: 11,
+			limit:         5,
+			tooLarge:      true,
+		},
+		{
+			name:          "unknown content length exceeds the limit",
+			body:          "hello world",
+			contentLength: -1,
+			limit:         5,
+			tooLarge:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := readResponse(newTestResponse(tc.body, tc.contentLength), tc.limit)
+			if tc.tooLarge {
+				_, ok := err.(*responseTooLargeError)
+				assert.True(t, ok, "expected a response too large error, got %v", err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, tc.expected, body)
+		})
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          string
+		contentLength int64
+		limit         int64
+		expected      []string
+		tooLarge      bool
+		shouldErr     bool
+	}{
+		{
+			name:          "within the limit",
+			body:          `{"repositories": ["foo", "bar"]}`,
+			contentLength: -1,
+			limit:         maxResponseSize,
+			expected:      []string{"foo", "bar"},
+		},
+		{
+			name:          "content length exceeds the limit",
+			body:          `{"repositories": ["foo", "bar"]}`,
+			contentLength: 32,
+			limit:         10,
+			tooLarge:      true,
+		},
+		{
+			name:          "body exceeds the limit",
+			body:          `{"repositories": ["foo", "bar"]}`,
+			contentLength: -1,
+			limit:         10,
+			tooLarge:      true,
+		},
+		{
+			name:          "invalid json",
+			body:          `{"repositories": [`,
+			contentLength: -1,
+			limit:         maxResponseSize,
+			shouldErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			catalog := apiV2CatalogResponse{}
+			err := decodeResponse(newTestResponse(tc.body, tc.contentLength), tc.limit, &catalog)
+			if tc.tooLarge {
+				_, ok := err.(*responseTooLargeError)
+				assert.True(t, ok, "expected a response too large error, got %v", err)
+				return
+			}
+			if tc.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, tc.expected, catalog.Repositories)
+		})
+	}
+}
@@ -17,21 +17,19 @@
 package adapters
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io/ioutil"
 
 	"github.com/automationbroker/bundle-lib/bundle"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/automationbroker/bundle-lib/registries/adapters/oauth"
-	log "github.com/sirupsen/logrus"
 )
 
 // NewRHCCAdapter - creates and returns a *RHCCAdapter ready to use.
 func NewRHCCAdapter(config Configuration) *RHCCAdapter {
 	return &RHCCAdapter{
 		Config: config,
-		client: oauth.NewClient(config.User, config.Pass, config.SkipVerifyTLS, config.URL),
+		client: oauth.NewClientWithTransport(config.User, config.Pass, config.URL, config.transportConfig()),
 	}
 }
 
@@ -116,12 +114,10 @@ func (r RHCCAdapter) loadImages(query string) (RHCCImageResponse, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return RHCCImageResponse{}, errors.New(resp.Status)
+		return RHCCImageResponse{}, liberrors.FromHTTPStatus(resp.StatusCode, resp.Status)
 	}
-	imageList, err := ioutil.ReadAll(resp.Body)
-
 	imageResp := RHCCImageResponse{}
-	err = json.Unmarshal(imageList, &imageResp)
+	err = decodeResponse(resp, maxResponseSize, &imageResp)
 	if err != nil {
 		return RHCCImageResponse{}, err
 	}
@@ -130,6 +126,11 @@ func (r RHCCAdapter) loadImages(query string) (RHCCImageResponse, error) {
 	return imageResp, nil
 }
 
+// FetchSpec - retrieve the spec for the image name.
+func (r RHCCAdapter) FetchSpec(imageName string) (*bundle.Spec, error) {
+	return r.loadSpec(imageName)
+}
+
 func (r RHCCAdapter) loadSpec(imageName string) (*bundle.Spec, error) {
 	log.Debug("RHCCAdapter::LoadSpec")
 	if r.Config.Tag == "" {
@@ -0,0 +1,63 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pushedAdapter - a batchAdapter that knows when the images were pushed.
+type pushedAdapter struct {
+	batchAdapter
+	pushed map[string]time.Time
+}
+
+func (p *pushedAdapter) LastPushed(names []string) (map[string]time.Time, error) {
+	return p.pushed, nil
+}
+
+func TestFilterByAge(t *testing.T) {
+	now := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	adapter := &pushedAdapter{pushed: map[string]time.Time{
+		"fresh-apb": now.AddDate(0, 0, -1),
+		"stale-apb": now.AddDate(-2, 0, 0),
+	}}
+	reg := Registry{config: Config{Name: "aged", MaxImageAgeDays: 30}, adapter: adapter}
+	valid := []string{"fresh-apb", "stale-apb", "unknown-apb"}
+	explanations := []FilterExplanation{
+		{Image: "fresh-apb", Included: true},
+		{Image: "stale-apb", Included: true},
+		{Image: "unknown-apb", Included: true},
+	}
+
+	kept, filtered, explanations := reg.filterByAge(valid, []string{}, explanations, now)
+	assert.Equal(t, []string{"fresh-apb", "unknown-apb"}, kept)
+	assert.Equal(t, []string{"stale-apb"}, filtered)
+	assert.True(t, explanations[0].Included)
+	assert.False(t, explanations[1].Included)
+	assert.Contains(t, explanations[1].Reason, "not pushed within 30 days")
+	assert.True(t, explanations[2].Included)
+
+	// adapters that do not know the push times are not filtered
+	reg = Registry{config: Config{Name: "batch", MaxImageAgeDays: 30}, adapter: &batchAdapter{}}
+	kept, filtered, _ = reg.filterByAge(valid, []string{}, nil, now)
+	assert.Equal(t, valid, kept)
+	assert.Empty(t, filtered)
+}
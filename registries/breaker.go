@@ -0,0 +1,127 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+)
+
+// DefaultBreakerCooldown - how long a registry is skipped when its config
+// does not set BreakerCooldown.
+const DefaultBreakerCooldown = 5 * time.Minute
+
+// ErrorRegistryUnavailable - The registry is skipped by its circuit
+// breaker and has no specs of a previous load to return.
+type ErrorRegistryUnavailable struct {
+	Registry string
+	Until    time.Time
+}
+
+func (e ErrorRegistryUnavailable) Error() string {
+	return fmt.Sprintf("registry %v is skipped after failed loads until %v",
+		e.Registry, e.Until.Format(time.RFC3339))
+}
+
+// ErrorCode - the error is of the Timeout class, the registry is loaded
+// again after Until.
+func (e ErrorRegistryUnavailable) ErrorCode() liberrors.Code {
+	return liberrors.CodeTimeout
+}
+
+// IsErrorRegistryUnavailable - true if the error is an
+// ErrorRegistryUnavailable.
+func IsErrorRegistryUnavailable(err error) bool {
+	_, ok := err.(ErrorRegistryUnavailable)
+	return ok
+}
+
+// circuitBreaker - Counts the failed loads of a registry in a row. Once
+// threshold loads failed the registry is skipped for cooldown, the load
+// after that either closes the breaker or opens it again. A nil breaker is
+// never open.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mutex    sync.Mutex
+	failures int
+	until    time.Time
+}
+
+// newCircuitBreaker - the breaker of the registry, nil when disabled.
+func newCircuitBreaker(config Config) *circuitBreaker {
+	if config.BreakerThreshold <= 0 {
+		return nil
+	}
+	cooldown := config.BreakerCooldown
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &circuitBreaker{
+		threshold: config.BreakerThreshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// openUntil - when the registry is loaded again, and true while it is
+// skipped.
+func (b *circuitBreaker) openUntil() (time.Time, bool) {
+	if b == nil {
+		return time.Time{}, false
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.until, b.now().Before(b.until)
+}
+
+func (b *circuitBreaker) setNow(now func() time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.now = now
+}
+
+// failed - counts a failed load, returns true when it opens the breaker.
+func (b *circuitBreaker) failed() bool {
+	if b == nil {
+		return false
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.failures++
+	if b.failures < b.threshold {
+		return false
+	}
+	b.until = b.now().Add(b.cooldown)
+	return true
+}
+
+// succeeded - closes the breaker.
+func (b *circuitBreaker) succeeded() {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.failures = 0
+	b.until = time.Time{}
+}
@@ -0,0 +1,136 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+type flakyAdapter struct {
+	fail  bool
+	calls int
+	spec  bundle.Spec
+}
+
+func (f *flakyAdapter) GetImageNames() ([]string, error) {
+	f.calls++
+	if f.fail {
+		return []string{}, fmt.Errorf("registry unavailable")
+	}
+	return []string{"image1-bundle"}, nil
+}
+
+func (f *flakyAdapter) FetchSpecs(names []string) ([]*bundle.Spec, error) {
+	spec := f.spec
+	return []*bundle.Spec{&spec}, nil
+}
+
+func (f *flakyAdapter) RegistryName() string {
+	return "flaky"
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	adapter := &flakyAdapter{spec: s}
+	c := Config{Name: "flaky", BreakerThreshold: 2, BreakerCooldown: time.Minute}
+	breaker := newCircuitBreaker(c)
+	breaker.now = func() time.Time { return now }
+	r := Registry{config: c, adapter: adapter, filter: Filter{}, breaker: breaker, cache: &specCache{}}
+
+	specs, _, err := r.LoadSpecs()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Len(t, specs, 1)
+	assert.False(t, specs[0].Provenance.Stale)
+
+	// the specs of the last load are returned stale
+	adapter.fail = true
+	for i := 0; i < 2; i++ {
+		specs, count, err := r.LoadSpecs()
+		if err != nil {
+			t.Fatalf("unknown error occured: %v", err)
+		}
+		assert.Equal(t, 1, count)
+		assert.Len(t, specs, 1)
+		assert.True(t, specs[0].Provenance.Stale)
+	}
+	assert.True(t, r.CircuitOpen())
+
+	// the registry is skipped until the cooldown is over
+	calls := adapter.calls
+	specs, _, err = r.LoadSpecs()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Len(t, specs, 1)
+	assert.Equal(t, calls, adapter.calls)
+
+	now = now.Add(2 * time.Minute)
+	adapter.fail = false
+	specs, _, err = r.LoadSpecs()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.False(t, specs[0].Provenance.Stale)
+	assert.False(t, r.CircuitOpen())
+}
+
+func TestCircuitBreakerWithoutSpecs(t *testing.T) {
+	now := time.Now()
+	c := Config{Name: "flaky", BreakerThreshold: 1}
+	breaker := newCircuitBreaker(c)
+	breaker.now = func() time.Time { return now }
+	r := Registry{config: c, adapter: &flakyAdapter{fail: true}, filter: Filter{}, breaker: breaker, cache: &specCache{}}
+
+	_, _, err := r.LoadSpecs()
+	assert.Error(t, err)
+	_, _, err = r.LoadSpecs()
+	if !IsErrorRegistryUnavailable(err) {
+		t.Fatalf("expected the registry to be unavailable got: %v", err)
+	}
+	assert.Equal(t, now.Add(DefaultBreakerCooldown), err.(ErrorRegistryUnavailable).Until)
+
+	assert.Nil(t, newCircuitBreaker(Config{}))
+	assert.False(t, Registry{}.CircuitOpen())
+}
+
+func TestRegistryClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	adapter := &flakyAdapter{spec: s}
+	c := Config{Name: "flaky", BreakerThreshold: 1, BreakerCooldown: time.Minute}
+	r := Registry{config: c, adapter: adapter, filter: Filter{}, breaker: newCircuitBreaker(c), cache: &specCache{}}
+	r.SetClock(fake)
+
+	specs, _, err := r.LoadSpecs()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, fake.Now(), specs[0].Provenance.FetchedAt)
+
+	adapter.fail = true
+	r.LoadSpecs()
+	assert.True(t, r.CircuitOpen())
+	fake.Advance(2 * time.Minute)
+	assert.False(t, r.CircuitOpen())
+}
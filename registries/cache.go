@@ -0,0 +1,69 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"sync"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+)
+
+// specCache - The specs of the last successful load of a registry. A nil
+// cache keeps nothing.
+type specCache struct {
+	mutex  sync.Mutex
+	specs  []*bundle.Spec
+	count  int
+	loaded bool
+}
+
+// store - keeps the specs of a successful load.
+func (c *specCache) store(specs []*bundle.Spec, count int) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.specs = specs
+	c.count = count
+	c.loaded = true
+}
+
+// stale - copies of the kept specs with their Provenance marked stale,
+// false if nothing was loaded.
+func (c *specCache) stale() ([]*bundle.Spec, int, bool) {
+	if c == nil {
+		return []*bundle.Spec{}, 0, false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.loaded {
+		return []*bundle.Spec{}, 0, false
+	}
+	specs := make([]*bundle.Spec, 0, len(c.specs))
+	for _, spec := range c.specs {
+		s := *spec
+		p := bundle.Provenance{}
+		if spec.Provenance != nil {
+			p = *spec.Provenance
+		}
+		p.Stale = true
+		s.Provenance = &p
+		specs = append(specs, &s)
+	}
+	return specs, c.count, true
+}
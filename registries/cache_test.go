@@ -0,0 +1,65 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeepStaleSpecs(t *testing.T) {
+	testCases := []struct {
+		name           string
+		keepStaleSpecs bool
+	}{
+		{name: "stale specs returned", keepStaleSpecs: true},
+		{name: "error returned", keepStaleSpecs: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter := &flakyAdapter{spec: s}
+			c := Config{Name: "flaky", KeepStaleSpecs: tc.keepStaleSpecs}
+			r := Registry{config: c, adapter: adapter, filter: Filter{}, cache: &specCache{}}
+
+			_, _, ok := r.cache.stale()
+			assert.False(t, ok)
+			if _, _, err := r.LoadSpecs(); err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+
+			adapter.fail = true
+			specs, _, err := r.LoadSpecs()
+			if tc.keepStaleSpecs {
+				if err != nil {
+					t.Fatalf("unknown error occured: %v", err)
+				}
+				assert.Len(t, specs, 1)
+				assert.True(t, specs[0].Provenance.Stale)
+			} else {
+				assert.Error(t, err)
+				assert.Empty(t, specs)
+			}
+
+			// the consumer can keep the stale specs itself
+			stale, count, ok := r.StaleSpecs()
+			assert.True(t, ok)
+			assert.Equal(t, 1, count)
+			assert.True(t, stale[0].Provenance.Stale)
+		})
+	}
+}
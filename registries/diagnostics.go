@@ -0,0 +1,65 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"sync"
+)
+
+// diagnostics - What happened to the images of a registry during its last
+// load. A registry without diagnostics reports nothing.
+type diagnostics struct {
+	mutex   sync.Mutex
+	filter  []FilterExplanation
+	reports []LintReport
+}
+
+func (d *diagnostics) setFilterExplanations(explanations []FilterExplanation) {
+	if d == nil {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.filter = explanations
+}
+
+func (d *diagnostics) filterExplanations() []FilterExplanation {
+	if d == nil {
+		return []FilterExplanation{}
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]FilterExplanation{}, d.filter...)
+}
+
+func (d *diagnostics) setLintReports(reports []LintReport) {
+	if d == nil {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.reports = reports
+}
+
+func (d *diagnostics) lintReports() []LintReport {
+	if d == nil {
+		return []LintReport{}
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]LintReport{}, d.reports...)
+}
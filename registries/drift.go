@@ -0,0 +1,50 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"fmt"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/registries/adapters"
+)
+
+// ResolveDigest - the digest of the tag of the image now, when the adapter
+// of the registry can resolve it, see adapters.DigestAdapter.
+func (r Registry) ResolveDigest(image string) (string, error) {
+	resolver, ok := r.adapter.(adapters.DigestAdapter)
+	if !ok {
+		return "", fmt.Errorf("registry %v can not resolve image digests", r.config.Name)
+	}
+	return resolver.ResolveDigest(image)
+}
+
+// NewDigestResolver - a bundle.DigestResolver resolving the image of a spec
+// with the registry named in its Provenance.
+func NewDigestResolver(registries []Registry) bundle.DigestResolver {
+	return bundle.DigestResolverFunc(func(spec *bundle.Spec) (string, error) {
+		if spec.Provenance == nil {
+			return "", fmt.Errorf("the registry of image %v is unknown", spec.Image)
+		}
+		for _, r := range registries {
+			if r.RegistryName() == spec.Provenance.Registry {
+				return r.ResolveDigest(spec.Image)
+			}
+		}
+		return "", fmt.Errorf("registry %v of image %v not found", spec.Provenance.Registry, spec.Image)
+	})
+}
@@ -0,0 +1,55 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/stretchr/testify/assert"
+)
+
+type digestAdapter struct {
+	TestingAdapter
+}
+
+func (d digestAdapter) ResolveDigest(image string) (string, error) {
+	return "sha256:" + image, nil
+}
+
+func TestNewDigestResolver(t *testing.T) {
+	resolver := NewDigestResolver([]Registry{
+		{config: Config{Name: "plain"}, adapter: &TestingAdapter{}},
+		{config: Config{Name: "resolving"}, adapter: digestAdapter{}},
+	})
+
+	digest, err := resolver.ResolveDigest(&bundle.Spec{
+		Image:      "foo/apb",
+		Provenance: &bundle.Provenance{Registry: "resolving"},
+	})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "sha256:foo/apb", digest)
+
+	_, err = resolver.ResolveDigest(&bundle.Spec{Image: "foo/apb", Provenance: &bundle.Provenance{Registry: "plain"}})
+	assert.Error(t, err)
+	_, err = resolver.ResolveDigest(&bundle.Spec{Image: "foo/apb", Provenance: &bundle.Provenance{Registry: "missing"}})
+	assert.Error(t, err)
+	_, err = resolver.ResolveDigest(&bundle.Spec{Image: "foo/apb"})
+	assert.Error(t, err)
+}
@@ -17,10 +17,11 @@
 package registries
 
 import (
+	"fmt"
 	"regexp"
 	"sync"
 
-	log "github.com/sirupsen/logrus"
+	log "github.com/automationbroker/bundle-lib/logging"
 )
 
 type filterMode uint8
@@ -93,6 +94,53 @@ func (f *Filter) Run(totalList []string) ([]string, []string) {
 	return applyMatchSets(whiteMatchSet, blackMatchSet, totalList)
 }
 
+// FilterExplanation - Why an image passed or failed the white and
+// blacklists.
+type FilterExplanation struct {
+	Image    string `json:"image"`
+	Included bool   `json:"included"`
+	// Whitelist - the first whitelist regex matching the image.
+	Whitelist string `json:"whitelist,omitempty"`
+	// Blacklist - the first blacklist regex matching the image, a
+	// blacklist match overrides the whitelist.
+	Blacklist string `json:"blacklist,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// Explain - explains the outcome of Run for every image in totalList.
+func (f *Filter) Explain(totalList []string) []FilterExplanation {
+	explanations := make([]FilterExplanation, 0, len(totalList))
+	for _, image := range totalList {
+		e := FilterExplanation{
+			Image:     image,
+			Whitelist: firstMatch(f.whiteRegexp, image),
+			Blacklist: firstMatch(f.blackRegexp, image),
+		}
+		switch {
+		case len(f.whiteRegexp) == 0:
+			e.Reason = "no whitelist is configured, every image is filtered"
+		case e.Whitelist == "":
+			e.Reason = "not matched by any whitelist regex"
+		case e.Blacklist != "":
+			e.Reason = fmt.Sprintf("matched by blacklist regex %v, overriding whitelist regex %v", e.Blacklist, e.Whitelist)
+		default:
+			e.Included = true
+			e.Reason = fmt.Sprintf("matched by whitelist regex %v", e.Whitelist)
+		}
+		explanations = append(explanations, e)
+	}
+	return explanations
+}
+
+func firstMatch(regexps []*regexp.Regexp, s string) string {
+	for _, rx := range regexps {
+		if rx.MatchString(s) {
+			return rx.String()
+		}
+	}
+	return ""
+}
+
 // FilterMode - FilterMode getter
 func (f *Filter) getFilterMode() filterMode {
 	if len(f.whiteRegexp) != 0 && len(f.blackRegexp) != 0 {
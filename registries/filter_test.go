@@ -22,7 +22,7 @@ import (
 	"strings"
 	"testing"
 
-	yaml "gopkg.in/yaml.v1"
+	yaml "gopkg.in/yaml.v2"
 
 	log "github.com/sirupsen/logrus"
 	ft "github.com/stretchr/testify/assert"
@@ -285,3 +285,57 @@ func TestOnlyWhitelistWithEmptyString(t *testing.T) {
 	ft.True(t, testSetEq(expectedFilteredNames, filteredNames))
 	ft.True(t, testSetEq(expectedTotal, testNames()))
 }
+
+func TestFilterExplain(t *testing.T) {
+	testCases := []struct {
+		name      string
+		whitelist []string
+		blacklist []string
+	}{
+		{name: "only blacklist", blacklist: testGetRegexFromFile(testBlacklistFile)},
+		{name: "only whitelist", whitelist: testGetRegexFromFile(testWhitelistFile)},
+		{
+			name:      "black and whitelist",
+			whitelist: testGetRegexFromFile(testWhitelistFile),
+			blacklist: testGetRegexFromFile(testBlacklistFile),
+		},
+		{name: "no lists"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := Filter{whitelist: tc.whitelist, blacklist: tc.blacklist}
+			filter.Init()
+			validNames, _ := filter.Run(testNames())
+			if validNames == nil {
+				validNames = []string{}
+			}
+
+			included := []string{}
+			for _, e := range filter.Explain(testNames()) {
+				ft.NotEmpty(t, e.Reason)
+				if e.Included {
+					ft.NotEmpty(t, e.Whitelist)
+					ft.Empty(t, e.Blacklist)
+					included = append(included, e.Image)
+				}
+			}
+			ft.True(t, testSetEq(validNames, included))
+		})
+	}
+}
+
+func TestFilterExplainBlacklistOverride(t *testing.T) {
+	filter := Filter{whitelist: []string{".*-apb$"}, blacklist: []string{"^malicious-.*"}}
+	filter.Init()
+	explanations := filter.Explain([]string{"foo-apb", "malicious-bar-apb", "foo"})
+
+	ft.Equal(t, []FilterExplanation{
+		{Image: "foo-apb", Included: true, Whitelist: ".*-apb$", Reason: "matched by whitelist regex .*-apb$"},
+		{
+			Image: "malicious-bar-apb", Whitelist: ".*-apb$", Blacklist: "^malicious-.*",
+			Reason: "matched by blacklist regex ^malicious-.*, overriding whitelist regex .*-apb$",
+		},
+		{Image: "foo", Reason: "not matched by any whitelist regex"},
+	}, explanations)
+}
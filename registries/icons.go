@@ -0,0 +1,393 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/clients"
+	log "github.com/automationbroker/bundle-lib/logging"
+	"github.com/automationbroker/bundle-lib/registries/adapters/oauth"
+	apicorev1 "k8s.io/api/core/v1"
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// IconPolicyLink - the icon and media URLs of the specs are left as
+	// they are, the browsers of the users fetch them. The default.
+	IconPolicyLink = "link"
+	// IconPolicyDataURI - the assets are fetched when the specs are loaded
+	// and the URLs replaced with data URIs.
+	IconPolicyDataURI = "datauri"
+	// IconPolicyConfigMap - like IconPolicyDataURI, the fetched assets are
+	// also stored in a config map so that they survive a restart and an
+	// asset host that became unreachable.
+	IconPolicyConfigMap = "configmap"
+
+	// DefaultIconMaxSize - the largest asset that is re-hosted, in bytes.
+	DefaultIconMaxSize = 256 * 1024
+	// DefaultIconTTL - how long a fetched asset is served before it is
+	// fetched again.
+	DefaultIconTTL = 24 * time.Hour
+
+	// iconCacheMaxSize - the most bytes of data URIs the cache keeps, below
+	// the 1MiB a config map holds.
+	iconCacheMaxSize = 900 * 1024
+	// iconFetchDeadline - how long the fetches of a load take at most.
+	iconFetchDeadline = 30 * time.Second
+	// iconFetchWorkers - the assets fetched at once.
+	iconFetchWorkers = 8
+)
+
+// MediaMetadataKeys - the keys of the spec metadata holding the URL of an
+// asset, or a list of URLs.
+var MediaMetadataKeys = []string{"imageUrl", "mediaUrls"}
+
+// iconContentTypes - the media types of the assets that are re-hosted.
+var iconContentTypes = map[string]bool{
+	"image/png":     true,
+	"image/jpeg":    true,
+	"image/gif":     true,
+	"image/svg+xml": true,
+	"image/webp":    true,
+}
+
+// iconAsset - a fetched asset, when it was fetched and last served.
+type iconAsset struct {
+	uri     string
+	fetched time.Time
+	used    time.Time
+}
+
+// size - the bytes the asset takes in the config map.
+func (a iconAsset) size(assetURL string) int {
+	return len(assetURL) + len(a.uri) + 96
+}
+
+// iconCache - fetches the assets of the specs and keeps them as data URIs,
+// by asset URL. Assets are served from the cache until their TTL expires
+// and the least recently served ones are evicted to keep the cache under
+// iconCacheMaxSize.
+type iconCache struct {
+	mutex   sync.Mutex
+	client  *http.Client
+	maxSize int64
+	ttl     time.Duration
+	assets  map[string]iconAsset
+	// configMap and namespace - where the assets are stored with the
+	// configmap policy, empty otherwise.
+	configMap string
+	namespace string
+	loaded    bool
+	dirty     bool
+}
+
+// newIconCache - returns the cache of the icon policy of the config, nil
+// for the link policy. The assets are fetched with the shared transport of
+// the registry and carry its identity.
+func newIconCache(config Config, namespace string, identity *oauth.RequestIdentity) *iconCache {
+	switch config.IconPolicy {
+	case "", IconPolicyLink:
+		return nil
+	}
+	c := &iconCache{
+		client: oauth.NewHTTPClient(oauth.TransportConfig{
+			SkipVerifyTLS:       config.SkipVerifyTLS,
+			MaxIdleConnsPerHost: config.MaxIdleConnsPerHost,
+			DisableHTTP2:        config.DisableHTTP2,
+			Identity:            identity,
+		}),
+		maxSize: config.IconMaxSize,
+		ttl:     config.IconTTL,
+		assets:  map[string]iconAsset{},
+	}
+	if c.maxSize == 0 {
+		c.maxSize = DefaultIconMaxSize
+	}
+	if c.ttl == 0 {
+		c.ttl = DefaultIconTTL
+	}
+	if config.IconPolicy == IconPolicyConfigMap {
+		c.configMap = config.IconConfigMap
+		if c.configMap == "" {
+			c.configMap = fmt.Sprintf("%s-icons", config.Name)
+		}
+		c.namespace = namespace
+	}
+	return c
+}
+
+// rehost - replaces the asset URLs in the metadata of the specs with data
+// URIs. The assets missing from the cache or expired are fetched at once,
+// all within iconFetchDeadline. An asset that can not be fetched keeps its
+// cached copy, or its URL when it has none.
+func (c *iconCache) rehost(ctx context.Context, specs []*bundle.Spec, now time.Time) {
+	c.mutex.Lock()
+	if c.configMap != "" && !c.loaded {
+		c.load()
+	}
+	stale := map[string]string{}
+	for _, spec := range specs {
+		for _, assetURL := range assetURLs(spec) {
+			if asset, ok := c.assets[assetURL]; !ok || now.Sub(asset.fetched) >= c.ttl {
+				stale[assetURL] = spec.FQName
+			}
+		}
+	}
+	c.mutex.Unlock()
+
+	fetched := c.fetchAll(ctx, stale)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for assetURL, uri := range fetched {
+		if c.assets[assetURL].uri != uri {
+			c.dirty = true
+		}
+		c.assets[assetURL] = iconAsset{uri: uri, fetched: now}
+	}
+	for _, spec := range specs {
+		for _, key := range MediaMetadataKeys {
+			switch value := spec.Metadata[key].(type) {
+			case string:
+				spec.Metadata[key] = c.dataURI(value, now)
+			case []interface{}:
+				rehosted := make([]interface{}, len(value))
+				for i, v := range value {
+					rehosted[i] = v
+					if s, ok := v.(string); ok {
+						rehosted[i] = c.dataURI(s, now)
+					}
+				}
+				spec.Metadata[key] = rehosted
+			}
+		}
+	}
+	c.evict()
+	if c.dirty && c.configMap != "" {
+		c.save()
+	}
+}
+
+// assetURLs - the http and https asset URLs in the metadata of the spec.
+func assetURLs(spec *bundle.Spec) []string {
+	urls := []string{}
+	add := func(v interface{}) {
+		s, ok := v.(string)
+		if !ok {
+			return
+		}
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		urls = append(urls, s)
+	}
+	for _, key := range MediaMetadataKeys {
+		switch value := spec.Metadata[key].(type) {
+		case string:
+			add(value)
+		case []interface{}:
+			for _, v := range value {
+				add(v)
+			}
+		}
+	}
+	return urls
+}
+
+// fetchAll - fetches the assets, by URL with the name of a spec using
+// them, with iconFetchWorkers workers. It returns the data URIs of the
+// assets that were fetched before the deadline.
+func (c *iconCache) fetchAll(ctx context.Context, assets map[string]string) map[string]string {
+	fetched := map[string]string{}
+	if len(assets) == 0 {
+		return fetched
+	}
+	ctx, cancel := context.WithTimeout(ctx, iconFetchDeadline)
+	defer cancel()
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	urls := make(chan string)
+	for i := 0; i < iconFetchWorkers && i < len(assets); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for assetURL := range urls {
+				uri, err := c.fetch(ctx, assetURL)
+				if err != nil {
+					log.Warningf("unable to re-host asset %s of %s - %v", assetURL, assets[assetURL], err)
+					continue
+				}
+				mutex.Lock()
+				fetched[assetURL] = uri
+				mutex.Unlock()
+			}
+		}()
+	}
+	for assetURL := range assets {
+		urls <- assetURL
+	}
+	close(urls)
+	wg.Wait()
+	return fetched
+}
+
+// dataURI - the cached data URI of the asset, or the URL when it is not
+// cached.
+func (c *iconCache) dataURI(assetURL string, now time.Time) string {
+	asset, ok := c.assets[assetURL]
+	if !ok {
+		return assetURL
+	}
+	asset.used = now
+	c.assets[assetURL] = asset
+	return asset.uri
+}
+
+// evict - drops the least recently served assets until the cache fits in
+// iconCacheMaxSize.
+func (c *iconCache) evict() {
+	total := 0
+	urls := make([]string, 0, len(c.assets))
+	for assetURL, asset := range c.assets {
+		total += asset.size(assetURL)
+		urls = append(urls, assetURL)
+	}
+	if total <= iconCacheMaxSize {
+		return
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		return c.assets[urls[i]].used.Before(c.assets[urls[j]].used)
+	})
+	for _, assetURL := range urls {
+		if total <= iconCacheMaxSize {
+			break
+		}
+		total -= c.assets[assetURL].size(assetURL)
+		delete(c.assets, assetURL)
+		c.dirty = true
+		log.Debugf("evicted asset %s from the icon cache", assetURL)
+	}
+}
+
+// fetch - fetches the asset and validates its size and type.
+func (c *iconCache) fetch(ctx context.Context, assetURL string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, assetURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !iconContentTypes[mediaType] {
+		return "", fmt.Errorf("unsupported content type %q", resp.Header.Get("Content-Type"))
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, c.maxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > c.maxSize {
+		return "", fmt.Errorf("asset is larger than %v bytes", c.maxSize)
+	}
+	return fmt.Sprintf("data:%s;base64,%s", mediaType, base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// iconKey - the config map key of the asset URL.
+func iconKey(assetURL string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(assetURL)))
+}
+
+// load - reads the assets stored in the config map. The config map maps
+// the hash of the URL to the URL, the unix time it was fetched and the
+// data URI, separated by newlines. Entries without the time are expired.
+func (c *iconCache) load() {
+	c.loaded = true
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		log.Warningf("unable to read the icon config map %s - %v", c.configMap, err)
+		return
+	}
+	cm, err := k8scli.Client.CoreV1().ConfigMaps(c.namespace).Get(c.configMap, metav1.GetOptions{})
+	if err != nil {
+		if !kapierrors.IsNotFound(err) {
+			log.Warningf("unable to read the icon config map %s - %v", c.configMap, err)
+		}
+		return
+	}
+	for _, entry := range cm.Data {
+		parts := strings.SplitN(entry, "\n", 3)
+		switch len(parts) {
+		case 2:
+			c.assets[parts[0]] = iconAsset{uri: parts[1]}
+		case 3:
+			fetched, err := strconv.ParseInt(parts[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			c.assets[parts[0]] = iconAsset{uri: parts[2], fetched: time.Unix(fetched, 0)}
+		}
+	}
+}
+
+// save - stores the assets in the config map.
+func (c *iconCache) save() {
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		log.Warningf("unable to store the icon config map %s - %v", c.configMap, err)
+		return
+	}
+	cm := &apicorev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: c.configMap, Namespace: c.namespace},
+		Data:       map[string]string{},
+	}
+	for assetURL, asset := range c.assets {
+		cm.Data[iconKey(assetURL)] = fmt.Sprintf("%s\n%d\n%s", assetURL, asset.fetched.Unix(), asset.uri)
+	}
+	client := k8scli.Client.CoreV1().ConfigMaps(c.namespace)
+	_, err = client.Update(cm)
+	if kapierrors.IsNotFound(err) {
+		_, err = client.Create(cm)
+	}
+	if err != nil {
+		log.Warningf("unable to store the icon config map %s - %v", c.configMap, err)
+		return
+	}
+	c.dirty = false
+}
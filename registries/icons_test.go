@@ -0,0 +1,151 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/clients"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newIconServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/icon.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png"))
+		case "/large.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(strings.Repeat("a", 16)))
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestIconCacheRehost(t *testing.T) {
+	server := newIconServer()
+	defer server.Close()
+
+	c := newIconCache(Config{Name: "reg", IconPolicy: IconPolicyDataURI, IconMaxSize: 8}, "", nil)
+	spec := &bundle.Spec{FQName: "reg-bundle", Metadata: map[string]interface{}{
+		"imageUrl": server.URL + "/icon.png",
+		"mediaUrls": []interface{}{
+			server.URL + "/large.png",
+			server.URL + "/page.html",
+			server.URL + "/missing.png",
+			"not a url",
+		},
+	}}
+	c.rehost(context.Background(), []*bundle.Spec{spec}, time.Now())
+
+	assert.Equal(t, "data:image/png;base64,cG5n", spec.Metadata["imageUrl"])
+	// assets that are too large, of the wrong type or missing keep their URL
+	assert.Equal(t, []interface{}{
+		server.URL + "/large.png",
+		server.URL + "/page.html",
+		server.URL + "/missing.png",
+		"not a url",
+	}, spec.Metadata["mediaUrls"])
+}
+
+func TestIconCacheLinkPolicy(t *testing.T) {
+	assert.Nil(t, newIconCache(Config{Name: "reg"}, "", nil))
+	assert.Nil(t, newIconCache(Config{Name: "reg", IconPolicy: IconPolicyLink}, "", nil))
+}
+
+func TestIconCacheConfigMap(t *testing.T) {
+	k, err := clients.Kubernetes()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	k.Client = fake.NewSimpleClientset()
+
+	server := newIconServer()
+	iconURL := server.URL + "/icon.png"
+	config := Config{Name: "reg", IconPolicy: IconPolicyConfigMap}
+	now := time.Unix(1500000000, 0)
+	newSpec := func() *bundle.Spec {
+		return &bundle.Spec{FQName: "reg-bundle", Metadata: map[string]interface{}{"imageUrl": iconURL}}
+	}
+
+	spec := newSpec()
+	newIconCache(config, "broker", nil).rehost(context.Background(), []*bundle.Spec{spec}, now)
+	assert.Equal(t, "data:image/png;base64,cG5n", spec.Metadata["imageUrl"])
+	cm, err := k.Client.CoreV1().ConfigMaps("broker").Get("reg-icons", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, iconURL+"\n1500000000\ndata:image/png;base64,cG5n", cm.Data[iconKey(iconURL)])
+
+	// a new cache, e.g. after a restart, uses the stored asset when the
+	// host is unreachable
+	server.Close()
+	spec = newSpec()
+	newIconCache(config, "broker", nil).rehost(context.Background(), []*bundle.Spec{spec}, now)
+	assert.Equal(t, "data:image/png;base64,cG5n", spec.Metadata["imageUrl"])
+}
+
+func TestIconCacheTTL(t *testing.T) {
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	c := newIconCache(Config{Name: "reg", IconPolicy: IconPolicyDataURI, IconTTL: time.Hour}, "", nil)
+	now := time.Unix(1500000000, 0)
+	rehost := func(now time.Time) {
+		spec := &bundle.Spec{FQName: "reg-bundle", Metadata: map[string]interface{}{"imageUrl": server.URL + "/icon.png"}}
+		c.rehost(context.Background(), []*bundle.Spec{spec}, now)
+		assert.Equal(t, "data:image/png;base64,cG5n", spec.Metadata["imageUrl"])
+	}
+
+	rehost(now)
+	rehost(now.Add(time.Minute))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+	rehost(now.Add(time.Hour))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+}
+
+func TestIconCacheEvict(t *testing.T) {
+	c := newIconCache(Config{Name: "reg", IconPolicy: IconPolicyDataURI}, "", nil)
+	now := time.Unix(1500000000, 0)
+	asset := strings.Repeat("a", iconCacheMaxSize/3)
+	for i, name := range []string{"old", "recent", "newest", "latest"} {
+		c.assets[name] = iconAsset{uri: asset, used: now.Add(time.Duration(i) * time.Minute)}
+	}
+	c.evict()
+	_, ok := c.assets["old"]
+	assert.False(t, ok)
+	assert.Len(t, c.assets, 2)
+}
@@ -0,0 +1,150 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"fmt"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/imageref"
+)
+
+// Severity - How serious a lint finding is.
+type Severity string
+
+const (
+	// SeverityError - the spec is rejected and not made available.
+	SeverityError Severity = "error"
+	// SeverityWarning - the spec is made available, the finding is a
+	// catalog quality issue.
+	SeverityWarning Severity = "warning"
+)
+
+// Finding - A problem found while linting a spec.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	// Field - the part of the spec the finding is about, e.g.
+	// metadata.displayName or plans[dev].description.
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// LintReport - The findings for a spec.
+type LintReport struct {
+	FQName   string    `json:"fqname"`
+	Image    string    `json:"image"`
+	Findings []Finding `json:"findings"`
+}
+
+// Valid - true if the report has no error findings.
+func (r LintReport) Valid() bool {
+	return len(r.Errors()) == 0
+}
+
+// Errors - the findings that reject the spec.
+func (r LintReport) Errors() []Finding {
+	return r.bySeverity(SeverityError)
+}
+
+// Warnings - the findings that do not reject the spec.
+func (r LintReport) Warnings() []Finding {
+	return r.bySeverity(SeverityWarning)
+}
+
+func (r LintReport) bySeverity(severity Severity) []Finding {
+	findings := []Finding{}
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func (r *LintReport) add(severity Severity, field, message string) {
+	r.Findings = append(r.Findings, Finding{Severity: severity, Field: field, Message: message})
+}
+
+// LintSpec - validates the spec. Error findings are the problems that
+// keep the spec from being loaded, warnings are catalog quality issues.
+func LintSpec(spec *bundle.Spec) LintReport {
+	return lintSpec(spec, false)
+}
+
+// LintSpecStrict - validates the spec like LintSpec, the unknown fields of
+// the apb.yml are errors instead of warnings.
+func LintSpecStrict(spec *bundle.Spec) LintReport {
+	return lintSpec(spec, true)
+}
+
+func lintSpec(spec *bundle.Spec, strict bool) LintReport {
+	report := LintReport{FQName: spec.FQName, Image: spec.Image, Findings: []Finding{}}
+
+	unknownSeverity := SeverityWarning
+	if strict {
+		unknownSeverity = SeverityError
+	}
+	for _, field := range spec.UnknownFields {
+		report.add(unknownSeverity, "spec", field)
+	}
+
+	if reason := spec.CheckVersion(); reason != bundle.VersionAccepted {
+		report.add(SeverityError, "version", fmt.Sprintf("Spec [%v] failed version validation - %v", spec.FQName, reason))
+	}
+	// Specs without an image, e.g. OLM operators, are not run as a pod
+	if spec.Image != "" {
+		if _, err := imageref.Parse(spec.Image); err != nil {
+			report.add(SeverityError, "image", err.Error())
+		}
+	}
+	// Specs must have at least one plan
+	if len(spec.Plans) == 0 {
+		report.add(SeverityError, "plans", "Specs must have at least one plan")
+	}
+	dupes := make(map[string]bool)
+	for _, plan := range spec.Plans {
+		if dupes[plan.Name] {
+			report.add(SeverityError, fmt.Sprintf("plans[%v]", plan.Name),
+				fmt.Sprintf("%s: %s", "Plans within a spec must not contain duplicate value", plan.Name))
+		}
+		dupes[plan.Name] = true
+	}
+
+	if s, _ := spec.Metadata["displayName"].(string); s == "" {
+		report.add(SeverityWarning, "metadata.displayName", "Spec has no display name")
+	}
+	if _, err := spec.MetadataCategories(); err != nil {
+		report.add(SeverityWarning, "metadata."+bundle.CategoriesKey, err.Error())
+	}
+	if s, _ := spec.Metadata["imageUrl"].(string); s == "" {
+		report.add(SeverityWarning, "metadata.imageUrl", "Spec has no icon")
+	}
+	for _, plan := range spec.Plans {
+		if plan.Description == "" {
+			report.add(SeverityWarning, fmt.Sprintf("plans[%v].description", plan.Name), "Plan has no description")
+		}
+		for _, params := range [][]bundle.ParameterDescriptor{plan.Parameters, plan.BindParameters} {
+			for _, pd := range params {
+				if _, err := pd.TypedDefault(); err != nil {
+					report.add(SeverityWarning, fmt.Sprintf("plans[%v].parameters[%v].default", plan.Name, pd.Name),
+						fmt.Sprintf("The default is not a valid %v", pd.Type))
+				}
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,160 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintSpec(t *testing.T) {
+	testCases := []struct {
+		name             string
+		spec             *bundle.Spec
+		valid            bool
+		expectedErrors   []string
+		expectedWarnings []string
+	}{
+		{
+			name: "clean spec",
+			spec: &bundle.Spec{
+				Version:  "1.0",
+				Runtime:  2,
+				Metadata: map[string]interface{}{"displayName": "Postgres", "imageUrl": "https://example.com/pg.png"},
+				Plans:    []bundle.Plan{{Name: "dev", Description: "development"}},
+			},
+			valid:            true,
+			expectedErrors:   []string{},
+			expectedWarnings: []string{},
+		},
+		{
+			name: "warnings only",
+			spec: &bundle.Spec{
+				Version: "1.0",
+				Runtime: 2,
+				Plans:   []bundle.Plan{{Name: "dev"}},
+			},
+			valid:            true,
+			expectedErrors:   []string{},
+			expectedWarnings: []string{"metadata.displayName", "metadata.imageUrl", "plans[dev].description"},
+		},
+		{
+			name: "rejected",
+			spec: &bundle.Spec{
+				Version:  "1.0",
+				Runtime:  2,
+				Metadata: map[string]interface{}{"displayName": "Postgres", "imageUrl": "https://example.com/pg.png"},
+				Plans: []bundle.Plan{
+					{Name: "dev", Description: "development"},
+					{Name: "dev", Description: "development"},
+				},
+			},
+			expectedErrors:   []string{"plans[dev]"},
+			expectedWarnings: []string{},
+		},
+		{
+			name: "invalid image",
+			spec: &bundle.Spec{
+				Image:    "/ansibleplaybookbundle/postgresql-apb",
+				Version:  "1.0",
+				Runtime:  2,
+				Metadata: map[string]interface{}{"displayName": "Postgres", "imageUrl": "https://example.com/pg.png"},
+				Plans:    []bundle.Plan{{Name: "dev", Description: "development"}},
+			},
+			expectedErrors:   []string{"image"},
+			expectedWarnings: []string{},
+		},
+		{
+			name: "no plans and bad version",
+			spec: &bundle.Spec{
+				Version:  "0.1",
+				Runtime:  2,
+				Metadata: map[string]interface{}{"displayName": "Postgres", "imageUrl": "https://example.com/pg.png"},
+			},
+			expectedErrors:   []string{"version", "plans"},
+			expectedWarnings: []string{},
+		},
+		{
+			name: "unknown fields",
+			spec: &bundle.Spec{
+				Version:       "1.0",
+				Runtime:       2,
+				Metadata:      map[string]interface{}{"displayName": "Postgres", "imageUrl": "https://example.com/pg.png"},
+				Plans:         []bundle.Plan{{Name: "dev", Description: "development"}},
+				UnknownFields: []string{"line 4: field bindabel not found in type bundle.Spec"},
+			},
+			valid:            true,
+			expectedErrors:   []string{},
+			expectedWarnings: []string{"spec"},
+		},
+		{
+			name: "invalid categories",
+			spec: &bundle.Spec{
+				Version: "1.0",
+				Runtime: 2,
+				Metadata: map[string]interface{}{
+					"displayName":        "Postgres",
+					"imageUrl":           "https://example.com/pg.png",
+					bundle.CategoriesKey: 10,
+				},
+				Plans: []bundle.Plan{{Name: "dev", Description: "development"}},
+			},
+			valid:            true,
+			expectedErrors:   []string{},
+			expectedWarnings: []string{"metadata.categories"},
+		},
+	}
+
+	fields := func(findings []Finding) []string {
+		out := []string{}
+		for _, f := range findings {
+			out = append(out, f.Field)
+		}
+		return out
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			report := LintSpec(tc.spec)
+			assert.Equal(t, tc.valid, report.Valid())
+			assert.Equal(t, tc.expectedErrors, fields(report.Errors()))
+			assert.Equal(t, tc.expectedWarnings, fields(report.Warnings()))
+		})
+	}
+}
+
+func TestLintSpecStrict(t *testing.T) {
+	spec := &bundle.Spec{
+		Version:       "1.0",
+		Runtime:       2,
+		Metadata:      map[string]interface{}{"displayName": "Postgres", "imageUrl": "https://example.com/pg.png"},
+		Plans:         []bundle.Plan{{Name: "dev", Description: "development"}},
+		UnknownFields: []string{"line 4: field bindabel not found in type bundle.Spec"},
+	}
+	report := LintSpecStrict(spec)
+	assert.False(t, report.Valid())
+	assert.Equal(t, []Finding{{
+		Severity: SeverityError,
+		Field:    "spec",
+		Message:  "line 4: field bindabel not found in type bundle.Spec",
+	}}, report.Errors())
+
+	spec.UnknownFields = nil
+	assert.True(t, LintSpecStrict(spec).Valid())
+}
@@ -0,0 +1,119 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"context"
+	"sync"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+const (
+	// specBatchSize - the images handed to the adapter per FetchSpecs call,
+	// bounds the manifests held in memory per worker.
+	specBatchSize = 10
+	// specPipelineWorkers - the batches fetched concurrently.
+	specPipelineWorkers = 4
+)
+
+type specBatch struct {
+	index  int
+	images []string
+}
+
+type specBatchResult struct {
+	index   int
+	specs   []*bundle.Spec
+	reports []LintReport
+	err     error
+}
+
+// loadSpecsPipeline - fetches and validates the specs of the images in
+// batches. The batches flow through bounded channels, image names to the
+// fetch workers and fetched specs to validation, so only the validated
+// specs are kept regardless of the size of the catalog. The validated
+// specs are returned in batch order along with the lint reports of all the
+// specs fetched.
+func (r Registry) loadSpecsPipeline(ctx context.Context, imageNames []string) ([]*bundle.Spec, []LintReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := make(chan specBatch, specPipelineWorkers)
+	results := make(chan specBatchResult, specPipelineWorkers)
+
+	go func() {
+		defer close(batches)
+		index := 0
+		// adapters are always asked once, even without images
+		for start := 0; start == 0 || start < len(imageNames); start += specBatchSize {
+			end := start + specBatchSize
+			if end > len(imageNames) {
+				end = len(imageNames)
+			}
+			select {
+			case batches <- specBatch{index: index, images: imageNames[start:end]}:
+				index++
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < specPipelineWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range batches {
+				specs, err := r.fetchSpecs(ctx, b.images)
+				result := specBatchResult{index: b.index, err: err}
+				if err == nil {
+					result.specs, result.reports = validateSpecs(specs, r.config.StrictSpecs)
+				}
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	validated := map[int]specBatchResult{}
+	for result := range results {
+		if result.err != nil {
+			log.Errorf("unable to fetch specs for registry %v - %v", r.config.Name, result.err)
+			cancel()
+			return nil, nil, result.err
+		}
+		validated[result.index] = result
+	}
+
+	specs := []*bundle.Spec{}
+	reports := []LintReport{}
+	for i := 0; i < len(validated); i++ {
+		specs = append(specs, validated[i].specs...)
+		reports = append(reports, validated[i].reports...)
+	}
+	return specs, reports, nil
+}
@@ -0,0 +1,159 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/tracing"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// batchAdapter - returns a spec per image and records the batch sizes.
+type batchAdapter struct {
+	mutex   sync.Mutex
+	batches []int
+	failOn  string
+}
+
+func (b *batchAdapter) GetImageNames() ([]string, error) {
+	return []string{}, nil
+}
+
+func (b *batchAdapter) FetchSpecs(names []string) ([]*bundle.Spec, error) {
+	b.mutex.Lock()
+	b.batches = append(b.batches, len(names))
+	b.mutex.Unlock()
+
+	specs := []*bundle.Spec{}
+	for _, name := range names {
+		if name == b.failOn {
+			return nil, fmt.Errorf("unable to fetch %v", name)
+		}
+		spec := s
+		spec.Image = name
+		specs = append(specs, &spec)
+	}
+	return specs, nil
+}
+
+func (b *batchAdapter) RegistryName() string {
+	return "batch"
+}
+
+func TestLoadSpecsPipeline(t *testing.T) {
+	images := []string{}
+	for i := 0; i < 2*specBatchSize+5; i++ {
+		images = append(images, fmt.Sprintf("image-%d", i))
+	}
+
+	testCases := []struct {
+		name            string
+		images          []string
+		failOn          string
+		expectedSpecs   int
+		expectedBatches int
+		shouldErr       bool
+	}{
+		{
+			name:            "no images",
+			images:          []string{},
+			expectedSpecs:   0,
+			expectedBatches: 1,
+		},
+		{
+			name:            "images in batches",
+			images:          images,
+			expectedSpecs:   len(images),
+			expectedBatches: 3,
+		},
+		{
+			name:      "batch fails",
+			images:    images,
+			failOn:    "image-12",
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter := &batchAdapter{failOn: tc.failOn}
+			reg := Registry{config: Config{Name: "batch"}, adapter: adapter}
+
+			specs, reports, err := reg.loadSpecsPipeline(context.Background(), tc.images)
+			if tc.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Equal(t, tc.expectedSpecs, len(specs))
+			assert.Equal(t, tc.expectedSpecs, len(reports))
+			assert.Equal(t, tc.expectedBatches, len(adapter.batches))
+			for _, size := range adapter.batches {
+				assert.True(t, size <= specBatchSize)
+			}
+		})
+	}
+}
+
+// specAdapter - a batchAdapter that also fetches the images one at a time.
+type specAdapter struct {
+	batchAdapter
+}
+
+func (a *specAdapter) FetchSpec(image string) (*bundle.Spec, error) {
+	if image == a.failOn {
+		return nil, fmt.Errorf("unable to fetch %v", image)
+	}
+	spec := s
+	spec.Image = image
+	return &spec, nil
+}
+
+func TestFetchSpecsSpanPerImage(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracing.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer tracing.SetTracerProvider(nil)
+
+	adapter := &specAdapter{batchAdapter{failOn: "image-1"}}
+	reg := Registry{config: Config{Name: "batch"}, adapter: adapter}
+	specs, err := reg.fetchSpecs(context.Background(), []string{"image-0", "image-1", "image-2"})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Len(t, specs, 2)
+	assert.Empty(t, adapter.batches)
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans got: %v", len(spans))
+	}
+	batch := spans[3]
+	assert.Equal(t, "registry.FetchSpecs", batch.Name())
+	for _, span := range spans[:3] {
+		assert.Equal(t, "registry.FetchSpec", span.Name())
+		assert.Equal(t, batch.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+}
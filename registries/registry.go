@@ -18,22 +18,44 @@ package registries
 
 import (
 	"bytes"
-	"errors"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/url"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/automationbroker/bundle-lib/bundle"
 	"github.com/automationbroker/bundle-lib/clients"
+	"github.com/automationbroker/bundle-lib/clock"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	"github.com/automationbroker/bundle-lib/idgen"
+	"github.com/automationbroker/bundle-lib/imageref"
+	log "github.com/automationbroker/bundle-lib/logging"
 	"github.com/automationbroker/bundle-lib/registries/adapters"
-	log "github.com/sirupsen/logrus"
+	"github.com/automationbroker/bundle-lib/registries/adapters/oauth"
+	"github.com/automationbroker/bundle-lib/tracing"
+	"go.opentelemetry.io/otel/attribute"
 
-	yaml "gopkg.in/yaml.v1"
+	yaml "gopkg.in/yaml.v2"
 )
 
+const (
+	// DeprecatedShow - deprecated bundles are loaded like the others.
+	DeprecatedShow = "show"
+	// DeprecatedHide - deprecated and end of life bundles are not loaded.
+	DeprecatedHide = "hide"
+	// DeprecatedTag - deprecated and end of life bundles are loaded with
+	// the DeprecatedSpecTag tag.
+	DeprecatedTag = "tag"
+)
+
+// DeprecatedSpecTag - the tag added to deprecated specs when the registry
+// Deprecated option is DeprecatedTag.
+const DeprecatedSpecTag = "deprecated"
+
 var regex = regexp.MustCompile(`[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*`)
 
 // Config - Configuration for the registry
@@ -61,6 +83,77 @@ type Config struct {
 	WhiteList     []string `yaml:"white_list"`
 	BlackList     []string `yaml:"black_list"`
 	SkipVerifyTLS bool     `yaml:"skip_verify_tls"`
+	// Deprecated - how deprecated bundles are handled, one of show, hide
+	// or tag. Defaults to show.
+	Deprecated string `yaml:"deprecated"`
+	// MaxIdleConnsPerHost - the idle connections kept open to the
+	// registry for reuse.
+	MaxIdleConnsPerHost int `yaml:"max_idle_conns_per_host"`
+	// DisableHTTP2 - only talk HTTP/1.1 to the registry.
+	DisableHTTP2 bool `yaml:"disable_http2"`
+	// RetryAttempts - the attempts made for a request to the registry
+	// that fails with a transient error, 1 disables retries.
+	RetryAttempts int `yaml:"retry_attempts"`
+	// RetryBackoff - the wait before the first retry, doubled for every
+	// following retry.
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
+	// MaxImageAgeDays - skip the images that were not pushed within this
+	// many days. Only applied by the adapters that know when images were
+	// pushed, e.g. quay and dockerhub. 0 disables the filter.
+	MaxImageAgeDays int `yaml:"max_image_age_days"`
+	// SignaturePolicy - what happens to the specs that are unsigned or
+	// fail the signature verification, one of ignore, warn or reject.
+	// Defaults to ignore.
+	SignaturePolicy string `yaml:"signature_policy"`
+	// SignatureKey - the file of the PEM encoded public key verifying the
+	// spec signature label, required by the warn and reject policies.
+	SignatureKey string `yaml:"signature_key"`
+	// RefreshInterval - how often the Scheduler refreshes the registry.
+	// Defaults to DefaultRefreshInterval.
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+	// UserAgent - the product put before the one of the library in the
+	// User-Agent of the requests to the registry, e.g. the broker name.
+	UserAgent string `yaml:"user_agent"`
+	// CorrelationHeader - the header carrying the ID of the catalog load
+	// in the requests to the registry. Defaults to X-Request-ID.
+	CorrelationHeader string `yaml:"correlation_header"`
+	// IconPolicy - how the icon and media URLs in the metadata of the specs
+	// are handled, one of link, datauri or configmap. Defaults to link.
+	IconPolicy string `yaml:"icon_policy"`
+	// IconMaxSize - the largest asset that is re-hosted, in bytes.
+	// Defaults to DefaultIconMaxSize.
+	IconMaxSize int64 `yaml:"icon_max_size"`
+	// IconConfigMap - the config map storing the assets with the configmap
+	// policy. Defaults to <name>-icons.
+	IconConfigMap string `yaml:"icon_config_map"`
+	// IconTTL - how long a fetched asset is served before it is fetched
+	// again. Defaults to DefaultIconTTL.
+	IconTTL time.Duration `yaml:"icon_ttl"`
+	// RateLimit - the requests per second sent to the registry by the
+	// built-in adapters. 0 does not limit.
+	RateLimit float64 `yaml:"rate_limit"`
+	// RateBurst - the requests sent at once before RateLimit applies.
+	// Defaults to the rate limit rounded up.
+	RateBurst int `yaml:"rate_burst"`
+	// BreakerThreshold - the failed loads in a row after which the registry
+	// is skipped for BreakerCooldown. While the breaker is enabled a failed
+	// load returns the stale specs, like with KeepStaleSpecs. 0 disables
+	// the breaker.
+	BreakerThreshold int `yaml:"breaker_threshold"`
+	// BreakerCooldown - how long the registry is skipped. Defaults to
+	// DefaultBreakerCooldown.
+	BreakerCooldown time.Duration `yaml:"breaker_cooldown"`
+	// KeepStaleSpecs - a failed load returns the specs of the last
+	// successful load, with their Provenance marked stale, instead of the
+	// error. A registry that never loaded still returns the error.
+	KeepStaleSpecs bool `yaml:"keep_stale_specs"`
+	// PlanIDs - how the IDs of the plans are assigned, legacy or stable.
+	// Defaults to legacy, see bundle.AssignPlanIDs.
+	PlanIDs string `yaml:"plan_ids"`
+	// StrictSpecs - the specs with fields that are not part of the spec,
+	// usually misspelled keys, are rejected instead of loaded with a lint
+	// warning.
+	StrictSpecs bool `yaml:"strict_specs"`
 }
 
 // Validate - makes sure the registry config is valid.
@@ -68,6 +161,33 @@ func (c Config) Validate() bool {
 	if c.Name == "" {
 		return false
 	}
+	switch c.Deprecated {
+	case "", DeprecatedShow, DeprecatedHide, DeprecatedTag:
+	default:
+		return false
+	}
+	if c.RefreshInterval < 0 {
+		return false
+	}
+	switch c.IconPolicy {
+	case "", IconPolicyLink, IconPolicyDataURI, IconPolicyConfigMap:
+	default:
+		return false
+	}
+	if c.IconMaxSize < 0 || c.IconTTL < 0 {
+		return false
+	}
+	switch c.PlanIDs {
+	case "", bundle.PlanIDLegacy, bundle.PlanIDStable:
+	default:
+		return false
+	}
+	if c.RateLimit < 0 || c.RateBurst < 0 || c.BreakerThreshold < 0 || c.BreakerCooldown < 0 {
+		return false
+	}
+	if err := c.ValidateSignaturePolicy(); err != nil {
+		return false
+	}
 	switch c.AuthType {
 	case "file":
 		if c.AuthName == "" {
@@ -105,10 +225,91 @@ type Registry struct {
 	adapter adapters.Adapter
 	filter  Filter
 	config  Config
+	// diagnostics - what happened to the images during the last load.
+	diagnostics *diagnostics
+	// verifier - verifies the spec signatures.
+	verifier SignatureVerifier
+	// imagePolicy - decides which images of the registry are loaded.
+	imagePolicy bundle.ImagePolicy
+	// identity - identifies the requests of the built-in adapters.
+	identity *oauth.RequestIdentity
+	// icons - re-hosts the assets of the specs, nil with the link policy.
+	icons *iconCache
+	// breaker - skips the registry after failed loads, nil when disabled.
+	breaker *circuitBreaker
+	// cache - the specs of the last successful load.
+	cache *specCache
+	// clock - the time of the provenance, the age filter and the breaker,
+	// nil uses clock.Real.
+	clock clock.Clock
+	// ids - generates the correlation IDs of the loads, nil uses
+	// idgen.Random.
+	ids idgen.Generator
 }
 
 // LoadSpecs - Load the specs for the registry.
 func (r Registry) LoadSpecs() ([]*bundle.Spec, int, error) {
+	return r.LoadSpecsContext(context.Background())
+}
+
+// LoadSpecsContext - Load the specs for the registry. The tracing spans
+// created while loading the specs are children of the span in the context.
+// With KeepStaleSpecs or the circuit breaker, the specs of the last
+// successful load are returned marked stale when the registry fails or is
+// skipped.
+func (r Registry) LoadSpecsContext(ctx context.Context) ([]*bundle.Spec, int, error) {
+	if until, open := r.breaker.openUntil(); open {
+		log.Warningf("skipping registry %v until %v after failed loads", r.config.Name, until.Format(time.RFC3339))
+		return r.staleSpecsOr(ErrorRegistryUnavailable{Registry: r.config.Name, Until: until})
+	}
+	specs, count, err := r.loadSpecs(ctx)
+	if err != nil {
+		if r.breaker.failed() {
+			log.Warningf("registry %v failed %d loads in a row, skipping it for %v",
+				r.config.Name, r.breaker.threshold, r.breaker.cooldown)
+		}
+		return r.staleSpecsOr(err)
+	}
+	r.breaker.succeeded()
+	r.cache.store(specs, count)
+	return specs, count, nil
+}
+
+// staleSpecsOr - the stale specs instead of the error of the load, when
+// the registry keeps them and has loaded before.
+func (r Registry) staleSpecsOr(err error) ([]*bundle.Spec, int, error) {
+	if r.breaker == nil && !r.config.KeepStaleSpecs {
+		return []*bundle.Spec{}, 0, err
+	}
+	specs, count, ok := r.cache.stale()
+	if !ok {
+		return []*bundle.Spec{}, 0, err
+	}
+	log.Warningf("returning %d stale specs of registry %v - %v", len(specs), r.config.Name, err)
+	return specs, count, nil
+}
+
+// StaleSpecs - the specs of the last successful load of the registry, with
+// their Provenance marked stale, and the number of images it found. False
+// if the registry never loaded. Lets a consumer keep the services of a
+// registry that failed to load without KeepStaleSpecs.
+func (r Registry) StaleSpecs() ([]*bundle.Spec, int, bool) {
+	return r.cache.stale()
+}
+
+func (r Registry) loadSpecs(ctx context.Context) (specs []*bundle.Spec, count int, err error) {
+	correlationID := idgen.OrRandom(r.ids).NewUUID().String()
+	ctx, span := tracing.StartSpan(ctx, "registry.LoadSpecs",
+		attribute.String("registry.name", r.config.Name),
+		attribute.String("registry.type", r.config.Type),
+		attribute.String("registry.correlation_id", correlationID),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+	if r.identity != nil {
+		r.identity.SetCorrelationID(correlationID)
+		log.Infof("Loading specs of registry %s with correlation id %s", r.config.Name, correlationID)
+	}
+
 	imageNames, err := r.adapter.GetImageNames()
 	if err != nil {
 		log.Errorf("unable to retrieve image names for registry %v - %v",
@@ -116,6 +317,11 @@ func (r Registry) LoadSpecs() ([]*bundle.Spec, int, error) {
 		return []*bundle.Spec{}, 0, err
 	}
 	validNames, filteredNames := r.filter.Run(imageNames)
+	explanations := r.filter.Explain(imageNames)
+	if r.config.MaxImageAgeDays > 0 {
+		validNames, filteredNames, explanations = r.filterByAge(validNames, filteredNames, explanations, r.now())
+	}
+	r.diagnostics.setFilterExplanations(explanations)
 
 	log.Debugf("Filter applied against registry: %s", r.config.Name)
 
@@ -129,28 +335,40 @@ func (r Registry) LoadSpecs() ([]*bundle.Spec, int, error) {
 	if len(filteredNames) != 0 {
 		var buffer bytes.Buffer
 		buffer.WriteString("Bundles filtered by white/blacklist filter:\n")
-		for _, name := range filteredNames {
-			buffer.WriteString(fmt.Sprintf("\t-> %s\n", name))
+		for _, e := range explanations {
+			if !e.Included {
+				buffer.WriteString(fmt.Sprintf("\t-> %s: %s\n", e.Image, e.Reason))
+			}
 		}
 		log.Infof(buffer.String())
 	}
 
-	// Debug output filtered out names.
-	specs, err := r.adapter.FetchSpecs(validNames)
+	// Specs are validated as they are fetched.
+	validatedSpecs, reports, err := r.loadSpecsPipeline(ctx, validNames)
 	if err != nil {
-		log.Errorf("unable to fetch specs for registry %v - %v",
-			r.config.Name, err)
 		return []*bundle.Spec{}, 0, err
 	}
-
-	log.Infof("Validating specs...")
-	validatedSpecs := validateSpecs(specs)
-	failedSpecsCount := len(specs) - len(validatedSpecs)
+	r.diagnostics.setLintReports(reports)
+	fetched := len(reports)
+
+	failedSpecsCount := fetched - len(validatedSpecs)
+	normalizeImages(validatedSpecs)
+	normalizeTaxonomy(validatedSpecs)
+	if r.icons != nil {
+		r.icons.rehost(ctx, validatedSpecs, r.now())
+	}
+	validatedSpecs = r.verifySignatures(validatedSpecs)
+	validatedSpecs = r.applyImagePolicy(validatedSpecs)
+	r.setProvenance(validatedSpecs, r.now().UTC())
+	for _, spec := range validatedSpecs {
+		bundle.AssignPlanIDs(spec, r.config.PlanIDs)
+	}
+	validatedSpecs = filterDeprecated(validatedSpecs, r.config.Deprecated, r.now())
 
 	if failedSpecsCount != 0 {
 		log.Warningf(
 			"%d specs of %d discovered specs failed validation from registry: %s",
-			failedSpecsCount, len(specs), r.adapter.RegistryName())
+			failedSpecsCount, fetched, r.adapter.RegistryName())
 	} else {
 		log.Infof("All specs passed validation!")
 	}
@@ -158,6 +376,45 @@ func (r Registry) LoadSpecs() ([]*bundle.Spec, int, error) {
 	return validatedSpecs, len(imageNames), nil
 }
 
+func (r Registry) fetchSpecs(ctx context.Context, imageNames []string) ([]*bundle.Spec, error) {
+	ctx, span := tracing.StartSpan(ctx, "registry.FetchSpecs",
+		attribute.String("registry.name", r.config.Name),
+		attribute.StringSlice("registry.images", imageNames),
+	)
+	var specs []*bundle.Spec
+	var err error
+	if fetcher, ok := r.adapter.(adapters.SpecAdapter); ok {
+		specs = r.fetchEachSpec(ctx, fetcher, imageNames)
+	} else {
+		specs, err = r.adapter.FetchSpecs(imageNames)
+	}
+	span.SetAttributes(attribute.Int("registry.specs", len(specs)))
+	tracing.EndSpan(span, err)
+	return specs, err
+}
+
+// fetchEachSpec - fetches the spec of every image in a span of its own. The
+// images whose spec can not be fetched are skipped, as the adapters do.
+func (r Registry) fetchEachSpec(ctx context.Context, fetcher adapters.SpecAdapter, imageNames []string) []*bundle.Spec {
+	specs := []*bundle.Spec{}
+	for _, image := range imageNames {
+		_, span := tracing.StartSpan(ctx, "registry.FetchSpec",
+			attribute.String("registry.name", r.config.Name),
+			attribute.String("registry.image", image),
+		)
+		spec, err := fetcher.FetchSpec(image)
+		if err != nil {
+			log.Errorf("Failed to retrieve spec data for image %s - %v", image, err)
+		}
+		span.SetAttributes(attribute.Bool("registry.spec", spec != nil))
+		tracing.EndSpan(span, err)
+		if spec != nil {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
 // Fail - will determine if the registry should cause a failure.
 func (r Registry) Fail(err error) bool {
 	if r.config.Fail {
@@ -166,15 +423,38 @@ func (r Registry) Fail(err error) bool {
 	return false
 }
 
+// LintReports - the lint reports of the specs fetched by the last load of
+// the registry, including the rejected specs.
+func (r Registry) LintReports() []LintReport {
+	return r.diagnostics.lintReports()
+}
+
+// FilterExplanations - why each image found by the last load of the
+// registry passed or failed the white and blacklists.
+func (r Registry) FilterExplanations() []FilterExplanation {
+	return r.diagnostics.filterExplanations()
+}
+
 // RegistryName - retrieve the registry name to allow namespacing.
 func (r Registry) RegistryName() string {
 	return r.config.Name
 }
 
+// CircuitOpen - true while the registry is skipped by its circuit breaker.
+func (r Registry) CircuitOpen() bool {
+	_, open := r.breaker.openUntil()
+	return open
+}
+
+// RefreshInterval - how often the registry is to be refreshed.
+func (r Registry) RefreshInterval() time.Duration {
+	return r.config.RefreshInterval
+}
+
 // NewCustomRegistry - Create a new registry from the registry config.
 func NewCustomRegistry(configuration Config, adapter adapters.Adapter, asbNamespace string) (Registry, error) {
 	if !configuration.Validate() {
-		return Registry{}, errors.New("unable to validate registry name")
+		return Registry{}, liberrors.New(liberrors.CodeValidation, "unable to validate registry name")
 	}
 
 	// Retrieve registry auth if defined.
@@ -199,19 +479,31 @@ func NewCustomRegistry(configuration Config, adapter adapters.Adapter, asbNamesp
 		u.Scheme = "http"
 	}
 
+	var identity *oauth.RequestIdentity
 	if adapter == nil {
+		// a custom adapter makes its own requests and is not rate limited
+		identity = &oauth.RequestIdentity{
+			UserAgent:         configuration.UserAgent,
+			CorrelationHeader: configuration.CorrelationHeader,
+		}
 		c := adapters.Configuration{
-			URL:           u,
-			User:          configuration.User,
-			Pass:          configuration.Pass,
-			Token:         configuration.Token,
-			Org:           configuration.Org,
-			Runner:        configuration.Runner,
-			Images:        configuration.Images,
-			Namespaces:    configuration.Namespaces,
-			Tag:           configuration.Tag,
-			SkipVerifyTLS: configuration.SkipVerifyTLS,
-			AdapterName:   configuration.Name,
+			URL:                 u,
+			User:                configuration.User,
+			Pass:                configuration.Pass,
+			Token:               configuration.Token,
+			Org:                 configuration.Org,
+			Runner:              configuration.Runner,
+			Images:              configuration.Images,
+			Namespaces:          configuration.Namespaces,
+			Tag:                 configuration.Tag,
+			SkipVerifyTLS:       configuration.SkipVerifyTLS,
+			AdapterName:         configuration.Name,
+			MaxIdleConnsPerHost: configuration.MaxIdleConnsPerHost,
+			DisableHTTP2:        configuration.DisableHTTP2,
+			RetryAttempts:       configuration.RetryAttempts,
+			RetryBackoff:        configuration.RetryBackoff,
+			Identity:            identity,
+			RateLimiter:         oauth.NewRateLimiter(configuration.RateLimit, configuration.RateBurst),
 		}
 
 		switch strings.ToLower(configuration.Type) {
@@ -235,11 +527,13 @@ func NewCustomRegistry(configuration Config, adapter adapters.Adapter, asbNamesp
 			adapter = adapters.NewQuayAdapter(c)
 		case "galaxy":
 			adapter = &adapters.GalaxyAdapter{Config: c}
+		case "olm":
+			adapter = &adapters.OLMAdapter{Config: c}
 		case "registry_proxy":
 			adapter, err = adapters.NewRegistryProxyAdapter(c)
 		default:
 			log.Errorf("Unknown registry type - %s", configuration.Type)
-			return Registry{}, errors.New("Unknown registry type")
+			return Registry{}, liberrors.New(liberrors.CodeValidation, "Unknown registry type")
 		}
 		if err != nil {
 			return Registry{}, err
@@ -248,10 +542,30 @@ func NewCustomRegistry(configuration Config, adapter adapters.Adapter, asbNamesp
 		log.Infof("Using custom adapter, %v", adapter.RegistryName())
 	}
 
+	var verifier SignatureVerifier
+	if configuration.SignatureKey != "" {
+		pemKey, err := ioutil.ReadFile(configuration.SignatureKey)
+		if err != nil {
+			log.Errorf("Unable to read the signature key: %v", err)
+			return Registry{}, err
+		}
+		verifier, err = NewKeyVerifier(pemKey)
+		if err != nil {
+			log.Errorf("Unable to parse the signature key: %v", err)
+			return Registry{}, err
+		}
+	}
+
 	return Registry{
-		adapter: adapter,
-		filter:  createFilter(configuration),
-		config:  configuration,
+		adapter:     adapter,
+		filter:      createFilter(configuration),
+		config:      configuration,
+		diagnostics: &diagnostics{},
+		verifier:    verifier,
+		identity:    identity,
+		icons:       newIconCache(configuration, asbNamespace, identity),
+		breaker:     newCircuitBreaker(configuration),
+		cache:       &specCache{},
 	}, nil
 }
 
@@ -290,22 +604,28 @@ func createFilter(config Config) Filter {
 	return filter
 }
 
-func validateSpecs(inSpecs []*bundle.Spec) []*bundle.Spec {
+// validateSpecs - lints the specs and returns the valid ones along with
+// the lint report of every spec. With strict the unknown fields of the
+// specs reject them.
+func validateSpecs(inSpecs []*bundle.Spec, strict bool) ([]*bundle.Spec, []LintReport) {
+	lint := LintSpec
+	if strict {
+		lint = LintSpecStrict
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(len(inSpecs))
 
 	type resultT struct {
-		ok         bool
-		spec       *bundle.Spec
-		failReason string
+		spec   *bundle.Spec
+		report LintReport
 	}
 
 	out := make(chan resultT)
 	for _, spec := range inSpecs {
 		go func(s *bundle.Spec) {
 			defer wg.Done()
-			ok, failReason := validateSpecFormat(s)
-			out <- resultT{ok, s, failReason}
+			out <- resultT{s, lint(s)}
 		}(spec)
 	}
 
@@ -315,43 +635,175 @@ func validateSpecs(inSpecs []*bundle.Spec) []*bundle.Spec {
 	}()
 
 	validSpecs := make([]*bundle.Spec, 0, len(inSpecs))
+	reports := make([]LintReport, 0, len(inSpecs))
 	for result := range out {
-		if result.ok {
+		reports = append(reports, result.report)
+		for _, w := range result.report.Warnings() {
+			log.Debugf("Spec [ %s ] %s: %s", result.spec.FQName, w.Field, w.Message)
+		}
+		if result.report.Valid() {
 			validSpecs = append(validSpecs, result.spec)
 		} else {
 			log.Warningf(
 				"Spec [ %s ] failed validation for the following reason: [ %s ]. "+
 					"It will not be made available.",
-				result.spec.FQName, result.failReason,
+				result.spec.FQName, result.report.Errors()[0].Message,
 			)
 		}
 	}
 
-	return validSpecs
+	return validSpecs, reports
 }
 
-func validateSpecFormat(spec *bundle.Spec) (bool, string) {
-	if !spec.ValidateVersion() {
-		return false, fmt.Sprintf("Spec [%v] failed version validation", spec.FQName)
+// SetImagePolicy - sets the policy deciding which images of the registry
+// are loaded.
+func (r *Registry) SetImagePolicy(policy bundle.ImagePolicy) {
+	r.imagePolicy = policy
+}
+
+// SetClock - sets the source of the time used by the loads of the registry.
+func (r *Registry) SetClock(c clock.Clock) {
+	r.clock = c
+	if r.breaker != nil {
+		r.breaker.setNow(clock.OrReal(c).Now)
 	}
+}
+
+// SetIDGenerator - sets the source of the correlation IDs of the loads of
+// the registry.
+func (r *Registry) SetIDGenerator(ids idgen.Generator) {
+	r.ids = ids
+}
+
+func (r Registry) now() time.Time {
+	return clock.OrReal(r.clock).Now()
+}
 
-	// Specs must have at least one plan
-	if !(len(spec.Plans) > 0) {
-		return false, "Specs must have at least one plan"
+// applyImagePolicy - drops the specs whose image is denied by the image
+// policy. Specs whose image could not be evaluated are kept, the executor
+// consults the policy again before running them.
+func (r Registry) applyImagePolicy(specs []*bundle.Spec) []*bundle.Spec {
+	if r.imagePolicy == nil {
+		return specs
 	}
+	admitted := make([]*bundle.Spec, 0, len(specs))
+	for _, spec := range specs {
+		err := bundle.CheckImagePolicy(r.imagePolicy, spec)
+		if bundle.IsErrImageDenied(err) {
+			log.Warningf("Spec [ %s ] will not be loaded - %v", spec.FQName, err)
+			continue
+		}
+		admitted = append(admitted, spec)
+	}
+	return admitted
+}
 
-	dupes := make(map[string]bool)
-	for _, plan := range spec.Plans {
-		if _, contains := dupes[plan.Name]; contains {
-			reason := fmt.Sprintf("%s: %s",
-				"Plans within a spec must not contain duplicate value", plan.Name)
+// normalizeImages - replaces the image of the specs with its fully
+// qualified reference. The images were validated by LintSpec.
+func normalizeImages(specs []*bundle.Spec) {
+	for _, spec := range specs {
+		if spec.Image == "" {
+			continue
+		}
+		if image, err := imageref.Normalize(spec.Image); err == nil {
+			spec.Image = image
+		}
+	}
+}
+
+// normalizeTaxonomy - normalizes the tags and sets the categories of the
+// specs. The categories that can not be read were reported by LintSpec.
+func normalizeTaxonomy(specs []*bundle.Spec) {
+	for _, spec := range specs {
+		bundle.NormalizeTaxonomy(spec)
+	}
+}
+
+// setProvenance - records where the specs were loaded from, keeping the
+// digest set by the adapter.
+func (r Registry) setProvenance(specs []*bundle.Spec, fetchedAt time.Time) {
+	for _, spec := range specs {
+		p := bundle.Provenance{}
+		if spec.Provenance != nil {
+			p = *spec.Provenance
+		}
+		p.Registry = r.config.Name
+		p.AdapterType = r.config.Type
+		p.Source = spec.Image
+		p.FetchedAt = fetchedAt
+		spec.Provenance = &p
+	}
+}
+
+// filterByAge - moves the images not pushed within MaxImageAgeDays from
+// validNames to filteredNames. Images without a known push time are kept.
+func (r Registry) filterByAge(
+	validNames, filteredNames []string, explanations []FilterExplanation, now time.Time,
+) ([]string, []string, []FilterExplanation) {
+	adapter, ok := r.adapter.(adapters.LastPushedAdapter)
+	if !ok {
+		log.Warningf("registry %v does not know when images were pushed, max_image_age_days is ignored", r.config.Name)
+		return validNames, filteredNames, explanations
+	}
+	pushed, err := adapter.LastPushed(validNames)
+	if err != nil {
+		log.Errorf("unable to get the push times for registry %v, not filtering by age - %v", r.config.Name, err)
+		return validNames, filteredNames, explanations
+	}
 
-			return false, reason
+	cutoff := now.AddDate(0, 0, -r.config.MaxImageAgeDays)
+	tooOld := map[string]bool{}
+	kept := make([]string, 0, len(validNames))
+	for _, name := range validNames {
+		if t, ok := pushed[name]; ok && t.Before(cutoff) {
+			tooOld[name] = true
+			filteredNames = append(filteredNames, name)
+			continue
 		}
-		dupes[plan.Name] = true
+		kept = append(kept, name)
 	}
+	for i, e := range explanations {
+		if tooOld[e.Image] {
+			explanations[i].Included = false
+			explanations[i].Reason = fmt.Sprintf("not pushed within %d days, last pushed %v",
+				r.config.MaxImageAgeDays, pushed[e.Image].Format(time.RFC3339))
+		}
+	}
+	return kept, filteredNames, explanations
+}
+
+// filterDeprecated - hides or tags the deprecated and end of life specs
+// depending on the deprecated option of the registry.
+func filterDeprecated(specs []*bundle.Spec, option string, now time.Time) []*bundle.Spec {
+	if option == "" || option == DeprecatedShow {
+		return specs
+	}
+	filtered := make([]*bundle.Spec, 0, len(specs))
+	for _, spec := range specs {
+		l := spec.Lifecycle()
+		if !l.Deprecated && !l.IsEndOfLife(now) {
+			filtered = append(filtered, spec)
+			continue
+		}
+		if option == DeprecatedHide {
+			log.Infof("Spec [ %s ] is deprecated and will not be loaded", spec.FQName)
+			continue
+		}
+		if !hasTag(spec.Tags, DeprecatedSpecTag) {
+			spec.Tags = append(spec.Tags, DeprecatedSpecTag)
+		}
+		filtered = append(filtered, spec)
+	}
+	return filtered
+}
 
-	return true, ""
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
 }
 
 func retrieveRegistryAuth(reg Config, asbNamespace string) (Config, error) {
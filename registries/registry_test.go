@@ -19,6 +19,7 @@ package registries
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -430,7 +431,7 @@ func TestRegistryLoadSpecs(t *testing.T) {
 			r:    setUpValidNameFilter(),
 			validate: func(specs []*bundle.Spec, images int, err error) bool {
 				return assert.Equal(t, len(specs), 1) &&
-					assert.Equal(t, "fusor/etherpad-bundle", specs[0].Image)
+					assert.Equal(t, "docker.io/fusor/etherpad-bundle:latest", specs[0].Image)
 			},
 			expectederr: false,
 		},
@@ -778,6 +779,16 @@ func TestValidate(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name:     "unknown deprecated option",
+			c:        Config{Name: "daname", Deprecated: "remove"},
+			expected: false,
+		},
+		{
+			name:     "deprecated option hide",
+			c:        Config{Name: "daname", Deprecated: DeprecatedHide},
+			expected: true,
+		},
 		{
 			name: "valid name, empty authtype, non-empty authname",
 			c: Config{
@@ -898,6 +909,67 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestFilterDeprecated(t *testing.T) {
+	now := time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC)
+	newSpecs := func() []*bundle.Spec {
+		return []*bundle.Spec{
+			{FQName: "current", Tags: []string{"database"}},
+			{FQName: "deprecated", Metadata: map[string]interface{}{bundle.DeprecatedKey: true}},
+			{FQName: "eol", Metadata: map[string]interface{}{bundle.EndOfLifeKey: "2019-06-30"}},
+			{FQName: "future-eol", Metadata: map[string]interface{}{bundle.EndOfLifeKey: "2020-06-30"}},
+		}
+	}
+	names := func(specs []*bundle.Spec) []string {
+		n := []string{}
+		for _, s := range specs {
+			n = append(n, s.FQName)
+		}
+		return n
+	}
+
+	assert.Equal(t, 4, len(filterDeprecated(newSpecs(), "", now)))
+	assert.Equal(t, 4, len(filterDeprecated(newSpecs(), DeprecatedShow, now)))
+
+	hidden := filterDeprecated(newSpecs(), DeprecatedHide, now)
+	assert.Equal(t, []string{"current", "future-eol"}, names(hidden))
+
+	tagged := filterDeprecated(newSpecs(), DeprecatedTag, now)
+	assert.Equal(t, 4, len(tagged))
+	assert.Equal(t, []string{"database"}, tagged[0].Tags)
+	assert.Equal(t, []string{DeprecatedSpecTag}, tagged[1].Tags)
+	assert.Equal(t, []string{DeprecatedSpecTag}, tagged[2].Tags)
+	assert.Nil(t, tagged[3].Tags)
+}
+
+func TestSetProvenance(t *testing.T) {
+	fetched := time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC)
+	r := Registry{config: Config{Name: "dh", Type: "dockerhub"}}
+	specs := []*bundle.Spec{
+		{FQName: "no-digest", Image: "docker.io/foo/no-digest:latest"},
+		{
+			FQName:     "digest",
+			Image:      "docker.io/foo/digest:latest",
+			Provenance: &bundle.Provenance{Digest: "sha256:abc"},
+		},
+	}
+
+	r.setProvenance(specs, fetched)
+
+	assert.Equal(t, &bundle.Provenance{
+		Registry:    "dh",
+		AdapterType: "dockerhub",
+		Source:      "docker.io/foo/no-digest:latest",
+		FetchedAt:   fetched,
+	}, specs[0].Provenance)
+	assert.Equal(t, &bundle.Provenance{
+		Registry:    "dh",
+		AdapterType: "dockerhub",
+		Source:      "docker.io/foo/digest:latest",
+		Digest:      "sha256:abc",
+		FetchedAt:   fetched,
+	}, specs[1].Provenance)
+}
+
 type fakeAdapter struct{}
 
 func (f fakeAdapter) GetImageNames() ([]string, error) {
@@ -1110,3 +1182,50 @@ func TestRetrieveRegistryAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyImagePolicy(t *testing.T) {
+	specs := []*bundle.Spec{
+		{FQName: "clean-apb", Image: "docker.io/clean-apb:latest"},
+		{
+			FQName:     "vulnerable-apb",
+			Image:      "docker.io/vulnerable-apb:latest",
+			Provenance: &bundle.Provenance{Digest: "sha256:bad"},
+		},
+		{FQName: "unknown-apb", Image: "docker.io/unknown-apb:latest"},
+	}
+	r := Registry{config: Config{Name: "dh"}}
+	r.SetImagePolicy(bundle.ImagePolicyFunc(func(ref bundle.ImageReference) (bundle.ImageDecision, error) {
+		switch {
+		case ref.Digest == "sha256:bad":
+			return bundle.ImageDecision{Reason: "CVE-2018-1000001 is critical"}, nil
+		case ref.Image == "docker.io/unknown-apb:latest":
+			return bundle.ImageDecision{}, fmt.Errorf("scanner unavailable")
+		}
+		return bundle.ImageDecision{Allowed: true}, nil
+	}))
+	loaded := r.applyImagePolicy(specs)
+	assert.Len(t, loaded, 2)
+	assert.Equal(t, "clean-apb", loaded[0].FQName)
+	assert.Equal(t, "unknown-apb", loaded[1].FQName)
+}
+
+func TestNewRegistryIdentity(t *testing.T) {
+	r, err := NewRegistry(Config{
+		Name:              "mock",
+		Type:              "mock",
+		UserAgent:         "ansible-service-broker/1.4",
+		CorrelationHeader: "X-Correlation-ID",
+	}, "")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "ansible-service-broker/1.4", r.identity.UserAgent)
+	assert.Equal(t, "X-Correlation-ID", r.identity.CorrelationHeader)
+	assert.True(t, r.adapter.(*adapters.MockAdapter).Config.Identity == r.identity)
+
+	custom, err := NewCustomRegistry(Config{Name: "custom"}, TestingAdapter{}, "")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Nil(t, custom.identity)
+}
@@ -0,0 +1,308 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/clock"
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+const (
+	// DefaultRefreshInterval - how often a registry without a
+	// RefreshInterval is refreshed.
+	DefaultRefreshInterval = 15 * time.Minute
+	// DefaultRefreshJitter - the fraction of the interval a refresh is
+	// moved earlier or later by, so registries added together do not
+	// refresh together.
+	DefaultRefreshJitter = 0.1
+)
+
+// Refresher - What the Scheduler refreshes, satisfied by Registry.
+type Refresher interface {
+	RegistryName() string
+	LoadSpecsContext(ctx context.Context) ([]*bundle.Spec, int, error)
+}
+
+// Leader - Tells if the replica is the elected leader, e.g. a
+// leaderelection.Elector.
+type Leader interface {
+	IsLeader() bool
+}
+
+// Delta - The specs of a registry that changed with a refresh. Specs are
+// matched by FQName, a spec is updated when anything but its Provenance
+// changed.
+type Delta struct {
+	Registry string
+	Added    []*bundle.Spec
+	Updated  []*bundle.Spec
+	Removed  []*bundle.Spec
+}
+
+// Empty - true if nothing changed.
+func (d Delta) Empty() bool {
+	return len(d.Added) == 0 && len(d.Updated) == 0 && len(d.Removed) == 0
+}
+
+// DeltaFunc - called with the changes of every refresh that changed
+// something. The calls for a registry are never concurrent.
+type DeltaFunc func(Delta)
+
+// scheduledRegistry - a registry, its interval and the specs of its last
+// successful refresh.
+type scheduledRegistry struct {
+	refresher Refresher
+	interval  time.Duration
+	// running - set while the registry is refreshed, a refresh requested
+	// meanwhile is skipped.
+	running int32
+	specs   map[string]*bundle.Spec
+}
+
+// Scheduler - Refreshes every registry added to it on its own interval
+// and hands the changes to the subscribers.
+type Scheduler struct {
+	mutex       sync.Mutex
+	jitter      float64
+	random      *rand.Rand
+	registries  map[string]*scheduledRegistry
+	subscribers []DeltaFunc
+	leader      Leader
+	clock       clock.Clock
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// NewScheduler - creates a scheduler moving every refresh by up to jitter
+// times the interval of the registry, e.g. 0.1 for 10%. A jitter outside
+// of [0, 1) is replaced with DefaultRefreshJitter.
+func NewScheduler(jitter float64) *Scheduler {
+	if jitter < 0 || jitter >= 1 {
+		log.Warnf("invalid refresh jitter %v, using %v", jitter, DefaultRefreshJitter)
+		jitter = DefaultRefreshJitter
+	}
+	return &Scheduler{
+		jitter:     jitter,
+		random:     rand.New(rand.NewSource(clock.Real.Now().UnixNano())),
+		registries: map[string]*scheduledRegistry{},
+	}
+}
+
+// Add - schedules the refresh of the registry every interval, or every
+// DefaultRefreshInterval when the interval is not positive. Registries
+// must be added before the scheduler is started.
+func (s *Scheduler) Add(r Refresher, interval time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.cancel != nil {
+		return fmt.Errorf("unable to add registry %v, the scheduler is running", r.RegistryName())
+	}
+	if _, ok := s.registries[r.RegistryName()]; ok {
+		return fmt.Errorf("registry %v is already scheduled", r.RegistryName())
+	}
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	s.registries[r.RegistryName()] = &scheduledRegistry{
+		refresher: r,
+		interval:  interval,
+		specs:     map[string]*bundle.Spec{},
+	}
+	return nil
+}
+
+// SetLeader - only refresh the registries while the replica is the
+// leader, so the replicas of an HA deployment do not all crawl the
+// registries. A nil leader refreshes on every replica.
+func (s *Scheduler) SetLeader(leader Leader) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.leader = leader
+}
+
+// SetClock - sets the source of the time the refreshes are scheduled
+// with, nil uses clock.Real. The clock must be set before the scheduler is
+// started.
+func (s *Scheduler) SetClock(c clock.Clock) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clock = c
+	s.random = rand.New(rand.NewSource(clock.OrReal(c).Now().UnixNano()))
+}
+
+// Subscribe - calls f with the changes of every following refresh.
+func (s *Scheduler) Subscribe(f DeltaFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.subscribers = append(s.subscribers, f)
+}
+
+// Start - refreshes every registry right away and then on its interval,
+// until Stop is called or the context is done.
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.cancel != nil {
+		return fmt.Errorf("the scheduler is already running")
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	clk := clock.OrReal(s.clock)
+	for _, reg := range s.registries {
+		s.wg.Add(1)
+		go s.run(ctx, clk, reg)
+	}
+	return nil
+}
+
+// Stop - stops the refreshes and waits for the running ones to finish.
+func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mutex.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	s.wg.Wait()
+}
+
+// Refresh - refreshes the named registry now, without waiting for its
+// interval. It returns false when the registry is unknown, already being
+// refreshed or the replica is not the leader.
+func (s *Scheduler) Refresh(ctx context.Context, name string) bool {
+	s.mutex.Lock()
+	reg, ok := s.registries[name]
+	s.mutex.Unlock()
+	if !ok {
+		return false
+	}
+	return s.refresh(ctx, reg)
+}
+
+func (s *Scheduler) run(ctx context.Context, clk clock.Clock, reg *scheduledRegistry) {
+	defer s.wg.Done()
+	for {
+		s.refresh(ctx, reg)
+		// a new ticker for every refresh, the jitter moves each of them
+		ticker := clk.NewTicker(s.next(reg.interval))
+		select {
+		case <-ctx.Done():
+			ticker.Stop()
+			return
+		case <-ticker.C():
+			ticker.Stop()
+		}
+	}
+}
+
+// next - the interval moved by a random part of the jitter.
+func (s *Scheduler) next(interval time.Duration) time.Duration {
+	s.mutex.Lock()
+	offset := (s.random.Float64()*2 - 1) * s.jitter
+	s.mutex.Unlock()
+	return interval + time.Duration(offset*float64(interval))
+}
+
+// refresh - loads the specs of the registry and publishes the changes,
+// unless the registry is already being refreshed or the replica is not the
+// leader. A failed refresh keeps the specs of the last successful one.
+func (s *Scheduler) refresh(ctx context.Context, reg *scheduledRegistry) bool {
+	s.mutex.Lock()
+	leader := s.leader
+	s.mutex.Unlock()
+	if leader != nil && !leader.IsLeader() {
+		log.Debugf("not the leader, skipping the refresh of registry %v", reg.refresher.RegistryName())
+		return false
+	}
+	if !atomic.CompareAndSwapInt32(&reg.running, 0, 1) {
+		log.Debugf("registry %v is already being refreshed", reg.refresher.RegistryName())
+		return false
+	}
+	defer atomic.StoreInt32(&reg.running, 0)
+
+	specs, _, err := reg.refresher.LoadSpecsContext(ctx)
+	if err != nil {
+		log.Errorf("unable to refresh registry %v - %v", reg.refresher.RegistryName(), err)
+		return true
+	}
+	delta, current := diffSpecs(reg.refresher.RegistryName(), reg.specs, specs)
+	reg.specs = current
+	if delta.Empty() {
+		return true
+	}
+
+	s.mutex.Lock()
+	subscribers := append([]DeltaFunc{}, s.subscribers...)
+	s.mutex.Unlock()
+	for _, f := range subscribers {
+		f(delta)
+	}
+	return true
+}
+
+// diffSpecs - the changes from the previous to the loaded specs, and the
+// loaded specs by FQName.
+func diffSpecs(registry string, previous map[string]*bundle.Spec, loaded []*bundle.Spec) (Delta, map[string]*bundle.Spec) {
+	delta := Delta{Registry: registry}
+	current := map[string]*bundle.Spec{}
+	for _, spec := range loaded {
+		current[spec.FQName] = spec
+		old, ok := previous[spec.FQName]
+		switch {
+		case !ok:
+			delta.Added = append(delta.Added, spec)
+		case !sameSpec(old, spec):
+			delta.Updated = append(delta.Updated, spec)
+		}
+	}
+	for name, spec := range previous {
+		if _, ok := current[name]; !ok {
+			delta.Removed = append(delta.Removed, spec)
+		}
+	}
+	sort.Slice(delta.Removed, func(i, j int) bool {
+		return delta.Removed[i].FQName < delta.Removed[j].FQName
+	})
+	return delta, current
+}
+
+// sameSpec - true if the specs only differ in their Provenance, which
+// changes with every load.
+func sameSpec(a, b *bundle.Spec) bool {
+	ca, cb := *a, *b
+	ca.Provenance, cb.Provenance = nil, nil
+	ja, err := json.Marshal(ca)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(cb)
+	if err != nil {
+		return false
+	}
+	return string(ja) == string(jb)
+}
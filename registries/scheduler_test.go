@@ -0,0 +1,235 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptedRefresher - returns the next set of specs on every load.
+type scriptedRefresher struct {
+	mutex sync.Mutex
+	name  string
+	loads [][]*bundle.Spec
+	calls int
+	block chan struct{}
+}
+
+func (r *scriptedRefresher) RegistryName() string {
+	return r.name
+}
+
+func (r *scriptedRefresher) LoadSpecsContext(ctx context.Context) ([]*bundle.Spec, int, error) {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	call := r.calls
+	r.calls++
+	if call >= len(r.loads) {
+		call = len(r.loads) - 1
+	}
+	if r.loads[call] == nil {
+		return nil, 0, fmt.Errorf("registry unavailable")
+	}
+	return r.loads[call], len(r.loads[call]), nil
+}
+
+func schedulerSpec(name, version string) *bundle.Spec {
+	return &bundle.Spec{
+		FQName:     name,
+		Image:      "docker.io/automationbroker/" + name,
+		Version:    version,
+		Provenance: &bundle.Provenance{FetchedAt: time.Now()},
+	}
+}
+
+func TestDiffSpecs(t *testing.T) {
+	previous := map[string]*bundle.Spec{
+		"kept":    schedulerSpec("kept", "1.0"),
+		"changed": schedulerSpec("changed", "1.0"),
+		"b-gone":  schedulerSpec("b-gone", "1.0"),
+		"a-gone":  schedulerSpec("a-gone", "1.0"),
+	}
+	loaded := []*bundle.Spec{
+		schedulerSpec("kept", "1.0"),
+		schedulerSpec("changed", "2.0"),
+		schedulerSpec("new", "1.0"),
+	}
+
+	delta, current := diffSpecs("reg", previous, loaded)
+	assert.Equal(t, "reg", delta.Registry)
+	assert.Equal(t, []*bundle.Spec{loaded[2]}, delta.Added)
+	assert.Equal(t, []*bundle.Spec{loaded[1]}, delta.Updated)
+	assert.Equal(t, []*bundle.Spec{previous["a-gone"], previous["b-gone"]}, delta.Removed)
+	assert.Len(t, current, 3)
+
+	delta, _ = diffSpecs("reg", current, loaded)
+	assert.True(t, delta.Empty())
+}
+
+func TestSchedulerPublishesDeltas(t *testing.T) {
+	refresher := &scriptedRefresher{
+		name: "reg",
+		loads: [][]*bundle.Spec{
+			{schedulerSpec("one", "1.0")},
+			nil,
+			{schedulerSpec("one", "1.0")},
+			{schedulerSpec("two", "1.0")},
+		},
+	}
+	s := NewScheduler(0.5)
+	if err := s.Add(refresher, 5*time.Millisecond); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	deltas := make(chan Delta, 10)
+	s.Subscribe(func(d Delta) { deltas <- d })
+
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	defer s.Stop()
+
+	first := <-deltas
+	assert.Len(t, first.Added, 1)
+	assert.Equal(t, "one", first.Added[0].FQName)
+
+	// the failed load and the unchanged load publish nothing
+	second := <-deltas
+	assert.Equal(t, "two", second.Added[0].FQName)
+	assert.Equal(t, "one", second.Removed[0].FQName)
+	assert.Empty(t, second.Updated)
+}
+
+func TestSchedulerClock(t *testing.T) {
+	refresher := &scriptedRefresher{
+		name: "reg",
+		loads: [][]*bundle.Spec{
+			{schedulerSpec("one", "1.0")},
+			{schedulerSpec("two", "1.0")},
+		},
+	}
+	fake := clock.NewFake(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler(0)
+	s.SetClock(fake)
+	if err := s.Add(refresher, time.Hour); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	deltas := make(chan Delta, 10)
+	s.Subscribe(func(d Delta) { deltas <- d })
+
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	defer s.Stop()
+
+	first := <-deltas
+	assert.Equal(t, "one", first.Added[0].FQName)
+	for fake.Tickers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-deltas:
+		t.Fatalf("refreshed before the interval")
+	default:
+	}
+
+	fake.Advance(time.Hour)
+	second := <-deltas
+	assert.Equal(t, "two", second.Added[0].FQName)
+}
+
+func TestSchedulerSkipsOverlappingRefresh(t *testing.T) {
+	refresher := &scriptedRefresher{
+		name:  "reg",
+		loads: [][]*bundle.Spec{{schedulerSpec("one", "1.0")}},
+		block: make(chan struct{}),
+	}
+	s := NewScheduler(0)
+	if err := s.Add(refresher, time.Hour); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	// the initial refresh is blocked in the refresher
+	for i := 0; i < 1000 && atomic.LoadInt32(&s.registries["reg"].running) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.False(t, s.Refresh(context.Background(), "reg"))
+	assert.False(t, s.Refresh(context.Background(), "unknown"))
+
+	close(refresher.block)
+	s.Stop()
+	assert.Equal(t, 1, refresher.calls)
+}
+
+func TestSchedulerAdd(t *testing.T) {
+	s := NewScheduler(2)
+	assert.Equal(t, DefaultRefreshJitter, s.jitter)
+
+	refresher := &scriptedRefresher{name: "reg", loads: [][]*bundle.Spec{{}}}
+	assert.NoError(t, s.Add(refresher, 0))
+	assert.Equal(t, DefaultRefreshInterval, s.registries["reg"].interval)
+	assert.Error(t, s.Add(refresher, time.Minute))
+
+	assert.NoError(t, s.Start(context.Background()))
+	assert.Error(t, s.Start(context.Background()))
+	assert.Error(t, s.Add(&scriptedRefresher{name: "other"}, time.Minute))
+	s.Stop()
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	s := NewScheduler(0.2)
+	for i := 0; i < 100; i++ {
+		next := s.next(10 * time.Second)
+		assert.True(t, next >= 8*time.Second && next <= 12*time.Second, next.String())
+	}
+}
+
+type fakeLeader bool
+
+func (f fakeLeader) IsLeader() bool {
+	return bool(f)
+}
+
+func TestSchedulerOnlyRefreshesOnLeader(t *testing.T) {
+	refresher := &scriptedRefresher{name: "reg", loads: [][]*bundle.Spec{{schedulerSpec("one", "1.0")}}}
+	s := NewScheduler(0)
+	if err := s.Add(refresher, time.Hour); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	s.SetLeader(fakeLeader(false))
+	assert.False(t, s.Refresh(context.Background(), "reg"))
+	assert.Equal(t, 0, refresher.calls)
+
+	s.SetLeader(fakeLeader(true))
+	assert.True(t, s.Refresh(context.Background(), "reg"))
+	assert.Equal(t, 1, refresher.calls)
+}
@@ -0,0 +1,197 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	b64 "encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+const (
+	// SignaturePolicyIgnore - signatures are not verified, the default.
+	SignaturePolicyIgnore = "ignore"
+	// SignaturePolicyWarn - specs that are unsigned or fail the
+	// verification are loaded with a warning.
+	SignaturePolicyWarn = "warn"
+	// SignaturePolicyReject - specs that are unsigned or fail the
+	// verification are not loaded.
+	SignaturePolicyReject = "reject"
+)
+
+// unsignedRegistryTypes - the registry types whose specs do not come from
+// image labels and so never carry a spec signature.
+var unsignedRegistryTypes = map[string]bool{
+	"helm":   true,
+	"galaxy": true,
+	"olm":    true,
+}
+
+// ValidateSignaturePolicy - the signature policy has to be known. The warn
+// and reject policies need a signature key and a registry type whose specs
+// can be signed.
+func (c Config) ValidateSignaturePolicy() error {
+	switch c.SignaturePolicy {
+	case "", SignaturePolicyIgnore:
+		return nil
+	case SignaturePolicyWarn, SignaturePolicyReject:
+	default:
+		return fmt.Errorf("unknown signature_policy %v", c.SignaturePolicy)
+	}
+	if c.SignatureKey == "" {
+		return fmt.Errorf("signature_policy %v requires a signature_key", c.SignaturePolicy)
+	}
+	if unsignedRegistryTypes[strings.ToLower(c.Type)] {
+		return fmt.Errorf("signature_policy %v is not supported by %v registries, their specs are not signed",
+			c.SignaturePolicy, c.Type)
+	}
+	return nil
+}
+
+// SignatureVerifier - Verifies the signature of a spec. The registry uses
+// a KeyVerifier for the spec signature label when the config has a
+// signature key, a verifier of image signatures, e.g. cosign or GPG, can
+// be set with SetSignatureVerifier.
+type SignatureVerifier interface {
+	// VerifySpec - returns ErrorUnsignedSpec when the spec has no
+	// signature the verifier understands.
+	VerifySpec(spec *bundle.Spec) error
+}
+
+// ErrorUnsignedSpec - The spec has no signature.
+type ErrorUnsignedSpec struct {
+	Image string
+}
+
+func (e ErrorUnsignedSpec) Error() string {
+	return fmt.Sprintf("spec of image %v is not signed", e.Image)
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrorUnsignedSpec) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrorUnsignedSpec - true if the error is an ErrorUnsignedSpec.
+func IsErrorUnsignedSpec(err error) bool {
+	_, ok := err.(ErrorUnsignedSpec)
+	return ok
+}
+
+// KeyVerifier - Verifies the spec signature label with a public key. RSA
+// keys verify PKCS #1 v1.5 signatures and ECDSA keys ASN.1 signatures, of
+// the sha256 of the decoded spec label, like `cosign sign-blob` writes.
+type KeyVerifier struct {
+	key crypto.PublicKey
+}
+
+// NewKeyVerifier - returns a verifier for the PEM encoded public key.
+func NewKeyVerifier(pemKey []byte) (*KeyVerifier, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, errors.New("no PEM encoded public key found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+	return &KeyVerifier{key: key}, nil
+}
+
+// VerifySpec - verifies the spec signature label.
+func (v *KeyVerifier) VerifySpec(spec *bundle.Spec) error {
+	if spec.Provenance == nil || spec.Provenance.SpecSignature == "" {
+		return ErrorUnsignedSpec{Image: spec.Image}
+	}
+	signature, err := b64.StdEncoding.DecodeString(spec.Provenance.SpecSignature)
+	if err != nil {
+		return fmt.Errorf("unable to decode the signature - %v", err)
+	}
+	digest := sha256.Sum256(spec.Provenance.SignedContent)
+	switch key := v.key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return errors.New("invalid ecdsa signature")
+		}
+	}
+	return nil
+}
+
+// SetSignatureVerifier - sets the verifier of the spec signatures, used
+// when the signature policy of the registry is warn or reject. It replaces
+// the verifier of the signature key.
+func (r *Registry) SetSignatureVerifier(verifier SignatureVerifier) {
+	r.verifier = verifier
+}
+
+// verifySignatures - verifies the signatures of the specs and applies the
+// signature policy of the registry to those that fail. Without a verifier
+// no spec can be verified, reject loads none of them.
+func (r Registry) verifySignatures(specs []*bundle.Spec) []*bundle.Spec {
+	policy := r.config.SignaturePolicy
+	if policy == "" || policy == SignaturePolicyIgnore {
+		return specs
+	}
+	if r.verifier == nil {
+		if policy == SignaturePolicyReject {
+			log.Errorf("registry %v has the signature policy %v but no verifier, none of its %d specs will be loaded",
+				r.config.Name, policy, len(specs))
+			return []*bundle.Spec{}
+		}
+		log.Warningf("registry %v has the signature policy %v but no verifier, signatures are not verified",
+			r.config.Name, policy)
+		return specs
+	}
+	verified := make([]*bundle.Spec, 0, len(specs))
+	for _, spec := range specs {
+		err := r.verifier.VerifySpec(spec)
+		if err == nil {
+			if spec.Provenance == nil {
+				spec.Provenance = &bundle.Provenance{}
+			}
+			spec.Provenance.Verified = true
+			verified = append(verified, spec)
+			continue
+		}
+		if policy == SignaturePolicyReject {
+			log.Errorf("Spec [ %s ] failed the signature verification and will not be loaded - %v",
+				spec.FQName, err)
+			continue
+		}
+		log.Warningf("Spec [ %s ] failed the signature verification - %v", spec.FQName, err)
+		verified = append(verified, spec)
+	}
+	return verified
+}
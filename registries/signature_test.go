@@ -0,0 +1,138 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package registries
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	b64 "encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedSpecs(t *testing.T) (*KeyVerifier, []*bundle.Spec) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	verifier, err := NewKeyVerifier(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	content := []byte("name: signed-apb\n")
+	digest := sha256.Sum256(content)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	specs := []*bundle.Spec{
+		{
+			FQName: "signed-apb",
+			Provenance: &bundle.Provenance{
+				SpecSignature: b64.StdEncoding.EncodeToString(signature),
+				SignedContent: content,
+			},
+		},
+		{
+			FQName: "tampered-apb",
+			Provenance: &bundle.Provenance{
+				SpecSignature: b64.StdEncoding.EncodeToString(signature),
+				SignedContent: []byte("name: tampered-apb\n"),
+			},
+		},
+		{FQName: "unsigned-apb"},
+	}
+	return verifier, specs
+}
+
+func TestKeyVerifier(t *testing.T) {
+	verifier, specs := signedSpecs(t)
+	assert.NoError(t, verifier.VerifySpec(specs[0]))
+	assert.Error(t, verifier.VerifySpec(specs[1]))
+	assert.True(t, IsErrorUnsignedSpec(verifier.VerifySpec(specs[2])))
+}
+
+func TestNewKeyVerifierInvalidKey(t *testing.T) {
+	_, err := NewKeyVerifier([]byte("not a key"))
+	assert.Error(t, err)
+}
+
+func TestVerifySignatures(t *testing.T) {
+	testCases := []struct {
+		policy   string
+		expected []string
+	}{
+		{policy: "", expected: []string{"signed-apb", "tampered-apb", "unsigned-apb"}},
+		{policy: SignaturePolicyWarn, expected: []string{"signed-apb", "tampered-apb", "unsigned-apb"}},
+		{policy: SignaturePolicyReject, expected: []string{"signed-apb"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			verifier, specs := signedSpecs(t)
+			r := Registry{config: Config{Name: "dh", SignaturePolicy: tc.policy}}
+			r.SetSignatureVerifier(verifier)
+			loaded := r.verifySignatures(specs)
+			names := []string{}
+			for _, spec := range loaded {
+				names = append(names, spec.FQName)
+			}
+			assert.Equal(t, tc.expected, names)
+			assert.Equal(t, tc.policy != "", specs[0].Provenance.Verified)
+		})
+	}
+}
+
+func TestVerifySignaturesWithoutVerifier(t *testing.T) {
+	_, specs := signedSpecs(t)
+	r := Registry{config: Config{Name: "dh", SignaturePolicy: SignaturePolicyReject}}
+	assert.Empty(t, r.verifySignatures(specs))
+
+	r.config.SignaturePolicy = SignaturePolicyWarn
+	assert.Len(t, r.verifySignatures(specs), 3)
+}
+
+func TestValidateSignaturePolicy(t *testing.T) {
+	c := Config{Name: "dh", Type: "dockerhub", SignaturePolicy: "sometimes", SignatureKey: "/etc/keys/spec.pub"}
+	assert.False(t, c.Validate())
+	c.SignaturePolicy = SignaturePolicyReject
+	assert.True(t, c.Validate())
+
+	// warn and reject need a key
+	c.SignatureKey = ""
+	assert.False(t, c.Validate())
+	c.SignaturePolicy = SignaturePolicyWarn
+	assert.False(t, c.Validate())
+	c.SignaturePolicy = SignaturePolicyIgnore
+	assert.True(t, c.Validate())
+
+	// the specs of helm, galaxy and olm registries are never signed
+	for _, registryType := range []string{"helm", "galaxy", "olm"} {
+		c := Config{Name: "unsigned", Type: registryType, SignaturePolicy: SignaturePolicyReject, SignatureKey: "/etc/keys/spec.pub"}
+		assert.Error(t, c.ValidateSignaturePolicy(), registryType)
+	}
+}
@@ -0,0 +1,132 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+	"path"
+
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultCacheMountPath - where the cache is mounted in the bundle
+	// container when CacheVolume.MountPath is empty.
+	DefaultCacheMountPath = "/opt/bundle-cache"
+	// DefaultCacheImagePath - the directory of the cache image that is
+	// copied when CacheVolume.ImagePath is empty.
+	DefaultCacheImagePath = "/cache"
+	// CacheDirEnvVar - the environment variable holding the mount path of
+	// the cache.
+	CacheDirEnvVar = "BUNDLE_CACHE_DIR"
+	// cacheVolumeName - the name of the cache volume and of the init
+	// container filling it from the cache image.
+	cacheVolumeName = "bundle-cache"
+	// cacheInitMountPath - where the init container writes the cache.
+	cacheInitMountPath = "/bundle-cache"
+)
+
+// CacheVolume - A cache mounted read-only into the bundle pods, e.g. the
+// Ansible collections and python wheels the bundles would otherwise
+// download on every run. The cache is either a persistent volume claim or
+// an image whose ImagePath is copied into the pod by an init container.
+//
+// The collections directory of the cache is added to the Ansible
+// collections path and the wheels directory to the pip find links.
+type CacheVolume struct {
+	// ClaimName - the claim holding the cache. It has to exist in the
+	// namespace the bundle pods run in, see SandboxStrategy.
+	ClaimName string
+	// Image - the image holding the cache, it has to provide cp.
+	Image string
+	// ImagePath - the directory of the image that is copied, defaults to
+	// DefaultCacheImagePath.
+	ImagePath string
+	// MountPath - where the cache is mounted, defaults to
+	// DefaultCacheMountPath.
+	MountPath string
+}
+
+// Enabled - true if a claim or an image is set.
+func (c CacheVolume) Enabled() bool {
+	return c.ClaimName != "" || c.Image != ""
+}
+
+// Validate - a cache is a claim or an image, not both.
+func (c CacheVolume) Validate() error {
+	if c.ClaimName != "" && c.Image != "" {
+		return fmt.Errorf("the cache volume is either a claim or an image")
+	}
+	if c.MountPath != "" && !path.IsAbs(c.MountPath) {
+		return fmt.Errorf("the cache mount path %v is not absolute", c.MountPath)
+	}
+	return nil
+}
+
+func (c CacheVolume) mountPath() string {
+	if c.MountPath == "" {
+		return DefaultCacheMountPath
+	}
+	return c.MountPath
+}
+
+// apply - adds the cache volume, its mount and environment to the
+// execution context, and the init container filling it for an image.
+func (c CacheVolume) apply(ec ExecutionContext) ExecutionContext {
+	if !c.Enabled() {
+		return ec
+	}
+	volume := v1.Volume{Name: cacheVolumeName}
+	if c.ClaimName != "" {
+		volume.VolumeSource = v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+				ClaimName: c.ClaimName,
+				ReadOnly:  true,
+			},
+		}
+	} else {
+		imagePath := c.ImagePath
+		if imagePath == "" {
+			imagePath = DefaultCacheImagePath
+		}
+		volume.VolumeSource = v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}
+		ec.InitContainers = append(ec.InitContainers, v1.Container{
+			Name:    cacheVolumeName,
+			Image:   c.Image,
+			Command: []string{"cp", "-a", imagePath + "/.", cacheInitMountPath},
+			VolumeMounts: []v1.VolumeMount{
+				{Name: cacheVolumeName, MountPath: cacheInitMountPath},
+			},
+		})
+	}
+	mountPath := c.mountPath()
+	ec.Volumes = append(ec.Volumes, volume)
+	ec.VolumeMounts = append(ec.VolumeMounts, v1.VolumeMount{
+		Name:      cacheVolumeName,
+		MountPath: mountPath,
+		ReadOnly:  true,
+	})
+	// The environment of the execution context comes last so it can
+	// override the cache locations.
+	env := []v1.EnvVar{
+		{Name: CacheDirEnvVar, Value: mountPath},
+		{Name: "ANSIBLE_COLLECTIONS_PATHS", Value: path.Join(mountPath, "collections") + ":~/.ansible/collections:/usr/share/ansible/collections"},
+		{Name: "PIP_FIND_LINKS", Value: path.Join(mountPath, "wheels")},
+	}
+	ec.Env = append(env, ec.Env...)
+	return ec
+}
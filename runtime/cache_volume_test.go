@@ -0,0 +1,62 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+)
+
+func TestCacheVolumeValidate(t *testing.T) {
+	assert.NoError(t, CacheVolume{}.Validate())
+	assert.NoError(t, CacheVolume{ClaimName: "cache", MountPath: "/cache"}.Validate())
+	assert.Error(t, CacheVolume{ClaimName: "cache", Image: "cache:latest"}.Validate())
+	assert.Error(t, CacheVolume{Image: "cache:latest", MountPath: "cache"}.Validate())
+}
+
+func TestCacheVolumeApply(t *testing.T) {
+	ec := ExecutionContext{Env: []v1.EnvVar{{Name: "PIP_FIND_LINKS", Value: "/wheels"}}}
+	assert.Equal(t, ec, CacheVolume{}.apply(ec))
+
+	claim := CacheVolume{ClaimName: "ansible-cache"}.apply(ec)
+	if len(claim.Volumes) != 1 || claim.Volumes[0].PersistentVolumeClaim == nil {
+		t.Fatalf("expected a claim volume, got: %#+v", claim.Volumes)
+	}
+	assert.True(t, claim.Volumes[0].PersistentVolumeClaim.ReadOnly)
+	assert.Equal(t, []v1.VolumeMount{{Name: cacheVolumeName, MountPath: DefaultCacheMountPath, ReadOnly: true}}, claim.VolumeMounts)
+	assert.Empty(t, claim.InitContainers)
+	assert.Equal(t, v1.EnvVar{Name: CacheDirEnvVar, Value: DefaultCacheMountPath}, claim.Env[0])
+	// the environment of the execution context is kept last
+	assert.Equal(t, ec.Env[0], claim.Env[len(claim.Env)-1])
+
+	image := CacheVolume{Image: "quay.io/example/ansible-cache", MountPath: "/cache"}.apply(ec)
+	if len(image.Volumes) != 1 || image.Volumes[0].EmptyDir == nil {
+		t.Fatalf("expected an empty dir volume, got: %#+v", image.Volumes)
+	}
+	if len(image.InitContainers) != 1 {
+		t.Fatalf("expected an init container, got: %#+v", image.InitContainers)
+	}
+	assert.Equal(t, "quay.io/example/ansible-cache", image.InitContainers[0].Image)
+	assert.Equal(t, []string{"cp", "-a", "/cache/.", cacheInitMountPath}, image.InitContainers[0].Command)
+	assert.Equal(t, "/cache", image.VolumeMounts[0].MountPath)
+	assert.Contains(t, image.Env, v1.EnvVar{
+		Name:  "ANSIBLE_COLLECTIONS_PATHS",
+		Value: "/cache/collections:~/.ansible/collections:/usr/share/ansible/collections",
+	})
+}
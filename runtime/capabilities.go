@@ -0,0 +1,130 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	log "github.com/automationbroker/bundle-lib/logging"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+)
+
+// Capabilities - What the connected cluster supports. Features of the
+// executor and the broker that depend on the cluster can be turned off
+// instead of failing when the cluster lacks them.
+type Capabilities struct {
+	// Version - the version of the cluster, empty when unknown.
+	Version string `json:"version,omitempty"`
+	// NetworkPolicies - network policies can be created.
+	NetworkPolicies bool `json:"network_policies"`
+	// JobTTLController - finished jobs are cleaned up after the
+	// ttlSecondsAfterFinished of the job.
+	JobTTLController bool `json:"job_ttl_controller"`
+	// PodSecurityAdmission - the pod security levels set with namespace
+	// labels are enforced.
+	PodSecurityAdmission bool `json:"pod_security_admission"`
+	// Routes - openshift routes can be created.
+	Routes bool `json:"routes"`
+	// Projects - openshift projects can be created.
+	Projects bool `json:"projects"`
+	// OperatorLifecycleManager - operators can be installed with OLM
+	// subscriptions.
+	OperatorLifecycleManager bool `json:"operator_lifecycle_manager"`
+}
+
+// apiResources - the API resources a capability depends on.
+var apiResources = []struct {
+	groupVersion string
+	resource     string
+	set          func(*Capabilities)
+}{
+	{"networking.k8s.io/v1", "networkpolicies", func(c *Capabilities) { c.NetworkPolicies = true }},
+	{"route.openshift.io/v1", "routes", func(c *Capabilities) { c.Routes = true }},
+	{"project.openshift.io/v1", "projects", func(c *Capabilities) { c.Projects = true }},
+	{"operators.coreos.com/v1alpha1", "subscriptions", func(c *Capabilities) { c.OperatorLifecycleManager = true }},
+}
+
+// Capabilities - discovers what the connected cluster supports. The API
+// resources are looked up, the controllers and admission plugins that have
+// no resource of their own are derived from the cluster version.
+func (p provider) Capabilities() (Capabilities, error) {
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		return Capabilities{}, err
+	}
+	caps, _, err := discoverCapabilities(k8scli.Client.Discovery())
+	return caps, err
+}
+
+// discoverCapabilities - the capabilities and the version of the cluster.
+func discoverCapabilities(client discovery.DiscoveryInterface) (Capabilities, *version.Info, error) {
+	caps := Capabilities{}
+	v, err := client.ServerVersion()
+	if err != nil {
+		return caps, nil, err
+	}
+	caps.Version = v.GitVersion
+	// ttlSecondsAfterFinished is enabled by default since 1.21, pod
+	// security admission since 1.23.
+	caps.JobTTLController = versionAtLeast(v, 1, 21)
+	caps.PodSecurityAdmission = versionAtLeast(v, 1, 23)
+
+	for _, r := range apiResources {
+		list, err := client.ServerResourcesForGroupVersion(r.groupVersion)
+		if err != nil || list == nil {
+			log.Debugf("group version %v is not served by the cluster - %v", r.groupVersion, err)
+			continue
+		}
+		for _, resource := range list.APIResources {
+			if resource.Name == r.resource {
+				r.set(&caps)
+				break
+			}
+		}
+	}
+	return caps, v, nil
+}
+
+// versionAtLeast - true if the version is major.minor or later. Providers
+// append a + to the minor version, e.g. 11+.
+func versionAtLeast(v *version.Info, major, minor int) bool {
+	vMajor, vMinor, ok := versionNumbers(v)
+	if !ok {
+		return false
+	}
+	if vMajor != major {
+		return vMajor > major
+	}
+	return vMinor >= minor
+}
+
+// versionNumbers - the major and minor version, false if they are not
+// numbers.
+func versionNumbers(v *version.Info) (int, int, bool) {
+	major, err := strconv.Atoi(v.Major)
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(strings.TrimSuffix(v.Minor, "+"))
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
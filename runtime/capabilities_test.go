@@ -0,0 +1,73 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCapabilities(t *testing.T) {
+	k, err := clients.Kubernetes()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	client := fake.NewSimpleClientset()
+	client.Fake.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "networking.k8s.io/v1",
+			APIResources: []metav1.APIResource{{Name: "networkpolicies"}},
+		},
+		{
+			GroupVersion: "route.openshift.io/v1",
+			APIResources: []metav1.APIResource{{Name: "routes"}},
+		},
+	}
+	k.Client = client
+
+	caps, err := provider{}.Capabilities()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if !caps.NetworkPolicies || !caps.Routes || caps.Projects {
+		t.Fatalf("unexpected capabilities: %#v", caps)
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	cases := []struct {
+		major    string
+		minor    string
+		expected bool
+	}{
+		{major: "1", minor: "23", expected: true},
+		{major: "1", minor: "24+", expected: true},
+		{major: "1", minor: "11+", expected: false},
+		{major: "2", minor: "0", expected: true},
+		{major: "", minor: "", expected: false},
+	}
+	for _, tc := range cases {
+		v := &version.Info{Major: tc.major, Minor: tc.minor}
+		if actual := versionAtLeast(v, 1, 23); actual != tc.expected {
+			t.Fatalf("expected %v for %v.%v got %v", tc.expected, tc.major, tc.minor, actual)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	log "github.com/automationbroker/bundle-lib/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ClusterInfoKey - the extra var that holds the ClusterInfo.
+	ClusterInfoKey = "_cluster"
+	// defaultStorageClassAnnotation - marks the default storage class.
+	defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+	// betaDefaultStorageClassAnnotation - marks the default storage class on
+	// older clusters.
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+// ClusterInfo - Facts about the cluster that are passed to the bundle in
+// the ClusterInfoKey extra var, so playbooks do not need to hardcode or
+// look them up:
+//
+//	_cluster:
+//	  platform: openshift
+//	  distribution: openshift
+//	  version: v1.27.6+f67aeb3
+//	  major: 1
+//	  minor: 27
+//	  distribution_version: 4.14.3
+//	  capabilities: {network_policies: true, routes: true, ...}
+//	  ingress_domain: apps.example.com
+//	  storage_classes: [gp2, standard]
+//	  default_storage_class: gp2
+type ClusterInfo struct {
+	Platform            string        `json:"platform"`
+	Distribution        string        `json:"distribution,omitempty"`
+	Version             string        `json:"version,omitempty"`
+	Major               int           `json:"major,omitempty"`
+	Minor               int           `json:"minor,omitempty"`
+	DistributionVersion string        `json:"distribution_version,omitempty"`
+	Capabilities        *Capabilities `json:"capabilities,omitempty"`
+	IngressDomain       string        `json:"ingress_domain,omitempty"`
+	StorageClasses      []string      `json:"storage_classes"`
+	DefaultStorageClass string        `json:"default_storage_class,omitempty"`
+}
+
+// clusterInfo - gathers the facts about the cluster. Facts that can not be
+// retrieved are left empty, failing the action for them is not worth it.
+func (p provider) clusterInfo() ClusterInfo {
+	info := ClusterInfo{
+		Platform:       p.GetRuntime(),
+		IngressDomain:  p.ingressDomain,
+		StorageClasses: []string{},
+	}
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		log.Warningf("unable to retrieve kubernetes client for the cluster info - %v", err)
+		return info
+	}
+
+	platform, err := p.PlatformInfo()
+	if err != nil {
+		log.Warningf("unable to retrieve the platform info - %v", err)
+	} else {
+		info.Distribution = platform.Distribution
+		info.Version = platform.Version
+		info.Major = platform.Major
+		info.Minor = platform.Minor
+		info.DistributionVersion = platform.DistributionVersion
+		info.Capabilities = &platform.Capabilities
+	}
+
+	classes, err := k8scli.Client.StorageV1().StorageClasses().List(metav1.ListOptions{})
+	if err != nil {
+		log.Warningf("unable to list the storage classes - %v", err)
+		return info
+	}
+	for _, c := range classes.Items {
+		info.StorageClasses = append(info.StorageClasses, c.Name)
+		if c.Annotations[defaultStorageClassAnnotation] == "true" ||
+			c.Annotations[betaDefaultStorageClassAnnotation] == "true" {
+			info.DefaultStorageClass = c.Name
+		}
+	}
+	sort.Strings(info.StorageClasses)
+	return info
+}
+
+// addClusterInfo - adds the ClusterInfo to the extra vars of the bundle.
+func addClusterInfo(extraVars string, info ClusterInfo) (string, error) {
+	vars := map[string]interface{}{}
+	if extraVars != "" {
+		if err := json.Unmarshal([]byte(extraVars), &vars); err != nil {
+			return extraVars, err
+		}
+	}
+	vars[ClusterInfoKey] = info
+	b, err := json.Marshal(vars)
+	if err != nil {
+		return extraVars, err
+	}
+	return string(b), nil
+}
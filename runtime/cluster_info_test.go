@@ -0,0 +1,79 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestClusterInfo(t *testing.T) {
+	k, err := clients.Kubernetes()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	client := fake.NewSimpleClientset(
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "standard"}},
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{
+			Name:        "gp2",
+			Annotations: map[string]string{defaultStorageClassAnnotation: "true"},
+		}},
+	)
+	k.Client = client
+
+	p := provider{coe: openshift{}, ingressDomain: "apps.example.com"}
+	expected := ClusterInfo{
+		Platform:            "openshift",
+		Distribution:        "openshift",
+		IngressDomain:       "apps.example.com",
+		StorageClasses:      []string{"gp2", "standard"},
+		DefaultStorageClass: "gp2",
+	}
+	info := p.clusterInfo()
+	// the fake discovery client reports the version of client-go
+	info.Version = ""
+	info.Major, info.Minor = 0, 0
+	if info.Capabilities == nil {
+		t.Fatalf("expected the capabilities in the cluster info")
+	}
+	info.Capabilities = nil
+	if !reflect.DeepEqual(info, expected) {
+		t.Fatalf("unexpected cluster info:\nGot: %#v\nExp: %#v", info, expected)
+	}
+
+	extraVars, err := addClusterInfo(`{"namespace": "ns"}`, info)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	vars := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(extraVars), &vars); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if vars["namespace"] != "ns" {
+		t.Fatalf("expected the existing extra vars to be kept got: %v", extraVars)
+	}
+	cluster, ok := vars[ClusterInfoKey].(map[string]interface{})
+	if !ok || cluster["platform"] != "openshift" || cluster["default_storage_class"] != "gp2" {
+		t.Fatalf("unexpected %s extra var: %v", ClusterInfoKey, vars[ClusterInfoKey])
+	}
+}
@@ -0,0 +1,103 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// The COEs built into the runtime.
+const (
+	// COEKubernetes - a kubernetes cluster.
+	COEKubernetes = "kubernetes"
+	// COEOpenShift - an OpenShift cluster, the bundle pods join the networks
+	// of the targets when the multitenant network plugin is used.
+	COEOpenShift = "openshift"
+)
+
+// COE - The container orchestration engine the bundles run on, for the
+// behaviors that are different between platforms.
+type COE interface {
+	// GetRuntime - the name of the platform, passed to the bundles.
+	GetRuntime() string
+	// ShouldJoinNetworks - whether the sandbox namespace has to join the
+	// network of the target namespace, and the hooks that join and isolate
+	// them.
+	ShouldJoinNetworks() (bool, PostSandboxCreate, PostSandboxDestroy)
+}
+
+// SandboxRoleCOE - Implemented by the COEs that bind the bundle service
+// account to another role than the one requested, e.g. a reduced role on
+// edge clusters.
+type SandboxRoleCOE interface {
+	// SandboxRole - the role bound in the target namespaces.
+	SandboxRole(requested string) string
+}
+
+// COEFactory - creates the COE, called by NewRuntime when the COE is
+// selected.
+type COEFactory func() (COE, error)
+
+var coeFactories = struct {
+	sync.RWMutex
+	m map[string]COEFactory
+}{
+	m: map[string]COEFactory{
+		COEKubernetes: func() (COE, error) { return newKubernetes(), nil },
+		COEOpenShift:  func() (COE, error) { return newOpenshift(), nil },
+	},
+}
+
+// RegisterCOE - makes the COE available to Configuration.COE under the
+// name. Names can not be registered twice.
+func RegisterCOE(name string, factory COEFactory) error {
+	if name == "" || factory == nil {
+		return fmt.Errorf("a COE needs a name and a factory")
+	}
+	coeFactories.Lock()
+	defer coeFactories.Unlock()
+	if _, ok := coeFactories.m[name]; ok {
+		return fmt.Errorf("the COE %v is already registered", name)
+	}
+	coeFactories.m[name] = factory
+	return nil
+}
+
+// RegisteredCOEs - the names of the COEs that can be selected, sorted.
+func RegisteredCOEs() []string {
+	coeFactories.RLock()
+	defer coeFactories.RUnlock()
+	names := []string{}
+	for name := range coeFactories.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newCOE - creates the registered COE with the name.
+func newCOE(name string) (COE, error) {
+	coeFactories.RLock()
+	factory, ok := coeFactories.m[name]
+	coeFactories.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown COE %v, registered COEs are %v", name, RegisteredCOEs())
+	}
+	return factory()
+}
@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type edgeCOE struct{}
+
+func (edgeCOE) GetRuntime() string {
+	return "k3s"
+}
+
+func (edgeCOE) ShouldJoinNetworks() (bool, PostSandboxCreate, PostSandboxDestroy) {
+	return false, nil, nil
+}
+
+func (edgeCOE) SandboxRole(requested string) string {
+	return "edit"
+}
+
+func TestRegisterCOE(t *testing.T) {
+	err := RegisterCOE("k3s", func() (COE, error) { return edgeCOE{}, nil })
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Contains(t, RegisteredCOEs(), "k3s")
+	assert.Contains(t, RegisteredCOEs(), COEKubernetes)
+	assert.Contains(t, RegisteredCOEs(), COEOpenShift)
+
+	c, err := newCOE("k3s")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "k3s", c.GetRuntime())
+	r, ok := c.(SandboxRoleCOE)
+	if assert.True(t, ok) {
+		assert.Equal(t, "edit", r.SandboxRole("admin"))
+	}
+
+	c, err = newCOE(COEOpenShift)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "openshift", c.GetRuntime())
+
+	assert.Error(t, RegisterCOE("k3s", func() (COE, error) { return edgeCOE{}, nil }))
+	assert.Error(t, RegisterCOE(COEKubernetes, func() (COE, error) { return kubernetes{}, nil }))
+	assert.Error(t, RegisterCOE("", func() (COE, error) { return edgeCOE{}, nil }))
+	assert.Error(t, RegisterCOE("microshift", nil))
+
+	_, err = newCOE("nomad")
+	assert.Error(t, err)
+}
@@ -0,0 +1,184 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	"github.com/automationbroker/bundle-lib/idgen"
+	log "github.com/automationbroker/bundle-lib/logging"
+	"github.com/pborman/uuid"
+	"k8s.io/api/core/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// DefaultDebugImage - the image of the debug container when
+	// DebugPolicy.Image is empty.
+	DefaultDebugImage = "busybox:latest"
+	// debugContainerPrefix - the prefix of the name of the debug
+	// containers.
+	debugContainerPrefix = "debugger-"
+)
+
+// DebugPolicy - The debug container attached to bundle pods by
+// DebugBundle, with the tools to troubleshoot a hung playbook that the
+// bundle image does not ship.
+type DebugPolicy struct {
+	// Image - the image of the debug container, defaults to
+	// DefaultDebugImage.
+	Image string
+	// Command - the entrypoint of the debug container, empty uses the
+	// one of the image.
+	Command []string
+}
+
+// DebugSession - A debug container attached to a bundle pod. The container
+// shares the process namespace of the bundle container, its processes and
+// files are visible from the debug container.
+type DebugSession struct {
+	PodName   string `json:"pod_name"`
+	Namespace string `json:"namespace"`
+	// Container - the name of the debug container.
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	// TargetContainer - the container the debug container is attached to.
+	TargetContainer string `json:"target_container"`
+	// AttachCommand - attaches a terminal to the debug container once it
+	// is running.
+	AttachCommand []string `json:"attach_command"`
+}
+
+// ErrorDebugUnsupported - The cluster does not serve ephemeral
+// containers, they are available since kubernetes 1.16.
+type ErrorDebugUnsupported struct {
+	PodName string
+}
+
+func (e ErrorDebugUnsupported) Error() string {
+	return fmt.Sprintf("unable to debug pod [ %s ], the cluster does not support ephemeral containers", e.PodName)
+}
+
+// ErrorCode - the error is of the NotFound class.
+func (e ErrorDebugUnsupported) ErrorCode() liberrors.Code {
+	return liberrors.CodeNotFound
+}
+
+// IsErrorDebugUnsupported - true if the error is an ErrorDebugUnsupported.
+func IsErrorDebugUnsupported(err error) bool {
+	_, ok := err.(ErrorDebugUnsupported)
+	return ok
+}
+
+// ephemeralContainer - the fields of an ephemeral container set by
+// DebugBundle. The kubernetes API used by bundle-lib predates ephemeral
+// containers, they are patched through the ephemeralcontainers
+// subresource of the pod.
+type ephemeralContainer struct {
+	Name                string   `json:"name"`
+	Image               string   `json:"image"`
+	Command             []string `json:"command,omitempty"`
+	Stdin               bool     `json:"stdin"`
+	TTY                 bool     `json:"tty"`
+	TargetContainerName string   `json:"targetContainerName"`
+}
+
+// DebugBundle - attaches a debug container to the running bundle pod. The
+// pod is left running when the debug container exits, it is removed with
+// the pod.
+func (p provider) DebugBundle(podName string, namespace string) (DebugSession, error) {
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		return DebugSession{}, err
+	}
+	return debugBundle(k8scli, p.debug, podName, namespace, idgen.OrRandom(p.ids).NewUUID())
+}
+
+func debugBundle(k8scli *clients.KubernetesClient, policy DebugPolicy, podName string, namespace string, id uuid.UUID) (DebugSession, error) {
+	pod, err := k8scli.Client.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+	if err != nil {
+		return DebugSession{}, err
+	}
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return DebugSession{}, fmt.Errorf("unable to debug pod [ %s ], it is %v", podName, pod.Status.Phase)
+	}
+
+	session := newDebugSession(policy, podName, namespace, id)
+	patch, err := ephemeralContainerPatch(policy, session)
+	if err != nil {
+		return DebugSession{}, err
+	}
+	log.Infof("Attaching debug container %s to pod %s, namespace %s", session.Container, podName, namespace)
+	err = k8scli.Client.CoreV1().RESTClient().Patch(types.StrategicMergePatchType).
+		Namespace(namespace).
+		Resource("pods").
+		Name(podName).
+		SubResource("ephemeralcontainers").
+		Body(patch).
+		Do().
+		Error()
+	if kerror.IsNotFound(err) {
+		return DebugSession{}, ErrorDebugUnsupported{PodName: podName}
+	}
+	if err != nil {
+		return DebugSession{}, err
+	}
+	return session, nil
+}
+
+// newDebugSession - the debug session of the pod, the debug containers
+// are named after the generated id so that every session gets its own.
+func newDebugSession(policy DebugPolicy, podName string, namespace string, id uuid.UUID) DebugSession {
+	image := policy.Image
+	if image == "" {
+		image = DefaultDebugImage
+	}
+	container := debugContainerPrefix + id.String()
+	return DebugSession{
+		PodName:         podName,
+		Namespace:       namespace,
+		Container:       container,
+		Image:           image,
+		TargetContainer: BundleContainerName,
+		AttachCommand: []string{
+			"kubectl", "attach", "-it", "-n", namespace, podName, "-c", container,
+		},
+	}
+}
+
+// ephemeralContainerPatch - the strategic merge patch adding the debug
+// container of the session to the pod.
+func ephemeralContainerPatch(policy DebugPolicy, session DebugSession) ([]byte, error) {
+	container := ephemeralContainer{
+		Name:                session.Container,
+		Image:               session.Image,
+		Command:             policy.Command,
+		Stdin:               true,
+		TTY:                 true,
+		TargetContainerName: session.TargetContainer,
+	}
+	return json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ephemeralContainers": []ephemeralContainer{container},
+		},
+	})
+}
@@ -0,0 +1,128 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	"github.com/automationbroker/bundle-lib/idgen"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakerest "k8s.io/client-go/rest/fake"
+)
+
+func TestNewDebugSession(t *testing.T) {
+	id := uuid.Parse("1dda1477-cace-4ec1-a8ec-4e1e1e6f1b33")
+	session := newDebugSession(DebugPolicy{}, "bundle-test", "test", id)
+	assert.Equal(t, DebugSession{
+		PodName:         "bundle-test",
+		Namespace:       "test",
+		Container:       "debugger-1dda1477-cace-4ec1-a8ec-4e1e1e6f1b33",
+		Image:           DefaultDebugImage,
+		TargetContainer: BundleContainerName,
+		AttachCommand: []string{"kubectl", "attach", "-it", "-n", "test", "bundle-test", "-c",
+			"debugger-1dda1477-cace-4ec1-a8ec-4e1e1e6f1b33"},
+	}, session)
+
+	session = newDebugSession(DebugPolicy{Image: "quay.io/example/debug"}, "bundle-test", "test", idgen.Random.NewUUID())
+	assert.Equal(t, "quay.io/example/debug", session.Image)
+
+	// two sessions attached at the same time get their own container
+	ids := idgen.NewSequence("debug")
+	first := newDebugSession(DebugPolicy{}, "bundle-test", "test", ids.NewUUID())
+	second := newDebugSession(DebugPolicy{}, "bundle-test", "test", ids.NewUUID())
+	assert.NotEqual(t, first.Container, second.Container)
+}
+
+func TestDebugBundle(t *testing.T) {
+	pod := func(name string, phase v1.PodPhase) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+	client := func(status int) (*clients.KubernetesClient, *fakerest.RESTClient) {
+		restClient := &fakerest.RESTClient{
+			Resp: &http.Response{
+				StatusCode: status,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{}`))),
+			},
+			NegotiatedSerializer: scheme.Codecs,
+		}
+		k8scli := &clients.KubernetesClient{Client: &fakeClientSet{
+			fake.NewSimpleClientset(pod("running", v1.PodRunning), pod("done", v1.PodSucceeded)),
+			restClient,
+		}}
+		return k8scli, restClient
+	}
+	policy := DebugPolicy{Image: "quay.io/example/debug", Command: []string{"bash"}}
+	id := idgen.NewSequence("debug").NewUUID()
+
+	k8scli, restClient := client(http.StatusOK)
+	session, err := debugBundle(k8scli, policy, "running", "test", id)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "quay.io/example/debug", session.Image)
+	assert.Equal(t, debugContainerPrefix+id.String(), session.Container)
+	if restClient.Req == nil {
+		t.Fatalf("expected the pod to be patched")
+	}
+	assert.Equal(t, "PATCH", restClient.Req.Method)
+	assert.Contains(t, restClient.Req.URL.Path, "/namespaces/test/pods/running/ephemeralcontainers")
+	body, err := ioutil.ReadAll(restClient.Req.Body)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	patch := struct {
+		Spec struct {
+			EphemeralContainers []ephemeralContainer `json:"ephemeralContainers"`
+		} `json:"spec"`
+	}{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, []ephemeralContainer{{
+		Name:                session.Container,
+		Image:               "quay.io/example/debug",
+		Command:             []string{"bash"},
+		Stdin:               true,
+		TTY:                 true,
+		TargetContainerName: BundleContainerName,
+	}}, patch.Spec.EphemeralContainers)
+
+	// finished pods can not be debugged
+	_, err = debugBundle(k8scli, policy, "done", "test", id)
+	assert.Error(t, err)
+	_, err = debugBundle(k8scli, policy, "missing", "test", id)
+	assert.Error(t, err)
+
+	// the cluster does not serve the subresource
+	k8scli, _ = client(http.StatusNotFound)
+	_, err = debugBundle(k8scli, policy, "running", "test", id)
+	assert.True(t, IsErrorDebugUnsupported(err), "unexpected error: %v", err)
+}
@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	log "github.com/automationbroker/bundle-lib/logging"
+	apicorev1 "k8s.io/api/core/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// executionLabel - label on the configmaps holding an execution context.
+	executionLabel = "bundle-lib-execution"
+	// executionKey - key of the serialized execution context in the
+	// configmap.
+	executionKey = "execution"
+)
+
+func executionName(bundleName string) string {
+	return fmt.Sprintf("%s-execution", bundleName)
+}
+
+// SaveExecution - persists the execution context in the master namespace so
+// that it can be recovered after a restart. The extra vars are not
+// persisted because they can hold user secrets.
+func (s state) SaveExecution(ec ExecutionContext) error {
+	k8s, err := clients.Kubernetes()
+	if err != nil {
+		return err
+	}
+	ec.ExtraVars = ""
+	b, err := json.Marshal(ec)
+	if err != nil {
+		return err
+	}
+	client := k8s.Client.CoreV1().ConfigMaps(s.nsTarget)
+	cm := &apicorev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   executionName(ec.BundleName),
+			Labels: map[string]string{executionLabel: "true"},
+		},
+		Data: map[string]string{executionKey: string(b)},
+	}
+	existing, err := client.Get(cm.Name, metav1.GetOptions{})
+	if err != nil {
+		if !kerror.IsNotFound(err) {
+			return err
+		}
+		_, err = client.Create(cm)
+		return err
+	}
+	existing.Data = cm.Data
+	_, err = client.Update(existing)
+	return err
+}
+
+// DeleteExecution - removes the persisted execution context of the bundle.
+func (s state) DeleteExecution(bundleName string) error {
+	k8s, err := clients.Kubernetes()
+	if err != nil {
+		return err
+	}
+	err = k8s.Client.CoreV1().ConfigMaps(s.nsTarget).Delete(executionName(bundleName), &metav1.DeleteOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// RecoverExecutions - returns the execution contexts of the bundles whose
+// sandbox has not been destroyed. After a restart the caller can resume
+// watching them with WatchRunningBundle and clean them up with
+// DestroySandbox, which removes the persisted execution context.
+func (s state) RecoverExecutions() ([]ExecutionContext, error) {
+	k8s, err := clients.Kubernetes()
+	if err != nil {
+		return nil, err
+	}
+	cms, err := k8s.Client.CoreV1().ConfigMaps(s.nsTarget).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", executionLabel),
+	})
+	if err != nil {
+		return nil, err
+	}
+	executions := []ExecutionContext{}
+	for _, cm := range cms.Items {
+		ec := ExecutionContext{}
+		if err := json.Unmarshal([]byte(cm.Data[executionKey]), &ec); err != nil {
+			log.Warningf("unable to recover execution from configmap %s - %v", cm.Name, err)
+			continue
+		}
+		executions = append(executions, ec)
+	}
+	return executions, nil
+}
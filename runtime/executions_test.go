@@ -0,0 +1,94 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestExecutions(t *testing.T) {
+	k, err := clients.Kubernetes()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	k.Client = fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "broken-execution",
+			Namespace: "nsTarget",
+			Labels:    map[string]string{executionLabel: "true"},
+		},
+		Data: map[string]string{executionKey: "{"},
+	})
+	s := state{nsTarget: "nsTarget"}
+
+	ec := ExecutionContext{
+		BundleName: "bundle-1",
+		Location:   "ns-1",
+		Account:    "bundle-1",
+		Targets:    []string{"target"},
+		Action:     "provision",
+		ExtraVars:  `{"password": "secret"}`,
+		ProxyConfig: &ProxyConfig{
+			HTTPProxy: "http://proxy:3128",
+		},
+	}
+	if err := s.SaveExecution(ec); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	ec.Image = "docker.io/bundle"
+	if err := s.SaveExecution(ec); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	executions, err := s.RecoverExecutions()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if len(executions) != 1 {
+		t.Fatalf("expected 1 execution, got %d", len(executions))
+	}
+	recovered := executions[0]
+	if recovered.BundleName != "bundle-1" || recovered.Location != "ns-1" ||
+		recovered.Image != "docker.io/bundle" || recovered.Action != "provision" {
+		t.Fatalf("unexpected execution recovered: %#v", recovered)
+	}
+	if recovered.ExtraVars != "" {
+		t.Fatalf("extra vars should not be persisted, got %s", recovered.ExtraVars)
+	}
+	if recovered.ProxyConfig == nil || recovered.ProxyConfig.HTTPProxy != "http://proxy:3128" {
+		t.Fatalf("unexpected proxy config recovered: %#v", recovered.ProxyConfig)
+	}
+
+	if err := s.DeleteExecution("bundle-1"); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if err := s.DeleteExecution("bundle-1"); err != nil {
+		t.Fatalf("deleting a missing execution should not fail: %v", err)
+	}
+	executions, err = s.RecoverExecutions()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if len(executions) != 0 {
+		t.Fatalf("expected no executions, got %d", len(executions))
+	}
+}
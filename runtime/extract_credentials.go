@@ -20,12 +20,16 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/automationbroker/bundle-lib/clients"
-	log "github.com/sirupsen/logrus"
+	"github.com/automationbroker/bundle-lib/clock"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 	"k8s.io/api/core/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
@@ -42,24 +46,178 @@ const (
 	bundleWatchRetries       = 7200
 )
 
+// CredentialRetryPolicy - How often and for how long extracting the
+// credentials of a bundle is retried. Only failures that can be transient,
+// credentials that are not present yet and API server errors, are retried.
+type CredentialRetryPolicy struct {
+	// Attempts - the maximum number of attempts, at least one attempt is
+	// made.
+	Attempts int
+	// Interval - the time to wait between attempts.
+	Interval time.Duration
+	// Timeout - the time after which extraction is abandoned, including a
+	// running attempt. When 0 there is no time bound.
+	Timeout time.Duration
+}
+
+// DefaultCredentialRetryPolicy - the retry policy used when the
+// Configuration does not set one.
+var DefaultCredentialRetryPolicy = CredentialRetryPolicy{
+	Attempts: 5,
+	Interval: 2 * time.Second,
+	Timeout:  time.Minute,
+}
+
+// ErrorCredentialsNotPresent - The bundle did not make credentials available.
+type ErrorCredentialsNotPresent struct {
+	PodName string
+}
+
+func (e ErrorCredentialsNotPresent) Error() string {
+	return fmt.Sprintf("[%s] credentials are not present", e.PodName)
+}
+
+// ErrorCode - the error is of the NotFound class.
+func (e ErrorCredentialsNotPresent) ErrorCode() liberrors.Code {
+	return liberrors.CodeNotFound
+}
+
+// IsErrorCredentialsNotPresent - true if the error is an
+// ErrorCredentialsNotPresent.
+func IsErrorCredentialsNotPresent(err error) bool {
+	_, ok := err.(ErrorCredentialsNotPresent)
+	return ok
+}
+
+// ErrorCredentialsMalformed - The credentials of the bundle can not be
+// decoded. Retrying will not help.
+type ErrorCredentialsMalformed struct {
+	PodName string
+	Err     error
+}
+
+func (e ErrorCredentialsMalformed) Error() string {
+	return fmt.Sprintf("[%s] credentials are malformed - %v", e.PodName, e.Err)
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrorCredentialsMalformed) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrorCredentialsMalformed - true if the error is an
+// ErrorCredentialsMalformed.
+func IsErrorCredentialsMalformed(err error) bool {
+	_, ok := err.(ErrorCredentialsMalformed)
+	return ok
+}
+
+// ErrorCredentialsAPIServer - The API server failed while the credentials
+// were extracted.
+type ErrorCredentialsAPIServer struct {
+	PodName string
+	Err     error
+}
+
+func (e ErrorCredentialsAPIServer) Error() string {
+	return fmt.Sprintf("[%s] unable to extract credentials - %v", e.PodName, e.Err)
+}
+
+// ErrorCode - the code of the API server error.
+func (e ErrorCredentialsAPIServer) ErrorCode() liberrors.Code {
+	return liberrors.CodeOf(e.Err)
+}
+
+// IsErrorCredentialsAPIServer - true if the error is an
+// ErrorCredentialsAPIServer.
+func IsErrorCredentialsAPIServer(err error) bool {
+	_, ok := err.(ErrorCredentialsAPIServer)
+	return ok
+}
+
 // ExtractCredentialsFunc - the func that should be used to extract credentials
 // Params:
 // pod name - name of the container that the APB is running as
 // namespace - name of the namespace where the container is running.
-type extractCredentialsFunc func(string, string) ([]byte, error)
+// stop - closed when the extraction is abandoned, a polling func returns.
+type extractCredentialsFunc func(string, string, <-chan struct{}) ([]byte, error)
 
 // ExtractCredentials - Extract credentials from pod in a certain namespace.
 // needs the podname, namespace and the runtime version.
 func (p provider) ExtractCredentials(podname string, ns string, runtime int) ([]byte, error) {
-	extractCredsFunc, err := getExtractCreds(runtime)
+	clk := clock.OrReal(p.clock)
+	extractCredsFunc, err := getExtractCreds(runtime, clk)
 	if err != nil {
 		return nil, err
 	}
-	return extractCredsFunc(podname, ns)
+	policy := p.credentialRetryPolicy
+	if runtime == 1 {
+		// runtime 1 bundles are polled by the exec for as long as they
+		// run, up to bundleWatchRetries, the timeout would fail slow binds.
+		policy.Timeout = 0
+	}
+	return retryExtractCredentials(clk, extractCredsFunc, podname, ns, policy)
+}
+
+// retryExtractCredentials - extracts the credentials until they are found,
+// the error can not be retried or the policy is exhausted. Every attempt
+// runs in its own goroutine so a hanging API call does not hold the action
+// past the timeout, the stop channel of an abandoned attempt is closed.
+func retryExtractCredentials(clk clock.Clock, extract extractCredentialsFunc, podname, ns string, policy CredentialRetryPolicy) ([]byte, error) {
+	type result struct {
+		creds []byte
+		err   error
+	}
+	var timeout <-chan time.Time
+	if policy.Timeout > 0 {
+		timeout = clk.After(policy.Timeout)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		results := make(chan result, 1)
+		go func() {
+			creds, err := extract(podname, ns, stop)
+			results <- result{creds: creds, err: err}
+		}()
+		select {
+		case r := <-results:
+			if r.err == nil {
+				return r.creds, nil
+			}
+			err = r.err
+		case <-timeout:
+			return nil, extractTimeout(podname, policy, err)
+		}
+		if !IsErrorCredentialsNotPresent(err) && !IsErrorCredentialsAPIServer(err) {
+			return nil, err
+		}
+		if attempt >= policy.Attempts {
+			return nil, err
+		}
+		log.Infof("retry attempt: %v extracting credentials of pod: %v in namespace: %v failed - %v", attempt, podname, ns, err)
+		select {
+		case <-clk.After(policy.Interval):
+		case <-timeout:
+			return nil, extractTimeout(podname, policy, err)
+		}
+	}
+}
+
+// extractTimeout - the error returned when the policy timeout expires, it
+// wraps the error of the last completed attempt.
+func extractTimeout(podname string, policy CredentialRetryPolicy, lastErr error) error {
+	if lastErr == nil {
+		return liberrors.Newf(liberrors.CodeTimeout, "[%s] timed out extracting credentials after %v", podname, policy.Timeout)
+	}
+	return liberrors.Wrapf(liberrors.CodeTimeout, lastErr, "[%s] timed out extracting credentials after %v", podname, policy.Timeout)
 }
 
 // ExtractCredentialsAsFile - Extract credentials from running APB using exec
-func extractCredentialsAsFile(podname string, namespace string) ([]byte, error) {
+func extractCredentialsAsFile(clk clock.Clock, podname string, namespace string, stop <-chan struct{}) ([]byte, error) {
 	k8scli, err := clients.Kubernetes()
 	if err != nil {
 		log.Errorf("error creating k8s client: %v", err)
@@ -136,39 +294,57 @@ func extractCredentialsAsFile(podname string, namespace string) ([]byte, error)
 			log.Infof("command output: %v - err: %v", stdoutBuffer.String(), stderrBuffer.String())
 			log.Infof("retry attempt: %v pod: %v in namespace: %v failed to exec into the container", r, podname, namespace)
 		}
-		time.Sleep(time.Duration(bundleWatchInterval) * time.Second)
+		select {
+		case <-clk.After(time.Duration(bundleWatchInterval) * time.Second):
+		case <-stop:
+			log.Infof("[%v] gave up gathering bind credentials", podname)
+			return nil, liberrors.Newf(liberrors.CodeTimeout, "[%s] gave up gathering bind credentials", podname)
+		}
 	}
 
-	return nil, fmt.Errorf("[%s] ExecTimeout: Failed to gather bind credentials after %d retries", podname, bundleWatchRetries)
+	return nil, liberrors.Newf(liberrors.CodeTimeout, "[%s] ExecTimeout: Failed to gather bind credentials after %d retries", podname, bundleWatchRetries)
 }
 
 // ExtractCredentialsAsSecret - Extract credentials from APB as secret in namespace.
-func extractCredentialsAsSecret(podname string, namespace string) ([]byte, error) {
+func extractCredentialsAsSecret(podname string, namespace string, stop <-chan struct{}) ([]byte, error) {
 	k8s, err := clients.Kubernetes()
 	if err != nil {
-		return nil, fmt.Errorf("Unable to retrive kubernetes client - %v", err)
+		return nil, ErrorCredentialsAPIServer{PodName: podname, Err: fmt.Errorf("Unable to retrive kubernetes client - %v", err)}
 	}
 
 	secret, err := k8s.GetSecretData(podname, namespace)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to retrieve secret [ %v ] - %v", podname, err)
+		if kerror.IsNotFound(err) {
+			return nil, ErrorCredentialsNotPresent{PodName: podname}
+		}
+		return nil, ErrorCredentialsAPIServer{PodName: podname, Err: fmt.Errorf("Unable to retrieve secret [ %v ] - %v", podname, err)}
 	}
 
-	return secret["fields"], nil
+	fields, ok := secret["fields"]
+	if !ok {
+		return nil, ErrorCredentialsMalformed{PodName: podname, Err: fmt.Errorf("secret [ %v ] has no fields key", podname)}
+	}
+	if !json.Valid(fields) {
+		return nil, ErrorCredentialsMalformed{PodName: podname, Err: fmt.Errorf("secret [ %v ] fields are not valid json", podname)}
+	}
+	return fields, nil
 }
 
-func getExtractCreds(runtimeVersion int) (extractCredentialsFunc, error) {
+func getExtractCreds(runtimeVersion int, clk clock.Clock) (extractCredentialsFunc, error) {
 	if runtimeVersion == 1 {
 		log.Infof("Runtime version 1 is being deprecated.\nYou should move the Bundle to use the latest bundle base")
-		return extractCredentialsAsFile, nil
+		return func(podname string, namespace string, stop <-chan struct{}) ([]byte, error) {
+			return extractCredentialsAsFile(clk, podname, namespace, stop)
+		}, nil
 	} else if runtimeVersion >= 2 {
+		// runtime 3 bundles also return their credentials in a secret
 		return extractCredentialsAsSecret, nil
 	} else {
 		return nil, fmt.Errorf(
 			"Unexpected runtime version [%v], support %v <= runtimeVersion <= %v",
 			runtimeVersion,
 			1,
-			2,
+			RuntimeVersion3,
 		)
 	}
 }
@@ -21,8 +21,11 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/automationbroker/bundle-lib/clients"
+	"github.com/automationbroker/bundle-lib/clock"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
 	ft "github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -123,6 +126,20 @@ func TestExtractCredentials(t *testing.T) {
 			podname:   "foo",
 			namespace: "bar",
 		},
+		{
+			name:      "runtime greater than equal 2 no fields",
+			shouldErr: true,
+			runtime:   2,
+			client: fake.NewSimpleClientset(&v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "bar",
+				},
+				Data: map[string][]byte{"other": []byte(`{"db": "name"}`)},
+			}),
+			podname:   "foo",
+			namespace: "bar",
+		},
 		{
 			name:      "invalid runtime",
 			expected:  []byte{},
@@ -152,3 +169,120 @@ func TestExtractCredentials(t *testing.T) {
 		})
 	}
 }
+
+func TestRetryExtractCredentials(t *testing.T) {
+	policy := CredentialRetryPolicy{Attempts: 3, Interval: time.Millisecond, Timeout: time.Second}
+	testCases := []struct {
+		name             string
+		errs             []error
+		policy           CredentialRetryPolicy
+		expectedAttempts int
+		validate         func(error) bool
+	}{
+		{
+			name:             "found after not present",
+			errs:             []error{ErrorCredentialsNotPresent{PodName: "foo"}, nil},
+			policy:           policy,
+			expectedAttempts: 2,
+			validate:         func(err error) bool { return err == nil },
+		},
+		{
+			name: "api server errors exhaust the attempts",
+			errs: []error{
+				ErrorCredentialsAPIServer{PodName: "foo", Err: fmt.Errorf("unavailable")},
+				ErrorCredentialsAPIServer{PodName: "foo", Err: fmt.Errorf("unavailable")},
+				ErrorCredentialsAPIServer{PodName: "foo", Err: fmt.Errorf("unavailable")},
+				nil,
+			},
+			policy:           policy,
+			expectedAttempts: 3,
+			validate:         IsErrorCredentialsAPIServer,
+		},
+		{
+			name:             "malformed credentials are not retried",
+			errs:             []error{ErrorCredentialsMalformed{PodName: "foo", Err: fmt.Errorf("bad")}, nil},
+			policy:           policy,
+			expectedAttempts: 1,
+			validate:         IsErrorCredentialsMalformed,
+		},
+		{
+			name: "timeout",
+			errs: []error{
+				ErrorCredentialsNotPresent{PodName: "foo"},
+				ErrorCredentialsNotPresent{PodName: "foo"},
+			},
+			policy:           CredentialRetryPolicy{Attempts: 2, Interval: time.Second, Timeout: 10 * time.Millisecond},
+			expectedAttempts: 1,
+			validate:         liberrors.IsTimeout,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			extract := func(podname, ns string, stop <-chan struct{}) ([]byte, error) {
+				err := tc.errs[attempts]
+				attempts++
+				if err != nil {
+					return nil, err
+				}
+				return []byte(`{"db": "name"}`), nil
+			}
+			_, err := retryExtractCredentials(clock.Real, extract, "foo", "bar", tc.policy)
+			if !tc.validate(err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if attempts != tc.expectedAttempts {
+				t.Fatalf("expected %d attempts got %d", tc.expectedAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestRetryExtractCredentialsStopsAbandonedAttempt(t *testing.T) {
+	stopped := make(chan struct{})
+	extract := func(podname, ns string, stop <-chan struct{}) ([]byte, error) {
+		<-stop
+		close(stopped)
+		return nil, ErrorCredentialsNotPresent{PodName: podname}
+	}
+	policy := CredentialRetryPolicy{Attempts: 1, Timeout: 10 * time.Millisecond}
+	_, err := retryExtractCredentials(clock.Real, extract, "foo", "bar", policy)
+	if !liberrors.IsTimeout(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("the abandoned attempt was not stopped")
+	}
+}
+
+func TestRetryExtractCredentialsClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	attempts := 0
+	extract := func(podname, ns string, stop <-chan struct{}) ([]byte, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, ErrorCredentialsNotPresent{PodName: podname}
+		}
+		return []byte(`{"db": "name"}`), nil
+	}
+	policy := CredentialRetryPolicy{Attempts: 2, Interval: time.Minute, Timeout: time.Hour}
+	done := make(chan error)
+	go func() {
+		_, err := retryExtractCredentials(fake, extract, "foo", "bar", policy)
+		done <- err
+	}()
+	// the timeout and the interval of the retry
+	for fake.Waiters() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	fake.Advance(time.Minute)
+	if err := <-done; err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts got %d", attempts)
+	}
+}
@@ -17,15 +17,14 @@
 package runtime
 
 import (
-	"errors"
-
 	"github.com/automationbroker/bundle-lib/clients"
-	log "github.com/sirupsen/logrus"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 )
 
 var (
 	//ErrCredentialsNotFound - Credentials not found.
-	ErrCredentialsNotFound = errors.New("extracted credentials were not found")
+	ErrCredentialsNotFound error = liberrors.New(liberrors.CodeNotFound, "extracted credentials were not found")
 )
 
 // ExtractedCredential - Interface to define CRUD operations for
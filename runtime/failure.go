@@ -0,0 +1,105 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+)
+
+// FailureReason - The cause of a failed bundle action. It lets callers
+// retry infrastructure failures but not failures of the bundle itself.
+type FailureReason string
+
+const (
+	// FailureReasonUnknown - the failure could not be classified.
+	FailureReasonUnknown FailureReason = ""
+	// FailureReasonPodStart - the bundle pod failed to start, e.g. the
+	// image could not be pulled. The action can be retried.
+	FailureReasonPodStart FailureReason = "PodStartFailed"
+	// FailureReasonBundle - the bundle ran and its playbook returned a
+	// non-zero exit code. Retrying the action will not help.
+	FailureReasonBundle FailureReason = "BundleFailed"
+	// FailureReasonPreflight - the checks run before the sandbox is
+	// created failed, nothing was created. The action can be retried once
+	// the problem is fixed.
+	FailureReasonPreflight FailureReason = "PreflightFailed"
+	// FailureReasonInternal - the library panicked, the panic was
+	// recovered and failed the action. It is a bug to be reported.
+	FailureReasonInternal FailureReason = "InternalError"
+)
+
+// ErrorBundleFailed - The bundle container exited with a non-zero exit code.
+type ErrorBundleFailed struct {
+	PodName  string
+	ExitCode int32
+}
+
+func (e ErrorBundleFailed) Error() string {
+	return fmt.Sprintf("Pod [ %s ] failed with exit code [%d]", e.PodName, e.ExitCode)
+}
+
+// IsErrorBundleFailed - true if the error is an ErrorBundleFailed.
+func IsErrorBundleFailed(err error) bool {
+	_, ok := err.(ErrorBundleFailed)
+	return ok
+}
+
+// ErrorBundleRestarted - The bundle container was restarted on failure more
+// often than allowed. The last termination state of the container is
+// reported.
+type ErrorBundleRestarted struct {
+	PodName  string
+	Restarts int32
+	ExitCode int32
+	Reason   string
+	Message  string
+}
+
+func (e ErrorBundleRestarted) Error() string {
+	msg := fmt.Sprintf("Pod [ %s ] restarted %d times, last exit code [%d]", e.PodName, e.Restarts, e.ExitCode)
+	if e.Reason != "" {
+		msg = fmt.Sprintf("%s %s", msg, e.Reason)
+	}
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s - %s", msg, e.Message)
+	}
+	return msg
+}
+
+// IsErrorBundleRestarted - true if the error is an ErrorBundleRestarted.
+func IsErrorBundleRestarted(err error) bool {
+	_, ok := err.(ErrorBundleRestarted)
+	return ok
+}
+
+// FailureReasonOf - classifies an error returned while running or watching
+// a bundle.
+func FailureReasonOf(err error) FailureReason {
+	switch {
+	case err == nil:
+		return FailureReasonUnknown
+	case err == ErrorPodPullErr:
+		return FailureReasonPodStart
+	case err == ErrorActionNotFound, IsErrorBundleFailed(err), IsErrorBundleRestarted(err), IsErrorCustomMsg(err):
+		return FailureReasonBundle
+	case IsErrorPreflightFailed(err), IsErrorTargetNamespaceNotFound(err):
+		return FailureReasonPreflight
+	case IsErrorPanicked(err):
+		return FailureReasonInternal
+	}
+	return FailureReasonUnknown
+}
@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFailureReasonOf(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected FailureReason
+	}{
+		{
+			name:     "no error",
+			expected: FailureReasonUnknown,
+		},
+		{
+			name:     "image pull error",
+			err:      ErrorPodPullErr,
+			expected: FailureReasonPodStart,
+		},
+		{
+			name:     "non-zero exit code",
+			err:      ErrorBundleFailed{PodName: "pod", ExitCode: 2},
+			expected: FailureReasonBundle,
+		},
+		{
+			name:     "too many restarts",
+			err:      ErrorBundleRestarted{PodName: "pod", Restarts: 4, ExitCode: 2},
+			expected: FailureReasonBundle,
+		},
+		{
+			name:     "termination message",
+			err:      ErrorCustomMsg{msg: "playbook failed"},
+			expected: FailureReasonBundle,
+		},
+		{
+			name:     "action not found",
+			err:      ErrorActionNotFound,
+			expected: FailureReasonBundle,
+		},
+		{
+			name:     "recovered panic",
+			err:      ErrorPanicked{Value: "boom"},
+			expected: FailureReasonInternal,
+		},
+		{
+			name:     "pre-flight check",
+			err:      ErrorPreflightFailed{Check: PreflightQuota, Namespace: "ns"},
+			expected: FailureReasonPreflight,
+		},
+		{
+			name:     "missing target namespace",
+			err:      ErrorTargetNamespaceNotFound{Namespace: "ns"},
+			expected: FailureReasonPreflight,
+		},
+		{
+			name:     "other error",
+			err:      fmt.Errorf("pod [ pod ] was unexpectedly deleted"),
+			expected: FailureReasonUnknown,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if r := FailureReasonOf(tc.err); r != tc.expected {
+				t.Fatalf("expected failure reason %q got %q", tc.expected, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,498 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/automationbroker/bundle-lib/idgen"
+)
+
+// BundleResult - The scripted outcome of running a bundle with the
+// FakeRuntime.
+type BundleResult struct {
+	// RunErr - returned by RunBundle, the bundle pod is never started.
+	RunErr error
+	// Err - returned by WatchRunningBundle, the bundle pod failed.
+	Err error
+	// Descriptions - the last description updates reported while the
+	// bundle is watched.
+	Descriptions []string
+	// DashboardURL - reported with the description updates.
+	DashboardURL string
+	// Credentials - the credentials the bundle makes available for
+	// extraction. When nil no credentials are found.
+	Credentials map[string]interface{}
+}
+
+// FakeSandbox - A sandbox created by the FakeRuntime.
+type FakeSandbox struct {
+	PodName        string
+	Namespace      string
+	ServiceAccount string
+	Role           string
+	Targets        []string
+	Labels         map[string]string
+	Destroyed      bool
+}
+
+// FakeRuntime - An in memory Runtime for tests that need a working runtime
+// without a cluster. Sandboxes, state and extracted credentials are kept in
+// memory and the outcome of running a bundle is scripted per image and
+// action with SetBundleResult. Bundles without a scripted result succeed.
+type FakeRuntime struct {
+	mutex       sync.Mutex
+	runtime     string
+	counter     int
+	results     map[string]BundleResult
+	sandboxes   []*FakeSandbox
+	pods        map[string]BundleResult
+	executions  []ExecutionContext
+	states      map[string]bool
+	credentials map[string]map[string]interface{}
+	history     map[string][]Operation
+
+	capabilities Capabilities
+	operators    map[string]OperatorSubscription
+	scheduled    map[string]ScheduledAction
+	scheduledRun map[string][]Operation
+	ids          idgen.Generator
+}
+
+// NewFakeRuntime - Creates an empty FakeRuntime that reports the openshift
+// runtime.
+func NewFakeRuntime() *FakeRuntime {
+	return &FakeRuntime{
+		runtime:     "openshift",
+		results:     map[string]BundleResult{},
+		pods:        map[string]BundleResult{},
+		states:      map[string]bool{},
+		credentials: map[string]map[string]interface{}{},
+		history:     map[string][]Operation{},
+		operators:   map[string]OperatorSubscription{},
+
+		scheduled:    map[string]ScheduledAction{},
+		scheduledRun: map[string][]Operation{},
+	}
+}
+
+// SetRuntime - sets the value returned by GetRuntime.
+func (f *FakeRuntime) SetRuntime(runtime string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.runtime = runtime
+}
+
+// SetIDGenerator - sets the source of the names of the debug containers,
+// defaults to idgen.Random.
+func (f *FakeRuntime) SetIDGenerator(ids idgen.Generator) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.ids = ids
+}
+
+// SetCapabilities - sets the capabilities reported for the cluster, by
+// default it supports nothing.
+func (f *FakeRuntime) SetCapabilities(c Capabilities) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.capabilities = c
+}
+
+// SetBundleResult - scripts the outcome of running the image for the
+// action. An empty action applies to all the actions of the image that do
+// not have their own result.
+func (f *FakeRuntime) SetBundleResult(image, action string, result BundleResult) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.results[resultKey(image, action)] = result
+}
+
+// Sandboxes - returns all the sandboxes that were created.
+func (f *FakeRuntime) Sandboxes() []FakeSandbox {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	sandboxes := []FakeSandbox{}
+	for _, s := range f.sandboxes {
+		sandboxes = append(sandboxes, *s)
+	}
+	return sandboxes
+}
+
+// Executions - returns the execution contexts of all the bundles run.
+func (f *FakeRuntime) Executions() []ExecutionContext {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]ExecutionContext{}, f.executions...)
+}
+
+// ValidateRuntime - the fake runtime is always valid.
+func (f *FakeRuntime) ValidateRuntime() error {
+	return nil
+}
+
+// GetRuntime - returns the runtime set with SetRuntime.
+func (f *FakeRuntime) GetRuntime() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.runtime
+}
+
+// Capabilities - the capabilities set with SetCapabilities.
+func (f *FakeRuntime) Capabilities() (Capabilities, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.capabilities, nil
+}
+
+// PlatformInfo - the runtime and capabilities that were set, the
+// distribution is openshift for the openshift runtime and kubernetes
+// otherwise.
+func (f *FakeRuntime) PlatformInfo() (PlatformInfo, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	info := PlatformInfo{
+		Platform:     f.runtime,
+		Distribution: DistributionKubernetes,
+		Version:      f.capabilities.Version,
+		Capabilities: f.capabilities,
+	}
+	if f.runtime == COEOpenShift {
+		info.Distribution = DistributionOpenShift
+	}
+	return info, nil
+}
+
+// CreateSandbox - records the sandbox. When the namespace is not one of the
+// targets a name is generated from it like the cluster would.
+func (f *FakeRuntime) CreateSandbox(podName string, namespace string, targets []string,
+	apbRole string, metadata map[string]string) (string, string, error) {
+	if len(targets) < 1 {
+		return "", "", fmt.Errorf("unable to get target namespaces: Must supply at least one target namespace")
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !isNamespaceInTargets(namespace, targets) {
+		f.counter++
+		namespace = fmt.Sprintf("%s%05d", namespace, f.counter)
+	}
+	f.sandboxes = append(f.sandboxes, &FakeSandbox{
+		PodName:        podName,
+		Namespace:      namespace,
+		ServiceAccount: podName,
+		Role:           apbRole,
+		Targets:        targets,
+		Labels:         metadata,
+	})
+	return podName, namespace, nil
+}
+
+// DestroySandbox - marks the sandbox as destroyed.
+func (f *FakeRuntime) DestroySandbox(podName string, namespace string, targets []string,
+	configNamespace string, keepNamespace bool, keepNamespaceOnError bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, s := range f.sandboxes {
+		if s.PodName == podName && s.Namespace == namespace {
+			s.Destroyed = true
+		}
+	}
+}
+
+// RunBundle - records the execution context and starts the scripted bundle.
+func (f *FakeRuntime) RunBundle(ec ExecutionContext) (ExecutionContext, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.executions = append(f.executions, ec)
+	result, ok := f.results[resultKey(ec.Image, ec.Action)]
+	if !ok {
+		result = f.results[resultKey(ec.Image, "")]
+	}
+	if result.RunErr != nil {
+		return ec, result.RunErr
+	}
+	f.pods[podKey(ec.BundleName, ec.Location)] = result
+	return ec, nil
+}
+
+// WatchRunningBundle - reports the scripted descriptions and returns the
+// scripted error.
+func (f *FakeRuntime) WatchRunningBundle(podName string, namespace string, updateFunc UpdateDescriptionFn) error {
+	result, err := f.pod(podName, namespace)
+	if err != nil {
+		return err
+	}
+	for _, d := range result.Descriptions {
+		if updateFunc != nil {
+			updateFunc(d, result.DashboardURL)
+		}
+	}
+	return result.Err
+}
+
+// ExtractCredentials - returns the scripted credentials of the bundle.
+func (f *FakeRuntime) ExtractCredentials(podName string, namespace string, runtime int) ([]byte, error) {
+	result, err := f.pod(podName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if result.Err != nil {
+		return nil, fmt.Errorf("[%v] APB failed", podName)
+	}
+	if result.Credentials == nil {
+		return nil, nil
+	}
+	return json.Marshal(result.Credentials)
+}
+
+// CopySecretsToNamespace - secrets are not copied by the fake runtime.
+func (f *FakeRuntime) CopySecretsToNamespace(ec ExecutionContext, cn string, secrets []string) error {
+	return nil
+}
+
+// CreateExtractedCredential - stores the credentials.
+func (f *FakeRuntime) CreateExtractedCredential(ID, ns string, creds map[string]interface{}, labels map[string]string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if _, ok := f.credentials[ID]; ok {
+		return fmt.Errorf("extracted credentials %v already exist", ID)
+	}
+	f.credentials[ID] = creds
+	return nil
+}
+
+// UpdateExtractedCredential - replaces the stored credentials.
+func (f *FakeRuntime) UpdateExtractedCredential(ID, ns string, creds map[string]interface{}, labels map[string]string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if _, ok := f.credentials[ID]; !ok {
+		return ErrCredentialsNotFound
+	}
+	f.credentials[ID] = creds
+	return nil
+}
+
+// GetExtractedCredential - returns the stored credentials.
+func (f *FakeRuntime) GetExtractedCredential(ID, ns string) (map[string]interface{}, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	creds, ok := f.credentials[ID]
+	if !ok {
+		return nil, ErrCredentialsNotFound
+	}
+	return creds, nil
+}
+
+// DeleteExtractedCredential - removes the stored credentials.
+func (f *FakeRuntime) DeleteExtractedCredential(ID, ns string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if _, ok := f.credentials[ID]; !ok {
+		return ErrCredentialsNotFound
+	}
+	delete(f.credentials, ID)
+	return nil
+}
+
+// CopyState - copies the state between the names.
+func (f *FakeRuntime) CopyState(fromName, toName, fromNS, toNS string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.states[podKey(fromName, fromNS)] {
+		f.states[podKey(toName, toNS)] = true
+	}
+	return nil
+}
+
+// DeleteState - removes the state from the master namespace.
+func (f *FakeRuntime) DeleteState(name string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.states, podKey(name, f.MasterNamespace()))
+	return nil
+}
+
+// StateIsPresent - true if the state is in the master namespace.
+func (f *FakeRuntime) StateIsPresent(name string) (bool, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.states[podKey(name, f.MasterNamespace())], nil
+}
+
+// SetState - marks the state as present in the namespace, like a bundle
+// that saved state would.
+func (f *FakeRuntime) SetState(name, namespace string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.states[podKey(name, namespace)] = true
+}
+
+// MasterName - the name of the master state of the instance.
+func (f *FakeRuntime) MasterName(instanceID string) string {
+	return instanceID + "-state"
+}
+
+// MasterNamespace - the namespace the master state is kept in.
+func (f *FakeRuntime) MasterNamespace() string {
+	return "fake-state"
+}
+
+// MountLocation - where the state is mounted in the bundle pod.
+func (f *FakeRuntime) MountLocation() string {
+	return "/apb/state"
+}
+
+// RecordOperation - appends the operation to the history of the instance.
+func (f *FakeRuntime) RecordOperation(instanceID string, op Operation) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.history[instanceID] = append(f.history[instanceID], op)
+	return nil
+}
+
+// History - the operations recorded for the instance, oldest first.
+func (f *FakeRuntime) History(instanceID string) ([]Operation, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]Operation{}, f.history[instanceID]...), nil
+}
+
+// InstallOperator - records the subscription, keyed by namespace and name.
+func (f *FakeRuntime) InstallOperator(sub OperatorSubscription) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.operators[sub.Namespace+"/"+sub.Name] = sub
+	return nil
+}
+
+// UninstallOperator - forgets the subscription.
+func (f *FakeRuntime) UninstallOperator(sub OperatorSubscription) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.operators, sub.Namespace+"/"+sub.Name)
+	return nil
+}
+
+// ScheduleAction - records the scheduled action, in the namespace of the
+// execution context.
+func (f *FakeRuntime) ScheduleAction(ec ExecutionContext, sa ScheduledAction) error {
+	if err := ValidateSchedule(sa.Schedule); err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	sa.Namespace = ec.Location
+	f.scheduled[ScheduledActionName(sa.InstanceID, sa.Action)] = sa
+	return nil
+}
+
+// UnscheduleAction - forgets the scheduled action.
+func (f *FakeRuntime) UnscheduleAction(sa ScheduledAction) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.scheduled, ScheduledActionName(sa.InstanceID, sa.Action))
+	return nil
+}
+
+// ScheduledActions - the actions scheduled on the instance.
+func (f *FakeRuntime) ScheduledActions(instanceID string) ([]ScheduledAction, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	actions := []ScheduledAction{}
+	for _, sa := range f.scheduled {
+		if sa.InstanceID == instanceID {
+			actions = append(actions, sa)
+		}
+	}
+	return actions, nil
+}
+
+// AddScheduledRun - scripts a finished run of a scheduled action of the
+// instance, recorded by the next RecordScheduledRuns.
+func (f *FakeRuntime) AddScheduledRun(instanceID string, op Operation) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.scheduledRun[instanceID] = append(f.scheduledRun[instanceID], op)
+}
+
+// RecordScheduledRuns - adds the scripted runs to the history.
+func (f *FakeRuntime) RecordScheduledRuns(instanceID string) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	runs := f.scheduledRun[instanceID]
+	delete(f.scheduledRun, instanceID)
+	f.history[instanceID] = append(f.history[instanceID], runs...)
+	return len(runs), nil
+}
+
+// Operators - the subscriptions of the installed operators.
+func (f *FakeRuntime) Operators() []OperatorSubscription {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	subs := []OperatorSubscription{}
+	for _, sub := range f.operators {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// RecoverExecutions - the executions whose sandbox has not been destroyed.
+func (f *FakeRuntime) RecoverExecutions() ([]ExecutionContext, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	executions := []ExecutionContext{}
+	for _, ec := range f.executions {
+		for _, s := range f.sandboxes {
+			if s.PodName == ec.BundleName && s.Namespace == ec.Location && !s.Destroyed {
+				executions = append(executions, ec)
+				break
+			}
+		}
+	}
+	return executions, nil
+}
+
+// DebugBundle - the debug session of the pod, the pod has to be running.
+func (f *FakeRuntime) DebugBundle(podName string, namespace string) (DebugSession, error) {
+	if _, err := f.pod(podName, namespace); err != nil {
+		return DebugSession{}, err
+	}
+	f.mutex.Lock()
+	ids := idgen.OrRandom(f.ids)
+	f.mutex.Unlock()
+	return newDebugSession(DebugPolicy{}, podName, namespace, ids.NewUUID()), nil
+}
+
+func (f *FakeRuntime) pod(podName, namespace string) (BundleResult, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	result, ok := f.pods[podKey(podName, namespace)]
+	if !ok {
+		return BundleResult{}, fmt.Errorf("pod [ %s ] in namespace %s not found", podName, namespace)
+	}
+	return result, nil
+}
+
+func resultKey(image, action string) string {
+	return image + "|" + action
+}
+
+func podKey(name, namespace string) string {
+	return namespace + "/" + name
+}
@@ -0,0 +1,194 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/idgen"
+)
+
+func TestFakeRuntimeSandbox(t *testing.T) {
+	var f Runtime = NewFakeRuntime()
+	sa, ns, err := f.CreateSandbox("bundle-1", "foo-prov-", []string{"target"}, "edit", nil)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if sa != "bundle-1" || ns != "foo-prov-00001" {
+		t.Fatalf("unexpected sandbox: %v %v", sa, ns)
+	}
+	_, ns2, err := f.CreateSandbox("bundle-2", "target", []string{"target"}, "edit", nil)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if ns2 != "target" {
+		t.Fatalf("expected target namespace to be used got: %v", ns2)
+	}
+	if _, _, err := f.CreateSandbox("bundle-3", "foo-prov-", []string{}, "edit", nil); err == nil {
+		t.Fatalf("expected an error without targets")
+	}
+	f.DestroySandbox("bundle-1", ns, []string{"target"}, "broker", false, false)
+
+	sandboxes := f.(*FakeRuntime).Sandboxes()
+	if len(sandboxes) != 2 || !sandboxes[0].Destroyed || sandboxes[1].Destroyed {
+		t.Fatalf("unexpected sandboxes: %#+v", sandboxes)
+	}
+}
+
+func TestFakeRuntimeRunBundle(t *testing.T) {
+	testCases := []struct {
+		name          string
+		result        *BundleResult
+		action        string
+		runErr        bool
+		watchErr      bool
+		expectedCreds []byte
+		descriptions  []string
+	}{
+		{
+			name:   "success without script",
+			action: "provision",
+		},
+		{
+			name:   "run error",
+			action: "provision",
+			result: &BundleResult{RunErr: fmt.Errorf("image pull failed")},
+			runErr: true,
+		},
+		{
+			name:         "failed pod",
+			action:       "provision",
+			result:       &BundleResult{Err: fmt.Errorf("exit code 1"), Descriptions: []string{"failing"}},
+			watchErr:     true,
+			descriptions: []string{"failing"},
+		},
+		{
+			name:          "credentials",
+			action:        "bind",
+			result:        &BundleResult{Credentials: map[string]interface{}{"user": "foo"}},
+			expectedCreds: []byte(`{"user":"foo"}`),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := NewFakeRuntime()
+			if tc.result != nil {
+				f.SetBundleResult("image", "", *tc.result)
+			}
+			ec := ExecutionContext{BundleName: "bundle-1", Location: "ns", Image: "image", Action: tc.action}
+			_, err := f.RunBundle(ec)
+			if tc.runErr {
+				if err == nil {
+					t.Fatalf("expected a run error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			descriptions := []string{}
+			err = f.WatchRunningBundle("bundle-1", "ns", func(d, url string) {
+				descriptions = append(descriptions, d)
+			})
+			if tc.watchErr != (err != nil) {
+				t.Fatalf("unexpected watch error: %v", err)
+			}
+			if len(tc.descriptions) != 0 && !reflect.DeepEqual(descriptions, tc.descriptions) {
+				t.Fatalf("expected descriptions: %v got: %v", tc.descriptions, descriptions)
+			}
+			if tc.watchErr {
+				return
+			}
+			creds, err := f.ExtractCredentials("bundle-1", "ns", 2)
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			if string(creds) != string(tc.expectedCreds) {
+				t.Fatalf("expected credentials: %s got: %s", tc.expectedCreds, creds)
+			}
+		})
+	}
+}
+
+func TestFakeRuntimeExtractedCredential(t *testing.T) {
+	f := NewFakeRuntime()
+	creds := map[string]interface{}{"user": "foo"}
+	if err := f.CreateExtractedCredential("id", "ns", creds, nil); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	got, err := f.GetExtractedCredential("id", "ns")
+	if err != nil || !reflect.DeepEqual(got, creds) {
+		t.Fatalf("expected credentials: %v got: %v - %v", creds, got, err)
+	}
+	if err := f.DeleteExtractedCredential("id", "ns"); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if _, err := f.GetExtractedCredential("id", "ns"); err != ErrCredentialsNotFound {
+		t.Fatalf("expected: %v got: %v", ErrCredentialsNotFound, err)
+	}
+}
+
+func TestFakeRuntimeDebugBundle(t *testing.T) {
+	f := NewFakeRuntime()
+	f.SetIDGenerator(idgen.NewSequence("debug"))
+	if _, err := f.DebugBundle("bundle-test", "ns"); err == nil {
+		t.Fatalf("expected an error for a pod that is not running")
+	}
+	ec := ExecutionContext{BundleName: "bundle-test", Location: "ns", Image: "image", Action: "provision"}
+	if _, err := f.RunBundle(ec); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	first, err := f.DebugBundle("bundle-test", "ns")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	second, err := f.DebugBundle("bundle-test", "ns")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	expected := debugContainerPrefix + idgen.NewSequence("debug").NewUUID().String()
+	if first.Container != expected {
+		t.Fatalf("expected container: %v got: %v", expected, first.Container)
+	}
+	if first.Container == second.Container {
+		t.Fatalf("expected two debug containers got: %v", first.Container)
+	}
+}
+
+func TestFakeRuntimeRecoverExecutions(t *testing.T) {
+	f := NewFakeRuntime()
+	sa, ns, err := f.CreateSandbox("pod", "target", []string{"target"}, "edit", nil)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	_, err = f.RunBundle(ExecutionContext{BundleName: "pod", Location: ns, Account: sa, Image: "image", Action: "provision"})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	executions, _ := f.RecoverExecutions()
+	if len(executions) != 1 {
+		t.Fatalf("expected 1 execution, got %d", len(executions))
+	}
+	f.DestroySandbox("pod", ns, []string{"target"}, "", false, false)
+	executions, _ = f.RecoverExecutions()
+	if len(executions) != 0 {
+		t.Fatalf("expected no executions, got %d", len(executions))
+	}
+}
@@ -0,0 +1,229 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	"github.com/automationbroker/bundle-lib/clock"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HeartbeatPolicy - How a watched bundle is kept from looking hung. The
+// bundle pod is polled while it is watched, a pending or running pod is
+// alive however quiet its watch is.
+type HeartbeatPolicy struct {
+	// Interval - how often the last description is sent again while no
+	// new one arrives. 0 disables the heartbeat.
+	Interval time.Duration
+	// StaleTimeout - the action fails with ErrorWatchStale when the polls
+	// did not find the bundle pod pending or running for this long, e.g.
+	// the pod is gone or done and the watch missed it. 0 disables the
+	// detection.
+	StaleTimeout time.Duration
+}
+
+// ErrorWatchStale - The watch of the bundle was given up on, the bundle
+// pod was not alive within the StaleTimeout of the HeartbeatPolicy or made
+// no progress within the StallTimeout of the WatchTimeout.
+type ErrorWatchStale struct {
+	PodName string
+	Timeout time.Duration
+}
+
+func (e ErrorWatchStale) Error() string {
+	return fmt.Sprintf("watch of pod [ %s ] given up, no sign of progress for %v", e.PodName, e.Timeout)
+}
+
+// ErrorCode - the error is of the Timeout class.
+func (e ErrorWatchStale) ErrorCode() liberrors.Code {
+	return liberrors.CodeTimeout
+}
+
+// IsErrorWatchStale - true if the error is an ErrorWatchStale.
+func IsErrorWatchStale(err error) bool {
+	_, ok := err.(ErrorWatchStale)
+	return ok
+}
+
+// podGetter - reads the bundle pod, it is polled while it is watched.
+type podGetter func(podName, namespace string) (*apiv1.Pod, error)
+
+// getBundlePod - reads the bundle pod from the cluster.
+func getBundlePod(podName, namespace string) (*apiv1.Pod, error) {
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		return nil, err
+	}
+	return k8scli.Client.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+}
+
+// heartbeat - Forwards the updates of a watch and remembers when the bundle
+// pod was last seen alive and making progress. Updates are dropped once the
+// watch was given up on.
+type heartbeat struct {
+	mutex        sync.Mutex
+	updateFunc   UpdateDescriptionFn
+	clock        clock.Clock
+	description  string
+	lastAlive    time.Time
+	lastProgress time.Time
+	version      string
+	stopped      bool
+}
+
+func (h *heartbeat) update(description, dashboardURL string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.stopped {
+		return
+	}
+	if description != "" && description != h.description {
+		h.lastProgress = h.clock.Now()
+		h.description = description
+	}
+	if description != "" || dashboardURL != "" {
+		h.updateFunc(description, dashboardURL)
+	}
+}
+
+// poll - records the bundle pod read by a poll. A pending or running pod
+// is alive, a change of the pod is progress.
+func (h *heartbeat) poll(pod *apiv1.Pod, err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if err != nil {
+		log.Debugf("unable to poll the bundle pod - %v", err)
+		return
+	}
+	now := h.clock.Now()
+	if pod.Status.Phase == apiv1.PodPending || pod.Status.Phase == apiv1.PodRunning {
+		h.lastAlive = now
+	}
+	if pod.ResourceVersion != h.version {
+		h.lastProgress = now
+		h.version = pod.ResourceVersion
+	}
+}
+
+// beat - sends the last description again.
+func (h *heartbeat) beat() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.description != "" {
+		h.updateFunc(h.description, "")
+	}
+}
+
+// idle - how long ago the pod was last seen alive and making progress.
+func (h *heartbeat) idle() (time.Duration, time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	now := h.clock.Now()
+	return now.Sub(h.lastAlive), now.Sub(h.lastProgress)
+}
+
+func (h *heartbeat) stop() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.stopped = true
+}
+
+// watchWithHeartbeat - runs the watch, sending heartbeats and polling the
+// pod with getPod, nil reads it from the cluster. The action fails with
+// ErrorWatchStale when the pod was not alive for the StaleTimeout of the
+// policy or made no progress for the StallTimeout of the timeout, and
+// with ErrorWatchTimeout after its Timeout. The default watch is stopped
+// when it is given up on, the updates of any other watch are dropped. The
+// heartbeats, the idle time and the Timeout are measured with the clock,
+// nil uses clock.Real.
+func watchWithHeartbeat(
+	watch WatchRunningBundleFunc, getPod podGetter, policy HeartbeatPolicy, timeout WatchTimeout,
+	clk clock.Clock, podName, namespace string, updateFunc UpdateDescriptionFn,
+) error {
+	stale, stall := policy.StaleTimeout, timeout.StallTimeout
+	if policy.Interval <= 0 && stale <= 0 && stall <= 0 && timeout.Timeout <= 0 {
+		return watch(podName, namespace, updateFunc)
+	}
+	if getPod == nil {
+		getPod = getBundlePod
+	}
+	clk = clock.OrReal(clk)
+	now := clk.Now()
+	h := &heartbeat{updateFunc: updateFunc, clock: clk, lastAlive: now, lastProgress: now}
+	defer h.stop()
+
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() { done <- err }()
+		defer recoverPanic(&err)
+		err = watch(podName, namespace, h.update)
+	}()
+
+	var deadline <-chan time.Time
+	if timeout.Timeout > 0 {
+		deadline = clk.After(timeout.Timeout)
+	}
+	var ticks <-chan time.Time
+	interval := time.Duration(0)
+	for _, d := range []time.Duration{policy.Interval, stale, stall} {
+		if d > 0 && (interval == 0 || d < interval) {
+			interval = d
+		}
+	}
+	if interval > 0 {
+		ticker := clk.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C()
+	}
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-deadline:
+			log.Errorf("pod [ %s ] in namespace [ %s ] did not complete within %v", podName, namespace, timeout.Timeout)
+			podWatches.stop(podName)
+			return ErrorWatchTimeout{PodName: podName, Timeout: timeout.Timeout}
+		case <-ticks:
+			if policy.Interval > 0 {
+				h.beat()
+			}
+			if stale <= 0 && stall <= 0 {
+				continue
+			}
+			h.poll(getPod(podName, namespace))
+			dead, stalled := h.idle()
+			if stale > 0 && dead >= stale {
+				log.Errorf("watch of pod [ %s ] in namespace [ %s ] is stale, the pod was not alive for %v", podName, namespace, dead)
+				podWatches.stop(podName)
+				return ErrorWatchStale{PodName: podName, Timeout: stale}
+			}
+			if stall > 0 && stalled >= stall {
+				log.Errorf("pod [ %s ] in namespace [ %s ] made no progress for %v", podName, namespace, stalled)
+				podWatches.stop(podName)
+				return ErrorWatchStale{PodName: podName, Timeout: stall}
+			}
+		}
+	}
+}
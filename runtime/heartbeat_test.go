@@ -0,0 +1,210 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clock"
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// polledPod - a podGetter returning the pod in the phase, its resource
+// version changes on every poll when progressing.
+func polledPod(phase apiv1.PodPhase, progressing bool) podGetter {
+	var mutex sync.Mutex
+	version := 0
+	return func(string, string) (*apiv1.Pod, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if progressing {
+			version++
+		}
+		return &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{ResourceVersion: fmt.Sprint(version)},
+			Status:     apiv1.PodStatus{Phase: phase},
+		}, nil
+	}
+}
+
+func TestWatchWithHeartbeat(t *testing.T) {
+	watchErr := errors.New("watch failed")
+	quietWatch := func(podName, namespace string, updateFunc UpdateDescriptionFn) error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}
+	testCases := []struct {
+		name          string
+		policy        HeartbeatPolicy
+		timeout       WatchTimeout
+		watch         WatchRunningBundleFunc
+		pod           podGetter
+		expectStale   bool
+		expectTimeout bool
+		expectErr     error
+		minUpdates    int
+	}{
+		{
+			name: "disabled",
+			watch: func(podName, namespace string, updateFunc UpdateDescriptionFn) error {
+				updateFunc("running", "")
+				return watchErr
+			},
+			expectErr:  watchErr,
+			minUpdates: 1,
+		},
+		{
+			name:   "heartbeat resends the last description",
+			policy: HeartbeatPolicy{Interval: 5 * time.Millisecond},
+			watch: func(podName, namespace string, updateFunc UpdateDescriptionFn) error {
+				updateFunc("running", "")
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			},
+			minUpdates: 3,
+		},
+		{
+			name:   "quiet watch of a running pod is not stale",
+			policy: HeartbeatPolicy{StaleTimeout: 20 * time.Millisecond},
+			watch:  quietWatch,
+			pod:    polledPod(apiv1.PodRunning, false),
+		},
+		{
+			name:        "watch of a finished pod is stale",
+			policy:      HeartbeatPolicy{Interval: 5 * time.Millisecond, StaleTimeout: 20 * time.Millisecond},
+			watch:       quietWatch,
+			pod:         polledPod(apiv1.PodSucceeded, true),
+			expectStale: true,
+		},
+		{
+			name:   "watch of a missing pod is stale",
+			policy: HeartbeatPolicy{StaleTimeout: 20 * time.Millisecond},
+			watch:  quietWatch,
+			pod: func(string, string) (*apiv1.Pod, error) {
+				return nil, errors.New("not found")
+			},
+			expectStale: true,
+		},
+		{
+			name:    "progressing pod is not stalled",
+			timeout: WatchTimeout{StallTimeout: 20 * time.Millisecond},
+			watch:   quietWatch,
+			pod:     polledPod(apiv1.PodRunning, true),
+		},
+		{
+			name:        "unchanged pod is stalled",
+			policy:      HeartbeatPolicy{StaleTimeout: time.Minute},
+			timeout:     WatchTimeout{StallTimeout: 20 * time.Millisecond},
+			watch:       quietWatch,
+			pod:         polledPod(apiv1.PodRunning, false),
+			expectStale: true,
+		},
+		{
+			name:    "live watch times out",
+			timeout: WatchTimeout{Timeout: 30 * time.Millisecond},
+			watch: func(podName, namespace string, updateFunc UpdateDescriptionFn) error {
+				for {
+					updateFunc("running", "")
+					time.Sleep(5 * time.Millisecond)
+				}
+			},
+			expectTimeout: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			updates := 0
+			err := watchWithHeartbeat(tc.watch, tc.pod, tc.policy, tc.timeout, nil, "pod", "ns", func(description, dashboardURL string) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				updates++
+			})
+			if tc.expectStale {
+				assert.True(t, IsErrorWatchStale(err))
+				return
+			}
+			if tc.expectTimeout {
+				assert.True(t, IsErrorWatchTimeout(err))
+				return
+			}
+			assert.Equal(t, tc.expectErr, err)
+			mutex.Lock()
+			defer mutex.Unlock()
+			assert.True(t, updates >= tc.minUpdates, "expected at least %d updates, got %d", tc.minUpdates, updates)
+		})
+	}
+}
+
+func TestWatchWithHeartbeatClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	go func() {
+		for fake.Waiters() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		fake.Advance(time.Hour)
+	}()
+	hung := func(podName, namespace string, updateFunc UpdateDescriptionFn) error {
+		select {}
+	}
+	err := watchWithHeartbeat(hung, nil, HeartbeatPolicy{}, WatchTimeout{Timeout: time.Hour}, fake, "pod", "ns", func(string, string) {})
+	assert.True(t, IsErrorWatchTimeout(err), "unexpected error: %v", err)
+}
+
+func TestWatchWithHeartbeatClockStale(t *testing.T) {
+	fake := clock.NewFake(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	go func() {
+		for fake.Tickers() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		fake.Advance(time.Minute)
+	}()
+	hung := func(podName, namespace string, updateFunc UpdateDescriptionFn) error {
+		select {}
+	}
+	gone := func(string, string) (*apiv1.Pod, error) {
+		return nil, errors.New("not found")
+	}
+	err := watchWithHeartbeat(hung, gone, HeartbeatPolicy{StaleTimeout: time.Minute}, WatchTimeout{}, fake, "pod", "ns", func(string, string) {})
+	assert.True(t, IsErrorWatchStale(err), "unexpected error: %v", err)
+	assert.Equal(t, 0, fake.Tickers())
+}
+
+func TestWatchWithHeartbeatStopsWatch(t *testing.T) {
+	fw := watch.NewFake()
+	defer podWatches.add("pod", fw)()
+	podWatch := func(podName, namespace string, updateFunc UpdateDescriptionFn) error {
+		for range fw.ResultChan() {
+		}
+		return nil
+	}
+	gone := func(string, string) (*apiv1.Pod, error) {
+		return nil, errors.New("not found")
+	}
+	err := watchWithHeartbeat(podWatch, gone, HeartbeatPolicy{StaleTimeout: 10 * time.Millisecond}, WatchTimeout{}, nil, "pod", "ns", func(string, string) {})
+	assert.True(t, IsErrorWatchStale(err), "unexpected error: %v", err)
+	_, open := <-fw.ResultChan()
+	assert.False(t, open, "expected the watch to be stopped")
+}
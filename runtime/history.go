@@ -0,0 +1,146 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	log "github.com/automationbroker/bundle-lib/logging"
+	apicorev1 "k8s.io/api/core/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// maxHistory - the number of operations kept per instance, the oldest
+	// are dropped first.
+	maxHistory = 50
+	historyKey = "history"
+)
+
+// Operation - An action that was run on a service instance.
+type Operation struct {
+	Action string `json:"action"`
+	// ParametersHash - the sha256 of the parameters the action was run
+	// with, the parameters themselves may hold secrets.
+	ParametersHash string `json:"parameters_hash,omitempty"`
+	// Result - succeeded or failed.
+	Result     string    `json:"result"`
+	Error      string    `json:"error,omitempty"`
+	PodName    string    `json:"pod_name,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// historyName - the name of the configmap holding the history of the
+// instance in the master namespace.
+func historyName(instanceID string) string {
+	return fmt.Sprintf("%s-history", instanceID)
+}
+
+// RecordOperation - appends the operation to the history of the instance.
+func (s state) RecordOperation(instanceID string, op Operation) error {
+	k8s, err := clients.Kubernetes()
+	if err != nil {
+		return err
+	}
+	return recordOperation(k8s, s.nsTarget, instanceID, op)
+}
+
+// History - the operations run on the instance, oldest first.
+func (s state) History(instanceID string) ([]Operation, error) {
+	k8s, err := clients.Kubernetes()
+	if err != nil {
+		return nil, err
+	}
+	return readHistory(k8s, s.nsTarget, instanceID)
+}
+
+func recordOperation(k8s *clients.KubernetesClient, namespace, instanceID string, op Operation) error {
+	client := k8s.Client.CoreV1().ConfigMaps(namespace)
+	cm, err := client.Get(historyName(instanceID), metav1.GetOptions{})
+	if kerror.IsNotFound(err) {
+		cm = &apicorev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      historyName(instanceID),
+				Namespace: namespace,
+				Labels:    map[string]string{"bundle-instance": instanceID},
+			},
+			Data: map[string]string{},
+		}
+		if err := setHistory(cm, []Operation{op}); err != nil {
+			return err
+		}
+		_, err = client.Create(cm)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	history, err := decodeHistory(cm)
+	if err != nil {
+		return err
+	}
+	history = append(history, op)
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	if err := setHistory(cm, history); err != nil {
+		return err
+	}
+	_, err = client.Update(cm)
+	return err
+}
+
+func readHistory(k8s *clients.KubernetesClient, namespace, instanceID string) ([]Operation, error) {
+	cm, err := k8s.Client.CoreV1().ConfigMaps(namespace).Get(historyName(instanceID), metav1.GetOptions{})
+	if kerror.IsNotFound(err) {
+		log.Debugf("state: no history found for instance %v", instanceID)
+		return []Operation{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeHistory(cm)
+}
+
+func decodeHistory(cm *apicorev1.ConfigMap) ([]Operation, error) {
+	history := []Operation{}
+	data, ok := cm.Data[historyKey]
+	if !ok {
+		return history, nil
+	}
+	if err := json.Unmarshal([]byte(data), &history); err != nil {
+		return nil, fmt.Errorf("unable to decode the history in %v - %v", cm.Name, err)
+	}
+	return history, nil
+}
+
+func setHistory(cm *apicorev1.ConfigMap, history []Operation) error {
+	b, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[historyKey] = string(b)
+	return nil
+}
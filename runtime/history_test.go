@@ -0,0 +1,60 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestOperationHistory(t *testing.T) {
+	k8scli := &clients.KubernetesClient{Client: fake.NewSimpleClientset()}
+
+	history, err := readHistory(k8scli, "master", "instance")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Empty(t, history)
+
+	for i := 0; i < maxHistory+5; i++ {
+		op := Operation{Action: "update", Result: "succeeded", PodName: fmt.Sprintf("bundle-%d", i)}
+		if err := recordOperation(k8scli, "master", "instance", op); err != nil {
+			t.Fatalf("unknown error occured: %v", err)
+		}
+	}
+
+	history, err = readHistory(k8scli, "master", "instance")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if len(history) != maxHistory {
+		t.Fatalf("expected %d operations, got: %d", maxHistory, len(history))
+	}
+	// the oldest operations are dropped
+	assert.Equal(t, "bundle-5", history[0].PodName)
+	assert.Equal(t, fmt.Sprintf("bundle-%d", maxHistory+4), history[maxHistory-1].PodName)
+
+	other, err := readHistory(k8scli, "master", "other")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Empty(t, other)
+}
@@ -0,0 +1,215 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+	apicorev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbac "k8s.io/api/rbac/v1beta1"
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IdempotencyKeyLabel - Label on every object created for a sandbox. Its
+// value is the IdempotencyKey of the action, passed to CreateSandbox in
+// this label of the metadata, or the pod name when the metadata has none.
+// A retried CreateSandbox adopts the objects with its key instead of
+// failing on them, DestroySandbox deletes the objects by it.
+const IdempotencyKeyLabel = "bundle.automationbroker.io/idempotency-key"
+
+// IdempotencyKey - the key of the sandbox of an attempt of the action on
+// the instance. Retries of the same attempt get the same key, and adopt
+// the objects created by the earlier tries.
+func IdempotencyKey(instanceID, action string, attempt int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%d", instanceID, action, attempt)))
+	return fmt.Sprintf("%.32x", sum)
+}
+
+// sandboxKey - the idempotency key of the metadata of the sandbox, the pod
+// name when it has none.
+func sandboxKey(podName string, metadata map[string]string) string {
+	if key := metadata[IdempotencyKeyLabel]; key != "" {
+		return key
+	}
+	return podName
+}
+
+// destroyedSandboxKey - the idempotency key of the sandbox being
+// destroyed, read from its execution or its service account.
+func destroyedSandboxKey(k8scli *clients.KubernetesClient, podName, namespace string) string {
+	if ec, ok := bundles.execution(podName); ok && ec.IdempotencyKey != "" {
+		return ec.IdempotencyKey
+	}
+	sa, err := k8scli.Client.CoreV1().ServiceAccounts(namespace).Get(podName, metav1.GetOptions{})
+	if err == nil && sa.Labels[IdempotencyKeyLabel] != "" {
+		return sa.Labels[IdempotencyKeyLabel]
+	}
+	return podName
+}
+
+// ErrorSandboxConflict - An object the sandbox needs exists but was not
+// created for the sandbox, so it is not adopted.
+type ErrorSandboxConflict struct {
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+func (e ErrorSandboxConflict) Error() string {
+	return fmt.Sprintf("%s %s/%s exists and does not belong to the sandbox", e.Kind, e.Namespace, e.Name)
+}
+
+// ErrorCode - the error is of the Conflict class.
+func (e ErrorSandboxConflict) ErrorCode() liberrors.Code {
+	return liberrors.CodeConflict
+}
+
+// IsErrorSandboxConflict - true if the error is an ErrorSandboxConflict.
+func IsErrorSandboxConflict(err error) bool {
+	_, ok := err.(ErrorSandboxConflict)
+	return ok
+}
+
+// idempotencyLabels - the labels of the objects of the sandbox.
+func idempotencyLabels(key string) map[string]string {
+	return map[string]string{IdempotencyKeyLabel: key}
+}
+
+// sandboxSelector - selects the objects of the sandbox.
+func sandboxSelector(key string) metav1.ListOptions {
+	return metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", IdempotencyKeyLabel, key)}
+}
+
+// adopt - nil when the existing object was created for the sandbox by an
+// earlier attempt, an ErrorSandboxConflict otherwise.
+func adopt(kind string, meta metav1.ObjectMeta, key string) error {
+	if meta.Labels[IdempotencyKeyLabel] != key {
+		return ErrorSandboxConflict{Kind: kind, Name: meta.Name, Namespace: meta.Namespace}
+	}
+	log.Debugf("adopting %s %s/%s of sandbox %s", kind, meta.Namespace, meta.Name, key)
+	return nil
+}
+
+// createServiceAccount - creates the service account of the sandbox, or
+// adopts it.
+func createServiceAccount(k8scli *clients.KubernetesClient, podName, key, namespace string) error {
+	sa := &apicorev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: podName, Labels: idempotencyLabels(key)},
+	}
+	client := k8scli.Client.CoreV1().ServiceAccounts(namespace)
+	_, err := client.Create(sa)
+	if !kapierrors.IsAlreadyExists(err) {
+		return err
+	}
+	existing, err := client.Get(podName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return adopt("ServiceAccount", existing.ObjectMeta, key)
+}
+
+// createRoleBinding - creates the role binding of the sandbox in the
+// target namespace, or adopts it.
+func createRoleBinding(
+	k8scli *clients.KubernetesClient, podName, key string, subjects []rbac.Subject, target string, roleRef rbac.RoleRef,
+) error {
+	log.Infof("Creating RoleBinding %s", podName)
+	rb := &rbac.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: target, Labels: idempotencyLabels(key)},
+		Subjects:   subjects,
+		RoleRef:    roleRef,
+	}
+	client := k8scli.Client.RbacV1beta1().RoleBindings(target)
+	_, err := client.Create(rb)
+	if !kapierrors.IsAlreadyExists(err) {
+		return err
+	}
+	existing, err := client.Get(podName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return adopt("RoleBinding", existing.ObjectMeta, key)
+}
+
+// createNetworkPolicy - creates the network policy of the sandbox in the
+// target namespace, or adopts it.
+func createNetworkPolicy(k8scli *clients.KubernetesClient, key string, policy *networkingv1.NetworkPolicy, target string) error {
+	policy.ObjectMeta.Labels = idempotencyLabels(key)
+	client := k8scli.Client.NetworkingV1().NetworkPolicies(target)
+	_, err := client.Create(policy)
+	if !kapierrors.IsAlreadyExists(err) {
+		return err
+	}
+	existing, err := client.Get(policy.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return adopt("NetworkPolicy", existing.ObjectMeta, key)
+}
+
+// deleteRoleBindings - deletes the role bindings of the sandbox in the
+// namespace. When none carries the key, the role binding named after the
+// pod is deleted, it was created before the objects were labeled.
+func deleteRoleBindings(k8scli *clients.KubernetesClient, podName, key, namespace string) error {
+	client := k8scli.Client.RbacV1beta1().RoleBindings(namespace)
+	list, err := client.List(sandboxSelector(key))
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for _, rb := range list.Items {
+		names = append(names, rb.Name)
+	}
+	if len(names) == 0 {
+		names = append(names, podName)
+	}
+	for _, name := range names {
+		if err := client.Delete(name, &metav1.DeleteOptions{}); err != nil && !kapierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteNetworkPolicies - deletes the network policies of the sandbox in
+// the namespace, falling back on the name like deleteRoleBindings.
+func deleteNetworkPolicies(k8scli *clients.KubernetesClient, podName, key, namespace string) error {
+	client := k8scli.Client.NetworkingV1().NetworkPolicies(namespace)
+	list, err := client.List(sandboxSelector(key))
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for _, np := range list.Items {
+		names = append(names, np.Name)
+	}
+	if len(names) == 0 {
+		names = append(names, podName)
+	}
+	for _, name := range names {
+		if err := client.Delete(name, &metav1.DeleteOptions{}); err != nil && !kapierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
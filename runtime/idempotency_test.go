@@ -0,0 +1,161 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbac "k8s.io/api/rbac/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	key := IdempotencyKey("instance", "provision", 1)
+	if key != IdempotencyKey("instance", "provision", 1) {
+		t.Fatalf("expected the same key for a retry of the attempt")
+	}
+	if len(key) != 32 {
+		t.Fatalf("expected a key of 32 characters got: %v", key)
+	}
+	for _, other := range []string{
+		IdempotencyKey("other", "provision", 1),
+		IdempotencyKey("instance", "deprovision", 1),
+		IdempotencyKey("instance", "provision", 2),
+	} {
+		if other == key {
+			t.Fatalf("expected a different key got: %v", other)
+		}
+	}
+	if sandboxKey("bundle-1", idempotencyLabels(key)) != key {
+		t.Fatalf("expected the key of the metadata")
+	}
+	if sandboxKey("bundle-1", nil) != "bundle-1" {
+		t.Fatalf("expected the pod name without a key in the metadata")
+	}
+}
+
+func TestCreateSandboxObjectsAdopt(t *testing.T) {
+	k8scli := &clients.KubernetesClient{Client: fake.NewSimpleClientset()}
+	key := IdempotencyKey("instance", "provision", 1)
+	podName := "bundle-" + key
+	subjects := []rbac.Subject{{Kind: "ServiceAccount", Name: podName, Namespace: "ns"}}
+	roleRef := rbac.RoleRef{Kind: "ClusterRole", Name: "edit"}
+
+	// The retry of the attempt adopts the objects of the first try.
+	for i := 0; i < 2; i++ {
+		if err := createServiceAccount(k8scli, podName, key, "ns"); err != nil {
+			t.Fatalf("unknown error occured: %v", err)
+		}
+		if err := createRoleBinding(k8scli, podName, key, subjects, "target", roleRef); err != nil {
+			t.Fatalf("unknown error occured: %v", err)
+		}
+		np := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: podName}}
+		if err := createNetworkPolicy(k8scli, key, np, "target"); err != nil {
+			t.Fatalf("unknown error occured: %v", err)
+		}
+	}
+	sa, err := k8scli.Client.CoreV1().ServiceAccounts("ns").Get(podName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if sa.Labels[IdempotencyKeyLabel] != key {
+		t.Fatalf("expected the idempotency key on the service account got: %v", sa.Labels)
+	}
+	if destroyedSandboxKey(k8scli, podName, "ns") != key {
+		t.Fatalf("expected the key of the service account of the sandbox")
+	}
+}
+
+func TestCreateSandboxObjectsConflict(t *testing.T) {
+	sa := &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "bundle-1", Namespace: "ns"}}
+	rb := &rbac.RoleBinding{ObjectMeta: metav1.ObjectMeta{
+		Name: "bundle-1", Namespace: "target", Labels: idempotencyLabels("bundle-2"),
+	}}
+	k8scli := &clients.KubernetesClient{Client: fake.NewSimpleClientset(sa, rb)}
+
+	err := createServiceAccount(k8scli, "bundle-1", "bundle-1", "ns")
+	if !IsErrorSandboxConflict(err) {
+		t.Fatalf("expected a conflict got: %v", err)
+	}
+	err = createRoleBinding(k8scli, "bundle-1", "bundle-1", nil, "target", rbac.RoleRef{})
+	if !IsErrorSandboxConflict(err) {
+		t.Fatalf("expected a conflict got: %v", err)
+	}
+}
+
+func TestDeleteSandboxObjects(t *testing.T) {
+	k8scli := &clients.KubernetesClient{Client: fake.NewSimpleClientset(
+		&rbac.RoleBinding{ObjectMeta: metav1.ObjectMeta{
+			Name: "renamed", Namespace: "target", Labels: idempotencyLabels("bundle-1"),
+		}},
+		&rbac.RoleBinding{ObjectMeta: metav1.ObjectMeta{
+			Name: "other", Namespace: "target", Labels: idempotencyLabels("bundle-2"),
+		}},
+		// created before the objects were labeled
+		&rbac.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "bundle-3", Namespace: "target"}},
+		&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{
+			Name: "bundle-1", Namespace: "target", Labels: idempotencyLabels("bundle-1"),
+		}},
+	)}
+
+	if err := deleteRoleBindings(k8scli, "bundle-x", "bundle-1", "target"); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if err := deleteRoleBindings(k8scli, "bundle-3", "bundle-3", "target"); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	// nothing left to delete is not an error
+	if err := deleteRoleBindings(k8scli, "bundle-4", "bundle-4", "target"); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if err := deleteNetworkPolicies(k8scli, "bundle-x", "bundle-1", "target"); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	rbs, err := k8scli.Client.RbacV1beta1().RoleBindings("target").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if len(rbs.Items) != 1 || rbs.Items[0].Name != "other" {
+		t.Fatalf("expected only the role binding of the other sandbox got: %v", rbs.Items)
+	}
+	nps, err := k8scli.Client.NetworkingV1().NetworkPolicies("target").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if len(nps.Items) != 0 {
+		t.Fatalf("expected no network policies got: %v", nps.Items)
+	}
+}
+
+func TestTransientNamespaceAdopt(t *testing.T) {
+	existing := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: "bundle-abcde", Labels: idempotencyLabels("bundle-1"),
+	}}
+	k8scli := &clients.KubernetesClient{Client: fake.NewSimpleClientset(existing)}
+	ns, err := transientNamespace{}.Prepare(k8scli, "bundle-", []string{"target"}, idempotencyLabels("bundle-1"))
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if ns != "bundle-abcde" {
+		t.Fatalf("expected the existing namespace got: %v", ns)
+	}
+}
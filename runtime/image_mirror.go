@@ -0,0 +1,147 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// ImageMirrorsKey - the extra var holding the image mirrors, so
+	// playbooks can resolve the images they deploy themselves.
+	ImageMirrorsKey = "_image_mirrors"
+	// DependenciesKey - the extra var holding the resolved image of each
+	// dependency of the bundle, keyed by the image of the spec.
+	DependenciesKey = "_dependencies"
+	// defaultRegistry - the registry of the images without one.
+	defaultRegistry = "docker.io"
+)
+
+// ImageMirror - Images starting with the Source prefix are pulled from the
+// Mirror prefix instead, e.g. docker.io/ansibleplaybookbundle to
+// mirror.example.com/apbs. Images without a registry are matched as
+// docker.io images.
+type ImageMirror struct {
+	Source string `json:"source"`
+	Mirror string `json:"mirror"`
+}
+
+// ImageMirrors - The mirrors of a disconnected cluster. The mirror with the
+// longest matching source is used.
+type ImageMirrors []ImageMirror
+
+// Validate - every mirror needs a source and a mirror.
+func (m ImageMirrors) Validate() error {
+	for i, mirror := range m {
+		if mirror.Source == "" || mirror.Mirror == "" {
+			return fmt.Errorf("image mirror %d needs a source and a mirror", i)
+		}
+	}
+	return nil
+}
+
+// Resolve - the image to pull for the image, unchanged when no mirror
+// matches.
+func (m ImageMirrors) Resolve(image string) string {
+	if image == "" {
+		return image
+	}
+	var best *ImageMirror
+	var rest string
+	for i, mirror := range m {
+		source := strings.TrimSuffix(mirror.Source, "/")
+		r, ok := trimImagePrefix(image, source)
+		if !ok {
+			r, ok = trimImagePrefix(qualifiedImage(image), source)
+		}
+		if ok && (best == nil || len(source) > len(strings.TrimSuffix(best.Source, "/"))) {
+			best = &m[i]
+			rest = r
+		}
+	}
+	if best == nil {
+		return image
+	}
+	return strings.TrimSuffix(best.Mirror, "/") + rest
+}
+
+// trimImagePrefix - the rest of the image after the prefix, which has to
+// end on a path, tag or digest boundary.
+func trimImagePrefix(image, prefix string) (string, bool) {
+	if !strings.HasPrefix(image, prefix) {
+		return "", false
+	}
+	rest := image[len(prefix):]
+	if rest == "" || strings.ContainsAny(rest[:1], "/:@") {
+		return rest, true
+	}
+	return "", false
+}
+
+// qualifiedImage - the image with the implicit docker.io registry and
+// library namespace.
+func qualifiedImage(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return image
+	}
+	if len(parts) == 1 {
+		return defaultRegistry + "/library/" + image
+	}
+	return defaultRegistry + "/" + image
+}
+
+// applyImageMirrors - pulls the images of the bundle pod from the mirrors
+// and passes the mirrors and the resolved dependencies to the bundle.
+func applyImageMirrors(ec ExecutionContext, mirrors ImageMirrors) (ExecutionContext, error) {
+	if len(mirrors) == 0 {
+		return ec, nil
+	}
+	ec.Image = mirrors.Resolve(ec.Image)
+	// the containers may be shared with the caller
+	ec.InitContainers = append([]v1.Container(nil), ec.InitContainers...)
+	ec.Sidecars = append([]v1.Container(nil), ec.Sidecars...)
+	for i := range ec.InitContainers {
+		ec.InitContainers[i].Image = mirrors.Resolve(ec.InitContainers[i].Image)
+	}
+	for i := range ec.Sidecars {
+		ec.Sidecars[i].Image = mirrors.Resolve(ec.Sidecars[i].Image)
+	}
+	dependencies := map[string]string{}
+	for _, d := range ec.Dependencies {
+		dependencies[d] = mirrors.Resolve(d)
+	}
+
+	vars := map[string]interface{}{}
+	if ec.ExtraVars != "" {
+		if err := json.Unmarshal([]byte(ec.ExtraVars), &vars); err != nil {
+			return ec, err
+		}
+	}
+	vars[ImageMirrorsKey] = mirrors
+	vars[DependenciesKey] = dependencies
+	b, err := json.Marshal(vars)
+	if err != nil {
+		return ec, err
+	}
+	ec.ExtraVars = string(b)
+	return ec, nil
+}
@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+)
+
+func TestImageMirrorsResolve(t *testing.T) {
+	mirrors := ImageMirrors{
+		{Source: "docker.io", Mirror: "mirror.example.com/dockerhub"},
+		{Source: "docker.io/ansibleplaybookbundle", Mirror: "mirror.example.com/apbs"},
+		{Source: "quay.io/coreos/etcd", Mirror: "mirror.example.com/etcd/"},
+	}
+	cases := []struct {
+		image    string
+		expected string
+	}{
+		{image: "docker.io/ansibleplaybookbundle/mediawiki-apb:latest", expected: "mirror.example.com/apbs/mediawiki-apb:latest"},
+		{image: "ansibleplaybookbundle/mediawiki-apb", expected: "mirror.example.com/apbs/mediawiki-apb"},
+		{image: "centos/postgresql-95-centos7", expected: "mirror.example.com/dockerhub/centos/postgresql-95-centos7"},
+		{image: "alpine:3.18", expected: "mirror.example.com/dockerhub/library/alpine:3.18"},
+		{image: "quay.io/coreos/etcd@sha256:abc", expected: "mirror.example.com/etcd@sha256:abc"},
+		{image: "quay.io/coreos/etcd-operator:v0.9.4", expected: "quay.io/coreos/etcd-operator:v0.9.4"},
+		{image: "registry.example.com/apb:latest", expected: "registry.example.com/apb:latest"},
+		{image: "", expected: ""},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, mirrors.Resolve(tc.image), tc.image)
+	}
+}
+
+func TestImageMirrorsValidate(t *testing.T) {
+	assert.NoError(t, ImageMirrors{}.Validate())
+	assert.Error(t, ImageMirrors{{Source: "docker.io"}}.Validate())
+}
+
+func TestApplyImageMirrors(t *testing.T) {
+	mirrors := ImageMirrors{{Source: "docker.io", Mirror: "mirror.example.com"}}
+	initContainers := []v1.Container{{Name: "fetch", Image: "alpine"}}
+	ec := ExecutionContext{
+		Image:          "docker.io/ansibleplaybookbundle/mediawiki-apb",
+		InitContainers: initContainers,
+		Dependencies:   []string{"centos/postgresql-95-centos7", "quay.io/coreos/etcd"},
+		ExtraVars:      `{"namespace": "ns"}`,
+	}
+	ec, err := applyImageMirrors(ec, mirrors)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "mirror.example.com/ansibleplaybookbundle/mediawiki-apb", ec.Image)
+	assert.Equal(t, "mirror.example.com/library/alpine", ec.InitContainers[0].Image)
+	assert.Equal(t, "alpine", initContainers[0].Image)
+
+	vars := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(ec.ExtraVars), &vars); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "ns", vars["namespace"])
+	assert.Equal(t, map[string]interface{}{
+		"centos/postgresql-95-centos7": "mirror.example.com/centos/postgresql-95-centos7",
+		"quay.io/coreos/etcd":          "quay.io/coreos/etcd",
+	}, vars[DependenciesKey])
+	assert.Len(t, vars[ImageMirrorsKey], 1)
+
+	unchanged, err := applyImageMirrors(ExecutionContext{Image: "alpine"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "alpine", unchanged.Image)
+}
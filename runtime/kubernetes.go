@@ -18,10 +18,10 @@ package runtime
 
 type kubernetes struct{}
 
-func (k kubernetes) getRuntime() string {
-	return "kubernetes"
+func (k kubernetes) GetRuntime() string {
+	return COEKubernetes
 }
 
-func (k kubernetes) shouldJoinNetworks() (bool, PostSandboxCreate, PostSandboxDestroy) {
+func (k kubernetes) ShouldJoinNetworks() (bool, PostSandboxCreate, PostSandboxDestroy) {
 	return false, nil, nil
 }
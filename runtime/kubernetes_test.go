@@ -20,7 +20,7 @@ import "testing"
 
 func TestKubernetesShouldJoinNetworks(t *testing.T) {
 	k := kubernetes{}
-	s := k.getRuntime()
+	s := k.GetRuntime()
 	if s != "kubernetes" {
 		t.Fatal("runtime does not match kubernetes")
 	}
@@ -29,7 +29,7 @@ func TestKubernetesShouldJoinNetworks(t *testing.T) {
 func TestKubernetesGetRuntime(t *testing.T) {
 	k := kubernetes{}
 
-	jn, postCreateHook, postDestroyHook := k.shouldJoinNetworks()
+	jn, postCreateHook, postDestroyHook := k.ShouldJoinNetworks()
 	if jn || postCreateHook != nil || postDestroyHook != nil {
 		t.Fatal("should join networks, or sand box hooks were not nil.")
 	}
@@ -10,6 +10,27 @@ type MockRuntime struct {
 	mock.Mock
 }
 
+// Capabilities provides a mock function with given fields:
+func (_m *MockRuntime) Capabilities() (Capabilities, error) {
+	ret := _m.Called()
+
+	var r0 Capabilities
+	if rf, ok := ret.Get(0).(func() Capabilities); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(Capabilities)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CopySecretsToNamespace provides a mock function with given fields: _a0, _a1, _a2
 func (_m *MockRuntime) CopySecretsToNamespace(_a0 ExecutionContext, _a1 string, _a2 []string) error {
 	ret := _m.Called(_a0, _a1, _a2)
@@ -80,6 +101,27 @@ func (_m *MockRuntime) CreateSandbox(_a0 string, _a1 string, _a2 []string, _a3 s
 	return r0, r1, r2
 }
 
+// DebugBundle provides a mock function with given fields: podName, namespace
+func (_m *MockRuntime) DebugBundle(podName string, namespace string) (DebugSession, error) {
+	ret := _m.Called(podName, namespace)
+
+	var r0 DebugSession
+	if rf, ok := ret.Get(0).(func(string, string) DebugSession); ok {
+		r0 = rf(podName, namespace)
+	} else {
+		r0 = ret.Get(0).(DebugSession)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(podName, namespace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteExtractedCredential provides a mock function with given fields: _a0, _a1
 func (_m *MockRuntime) DeleteExtractedCredential(_a0 string, _a1 string) error {
 	ret := _m.Called(_a0, _a1)
@@ -173,6 +215,43 @@ func (_m *MockRuntime) GetRuntime() string {
 	return r0
 }
 
+// History provides a mock function with given fields: instanceID
+func (_m *MockRuntime) History(instanceID string) ([]Operation, error) {
+	ret := _m.Called(instanceID)
+
+	var r0 []Operation
+	if rf, ok := ret.Get(0).(func(string) []Operation); ok {
+		r0 = rf(instanceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(instanceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InstallOperator provides a mock function with given fields: _a0
+func (_m *MockRuntime) InstallOperator(_a0 OperatorSubscription) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(OperatorSubscription) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // MasterName provides a mock function with given fields: instanceID
 func (_m *MockRuntime) MasterName(instanceID string) string {
 	ret := _m.Called(instanceID)
@@ -215,6 +294,85 @@ func (_m *MockRuntime) MountLocation() string {
 	return r0
 }
 
+// PlatformInfo provides a mock function with given fields:
+func (_m *MockRuntime) PlatformInfo() (PlatformInfo, error) {
+	ret := _m.Called()
+
+	var r0 PlatformInfo
+	if rf, ok := ret.Get(0).(func() PlatformInfo); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(PlatformInfo)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecoverExecutions provides a mock function with given fields:
+func (_m *MockRuntime) RecoverExecutions() ([]ExecutionContext, error) {
+	ret := _m.Called()
+
+	var r0 []ExecutionContext
+	if rf, ok := ret.Get(0).(func() []ExecutionContext); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ExecutionContext)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordOperation provides a mock function with given fields: instanceID, op
+func (_m *MockRuntime) RecordOperation(instanceID string, op Operation) error {
+	ret := _m.Called(instanceID, op)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, Operation) error); ok {
+		r0 = rf(instanceID, op)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordScheduledRuns provides a mock function with given fields: instanceID
+func (_m *MockRuntime) RecordScheduledRuns(instanceID string) (int, error) {
+	ret := _m.Called(instanceID)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(string) int); ok {
+		r0 = rf(instanceID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(instanceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RunBundle provides a mock function with given fields: _a0
 func (_m *MockRuntime) RunBundle(_a0 ExecutionContext) (ExecutionContext, error) {
 	ret := _m.Called(_a0)
@@ -236,6 +394,43 @@ func (_m *MockRuntime) RunBundle(_a0 ExecutionContext) (ExecutionContext, error)
 	return r0, r1
 }
 
+// ScheduleAction provides a mock function with given fields: _a0, _a1
+func (_m *MockRuntime) ScheduleAction(_a0 ExecutionContext, _a1 ScheduledAction) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(ExecutionContext, ScheduledAction) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScheduledActions provides a mock function with given fields: instanceID
+func (_m *MockRuntime) ScheduledActions(instanceID string) ([]ScheduledAction, error) {
+	ret := _m.Called(instanceID)
+
+	var r0 []ScheduledAction
+	if rf, ok := ret.Get(0).(func(string) []ScheduledAction); ok {
+		r0 = rf(instanceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ScheduledAction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(instanceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // StateIsPresent provides a mock function with given fields: name
 func (_m *MockRuntime) StateIsPresent(name string) (bool, error) {
 	ret := _m.Called(name)
@@ -257,6 +452,34 @@ func (_m *MockRuntime) StateIsPresent(name string) (bool, error) {
 	return r0, r1
 }
 
+// UninstallOperator provides a mock function with given fields: _a0
+func (_m *MockRuntime) UninstallOperator(_a0 OperatorSubscription) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(OperatorSubscription) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UnscheduleAction provides a mock function with given fields: _a0
+func (_m *MockRuntime) UnscheduleAction(_a0 ScheduledAction) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(ScheduledAction) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateExtractedCredential provides a mock function with given fields: _a0, _a1, _a2, _a3
 func (_m *MockRuntime) UpdateExtractedCredential(_a0 string, _a1 string, _a2 map[string]interface{}, _a3 map[string]string) error {
 	ret := _m.Called(_a0, _a1, _a2, _a3)
@@ -21,7 +21,7 @@ import (
 	"strings"
 	"time"
 
-	log "github.com/sirupsen/logrus"
+	log "github.com/automationbroker/bundle-lib/logging"
 
 	"github.com/automationbroker/bundle-lib/clients"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -29,11 +29,11 @@ import (
 
 type openshift struct{}
 
-func (o openshift) getRuntime() string {
-	return "openshift"
+func (o openshift) GetRuntime() string {
+	return COEOpenShift
 }
 
-func (o openshift) shouldJoinNetworks() (bool, PostSandboxCreate, PostSandboxDestroy) {
+func (o openshift) ShouldJoinNetworks() (bool, PostSandboxCreate, PostSandboxDestroy) {
 	ocli, err := clients.Openshift()
 	if err != nil {
 		log.Errorf("unable to get openshift client - %v", err)
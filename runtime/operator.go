@@ -0,0 +1,288 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	"github.com/automationbroker/bundle-lib/clock"
+	log "github.com/automationbroker/bundle-lib/logging"
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	operatorsGroupVersion      = "operators.coreos.com/v1"
+	operatorsAlphaGroupVersion = "operators.coreos.com/v1alpha1"
+	// OperatorGroupName - the name of the operator group created in the
+	// target namespaces that have none.
+	OperatorGroupName = "bundle-lib-operators"
+	// DefaultOperatorInstallTimeout - how long the installation of an
+	// operator is waited for.
+	DefaultOperatorInstallTimeout = 10 * time.Minute
+	operatorInstallPollInterval   = 5 * time.Second
+)
+
+// OperatorSubscription - The OLM subscription installing an operator in a
+// namespace.
+type OperatorSubscription struct {
+	// Name - the name of the subscription.
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Package - the package of the operator in the catalog.
+	Package string `json:"package"`
+	Channel string `json:"channel"`
+	// CatalogSource and CatalogSourceNamespace - the catalog the package
+	// is installed from.
+	CatalogSource          string `json:"catalog_source"`
+	CatalogSourceNamespace string `json:"catalog_source_namespace"`
+}
+
+// OperatorInstaller - Installs and uninstalls operators with OLM. The
+// default talks to the OLM API of the cluster.
+type OperatorInstaller interface {
+	// Install - creates or updates the subscription, with an operator group
+	// when the namespace has none, and waits for the operator to be
+	// installed.
+	Install(sub OperatorSubscription) error
+	// Uninstall - deletes the subscription and the installed operator.
+	Uninstall(sub OperatorSubscription) error
+}
+
+// ErrorOperatorInstallFailed - OLM failed to install the operator.
+type ErrorOperatorInstallFailed struct {
+	Subscription string
+	Namespace    string
+	Reason       string
+}
+
+func (e ErrorOperatorInstallFailed) Error() string {
+	return fmt.Sprintf("operator subscription %v in namespace %v failed: %v", e.Subscription, e.Namespace, e.Reason)
+}
+
+// IsErrorOperatorInstallFailed - true if the error is an
+// ErrorOperatorInstallFailed.
+func IsErrorOperatorInstallFailed(err error) bool {
+	_, ok := err.(ErrorOperatorInstallFailed)
+	return ok
+}
+
+// InstallOperator - installs the operator of the subscription.
+func (p provider) InstallOperator(sub OperatorSubscription) error {
+	log.Infof("installing operator %v from channel %v in namespace %v", sub.Package, sub.Channel, sub.Namespace)
+	return p.operatorInstaller.Install(sub)
+}
+
+// UninstallOperator - uninstalls the operator of the subscription.
+func (p provider) UninstallOperator(sub OperatorSubscription) error {
+	log.Infof("uninstalling operator %v from namespace %v", sub.Package, sub.Namespace)
+	return p.operatorInstaller.Uninstall(sub)
+}
+
+// olmInstaller - An OperatorInstaller using the OLM API of the cluster.
+type olmInstaller struct {
+	timeout time.Duration
+	clock   clock.Clock
+}
+
+func (o olmInstaller) client() (rest.Interface, error) {
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		return nil, err
+	}
+	return k8scli.Client.Discovery().RESTClient(), nil
+}
+
+func olmPath(groupVersion, namespace, resource, name string) string {
+	path := fmt.Sprintf("/apis/%s/namespaces/%s/%s", groupVersion, namespace, resource)
+	if name != "" {
+		path = path + "/" + name
+	}
+	return path
+}
+
+// Install - creates the operator group and the subscription and waits for
+// the cluster service version of the operator to succeed.
+func (o olmInstaller) Install(sub OperatorSubscription) error {
+	c, err := o.client()
+	if err != nil {
+		return err
+	}
+	if err := ensureOperatorGroup(c, sub.Namespace); err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"apiVersion": operatorsAlphaGroupVersion,
+		"kind":       "Subscription",
+		"metadata":   map[string]interface{}{"name": sub.Name, "namespace": sub.Namespace},
+		"spec": map[string]interface{}{
+			"name":            sub.Package,
+			"channel":         sub.Channel,
+			"source":          sub.CatalogSource,
+			"sourceNamespace": sub.CatalogSourceNamespace,
+		},
+	}
+	path := olmPath(operatorsAlphaGroupVersion, sub.Namespace, "subscriptions", sub.Name)
+	existing := map[string]interface{}{}
+	raw, err := c.Get().AbsPath(path).Do().Raw()
+	switch {
+	case kapierrors.IsNotFound(err):
+		b, _ := json.Marshal(body)
+		_, err = c.Post().AbsPath(olmPath(operatorsAlphaGroupVersion, sub.Namespace, "subscriptions", "")).
+			Body(b).Do().Raw()
+	case err == nil:
+		// keep the resource version so the update is accepted
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return err
+		}
+		body["metadata"] = existing["metadata"]
+		b, _ := json.Marshal(body)
+		_, err = c.Put().AbsPath(path).Body(b).Do().Raw()
+	}
+	if err != nil {
+		log.Errorf("unable to create the subscription %v in namespace %v - %v", sub.Name, sub.Namespace, err)
+		return err
+	}
+	return o.waitForInstall(c, sub)
+}
+
+// ensureOperatorGroup - creates an operator group targeting the namespace
+// when it has none, OLM only installs operators in namespaces with one.
+func ensureOperatorGroup(c rest.Interface, namespace string) error {
+	raw, err := c.Get().AbsPath(olmPath(operatorsGroupVersion, namespace, "operatorgroups", "")).Do().Raw()
+	if err != nil {
+		return err
+	}
+	list := struct {
+		Items []interface{} `json:"items"`
+	}{}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return err
+	}
+	if len(list.Items) > 0 {
+		return nil
+	}
+	b, _ := json.Marshal(map[string]interface{}{
+		"apiVersion": operatorsGroupVersion,
+		"kind":       "OperatorGroup",
+		"metadata":   map[string]interface{}{"name": OperatorGroupName, "namespace": namespace},
+		"spec":       map[string]interface{}{"targetNamespaces": []string{namespace}},
+	})
+	_, err = c.Post().AbsPath(olmPath(operatorsGroupVersion, namespace, "operatorgroups", "")).Body(b).Do().Raw()
+	if err != nil {
+		log.Errorf("unable to create the operator group in namespace %v - %v", namespace, err)
+	}
+	return err
+}
+
+// installedCSV - the cluster service version installed by the
+// subscription, empty until OLM installed it.
+func installedCSV(c rest.Interface, sub OperatorSubscription) (string, error) {
+	raw, err := c.Get().AbsPath(olmPath(operatorsAlphaGroupVersion, sub.Namespace, "subscriptions", sub.Name)).Do().Raw()
+	if err != nil {
+		return "", err
+	}
+	s := struct {
+		Status struct {
+			InstalledCSV string `json:"installedCSV"`
+		} `json:"status"`
+	}{}
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", err
+	}
+	return s.Status.InstalledCSV, nil
+}
+
+// waitForInstall - polls the cluster service version of the subscription
+// until it succeeds, fails or the timeout expires.
+func (o olmInstaller) waitForInstall(c rest.Interface, sub OperatorSubscription) error {
+	timeout := o.timeout
+	if timeout == 0 {
+		timeout = DefaultOperatorInstallTimeout
+	}
+	clk := clock.OrReal(o.clock)
+	deadline := clk.Now().Add(timeout)
+	for clk.Now().Before(deadline) {
+		csv, err := installedCSV(c, sub)
+		if err != nil {
+			return err
+		}
+		if csv != "" {
+			raw, err := c.Get().AbsPath(olmPath(operatorsAlphaGroupVersion, sub.Namespace, "clusterserviceversions", csv)).Do().Raw()
+			if err != nil && !kapierrors.IsNotFound(err) {
+				return err
+			}
+			status := struct {
+				Status struct {
+					Phase   string `json:"phase"`
+					Message string `json:"message"`
+				} `json:"status"`
+			}{}
+			if err == nil {
+				if err := json.Unmarshal(raw, &status); err != nil {
+					return err
+				}
+			}
+			switch status.Status.Phase {
+			case "Succeeded":
+				log.Infof("operator %v installed in namespace %v", csv, sub.Namespace)
+				return nil
+			case "Failed":
+				return ErrorOperatorInstallFailed{Subscription: sub.Name, Namespace: sub.Namespace, Reason: status.Status.Message}
+			}
+		}
+		<-clk.After(operatorInstallPollInterval)
+	}
+	return ErrorOperatorInstallFailed{
+		Subscription: sub.Name,
+		Namespace:    sub.Namespace,
+		Reason:       fmt.Sprintf("not installed after %v", timeout),
+	}
+}
+
+// Uninstall - deletes the subscription and the cluster service version it
+// installed. The operator group is kept, other operators may use it.
+func (o olmInstaller) Uninstall(sub OperatorSubscription) error {
+	c, err := o.client()
+	if err != nil {
+		return err
+	}
+	csv, err := installedCSV(c, sub)
+	if kapierrors.IsNotFound(err) {
+		log.Infof("subscription %v not found in namespace %v, nothing to uninstall", sub.Name, sub.Namespace)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = c.Delete().AbsPath(olmPath(operatorsAlphaGroupVersion, sub.Namespace, "subscriptions", sub.Name)).Do().Raw()
+	if err != nil && !kapierrors.IsNotFound(err) {
+		return err
+	}
+	if csv == "" {
+		return nil
+	}
+	_, err = c.Delete().AbsPath(olmPath(operatorsAlphaGroupVersion, sub.Namespace, "clusterserviceversions", csv)).Do().Raw()
+	if err != nil && !kapierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+// ErrorPanicked - A panic was recovered in a goroutine of the runtime and
+// turned into an error, so it fails the action instead of crashing the
+// consumer.
+type ErrorPanicked struct {
+	Value interface{}
+	// Stack - the stack of the goroutine that panicked.
+	Stack string
+}
+
+func (e ErrorPanicked) Error() string {
+	return fmt.Sprintf("recovered from panic - %v", e.Value)
+}
+
+// IsErrorPanicked - true if the error is an ErrorPanicked.
+func IsErrorPanicked(err error) bool {
+	_, ok := err.(ErrorPanicked)
+	return ok
+}
+
+// recoverPanic - deferred by the goroutines of the runtime, sets err to an
+// ErrorPanicked when the goroutine panics.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		stack := string(debug.Stack())
+		log.Errorf("recovered from panic - %v\n%s", r, stack)
+		*err = ErrorPanicked{Value: r, Stack: stack}
+	}
+}
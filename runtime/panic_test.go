@@ -0,0 +1,46 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	run := func() (err error) {
+		defer recoverPanic(&err)
+		panic("boom")
+	}
+	err := run()
+	assert.True(t, IsErrorPanicked(err))
+	assert.Equal(t, "recovered from panic - boom", err.Error())
+	assert.Contains(t, err.(ErrorPanicked).Stack, "TestRecoverPanic")
+}
+
+func TestWatchWithHeartbeatRecoversPanic(t *testing.T) {
+	watch := func(string, string, UpdateDescriptionFn) error {
+		panic("watch panicked")
+	}
+	err := watchWithHeartbeat(watch, nil, HeartbeatPolicy{StaleTimeout: time.Minute}, WatchTimeout{}, nil, "pod", "ns", func(string, string) {})
+	assert.True(t, IsErrorPanicked(err))
+
+	err = newLifecycle().watch("pod", func() error { panic("watch panicked") })
+	assert.True(t, IsErrorPanicked(err))
+}
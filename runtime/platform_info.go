@@ -0,0 +1,126 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	log "github.com/automationbroker/bundle-lib/logging"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/rest"
+)
+
+// The distributions told apart by PlatformInfo, the others are reported
+// as kubernetes.
+const (
+	DistributionKubernetes = "kubernetes"
+	DistributionOpenShift  = "openshift"
+	DistributionK3s        = "k3s"
+	DistributionRKE2       = "rke2"
+	DistributionEKS        = "eks"
+	DistributionGKE        = "gke"
+)
+
+// clusterVersionPath - the OpenShift 4 cluster version, which holds the
+// version of the distribution rather than the one of kubernetes.
+const clusterVersionPath = "/apis/config.openshift.io/v1/clusterversions/version"
+
+// PlatformInfo - What the bundles run on, in more details than the name of
+// the COE returned by GetRuntime.
+type PlatformInfo struct {
+	// Platform - the name of the COE, as returned by GetRuntime.
+	Platform string `json:"platform"`
+	// Distribution - the kubernetes distribution of the cluster.
+	Distribution string `json:"distribution"`
+	// Version - the kubernetes version of the cluster, e.g. v1.27.4+k3s1.
+	Version string `json:"version,omitempty"`
+	// Major and Minor - the kubernetes version numbers, 0 when unknown.
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	// DistributionVersion - the version of the distribution when it has
+	// its own, e.g. 4.14.3 for OpenShift 4.
+	DistributionVersion string `json:"distribution_version,omitempty"`
+	// Capabilities - what the cluster supports.
+	Capabilities Capabilities `json:"capabilities"`
+}
+
+// PlatformInfo - discovers the distribution, version and capabilities of
+// the cluster.
+func (p provider) PlatformInfo() (PlatformInfo, error) {
+	info := PlatformInfo{Platform: p.GetRuntime()}
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		return info, err
+	}
+	caps, v, err := discoverCapabilities(k8scli.Client.Discovery())
+	if err != nil {
+		return info, err
+	}
+	info.Capabilities = caps
+	info.Version = v.GitVersion
+	info.Major, info.Minor, _ = versionNumbers(v)
+	info.Distribution = distribution(info.Platform, v)
+	if info.Distribution == DistributionOpenShift {
+		info.DistributionVersion = openshiftVersion(k8scli.Client.Discovery().RESTClient())
+	}
+	return info, nil
+}
+
+// distribution - the distribution of the cluster. OpenShift is known from
+// the COE, the others add their name to the kubernetes version.
+func distribution(platform string, v *version.Info) string {
+	switch {
+	case platform == COEOpenShift:
+		return DistributionOpenShift
+	case strings.Contains(v.GitVersion, "+k3s"):
+		return DistributionK3s
+	case strings.Contains(v.GitVersion, "+rke2"):
+		return DistributionRKE2
+	case strings.Contains(v.GitVersion, "-eks-"):
+		return DistributionEKS
+	case strings.Contains(v.GitVersion, "-gke."):
+		return DistributionGKE
+	}
+	return DistributionKubernetes
+}
+
+// openshiftVersion - the desired version of the OpenShift 4 cluster
+// version, empty on OpenShift 3 or when it can not be read.
+func openshiftVersion(c rest.Interface) string {
+	if c == nil {
+		return ""
+	}
+	raw, err := c.Get().AbsPath(clusterVersionPath).Do().Raw()
+	if err != nil {
+		log.Debugf("unable to retrieve the openshift cluster version - %v", err)
+		return ""
+	}
+	cv := struct {
+		Status struct {
+			Desired struct {
+				Version string `json:"version"`
+			} `json:"desired"`
+		} `json:"status"`
+	}{}
+	if err := json.Unmarshal(raw, &cv); err != nil {
+		log.Debugf("unable to decode the openshift cluster version - %v", err)
+		return ""
+	}
+	return cv.Status.Desired.Version
+}
@@ -0,0 +1,86 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDistribution(t *testing.T) {
+	cases := []struct {
+		platform   string
+		gitVersion string
+		expected   string
+	}{
+		{platform: "openshift", gitVersion: "v1.27.6+f67aeb3", expected: DistributionOpenShift},
+		{platform: "kubernetes", gitVersion: "v1.27.4+k3s1", expected: DistributionK3s},
+		{platform: "kubernetes", gitVersion: "v1.26.8+rke2r1", expected: DistributionRKE2},
+		{platform: "kubernetes", gitVersion: "v1.27.4-eks-2d98532", expected: DistributionEKS},
+		{platform: "kubernetes", gitVersion: "v1.27.3-gke.100", expected: DistributionGKE},
+		{platform: "kubernetes", gitVersion: "v1.28.0", expected: DistributionKubernetes},
+	}
+	for _, tc := range cases {
+		actual := distribution(tc.platform, &version.Info{GitVersion: tc.gitVersion})
+		assert.Equal(t, tc.expected, actual, tc.gitVersion)
+	}
+}
+
+func TestPlatformInfo(t *testing.T) {
+	k, err := clients.Kubernetes()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	client := fake.NewSimpleClientset()
+	client.Fake.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "networking.k8s.io/v1",
+			APIResources: []metav1.APIResource{{Name: "networkpolicies"}},
+		},
+	}
+	k.Client = client
+
+	info, err := provider{coe: kubernetes{}}.PlatformInfo()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "kubernetes", info.Platform)
+	assert.Equal(t, DistributionKubernetes, info.Distribution)
+	assert.True(t, info.Capabilities.NetworkPolicies)
+	assert.Equal(t, info.Capabilities.Version, info.Version)
+}
+
+func TestFakeRuntimePlatformInfo(t *testing.T) {
+	f := NewFakeRuntime()
+	f.SetCapabilities(Capabilities{Version: "v1.27.6", Routes: true})
+	info, err := f.PlatformInfo()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, DistributionOpenShift, info.Distribution)
+	assert.Equal(t, "v1.27.6", info.Version)
+	assert.True(t, info.Capabilities.Routes)
+
+	f.SetRuntime("kubernetes")
+	info, _ = f.PlatformInfo()
+	assert.Equal(t, DistributionKubernetes, info.Distribution)
+}
@@ -0,0 +1,206 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apicorev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PodSecurityEnforceLabel - the namespace label holding the pod security
+// admission level that is enforced in the namespace.
+const PodSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+
+// podSecurityLevels - the pod security admission levels, from the least to
+// the most restrictive.
+var podSecurityLevels = map[string]int{
+	"privileged": 0,
+	"baseline":   1,
+	"restricted": 2,
+}
+
+// IsPodSecurityLevel - true if the level is a pod security admission level.
+func IsPodSecurityLevel(level string) bool {
+	_, ok := podSecurityLevels[level]
+	return ok
+}
+
+// PreflightPolicy - The checks that are run before the sandbox of an action
+// is created, so the action fails before anything is created instead of
+// while the bundle is running. Disabled by default.
+type PreflightPolicy struct {
+	// Enabled - run the pre-flight checks. The target namespaces are
+	// checked whether or not it is set.
+	Enabled bool
+	// PodSecurityLevel - the pod security admission level the bundle pods
+	// need, privileged, baseline or restricted. When set a bundle pod
+	// running in a target namespace that enforces a more restrictive level
+	// fails the check.
+	PodSecurityLevel string
+}
+
+// PreflightCheck - A check run before the sandbox is created.
+type PreflightCheck string
+
+const (
+	// PreflightPermissions - the broker can create the sandbox namespace
+	// and the role bindings in the target namespaces.
+	PreflightPermissions PreflightCheck = "Permissions"
+	// PreflightQuota - the resource quotas of the namespace the bundle pod
+	// runs in have room for another pod.
+	PreflightQuota PreflightCheck = "Quota"
+	// PreflightPodSecurity - the namespace the bundle pod runs in allows
+	// the pod security level of the bundle pods.
+	PreflightPodSecurity PreflightCheck = "PodSecurity"
+)
+
+// ErrorPreflightFailed - A pre-flight check of the sandbox failed.
+type ErrorPreflightFailed struct {
+	Check     PreflightCheck
+	Namespace string
+	Reason    string
+}
+
+func (e ErrorPreflightFailed) Error() string {
+	return fmt.Sprintf("pre-flight check %v failed for namespace %v: %v", e.Check, e.Namespace, e.Reason)
+}
+
+// ErrorCode - missing permissions are of the Unauthorized class, the other
+// checks of the Conflict class.
+func (e ErrorPreflightFailed) ErrorCode() liberrors.Code {
+	if e.Check == PreflightPermissions {
+		return liberrors.CodeUnauthorized
+	}
+	return liberrors.CodeConflict
+}
+
+// IsErrorPreflightFailed - true if the error is an ErrorPreflightFailed.
+func IsErrorPreflightFailed(err error) bool {
+	_, ok := err.(ErrorPreflightFailed)
+	return ok
+}
+
+// preflightSandbox - runs the pre-flight checks for a sandbox in namespace
+// with the target namespaces, the first failed check is returned.
+func preflightSandbox(k8scli *clients.KubernetesClient, namespace string, targets []string, policy PreflightPolicy) error {
+	if !policy.Enabled {
+		return nil
+	}
+	inTargets := isNamespaceInTargets(namespace, targets)
+	if !inTargets {
+		if err := checkAccess(k8scli, "", "", "namespaces"); err != nil {
+			return err
+		}
+	}
+	for _, target := range targets {
+		if err := checkAccess(k8scli, target, "rbac.authorization.k8s.io", "rolebindings"); err != nil {
+			return err
+		}
+	}
+	// A new sandbox namespace has no quota or pod security level, only a
+	// target namespace the bundle pod runs in is checked.
+	if !inTargets {
+		return nil
+	}
+	if err := checkPodQuota(k8scli, namespace); err != nil {
+		return err
+	}
+	return checkPodSecurity(k8scli, namespace, policy.PodSecurityLevel)
+}
+
+// checkAccess - verifies the broker can create the resource in the
+// namespace, an empty namespace is for cluster scoped resources.
+func checkAccess(k8scli *clients.KubernetesClient, namespace, group, resource string) error {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "create",
+				Group:     group,
+				Resource:  resource,
+			},
+		},
+	}
+	result, err := k8scli.Client.AuthorizationV1().SelfSubjectAccessReviews().Create(review)
+	if err != nil {
+		log.Errorf("unable to review the access to %v in namespace %v - %v", resource, namespace, err)
+		return err
+	}
+	if !result.Status.Allowed {
+		return ErrorPreflightFailed{
+			Check:     PreflightPermissions,
+			Namespace: namespace,
+			Reason:    fmt.Sprintf("the broker can not create %v", resource),
+		}
+	}
+	return nil
+}
+
+// checkPodQuota - verifies the resource quotas of the namespace allow one
+// more pod.
+func checkPodQuota(k8scli *clients.KubernetesClient, namespace string) error {
+	quotas, err := k8scli.Client.CoreV1().ResourceQuotas(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		log.Errorf("unable to list resource quotas in namespace %v - %v", namespace, err)
+		return err
+	}
+	for _, quota := range quotas.Items {
+		hard, ok := quota.Status.Hard[apicorev1.ResourcePods]
+		if !ok {
+			continue
+		}
+		used := quota.Status.Used[apicorev1.ResourcePods]
+		if used.Cmp(hard) >= 0 {
+			return ErrorPreflightFailed{
+				Check:     PreflightQuota,
+				Namespace: namespace,
+				Reason:    fmt.Sprintf("quota %v allows no more pods", quota.Name),
+			}
+		}
+	}
+	return nil
+}
+
+// checkPodSecurity - verifies the pod security level enforced in the
+// namespace is not more restrictive than the level the bundle pods need.
+func checkPodSecurity(k8scli *clients.KubernetesClient, namespace, level string) error {
+	if level == "" {
+		return nil
+	}
+	ns, err := k8scli.Client.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	enforced, ok := ns.Labels[PodSecurityEnforceLabel]
+	if !ok || !IsPodSecurityLevel(enforced) {
+		return nil
+	}
+	if podSecurityLevels[enforced] > podSecurityLevels[level] {
+		return ErrorPreflightFailed{
+			Check:     PreflightPodSecurity,
+			Namespace: namespace,
+			Reason:    fmt.Sprintf("the namespace enforces %v but bundle pods need %v", enforced, level),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+)
+
+func TestPreflightSandbox(t *testing.T) {
+	restricted := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "restricted",
+		Labels: map[string]string{PodSecurityEnforceLabel: "restricted"},
+	}}
+	full := &v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "pods", Namespace: "full"},
+		Status: v1.ResourceQuotaStatus{
+			Hard: v1.ResourceList{v1.ResourcePods: resource.MustParse("2")},
+			Used: v1.ResourceList{v1.ResourcePods: resource.MustParse("2")},
+		},
+	}
+
+	cases := []struct {
+		name          string
+		namespace     string
+		targets       []string
+		policy        PreflightPolicy
+		denied        string
+		expectedCheck PreflightCheck
+	}{
+		{
+			name:      "disabled",
+			namespace: "sandbox",
+			targets:   []string{"target"},
+			denied:    "rolebindings",
+		},
+		{
+			name:      "all checks pass",
+			namespace: "sandbox",
+			targets:   []string{"target"},
+			policy:    PreflightPolicy{Enabled: true},
+		},
+		{
+			name:          "namespaces can not be created",
+			namespace:     "sandbox",
+			targets:       []string{"target"},
+			policy:        PreflightPolicy{Enabled: true},
+			denied:        "namespaces",
+			expectedCheck: PreflightPermissions,
+		},
+		{
+			name:          "role bindings can not be created",
+			namespace:     "sandbox",
+			targets:       []string{"target"},
+			policy:        PreflightPolicy{Enabled: true},
+			denied:        "rolebindings",
+			expectedCheck: PreflightPermissions,
+		},
+		{
+			name:          "no room for the bundle pod",
+			namespace:     "full",
+			targets:       []string{"full"},
+			policy:        PreflightPolicy{Enabled: true},
+			expectedCheck: PreflightQuota,
+		},
+		{
+			name:      "quota of a target the pod does not run in is ignored",
+			namespace: "sandbox",
+			targets:   []string{"full"},
+			policy:    PreflightPolicy{Enabled: true},
+		},
+		{
+			name:          "namespace too restrictive",
+			namespace:     "restricted",
+			targets:       []string{"restricted"},
+			policy:        PreflightPolicy{Enabled: true, PodSecurityLevel: "baseline"},
+			expectedCheck: PreflightPodSecurity,
+		},
+		{
+			name:      "namespace allows the pod security level",
+			namespace: "restricted",
+			targets:   []string{"restricted"},
+			policy:    PreflightPolicy{Enabled: true, PodSecurityLevel: "restricted"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(restricted, full)
+			client.PrependReactor("create", "selfsubjectaccessreviews", func(action clientgotesting.Action) (bool, k8sruntime.Object, error) {
+				review := action.(clientgotesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+				review.Status.Allowed = review.Spec.ResourceAttributes.Resource != tc.denied
+				return true, review, nil
+			})
+			k8scli := &clients.KubernetesClient{Client: client}
+
+			err := preflightSandbox(k8scli, tc.namespace, tc.targets, tc.policy)
+			if tc.expectedCheck == "" {
+				if err != nil {
+					t.Fatalf("unknown error occured: %v", err)
+				}
+				return
+			}
+			perr, ok := err.(ErrorPreflightFailed)
+			if !ok {
+				t.Fatalf("expected ErrorPreflightFailed, got: %v", err)
+			}
+			if perr.Check != tc.expectedCheck {
+				t.Fatalf("expected check %v to fail, got: %v", tc.expectedCheck, perr.Check)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrorQuotaExceeded - Error indicating the bundle pod does not fit in the
+// resource quota of the namespace.
+type ErrorQuotaExceeded struct {
+	Namespace string
+	Quota     string
+	Resource  v1.ResourceName
+	Requested resource.Quantity
+	Available resource.Quantity
+}
+
+func (e ErrorQuotaExceeded) Error() string {
+	return fmt.Sprintf("bundle pod requires %v %v but only %v is available in quota %v of namespace %v",
+		e.Requested.String(), e.Resource, e.Available.String(), e.Quota, e.Namespace)
+}
+
+// ErrorCode - the error is of the Conflict class.
+func (e ErrorQuotaExceeded) ErrorCode() liberrors.Code {
+	return liberrors.CodeConflict
+}
+
+// IsErrorQuotaExceeded - true if the error is a quota exceeded error
+func IsErrorQuotaExceeded(err error) bool {
+	_, ok := err.(ErrorQuotaExceeded)
+	return ok
+}
+
+// quotaResources - the quota resource names that constrain a container
+// resource.
+var quotaResources = []struct {
+	quota    v1.ResourceName
+	resource v1.ResourceName
+	limits   bool
+}{
+	{v1.ResourceCPU, v1.ResourceCPU, false},
+	{v1.ResourceRequestsCPU, v1.ResourceCPU, false},
+	{v1.ResourceLimitsCPU, v1.ResourceCPU, true},
+	{v1.ResourceMemory, v1.ResourceMemory, false},
+	{v1.ResourceRequestsMemory, v1.ResourceMemory, false},
+	{v1.ResourceLimitsMemory, v1.ResourceMemory, true},
+}
+
+// checkQuota - verifies the resources fit in the remaining resource quota
+// of the namespace before the bundle pod is created.
+func checkQuota(k8scli *clients.KubernetesClient, namespace string, resources v1.ResourceRequirements) error {
+	if len(resources.Requests) == 0 && len(resources.Limits) == 0 {
+		return nil
+	}
+	quotas, err := k8scli.Client.CoreV1().ResourceQuotas(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		log.Errorf("unable to list resource quotas in namespace %v - %v", namespace, err)
+		return err
+	}
+	for _, quota := range quotas.Items {
+		for _, r := range quotaResources {
+			hard, ok := quota.Status.Hard[r.quota]
+			if !ok {
+				continue
+			}
+			list := resources.Requests
+			if r.limits {
+				list = resources.Limits
+			}
+			requested, ok := list[r.resource]
+			if !ok {
+				continue
+			}
+			available := hard.DeepCopy()
+			if used, ok := quota.Status.Used[r.quota]; ok {
+				available.Sub(used)
+			}
+			if requested.Cmp(available) > 0 {
+				return ErrorQuotaExceeded{
+					Namespace: namespace,
+					Quota:     quota.Name,
+					Resource:  r.quota,
+					Requested: requested,
+					Available: available,
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckQuota(t *testing.T) {
+	quota := &v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "target"},
+		Status: v1.ResourceQuotaStatus{
+			Hard: v1.ResourceList{
+				v1.ResourceRequestsCPU:  resource.MustParse("2"),
+				v1.ResourceLimitsMemory: resource.MustParse("1Gi"),
+			},
+			Used: v1.ResourceList{
+				v1.ResourceRequestsCPU:  resource.MustParse("1500m"),
+				v1.ResourceLimitsMemory: resource.MustParse("512Mi"),
+			},
+		},
+	}
+	resources := func(cpu, memory string) v1.ResourceRequirements {
+		list := v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(cpu),
+			v1.ResourceMemory: resource.MustParse(memory),
+		}
+		return v1.ResourceRequirements{Requests: list, Limits: list}
+	}
+
+	cases := []struct {
+		name        string
+		resources   v1.ResourceRequirements
+		expectError bool
+	}{
+		{
+			name: "no resources",
+		},
+		{
+			name:      "fits in quota",
+			resources: resources("500m", "256Mi"),
+		},
+		{
+			name:        "cpu exceeds quota",
+			resources:   resources("1", "256Mi"),
+			expectError: true,
+		},
+		{
+			name:        "memory exceeds quota",
+			resources:   resources("100m", "1Gi"),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			k8scli := &clients.KubernetesClient{Client: fake.NewSimpleClientset(quota)}
+			err := checkQuota(k8scli, "target", tc.resources)
+			if tc.expectError {
+				if !IsErrorQuotaExceeded(err) {
+					t.Fatalf("expected a quota exceeded error got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+		})
+	}
+}
@@ -21,8 +21,9 @@ import (
 	"strings"
 
 	"github.com/automationbroker/bundle-lib/clients"
-	log "github.com/sirupsen/logrus"
+	log "github.com/automationbroker/bundle-lib/logging"
 	"k8s.io/api/core/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -33,36 +34,95 @@ const (
 	httpProxyEnvVar     = "HTTP_PROXY"
 	httpsProxyEnvVar    = "HTTPS_PROXY"
 	noProxyEnvVar       = "NO_PROXY"
+	// NodeOSLabel - the node label holding the operating system of the node.
+	NodeOSLabel = "kubernetes.io/os"
+	// NodeArchLabel - the node label holding the CPU architecture of the node.
+	NodeArchLabel = "kubernetes.io/arch"
+	// WindowsTaintKey - the key of the taint that keeps linux pods off of
+	// windows nodes.
+	WindowsTaintKey = "os"
+	// CopiedSecretLabel - the label of the secrets copied to the namespace
+	// of a bundle pod, the value is the name of the pod. The secrets are
+	// deleted when the sandbox is destroyed.
+	CopiedSecretLabel = "bundle-copied-secret-for"
 )
 
 // ProxyConfig - Contains a desired proxy configuration for the broker and
 // the assets that it spawns
 type ProxyConfig struct {
-	HTTPProxy  string
-	HTTPSProxy string
-	NoProxy    string
+	HTTPProxy  string `json:"http_proxy,omitempty"`
+	HTTPSProxy string `json:"https_proxy,omitempty"`
+	NoProxy    string `json:"no_proxy,omitempty"`
 }
 
 // ExecutionContext - Contains the information necessary to track and clean up
 // an APB run
 type ExecutionContext struct {
-	BundleName string
+	BundleName string `json:"bundle_name"`
 	// In k8s location is the namespace that the pod is running in
-	Location string
+	Location string `json:"location"`
 	// Account/user that the bundle is running as
-	Account     string
-	Targets     []string
-	Secrets     []string
-	ExtraVars   string
-	Image       string
-	Action      string
-	Policy      string
-	ProxyConfig *ProxyConfig
-	Metadata    map[string]string
+	Account     string            `json:"account"`
+	Targets     []string          `json:"targets"`
+	Secrets     []string          `json:"secrets,omitempty"`
+	ExtraVars   string            `json:"extra_vars,omitempty"`
+	Image       string            `json:"image"`
+	Action      string            `json:"action"`
+	Policy      string            `json:"policy,omitempty"`
+	ProxyConfig *ProxyConfig      `json:"proxy_config,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
 	// StateName the name of the configmap that holds the state for the bundle
-	StateName string
+	StateName string `json:"state_name,omitempty"`
 	// StateLocation the location in the pod that the state will be mounted
-	StateLocation string
+	StateLocation string `json:"state_location,omitempty"`
+	// OS the operating system of the bundle image, empty means linux
+	OS string `json:"os,omitempty"`
+	// Architecture the CPU architecture of the bundle image
+	Architecture string `json:"architecture,omitempty"`
+	// InitContainers containers to run before the bundle container
+	InitContainers []v1.Container `json:"init_containers,omitempty"`
+	// Sidecars containers to run next to the bundle container
+	Sidecars []v1.Container `json:"sidecars,omitempty"`
+	// Resources the resources of the bundle container, from the plan
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+	// RestartPolicy the restart policy of the bundle pod, defaults to Never
+	RestartPolicy v1.RestartPolicy `json:"restart_policy,omitempty"`
+	// Annotations the annotations of the bundle pod
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// PriorityClassName the priority class of the bundle pod, empty uses
+	// the cluster default
+	PriorityClassName string `json:"priority_class_name,omitempty"`
+	// DNSPolicy the DNS policy of the bundle pod, empty uses ClusterFirst
+	DNSPolicy v1.DNSPolicy `json:"dns_policy,omitempty"`
+	// DNSConfig the DNS parameters of the bundle pod
+	DNSConfig *v1.PodDNSConfig `json:"dns_config,omitempty"`
+	// HostAliases the entries added to the hosts file of the bundle pod
+	HostAliases []v1.HostAlias `json:"host_aliases,omitempty"`
+	// RuntimeVersion the runtime contract of the bundle, from the spec
+	RuntimeVersion int `json:"runtime_version,omitempty"`
+	// Command the entrypoint of the bundle container, empty uses the one
+	// of the image
+	Command []string `json:"command,omitempty"`
+	// Args the arguments of the bundle container, empty passes the action
+	// and the extra vars
+	Args []string `json:"args,omitempty"`
+	// Env the environment added to the bundle container
+	Env []v1.EnvVar `json:"env,omitempty"`
+	// Volumes the volumes added to the bundle pod
+	Volumes []v1.Volume `json:"volumes,omitempty"`
+	// VolumeMounts the volume mounts added to the bundle container
+	VolumeMounts []v1.VolumeMount `json:"volume_mounts,omitempty"`
+	// Dependencies the images the bundle deploys, from the spec
+	Dependencies []string `json:"dependencies,omitempty"`
+	// AutomountServiceAccountToken whether the default token of the
+	// service account is mounted, nil uses the setting of the account
+	AutomountServiceAccountToken *bool `json:"automount_service_account_token,omitempty"`
+	// WatchTimeout the watch timeout of the action, from the spec. It
+	// overrides the WatchTimeouts of the runtime
+	WatchTimeout WatchTimeout `json:"watch_timeout,omitempty"`
+	// IdempotencyKey the key labelling the objects of the sandbox, see
+	// IdempotencyKey
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
 // RunBundleFunc - method that defines how to run a bundle
@@ -76,42 +136,105 @@ func defaultRunBundle(extContext ExecutionContext) (ExecutionContext, error) {
 	if err != nil {
 		return extContext, err
 	}
-	pullPolicy, err := checkPullPolicy(extContext.Policy)
+	pod, err := bundlePod(extContext)
 	if err != nil {
 		return extContext, err
 	}
+	if err := checkQuota(k8scli, extContext.Location, extContext.Resources); err != nil {
+		return extContext, err
+	}
+
+	log.Infof(fmt.Sprintf("Creating pod %q in the %s namespace", pod.Name, extContext.Location))
+	_, err = k8scli.Client.CoreV1().Pods(extContext.Location).Create(pod)
+
+	return extContext, err
+}
+
+// bundlePod - the pod running the bundle of the execution context.
+func bundlePod(extContext ExecutionContext) (*v1.Pod, error) {
+	pullPolicy, err := checkPullPolicy(extContext.Policy)
+	if err != nil {
+		return nil, err
+	}
 	volumes, volumeMounts := buildVolumeSpecs(extContext.Secrets, extContext.StateName)
+	volumes = append(volumes, extContext.Volumes...)
+	volumeMounts = append(volumeMounts, extContext.VolumeMounts...)
 
 	pod := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   extContext.BundleName,
-			Labels: extContext.Metadata,
+			Name:        extContext.BundleName,
+			Labels:      extContext.Metadata,
+			Annotations: extContext.Annotations,
 		},
 		Spec: v1.PodSpec{
+			InitContainers: extContext.InitContainers,
 			Containers: []v1.Container{
 				{
-					Name:  BundleContainerName,
-					Image: extContext.Image,
-					Args: []string{
-						extContext.Action,
-						"--extra-vars",
-						extContext.ExtraVars,
-					},
+					Name:            BundleContainerName,
+					Image:           extContext.Image,
+					Command:         extContext.Command,
+					Args:            bundleArgs(extContext),
 					Env:             createPodEnv(extContext),
 					ImagePullPolicy: pullPolicy,
 					VolumeMounts:    volumeMounts,
+					Resources:       extContext.Resources,
 				},
 			},
-			RestartPolicy:      v1.RestartPolicyNever,
-			ServiceAccountName: extContext.Account,
-			Volumes:            volumes,
+			RestartPolicy:                restartPolicy(extContext),
+			ServiceAccountName:           extContext.Account,
+			Volumes:                      volumes,
+			NodeSelector:                 nodeSelector(extContext),
+			Tolerations:                  tolerations(extContext),
+			PriorityClassName:            extContext.PriorityClassName,
+			DNSPolicy:                    extContext.DNSPolicy,
+			DNSConfig:                    extContext.DNSConfig,
+			HostAliases:                  extContext.HostAliases,
+			AutomountServiceAccountToken: extContext.AutomountServiceAccountToken,
 		},
 	}
 
-	log.Infof(fmt.Sprintf("Creating pod %q in the %s namespace", pod.Name, extContext.Location))
-	_, err = k8scli.Client.CoreV1().Pods(extContext.Location).Create(pod)
+	pod.Spec.Containers = append(pod.Spec.Containers, extContext.Sidecars...)
+	return pod, nil
+}
 
-	return extContext, err
+// restartPolicy - the restart policy of the bundle pod. The bundle is run
+// once unless it asks for the pod to be restarted on failure.
+func restartPolicy(ec ExecutionContext) v1.RestartPolicy {
+	if ec.RestartPolicy == "" {
+		return v1.RestartPolicyNever
+	}
+	return ec.RestartPolicy
+}
+
+// nodeSelector - selects nodes matching the platform of the bundle image.
+func nodeSelector(ec ExecutionContext) map[string]string {
+	selector := map[string]string{}
+	if ec.OS != "" {
+		selector[NodeOSLabel] = strings.ToLower(ec.OS)
+	}
+	if ec.Architecture != "" {
+		selector[NodeArchLabel] = strings.ToLower(ec.Architecture)
+	}
+	if len(selector) == 0 {
+		return nil
+	}
+	return selector
+}
+
+// tolerations - windows nodes are commonly tainted so only windows pods are
+// scheduled on them, windows bundles need to tolerate the taint.
+func tolerations(ec ExecutionContext) []v1.Toleration {
+	if strings.ToLower(ec.OS) != "windows" {
+		return nil
+	}
+	return []v1.Toleration{
+		{
+			Key:      WindowsTaintKey,
+			Operator: v1.TolerationOpEqual,
+			Value:    "windows",
+			Effect:   v1.TaintEffectNoSchedule,
+		},
+	}
 }
 
 // Verify PullPolicy is acceptable
@@ -194,6 +317,9 @@ func createPodEnv(executionContext ExecutionContext) []v1.EnvVar {
 		},
 	}
 
+	podEnv = append(podEnv, runtimeVersionEnv(executionContext)...)
+	podEnv = append(podEnv, executionContext.Env...)
+
 	if executionContext.StateName != "" {
 		podEnv = append(podEnv, v1.EnvVar{
 			Name:  "BUNDLE_STATE_LOCATION",
@@ -251,7 +377,11 @@ func defaultCopySecretsToNamespace(ec ExecutionContext, cn string, secrets []str
 			return err
 		}
 		oldMeta := secretData.ObjectMeta
-		secretData.ObjectMeta = metav1.ObjectMeta{Name: oldMeta.Name, Namespace: ec.Location, Labels: oldMeta.Labels, Annotations: oldMeta.Annotations}
+		labels := map[string]string{CopiedSecretLabel: ec.BundleName}
+		for k, v := range oldMeta.Labels {
+			labels[k] = v
+		}
+		secretData.ObjectMeta = metav1.ObjectMeta{Name: oldMeta.Name, Namespace: ec.Location, Labels: labels, Annotations: oldMeta.Annotations}
 		_, err = k8scli.Client.CoreV1().Secrets(ec.Location).Create(secretData)
 		if err != nil {
 			return err
@@ -259,3 +389,22 @@ func defaultCopySecretsToNamespace(ec ExecutionContext, cn string, secrets []str
 	}
 	return nil
 }
+
+// deleteCopiedSecrets - deletes the secrets copied to the namespace for the
+// bundle pod.
+func deleteCopiedSecrets(k8scli *clients.KubernetesClient, podName string, namespace string) error {
+	secrets, err := k8scli.Client.CoreV1().Secrets(namespace).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", CopiedSecretLabel, podName),
+	})
+	if err != nil {
+		return err
+	}
+	for _, s := range secrets.Items {
+		log.Debugf("Deleting copied secret %s, namespace %s", s.Name, namespace)
+		err := k8scli.Client.CoreV1().Secrets(namespace).Delete(s.Name, &metav1.DeleteOptions{})
+		if err != nil && !kerror.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
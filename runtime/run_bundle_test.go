@@ -19,6 +19,7 @@ package runtime
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
@@ -467,6 +468,33 @@ func TestDefaultRunBundle(t *testing.T) {
 			}),
 			shouldErr: true,
 		},
+		{
+			name: "run bundle with a priority class",
+			exContext: ExecutionContext{
+				BundleName:        "bundle-test-priority",
+				Account:           "svc-acct-bundle-test",
+				Action:            "provision",
+				Location:          "test-bundle-test",
+				Image:             "new-image",
+				Policy:            "Always",
+				PriorityClassName: "bundle-low",
+			},
+			expectedEX: ExecutionContext{
+				BundleName:        "bundle-test-priority",
+				Account:           "svc-acct-bundle-test",
+				Action:            "provision",
+				Location:          "test-bundle-test",
+				Image:             "new-image",
+				Policy:            "Always",
+				PriorityClassName: "bundle-low",
+			},
+			client: fake.NewSimpleClientset(),
+			validatePod: func(t *testing.T, pod *v1.Pod) {
+				if pod.Spec.PriorityClassName != "bundle-low" {
+					t.Fatalf("expected priority class %s but was %s", "bundle-low", pod.Spec.PriorityClassName)
+				}
+			},
+		},
 	}
 	k, err := clients.Kubernetes()
 	if err != nil {
@@ -536,7 +564,8 @@ func TestDefaultCopySecretsToNamespace(t *testing.T) {
 		{
 			name: "copy secret",
 			ec: ExecutionContext{
-				Location: "test",
+				BundleName: "bundle-test",
+				Location:   "test",
 			},
 			cn:      "cluster-test",
 			secrets: []string{"test-secret"},
@@ -558,7 +587,8 @@ func TestDefaultCopySecretsToNamespace(t *testing.T) {
 					Name:      "test-secret",
 					Namespace: "test",
 					Labels: map[string]string{
-						"label": "value",
+						"label":           "value",
+						CopiedSecretLabel: "bundle-test",
 					},
 					Annotations: map[string]string{
 						"annotation": "value",
@@ -642,3 +672,86 @@ func TestDefaultCopySecretsToNamespace(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteCopiedSecrets(t *testing.T) {
+	secret := func(name, podName string) *v1.Secret {
+		s := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"}}
+		if podName != "" {
+			s.Labels = map[string]string{CopiedSecretLabel: podName}
+		}
+		return s
+	}
+	k8scli := &clients.KubernetesClient{Client: fake.NewSimpleClientset(
+		secret("copied", "bundle-test"),
+		secret("other-pod", "bundle-other"),
+		secret("not-copied", ""),
+	)}
+
+	if err := deleteCopiedSecrets(k8scli, "bundle-test", "test"); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	secrets, err := k8scli.Client.CoreV1().Secrets("test").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	names := []string{}
+	for _, s := range secrets.Items {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"not-copied", "other-pod"}) {
+		t.Fatalf("unexpected secrets left: %v", names)
+	}
+}
+
+func TestNodeSelectorAndTolerations(t *testing.T) {
+	cases := []struct {
+		name                string
+		ec                  ExecutionContext
+		expectedSelector    map[string]string
+		expectedTolerations []v1.Toleration
+	}{
+		{
+			name: "no platform",
+			ec:   ExecutionContext{},
+		},
+		{
+			name:             "linux image",
+			ec:               ExecutionContext{OS: "linux", Architecture: "amd64"},
+			expectedSelector: map[string]string{NodeOSLabel: "linux", NodeArchLabel: "amd64"},
+		},
+		{
+			name:             "windows image",
+			ec:               ExecutionContext{OS: "Windows"},
+			expectedSelector: map[string]string{NodeOSLabel: "windows"},
+			expectedTolerations: []v1.Toleration{
+				{
+					Key:      WindowsTaintKey,
+					Operator: v1.TolerationOpEqual,
+					Value:    "windows",
+					Effect:   v1.TaintEffectNoSchedule,
+				},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if s := nodeSelector(tc.ec); !reflect.DeepEqual(s, tc.expectedSelector) {
+				t.Fatalf("unexpected node selector:\nGot: %v\nExp: %v", s, tc.expectedSelector)
+			}
+			if tol := tolerations(tc.ec); !reflect.DeepEqual(tol, tc.expectedTolerations) {
+				t.Fatalf("unexpected tolerations:\nGot: %v\nExp: %v", tol, tc.expectedTolerations)
+			}
+		})
+	}
+}
+
+func TestRestartPolicy(t *testing.T) {
+	if p := restartPolicy(ExecutionContext{}); p != v1.RestartPolicyNever {
+		t.Fatalf("expected the default restart policy to be Never got %v", p)
+	}
+	ec := ExecutionContext{RestartPolicy: v1.RestartPolicyOnFailure}
+	if p := restartPolicy(ec); p != v1.RestartPolicyOnFailure {
+		t.Fatalf("expected restart policy OnFailure got %v", p)
+	}
+}
@@ -21,9 +21,11 @@ import (
 	"fmt"
 
 	"github.com/automationbroker/bundle-lib/clients"
+	"github.com/automationbroker/bundle-lib/clock"
+	"github.com/automationbroker/bundle-lib/idgen"
 	"github.com/automationbroker/bundle-lib/metrics"
 
-	log "github.com/sirupsen/logrus"
+	log "github.com/automationbroker/bundle-lib/logging"
 	apicorev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	rbac "k8s.io/api/rbac/v1beta1"
@@ -59,12 +61,107 @@ type Configuration struct {
 	StateMountLocation string
 	// StateMasterNamespace the namespace where state created by bundles will be copied to between actions
 	StateMasterNamespace string
+	// Logger - the logger used by bundle-lib. If nil the global logrus
+	// logger is used.
+	Logger log.Logger
+	// InitContainers - containers that are run to completion before the
+	// bundle container starts, e.g. to fetch Ansible collections.
+	InitContainers []apicorev1.Container
+	// Sidecars - containers that are run next to the bundle container, e.g.
+	// a logging agent. The action is complete when the bundle container
+	// terminates, sidecars do not need to exit.
+	Sidecars []apicorev1.Container
+	// RestartPolicy - the restart policy of the bundle pod, Never or
+	// OnFailure. Defaults to Never.
+	RestartPolicy apicorev1.RestartPolicy
+	// MaxRestarts - the restarts of the bundle container allowed with the
+	// OnFailure restart policy. The action fails with ErrorBundleRestarted
+	// once the container restarted more often. Defaults to
+	// DefaultMaxRestarts. Ignored when WatchBundle is set.
+	MaxRestarts int
+	// ImagePullFailureThreshold - the number of image pull errors reported
+	// by a pending bundle pod before the action fails. When 0 the action
+	// fails only once the pod does. Ignored when WatchBundle is set.
+	ImagePullFailureThreshold int
+	// CredentialRetryPolicy - how extracting credentials is retried. When
+	// not set DefaultCredentialRetryPolicy is used.
+	CredentialRetryPolicy CredentialRetryPolicy
+	// InjectClusterInfo - pass the ClusterInfo to bundles in the _cluster
+	// extra var.
+	InjectClusterInfo bool
+	// IngressDomain - the default ingress domain of the cluster, passed to
+	// bundles in the ClusterInfo.
+	IngressDomain string
+	// TargetNamespacePolicy - whether target namespaces that do not exist
+	// are created. By default they are not and the action fails.
+	TargetNamespacePolicy TargetNamespacePolicy
+	// Heartbeat - how the watch of a running bundle is kept alive and
+	// detected as stale. Disabled by default.
+	Heartbeat HeartbeatPolicy
+	// PriorityClassName - the priority class of the bundle pods. Whether
+	// bundle pods preempt other pods, or can be preempted, is set on the
+	// priority class. When empty the cluster default is used.
+	PriorityClassName string
+	// ServiceMesh - whether sidecar injection is disabled for the bundle
+	// pods. By default it is not.
+	ServiceMesh ServiceMeshPolicy
+	// DNSPolicy - the DNS policy of the bundle pods. When empty the
+	// kubernetes default, ClusterFirst, is used.
+	DNSPolicy apicorev1.DNSPolicy
+	// DNSConfig - the DNS parameters of the bundle pods, e.g. extra
+	// nameservers and search domains. Required when DNSPolicy is None.
+	DNSConfig *apicorev1.PodDNSConfig
+	// HostAliases - entries added to the hosts file of the bundle pods,
+	// for hostnames that can not be resolved with the cluster DNS.
+	HostAliases []apicorev1.HostAlias
+	// Preflight - the checks run before the sandbox is created. Disabled
+	// by default.
+	Preflight PreflightPolicy
+	// SandboxStrategy - where the bundle pods run, see NewSandboxStrategy.
+	// When nil every action gets a transient namespace.
+	SandboxStrategy SandboxStrategy
+	// OperatorInstaller - installs the operators of the specs loaded from
+	// OLM catalogs. When nil the OLM API of the cluster is used.
+	OperatorInstaller OperatorInstaller
+	// CacheVolume - a cache mounted read-only into the bundle pods, e.g.
+	// Ansible collections. Disabled by default.
+	CacheVolume CacheVolume
+	// ImageMirrors - the mirrors the images are pulled from on disconnected
+	// clusters. The bundles get the mirrors and their resolved dependencies
+	// in the ImageMirrorsKey and DependenciesKey extra vars.
+	ImageMirrors ImageMirrors
+	// COE - the name of the registered COE the bundles run on, see
+	// RegisterCOE. When empty openshift is used if the cluster answers
+	// the OpenShift version probe, kubernetes otherwise.
+	COE string
+	// SandboxToken - whether the bundle pods get a projected token of the
+	// sandbox service account bound to the run, instead of the default
+	// automounted token. Disabled by default, NewRuntime fails when it is
+	// invalid.
+	SandboxToken SandboxTokenPolicy
+	// Debug - the debug container attached to bundle pods by DebugBundle.
+	Debug DebugPolicy
+	// WatchTimeouts - how long the watch of a bundle waits for each
+	// action, overridden by the watchTimeouts metadata of the spec. By
+	// default only the Heartbeat applies.
+	WatchTimeouts WatchTimeouts
+	// Clock - the source of the time used by the watches and the operator
+	// installs. Defaults to clock.Real.
+	Clock clock.Clock
+	// IDs - the source of the names of the debug containers. Defaults to
+	// idgen.Random.
+	IDs idgen.Generator
 }
 
 // Runtime - Abstraction for broker actions
 type Runtime interface {
 	ValidateRuntime() error
 	GetRuntime() string
+	// Capabilities - what the connected cluster supports.
+	Capabilities() (Capabilities, error)
+	// PlatformInfo - the distribution, version and capabilities of the
+	// connected cluster.
+	PlatformInfo() (PlatformInfo, error)
 	CreateSandbox(string, string, []string, string, map[string]string) (string, string, error)
 	DestroySandbox(string, string, []string, string, bool, bool)
 	ExtractCredentials(string, string, int) ([]byte, error)
@@ -73,11 +170,25 @@ type Runtime interface {
 	RunBundle(ExecutionContext) (ExecutionContext, error)
 	CopySecretsToNamespace(ExecutionContext, string, []string) error
 	StateManager
+	// RecoverExecutions - returns the bundles that were running when the
+	// process stopped.
+	RecoverExecutions() ([]ExecutionContext, error)
+	// InstallOperator and UninstallOperator - manage the operators of the
+	// specs loaded from OLM catalogs, which are not run as bundles.
+	InstallOperator(OperatorSubscription) error
+	UninstallOperator(OperatorSubscription) error
+	// ScheduleAction, UnscheduleAction and ScheduledActions - manage the
+	// cron jobs running actions of instances on a schedule.
+	ScheduleAction(ExecutionContext, ScheduledAction) error
+	UnscheduleAction(ScheduledAction) error
+	ScheduledActions(instanceID string) ([]ScheduledAction, error)
+	// DebugBundle - attaches a debug container to a running bundle pod.
+	DebugBundle(podName string, namespace string) (DebugSession, error)
 }
 
 // Variables for interacting with runtimes
 type provider struct {
-	coe
+	coe COE
 	ExtractedCredential
 	postSandboxCreate      []PostSandboxCreate
 	preSandboxCreate       []PreSandboxCreate
@@ -87,12 +198,31 @@ type provider struct {
 	runBundle              RunBundleFunc
 	copySecretsToNamespace CopySecretsToNamespaceFunc
 	state
-}
-
-// Abstraction for actions that are different between runtimes
-type coe interface {
-	getRuntime() string
-	shouldJoinNetworks() (bool, PostSandboxCreate, PostSandboxDestroy)
+	initContainers []apicorev1.Container
+	sidecars       []apicorev1.Container
+	restartPolicy  apicorev1.RestartPolicy
+
+	priorityClassName string
+	serviceMesh       ServiceMeshPolicy
+	dnsPolicy         apicorev1.DNSPolicy
+	dnsConfig         *apicorev1.PodDNSConfig
+	hostAliases       []apicorev1.HostAlias
+	preflight         PreflightPolicy
+	sandboxStrategy   SandboxStrategy
+	operatorInstaller OperatorInstaller
+	cacheVolume       CacheVolume
+	imageMirrors      ImageMirrors
+	sandboxToken      SandboxTokenPolicy
+	debug             DebugPolicy
+
+	credentialRetryPolicy CredentialRetryPolicy
+	injectClusterInfo     bool
+	ingressDomain         string
+	targetNamespacePolicy TargetNamespacePolicy
+	heartbeat             HeartbeatPolicy
+	watchTimeouts         WatchTimeouts
+	clock                 clock.Clock
+	ids                   idgen.Generator
 }
 
 // NewRuntime - Initialize provider variable
@@ -100,31 +230,32 @@ type coe interface {
 // be used to do CRUD operations. If you want to use the default pass nil
 // and we will use the built-in default of saving them as secrets in the
 // broker namespace.
-func NewRuntime(config Configuration) {
+// An error is returned, and the Provider is left unchanged, when the
+// cluster can not be reached or identified, or the SandboxToken policy is
+// invalid.
+func NewRuntime(config Configuration) error {
+	if config.Logger != nil {
+		log.SetLogger(config.Logger)
+	}
 	k8scli, err := clients.Kubernetes()
 	if err != nil {
 		log.Error(err.Error())
-		panic(err.Error())
+		return err
 	}
 	// Identify which cluster we're using
-	restclient := k8scli.Client.CoreV1().RESTClient()
-	body, err := restclient.Get().AbsPath("/version/openshift").Do().Raw()
-	var cluster coe
-	switch {
-	case err == nil:
-		var kubeServerInfo kubeversiontypes.Info
-		err = json.Unmarshal(body, &kubeServerInfo)
-		if err != nil && len(body) > 0 {
+	var cluster COE
+	if config.COE != "" {
+		cluster, err = newCOE(config.COE)
+		if err != nil {
 			log.Error(err.Error())
-			panic(err.Error())
+			return err
+		}
+		log.Infof("Using the %v COE", config.COE)
+	} else {
+		cluster, err = detectCOE(k8scli)
+		if err != nil {
+			return err
 		}
-		log.Infof("OpenShift version: %v", kubeServerInfo)
-		cluster = newOpenshift()
-	case kapierrors.IsNotFound(err) || kapierrors.IsUnauthorized(err) || kapierrors.IsForbidden(err):
-		cluster = newKubernetes()
-	default:
-		log.Error(err.Error())
-		panic(err.Error())
 	}
 
 	var c ExtractedCredential
@@ -141,12 +272,19 @@ func NewRuntime(config Configuration) {
 		config.StateMountLocation = defaultMountLocation
 	}
 
-	defaultStateManager := state{mountLocation: config.StateMountLocation, nsTarget: config.StateMasterNamespace}
+	defaultStateManager := state{mountLocation: config.StateMountLocation, nsTarget: config.StateMasterNamespace, clock: config.Clock}
 	var w WatchRunningBundleFunc
 	if config.WatchBundle != nil {
 		w = config.WatchBundle
 	} else {
-		w = defaultWatchRunningBundle
+		maxRestarts := config.MaxRestarts
+		if maxRestarts <= 0 {
+			if maxRestarts < 0 {
+				log.Warningf("invalid bundle container max restarts %v, using %v", maxRestarts, DefaultMaxRestarts)
+			}
+			maxRestarts = DefaultMaxRestarts
+		}
+		w = newWatchRunningBundle(config.ImagePullFailureThreshold, maxRestarts)
 	}
 	var r RunBundleFunc
 	if config.RunBundle != nil {
@@ -173,6 +311,63 @@ func NewRuntime(config Configuration) {
 		p.preSandboxCreate = config.PreCreateSandboxHooks
 	}
 
+	p.initContainers = config.InitContainers
+	p.sidecars = config.Sidecars
+	p.credentialRetryPolicy = config.CredentialRetryPolicy
+	p.injectClusterInfo = config.InjectClusterInfo
+	p.ingressDomain = config.IngressDomain
+	p.targetNamespacePolicy = config.TargetNamespacePolicy
+	p.heartbeat = config.Heartbeat
+	p.priorityClassName = config.PriorityClassName
+	p.serviceMesh = config.ServiceMesh
+	p.dnsPolicy = config.DNSPolicy
+	p.dnsConfig = config.DNSConfig
+	p.hostAliases = config.HostAliases
+	p.preflight = config.Preflight
+	p.debug = config.Debug
+	p.clock = clock.OrReal(config.Clock)
+	p.ids = idgen.OrRandom(config.IDs)
+	p.sandboxStrategy = config.SandboxStrategy
+	if p.sandboxStrategy == nil {
+		p.sandboxStrategy = transientNamespace{}
+	}
+	p.operatorInstaller = config.OperatorInstaller
+	if p.operatorInstaller == nil {
+		p.operatorInstaller = olmInstaller{clock: p.clock}
+	}
+	if err := config.CacheVolume.Validate(); err != nil {
+		log.Warningf("ignoring the cache volume - %v", err)
+	} else {
+		p.cacheVolume = config.CacheVolume
+	}
+	if err := config.ImageMirrors.Validate(); err != nil {
+		log.Warningf("ignoring the image mirrors - %v", err)
+	} else {
+		p.imageMirrors = config.ImageMirrors
+	}
+	if err := config.WatchTimeouts.Validate(); err != nil {
+		log.Warningf("ignoring the watch timeouts - %v", err)
+	} else {
+		p.watchTimeouts = config.WatchTimeouts
+	}
+	// Falling back to the automounted token would widen the access of the
+	// bundles, an invalid sandbox token policy is not ignored.
+	if err := config.SandboxToken.Validate(); err != nil {
+		log.Errorf("invalid sandbox token policy - %v", err)
+		return err
+	}
+	p.sandboxToken = config.SandboxToken
+	if p.credentialRetryPolicy == (CredentialRetryPolicy{}) {
+		p.credentialRetryPolicy = DefaultCredentialRetryPolicy
+	}
+	switch config.RestartPolicy {
+	case "", apicorev1.RestartPolicyNever, apicorev1.RestartPolicyOnFailure:
+		p.restartPolicy = config.RestartPolicy
+	default:
+		log.Warningf("unsupported bundle pod restart policy %v, using %v",
+			config.RestartPolicy, apicorev1.RestartPolicyNever)
+	}
+
 	if len(config.PostCreateSandboxHooks) > 0 {
 		p.postSandboxCreate = config.PostCreateSandboxHooks
 	}
@@ -185,7 +380,7 @@ func NewRuntime(config Configuration) {
 		p.postSandboxDestroy = config.PostDestroySandboxHooks
 	}
 
-	if ok, postCreateHook, postDestroyHook := cluster.shouldJoinNetworks(); ok {
+	if ok, postCreateHook, postDestroyHook := cluster.ShouldJoinNetworks(); ok {
 		log.Debugf("adding posthook to provider now.")
 		if postCreateHook != nil {
 			p.addPostCreateSandbox(postCreateHook)
@@ -195,14 +390,53 @@ func NewRuntime(config Configuration) {
 		}
 	}
 	Provider = p
+	return nil
+}
 
+// ErrorClusterProbe - The cluster did not answer the probe identifying
+// it, e.g. because it is unreachable.
+type ErrorClusterProbe struct {
+	Err error
+}
+
+func (e ErrorClusterProbe) Error() string {
+	return fmt.Sprintf("unable to identify the cluster - %v", e.Err)
+}
+
+// IsErrorClusterProbe - true if the error is an ErrorClusterProbe.
+func IsErrorClusterProbe(err error) bool {
+	_, ok := err.(ErrorClusterProbe)
+	return ok
+}
+
+// detectCOE - openshift if the cluster answers the OpenShift version
+// probe, kubernetes otherwise.
+func detectCOE(k8scli *clients.KubernetesClient) (COE, error) {
+	restclient := k8scli.Client.CoreV1().RESTClient()
+	body, err := restclient.Get().AbsPath("/version/openshift").Do().Raw()
+	switch {
+	case err == nil:
+		var kubeServerInfo kubeversiontypes.Info
+		err = json.Unmarshal(body, &kubeServerInfo)
+		if err != nil && len(body) > 0 {
+			log.Error(err.Error())
+			return nil, ErrorClusterProbe{Err: err}
+		}
+		log.Infof("OpenShift version: %v", kubeServerInfo)
+		return newOpenshift(), nil
+	case kapierrors.IsNotFound(err) || kapierrors.IsUnauthorized(err) || kapierrors.IsForbidden(err):
+		return newKubernetes(), nil
+	default:
+		log.Error(err.Error())
+		return nil, ErrorClusterProbe{Err: err}
+	}
 }
 
-func newOpenshift() coe {
+func newOpenshift() COE {
 	return openshift{}
 }
 
-func newKubernetes() coe {
+func newKubernetes() COE {
 	return kubernetes{}
 }
 
@@ -249,31 +483,47 @@ func (p provider) CreateSandbox(podName string,
 	apbRole string,
 	metadata map[string]string,
 ) (string, string, error) {
+	if r, ok := p.coe.(SandboxRoleCOE); ok {
+		apbRole = r.SandboxRole(apbRole)
+	}
 	k8scli, err := clients.Kubernetes()
 	if err != nil {
 		return "", "", err
 	}
-	err = validateTargets(targets)
+	err = p.ensureTargets(targets)
+	if IsErrorTargetNamespaceNotFound(err) {
+		return "", "", err
+	}
 	if err != nil {
 		return "", "", fmt.Errorf("unable to get target namespaces: %v", err)
 	}
+	if err := preflightSandbox(k8scli, namespace, targets, p.preflight); err != nil {
+		log.Errorf("pre-flight checks of the sandbox failed - %v", err)
+		return "", "", err
+	}
 
-	// If Location is in the targets then we should not create the namespace.
-	if !isNamespaceInTargets(namespace, targets) {
-		// Create namespace.
-		ns := &apicorev1.Namespace{
-			ObjectMeta: metav1.ObjectMeta{
-				Labels:       metadata,
-				GenerateName: namespace,
-			},
-		}
-		ns, err = k8scli.Client.CoreV1().Namespaces().Create(ns)
-		if err != nil {
-			return "", "", err
-		}
-		// Sandbox (i.e Namespace) was created.
-		namespace = ns.ObjectMeta.Name
+	// Every object of the sandbox carries the idempotency key, so a retried
+	// attempt adopts what an earlier one created and the sandbox is
+	// destroyed by its label.
+	key := sandboxKey(podName, metadata)
+	labels := map[string]string{}
+	for k, v := range metadata {
+		labels[k] = v
+	}
+	for k, v := range idempotencyLabels(key) {
+		labels[k] = v
+	}
+	metadata = labels
 
+	namespace, err = p.sandboxStrategy.Prepare(k8scli, namespace, targets, metadata)
+	if err != nil {
+		log.Errorf("unable to prepare the %s sandbox namespace - %v", p.sandboxStrategy.Name(), err)
+		return "", "", err
+	}
+
+	// When the bundle runs outside of the targets it needs network access
+	// to them.
+	if !isNamespaceInTargets(namespace, targets) {
 		// Check to see if there are already namespaces available before
 		// creating ours
 		policies, err := k8scli.Client.NetworkingV1().NetworkPolicies(targets[0]).List(metav1.ListOptions{})
@@ -304,7 +554,7 @@ func (p provider) CreateSandbox(podName string,
 			}
 
 			log.Debugf("Creating network policy for pod: %v to grant network access to ns: %v", podName, targets[0])
-			_, err = k8scli.Client.NetworkingV1().NetworkPolicies(targets[0]).Create(networkPolicy)
+			err = createNetworkPolicy(k8scli, key, networkPolicy, targets[0])
 			if err != nil {
 				log.Errorf("unable to create network policy object - %v", err)
 				return "", "", err
@@ -325,7 +575,7 @@ func (p provider) CreateSandbox(podName string,
 		}
 	}
 
-	err = k8scli.CreateServiceAccount(podName, namespace)
+	err = createServiceAccount(k8scli, podName, key, namespace)
 	if err != nil {
 		return "", "", err
 	}
@@ -347,7 +597,7 @@ func (p provider) CreateSandbox(podName string,
 	}
 
 	// targetNamespace and namespace are the same
-	err = k8scli.CreateRoleBinding(podName, subjects, namespace, namespace, roleRef)
+	err = createRoleBinding(k8scli, podName, key, subjects, namespace, roleRef)
 	if err != nil {
 		return "", "", err
 	}
@@ -355,7 +605,7 @@ func (p provider) CreateSandbox(podName string,
 	for _, target := range targets {
 		// It could be the case that we already added the rolebinding as target and namespace are equal.
 		if target != namespace {
-			err = k8scli.CreateRoleBinding(podName, subjects, namespace, target, roleRef)
+			err = createRoleBinding(k8scli, podName, key, subjects, target, roleRef)
 			if err != nil {
 				return "", "", err
 			}
@@ -365,6 +615,19 @@ func (p provider) CreateSandbox(podName string,
 	log.Infof("Successfully created apb sandbox: [ %s ], with %s permissions in namespace [ %s ]", podName, apbRole, namespace)
 	metrics.SandboxCreated()
 
+	err = p.SaveExecution(ExecutionContext{
+		BundleName:     podName,
+		Location:       namespace,
+		Account:        podName,
+		Targets:        targets,
+		Metadata:       metadata,
+		Action:         metadata["bundle-action"],
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		log.Warningf("unable to persist the execution of sandbox %s - %v", podName, err)
+	}
+
 	log.Debug("Running post create sandbox functions if defined.")
 	for i, f := range p.postSandboxCreate {
 		log.Debugf("Running post create sandbox function: %v", i+1)
@@ -379,24 +642,6 @@ func (p provider) CreateSandbox(podName string,
 	return podName, namespace, nil
 }
 
-func validateTargets(targets []string) error {
-	if len(targets) < 1 {
-		return fmt.Errorf("Must supply at least one target namespace")
-	}
-
-	k8scli, err := clients.Kubernetes()
-	if err != nil {
-		return err
-	}
-	for _, ns := range targets {
-		_, err = k8scli.Client.CoreV1().Namespaces().Get(ns, metav1.GetOptions{})
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 // DestroySandbox - Translate the broker DestorySandbox call into cluster resource calls
 func (p provider) DestroySandbox(podName string,
 	namespace string,
@@ -420,32 +665,38 @@ func (p provider) DestroySandbox(podName string,
 		log.Info("Requested destruction of APB sandbox with empty handle, skipping.")
 		return
 	}
+	if !bundles.release(podName) {
+		log.Infof("Keeping APB sandbox %s running for recovery after the shutdown.", podName)
+		return
+	}
+	defer func() {
+		if err := p.DeleteExecution(podName); err != nil {
+			log.Warningf("unable to delete the persisted execution of sandbox %s - %v", podName, err)
+		}
+	}()
 	k8scli, err := clients.Kubernetes()
 	if err != nil {
 		log.Error("Something went wrong getting kubernetes client")
 		log.Errorf("%s", err.Error())
 		return
 	}
+	key := destroyedSandboxKey(k8scli, podName, namespace)
 	pod, err := k8scli.Client.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
 	if err != nil {
 		log.Errorf("Unable to retrieve pod - %v", err)
 	}
-	if shouldDeleteNamespace(keepNamespace, keepNamespaceOnError, pod, err) {
-		if configNamespace != namespace {
-			log.Debugf("Deleting namespace %s", namespace)
-			k8scli.Client.CoreV1().Namespaces().Delete(namespace, &metav1.DeleteOptions{})
-			// This is keeping track of namespaces.
-		} else {
-			// We should not be attempting to run pods in the ASB namespace, if we are, something is seriously wrong.
-			panic(fmt.Errorf("Broker is attempting to delete its own namespace"))
+	deleteNamespace := shouldDeleteNamespace(keepNamespace, keepNamespaceOnError, pod, err)
+	deleted := p.sandboxStrategy.Release(k8scli, podName, namespace, configNamespace, deleteNamespace)
+	// The copied secrets are kept with the namespace when it is kept because
+	// the action failed, otherwise they are deleted.
+	if !deleted && shouldDeleteNamespace(false, keepNamespaceOnError, pod, err) {
+		if err := deleteCopiedSecrets(k8scli, podName, namespace); err != nil {
+			log.Errorf("unable to delete the secrets copied to namespace %s - %v", namespace, err)
 		}
-
-	} else {
-		log.Debugf("Keeping namespace alive due to configuration")
 	}
 	log.Debugf("Deleting rolebinding %s, namespace %s", podName, namespace)
 
-	err = k8scli.DeleteRoleBinding(podName, namespace)
+	err = deleteRoleBindings(k8scli, podName, key, namespace)
 	if err != nil {
 		log.Errorf("Something went wrong trying to destroy the rolebinding! - %v", err)
 		return
@@ -454,7 +705,7 @@ func (p provider) DestroySandbox(podName string,
 
 	for _, target := range targets {
 		log.Debugf("Deleting rolebinding %s, namespace %s", podName, target)
-		err = k8scli.DeleteRoleBinding(podName, target)
+		err = deleteRoleBindings(k8scli, podName, key, target)
 		if err != nil {
 			log.Error("Something went wrong trying to destroy the rolebinding!")
 			return
@@ -463,24 +714,14 @@ func (p provider) DestroySandbox(podName string,
 	}
 
 	if !isNamespaceInTargets(namespace, targets) {
-		policies, err := k8scli.Client.NetworkingV1().NetworkPolicies(targets[0]).List(metav1.ListOptions{})
+		// Must clean up the network policy that allowed communication from
+		// the APB pod to the target namespace, if one was created.
+		log.Debugf("Deleting network policy for pod: %v to grant network access to ns: %v", podName, targets[0])
+		err = deleteNetworkPolicies(k8scli, podName, key, targets[0])
 		if err != nil {
-			log.Errorf("Something went wrong trying to determine if we have network policies! - %v", err)
+			log.Errorf("unable to delete the network policy object - %v", err)
 			return
 		}
-
-		// If there are already network policies, we need to clean up the ones we
-		// created to allow communication from the APB pod to the target namespace.
-		if len(policies.Items) > 0 {
-			log.Debugf("Deleting network policy for pod: %v to grant network access to ns: %v", podName, targets[0])
-			// Must clean up the network policy that allowed communication from the APB pod to the target namespace.
-			err = k8scli.Client.NetworkingV1().NetworkPolicies(targets[0]).Delete(podName, &metav1.DeleteOptions{})
-			if err != nil {
-				log.Errorf("unable to delete the network policy object - %v", err)
-				return
-			}
-			log.Debugf("Successfully deleted network policy for pod: %v to grant network access to ns: %v", podName, targets[0])
-		}
 	}
 
 	metrics.SandboxDeleted()
@@ -500,11 +741,16 @@ func (p provider) DestroySandbox(podName string,
 
 // GetRuntime - Return a string value of the runtime
 func (p provider) GetRuntime() string {
-	return p.coe.getRuntime()
+	return p.coe.GetRuntime()
 }
 
 func (p provider) WatchRunningBundle(podName string, namespace string, updateFunc UpdateDescriptionFn) error {
-	return p.watchBundle(podName, namespace, updateFunc)
+	// Bundles that were not run by the runtime get the default timeouts.
+	ec, _ := bundles.execution(podName)
+	timeout := p.watchTimeouts.resolve(ec)
+	return bundles.watch(podName, func() error {
+		return watchWithHeartbeat(p.watchBundle, getBundlePod, p.heartbeat, timeout, p.clock, podName, namespace, updateFunc)
+	})
 }
 
 func (p provider) CopySecretsToNamespace(ec ExecutionContext, cn string, secrets []string) error {
@@ -512,7 +758,59 @@ func (p provider) CopySecretsToNamespace(ec ExecutionContext, cn string, secrets
 }
 
 func (p provider) RunBundle(ec ExecutionContext) (ExecutionContext, error) {
-	return p.runBundle(ec)
+	if bundles.isClosed() {
+		return ec, ErrorShuttingDown{}
+	}
+	ec, err := p.podPolicy(ec)
+	if err != nil {
+		return ec, err
+	}
+	ec = p.sandboxToken.apply(ec)
+	ec, err = p.runBundle(ec)
+	if err != nil {
+		return ec, err
+	}
+	if err := p.SaveExecution(ec); err != nil {
+		log.Warningf("unable to persist the execution of bundle %s - %v", ec.BundleName, err)
+	}
+	bundles.track(ec, p.SaveExecution)
+	return ec, nil
+}
+
+// podPolicy - applies the configuration of the bundle pods to the
+// execution context.
+func (p provider) podPolicy(ec ExecutionContext) (ExecutionContext, error) {
+	// The configured containers come first, containers already on the
+	// execution context are kept.
+	if len(p.initContainers) > 0 {
+		ec.InitContainers = append(append([]apicorev1.Container{}, p.initContainers...), ec.InitContainers...)
+	}
+	if len(p.sidecars) > 0 {
+		ec.Sidecars = append(append([]apicorev1.Container{}, p.sidecars...), ec.Sidecars...)
+	}
+	if ec.RestartPolicy == "" {
+		ec.RestartPolicy = p.restartPolicy
+	}
+	if ec.PriorityClassName == "" {
+		ec.PriorityClassName = p.priorityClassName
+	}
+	ec.Annotations = p.serviceMesh.podAnnotations(ec.Annotations)
+	if ec.DNSPolicy == "" && ec.DNSConfig == nil {
+		ec.DNSPolicy = p.dnsPolicy
+		ec.DNSConfig = p.dnsConfig
+	}
+	if len(p.hostAliases) > 0 {
+		ec.HostAliases = append(append([]apicorev1.HostAlias{}, p.hostAliases...), ec.HostAliases...)
+	}
+	ec = p.cacheVolume.apply(ec)
+	if p.injectClusterInfo {
+		extraVars, err := addClusterInfo(ec.ExtraVars, p.clusterInfo())
+		if err != nil {
+			return ec, err
+		}
+		ec.ExtraVars = extraVars
+	}
+	return applyImageMirrors(ec, p.imageMirrors)
 }
 
 func shouldDeleteNamespace(keepNamespace bool,
@@ -248,7 +248,7 @@ func TestNewRuntime(t *testing.T) {
 		client           *fake.Clientset
 		response         *http.Response
 		expectedProvider *provider
-		shouldPanic      bool
+		shouldErr        bool
 	}{
 		{
 			name:   "New Default Openshift Runtime",
@@ -319,14 +319,14 @@ func TestNewRuntime(t *testing.T) {
 			},
 		},
 		{
-			name:   "Panic on finding cluster error",
+			name:   "Error on finding cluster error",
 			config: Configuration{},
 			client: fake.NewSimpleClientset(),
 			response: &http.Response{
 				StatusCode: http.StatusInternalServerError,
 				Body:       ioutil.NopCloser(bytes.NewReader([]byte(""))),
 			},
-			shouldPanic: true,
+			shouldErr: true,
 			expectedProvider: &provider{
 				state:                  stateManager,
 				coe:                    newKubernetes(),
@@ -336,6 +336,26 @@ func TestNewRuntime(t *testing.T) {
 				copySecretsToNamespace: defaultCopySecretsToNamespace,
 			},
 		},
+		{
+			name: "Error on invalid sandbox token policy",
+			config: Configuration{
+				SandboxToken: SandboxTokenPolicy{Enabled: true, MountPath: "token"},
+			},
+			client: fake.NewSimpleClientset(),
+			response: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"major":"3", "minor": "2"}`))),
+			},
+			shouldErr: true,
+			expectedProvider: &provider{
+				state:                  stateManager,
+				coe:                    newOpenshift(),
+				ExtractedCredential:    defaultExtractedCredential{},
+				watchBundle:            defaultWatchRunningBundle,
+				runBundle:              defaultRunBundle,
+				copySecretsToNamespace: defaultCopySecretsToNamespace,
+			},
+		},
 		{
 			name: "New Default Openshift Runtime with mock extracted credentials",
 			config: Configuration{
@@ -452,11 +472,6 @@ func TestNewRuntime(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			defer func() {
-				if r := recover(); r != nil && !tc.shouldPanic {
-					t.Fatalf("test panic unexpectedly: %#+v", r)
-				}
-			}()
 			k.Client = &fakeClientSet{
 				tc.client,
 				&fakerest.RESTClient{
@@ -464,7 +479,16 @@ func TestNewRuntime(t *testing.T) {
 					NegotiatedSerializer: scheme.Codecs,
 				},
 			}
-			NewRuntime(tc.config)
+			err := NewRuntime(tc.config)
+			if tc.shouldErr {
+				if !IsErrorClusterProbe(err) {
+					t.Fatalf("expected a cluster probe error, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
 			p := Provider.(*provider)
 			if p.watchBundle == nil {
 				t.Fatalf("expected a watchBundle function to be defined but it was nil ")
@@ -491,3 +515,82 @@ func TestNewRuntime(t *testing.T) {
 		})
 	}
 }
+
+func TestRunBundleContainers(t *testing.T) {
+	var actual ExecutionContext
+	p := provider{
+		runBundle: func(ec ExecutionContext) (ExecutionContext, error) {
+			actual = ec
+			return ec, nil
+		},
+		initContainers: []apicorev1.Container{{Name: "collections"}},
+		sidecars:       []apicorev1.Container{{Name: "logging"}},
+	}
+	_, err := p.RunBundle(ExecutionContext{Sidecars: []apicorev1.Container{{Name: "vault"}}})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if !reflect.DeepEqual(actual.InitContainers, []apicorev1.Container{{Name: "collections"}}) {
+		t.Fatalf("unexpected init containers: %v", actual.InitContainers)
+	}
+	if !reflect.DeepEqual(actual.Sidecars, []apicorev1.Container{{Name: "logging"}, {Name: "vault"}}) {
+		t.Fatalf("unexpected sidecars: %v", actual.Sidecars)
+	}
+}
+
+func TestRunBundleDNS(t *testing.T) {
+	var actual ExecutionContext
+	p := provider{
+		runBundle: func(ec ExecutionContext) (ExecutionContext, error) {
+			actual = ec
+			return ec, nil
+		},
+		dnsPolicy:   apicorev1.DNSNone,
+		dnsConfig:   &apicorev1.PodDNSConfig{Nameservers: []string{"10.0.0.10"}},
+		hostAliases: []apicorev1.HostAlias{{IP: "10.0.0.20", Hostnames: []string{"git.internal"}}},
+	}
+	ec := ExecutionContext{HostAliases: []apicorev1.HostAlias{{IP: "10.0.0.21", Hostnames: []string{"vault.internal"}}}}
+	if _, err := p.RunBundle(ec); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if actual.DNSPolicy != apicorev1.DNSNone || !reflect.DeepEqual(actual.DNSConfig, p.dnsConfig) {
+		t.Fatalf("expected the configured dns settings got: %v %v", actual.DNSPolicy, actual.DNSConfig)
+	}
+	expectedAliases := []apicorev1.HostAlias{
+		{IP: "10.0.0.20", Hostnames: []string{"git.internal"}},
+		{IP: "10.0.0.21", Hostnames: []string{"vault.internal"}},
+	}
+	if !reflect.DeepEqual(actual.HostAliases, expectedAliases) {
+		t.Fatalf("expected host aliases: %v got: %v", expectedAliases, actual.HostAliases)
+	}
+
+	if _, err := p.RunBundle(ExecutionContext{DNSPolicy: apicorev1.DNSDefault}); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if actual.DNSPolicy != apicorev1.DNSDefault || actual.DNSConfig != nil {
+		t.Fatalf("expected the execution context dns settings got: %v %v", actual.DNSPolicy, actual.DNSConfig)
+	}
+}
+
+func TestRunBundlePriorityClassName(t *testing.T) {
+	var actual ExecutionContext
+	p := provider{
+		runBundle: func(ec ExecutionContext) (ExecutionContext, error) {
+			actual = ec
+			return ec, nil
+		},
+		priorityClassName: "bundle-low",
+	}
+	if _, err := p.RunBundle(ExecutionContext{}); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if actual.PriorityClassName != "bundle-low" {
+		t.Fatalf("expected the configured priority class got: %v", actual.PriorityClassName)
+	}
+	if _, err := p.RunBundle(ExecutionContext{PriorityClassName: "bundle-high"}); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if actual.PriorityClassName != "bundle-high" {
+		t.Fatalf("expected the execution context priority class got: %v", actual.PriorityClassName)
+	}
+}
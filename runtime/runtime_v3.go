@@ -0,0 +1,109 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"strconv"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// RuntimeVersion3 - The runtime contract where the bundle is given its
+// action and parameters as explicit arguments, reports its status as json
+// in the BundleStatusAnnotation and its failure as json in the termination
+// message, and returns the bind credentials in a secret like runtime 2.
+const RuntimeVersion3 = 3
+
+// BundleStatusAnnotation - the annotation runtime 3 bundles write their
+// BundleStatus to while they run.
+const BundleStatusAnnotation = "bundle.automationbroker.io/status"
+
+// BundleRuntimeVersionEnvVar - tells the bundle the runtime contract the
+// broker speaks.
+const BundleRuntimeVersionEnvVar = "BUNDLE_RUNTIME_VERSION"
+
+// BundleStatus - The structured status of a runtime 3 bundle.
+type BundleStatus struct {
+	// Description - the description of the last operation.
+	Description string `json:"description,omitempty"`
+	// DashboardURL - the dashboard of the service instance.
+	DashboardURL string `json:"dashboard_url,omitempty"`
+	// Error - why the bundle failed, only in the termination message.
+	Error string `json:"error,omitempty"`
+}
+
+// parseBundleStatus - decodes the status, false when it is not json.
+func parseBundleStatus(s string) (BundleStatus, bool) {
+	status := BundleStatus{}
+	if s == "" || s[0] != '{' {
+		return status, false
+	}
+	if err := json.Unmarshal([]byte(s), &status); err != nil {
+		return status, false
+	}
+	return status, true
+}
+
+// bundleArgs - the arguments of the bundle container. Runtime 3 bundles
+// get the action and the parameters as named arguments.
+func bundleArgs(ec ExecutionContext) []string {
+	if len(ec.Args) > 0 {
+		return ec.Args
+	}
+	if ec.RuntimeVersion >= RuntimeVersion3 {
+		return []string{
+			"--action", ec.Action,
+			"--parameters", ec.ExtraVars,
+		}
+	}
+	return []string{
+		ec.Action,
+		"--extra-vars",
+		ec.ExtraVars,
+	}
+}
+
+// runtimeVersionEnv - the environment telling a runtime 3 bundle the
+// contract, older bundles do not get it.
+func runtimeVersionEnv(ec ExecutionContext) []apiv1.EnvVar {
+	if ec.RuntimeVersion < RuntimeVersion3 {
+		return nil
+	}
+	return []apiv1.EnvVar{{
+		Name:  BundleRuntimeVersionEnvVar,
+		Value: strconv.Itoa(ec.RuntimeVersion),
+	}}
+}
+
+// podLastOperation - the description of the last operation of the bundle,
+// from the structured status of runtime 3 bundles or the
+// apb_last_operation annotation of older ones.
+func podLastOperation(pod *apiv1.Pod) string {
+	if status, ok := parseBundleStatus(pod.Annotations[BundleStatusAnnotation]); ok {
+		return status.Description
+	}
+	return pod.Annotations["apb_last_operation"]
+}
+
+// podDashboardURL - the dashboard url reported by the bundle.
+func podDashboardURL(pod *apiv1.Pod) string {
+	if status, ok := parseBundleStatus(pod.Annotations[BundleStatusAnnotation]); ok && status.DashboardURL != "" {
+		return status.DashboardURL
+	}
+	return pod.Annotations["apb_dashboard_url"]
+}
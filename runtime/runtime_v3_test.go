@@ -0,0 +1,94 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBundleArgs(t *testing.T) {
+	ec := ExecutionContext{Action: "provision", ExtraVars: `{"db":"admin"}`}
+	assert.Equal(t, []string{"provision", "--extra-vars", `{"db":"admin"}`}, bundleArgs(ec))
+	assert.Empty(t, runtimeVersionEnv(ec))
+
+	ec.RuntimeVersion = RuntimeVersion3
+	assert.Equal(t, []string{"--action", "provision", "--parameters", `{"db":"admin"}`}, bundleArgs(ec))
+	assert.Equal(t, []apiv1.EnvVar{{Name: BundleRuntimeVersionEnvVar, Value: "3"}}, runtimeVersionEnv(ec))
+}
+
+func TestPodStatusAnnotations(t *testing.T) {
+	testCases := []struct {
+		name          string
+		annotations   map[string]string
+		lastOperation string
+		dashboardURL  string
+	}{
+		{
+			name: "runtime 2",
+			annotations: map[string]string{
+				"apb_last_operation": "creating the database",
+				"apb_dashboard_url":  "https://db.example.com",
+			},
+			lastOperation: "creating the database",
+			dashboardURL:  "https://db.example.com",
+		},
+		{
+			name: "runtime 3",
+			annotations: map[string]string{
+				BundleStatusAnnotation: `{"description":"creating the database","dashboard_url":"https://db.example.com"}`,
+			},
+			lastOperation: "creating the database",
+			dashboardURL:  "https://db.example.com",
+		},
+		{
+			name: "runtime 3 invalid status",
+			annotations: map[string]string{
+				BundleStatusAnnotation: `{"description":`,
+				"apb_last_operation":   "fallback",
+			},
+			lastOperation: "fallback",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			assert.Equal(t, tc.lastOperation, podLastOperation(pod))
+			assert.Equal(t, tc.dashboardURL, podDashboardURL(pod))
+		})
+	}
+}
+
+func TestTranslateExitStatusRuntime3(t *testing.T) {
+	status := apiv1.PodStatus{
+		ContainerStatuses: []apiv1.ContainerStatus{{
+			Name: BundleContainerName,
+			State: apiv1.ContainerState{
+				Terminated: &apiv1.ContainerStateTerminated{
+					ExitCode: 2,
+					Message:  `{"error":"database quota exceeded"}`,
+				},
+			},
+		}},
+	}
+	err := translateExitStatus("bundle-pod", status)
+	assert.True(t, IsErrorCustomMsg(err))
+	assert.Equal(t, "database quota exceeded", err.Error())
+}
@@ -0,0 +1,209 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	log "github.com/automationbroker/bundle-lib/logging"
+	apicorev1 "k8s.io/api/core/v1"
+	kerror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// SandboxTransientNamespace - every action runs in a new namespace that
+	// is deleted with the sandbox.
+	SandboxTransientNamespace = "transient-namespace"
+	// SandboxSharedRunnerNamespace - the bundle pods of all actions run in
+	// a single namespace.
+	SandboxSharedRunnerNamespace = "shared-runner-namespace"
+	// SandboxInTargetNamespace - the bundle pods run in the first target
+	// namespace of the action.
+	SandboxInTargetNamespace = "in-target-namespace"
+)
+
+// SandboxStrategy - Decides the namespace the bundle pod of an action runs
+// in, and what is left of it once the sandbox is destroyed. The service
+// account, role bindings and network policies of the sandbox are handled by
+// the provider whatever the strategy.
+type SandboxStrategy interface {
+	// Name - the name of the strategy, used in logs.
+	Name() string
+	// Prepare - returns the namespace the bundle pod runs in, creating it
+	// when needed. The namespace is the one requested by the executor,
+	// either a target namespace or the prefix of a namespace to create.
+	Prepare(k8scli *clients.KubernetesClient, namespace string, targets []string, metadata map[string]string) (string, error)
+	// Release - cleans up after the action once the sandbox is destroyed,
+	// deleteNamespace is false when the sandbox is kept for debugging.
+	// Returns true if the namespace was deleted.
+	Release(k8scli *clients.KubernetesClient, podName, namespace, configNamespace string, deleteNamespace bool) bool
+}
+
+// NewSandboxStrategy - returns the strategy with the name, the runner
+// namespace is only used by the shared runner namespace strategy.
+func NewSandboxStrategy(name string, runnerNamespace string) (SandboxStrategy, error) {
+	switch name {
+	case "", SandboxTransientNamespace:
+		return transientNamespace{}, nil
+	case SandboxSharedRunnerNamespace:
+		if runnerNamespace == "" {
+			return nil, fmt.Errorf("the %v sandbox strategy requires a runner namespace", name)
+		}
+		return sharedRunnerNamespace{namespace: runnerNamespace}, nil
+	case SandboxInTargetNamespace:
+		return inTargetNamespace{}, nil
+	}
+	return nil, fmt.Errorf("unknown sandbox strategy %v", name)
+}
+
+// transientNamespace - Creates a namespace for every action, unless the
+// executor asks for the action to run in a target namespace. The namespace
+// is deleted with the sandbox.
+type transientNamespace struct{}
+
+func (transientNamespace) Name() string {
+	return SandboxTransientNamespace
+}
+
+func (transientNamespace) Prepare(
+	k8scli *clients.KubernetesClient, namespace string, targets []string, metadata map[string]string,
+) (string, error) {
+	if isNamespaceInTargets(namespace, targets) {
+		return namespace, nil
+	}
+	// A retried attempt adopts the namespace created by an earlier one.
+	if key, ok := metadata[IdempotencyKeyLabel]; ok {
+		existing, err := k8scli.Client.CoreV1().Namespaces().List(sandboxSelector(key))
+		if err != nil {
+			return "", err
+		}
+		if len(existing.Items) > 0 {
+			log.Debugf("adopting namespace %s of sandbox %s", existing.Items[0].Name, key)
+			return existing.Items[0].Name, nil
+		}
+	}
+	ns := &apicorev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:       metadata,
+			GenerateName: namespace,
+		},
+	}
+	ns, err := k8scli.Client.CoreV1().Namespaces().Create(ns)
+	if err != nil {
+		return "", err
+	}
+	return ns.ObjectMeta.Name, nil
+}
+
+func (transientNamespace) Release(
+	k8scli *clients.KubernetesClient, podName, namespace, configNamespace string, deleteNamespace bool,
+) bool {
+	if !deleteNamespace {
+		log.Debugf("Keeping namespace alive due to configuration")
+		return false
+	}
+	if configNamespace == namespace {
+		// We should not be attempting to run pods in the ASB namespace, if we are, something is seriously wrong.
+		log.Errorf("Broker is attempting to delete its own namespace %s, keeping it", namespace)
+		return false
+	}
+	log.Debugf("Deleting namespace %s", namespace)
+	k8scli.Client.CoreV1().Namespaces().Delete(namespace, &metav1.DeleteOptions{})
+	return true
+}
+
+// sharedRunnerNamespace - Runs the bundle pods of all actions in one
+// namespace, which is created when it does not exist. The namespace is
+// never deleted, the bundle pod and its service account are.
+type sharedRunnerNamespace struct {
+	namespace string
+}
+
+func (s sharedRunnerNamespace) Name() string {
+	return SandboxSharedRunnerNamespace
+}
+
+func (s sharedRunnerNamespace) Prepare(
+	k8scli *clients.KubernetesClient, namespace string, targets []string, metadata map[string]string,
+) (string, error) {
+	_, err := k8scli.Client.CoreV1().Namespaces().Get(s.namespace, metav1.GetOptions{})
+	if err == nil {
+		return s.namespace, nil
+	}
+	if !kerror.IsNotFound(err) {
+		return "", err
+	}
+	log.Infof("Creating the shared runner namespace %s", s.namespace)
+	ns := &apicorev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: s.namespace}}
+	_, err = k8scli.Client.CoreV1().Namespaces().Create(ns)
+	if err != nil && !kerror.IsAlreadyExists(err) {
+		return "", err
+	}
+	return s.namespace, nil
+}
+
+func (s sharedRunnerNamespace) Release(
+	k8scli *clients.KubernetesClient, podName, namespace, configNamespace string, deleteNamespace bool,
+) bool {
+	if deleteNamespace {
+		deleteBundlePod(k8scli, podName, namespace)
+	}
+	return false
+}
+
+// inTargetNamespace - Runs the bundle pod in the first target namespace.
+// The namespace is never deleted, the bundle pod and its service account
+// are.
+type inTargetNamespace struct{}
+
+func (inTargetNamespace) Name() string {
+	return SandboxInTargetNamespace
+}
+
+func (inTargetNamespace) Prepare(
+	k8scli *clients.KubernetesClient, namespace string, targets []string, metadata map[string]string,
+) (string, error) {
+	if len(targets) < 1 {
+		return "", fmt.Errorf("Must supply at least one target namespace")
+	}
+	return targets[0], nil
+}
+
+func (inTargetNamespace) Release(
+	k8scli *clients.KubernetesClient, podName, namespace, configNamespace string, deleteNamespace bool,
+) bool {
+	if deleteNamespace {
+		deleteBundlePod(k8scli, podName, namespace)
+	}
+	return false
+}
+
+// deleteBundlePod - deletes the bundle pod and its service account from a
+// namespace that outlives the sandbox.
+func deleteBundlePod(k8scli *clients.KubernetesClient, podName, namespace string) {
+	log.Debugf("Deleting pod %s, namespace %s", podName, namespace)
+	err := k8scli.Client.CoreV1().Pods(namespace).Delete(podName, &metav1.DeleteOptions{})
+	if err != nil && !kerror.IsNotFound(err) {
+		log.Errorf("unable to delete pod %s in namespace %s - %v", podName, namespace, err)
+	}
+	err = k8scli.DeleteServiceAccount(podName, namespace)
+	if err != nil && !kerror.IsNotFound(err) {
+		log.Errorf("unable to delete service account %s in namespace %s - %v", podName, namespace, err)
+	}
+}
@@ -0,0 +1,139 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+)
+
+func TestNewSandboxStrategy(t *testing.T) {
+	cases := []struct {
+		name            string
+		strategy        string
+		runnerNamespace string
+		expected        string
+		shouldErr       bool
+	}{
+		{name: "default", expected: SandboxTransientNamespace},
+		{name: "transient", strategy: SandboxTransientNamespace, expected: SandboxTransientNamespace},
+		{name: "shared", strategy: SandboxSharedRunnerNamespace, runnerNamespace: "runner", expected: SandboxSharedRunnerNamespace},
+		{name: "shared without namespace", strategy: SandboxSharedRunnerNamespace, shouldErr: true},
+		{name: "in target", strategy: SandboxInTargetNamespace, expected: SandboxInTargetNamespace},
+		{name: "unknown", strategy: "unknown", shouldErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewSandboxStrategy(tc.strategy, tc.runnerNamespace)
+			if tc.shouldErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			if s.Name() != tc.expected {
+				t.Fatalf("expected strategy %v got: %v", tc.expected, s.Name())
+			}
+		})
+	}
+}
+
+func TestSandboxStrategies(t *testing.T) {
+	target := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "target"}}
+	cases := []struct {
+		name              string
+		strategy          SandboxStrategy
+		expectedNamespace string
+		expectDeleted     bool
+		expectPodDeleted  bool
+	}{
+		{
+			name:              "transient namespace",
+			strategy:          transientNamespace{},
+			expectedNamespace: "sandbox-",
+			expectDeleted:     true,
+		},
+		{
+			name:              "shared runner namespace",
+			strategy:          sharedRunnerNamespace{namespace: "runner"},
+			expectedNamespace: "runner",
+			expectPodDeleted:  true,
+		},
+		{
+			name:              "in target namespace",
+			strategy:          inTargetNamespace{},
+			expectedNamespace: "target",
+			expectPodDeleted:  true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(target)
+			// the fake client does not generate names
+			client.PrependReactor("create", "namespaces", func(action clientgotesting.Action) (bool, k8sruntime.Object, error) {
+				ns := action.(clientgotesting.CreateAction).GetObject().(*v1.Namespace)
+				if ns.Name == "" {
+					ns.Name = ns.GenerateName
+				}
+				return false, ns, nil
+			})
+			k8scli := &clients.KubernetesClient{Client: client}
+			ns, err := tc.strategy.Prepare(k8scli, "sandbox-", []string{"target"}, nil)
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			if ns != tc.expectedNamespace {
+				t.Fatalf("expected namespace %v got: %v", tc.expectedNamespace, ns)
+			}
+			if _, err := k8scli.Client.CoreV1().Namespaces().Get(ns, metav1.GetOptions{}); err != nil {
+				t.Fatalf("expected namespace %v to exist: %v", ns, err)
+			}
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bundle", Namespace: ns}}
+			if _, err := k8scli.Client.CoreV1().Pods(ns).Create(pod); err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+
+			if deleted := tc.strategy.Release(k8scli, "bundle", ns, "broker", false); deleted {
+				t.Fatalf("expected the namespace to be kept")
+			}
+			if _, err := k8scli.Client.CoreV1().Pods(ns).Get("bundle", metav1.GetOptions{}); err != nil {
+				t.Fatalf("expected the pod to be kept: %v", err)
+			}
+
+			if deleted := tc.strategy.Release(k8scli, "bundle", ns, "broker", true); deleted != tc.expectDeleted {
+				t.Fatalf("expected namespace deleted to be %v", tc.expectDeleted)
+			}
+			_, err = k8scli.Client.CoreV1().Namespaces().Get(ns, metav1.GetOptions{})
+			if tc.expectDeleted && err == nil {
+				t.Fatalf("expected the namespace to be deleted")
+			}
+			_, err = k8scli.Client.CoreV1().Pods(ns).Get("bundle", metav1.GetOptions{})
+			if tc.expectPodDeleted && err == nil {
+				t.Fatalf("expected the pod to be deleted")
+			}
+		})
+	}
+}
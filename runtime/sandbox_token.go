@@ -0,0 +1,155 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultSandboxTokenMountPath - where the sandbox token is mounted in
+	// the bundle container when SandboxTokenPolicy.MountPath is empty.
+	DefaultSandboxTokenMountPath = "/var/run/secrets/bundle/serviceaccount"
+	// DefaultSandboxTokenExpiration - how long the sandbox token is valid
+	// for when SandboxTokenPolicy.Expiration is 0.
+	DefaultSandboxTokenExpiration = time.Hour
+	// MinSandboxTokenExpiration - the shortest expiration the API server
+	// accepts for a projected service account token.
+	MinSandboxTokenExpiration = 10 * time.Minute
+	// SandboxTokenEnvVar - the environment variable holding the path of the
+	// token file in the bundle container.
+	SandboxTokenEnvVar = "BUNDLE_SANDBOX_TOKEN_FILE"
+	// sandboxTokenVolumeName - the name of the volume of the token.
+	sandboxTokenVolumeName = "bundle-sandbox-token"
+	// rootCAConfigMap - the config map with the CA of the cluster
+	// published in every namespace.
+	rootCAConfigMap = "kube-root-ca.crt"
+)
+
+// SandboxTokenPolicy - Whether the bundle pods get the token of the sandbox
+// service account, to call back to the cluster with the identity of the
+// sandbox. By default the token is automounted as with any pod.
+//
+// When enabled the automount is turned off and a projected service account
+// token is mounted read-only at MountPath instead. The kubelet requests the
+// token for the bundle pod with the TokenRequest API: it is bound to the
+// pod, limited to the Audience, expires after Expiration and is rotated
+// while the pod runs. Deleting the pod invalidates it. Only the bundle
+// container mounts the token, init containers and sidecars do not.
+//
+// The token is scoped to the sandbox role and targets. The cluster has to
+// serve the TokenRequest API, kubernetes 1.12 or later.
+type SandboxTokenPolicy struct {
+	// Enabled - mount a token issued for the run instead of the default
+	// automounted token.
+	Enabled bool
+	// MountPath - where the token, ca.crt and namespace files are mounted,
+	// defaults to DefaultSandboxTokenMountPath.
+	MountPath string
+	// Audience - the audience the token is valid for, the API server when
+	// empty.
+	Audience string
+	// Expiration - how long the token is valid for, defaults to
+	// DefaultSandboxTokenExpiration.
+	Expiration time.Duration
+}
+
+// Validate - the mount path has to be absolute and the expiration at least
+// MinSandboxTokenExpiration.
+func (s SandboxTokenPolicy) Validate() error {
+	if s.MountPath != "" && !path.IsAbs(s.MountPath) {
+		return fmt.Errorf("the sandbox token mount path %v is not absolute", s.MountPath)
+	}
+	if s.Expiration != 0 && s.Expiration < MinSandboxTokenExpiration {
+		return fmt.Errorf("the sandbox token expiration %v is shorter than %v", s.Expiration, MinSandboxTokenExpiration)
+	}
+	return nil
+}
+
+func (s SandboxTokenPolicy) mountPath() string {
+	if s.MountPath == "" {
+		return DefaultSandboxTokenMountPath
+	}
+	return s.MountPath
+}
+
+func (s SandboxTokenPolicy) expiration() time.Duration {
+	if s.Expiration == 0 {
+		return DefaultSandboxTokenExpiration
+	}
+	return s.Expiration
+}
+
+// apply - turns off the automount of the default token and mounts a
+// projected token of the service account of the pod in the bundle
+// container, when the policy is enabled.
+func (s SandboxTokenPolicy) apply(ec ExecutionContext) ExecutionContext {
+	if !s.Enabled {
+		return ec
+	}
+	automount := false
+	ec.AutomountServiceAccountToken = &automount
+	expirationSeconds := int64(s.expiration() / time.Second)
+	optional := true
+	mountPath := s.mountPath()
+	ec.Volumes = append(ec.Volumes, v1.Volume{
+		Name: sandboxTokenVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Projected: &v1.ProjectedVolumeSource{
+				Sources: []v1.VolumeProjection{
+					{
+						ServiceAccountToken: &v1.ServiceAccountTokenProjection{
+							Audience:          s.Audience,
+							ExpirationSeconds: &expirationSeconds,
+							Path:              v1.ServiceAccountTokenKey,
+						},
+					},
+					{
+						DownwardAPI: &v1.DownwardAPIProjection{
+							Items: []v1.DownwardAPIVolumeFile{{
+								Path:     v1.ServiceAccountNamespaceKey,
+								FieldRef: &v1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.namespace"},
+							}},
+						},
+					},
+					{
+						// the CA is only published by kubernetes 1.20 and
+						// later
+						ConfigMap: &v1.ConfigMapProjection{
+							LocalObjectReference: v1.LocalObjectReference{Name: rootCAConfigMap},
+							Items:                []v1.KeyToPath{{Key: v1.ServiceAccountRootCAKey, Path: v1.ServiceAccountRootCAKey}},
+							Optional:             &optional,
+						},
+					},
+				},
+			},
+		},
+	})
+	ec.VolumeMounts = append(ec.VolumeMounts, v1.VolumeMount{
+		Name:      sandboxTokenVolumeName,
+		MountPath: mountPath,
+		ReadOnly:  true,
+	})
+	ec.Env = append([]v1.EnvVar{
+		{Name: SandboxTokenEnvVar, Value: path.Join(mountPath, v1.ServiceAccountTokenKey)},
+	}, ec.Env...)
+	return ec
+}
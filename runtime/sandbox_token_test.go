@@ -0,0 +1,80 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+)
+
+func TestSandboxTokenPolicyValidate(t *testing.T) {
+	assert.NoError(t, SandboxTokenPolicy{}.Validate())
+	assert.NoError(t, SandboxTokenPolicy{Enabled: true, MountPath: "/token"}.Validate())
+	assert.NoError(t, SandboxTokenPolicy{Enabled: true, Expiration: MinSandboxTokenExpiration}.Validate())
+	assert.Error(t, SandboxTokenPolicy{Enabled: true, MountPath: "token"}.Validate())
+	assert.Error(t, SandboxTokenPolicy{Enabled: true, Expiration: time.Minute}.Validate())
+	assert.Error(t, SandboxTokenPolicy{Enabled: true, Expiration: -time.Hour}.Validate())
+}
+
+func TestSandboxTokenApply(t *testing.T) {
+	ec := ExecutionContext{Env: []v1.EnvVar{{Name: "FOO", Value: "bar"}}}
+	assert.Equal(t, ec, SandboxTokenPolicy{}.apply(ec))
+
+	ec = SandboxTokenPolicy{Enabled: true, Audience: "vault", Expiration: 2 * time.Hour}.apply(ec)
+
+	if ec.AutomountServiceAccountToken == nil || *ec.AutomountServiceAccountToken {
+		t.Fatalf("expected the automount to be turned off")
+	}
+	if len(ec.Volumes) != 1 || ec.Volumes[0].Projected == nil {
+		t.Fatalf("expected a projected volume, got: %v", ec.Volumes)
+	}
+	assert.Equal(t, sandboxTokenVolumeName, ec.Volumes[0].Name)
+	sources := ec.Volumes[0].Projected.Sources
+	if len(sources) != 3 || sources[0].ServiceAccountToken == nil {
+		t.Fatalf("expected the token, namespace and ca projections, got: %v", sources)
+	}
+	token := sources[0].ServiceAccountToken
+	assert.Equal(t, "vault", token.Audience)
+	assert.Equal(t, int64(7200), *token.ExpirationSeconds)
+	assert.Equal(t, v1.ServiceAccountTokenKey, token.Path)
+	assert.Equal(t, v1.ServiceAccountNamespaceKey, sources[1].DownwardAPI.Items[0].Path)
+	assert.Equal(t, "metadata.namespace", sources[1].DownwardAPI.Items[0].FieldRef.FieldPath)
+	assert.Equal(t, rootCAConfigMap, sources[2].ConfigMap.Name)
+	assert.True(t, *sources[2].ConfigMap.Optional)
+
+	assert.Equal(t, []v1.VolumeMount{{Name: sandboxTokenVolumeName, MountPath: DefaultSandboxTokenMountPath, ReadOnly: true}}, ec.VolumeMounts)
+	assert.Equal(t, []v1.EnvVar{
+		{Name: SandboxTokenEnvVar, Value: DefaultSandboxTokenMountPath + "/token"},
+		{Name: "FOO", Value: "bar"},
+	}, ec.Env)
+
+	pod, err := bundlePod(ExecutionContext{Policy: "always", AutomountServiceAccountToken: ec.AutomountServiceAccountToken})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.False(t, *pod.Spec.AutomountServiceAccountToken)
+}
+
+func TestSandboxTokenDefaultExpiration(t *testing.T) {
+	ec := SandboxTokenPolicy{Enabled: true}.apply(ExecutionContext{})
+	token := ec.Volumes[0].Projected.Sources[0].ServiceAccountToken
+	assert.Equal(t, int64(DefaultSandboxTokenExpiration/time.Second), *token.ExpirationSeconds)
+	assert.Empty(t, token.Audience)
+}
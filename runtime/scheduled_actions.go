@@ -0,0 +1,405 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	"github.com/automationbroker/bundle-lib/clock"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	apicorev1 "k8s.io/api/core/v1"
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ScheduledActionLabel - the label of the cron jobs, and of their jobs,
+	// running a scheduled action. The value is the action.
+	ScheduledActionLabel = "bundle-scheduled-action"
+	// ScheduledInstanceLabel - the label of the cron jobs, and of their
+	// jobs, with the ID of the instance the action is run on.
+	ScheduledInstanceLabel = "bundle-instance"
+	// recordedAnnotation - set on the jobs whose run was added to the
+	// history of the instance.
+	recordedAnnotation = "bundle.automationbroker.io/history-recorded"
+	// scheduledJobsHistory - the finished jobs kept by the cron jobs.
+	scheduledJobsHistory = 3
+	// scheduledExtraVarsKey - the key of the secret of a scheduled action
+	// holding the extra vars, the parameters and credentials of the
+	// instance are not written to the cron job.
+	scheduledExtraVarsKey = "extra_vars"
+	// scheduledExtraVarsEnv - the environment variable of the bundle
+	// container the extra vars are read into, expanded in its arguments.
+	scheduledExtraVarsEnv = "BUNDLE_EXTRA_VARS"
+)
+
+// ScheduledAction - An action run periodically on a service instance by a
+// cron job.
+type ScheduledAction struct {
+	InstanceID string `json:"instance_id"`
+	Action     string `json:"action"`
+	// Schedule - the cron expression of the runs, see ValidateSchedule.
+	Schedule string `json:"schedule"`
+	// Namespace - the namespace the cron job runs in, set by the runtime.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ErrorInvalidSchedule - The schedule of an action is not a valid cron
+// expression.
+type ErrorInvalidSchedule struct {
+	Schedule string
+	Reason   string
+}
+
+func (e ErrorInvalidSchedule) Error() string {
+	return fmt.Sprintf("invalid schedule %q - %v", e.Schedule, e.Reason)
+}
+
+// ErrorCode - the error is of the Validation class.
+func (e ErrorInvalidSchedule) ErrorCode() liberrors.Code {
+	return liberrors.CodeValidation
+}
+
+// IsErrorInvalidSchedule - true if the error is an ErrorInvalidSchedule.
+func IsErrorInvalidSchedule(err error) bool {
+	_, ok := err.(ErrorInvalidSchedule)
+	return ok
+}
+
+// scheduleFields - the range of the fields of a cron expression: minute,
+// hour, day of month, month and day of week.
+var scheduleFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ValidateSchedule - validates a standard five field cron expression, or
+// one of the @yearly, @monthly, @weekly, @daily and @hourly macros. Names
+// of months and days are not supported.
+func ValidateSchedule(schedule string) error {
+	switch schedule {
+	case "@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly":
+		return nil
+	}
+	fields := strings.Fields(schedule)
+	if len(fields) != len(scheduleFields) {
+		return ErrorInvalidSchedule{Schedule: schedule, Reason: "expected 5 fields"}
+	}
+	for i, field := range fields {
+		f := scheduleFields[i]
+		if err := validateScheduleField(field, f.min, f.max); err != nil {
+			return ErrorInvalidSchedule{Schedule: schedule, Reason: fmt.Sprintf("%v %v", f.name, err)}
+		}
+	}
+	return nil
+}
+
+// validateScheduleField - validates a list of values, ranges and steps.
+func validateScheduleField(field string, min, max int) error {
+	for _, item := range strings.Split(field, ",") {
+		rng := item
+		if i := strings.Index(item, "/"); i >= 0 {
+			rng = item[:i]
+			step, err := strconv.Atoi(item[i+1:])
+			if err != nil || step < 1 {
+				return fmt.Errorf("has an invalid step %q", item[i+1:])
+			}
+		}
+		if rng == "*" {
+			continue
+		}
+		bounds := strings.SplitN(rng, "-", 2)
+		for _, b := range bounds {
+			v, err := strconv.Atoi(b)
+			if err != nil || v < min || v > max {
+				return fmt.Errorf("value %q is not within %v-%v", b, min, max)
+			}
+		}
+	}
+	return nil
+}
+
+// ScheduledActionName - the name of the cron job, and of the sandbox, of
+// the action of the instance.
+func ScheduledActionName(instanceID, action string) string {
+	return fmt.Sprintf("bundle-cron-%.10x", sha256.Sum256([]byte(instanceID+"/"+action)))
+}
+
+func scheduledActionSelector(instanceID string) metav1.ListOptions {
+	return metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", ScheduledInstanceLabel, instanceID)}
+}
+
+// ScheduleAction - creates the cron job running the bundle of the
+// execution context on the schedule, or updates it when the action is
+// already scheduled. The cron job runs in the sandbox of the execution
+// context, which is kept until the action is unscheduled.
+func (p provider) ScheduleAction(ec ExecutionContext, sa ScheduledAction) error {
+	if err := ValidateSchedule(sa.Schedule); err != nil {
+		return err
+	}
+	ec, err := p.podPolicy(ec)
+	if err != nil {
+		return err
+	}
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		return err
+	}
+	return createCronJob(k8scli, ec, sa)
+}
+
+func createCronJob(k8scli *clients.KubernetesClient, ec ExecutionContext, sa ScheduledAction) error {
+	labels := map[string]string{}
+	for k, v := range ec.Metadata {
+		labels[k] = v
+	}
+	labels[ScheduledActionLabel] = sa.Action
+	labels[ScheduledInstanceLabel] = sa.InstanceID
+
+	name := ScheduledActionName(sa.InstanceID, sa.Action)
+	if err := applyExtraVarsSecret(k8scli, name, ec.Location, labels, ec.ExtraVars); err != nil {
+		return err
+	}
+	// the arguments reference the variable, kubernetes expands it
+	ec.ExtraVars = fmt.Sprintf("$(%s)", scheduledExtraVarsEnv)
+	pod, err := bundlePod(ec)
+	if err != nil {
+		return err
+	}
+	pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, apicorev1.EnvVar{
+		Name: scheduledExtraVarsEnv,
+		ValueFrom: &apicorev1.EnvVarSource{
+			SecretKeyRef: &apicorev1.SecretKeySelector{
+				LocalObjectReference: apicorev1.LocalObjectReference{Name: name},
+				Key:                  scheduledExtraVarsKey,
+			},
+		},
+	})
+
+	// The cron job pods must not restart forever, a failed run is
+	// recorded and the next one waits for the schedule.
+	pod.Spec.RestartPolicy = apicorev1.RestartPolicyNever
+	history := int32(scheduledJobsHistory)
+	backoff := int32(0)
+	cronJob := &batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ec.Location,
+			Labels:    labels,
+		},
+		Spec: batchv1beta1.CronJobSpec{
+			Schedule:                   sa.Schedule,
+			ConcurrencyPolicy:          batchv1beta1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: &history,
+			FailedJobsHistoryLimit:     &history,
+			JobTemplate: batchv1beta1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backoff,
+					Template: apicorev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels, Annotations: pod.Annotations},
+						Spec:       pod.Spec,
+					},
+				},
+			},
+		},
+	}
+
+	client := k8scli.Client.BatchV1beta1().CronJobs(ec.Location)
+	log.Infof("scheduling action %v of instance %v on %q", sa.Action, sa.InstanceID, sa.Schedule)
+	_, err = client.Create(cronJob)
+	if !kapierrors.IsAlreadyExists(err) {
+		return err
+	}
+	existing, err := client.Get(cronJob.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	existing.Labels = cronJob.Labels
+	existing.Spec = cronJob.Spec
+	_, err = client.Update(existing)
+	return err
+}
+
+// applyExtraVarsSecret - creates the secret holding the extra vars of the
+// scheduled action, or updates it when the action is scheduled again.
+func applyExtraVarsSecret(k8scli *clients.KubernetesClient, name, namespace string, labels map[string]string, extraVars string) error {
+	secret := &apicorev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		StringData: map[string]string{scheduledExtraVarsKey: extraVars},
+	}
+	client := k8scli.Client.CoreV1().Secrets(namespace)
+	_, err := client.Create(secret)
+	if !kapierrors.IsAlreadyExists(err) {
+		return err
+	}
+	existing, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	existing.Labels = labels
+	existing.Data = nil
+	existing.StringData = secret.StringData
+	_, err = client.Update(existing)
+	return err
+}
+
+// ScheduledActions - the actions scheduled on the instance.
+func (p provider) ScheduledActions(instanceID string) ([]ScheduledAction, error) {
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		return nil, err
+	}
+	return scheduledActions(k8scli, instanceID)
+}
+
+func scheduledActions(k8scli *clients.KubernetesClient, instanceID string) ([]ScheduledAction, error) {
+	list, err := k8scli.Client.BatchV1beta1().CronJobs(metav1.NamespaceAll).List(scheduledActionSelector(instanceID))
+	if err != nil {
+		return nil, err
+	}
+	actions := []ScheduledAction{}
+	for _, cj := range list.Items {
+		actions = append(actions, ScheduledAction{
+			InstanceID: instanceID,
+			Action:     cj.Labels[ScheduledActionLabel],
+			Schedule:   cj.Spec.Schedule,
+			Namespace:  cj.Namespace,
+		})
+	}
+	return actions, nil
+}
+
+// UnscheduleAction - deletes the cron job of the action, its jobs and the
+// secret of its extra vars. The sandbox of the action is destroyed by the
+// caller.
+func (p provider) UnscheduleAction(sa ScheduledAction) error {
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		return err
+	}
+	return deleteCronJob(k8scli, sa)
+}
+
+func deleteCronJob(k8scli *clients.KubernetesClient, sa ScheduledAction) error {
+	name := ScheduledActionName(sa.InstanceID, sa.Action)
+	propagation := metav1.DeletePropagationBackground
+	err := k8scli.Client.BatchV1beta1().CronJobs(sa.Namespace).Delete(
+		name, &metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !kapierrors.IsNotFound(err) {
+		return err
+	}
+	err = k8scli.Client.CoreV1().Secrets(sa.Namespace).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !kapierrors.IsNotFound(err) {
+		return err
+	}
+	log.Infof("unscheduled action %v of instance %v", sa.Action, sa.InstanceID)
+	return nil
+}
+
+// RecordScheduledRuns - adds the runs of the scheduled actions of the
+// instance that finished since the last call to the history of the
+// instance. Returns the number of runs recorded.
+func (s state) RecordScheduledRuns(instanceID string) (int, error) {
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		return 0, err
+	}
+	return recordScheduledRuns(k8scli, s.nsTarget, instanceID, clock.OrReal(s.clock).Now())
+}
+
+// recordScheduledRuns - records the finished runs, a run without a finish
+// time finished at now.
+func recordScheduledRuns(k8scli *clients.KubernetesClient, namespace, instanceID string, now time.Time) (int, error) {
+	list, err := k8scli.Client.BatchV1().Jobs(metav1.NamespaceAll).List(scheduledActionSelector(instanceID))
+	if err != nil {
+		return 0, err
+	}
+	recorded := 0
+	for i := range list.Items {
+		job := &list.Items[i]
+		op, finished := jobOperation(job, now)
+		if !finished || job.Annotations[recordedAnnotation] != "" {
+			continue
+		}
+		if err := recordOperation(k8scli, namespace, instanceID, op); err != nil {
+			return recorded, err
+		}
+		if job.Annotations == nil {
+			job.Annotations = map[string]string{}
+		}
+		job.Annotations[recordedAnnotation] = "true"
+		if _, err := k8scli.Client.BatchV1().Jobs(job.Namespace).Update(job); err != nil {
+			return recorded, err
+		}
+		recorded++
+	}
+	return recorded, nil
+}
+
+// jobOperation - the operation of a finished job of a scheduled action,
+// finished at now when the job has no finish time.
+func jobOperation(job *batchv1.Job, now time.Time) (Operation, bool) {
+	op := Operation{
+		Action:  job.Labels[ScheduledActionLabel],
+		PodName: job.Name,
+	}
+	if job.Status.StartTime != nil {
+		op.StartedAt = job.Status.StartTime.Time.UTC()
+	}
+	for _, c := range job.Status.Conditions {
+		if c.Status != apicorev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			op.Result = "succeeded"
+		case batchv1.JobFailed:
+			op.Result = "failed"
+			op.Error = c.Message
+		default:
+			continue
+		}
+		op.FinishedAt = c.LastTransitionTime.Time.UTC()
+		if job.Status.CompletionTime != nil {
+			op.FinishedAt = job.Status.CompletionTime.Time.UTC()
+		}
+		if op.FinishedAt.IsZero() {
+			op.FinishedAt = now.UTC()
+		}
+		return op, true
+	}
+	return op, false
+}
@@ -0,0 +1,179 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	apicorev1 "k8s.io/api/core/v1"
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateSchedule(t *testing.T) {
+	testCases := []struct {
+		schedule string
+		valid    bool
+	}{
+		{schedule: "0 2 * * *", valid: true},
+		{schedule: "*/15 0-6,22 1 1-12/2 7", valid: true},
+		{schedule: "@daily", valid: true},
+		{schedule: "", valid: false},
+		{schedule: "0 2 * *", valid: false},
+		{schedule: "60 2 * * *", valid: false},
+		{schedule: "0 2 0 * *", valid: false},
+		{schedule: "*/0 * * * *", valid: false},
+		{schedule: "0 2 * JAN *", valid: false},
+		{schedule: "@sometimes", valid: false},
+	}
+	for _, tc := range testCases {
+		err := ValidateSchedule(tc.schedule)
+		if tc.valid {
+			assert.NoError(t, err, tc.schedule)
+			continue
+		}
+		if !IsErrorInvalidSchedule(err) {
+			t.Fatalf("expected an invalid schedule for %q got: %v", tc.schedule, err)
+		}
+	}
+}
+
+func TestCreateCronJob(t *testing.T) {
+	k8scli := &clients.KubernetesClient{Client: fake.NewSimpleClientset()}
+	ec := ExecutionContext{
+		BundleName: ScheduledActionName("instance", "backup"),
+		Action:     "backup",
+		Image:      "docker.io/automationbroker/postgresql-apb",
+		Location:   "sandbox",
+		Account:    "sandbox",
+		Policy:     "always",
+		Metadata:   map[string]string{"bundle-action": "backup"},
+		ExtraVars:  `{"password": "secret"}`,
+	}
+	sa := ScheduledAction{InstanceID: "instance", Action: "backup", Schedule: "0 2 * * *"}
+
+	if err := createCronJob(k8scli, ec, sa); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	cronJob, err := k8scli.Client.BatchV1beta1().CronJobs("sandbox").Get(ec.BundleName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "0 2 * * *", cronJob.Spec.Schedule)
+	assert.Equal(t, "instance", cronJob.Labels[ScheduledInstanceLabel])
+	assert.Equal(t, apicorev1.RestartPolicyNever, cronJob.Spec.JobTemplate.Spec.Template.Spec.RestartPolicy)
+
+	// the extra vars are read from a secret
+	container := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []string{"backup", "--extra-vars", "$(BUNDLE_EXTRA_VARS)"}, container.Args)
+	assert.Contains(t, container.Env, apicorev1.EnvVar{
+		Name: scheduledExtraVarsEnv,
+		ValueFrom: &apicorev1.EnvVarSource{
+			SecretKeyRef: &apicorev1.SecretKeySelector{
+				LocalObjectReference: apicorev1.LocalObjectReference{Name: ec.BundleName},
+				Key:                  scheduledExtraVarsKey,
+			},
+		},
+	})
+	secret, err := k8scli.Client.CoreV1().Secrets("sandbox").Get(ec.BundleName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, ec.ExtraVars, secret.StringData[scheduledExtraVarsKey])
+
+	// scheduling the action again replaces its schedule
+	sa.Schedule = "@hourly"
+	if err := createCronJob(k8scli, ec, sa); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	actions, err := scheduledActions(k8scli, "instance")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, []ScheduledAction{
+		{InstanceID: "instance", Action: "backup", Schedule: "@hourly", Namespace: "sandbox"},
+	}, actions)
+
+	actions, err = scheduledActions(k8scli, "other")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Empty(t, actions)
+
+	// unscheduling deletes the cron job and the secret
+	sa.Namespace = "sandbox"
+	if err := deleteCronJob(k8scli, sa); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	_, err = k8scli.Client.CoreV1().Secrets("sandbox").Get(ec.BundleName, metav1.GetOptions{})
+	assert.True(t, kapierrors.IsNotFound(err))
+	actions, err = scheduledActions(k8scli, "instance")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Empty(t, actions)
+}
+
+func TestRecordScheduledRuns(t *testing.T) {
+	labels := map[string]string{
+		ScheduledActionLabel:   "backup",
+		ScheduledInstanceLabel: "instance",
+	}
+	finished := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup-1", Namespace: "sandbox", Labels: labels},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobComplete, Status: apicorev1.ConditionTrue},
+			},
+		},
+	}
+	running := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup-2", Namespace: "sandbox", Labels: labels},
+	}
+	k8scli := &clients.KubernetesClient{Client: fake.NewSimpleClientset(finished, running)}
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	recorded, err := recordScheduledRuns(k8scli, "master", "instance", now)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, 1, recorded)
+
+	history, err := readHistory(k8scli, "master", "instance")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("expected 1 operation, got: %d", len(history))
+	}
+	assert.Equal(t, "backup", history[0].Action)
+	assert.Equal(t, "succeeded", history[0].Result)
+	assert.Equal(t, "backup-1", history[0].PodName)
+	assert.Equal(t, now, history[0].FinishedAt)
+
+	// a run is recorded once
+	recorded, err = recordScheduledRuns(k8scli, "master", "instance", now)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, 0, recorded)
+}
@@ -0,0 +1,59 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+// DefaultSidecarInjectionAnnotations - The annotations that keep the service
+// meshes from injecting a sidecar into a pod. Used when the
+// ServiceMeshPolicy does not set its own.
+var DefaultSidecarInjectionAnnotations = map[string]string{
+	"sidecar.istio.io/inject": "false",
+	"linkerd.io/inject":       "disabled",
+}
+
+// ServiceMeshPolicy - How bundle pods are run in namespaces with automatic
+// sidecar injection. An injected sidecar keeps running after the bundle is
+// done, the default watch completes the action once the bundle container
+// terminates whether or not injection is disabled.
+type ServiceMeshPolicy struct {
+	// DisableInjection - annotate the bundle pods so no sidecar is
+	// injected.
+	DisableInjection bool
+	// Annotations - the annotations set on the bundle pods when
+	// DisableInjection is set. When empty
+	// DefaultSidecarInjectionAnnotations are used.
+	Annotations map[string]string
+}
+
+// podAnnotations - the annotations of the bundle pod. The annotations that
+// disable injection are added, the ones already set are kept.
+func (s ServiceMeshPolicy) podAnnotations(annotations map[string]string) map[string]string {
+	if !s.DisableInjection {
+		return annotations
+	}
+	injection := s.Annotations
+	if len(injection) == 0 {
+		injection = DefaultSidecarInjectionAnnotations
+	}
+	merged := map[string]string{}
+	for k, v := range injection {
+		merged[k] = v
+	}
+	for k, v := range annotations {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,85 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestServiceMeshPodAnnotations(t *testing.T) {
+	testCases := []struct {
+		name        string
+		policy      ServiceMeshPolicy
+		annotations map[string]string
+		expected    map[string]string
+	}{
+		{
+			name:        "injection not disabled",
+			policy:      ServiceMeshPolicy{},
+			annotations: map[string]string{"team": "a"},
+			expected:    map[string]string{"team": "a"},
+		},
+		{
+			name:   "default annotations",
+			policy: ServiceMeshPolicy{DisableInjection: true},
+			expected: map[string]string{
+				"sidecar.istio.io/inject": "false",
+				"linkerd.io/inject":       "disabled",
+			},
+		},
+		{
+			name: "configured annotations do not override the pod annotations",
+			policy: ServiceMeshPolicy{
+				DisableInjection: true,
+				Annotations:      map[string]string{"sidecar.istio.io/inject": "false"},
+			},
+			annotations: map[string]string{"sidecar.istio.io/inject": "true", "team": "a"},
+			expected:    map[string]string{"sidecar.istio.io/inject": "true", "team": "a"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.policy.podAnnotations(tc.annotations)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("expected annotations: %v got: %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRunBundleServiceMesh(t *testing.T) {
+	var actual ExecutionContext
+	p := provider{
+		runBundle: func(ec ExecutionContext) (ExecutionContext, error) {
+			actual = ec
+			return ec, nil
+		},
+		serviceMesh: ServiceMeshPolicy{DisableInjection: true},
+	}
+	annotations := map[string]string{"team": "a"}
+	if _, err := p.RunBundle(ExecutionContext{Annotations: annotations}); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	if actual.Annotations["sidecar.istio.io/inject"] != "false" || actual.Annotations["team"] != "a" {
+		t.Fatalf("unexpected pod annotations: %v", actual.Annotations)
+	}
+	if len(annotations) != 1 {
+		t.Fatalf("the annotations of the execution context were modified: %v", annotations)
+	}
+}
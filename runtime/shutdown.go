@@ -0,0 +1,211 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+// ErrorShuttingDown - The bundle was not run or watched because the
+// runtime is shutting down.
+type ErrorShuttingDown struct{}
+
+func (e ErrorShuttingDown) Error() string {
+	return "runtime is shutting down"
+}
+
+// ErrorCode - the error is of the Conflict class.
+func (e ErrorShuttingDown) ErrorCode() liberrors.Code {
+	return liberrors.CodeConflict
+}
+
+// IsErrorShuttingDown - true if the error is an ErrorShuttingDown.
+func IsErrorShuttingDown(err error) bool {
+	_, ok := err.(ErrorShuttingDown)
+	return ok
+}
+
+// ErrorWatchAbandoned - The shutdown gave up waiting for the watch of the
+// bundle. The bundle keeps running, its sandbox and persisted execution
+// are kept for RecoverExecutions.
+type ErrorWatchAbandoned struct {
+	PodName string
+}
+
+func (e ErrorWatchAbandoned) Error() string {
+	return fmt.Sprintf("watch of pod [ %s ] abandoned on shutdown", e.PodName)
+}
+
+// ErrorCode - the error is of the Timeout class.
+func (e ErrorWatchAbandoned) ErrorCode() liberrors.Code {
+	return liberrors.CodeTimeout
+}
+
+// IsErrorWatchAbandoned - true if the error is an ErrorWatchAbandoned.
+func IsErrorWatchAbandoned(err error) bool {
+	_, ok := err.(ErrorWatchAbandoned)
+	return ok
+}
+
+// trackedExecution - a bundle that was started and whose sandbox was not
+// destroyed yet.
+type trackedExecution struct {
+	ec   ExecutionContext
+	save func(ExecutionContext) error
+}
+
+// lifecycle - The bundles run and watched by the runtime, so they can be
+// drained on shutdown.
+type lifecycle struct {
+	mutex      sync.Mutex
+	closed     bool
+	watches    sync.WaitGroup
+	abandoned  chan struct{}
+	executions map[string]trackedExecution
+	// kept - the sandboxes left running for recovery.
+	kept map[string]bool
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		abandoned:  make(chan struct{}),
+		executions: map[string]trackedExecution{},
+		kept:       map[string]bool{},
+	}
+}
+
+// bundles - the lifecycle of the bundles of the runtime.
+var bundles = newLifecycle()
+
+func (l *lifecycle) isClosed() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.closed
+}
+
+// track - remembers the started bundle until its sandbox is destroyed.
+func (l *lifecycle) track(ec ExecutionContext, save func(ExecutionContext) error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.executions[ec.BundleName] = trackedExecution{ec: ec, save: save}
+}
+
+// execution - the started bundle, false if it is not tracked.
+func (l *lifecycle) execution(bundleName string) (ExecutionContext, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	e, ok := l.executions[bundleName]
+	return e.ec, ok
+}
+
+// release - forgets the bundle whose sandbox is destroyed. False is
+// returned when the sandbox is to be kept for recovery.
+func (l *lifecycle) release(bundleName string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.kept[bundleName] {
+		return false
+	}
+	delete(l.executions, bundleName)
+	return true
+}
+
+// watch - runs the watch unless the runtime is shutting down. The watch
+// is given up on with ErrorWatchAbandoned when the shutdown stops waiting,
+// the default watch of the pod is stopped so that it does not outlive the
+// shutdown.
+func (l *lifecycle) watch(podName string, watch func() error) error {
+	l.mutex.Lock()
+	if l.closed {
+		l.mutex.Unlock()
+		return ErrorShuttingDown{}
+	}
+	l.watches.Add(1)
+	abandoned := l.abandoned
+	l.mutex.Unlock()
+	defer l.watches.Done()
+
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() { done <- err }()
+		defer recoverPanic(&err)
+		err = watch()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-abandoned:
+		podWatches.stop(podName)
+		return ErrorWatchAbandoned{PodName: podName}
+	}
+}
+
+// shutdown - stops new bundles from being run or watched and waits for
+// the running watches. When ctx is done first, the watches are abandoned
+// and the bundles that were started are persisted again and kept for
+// recovery.
+func (l *lifecycle) shutdown(ctx context.Context) error {
+	l.mutex.Lock()
+	l.closed = true
+	l.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		l.watches.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	log.Warningf("abandoning the in-flight bundle watches - %v", ctx.Err())
+	close(l.abandoned)
+	for name, e := range l.executions {
+		l.kept[name] = true
+		if err := e.save(e.ec); err != nil {
+			log.Errorf("unable to persist the execution of bundle %s - %v", name, err)
+		}
+	}
+	return ctx.Err()
+}
+
+// Shutdown - stops the runtime from running and watching new bundles and
+// waits for the in-flight watches to finish. When ctx is done first, the
+// watches are abandoned with ErrorWatchAbandoned and the bundles keep
+// running: their executions are persisted and their sandboxes are not
+// destroyed, so RecoverExecutions picks them up after the restart. The
+// shared clients are closed once the watches are drained, they may still
+// be in use by the abandoned ones otherwise.
+func Shutdown(ctx context.Context) error {
+	if err := bundles.shutdown(ctx); err != nil {
+		return err
+	}
+	clients.Close()
+	return nil
+}
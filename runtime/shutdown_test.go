@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestLifecycleShutdownWaitsForWatches(t *testing.T) {
+	l := newLifecycle()
+	release := make(chan struct{})
+	result := make(chan error, 1)
+	go func() {
+		result <- l.watch("pod", func() error {
+			<-release
+			return nil
+		})
+	}()
+	// the watch is registered before the shutdown starts
+	for i := 0; i < 1000 && !watching(l); i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- l.shutdown(context.Background()) }()
+	for i := 0; i < 1000 && !l.isClosed(); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.True(t, IsErrorShuttingDown(l.watch("other", func() error { return nil })))
+
+	close(release)
+	assert.NoError(t, <-result)
+	assert.NoError(t, <-shutdown)
+}
+
+func TestLifecycleShutdownAbandonsWatches(t *testing.T) {
+	l := newLifecycle()
+	saved := []string{}
+	save := func(ec ExecutionContext) error {
+		saved = append(saved, ec.BundleName)
+		return nil
+	}
+	l.track(ExecutionContext{BundleName: "running"}, save)
+	l.track(ExecutionContext{BundleName: "done"}, save)
+	assert.True(t, l.release("done"))
+
+	fw := watch.NewFake()
+	defer podWatches.add("running", fw)()
+	finished := make(chan struct{})
+	result := make(chan error, 1)
+	go func() {
+		result <- l.watch("running", func() error {
+			defer close(finished)
+			for range fw.ResultChan() {
+			}
+			return nil
+		})
+	}()
+	for i := 0; i < 1000 && !watching(l); i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.shutdown(ctx))
+
+	err := <-result
+	assert.True(t, IsErrorWatchAbandoned(err))
+	assert.Equal(t, []string{"running"}, saved)
+	// the sandbox of the abandoned bundle is kept for recovery
+	assert.False(t, l.release("running"))
+	// the abandoned watch is stopped instead of leaking
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("the abandoned watch is still running")
+	}
+}
+
+// watching - true once a watch is registered with the lifecycle.
+func watching(l *lifecycle) bool {
+	done := make(chan struct{})
+	go func() {
+		l.watches.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return false
+	case <-time.After(time.Millisecond):
+		return true
+	}
+}
@@ -20,7 +20,8 @@ import (
 	"fmt"
 
 	"github.com/automationbroker/bundle-lib/clients"
-	log "github.com/sirupsen/logrus"
+	"github.com/automationbroker/bundle-lib/clock"
+	log "github.com/automationbroker/bundle-lib/logging"
 	kerror "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -36,6 +37,9 @@ type state struct {
 	nsTarget string
 	// mountLocation is where in the pod the state will be mounted
 	mountLocation string
+	// clock is the source of the time of the recorded runs, nil uses
+	// clock.Real
+	clock clock.Clock
 }
 
 // StateManager defines an interface for managing state created by service bundles
@@ -46,6 +50,14 @@ type StateManager interface {
 	MasterName(instanceID string) string
 	MasterNamespace() string
 	MountLocation() string
+	// RecordOperation - appends the operation to the history of the
+	// instance.
+	RecordOperation(instanceID string, op Operation) error
+	// History - the operations run on the instance, oldest first.
+	History(instanceID string) ([]Operation, error)
+	// RecordScheduledRuns - adds the finished runs of the scheduled
+	// actions of the instance to its history.
+	RecordScheduledRuns(instanceID string) (int, error)
 }
 
 // CopyState copies the state configmap from one namespace to another
@@ -0,0 +1,133 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
+	apicorev1 "k8s.io/api/core/v1"
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// targetNamespaceQuotaName - the name of the resource quota created in the
+// target namespaces created by the runtime.
+const targetNamespaceQuotaName = "bundle-target-quota"
+
+// TargetNamespacePolicy - Whether target namespaces that do not exist are
+// created before the bundle runs, and how. The same policy applies on
+// kubernetes and openshift.
+type TargetNamespacePolicy struct {
+	// Create - create missing target namespaces. When false a missing
+	// target namespace fails the action with ErrorTargetNamespaceNotFound.
+	Create bool
+	// Labels and Annotations - set on the namespaces that are created.
+	Labels      map[string]string
+	Annotations map[string]string
+	// Quota - the hard limits of a resource quota created in the
+	// namespaces that are created. No quota is created when empty.
+	Quota apicorev1.ResourceList
+}
+
+// ErrorTargetNamespaceNotFound - A target namespace does not exist and the
+// TargetNamespacePolicy does not allow creating it.
+type ErrorTargetNamespaceNotFound struct {
+	Namespace string
+}
+
+func (e ErrorTargetNamespaceNotFound) Error() string {
+	return fmt.Sprintf("target namespace %v does not exist", e.Namespace)
+}
+
+// ErrorCode - the error is of the NotFound class.
+func (e ErrorTargetNamespaceNotFound) ErrorCode() liberrors.Code {
+	return liberrors.CodeNotFound
+}
+
+// IsErrorTargetNamespaceNotFound - true if the error is an
+// ErrorTargetNamespaceNotFound.
+func IsErrorTargetNamespaceNotFound(err error) bool {
+	_, ok := err.(ErrorTargetNamespaceNotFound)
+	return ok
+}
+
+// ensureTargets - makes sure the target namespaces exist, creating the
+// missing ones when the policy allows it.
+func (p provider) ensureTargets(targets []string) error {
+	if len(targets) < 1 {
+		return fmt.Errorf("Must supply at least one target namespace")
+	}
+
+	k8scli, err := clients.Kubernetes()
+	if err != nil {
+		return err
+	}
+	return ensureTargetNamespaces(k8scli, targets, p.targetNamespacePolicy)
+}
+
+func ensureTargetNamespaces(k8scli *clients.KubernetesClient, targets []string, policy TargetNamespacePolicy) error {
+	for _, ns := range targets {
+		_, err := k8scli.Client.CoreV1().Namespaces().Get(ns, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			continue
+		case !kapierrors.IsNotFound(err):
+			return err
+		case !policy.Create:
+			return ErrorTargetNamespaceNotFound{Namespace: ns}
+		}
+		if err := createTargetNamespace(k8scli, ns, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createTargetNamespace(k8scli *clients.KubernetesClient, name string, policy TargetNamespacePolicy) error {
+	log.Infof("creating target namespace %v", name)
+	ns := &apicorev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      policy.Labels,
+			Annotations: policy.Annotations,
+		},
+	}
+	_, err := k8scli.Client.CoreV1().Namespaces().Create(ns)
+	if err != nil && !kapierrors.IsAlreadyExists(err) {
+		log.Errorf("unable to create target namespace %v - %v", name, err)
+		return err
+	}
+	if len(policy.Quota) == 0 {
+		return nil
+	}
+	quota := &apicorev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   targetNamespaceQuotaName,
+			Labels: policy.Labels,
+		},
+		Spec: apicorev1.ResourceQuotaSpec{Hard: policy.Quota},
+	}
+	_, err = k8scli.Client.CoreV1().ResourceQuotas(name).Create(quota)
+	if err != nil && !kapierrors.IsAlreadyExists(err) {
+		log.Errorf("unable to create the resource quota of target namespace %v - %v", name, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/clients"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEnsureTargetNamespaces(t *testing.T) {
+	existing := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "existing"}}
+	quota := v1.ResourceList{v1.ResourceRequestsCPU: resource.MustParse("2")}
+
+	cases := []struct {
+		name          string
+		target        string
+		policy        TargetNamespacePolicy
+		expectCreated bool
+		expectQuota   bool
+		expectError   bool
+	}{
+		{
+			name:   "existing namespace",
+			target: "existing",
+		},
+		{
+			name:        "missing namespace without create",
+			target:      "missing",
+			expectError: true,
+		},
+		{
+			name:          "missing namespace with create",
+			target:        "missing",
+			policy:        TargetNamespacePolicy{Create: true, Labels: map[string]string{"team": "a"}},
+			expectCreated: true,
+		},
+		{
+			name:          "missing namespace with create and quota",
+			target:        "missing",
+			policy:        TargetNamespacePolicy{Create: true, Quota: quota},
+			expectCreated: true,
+			expectQuota:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			k8scli := &clients.KubernetesClient{Client: fake.NewSimpleClientset(existing)}
+			err := ensureTargetNamespaces(k8scli, []string{tc.target}, tc.policy)
+			if tc.expectError {
+				if !IsErrorTargetNamespaceNotFound(err) {
+					t.Fatalf("expected ErrorTargetNamespaceNotFound, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			ns, err := k8scli.Client.CoreV1().Namespaces().Get(tc.target, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			if tc.expectCreated && ns.Labels["team"] != tc.policy.Labels["team"] {
+				t.Fatalf("expected labels %v, got %v", tc.policy.Labels, ns.Labels)
+			}
+			_, err = k8scli.Client.CoreV1().ResourceQuotas(tc.target).Get(targetNamespaceQuotaName, metav1.GetOptions{})
+			if tc.expectQuota && err != nil {
+				t.Fatalf("expected a resource quota: %v", err)
+			}
+			if !tc.expectQuota && err == nil {
+				t.Fatalf("unexpected resource quota in %v", tc.target)
+			}
+		})
+	}
+}
@@ -18,11 +18,13 @@ package runtime
 
 import (
 	"fmt"
+	"sync"
 
 	"reflect"
 
 	"github.com/automationbroker/bundle-lib/clients"
-	log "github.com/sirupsen/logrus"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+	log "github.com/automationbroker/bundle-lib/logging"
 	apiv1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
@@ -32,10 +34,51 @@ var (
 	// ErrorPodPullErr - Error indicating we could not pull the image.
 	ErrorPodPullErr = fmt.Errorf("Unable to pull APB image from it's registry. Please contact your cluster admin")
 	// ErrorActionNotFound - Error indicating pod does not have the action.
-	ErrorActionNotFound = fmt.Errorf("action not found")
+	ErrorActionNotFound error = liberrors.New(liberrors.CodeNotFound, "action not found")
 )
 
+// DefaultMaxRestarts - the restarts of the bundle container allowed with
+// the OnFailure restart policy, see Configuration.MaxRestarts.
+const DefaultMaxRestarts = 3
+
+// watchRegistry - The open watches of the default WatchRunningBundleFunc
+// by pod name, so that a watch that is given up on can be stopped instead
+// of running until the pod is done.
+type watchRegistry struct {
+	mutex   sync.Mutex
+	watches map[string]watch.Interface
+}
+
+// podWatches - the open watches of the bundle pods.
+var podWatches = &watchRegistry{watches: map[string]watch.Interface{}}
+
+// add - registers the watch of the pod, the returned func removes it.
+func (r *watchRegistry) add(podName string, w watch.Interface) func() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.watches[podName] = w
+	return func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if r.watches[podName] == w {
+			delete(r.watches, podName)
+		}
+	}
+}
+
+// stop - stops the watch of the pod, if it is open.
+func (r *watchRegistry) stop(podName string) {
+	r.mutex.Lock()
+	w, ok := r.watches[podName]
+	delete(r.watches, podName)
+	r.mutex.Unlock()
+	if ok {
+		w.Stop()
+	}
+}
+
 // UpdateDescriptionFn function that will should handle the LastDescription from the bundle.
+// Both values may be empty.
 type UpdateDescriptionFn func(string, string)
 
 // ErrorCustomMsg - An error to propagate the custom error message to the callers
@@ -59,6 +102,26 @@ func IsErrorCustomMsg(err error) bool {
 type WatchRunningBundleFunc func(string, string, UpdateDescriptionFn) error
 
 func defaultWatchRunningBundle(podName string, namespace string, updateFunc UpdateDescriptionFn) error {
+	return watchRunningBundle(podName, namespace, updateFunc, 0, DefaultMaxRestarts)
+}
+
+// newWatchRunningBundle - returns the default WatchRunningBundleFunc that
+// fails the action once the image pull errors reach the threshold or the
+// bundle container restarted more than maxRestarts times.
+func newWatchRunningBundle(pullFailureThreshold, maxRestarts int) WatchRunningBundleFunc {
+	return func(podName string, namespace string, updateFunc UpdateDescriptionFn) error {
+		return watchRunningBundle(podName, namespace, updateFunc, pullFailureThreshold, maxRestarts)
+	}
+}
+
+// watchRunningBundle - watches the pod until completion. While the pod is
+// pending image pull errors are counted, when pullFailureThreshold is
+// reached ErrorPodPullErr is returned. A threshold of 0 waits for the pod
+// to fail. A pod restarted on failure is deleted once its bundle container
+// restarted more than maxRestarts times, ErrorBundleRestarted is returned.
+func watchRunningBundle(
+	podName string, namespace string, updateFunc UpdateDescriptionFn, pullFailureThreshold, maxRestarts int,
+) error {
 	k8scli, err := clients.Kubernetes()
 	if err != nil {
 		return fmt.Errorf("failed to retrieve kubernetes client %v", err)
@@ -75,6 +138,8 @@ func defaultWatchRunningBundle(podName string, namespace string, updateFunc Upda
 	if err != nil {
 		return fmt.Errorf("failed to watch pod %s in namespace %s error: %v", podName, namespace, err)
 	}
+	defer podWatches.add(podName, w)()
+	pullFailures := 0
 	for podEvent := range w.ResultChan() {
 		pod, ok := podEvent.Object.(*apiv1.Pod)
 		if !ok {
@@ -86,10 +151,7 @@ func defaultWatchRunningBundle(podName string, namespace string, updateFunc Upda
 			continue
 		}
 
-		lastOp := pod.Annotations["apb_last_operation"]
-		if lastOp != "" {
-			updateFunc(lastOp, "")
-		}
+		updateFunc(podLastOperation(pod), "")
 		podStatus := pod.Status
 		log.Debugf("pod [%s] in phase %s", podName, podStatus.Phase)
 		switch podStatus.Phase {
@@ -102,12 +164,41 @@ func defaultWatchRunningBundle(podName string, namespace string, updateFunc Upda
 		case apiv1.PodSucceeded:
 			w.Stop()
 			// Check for dashboard_url
-			dashURL := pod.Annotations["apb_dashboard_url"]
+			dashURL := podDashboardURL(pod)
 			updateFunc("", dashURL)
 			log.Debugf("Pod [ %s ] completed", podName)
 			return nil
+		case apiv1.PodPending:
+			log.Debugf("Pod [ %s ] %s", podName, podStatus.Phase)
+			if errorPullingImage(podStatus.ContainerStatuses) {
+				pullFailures++
+				log.Debugf("Pod [ %s ] failed to pull the image %d time(s)", podName, pullFailures)
+				if pullFailureThreshold > 0 && pullFailures >= pullFailureThreshold {
+					w.Stop()
+					return ErrorPodPullErr
+				}
+			}
 		default:
 			log.Debugf("Pod [ %s ] %s", podName, podStatus.Phase)
+			if err := checkRestarts(podName, podStatus, maxRestarts); err != nil {
+				w.Stop()
+				log.Errorf("%v, deleting it", err)
+				if err := podClient.Delete(podName, &meta_v1.DeleteOptions{}); err != nil {
+					log.Warningf("unable to delete pod [ %s ] - %v", podName, err)
+				}
+				return err
+			}
+			// Sidecars keep the pod running after the bundle container is
+			// done, the action is complete when the bundle container is.
+			if bundleContainerTerminated(podStatus.ContainerStatuses) {
+				w.Stop()
+				log.Debugf("Pod [ %s ] bundle container terminated", podName)
+				if bundleContainerStatus(podStatus.ContainerStatuses).State.Terminated.ExitCode != 0 {
+					return translateExitStatus(podName, podStatus)
+				}
+				updateFunc("", podDashboardURL(pod))
+				return nil
+			}
 		}
 		if podEvent.Type == watch.Deleted {
 			w.Stop()
@@ -118,16 +209,35 @@ func defaultWatchRunningBundle(podName string, namespace string, updateFunc Upda
 	return nil
 }
 
+// checkRestarts - an ErrorBundleRestarted when the bundle container was
+// restarted more than maxRestarts times, with its last termination state.
+func checkRestarts(podName string, podStatus apiv1.PodStatus, maxRestarts int) error {
+	if len(podStatus.ContainerStatuses) < 1 {
+		return nil
+	}
+	status := bundleContainerStatus(podStatus.ContainerStatuses)
+	if int(status.RestartCount) <= maxRestarts {
+		return nil
+	}
+	err := ErrorBundleRestarted{PodName: podName, Restarts: status.RestartCount}
+	if last := status.LastTerminationState.Terminated; last != nil {
+		err.ExitCode = last.ExitCode
+		err.Reason = last.Reason
+		err.Message = last.Message
+	}
+	return err
+}
+
 func errorPullingImage(conds []apiv1.ContainerStatus) bool {
 	if len(conds) < 1 {
 		log.Warningf("unable to get container status for APB pod")
 		return false
 	}
-	// We should expect only a single container for our APB pod.
-	// If this assumption changes then we may need to update this code.
+	// Sidecars may be running next to the bundle container, only the
+	// bundle container status is considered.
 	// Basis for the image strings is here:
 	// https://github.com/kubernetes/kubernetes/blob/886e04f1fffbb04faf8a9f9ee141143b2684ae68/pkg/kubelet/images/types.go#L27
-	status := conds[0].State.Waiting
+	status := bundleContainerStatus(conds).State.Waiting
 	if status == nil {
 		return false
 	}
@@ -148,11 +258,16 @@ func translateExitStatus(podName string, podStatus apiv1.PodStatus) error {
 		return fmt.Errorf("Pod [ %s ] failed - Unable to determine exit code - %v", podName, podStatus.Message)
 	}
 
-	status := conds[0].State.Terminated
+	status := bundleContainerStatus(conds).State.Terminated
 	if status == nil {
 		return fmt.Errorf("Pod [ %s ] failed. Unable to determine status - %v", podName, podStatus.Message)
 	}
 
+	// runtime 3 bundles write their failure as json
+	if s, ok := parseBundleStatus(status.Message); ok && s.Error != "" {
+		return ErrorCustomMsg{msg: s.Error}
+	}
+
 	// return the termination message if it's not empty
 	if status.Message != "" {
 		return ErrorCustomMsg{msg: status.Message}
@@ -162,10 +277,31 @@ func translateExitStatus(podName string, podStatus apiv1.PodStatus) error {
 		log.Errorf("Pod [ %s ] failed - action's playbook not found.", podName)
 		return ErrorActionNotFound
 	} else if status.ExitCode != 0 {
-		return fmt.Errorf("Pod [ %s ] failed with exit code [%d]", podName, status.ExitCode)
+		return ErrorBundleFailed{PodName: podName, ExitCode: status.ExitCode}
 	}
 
 	// exit code was 0 so not really an error
 	log.Warningf("Pod was marked as failed but exit code was 0 - %v", status.Message)
 	return nil
 }
+
+// bundleContainerStatus - returns the status of the bundle container. The
+// first container is the bundle container when it is not found by name,
+// which is the case for pods created by a custom RunBundleFunc.
+func bundleContainerStatus(conds []apiv1.ContainerStatus) apiv1.ContainerStatus {
+	for _, c := range conds {
+		if c.Name == BundleContainerName {
+			return c
+		}
+	}
+	return conds[0]
+}
+
+// bundleContainerTerminated - true if the pod has more than one container
+// and the bundle container has terminated.
+func bundleContainerTerminated(conds []apiv1.ContainerStatus) bool {
+	if len(conds) < 2 {
+		return false
+	}
+	return bundleContainerStatus(conds).State.Terminated != nil
+}
@@ -42,11 +42,13 @@ func TestWatchPod(t *testing.T) {
 	}
 
 	cases := []struct {
-		Name            string
-		PodClient       func() (*fake.Clientset, *watch.FakeWatcher)
-		UpdatePodStates func(watcher *watch.FakeWatcher)
-		ExpectError     bool
-		Validate        func(status []string) error
+		Name                 string
+		PodClient            func() (*fake.Clientset, *watch.FakeWatcher)
+		UpdatePodStates      func(watcher *watch.FakeWatcher)
+		PullFailureThreshold int
+		MaxRestarts          int
+		ExpectError          bool
+		Validate             func(status []string) error
 	}{
 		{
 			Name: "should get error and state update when pod fails",
@@ -174,6 +176,135 @@ func TestWatchPod(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			Name: "should complete when the bundle container terminates next to a sidecar",
+			PodClient: func() (*fake.Clientset, *watch.FakeWatcher) {
+				kfake := &fake.Clientset{}
+				podWatch := watch.NewFake()
+				kfake.AddWatchReactor("pods", ktesting.DefaultWatchReactor(podWatch, nil))
+				return kfake, podWatch
+			},
+			UpdatePodStates: func(watcher *watch.FakeWatcher) {
+				podStates := []*core1.Pod{{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+						Annotations: map[string]string{
+							"apb_last_operation": "lastop0",
+						},
+					},
+					Status: core1.PodStatus{
+						Phase: core1.PodRunning,
+						ContainerStatuses: []core1.ContainerStatus{
+							{Name: "logging", State: core1.ContainerState{Running: &core1.ContainerStateRunning{}}},
+							{Name: BundleContainerName, State: core1.ContainerState{Terminated: &core1.ContainerStateTerminated{ExitCode: 0}}},
+						},
+					},
+				}}
+				podStateUpdater(watcher, podStates)
+			},
+			Validate: func(status []string) error {
+				if len(status) != 1 || status[0] != "lastop0" {
+					return fmt.Errorf("expected 1 status update got %v", status)
+				}
+				return nil
+			},
+		},
+		{
+			Name: "should get error when the bundle container fails next to a sidecar",
+			PodClient: func() (*fake.Clientset, *watch.FakeWatcher) {
+				kfake := &fake.Clientset{}
+				podWatch := watch.NewFake()
+				kfake.AddWatchReactor("pods", ktesting.DefaultWatchReactor(podWatch, nil))
+				return kfake, podWatch
+			},
+			ExpectError: true,
+			UpdatePodStates: func(watcher *watch.FakeWatcher) {
+				podStates := []*core1.Pod{{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Status: core1.PodStatus{
+						Phase: core1.PodRunning,
+						ContainerStatuses: []core1.ContainerStatus{
+							{Name: "logging", State: core1.ContainerState{Running: &core1.ContainerStateRunning{}}},
+							{Name: BundleContainerName, State: core1.ContainerState{Terminated: &core1.ContainerStateTerminated{ExitCode: 2}}},
+						},
+					},
+				}}
+				podStateUpdater(watcher, podStates)
+			},
+		},
+		{
+			Name: "should get error when the bundle container restarted too often",
+			PodClient: func() (*fake.Clientset, *watch.FakeWatcher) {
+				kfake := &fake.Clientset{}
+				podWatch := watch.NewFake()
+				kfake.AddWatchReactor("pods", ktesting.DefaultWatchReactor(podWatch, nil))
+				return kfake, podWatch
+			},
+			MaxRestarts: 1,
+			ExpectError: true,
+			UpdatePodStates: func(watcher *watch.FakeWatcher) {
+				last := core1.ContainerState{Terminated: &core1.ContainerStateTerminated{ExitCode: 2, Reason: "Error"}}
+				podStates := []*core1.Pod{{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Status: core1.PodStatus{
+						Phase: core1.PodRunning,
+						ContainerStatuses: []core1.ContainerStatus{
+							{Name: BundleContainerName, RestartCount: 1, LastTerminationState: last},
+						},
+					},
+				}, {
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Status: core1.PodStatus{
+						Phase: core1.PodRunning,
+						ContainerStatuses: []core1.ContainerStatus{
+							{Name: BundleContainerName, RestartCount: 2, LastTerminationState: last},
+						},
+					},
+				}}
+				podStateUpdater(watcher, podStates)
+			},
+		},
+		{
+			Name: "should get error when the image pull failure threshold is reached",
+			PodClient: func() (*fake.Clientset, *watch.FakeWatcher) {
+				kfake := &fake.Clientset{}
+				podWatch := watch.NewFake()
+				kfake.AddWatchReactor("pods", ktesting.DefaultWatchReactor(podWatch, nil))
+				return kfake, podWatch
+			},
+			PullFailureThreshold: 2,
+			ExpectError:          true,
+			UpdatePodStates: func(watcher *watch.FakeWatcher) {
+				podStates := []*core1.Pod{{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Status: core1.PodStatus{
+						Phase: core1.PodPending,
+						ContainerStatuses: []core1.ContainerStatus{
+							{Name: BundleContainerName, State: core1.ContainerState{Waiting: &core1.ContainerStateWaiting{Reason: "ErrImagePull"}}},
+						},
+					},
+				}, {
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Status: core1.PodStatus{
+						Phase: core1.PodPending,
+						ContainerStatuses: []core1.ContainerStatus{
+							{Name: BundleContainerName, State: core1.ContainerState{Waiting: &core1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+						},
+					},
+				}}
+				podStateUpdater(watcher, podStates)
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -186,7 +317,7 @@ func TestWatchPod(t *testing.T) {
 			k8scli.Client = podClient
 
 			go func() {
-				watchErr = defaultWatchRunningBundle("test", "test", func(d, newDashURL string) {
+				watchErr = watchRunningBundle("test", "test", func(d, newDashURL string) {
 					fmt.Printf("got newDescription -> %v\n", d)
 					fmt.Printf("got newDashURL-> %v\n", newDashURL)
 					if d != "" {
@@ -196,7 +327,7 @@ func TestWatchPod(t *testing.T) {
 					if dashURL != "" {
 						dashURL = newDashURL
 					}
-				})
+				}, tc.PullFailureThreshold, tc.MaxRestarts)
 				done <- true
 			}()
 			go tc.UpdatePodStates(podWatch)
@@ -0,0 +1,104 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+	"time"
+
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+)
+
+// DefaultWatchTimeoutAction - the key of the WatchTimeouts applying to the
+// actions that have none of their own.
+const DefaultWatchTimeoutAction = "default"
+
+// WatchTimeout - How long the watch of a bundle waits for its action.
+type WatchTimeout struct {
+	// Timeout - the action fails with ErrorWatchTimeout when the bundle
+	// is not done within it. 0 waits until the bundle is done.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// StallTimeout - the action fails with ErrorWatchStale when the
+	// bundle makes no progress, neither a new description nor a change
+	// of its pod seen by the polls of the watch, for this long. 0 disables
+	// the detection, the StaleTimeout of the HeartbeatPolicy still applies.
+	StallTimeout time.Duration `json:"stall_timeout,omitempty"`
+}
+
+// override - the timeouts set in o replace the ones of w.
+func (w WatchTimeout) override(o WatchTimeout) WatchTimeout {
+	if o.Timeout != 0 {
+		w.Timeout = o.Timeout
+	}
+	if o.StallTimeout != 0 {
+		w.StallTimeout = o.StallTimeout
+	}
+	return w
+}
+
+// Validate - the timeouts can not be negative.
+func (w WatchTimeout) Validate() error {
+	if w.Timeout < 0 || w.StallTimeout < 0 {
+		return fmt.Errorf("negative watch timeout %+v", w)
+	}
+	return nil
+}
+
+// WatchTimeouts - The watch timeouts keyed by action, e.g. provision or
+// bind. The DefaultWatchTimeoutAction applies to the actions without
+// timeouts of their own.
+type WatchTimeouts map[string]WatchTimeout
+
+// Validate - validates the timeouts of every action.
+func (w WatchTimeouts) Validate() error {
+	for action, t := range w {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("action %v - %v", action, err)
+		}
+	}
+	return nil
+}
+
+// resolve - the watch timeout of the execution. The timeouts of the
+// execution, from the spec of the bundle, come first, then the ones of
+// its action and the default ones.
+func (w WatchTimeouts) resolve(ec ExecutionContext) WatchTimeout {
+	return w[DefaultWatchTimeoutAction].override(w[ec.Action]).override(ec.WatchTimeout)
+}
+
+// ErrorWatchTimeout - The bundle was not done within the Timeout of the
+// WatchTimeout of its action. The bundle pod is left to the sandbox
+// cleanup.
+type ErrorWatchTimeout struct {
+	PodName string
+	Timeout time.Duration
+}
+
+func (e ErrorWatchTimeout) Error() string {
+	return fmt.Sprintf("pod [ %s ] did not complete within %v", e.PodName, e.Timeout)
+}
+
+// ErrorCode - the error is of the Timeout class.
+func (e ErrorWatchTimeout) ErrorCode() liberrors.Code {
+	return liberrors.CodeTimeout
+}
+
+// IsErrorWatchTimeout - true if the error is an ErrorWatchTimeout.
+func IsErrorWatchTimeout(err error) bool {
+	_, ok := err.(ErrorWatchTimeout)
+	return ok
+}
@@ -0,0 +1,49 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchTimeoutsValidate(t *testing.T) {
+	assert.NoError(t, WatchTimeouts{}.Validate())
+	assert.NoError(t, WatchTimeouts{"provision": {Timeout: time.Hour, StallTimeout: time.Minute}}.Validate())
+	assert.Error(t, WatchTimeouts{"bind": {Timeout: -time.Minute}}.Validate())
+	assert.Error(t, WatchTimeouts{"bind": {StallTimeout: -time.Minute}}.Validate())
+}
+
+func TestWatchTimeoutsResolve(t *testing.T) {
+	timeouts := WatchTimeouts{
+		DefaultWatchTimeoutAction: {Timeout: 10 * time.Minute, StallTimeout: 2 * time.Minute},
+		"provision":               {Timeout: time.Hour},
+	}
+	assert.Equal(t, WatchTimeout{}, WatchTimeouts{}.resolve(ExecutionContext{Action: "provision"}))
+	assert.Equal(t,
+		WatchTimeout{Timeout: 10 * time.Minute, StallTimeout: 2 * time.Minute},
+		timeouts.resolve(ExecutionContext{Action: "bind"}))
+	assert.Equal(t,
+		WatchTimeout{Timeout: time.Hour, StallTimeout: 2 * time.Minute},
+		timeouts.resolve(ExecutionContext{Action: "provision"}))
+	// the timeouts of the spec come first
+	assert.Equal(t,
+		WatchTimeout{Timeout: time.Hour, StallTimeout: 5 * time.Minute},
+		timeouts.resolve(ExecutionContext{Action: "provision", WatchTimeout: WatchTimeout{StallTimeout: 5 * time.Minute}}))
+}
@@ -0,0 +1,229 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/crd"
+)
+
+// documentStore - Keeps the encoded objects, by kind and id. get returns
+// ErrorNotFound when the object is not stored.
+type documentStore interface {
+	get(kind Kind, id string) ([]byte, error)
+	list(kind Kind) ([][]byte, error)
+	set(kind Kind, id string, data []byte) error
+	delete(kind Kind, id string) error
+}
+
+// documents - Implements Storage on top of a documentStore, encoding the
+// objects as json. Instances and bindings use the bundle wire format, with
+// their parameters sealed apart.
+type documents struct {
+	store documentStore
+}
+
+// sealedDocument - An instance or binding with its parameters taken out and
+// encrypted by the parameter cipher of the crd package, when one is set.
+type sealedDocument struct {
+	Object     json.RawMessage `json:"object"`
+	Parameters string          `json:"parameters,omitempty"`
+}
+
+// seal - returns the document of the encoded object and its parameters.
+func seal(object []byte, params *bundle.Parameters) ([]byte, error) {
+	doc := sealedDocument{Object: object}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		doc.Parameters, err = crd.EncryptParameters(b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// unseal - returns the encoded object of the document and its decrypted
+// parameters, nil when it has none.
+func unseal(b []byte) ([]byte, *bundle.Parameters, error) {
+	doc := sealedDocument{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, nil, err
+	}
+	if doc.Parameters == "" {
+		return doc.Object, nil, nil
+	}
+	p, err := crd.DecryptParameters(doc.Parameters)
+	if err != nil {
+		return nil, nil, err
+	}
+	params := &bundle.Parameters{}
+	if err := json.Unmarshal(p, params); err != nil {
+		return nil, nil, err
+	}
+	return doc.Object, params, nil
+}
+
+// GetSpec - returns the spec.
+func (d documents) GetSpec(id string) (*bundle.Spec, error) {
+	b, err := d.store.get(KindSpec, id)
+	if err != nil {
+		return nil, err
+	}
+	spec := &bundle.Spec{}
+	if err := json.Unmarshal(b, spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// ListSpecs - returns all the specs.
+func (d documents) ListSpecs() ([]*bundle.Spec, error) {
+	docs, err := d.store.list(KindSpec)
+	if err != nil {
+		return nil, err
+	}
+	specs := []*bundle.Spec{}
+	for _, b := range docs {
+		spec := &bundle.Spec{}
+		if err := json.Unmarshal(b, spec); err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// SetSpec - stores the spec.
+func (d documents) SetSpec(id string, spec *bundle.Spec) error {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	return d.store.set(KindSpec, id, b)
+}
+
+// DeleteSpec - deletes the spec.
+func (d documents) DeleteSpec(id string) error {
+	return d.store.delete(KindSpec, id)
+}
+
+// GetServiceInstance - returns the instance.
+func (d documents) GetServiceInstance(id string) (*bundle.ServiceInstance, error) {
+	b, err := d.store.get(KindServiceInstance, id)
+	if err != nil {
+		return nil, err
+	}
+	object, params, err := unseal(b)
+	if err != nil {
+		return nil, err
+	}
+	si, err := bundle.UnmarshalServiceInstance(object)
+	if err != nil {
+		return nil, err
+	}
+	si.Parameters = params
+	return si, nil
+}
+
+// SetServiceInstance - stores the instance.
+func (d documents) SetServiceInstance(id string, si *bundle.ServiceInstance) error {
+	unsealed := *si
+	unsealed.Parameters = nil
+	object, err := bundle.MarshalServiceInstance(&unsealed)
+	if err != nil {
+		return err
+	}
+	b, err := seal(object, si.Parameters)
+	if err != nil {
+		return err
+	}
+	return d.store.set(KindServiceInstance, id, b)
+}
+
+// DeleteServiceInstance - deletes the instance.
+func (d documents) DeleteServiceInstance(id string) error {
+	return d.store.delete(KindServiceInstance, id)
+}
+
+// GetBindInstance - returns the binding.
+func (d documents) GetBindInstance(id string) (*bundle.BindInstance, error) {
+	b, err := d.store.get(KindBindInstance, id)
+	if err != nil {
+		return nil, err
+	}
+	object, params, err := unseal(b)
+	if err != nil {
+		return nil, err
+	}
+	bi, err := bundle.UnmarshalBindInstance(object)
+	if err != nil {
+		return nil, err
+	}
+	bi.Parameters = params
+	return bi, nil
+}
+
+// SetBindInstance - stores the binding.
+func (d documents) SetBindInstance(id string, bi *bundle.BindInstance) error {
+	unsealed := *bi
+	unsealed.Parameters = nil
+	object, err := bundle.MarshalBindInstance(&unsealed)
+	if err != nil {
+		return err
+	}
+	b, err := seal(object, bi.Parameters)
+	if err != nil {
+		return err
+	}
+	return d.store.set(KindBindInstance, id, b)
+}
+
+// DeleteBindInstance - deletes the binding.
+func (d documents) DeleteBindInstance(id string) error {
+	return d.store.delete(KindBindInstance, id)
+}
+
+// GetJobState - returns the state of the job.
+func (d documents) GetJobState(id string) (bundle.JobState, error) {
+	state := bundle.JobState{}
+	b, err := d.store.get(KindJobState, id)
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(b, &state)
+	return state, err
+}
+
+// SetJobState - stores the state of the job.
+func (d documents) SetJobState(id string, state bundle.JobState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return d.store.set(KindJobState, id, b)
+}
+
+// DeleteJobState - deletes the state of the job.
+func (d documents) DeleteJobState(id string) error {
+	return d.store.delete(KindJobState, id)
+}
@@ -0,0 +1,139 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+// MemoryStorage - A thread safe Storage keeping the objects in memory, for
+// demos, tests and short lived brokers. When it has a snapshot file, the
+// objects are loaded from it and it is rewritten after every change.
+type MemoryStorage struct {
+	documents
+	mutex    sync.RWMutex
+	objects  map[Kind]map[string]json.RawMessage
+	snapshot string
+}
+
+// NewMemoryStorage - returns an empty in memory storage.
+func NewMemoryStorage() *MemoryStorage {
+	s := &MemoryStorage{objects: map[Kind]map[string]json.RawMessage{}}
+	s.documents = documents{store: s}
+	return s
+}
+
+// NewMemoryStorageWithSnapshot - returns an in memory storage loaded from
+// the snapshot file, when it exists, and saved to it after every change.
+func NewMemoryStorageWithSnapshot(path string) (*MemoryStorage, error) {
+	s := NewMemoryStorage()
+	s.snapshot = path
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &s.objects); err != nil {
+		log.Errorf("unable to load the snapshot %v - %v", path, err)
+		return nil, err
+	}
+	return s, nil
+}
+
+// Snapshot - writes the objects to the snapshot file. It does nothing
+// when the storage has no snapshot file.
+func (s *MemoryStorage) Snapshot() error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.save()
+}
+
+// save - writes the snapshot file, the mutex must be held. The file is
+// replaced atomically so a crash never leaves a partial snapshot.
+func (s *MemoryStorage) save() error {
+	if s.snapshot == "" {
+		return nil
+	}
+	b, err := json.Marshal(s.objects)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.snapshot), filepath.Base(s.snapshot))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	err = os.Rename(tmp.Name(), s.snapshot)
+	if err != nil {
+		log.Errorf("unable to save the snapshot %v - %v", s.snapshot, err)
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func (s *MemoryStorage) get(kind Kind, id string) ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	data, ok := s.objects[kind][id]
+	if !ok {
+		return nil, ErrorNotFound{Kind: kind, ID: id}
+	}
+	return data, nil
+}
+
+func (s *MemoryStorage) list(kind Kind) ([][]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	docs := [][]byte{}
+	for _, data := range s.objects[kind] {
+		docs = append(docs, data)
+	}
+	return docs, nil
+}
+
+func (s *MemoryStorage) set(kind Kind, id string, data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.objects[kind] == nil {
+		s.objects[kind] = map[string]json.RawMessage{}
+	}
+	s.objects[kind][id] = json.RawMessage(data)
+	return s.save()
+}
+
+func (s *MemoryStorage) delete(kind Kind, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.objects[kind], id)
+	return s.save()
+}
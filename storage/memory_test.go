@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	s := NewMemoryStorage()
+	bi := &bundle.BindInstance{
+		ID:         uuid.NewRandom(),
+		ServiceID:  uuid.NewRandom(),
+		Parameters: &bundle.Parameters{"user": "admin"},
+	}
+	if err := s.SetBindInstance(bi.ID.String(), bi); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	got, err := s.GetBindInstance(bi.ID.String())
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, bi.ServiceID.String(), got.ServiceID.String())
+	assert.Equal(t, "admin", (*got.Parameters)["user"])
+
+	if err := s.DeleteBindInstance(bi.ID.String()); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	_, err = s.GetBindInstance(bi.ID.String())
+	assert.True(t, IsErrorNotFound(err))
+}
+
+func TestMemoryStorageConcurrent(t *testing.T) {
+	s := NewMemoryStorage()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("spec-%d", i)
+			s.SetSpec(id, &bundle.Spec{ID: id})
+			s.ListSpecs()
+		}(i)
+	}
+	wg.Wait()
+	specs, err := s.ListSpecs()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Len(t, specs, 20)
+}
+
+func TestMemoryStorageSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.json")
+
+	s, err := NewMemoryStorageWithSnapshot(path)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	state := bundle.JobState{Token: "token", State: bundle.StateInProgress, Method: bundle.JobMethodProvision}
+	if err := s.SetJobState("token", state); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+
+	loaded, err := NewMemoryStorageWithSnapshot(path)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	got, err := loaded.GetJobState("token")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, state, got)
+}
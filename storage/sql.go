@@ -0,0 +1,159 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	log "github.com/automationbroker/bundle-lib/logging"
+)
+
+// Dialect - The SQL dialect of the database.
+type Dialect string
+
+const (
+	// DialectPostgres - PostgreSQL 9.5 or later.
+	DialectPostgres Dialect = "postgres"
+	// DialectMySQL - MySQL 5.6 or later.
+	DialectMySQL Dialect = "mysql"
+)
+
+// DefaultTable - the table the objects are stored in when none is set.
+const DefaultTable = "bundle_lib_objects"
+
+// MaxIDLength - the longest id the table holds. The primary key of kind and
+// id stays under the 767 bytes InnoDB allows for an index in utf8mb4.
+const MaxIDLength = 128
+
+// tableNameRegex - the table names accepted by NewSQLStorage. The name is
+// part of every statement, it can not be passed as an argument.
+var tableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLStorage - A Storage keeping the objects as json documents in a single
+// table of a PostgreSQL or MySQL database. The database driver is
+// registered by the broker, bundle-lib does not depend on one.
+type SQLStorage struct {
+	documents
+	db      *sql.DB
+	dialect Dialect
+	table   string
+}
+
+// NewSQLStorage - returns the storage for the database. An empty table
+// uses DefaultTable, any other has to be a plain identifier of letters,
+// digits and underscores.
+func NewSQLStorage(db *sql.DB, dialect Dialect, table string) (*SQLStorage, error) {
+	if dialect != DialectPostgres && dialect != DialectMySQL {
+		return nil, fmt.Errorf("unsupported sql dialect %v", dialect)
+	}
+	if table == "" {
+		table = DefaultTable
+	}
+	if !tableNameRegex.MatchString(table) {
+		return nil, fmt.Errorf("invalid sql table name %q", table)
+	}
+	s := &SQLStorage{db: db, dialect: dialect, table: table}
+	s.documents = documents{store: s}
+	return s, nil
+}
+
+// CreateSchema - creates the table when it does not exist. The documents
+// are kept in a MEDIUMTEXT on MySQL, a TEXT holds only 64KiB there.
+func (s *SQLStorage) CreateSchema() error {
+	data := "TEXT"
+	if s.dialect == DialectMySQL {
+		data = "MEDIUMTEXT"
+	}
+	_, err := s.db.Exec(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (kind VARCHAR(16) NOT NULL, id VARCHAR(%d) NOT NULL, data %s NOT NULL, PRIMARY KEY (kind, id))",
+		s.table, MaxIDLength, data))
+	return err
+}
+
+// placeholder - the placeholder of the nth argument of a query.
+func (s *SQLStorage) placeholder(n int) string {
+	if s.dialect == DialectPostgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func (s *SQLStorage) get(kind Kind, id string) ([]byte, error) {
+	query := fmt.Sprintf("SELECT data FROM %s WHERE kind = %s AND id = %s",
+		s.table, s.placeholder(1), s.placeholder(2))
+	var data string
+	err := s.db.QueryRow(query, string(kind), id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrorNotFound{Kind: kind, ID: id}
+	}
+	if err != nil {
+		log.Errorf("unable to get %v %v - %v", kind, id, err)
+		return nil, err
+	}
+	return []byte(data), nil
+}
+
+func (s *SQLStorage) list(kind Kind) ([][]byte, error) {
+	query := fmt.Sprintf("SELECT data FROM %s WHERE kind = %s", s.table, s.placeholder(1))
+	rows, err := s.db.Query(query, string(kind))
+	if err != nil {
+		log.Errorf("unable to list %v - %v", kind, err)
+		return nil, err
+	}
+	defer rows.Close()
+	docs := [][]byte{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		docs = append(docs, []byte(data))
+	}
+	return docs, rows.Err()
+}
+
+func (s *SQLStorage) set(kind Kind, id string, data []byte) error {
+	if len(id) > MaxIDLength {
+		return fmt.Errorf("the id of %v %v is longer than %d", kind, id, MaxIDLength)
+	}
+	var query string
+	switch s.dialect {
+	case DialectPostgres:
+		query = fmt.Sprintf("INSERT INTO %s (kind, id, data) VALUES ($1, $2, $3) "+
+			"ON CONFLICT (kind, id) DO UPDATE SET data = EXCLUDED.data", s.table)
+	default:
+		query = fmt.Sprintf("INSERT INTO %s (kind, id, data) VALUES (?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE data = VALUES(data)", s.table)
+	}
+	_, err := s.db.Exec(query, string(kind), id, string(data))
+	if err != nil {
+		log.Errorf("unable to set %v %v - %v", kind, id, err)
+	}
+	return err
+}
+
+func (s *SQLStorage) delete(kind Kind, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE kind = %s AND id = %s",
+		s.table, s.placeholder(1), s.placeholder(2))
+	_, err := s.db.Exec(query, string(kind), id)
+	if err != nil {
+		log.Errorf("unable to delete %v %v - %v", kind, id, err)
+	}
+	return err
+}
@@ -0,0 +1,288 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	"github.com/automationbroker/bundle-lib/crd"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDB - an in memory driver understanding the queries of SQLStorage.
+type fakeDB struct {
+	mutex   sync.Mutex
+	objects map[string]string
+	queries []string
+}
+
+var fakeDBs = map[string]*fakeDB{}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{db: fakeDBs[name]}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *fakeConn) Commit() error { return nil }
+
+func (c *fakeConn) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error { return nil }
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mutex.Lock()
+	defer s.db.mutex.Unlock()
+	s.db.queries = append(s.db.queries, s.query)
+	switch {
+	case strings.HasPrefix(s.query, "INSERT"):
+		s.db.objects[args[0].(string)+"/"+args[1].(string)] = args[2].(string)
+	case strings.HasPrefix(s.query, "DELETE"):
+		delete(s.db.objects, args[0].(string)+"/"+args[1].(string))
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mutex.Lock()
+	defer s.db.mutex.Unlock()
+	s.db.queries = append(s.db.queries, s.query)
+	rows := &fakeRows{}
+	for key, data := range s.db.objects {
+		parts := strings.SplitN(key, "/", 2)
+		if parts[0] != args[0].(string) {
+			continue
+		}
+		if len(args) > 1 && parts[1] != args[1].(string) {
+			continue
+		}
+		rows.data = append(rows.data, data)
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	data []string
+}
+
+func (r *fakeRows) Columns() []string { return []string{"data"} }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.data) == 0 {
+		return io.EOF
+	}
+	dest[0] = r.data[0]
+	r.data = r.data[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("fake", fakeDriver{})
+}
+
+func newFakeStorage(t *testing.T, dialect Dialect) (*SQLStorage, *fakeDB) {
+	name := uuid.New()
+	fdb := &fakeDB{objects: map[string]string{}}
+	fakeDBs[name] = fdb
+	db, err := sql.Open("fake", name)
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	s, err := NewSQLStorage(db, dialect, "")
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	return s, fdb
+}
+
+func TestNewSQLStorageUnsupportedDialect(t *testing.T) {
+	_, err := NewSQLStorage(nil, Dialect("sqlite"), "")
+	assert.Error(t, err)
+}
+
+func TestNewSQLStorageTableName(t *testing.T) {
+	for _, table := range []string{"objects", "_bundle_lib_2", "BundleObjects"} {
+		_, err := NewSQLStorage(nil, DialectPostgres, table)
+		assert.NoError(t, err, table)
+	}
+	for _, table := range []string{"2objects", "objects; DROP TABLE users", "public.objects", `"objects"`, "obj-ects"} {
+		_, err := NewSQLStorage(nil, DialectMySQL, table)
+		assert.Error(t, err, table)
+	}
+}
+
+func TestSQLStorageDialects(t *testing.T) {
+	testCases := []struct {
+		dialect Dialect
+		query   string
+		upsert  string
+	}{
+		{
+			dialect: DialectPostgres,
+			query:   "SELECT data FROM bundle_lib_objects WHERE kind = $1 AND id = $2",
+			upsert:  "ON CONFLICT (kind, id) DO UPDATE SET data = EXCLUDED.data",
+		},
+		{
+			dialect: DialectMySQL,
+			query:   "SELECT data FROM bundle_lib_objects WHERE kind = ? AND id = ?",
+			upsert:  "ON DUPLICATE KEY UPDATE data = VALUES(data)",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.dialect), func(t *testing.T) {
+			s, fdb := newFakeStorage(t, tc.dialect)
+			if err := s.SetJobState("token", bundle.JobState{Token: "token"}); err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			if _, err := s.GetJobState("token"); err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Contains(t, fdb.queries[0], tc.upsert)
+			assert.Equal(t, tc.query, fdb.queries[1])
+		})
+	}
+}
+
+func TestSQLStorageServiceInstance(t *testing.T) {
+	s, _ := newFakeStorage(t, DialectPostgres)
+	id := uuid.NewRandom()
+	si := &bundle.ServiceInstance{
+		ID:         id,
+		Spec:       &bundle.Spec{ID: "spec-id", FQName: "dh-postgresql-apb"},
+		Parameters: &bundle.Parameters{"db_name": "admin"},
+		BindingIDs: map[string]bool{"binding": true},
+	}
+	if err := s.SetServiceInstance(id.String(), si); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	got, err := s.GetServiceInstance(id.String())
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, si.ID.String(), got.ID.String())
+	assert.Equal(t, "spec-id", got.Spec.ID)
+	assert.Equal(t, "admin", (*got.Parameters)["db_name"])
+	assert.Equal(t, si.BindingIDs, got.BindingIDs)
+
+	if err := s.DeleteServiceInstance(id.String()); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	_, err = s.GetServiceInstance(id.String())
+	assert.True(t, IsErrorNotFound(err))
+}
+
+func TestSQLStorageListSpecs(t *testing.T) {
+	s, _ := newFakeStorage(t, DialectMySQL)
+	for _, id := range []string{"one", "two"} {
+		if err := s.SetSpec(id, &bundle.Spec{ID: id}); err != nil {
+			t.Fatalf("unknown error occured: %v", err)
+		}
+	}
+	if err := s.SetJobState("token", bundle.JobState{Token: "token"}); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	specs, err := s.ListSpecs()
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	ids := []string{}
+	for _, spec := range specs {
+		ids = append(ids, spec.ID)
+	}
+	sort.Strings(ids)
+	assert.Equal(t, []string{"one", "two"}, ids)
+}
+
+func TestSQLStorageSchema(t *testing.T) {
+	testCases := []struct {
+		dialect Dialect
+		data    string
+	}{
+		{dialect: DialectPostgres, data: "data TEXT NOT NULL"},
+		{dialect: DialectMySQL, data: "data MEDIUMTEXT NOT NULL"},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.dialect), func(t *testing.T) {
+			s, fdb := newFakeStorage(t, tc.dialect)
+			if err := s.CreateSchema(); err != nil {
+				t.Fatalf("unknown error occured: %v", err)
+			}
+			assert.Contains(t, fdb.queries[0], "kind VARCHAR(16) NOT NULL, id VARCHAR(128) NOT NULL")
+			assert.Contains(t, fdb.queries[0], tc.data)
+		})
+	}
+
+	s, _ := newFakeStorage(t, DialectMySQL)
+	assert.Error(t, s.SetJobState(strings.Repeat("a", MaxIDLength+1), bundle.JobState{}))
+}
+
+func TestSQLStorageEncryptsParameters(t *testing.T) {
+	c, err := crd.NewAESGCMCipher([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	crd.SetParameterCipher(c)
+	defer crd.SetParameterCipher(nil)
+
+	s, fdb := newFakeStorage(t, DialectPostgres)
+	id := uuid.NewRandom()
+	si := &bundle.ServiceInstance{
+		ID:         id,
+		Spec:       &bundle.Spec{ID: "spec-id"},
+		Parameters: &bundle.Parameters{"password": "secret"},
+	}
+	if err := s.SetServiceInstance(id.String(), si); err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	for _, data := range fdb.objects {
+		assert.NotContains(t, data, "secret")
+	}
+	got, err := s.GetServiceInstance(id.String())
+	if err != nil {
+		t.Fatalf("unknown error occured: %v", err)
+	}
+	assert.Equal(t, "secret", (*got.Parameters)["password"])
+}
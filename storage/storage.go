@@ -0,0 +1,98 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package storage persists the broker state, the specs, instances,
+// bindings and job states, for brokers that do not store it in CRDs.
+package storage
+
+import (
+	"fmt"
+
+	"github.com/automationbroker/bundle-lib/bundle"
+	liberrors "github.com/automationbroker/bundle-lib/errors"
+)
+
+// Kind - The kind of object that is stored.
+type Kind string
+
+const (
+	// KindSpec - a bundle spec.
+	KindSpec Kind = "spec"
+	// KindServiceInstance - a service instance.
+	KindServiceInstance Kind = "instance"
+	// KindBindInstance - a binding.
+	KindBindInstance Kind = "binding"
+	// KindJobState - the state of the job of an action.
+	KindJobState Kind = "job_state"
+)
+
+// Storage - Persists the broker state. The in memory and SQL storages
+// implement it.
+type Storage interface {
+	// GetSpec - returns the spec, ErrorNotFound when it is not stored.
+	GetSpec(id string) (*bundle.Spec, error)
+	// ListSpecs - returns all the specs.
+	ListSpecs() ([]*bundle.Spec, error)
+	// SetSpec - stores the spec, replacing the stored one.
+	SetSpec(id string, spec *bundle.Spec) error
+	// DeleteSpec - deletes the spec.
+	DeleteSpec(id string) error
+
+	// GetServiceInstance - returns the instance, ErrorNotFound when it is
+	// not stored.
+	GetServiceInstance(id string) (*bundle.ServiceInstance, error)
+	// SetServiceInstance - stores the instance, replacing the stored one.
+	SetServiceInstance(id string, si *bundle.ServiceInstance) error
+	// DeleteServiceInstance - deletes the instance.
+	DeleteServiceInstance(id string) error
+
+	// GetBindInstance - returns the binding, ErrorNotFound when it is not
+	// stored.
+	GetBindInstance(id string) (*bundle.BindInstance, error)
+	// SetBindInstance - stores the binding, replacing the stored one.
+	SetBindInstance(id string, bi *bundle.BindInstance) error
+	// DeleteBindInstance - deletes the binding.
+	DeleteBindInstance(id string) error
+
+	// GetJobState - returns the state of the job, ErrorNotFound when it
+	// is not stored.
+	GetJobState(id string) (bundle.JobState, error)
+	// SetJobState - stores the state of the job, replacing the stored one.
+	SetJobState(id string, state bundle.JobState) error
+	// DeleteJobState - deletes the state of the job.
+	DeleteJobState(id string) error
+}
+
+// ErrorNotFound - The object is not stored.
+type ErrorNotFound struct {
+	Kind Kind
+	ID   string
+}
+
+func (e ErrorNotFound) Error() string {
+	return fmt.Sprintf("%v %v not found", e.Kind, e.ID)
+}
+
+// ErrorCode - the error is of the NotFound class.
+func (e ErrorNotFound) ErrorCode() liberrors.Code {
+	return liberrors.CodeNotFound
+}
+
+// IsErrorNotFound - true if the error is an ErrorNotFound.
+func IsErrorNotFound(err error) bool {
+	_, ok := err.(ErrorNotFound)
+	return ok
+}
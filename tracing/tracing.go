@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName - the name of the tracer used by bundle-lib.
+const InstrumentationName = "github.com/automationbroker/bundle-lib"
+
+var (
+	mutex    sync.RWMutex
+	provider trace.TracerProvider
+)
+
+// SetTracerProvider - sets the tracer provider used to create the bundle-lib
+// spans. Passing nil will use the global OpenTelemetry tracer provider, which
+// does not record anything unless the consumer has registered one.
+func SetTracerProvider(tp trace.TracerProvider) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	provider = tp
+}
+
+func tracer() trace.Tracer {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if provider == nil {
+		return otel.GetTracerProvider().Tracer(InstrumentationName)
+	}
+	return provider.Tracer(InstrumentationName)
+}
+
+// StartSpan - starts a span that is a child of the span in the context. A nil
+// context is treated as context.Background().
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan - ends the span, recording the error on the span if there is one.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
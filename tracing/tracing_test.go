@@ -0,0 +1,62 @@
+//
+// Copyright (c) 2018 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStartSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer SetTracerProvider(nil)
+
+	ctx, parent := StartSpan(nil, "parent")
+	_, child := StartSpan(ctx, "child")
+	EndSpan(child, fmt.Errorf("child failed"))
+	EndSpan(parent, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans got: %v", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.Parent().SpanID() != p.SpanContext().SpanID() {
+		t.Fatalf("expected child span to be a child of the parent span")
+	}
+	if c.Status().Code != codes.Error {
+		t.Fatalf("expected child span status: %v got: %v", codes.Error, c.Status().Code)
+	}
+	if p.Status().Code != codes.Unset {
+		t.Fatalf("expected parent span status: %v got: %v", codes.Unset, p.Status().Code)
+	}
+}
+
+func TestStartSpanDefaultProvider(t *testing.T) {
+	SetTracerProvider(nil)
+	_, span := StartSpan(context.Background(), "noop")
+	defer EndSpan(span, nil)
+	if span.SpanContext().IsValid() {
+		t.Fatalf("expected a non recording span without a tracer provider")
+	}
+}